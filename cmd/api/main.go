@@ -18,8 +18,10 @@ import (
 	"github.com/Agnikulu/WikiSurge/internal/config"
 	"github.com/Agnikulu/WikiSurge/internal/digest"
 	"github.com/Agnikulu/WikiSurge/internal/email"
+	"github.com/Agnikulu/WikiSurge/internal/kafka"
 	"github.com/Agnikulu/WikiSurge/internal/llm"
 	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
@@ -176,6 +178,15 @@ func main() {
 
 	// ---- API Server ----
 	apiServer := api.NewAPIServer(redisClient, esClient, trendingScorer, hotPageTracker, alerts, userStore, jwtSvc, cfg, logger)
+
+	// ---- DLQ admin (inspect/replay dead-lettered messages) ----
+	dlqAdmin, err := kafka.NewDLQAdmin(cfg.Kafka.Brokers, cfg.Kafka.Retry, redisClient, logger)
+	if err != nil {
+		logger.Warn().Err(err).Msg("DLQ admin disabled")
+	} else {
+		apiServer.SetDLQAdmin(dlqAdmin)
+	}
+
 	addr := fmt.Sprintf(":%d", cfg.API.Port)
 	httpServer := apiServer.ListenAndServe(addr)
 
@@ -184,6 +195,7 @@ func main() {
 	if cfg.Email.Enabled {
 		statsTracker := storage.NewStatsTracker(redisClient)
 		collector := digest.NewCollectorWithRedis(trendingScorer, alerts, hotPageTracker, statsTracker, redisClient, logger)
+		collector.SetWatchlistWiki(cfg.Redis.LegacyWiki)
 
 		// Attach LLM analyzer so the collector can regenerate analyses on
 		// cache misses (and persist them to the digest archive).
@@ -197,8 +209,8 @@ func main() {
 			Timeout:     cfg.LLM.Timeout,
 		}, logger)
 		analysisSvc := llm.NewAnalysisService(llmClient, redisClient, cfg.LLM.CacheTTL, logger)
-		collector.SetAnalyzer(func(ctx context.Context, pageTitle string) error {
-			_, err := analysisSvc.Analyze(ctx, pageTitle)
+		collector.SetAnalyzer(func(ctx context.Context, key models.PageKey) error {
+			_, err := analysisSvc.Analyze(ctx, key)
 			return err
 		})
 		logger.Info().Bool("llm_enabled", llmClient.Enabled()).Msg("Digest collector: LLM analyzer attached")
@@ -279,6 +291,13 @@ func main() {
 	hotPageTracker.Shutdown()
 	trendingScorer.Stop()
 
+	// Close DLQ admin
+	if dlqAdmin != nil {
+		if err := dlqAdmin.Close(); err != nil {
+			logger.Error().Err(err).Msg("DLQ admin close error")
+		}
+	}
+
 	// Close Redis
 	if err := redisClient.Close(); err != nil {
 		logger.Error().Err(err).Msg("Redis close error")
@@ -21,8 +21,13 @@ import (
 func main() {
 	// Parse command line flags
 	var (
-		configPath = flag.String("config", "", "Path to configuration file")
-		verbose    = flag.Bool("verbose", false, "Enable debug logging")
+		configPath  = flag.String("config", "", "Path to configuration file")
+		verbose     = flag.Bool("verbose", false, "Enable debug logging")
+		replayPath  = flag.String("replay", "", "Replay recorded events from an NDJSON(.gz) file instead of the live stream")
+		replaySpeed = flag.Float64("replay-speed", 1, "Replay speed multiplier (0 = as fast as possible)")
+		replayFrom  = flag.String("replay-from", "", "Only replay events at or after this RFC3339 time")
+		replayTo    = flag.String("replay-to", "", "Only replay events at or before this RFC3339 time")
+		sourceURL   = flag.String("source-url", "", "Read events from this SSE endpoint instead of Wikipedia EventStreams")
 	)
 	flag.Parse()
 
@@ -41,6 +46,27 @@ func main() {
 		log.Fatal().Err(err).Str("path", cfgPath).Msg("Failed to load configuration")
 	}
 
+	// Command-line source overrides take precedence over the config file
+	switch {
+	case *replayPath != "":
+		if *replaySpeed < 0 {
+			log.Fatal().Float64("speed", *replaySpeed).Msg("Replay speed cannot be negative")
+		}
+		cfg.Ingestor.Source = config.SourceConfig{Type: "replay", Path: *replayPath, Speed: *replaySpeed}
+		if *replayFrom != "" {
+			if cfg.Ingestor.Source.From, err = time.Parse(time.RFC3339, *replayFrom); err != nil {
+				log.Fatal().Err(err).Msg("Invalid -replay-from time")
+			}
+		}
+		if *replayTo != "" {
+			if cfg.Ingestor.Source.To, err = time.Parse(time.RFC3339, *replayTo); err != nil {
+				log.Fatal().Err(err).Msg("Invalid -replay-to time")
+			}
+		}
+	case *sourceURL != "":
+		cfg.Ingestor.Source = config.SourceConfig{Type: "sse", URL: *sourceURL}
+	}
+
 	// Initialize logger
 	logger := setupLogger(cfg, *verbose)
 	logger.Info().
@@ -82,11 +108,27 @@ func main() {
 	// Create Wikipedia SSE client
 	client := ingestor.NewWikiStreamClient(cfg, logger, producer)
 
+	// MediaWiki log events (protections, blocks, ...) go to their own topic
+	if cfg.Ingestor.LogEvents.Enabled {
+		logProducer, err := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.LogEventsTopic, cfg, logger)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to create log event producer")
+		}
+		if err := logProducer.Start(); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to start log event producer")
+		}
+		client.SetLogEventProducer(logProducer)
+		logger.Info().
+			Str("topic", cfg.Kafka.LogEventsTopic).
+			Strs("types", cfg.Ingestor.LogEvents.Types).
+			Msg("Log event forwarding enabled")
+	}
+
 	// Set up signal handling early so we can catch SIGTERM during connect retries
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Wait for Wikipedia SSE to become reachable.  The endpoint may be
+	// Wait for the event source to become reachable.  The endpoint may be
 	// temporarily down (503) during Wikimedia maintenance windows, so we
 	// retry with exponential backoff instead of crashing the process.
 	{
@@ -99,7 +141,7 @@ func main() {
 					Err(err).
 					Int("attempt", attempt).
 					Dur("retry_in", delay).
-					Msg("Wikipedia SSE not reachable, retrying")
+					Msg("Event source not reachable, retrying")
 
 				select {
 				case <-time.After(delay):
@@ -126,11 +168,15 @@ func main() {
 		Str("metrics_url", fmt.Sprintf("http://localhost:%d/metrics", cfg.Ingestor.MetricsPort)).
 		Msg("Metrics available at endpoint")
 
-	// Wait for shutdown signal
-	sig := <-sigChan
-	logger.Info().
-		Str("signal", sig.String()).
-		Msg("Shutdown signal received")
+	// Wait for shutdown signal, or for a finite source (replay) to finish
+	select {
+	case sig := <-sigChan:
+		logger.Info().
+			Str("signal", sig.String()).
+			Msg("Shutdown signal received")
+	case <-client.Done():
+		logger.Info().Msg("Event source finished")
+	}
 
 	// Graceful shutdown
 	logger.Info().Msg("Initiating graceful shutdown")
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/kafka"
+	"github.com/rs/zerolog"
+)
+
+const dlqUsage = `Usage: processor [-config path] dlq <command> [flags]
+
+Commands:
+  list     List dead-lettered messages
+  replay   Replay dead-lettered messages to their source topic,
+           or to one consumer group's retry topic with -target-group
+
+Run "processor dlq <command> -h" for the command's flags.
+`
+
+// runDLQCommand implements the "dlq" subcommand and returns the exit code.
+func runDLQCommand(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, dlqUsage)
+		return 2
+	}
+
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(zerolog.WarnLevel).With().Timestamp().Logger()
+	redisClient, err := initRedis(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlq: %v\n", err)
+		return 1
+	}
+	defer redisClient.Close()
+
+	admin, err := kafka.NewDLQAdmin(cfg.Kafka.Brokers, cfg.Kafka.Retry, redisClient, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlq: %v\n", err)
+		return 1
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "list":
+		err = dlqList(ctx, admin, args[1:], os.Stdout)
+	case "replay":
+		err = dlqReplay(ctx, admin, args[1:], os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "dlq: unknown command %q\n\n%s", args[0], dlqUsage)
+		return 2
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlq %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// dlqFilterFlags registers the flags shared by list and replay.
+func dlqFilterFlags(fs *flag.FlagSet) func() kafka.DLQFilter {
+	errSubstr := fs.String("error", "", "Only messages whose error contains this text (case-insensitive)")
+	group := fs.String("group", "", "Only messages dead-lettered by this consumer group")
+	ids := fs.String("ids", "", "Comma-separated DLQ entry IDs (partition:offset)")
+	return func() kafka.DLQFilter {
+		filter := kafka.DLQFilter{Error: *errSubstr, ConsumerGroup: *group}
+		for _, id := range strings.Split(*ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				filter.IDs = append(filter.IDs, id)
+			}
+		}
+		return filter
+	}
+}
+
+func dlqList(ctx context.Context, admin *kafka.DLQAdmin, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("dlq list", flag.ContinueOnError)
+	filter := dlqFilterFlags(fs)
+	limit := fs.Int("limit", 100, "Maximum number of messages to list")
+	asJSON := fs.Bool("json", false, "Print messages as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries, total, err := admin.List(ctx, filter(), *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{"messages": entries, "total": total})
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFAILED AT\tGROUP\tSOURCE\tREPLAYS\tREPLAYED\tERROR")
+	for _, e := range entries {
+		replayed := "no"
+		if e.Replayed {
+			replayed = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s[%d]@%d\t%d\t%s\t%s\n",
+			e.ID, e.Timestamp, e.ConsumerGroup,
+			e.OriginalTopic, e.OriginalPartition, e.OriginalOffset,
+			e.ReplayCount, replayed, e.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n%d of %d matching messages\n", len(entries), total)
+	return nil
+}
+
+func dlqReplay(ctx context.Context, admin *kafka.DLQAdmin, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("dlq replay", flag.ContinueOnError)
+	filter := dlqFilterFlags(fs)
+	all := fs.Bool("all", false, "Replay every message in the DLQ")
+	target := fs.String("target-group", "", "Replay only to this consumer group's retry topic instead of the source topic")
+	force := fs.Bool("force", false, "Replay messages even if they were replayed before")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := admin.Replay(ctx, kafka.DLQReplayRequest{
+		DLQFilter:   filter(),
+		All:         *all,
+		TargetGroup: *target,
+		Force:       *force,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Replayed %d message(s)\n", result.Replayed)
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(out, "Skipped %d message(s) at the replay limit: %s\n",
+			len(result.Skipped), strings.Join(result.Skipped, ", "))
+	}
+	if len(result.AlreadyReplayed) > 0 {
+		fmt.Fprintf(out, "Skipped %d message(s) already replayed (use -force to replay again): %s\n",
+			len(result.AlreadyReplayed), strings.Join(result.AlreadyReplayed, ", "))
+	}
+	return nil
+}
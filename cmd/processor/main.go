@@ -50,22 +50,38 @@ type processorOrchestrator struct {
 	trendingScorer   *storage.TrendingScorer
 
 	// Processors
-	spikeDetector      *processor.SpikeDetector
-	editWarDetector    *processor.EditWarDetector
-	trendingAggregator *processor.TrendingAggregator
-	selectiveIndexer   *processor.SelectiveIndexer
-	indexingStrategy   *storage.IndexingStrategy
-	wsForwarder        *processor.WebSocketForwarder
+	spikeDetector        *processor.SpikeDetector
+	storyClusterer       *processor.StoryClusterer
+	editWarDetector      *processor.EditWarDetector
+	trendingAggregator   *processor.TrendingAggregator
+	anomalyDetector      *processor.AggregateAnomalyDetector
+	selectiveIndexer     *processor.SelectiveIndexer
+	indexingStrategy     *storage.IndexingStrategy
+	wsForwarder          *processor.WebSocketForwarder
+	logEventRecorder     *processor.LogEventRecorder
+	vandalismDetector    *processor.VandalismDetector
+	coordinationDetector *processor.CoordinationDetector
+	editorProfiler       *processor.EditorProfiler
+	ruleEvaluator        *processor.RuleEvaluator
+	userStore            *storage.UserStore
 
 	// WebSocket hub
 	wsHub              *api.WebSocketHub
 
 	// Consumers
-	spikeConsumer    *kafka.Consumer
-	trendingConsumer *kafka.Consumer
-	editWarConsumer  *kafka.Consumer
-	indexerConsumer  *kafka.Consumer
-	wsConsumer       *kafka.Consumer
+	spikeConsumer        *kafka.Consumer
+	trendingConsumer     *kafka.Consumer
+	editWarConsumer      *kafka.Consumer
+	indexerConsumer      *kafka.Consumer
+	wsConsumer           *kafka.Consumer
+	logEventConsumer     *kafka.Consumer
+	vandalismConsumer    *kafka.Consumer
+	coordinationConsumer *kafka.Consumer
+	editorConsumer       *kafka.Consumer
+	ruleConsumer         *kafka.Consumer
+
+	// Dead letter queue shared by all consumers (retry enabled only)
+	deadLetter       *kafka.DeadLetterProducer
 
 	// Health monitoring
 	components       []*componentHealth
@@ -73,6 +89,9 @@ type processorOrchestrator struct {
 
 	// Background goroutine lifecycle
 	sweeperCancel    context.CancelFunc
+	anomalyCancel    context.CancelFunc
+	botRunCancel     context.CancelFunc
+	snapshotCancel   context.CancelFunc
 
 	// Metrics server
 	metricsServer    *http.Server
@@ -102,6 +121,11 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
+	// Subcommands run against Kafka and exit without starting the pipeline
+	if flag.Arg(0) == "dlq" {
+		os.Exit(runDLQCommand(cfg, flag.Args()[1:]))
+	}
+
 	// Initialize logger
 	logger := initLogger(cfg)
 	logger.Info().Str("config", configPath).Msg("Starting WikiSurge Processor")
@@ -198,6 +222,16 @@ func (o *processorOrchestrator) initInfrastructure() error {
 	o.logger.Info().Msg("Connected to Redis")
 	o.registerComponent("redis")
 
+	// Rewrite per-page state left over from title-only keys
+	migrateCtx, migrateCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer migrateCancel()
+	migrated, err := storage.MigrateLegacyPageKeys(migrateCtx, o.redisClient, o.cfg.Redis.LegacyWiki)
+	if err != nil {
+		o.logger.Warn().Err(err).Msg("Failed to migrate legacy page keys")
+	} else if migrated > 0 {
+		o.logger.Info().Int("migrated", migrated).Str("legacy_wiki", o.cfg.Redis.LegacyWiki).Msg("Migrated legacy page keys")
+	}
+
 	// Initialize HotPageTracker (shared)
 	o.hotPageTracker = storage.NewHotPageTracker(o.redisClient, &o.cfg.Redis.HotPages)
 	o.logger.Info().Msg("Initialized HotPageTracker")
@@ -205,7 +239,7 @@ func (o *processorOrchestrator) initInfrastructure() error {
 	// Initialize TrendingScorer (shared)
 	o.trendingScorer = storage.NewTrendingScorer(o.redisClient, &o.cfg.Redis.Trending)
 	o.trendingScorer.StartPruning()
-	o.logger.Info().Msg("Initialized TrendingScorer with pruning")
+	o.logger.Info().Str("model", o.cfg.Redis.Trending.Model).Msg("Initialized TrendingScorer with pruning")
 
 	// Initialize Elasticsearch client (shared, if enabled)
 	if o.cfg.Elasticsearch.Enabled {
@@ -241,6 +275,12 @@ func (o *processorOrchestrator) initInfrastructure() error {
 func (o *processorOrchestrator) initProcessors() {
 	// Spike Detector
 	o.spikeDetector = processor.NewSpikeDetector(o.hotPageTracker, o.redisClient, o.cfg, o.logger)
+	// Hot page windows are measured back from the spike detector's watermark
+	o.hotPageTracker.SetTimeProvider(o.spikeDetector.Clock())
+	if o.cfg.Processor.Stories.Enabled {
+		o.storyClusterer = processor.NewStoryClusterer(o.redisClient, o.cfg, o.logger)
+		o.spikeDetector.SetStoryClusterer(o.storyClusterer)
+	}
 	o.logger.Info().Msg("Initialized SpikeDetector")
 	o.registerComponent("spike-detector")
 
@@ -278,9 +318,32 @@ func (o *processorOrchestrator) initProcessors() {
 	// Trending Aggregator
 	statsTracker := storage.NewStatsTracker(o.redisClient)
 	o.trendingAggregator = processor.NewTrendingAggregator(o.trendingScorer, statsTracker, o.cfg, o.logger)
+	o.trendingScorer.SetTimeProvider(o.trendingAggregator.Clock())
+	statsTracker.SetTimeProvider(o.trendingAggregator.Clock())
 	o.logger.Info().Msg("Initialized TrendingAggregator with StatsTracker")
 	o.registerComponent("trending-aggregator")
 
+	// Aggregate anomaly detector reads the aggregator's per-minute counters
+	if o.cfg.Processor.Anomaly.Enabled {
+		o.anomalyDetector = processor.NewAggregateAnomalyDetector(statsTracker, storage.NewRedisAlerts(o.redisClient), o.redisClient, o.cfg, o.logger)
+		o.anomalyDetector.SetTimeProvider(o.trendingAggregator.Clock())
+		anomalyCtx, anomalyCancel := context.WithCancel(context.Background())
+		o.anomalyCancel = anomalyCancel
+		o.anomalyDetector.Start(anomalyCtx)
+		o.logger.Info().Msg("Initialized AggregateAnomalyDetector")
+	}
+
+	// Trending history snapshots, on the same clock as the scores
+	if o.cfg.Redis.Trending.Enabled && o.cfg.Redis.Trending.History.Enabled {
+		history := storage.NewTrendingHistory(o.redisClient, o.cfg.Redis.Trending.History)
+		snapshotter := processor.NewTrendingSnapshotter(o.trendingScorer, history, o.cfg, o.logger)
+		snapshotter.SetTimeProvider(o.trendingAggregator.Clock())
+		snapshotCtx, snapshotCancel := context.WithCancel(context.Background())
+		o.snapshotCancel = snapshotCancel
+		snapshotter.Start(snapshotCtx)
+		o.logger.Info().Msg("Initialized TrendingSnapshotter")
+	}
+
 	// Selective Indexer (if ES is available)
 	if o.esClient != nil {
 		o.indexingStrategy = storage.NewIndexingStrategy(
@@ -300,13 +363,111 @@ func (o *processorOrchestrator) initProcessors() {
 		o.wsForwarder = processor.NewWebSocketForwarder(o.wsHub, o.redisClient, o.logger)
 		o.logger.Info().Msg("Initialized WebSocketForwarder")
 		o.registerComponent("websocket-forwarder")
+
+		// Bursts by one account reach the feed as bot_run summaries
+		if o.cfg.Processor.BotRuns.Enabled {
+			tracker := processor.NewBotRunTracker(storage.NewBotRunStore(o.redisClient, o.cfg.Processor.BotRuns), o.cfg, o.logger)
+			o.wsForwarder.SetBotRunTracker(tracker)
+			botRunCtx, botRunCancel := context.WithCancel(context.Background())
+			o.botRunCancel = botRunCancel
+			tracker.Start(botRunCtx)
+			o.logger.Info().Int("min_edits", o.cfg.Processor.BotRuns.MinEdits).Msg("Initialized BotRunTracker")
+		}
+	}
+
+	// Log Event Recorder
+	if o.cfg.Ingestor.LogEvents.Enabled {
+		o.logEventRecorder = processor.NewLogEventRecorder(storage.NewLogEventStore(o.redisClient), o.logger)
+		// Accounts are remembered for as long as any detector counts them as new
+		newAccountAge := max(o.cfg.Processor.Vandalism.NewAccountAge, o.cfg.Processor.Coordination.NewAccountAge)
+		o.logEventRecorder.SetAccountRegistry(storage.NewAccountRegistry(o.redisClient, newAccountAge))
+		o.logger.Info().Msg("Initialized LogEventRecorder")
+		o.registerComponent("log-event-recorder")
+	}
+
+	// Vandalism Detector
+	if o.cfg.Processor.Vandalism.Enabled {
+		o.vandalismDetector = processor.NewVandalismDetector(storage.NewRedisAlerts(o.redisClient), o.hotPageTracker, o.redisClient, o.cfg, o.logger)
+		o.logger.Info().Msg("Initialized VandalismDetector")
+		o.registerComponent("vandalism-detector")
+	}
+
+	// Coordinated Editing Detector
+	if o.cfg.Processor.Coordination.Enabled {
+		o.coordinationDetector = processor.NewCoordinationDetector(storage.NewRedisAlerts(o.redisClient), o.hotPageTracker, o.redisClient, o.cfg, o.logger)
+		o.logger.Info().Msg("Initialized CoordinationDetector")
+		o.registerComponent("coordination-detector")
+	}
+
+	// Editor Profiler
+	if o.cfg.Processor.Editors.Enabled {
+		o.editorProfiler = processor.NewEditorProfiler(storage.NewEditorStore(o.redisClient, o.cfg.Processor.Editors), o.redisClient, o.logger)
+		o.logger.Info().Bool("pseudonymize_ips", o.cfg.Processor.Editors.PseudonymizeIPs).Msg("Initialized EditorProfiler")
+		o.registerComponent("editor-profiler")
+	}
+
+	// Alert Rule Evaluator (reads users' rules from the API's database)
+	if o.cfg.Processor.AlertRules.Enabled {
+		userStore, err := storage.NewUserStore(o.cfg.Database.Path)
+		if err != nil {
+			o.logger.Error().Err(err).Str("path", o.cfg.Database.Path).Msg("Failed to open user database, alert rules disabled")
+		} else {
+			o.userStore = userStore
+			o.ruleEvaluator = processor.NewRuleEvaluator(userStore, o.redisClient, storage.NewRedisAlerts(o.redisClient), o.cfg, o.logger)
+			o.logger.Info().Dur("refresh_interval", o.cfg.Processor.AlertRules.RefreshInterval).Msg("Initialized RuleEvaluator")
+			o.registerComponent("rule-evaluator")
+		}
+	}
+
+	// Revision dedup shared by every processor, so redelivered edits are skipped
+	if dedup := storage.NewEditDeduplicator(o.redisClient, &o.cfg.Redis.Dedup); dedup != nil {
+		o.spikeDetector.SetDeduplicator(dedup)
+		o.editWarDetector.SetDeduplicator(dedup)
+		o.trendingAggregator.SetDeduplicator(dedup)
+		if o.selectiveIndexer != nil {
+			o.selectiveIndexer.SetDeduplicator(dedup)
+		}
+		if o.wsForwarder != nil {
+			o.wsForwarder.SetDeduplicator(dedup)
+		}
+		if o.logEventRecorder != nil {
+			o.logEventRecorder.SetDeduplicator(dedup)
+		}
+		if o.vandalismDetector != nil {
+			o.vandalismDetector.SetDeduplicator(dedup)
+		}
+		if o.coordinationDetector != nil {
+			o.coordinationDetector.SetDeduplicator(dedup)
+		}
+		if o.editorProfiler != nil {
+			o.editorProfiler.SetDeduplicator(dedup)
+		}
+		if o.ruleEvaluator != nil {
+			o.ruleEvaluator.SetDeduplicator(dedup)
+		}
+		o.logger.Info().Dur("window", o.cfg.Redis.Dedup.Window).Msg("Revision dedup enabled")
 	}
 }
 
 // createConsumers creates all Kafka consumers with separate consumer groups
 func (o *processorOrchestrator) createConsumers() error {
+	var err error
+
+	// Failed messages are retried per the group's policy, then dead-lettered
+	if o.cfg.Kafka.Retry.Enabled {
+		o.deadLetter, err = kafka.NewDeadLetterProducer(o.cfg.Kafka.Brokers, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create dead letter producer: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := kafka.EnsureTopics(ctx, o.cfg.Kafka.Brokers, kafka.DefaultDLQTopic); err != nil {
+			o.logger.Warn().Err(err).Msg("Could not create DLQ topic; it must exist or be auto-created")
+		}
+		cancel()
+	}
+
 	baseConsumerCfg := func(groupID string) kafka.ConsumerConfig {
-		return kafka.ConsumerConfig{
+		consumerCfg := kafka.ConsumerConfig{
 			Brokers:        o.cfg.Kafka.Brokers,
 			Topic:          "wikipedia.edits",
 			GroupID:        groupID,
@@ -316,10 +477,14 @@ func (o *processorOrchestrator) createConsumers() error {
 			CommitInterval: time.Second,
 			MaxWait:        500 * time.Millisecond,
 		}
+		if o.cfg.Kafka.Retry.Enabled {
+			policy := o.cfg.Kafka.Retry.PolicyFor(groupID)
+			consumerCfg.Retry = &policy
+			consumerCfg.DeadLetter = o.deadLetter
+		}
+		return consumerCfg
 	}
 
-	var err error
-
 	// Spike detection consumer
 	o.spikeConsumer, err = kafka.NewConsumer(o.cfg, baseConsumerCfg("spike-detector"), o.spikeDetector, o.logger)
 	if err != nil {
@@ -354,6 +519,48 @@ func (o *processorOrchestrator) createConsumers() error {
 		}
 	}
 
+	// Log event consumer reads its own topic
+	if o.logEventRecorder != nil {
+		logCfg := baseConsumerCfg("log-event-recorder")
+		logCfg.Topic = o.cfg.Kafka.LogEventsTopic
+		o.logEventConsumer, err = kafka.NewConsumer(o.cfg, logCfg, o.logEventRecorder, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create log event consumer: %w", err)
+		}
+	}
+
+	// Vandalism detection consumer
+	if o.vandalismDetector != nil {
+		o.vandalismConsumer, err = kafka.NewConsumer(o.cfg, baseConsumerCfg("vandalism-detector"), o.vandalismDetector, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create vandalism detection consumer: %w", err)
+		}
+	}
+
+	// Coordinated editing detection consumer
+	if o.coordinationDetector != nil {
+		o.coordinationConsumer, err = kafka.NewConsumer(o.cfg, baseConsumerCfg("coordination-detector"), o.coordinationDetector, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create coordination detection consumer: %w", err)
+		}
+	}
+
+	// Editor profile consumer
+	if o.editorProfiler != nil {
+		o.editorConsumer, err = kafka.NewConsumer(o.cfg, baseConsumerCfg("editor-profiler"), o.editorProfiler, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create editor profile consumer: %w", err)
+		}
+	}
+
+	// Alert rule consumer
+	if o.ruleEvaluator != nil {
+		o.ruleConsumer, err = kafka.NewConsumer(o.cfg, baseConsumerCfg("rule-evaluator"), o.ruleEvaluator, o.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create alert rule consumer: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -378,6 +585,26 @@ func (o *processorOrchestrator) startAllConsumers() error {
 		consumers = append(consumers, consumerEntry{"websocket-forwarder", o.wsConsumer})
 	}
 
+	if o.logEventConsumer != nil {
+		consumers = append(consumers, consumerEntry{"log-event-recorder", o.logEventConsumer})
+	}
+
+	if o.vandalismConsumer != nil {
+		consumers = append(consumers, consumerEntry{"vandalism-detector", o.vandalismConsumer})
+	}
+
+	if o.coordinationConsumer != nil {
+		consumers = append(consumers, consumerEntry{"coordination-detector", o.coordinationConsumer})
+	}
+
+	if o.editorConsumer != nil {
+		consumers = append(consumers, consumerEntry{"editor-profiler", o.editorConsumer})
+	}
+
+	if o.ruleConsumer != nil {
+		consumers = append(consumers, consumerEntry{"rule-evaluator", o.ruleConsumer})
+	}
+
 	for _, c := range consumers {
 		if err := c.consumer.Start(); err != nil {
 			return fmt.Errorf("failed to start %s consumer: %w", c.name, err)
@@ -521,6 +748,25 @@ func (o *processorOrchestrator) checkConsumerLag() {
 	if o.indexerConsumer != nil {
 		consumers = append(consumers, consumerEntry{"selective-indexer", o.indexerConsumer})
 	}
+	if o.logEventConsumer != nil {
+		consumers = append(consumers, consumerEntry{"log-event-recorder", o.logEventConsumer})
+	}
+
+	if o.vandalismConsumer != nil {
+		consumers = append(consumers, consumerEntry{"vandalism-detector", o.vandalismConsumer})
+	}
+
+	if o.coordinationConsumer != nil {
+		consumers = append(consumers, consumerEntry{"coordination-detector", o.coordinationConsumer})
+	}
+
+	if o.editorConsumer != nil {
+		consumers = append(consumers, consumerEntry{"editor-profiler", o.editorConsumer})
+	}
+
+	if o.ruleConsumer != nil {
+		consumers = append(consumers, consumerEntry{"rule-evaluator", o.ruleConsumer})
+	}
 
 	for _, c := range consumers {
 		ch := o.findComponent(c.name)
@@ -572,6 +818,24 @@ func (o *processorOrchestrator) gracefulShutdown() {
 		o.logger.Info().Msg("Edit war deactivation sweeper stopped")
 	}
 
+	// 1c. Stop aggregate anomaly detector
+	if o.anomalyCancel != nil {
+		o.anomalyCancel()
+		o.logger.Info().Msg("Aggregate anomaly detector stopped")
+	}
+
+	// 1d. Stop bot run sweeper
+	if o.botRunCancel != nil {
+		o.botRunCancel()
+		o.logger.Info().Msg("Bot run sweeper stopped")
+	}
+
+	// 1e. Stop trending snapshots
+	if o.snapshotCancel != nil {
+		o.snapshotCancel()
+		o.logger.Info().Msg("Trending snapshotter stopped")
+	}
+
 	// 2. Stop all consumers (stop accepting new messages)
 	o.logger.Info().Msg("Stopping Kafka consumers...")
 	var consumerWg sync.WaitGroup
@@ -600,9 +864,46 @@ func (o *processorOrchestrator) gracefulShutdown() {
 		go stopConsumer("websocket-forwarder", o.wsConsumer)
 	}
 
+	if o.logEventConsumer != nil {
+		consumerWg.Add(1)
+		go stopConsumer("log-event-recorder", o.logEventConsumer)
+	}
+
+	if o.vandalismConsumer != nil {
+		consumerWg.Add(1)
+		go stopConsumer("vandalism-detector", o.vandalismConsumer)
+	}
+
+	if o.coordinationConsumer != nil {
+		consumerWg.Add(1)
+		go stopConsumer("coordination-detector", o.coordinationConsumer)
+	}
+
+	if o.editorConsumer != nil {
+		consumerWg.Add(1)
+		go stopConsumer("editor-profiler", o.editorConsumer)
+	}
+
+	if o.ruleConsumer != nil {
+		consumerWg.Add(1)
+		go stopConsumer("rule-evaluator", o.ruleConsumer)
+	}
+
 	consumerWg.Wait()
 	o.logger.Info().Msg("All Kafka consumers stopped")
 
+	// 2b. Wait for story lookups the spike consumer started
+	if o.storyClusterer != nil {
+		o.storyClusterer.Stop()
+		o.logger.Info().Msg("Story clusterer stopped")
+	}
+
+	if o.deadLetter != nil {
+		if err := o.deadLetter.Close(); err != nil {
+			o.logger.Error().Err(err).Msg("Error closing dead letter producer")
+		}
+	}
+
 	// 3. Flush all buffers
 	if o.selectiveIndexer != nil {
 		o.logger.Info().Msg("Flushing selective indexer buffer...")
@@ -646,6 +947,12 @@ func (o *processorOrchestrator) gracefulShutdown() {
 		o.logger.Info().Msg("WebSocket hub stopped")
 	}
 
+	if o.userStore != nil {
+		if err := o.userStore.Close(); err != nil {
+			o.logger.Error().Err(err).Msg("Error closing user database")
+		}
+	}
+
 	if err := o.redisClient.Close(); err != nil {
 		o.logger.Error().Err(err).Msg("Error closing Redis connection")
 	} else {
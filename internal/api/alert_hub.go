@@ -74,7 +74,7 @@ func (h *AlertHub) runWithRestart(restartCount int) {
 
 	backoff := time.Second
 	for {
-		err := h.alerts.SubscribeToAlerts(ctx, []string{"spikes", "editwars"}, func(alert storage.Alert) error {
+		err := h.alerts.SubscribeToAlerts(ctx, liveAlertStreams, func(alert storage.Alert) error {
 			h.broadcast(alert)
 			return nil
 		})
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/auth"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+)
+
+// alertRuleRequest is the body of rule creates and updates. Enabled
+// defaults to true on create and to the current value on update.
+type alertRuleRequest struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+// validate trims the request and checks its name and expression, returning
+// a message for the client if either is unusable.
+func (req *alertRuleRequest) validate() (msg, field string) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Expression = strings.TrimSpace(req.Expression)
+	if req.Name == "" || len(req.Name) > models.MaxRuleNameLength {
+		return fmt.Sprintf("Rule name must be 1-%d characters", models.MaxRuleNameLength), "field: name"
+	}
+	if _, err := models.ParseRule(req.Expression); err != nil {
+		return "Invalid rule: " + err.Error(), "field: expression"
+	}
+	return "", ""
+}
+
+// handleListAlertRules returns the authenticated user's alert rules.
+func (s *APIServer) handleListAlertRules(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+
+	rules, err := s.userStore.ListAlertRules(userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("failed to list alert rules")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to list alert rules", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+		"limit": s.config.Processor.AlertRules.MaxRulesPerUser,
+	})
+}
+
+// handleCreateAlertRule adds an alert rule for the authenticated user.
+func (s *APIServer) handleCreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+
+	var req alertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, r, http.StatusBadRequest, "Invalid JSON body", ErrCodeInvalidParameter, "")
+		return
+	}
+	if msg, field := req.validate(); msg != "" {
+		writeAPIError(w, r, http.StatusBadRequest, msg, ErrCodeInvalidParameter, field)
+		return
+	}
+
+	existing, err := s.userStore.ListAlertRules(userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("failed to count alert rules")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to create alert rule", ErrCodeInternalError, "")
+		return
+	}
+	if limit := s.config.Processor.AlertRules.MaxRulesPerUser; len(existing) >= limit {
+		writeAPIError(w, r, http.StatusBadRequest,
+			fmt.Sprintf("Cannot have more than %d alert rules", limit), ErrCodeInvalidParameter, "")
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+	rule, err := s.userStore.CreateAlertRule(userID, req.Name, req.Expression, enabled)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("failed to create alert rule")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to create alert rule", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, rule)
+}
+
+// handleUpdateAlertRule replaces the name and expression of one of the
+// authenticated user's rules, and optionally enables or disables it.
+func (s *APIServer) handleUpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+
+	var req alertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, r, http.StatusBadRequest, "Invalid JSON body", ErrCodeInvalidParameter, "")
+		return
+	}
+	if msg, field := req.validate(); msg != "" {
+		writeAPIError(w, r, http.StatusBadRequest, msg, ErrCodeInvalidParameter, field)
+		return
+	}
+
+	rule, ok := s.lookupAlertRule(w, r, userID)
+	if !ok {
+		return
+	}
+	rule.Name, rule.Expression = req.Name, req.Expression
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := s.userStore.UpdateAlertRule(rule); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Str("rule_id", rule.ID).Msg("failed to update alert rule")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to update alert rule", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// handleDeleteAlertRule removes one of the authenticated user's rules.
+func (s *APIServer) handleDeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+
+	rule, ok := s.lookupAlertRule(w, r, userID)
+	if !ok {
+		return
+	}
+	if err := s.userStore.DeleteAlertRule(userID, rule.ID); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Str("rule_id", rule.ID).Msg("failed to delete alert rule")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to delete alert rule", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// lookupAlertRule fetches the user's rule named by the {id} path value. It
+// writes the error response and returns false if there is none.
+func (s *APIServer) lookupAlertRule(w http.ResponseWriter, r *http.Request, userID string) (*models.AlertRule, bool) {
+	ruleID := r.PathValue("id")
+	rule, err := s.userStore.GetAlertRule(userID, ruleID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Str("rule_id", ruleID).Msg("failed to get alert rule")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to get alert rule", ErrCodeInternalError, "")
+		return nil, false
+	}
+	if rule == nil {
+		writeAPIError(w, r, http.StatusNotFound, "Alert rule not found", ErrCodeNotFound, "")
+		return nil, false
+	}
+	return rule, true
+}
+
+// handleGetUserAlerts returns the personal alerts the authenticated user's
+// rules have raised, newest first.
+// Query params:
+// - since: RFC3339 or Unix timestamp (optional, default: 24 hours ago)
+// - limit: number of alerts (optional, default: 50, max: 200)
+func (s *APIServer) handleGetUserAlerts(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+
+	since, err := parseTimeQuery(r, "since", time.Now().Add(-24*time.Hour))
+	if err != nil {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'since' parameter (must be RFC3339 or Unix timestamp)", ErrCodeInvalidParameter, "field: since")
+		return
+	}
+	limit, err := parseIntQuery(r, "limit", 50, 200)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-200)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+	if s.alerts == nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{"alerts": []interface{}{}, "count": 0})
+		return
+	}
+
+	alerts, err := s.alerts.GetUserAlerts(r.Context(), userID, since, int64(limit))
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("failed to get user alerts")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to retrieve alerts", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"alerts": alerts,
+		"count":  len(alerts),
+	})
+}
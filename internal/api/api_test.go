@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -38,11 +39,19 @@ func testServer(t *testing.T) (*APIServer, *miniredis.Miniredis) {
 			},
 			Trending: config.TrendingConfig{
 				Enabled: true, MaxPages: 500, HalfLifeMinutes: 30.0, PruneInterval: 5 * time.Minute,
+				History: config.TrendingHistoryConfig{
+					Enabled: true, Interval: 5 * time.Minute, Retention: 24 * time.Hour, TopN: 50, ChangeWindow: time.Hour,
+				},
 			},
 		},
 		Elasticsearch: config.Elasticsearch{Enabled: false},
 		API:           config.API{Port: 8080, RateLimit: 10000},
 		Logging:       config.Logging{Level: "error", Format: "json"},
+		Processor: config.Processor{
+			Stories: config.StoryClusteringConfig{Window: 6 * time.Hour, MinWikis: 2},
+			Editors: config.EditorProfilesConfig{TTL: 24 * time.Hour, RecentPages: 10, MaxRanked: 100},
+			BotRuns: config.BotRunConfig{RateWindow: time.Minute, SuspectTTL: 24 * time.Hour},
+		},
 	}
 
 	logger := zerolog.Nop()
@@ -122,7 +131,7 @@ func TestTrending_WithData(t *testing.T) {
 
 	// Seed trending data
 	for i := 0; i < 5; i++ {
-		_ = srv.trending.IncrementScore(fmt.Sprintf("Page_%d", i), float64(10-i))
+		_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", fmt.Sprintf("Page_%d", i)), float64(10-i))
 	}
 
 	rec := doRequest(srv, "GET", "/api/trending?limit=3")
@@ -135,6 +144,111 @@ func TestTrending_WithData(t *testing.T) {
 	assert.GreaterOrEqual(t, results[0].Score, results[1].Score)
 }
 
+func TestTrending_PerWiki(t *testing.T) {
+	srv, _ := testServer(t)
+
+	// enwiki fills the global top 3; fiwiki only ranks in its own leaderboard
+	for i := 0; i < 5; i++ {
+		_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", fmt.Sprintf("Page_%d", i)), float64(100-i))
+	}
+	_ = srv.trending.IncrementScore(models.NewPageKey("fiwiki", "Helsinki"), 2)
+	_ = srv.trending.IncrementScore(models.NewPageKey("fiwiki", "Tampere"), 1)
+
+	for _, path := range []string{"/api/trending?wiki=fiwiki&limit=3", "/api/trending?language=fi&limit=3"} {
+		rec := doRequest(srv, "GET", path)
+		require.Equal(t, http.StatusOK, rec.Code, path)
+
+		var results []TrendingPageResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		require.Len(t, results, 2, path)
+		assert.Equal(t, "Helsinki", results[0].Title)
+		assert.Equal(t, 2, results[1].Rank)
+		assert.Equal(t, "fi", results[1].Language)
+	}
+}
+
+func TestTrending_InvalidScope(t *testing.T) {
+	srv, _ := testServer(t)
+
+	for _, path := range []string{
+		"/api/trending?wiki=fi.wikipedia.org",
+		"/api/trending?project=myspace",
+		"/api/trending?namespace=-1",
+		"/api/trending?wiki=fiwiki&language=fi",
+		"/api/trending?namespace=1", // namespace leaderboards are off in tests
+	} {
+		rec := doRequest(srv, "GET", path)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+}
+
+func TestTrending_History(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	// Snapshots are stamped with the aggregator's event clock, here half a
+	// day behind the wall clock as in a replay. Ninety minutes before the
+	// latest snapshot Alpha led Beta; since then Beta has overtaken it and
+	// Gamma has appeared
+	_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", "Alpha"), 20)
+	_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", "Beta"), 10)
+	latestAt := time.Now().Add(-12 * time.Hour)
+	snapAt := latestAt.Add(-90 * time.Minute)
+	_, err := srv.trendHistory.Snapshot(ctx, srv.trending, snapAt)
+	require.NoError(t, err)
+	_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", "Beta"), 50)
+	_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", "Gamma"), 5)
+	_, err = srv.trendHistory.Snapshot(ctx, srv.trending, latestAt)
+	require.NoError(t, err)
+
+	rec := doRequest(srv, "GET", "/api/trending?wiki=enwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var results []TrendingPageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	assert.Equal(t, "Beta", results[0].Title)
+	require.NotNil(t, results[0].RankChange)
+	assert.Equal(t, 1, *results[0].RankChange)
+	assert.Equal(t, -1, *results[1].RankChange)
+	assert.Equal(t, 1, *results[1].PeakRank, "Alpha led an hour ago")
+	assert.Nil(t, results[2].RankChange, "Gamma was not ranked")
+	assert.Equal(t, 3, *results[2].PeakRank)
+
+	rec = doRequest(srv, "GET", fmt.Sprintf("/api/trending/history?at=%d", snapAt.Add(time.Minute).Unix()))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var snap storage.TrendingSnapshot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snap))
+	require.Len(t, snap.Pages, 2)
+	assert.Equal(t, "Alpha", snap.Pages[0].Title)
+
+	rec = doRequest(srv, "GET", fmt.Sprintf("/api/trending/history?at=%d", snapAt.Add(-time.Hour).Unix()))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Without at, the latest snapshot
+	rec = doRequest(srv, "GET", "/api/trending/history?wiki=enwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snap))
+	assert.Equal(t, latestAt.Truncate(5*time.Minute).Unix(), snap.At.Unix())
+	assert.Equal(t, "Beta", snap.Pages[0].Title)
+
+	rec = doRequest(srv, "GET", "/api/trending/movers?wiki=enwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var movers storage.TrendingMovers
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &movers))
+	assert.Equal(t, snapAt.Truncate(5*time.Minute).Unix(), movers.Since.Unix(), "an hour before the latest snapshot")
+	require.Len(t, movers.Rising, 1)
+	assert.Equal(t, "Beta", movers.Rising[0].Title)
+	require.Len(t, movers.Falling, 1)
+	assert.Equal(t, 2, movers.Falling[0].Rank)
+	require.Len(t, movers.New, 1)
+	assert.Equal(t, "Gamma", movers.New[0].Title)
+
+	rec = doRequest(srv, "GET", "/api/trending/movers?window=3h")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = doRequest(srv, "GET", "/api/trending/movers?window=soon")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
 func TestTrending_InvalidLimit(t *testing.T) {
 	srv, _ := testServer(t)
 	rec := doRequest(srv, "GET", "/api/trending?limit=999")
@@ -176,6 +290,35 @@ func TestStats_OK(t *testing.T) {
 	assert.NotNil(t, resp.TopLanguages)
 }
 
+func TestStats_Projects(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, srv.statsTracker.RecordEdit(ctx, "wikipedia", "en", false))
+	require.NoError(t, srv.statsTracker.RecordEdit(ctx, "wikipedia", "de", false))
+	require.NoError(t, srv.statsTracker.RecordEdit(ctx, "wikidata", "unknown", true))
+	require.NoError(t, srv.statsTracker.RecordEdit(ctx, "wiktionary", "fr", false))
+
+	rec := doRequest(srv, "GET", "/api/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp StatsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Projects, 3)
+	assert.Equal(t, "wikipedia", resp.Projects[0].Project)
+	assert.Equal(t, 2, resp.Projects[0].Count)
+	assert.Equal(t, 50.0, resp.Projects[0].Percentage)
+}
+
+func TestExtractProjectFromURL(t *testing.T) {
+	assert.Equal(t, "wikipedia", extractProjectFromURL("https://en.wikipedia.org"))
+	assert.Equal(t, "wiktionary", extractProjectFromURL("https://fr.wiktionary.org"))
+	assert.Equal(t, "wikidata", extractProjectFromURL("https://www.wikidata.org"))
+	assert.Equal(t, "fr", extractLanguageFromURL("https://fr.wiktionary.org"))
+	assert.Equal(t, "zh-min-nan", extractLanguageFromURL("https://zh-min-nan.wikipedia.org"))
+	assert.Equal(t, "", extractLanguageFromURL("https://www.wikidata.org"))
+}
+
 func TestStats_Cached(t *testing.T) {
 	srv, _ := testServer(t)
 
@@ -246,6 +389,73 @@ func TestAlerts_WithSpikeData(t *testing.T) {
 	assert.Equal(t, 3.5, resp.Alerts[0].SpikeRatio)
 }
 
+func TestAlerts_AggregateAnomalies(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	ns := 2
+	now := time.Now()
+	require.NoError(t, srv.alerts.PublishWikiSurgeAlert(ctx, storage.AggregateAnomaly{
+		Scope: "namespace", Namespace: &ns, Rate: 40, ExpectedRate: 10, ZScore: 9,
+		WindowMinutes: 5, Severity: "high", At: now,
+	}))
+	require.NoError(t, srv.alerts.PublishStreamDropAlert(ctx, storage.AggregateAnomaly{
+		Scope: "global", Rate: 1, ExpectedRate: 300, WindowMinutes: 5,
+		Severity: "critical", Reason: "rate_drop", At: now,
+	}))
+
+	rec := doRequest(srv, "GET", "/api/alerts?type=wiki_surge")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp AlertsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Alerts, 1)
+	surge := resp.Alerts[0]
+	assert.Equal(t, "wiki_surge", surge.Type)
+	assert.Equal(t, "namespace", surge.Scope)
+	require.NotNil(t, surge.Namespace)
+	assert.Equal(t, 2, *surge.Namespace)
+	assert.Equal(t, 40.0, surge.Rate)
+	assert.Equal(t, "high", surge.Severity)
+
+	// Both types are included in the unfiltered list
+	rec = doRequest(srv, "GET", "/api/alerts?severity=critical")
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = AlertsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Alerts, 1)
+	assert.Equal(t, "stream_drop", resp.Alerts[0].Type)
+	assert.Equal(t, "rate_drop", resp.Alerts[0].Reason)
+}
+
+func TestAlerts_Vandalism(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	edit := &models.WikipediaEdit{Title: "Mount Kosciuszko", User: "203.0.113.42", Wiki: "enwiki"}
+	edit.Length.Old, edit.Length.New = 18432, 9
+	require.NoError(t, srv.alerts.PublishVandalismAlert(ctx, edit, 0.625, []string{"anonymous editor", "page blanked (18432 → 9 bytes)"}))
+
+	rec := doRequest(srv, "GET", "/api/alerts?type=vandalism")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp AlertsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Alerts, 1)
+
+	a := resp.Alerts[0]
+	assert.Equal(t, "vandalism", a.Type)
+	assert.Equal(t, "Mount Kosciuszko", a.PageTitle)
+	assert.Equal(t, "203.0.113.42", a.User)
+	assert.Equal(t, 0.625, a.Confidence)
+	assert.Equal(t, "medium", a.Severity)
+	assert.Equal(t, []string{"anonymous editor", "page blanked (18432 → 9 bytes)"}, a.Reasons)
+
+	// Listed with the other live alerts too
+	rec = doRequest(srv, "GET", "/api/alerts")
+	resp = AlertsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Alerts, 1)
+}
+
 func TestAlerts_SeverityFilter(t *testing.T) {
 	srv, _ := testServer(t)
 	ctx := context.Background()
@@ -402,6 +612,158 @@ func TestEditWars_Historical(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestEditWars_EmbedsLogEvents(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	pageTitle := "Protected_Topic"
+	srv.redis.Set(ctx, fmt.Sprintf("editwar:%s", pageTitle), "1", time.Hour)
+	srv.redis.HSet(ctx, fmt.Sprintf("editwar:editors:%s", pageTitle), "UserA", "5", "UserB", "3")
+	srv.redis.Set(ctx, fmt.Sprintf("editwar:serverurl:%s", pageTitle), "https://en.wikipedia.org", time.Hour)
+
+	require.NoError(t, srv.logEvents.Record(ctx, &models.LogEvent{
+		LogID: 1, Wiki: "enwiki", Title: pageTitle, LogType: "protect", LogAction: "protect",
+		User: "Admin", Summary: "page protected by Admin",
+	}))
+	// Same title on another wiki must not leak in.
+	require.NoError(t, srv.logEvents.Record(ctx, &models.LogEvent{
+		LogID: 2, Wiki: "dewiki", Title: pageTitle, LogType: "delete", LogAction: "delete", User: "Admin",
+	}))
+
+	rec := doRequest(srv, "GET", "/api/edit-wars?active=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var results []EditWarEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	require.Len(t, results[0].LogEvents, 1)
+	assert.Equal(t, "page protected by Admin", results[0].LogEvents[0].Summary)
+
+	rec = doRequest(srv, "GET", "/api/log-events?type=delete")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var events []models.LogEvent
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, "dewiki", events[0].Wiki)
+
+	rec = doRequest(srv, "GET", "/api/log-events?page=Protected_Topic&wiki=dewiki")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(2), events[0].LogID)
+}
+
+func TestEditWarViolations(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+	at := time.Now().UTC().Truncate(time.Second)
+
+	for _, v := range []storage.ThreeRevertViolation{
+		{Wiki: "enwiki", Title: "Contested", User: "Bob", RevertCount: 4, Limit: 3, Severity: "high", At: at},
+		{Wiki: "dewiki", Title: "Umstritten", User: "Bob", RevertCount: 4, Limit: 3, Severity: "critical", At: at},
+		{Wiki: "enwiki", Title: "Contested", User: "Carol", RevertCount: 4, Limit: 3, Severity: "high", At: at},
+	} {
+		require.NoError(t, srv.alerts.PublishThreeRevertViolation(ctx, v))
+	}
+
+	get := func(query string) []storage.ThreeRevertViolation {
+		t.Helper()
+		rec := doRequest(srv, "GET", "/api/edit-wars/violations"+query)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var violations []storage.ThreeRevertViolation
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &violations))
+		return violations
+	}
+
+	all := get("")
+	require.Len(t, all, 3)
+	assert.Equal(t, "Carol", all[0].User, "newest first")
+	assert.Len(t, get("?user=Bob"), 2)
+	assert.Len(t, get("?wiki=dewiki"), 1)
+	assert.Len(t, get("?page=Contested"), 2)
+	assert.Len(t, get("?page=Contested&user=Bob"), 1)
+	assert.Len(t, get("?limit=1"), 1)
+
+	rec := doRequest(srv, "GET", "/api/edit-wars/violations?since=yesterday")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Violations are alerts too
+	rec = doRequest(srv, "GET", "/api/alerts?type=3rr_violation")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var alerts AlertsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &alerts))
+	require.Len(t, alerts.Alerts, 3)
+	assert.Equal(t, 4, alerts.Alerts[0].RevertCount)
+	assert.Equal(t, "Contested", alerts.Alerts[0].PageTitle)
+}
+
+func TestAlerts_CoordinatedEditing(t *testing.T) {
+	srv, _ := testServer(t)
+	g := storage.CoordinatedGroup{
+		Wiki:        "enwiki",
+		Accounts:    []string{"Sock1", "Sock2"},
+		SharedPages: []string{"Contested A", "Contested B", "Contested C"},
+		Pairs: []storage.CoordinatedPair{{
+			Users: [2]string{"Sock1", "Sock2"}, SharedPages: []string{"Contested A", "Contested B", "Contested C"}, Overlap: 1,
+			TimingCorrelation: 1, TagTeamPages: []string{"Contested A", "Contested B"}, Score: 0.87,
+		}},
+		Score:    0.87,
+		Severity: "high",
+		At:       time.Now().UTC(),
+	}
+	require.NoError(t, srv.alerts.PublishCoordinationAlert(context.Background(), g))
+
+	rec := doRequest(srv, "GET", "/api/alerts?type=coordinated_editing")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp AlertsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Alerts, 1)
+
+	a := resp.Alerts[0]
+	assert.Equal(t, "coordinated_editing", a.Type)
+	assert.Equal(t, "high", a.Severity)
+	assert.Equal(t, []string{"Sock1", "Sock2"}, a.Editors)
+	assert.Equal(t, g.SharedPages, a.SharedPages)
+	assert.Equal(t, g.Pairs, a.Pairs)
+	assert.InDelta(t, 0.87, a.Confidence, 1e-9)
+}
+
+func TestStories(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	pages := []struct {
+		qid, wiki, title string
+	}{
+		{"Q7944", "dewiki", "Erdbeben"},
+		{"Q7944", "enwiki", "Earthquake"},
+		{"Q1", "frwiki", "Univers"},
+	}
+	for _, p := range pages {
+		_, err := srv.stories.AddSpike(ctx, p.qid, storage.StoryPage{
+			Wiki: p.wiki, Title: p.title, SpikeRatio: 8, Severity: "high", Spikes: 1, LastSpike: now,
+		})
+		require.NoError(t, err)
+	}
+
+	rec := doRequest(srv, "GET", "/api/stories")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stories []storage.Story
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stories))
+	require.Len(t, stories, 1, "a single-wiki topic is not a story")
+	assert.Equal(t, "Q7944", stories[0].QID)
+	assert.Equal(t, "Earthquake", stories[0].Title)
+	assert.Len(t, stories[0].Pages, 2)
+
+	rec = doRequest(srv, "GET", "/api/stories?min_wikis=3")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stories))
+	assert.Empty(t, stories)
+
+	rec = doRequest(srv, "GET", "/api/stories?min_wikis=1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
 func TestEditWars_InvalidLimit(t *testing.T) {
 	srv, _ := testServer(t)
 	rec := doRequest(srv, "GET", "/api/edit-wars?limit=abc")
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+)
+
+// handleGetBotRuns returns bursts of rapid edits by one account, collapsed
+// into runs: those still in progress first, then recently finished ones.
+// Query params:
+// - wiki: restrict to one wiki (optional, default: all wikis)
+// - limit: number of runs (optional, default: 20, max: 100)
+func (s *APIServer) handleGetBotRuns(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 20, 100)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-100)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+
+	ctx := r.Context()
+	runs, err := s.botRuns.GetRuns(ctx, r.URL.Query().Get("wiki"), limit)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get bot runs")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve bot runs", ErrCodeInternalError, "")
+		return
+	}
+	if runs == nil {
+		runs = []storage.BotRun{}
+	}
+
+	respondJSON(w, http.StatusOK, runs)
+}
+
+// handleGetSuspectedBots returns accounts without the bot flag whose
+// editing cadence looks automated, most recently flagged first, for review.
+// Query params:
+// - wiki: restrict to one wiki (optional, default: all wikis)
+// - limit: number of accounts (optional, default: 20, max: 100)
+func (s *APIServer) handleGetSuspectedBots(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 20, 100)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-100)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+
+	ctx := r.Context()
+	suspects, err := s.botRuns.GetSuspects(ctx, r.URL.Query().Get("wiki"), limit)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get suspected bots")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve suspected bots", ErrCodeInternalError, "")
+		return
+	}
+	if suspects == nil {
+		suspects = []storage.SuspectedBot{}
+	}
+
+	respondJSON(w, http.StatusOK, suspects)
+}
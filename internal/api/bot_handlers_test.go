@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotRuns(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := srv.botRuns.AddEdit(ctx, storage.BotRunEdit{
+			Wiki: "enwiki", User: "SweepBot", Bot: true, Title: "Alpha", Summary: "fix", At: now,
+		})
+		require.NoError(t, err)
+	}
+	_, _, err := srv.botRuns.AddEdit(ctx, storage.BotRunEdit{Wiki: "dewiki", User: "Leise", Title: "Beta", At: now})
+	require.NoError(t, err)
+	require.NoError(t, srv.botRuns.FlagSuspect(ctx, &storage.SuspectedBot{
+		Wiki: "dewiki", User: "Leise", Score: 0.8, Reasons: []string{"regular gaps between edits (CV 0.10)"}, LastFlagged: now,
+	}))
+
+	rec := doRequest(srv, "GET", "/api/bots/runs?wiki=enwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runs []storage.BotRun
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runs))
+	require.Len(t, runs, 1)
+	assert.Equal(t, "SweepBot", runs[0].User)
+	assert.Equal(t, int64(3), runs[0].Edits)
+	assert.True(t, runs[0].Active)
+
+	rec = doRequest(srv, "GET", "/api/bots/runs?wiki=frwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	rec = doRequest(srv, "GET", "/api/bots/suspected")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var suspects []storage.SuspectedBot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &suspects))
+	require.Len(t, suspects, 1)
+	assert.Equal(t, "Leise", suspects[0].User)
+	assert.Equal(t, 0.8, suspects[0].Score)
+
+	rec = doRequest(srv, "GET", "/api/bots/runs?limit=0")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(srv, "GET", "/api/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats StatsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.NotNil(t, stats.BotRuns)
+	assert.Equal(t, storage.BotRunStats{Runs: 2, Edits: 4, Active: 2}, *stats.BotRuns)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Agnikulu/WikiSurge/internal/kafka"
+)
+
+// dlqService defines the DLQ operations used by the admin handlers.
+// Satisfied by *kafka.DLQAdmin; useful for testing with mocks.
+type dlqService interface {
+	List(ctx context.Context, filter kafka.DLQFilter, limit int) ([]kafka.DLQEntry, int, error)
+	Replay(ctx context.Context, req kafka.DLQReplayRequest) (kafka.DLQReplayResult, error)
+}
+
+// SetDLQAdmin enables the admin DLQ endpoints.
+func (s *APIServer) SetDLQAdmin(admin dlqService) {
+	s.dlq = admin
+}
+
+// dlqReplayRequest is the JSON body for POST /api/admin/dlq/replay.
+type dlqReplayRequest struct {
+	IDs         []string `json:"ids"`
+	Error       string   `json:"error"`
+	Group       string   `json:"group"`
+	All         bool     `json:"all"`
+	TargetGroup string   `json:"target_group"`
+	Force       bool     `json:"force"`
+}
+
+// handleAdminListDLQ lists dead-lettered messages (admin only).
+// Query params: error (substring), group, ids (comma-separated), limit.
+func (s *APIServer) handleAdminListDLQ(w http.ResponseWriter, r *http.Request) {
+	if s.dlq == nil {
+		writeAPIError(w, r, http.StatusServiceUnavailable, "DLQ admin is not configured", ErrCodeServiceUnavailable, "")
+		return
+	}
+
+	limit, err := parseIntQuery(r, "limit", 100, 1000)
+	if err != nil {
+		writeAPIError(w, r, http.StatusBadRequest, "limit must be between 0 and 1000", ErrCodeInvalidParameter, "")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := kafka.DLQFilter{
+		Error:         q.Get("error"),
+		ConsumerGroup: q.Get("group"),
+		IDs:           splitIDs(q.Get("ids")),
+	}
+
+	entries, total, err := s.dlq.List(r.Context(), filter, limit)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("admin: failed to list DLQ")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to read the DLQ", ErrCodeInternalError, "")
+		return
+	}
+	if entries == nil {
+		entries = []kafka.DLQEntry{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"messages": entries,
+		"total":    total,
+	})
+}
+
+// handleAdminReplayDLQ replays selected DLQ messages to their source topic or
+// to a single consumer group's retry topic (admin only).
+func (s *APIServer) handleAdminReplayDLQ(w http.ResponseWriter, r *http.Request) {
+	if s.dlq == nil {
+		writeAPIError(w, r, http.StatusServiceUnavailable, "DLQ admin is not configured", ErrCodeServiceUnavailable, "")
+		return
+	}
+
+	var req dlqReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, r, http.StatusBadRequest, "Invalid JSON body", ErrCodeInvalidParameter, "")
+		return
+	}
+
+	result, err := s.dlq.Replay(r.Context(), kafka.DLQReplayRequest{
+		DLQFilter: kafka.DLQFilter{
+			Error:         req.Error,
+			ConsumerGroup: req.Group,
+			IDs:           req.IDs,
+		},
+		All:         req.All,
+		TargetGroup: req.TargetGroup,
+		Force:       req.Force,
+	})
+	if err != nil {
+		if errors.Is(err, kafka.ErrNoReplaySelection) || errors.Is(err, kafka.ErrNoRetryTopic) {
+			writeAPIError(w, r, http.StatusBadRequest, err.Error(), ErrCodeInvalidParameter, "")
+			return
+		}
+		s.logger.Error().Err(err).Msg("admin: failed to replay DLQ")
+		writeAPIError(w, r, http.StatusInternalServerError, "Failed to replay DLQ messages", ErrCodeInternalError, "")
+		return
+	}
+
+	s.logger.Info().
+		Int("replayed", result.Replayed).
+		Int("skipped", len(result.Skipped)).
+		Int("already_replayed", len(result.AlreadyReplayed)).
+		Str("target_group", req.TargetGroup).
+		Msg("Admin replayed DLQ messages")
+	respondJSON(w, http.StatusOK, result)
+}
+
+// splitIDs parses a comma-separated list of DLQ entry IDs.
+func splitIDs(raw string) []string {
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Agnikulu/WikiSurge/internal/kafka"
+)
+
+// mockDLQService records the last call and returns canned results.
+type mockDLQService struct {
+	entries    []kafka.DLQEntry
+	lastFilter kafka.DLQFilter
+	lastLimit  int
+	lastReplay kafka.DLQReplayRequest
+	replayErr  error
+}
+
+func (m *mockDLQService) List(_ context.Context, filter kafka.DLQFilter, limit int) ([]kafka.DLQEntry, int, error) {
+	m.lastFilter = filter
+	m.lastLimit = limit
+	return m.entries, len(m.entries), nil
+}
+
+func (m *mockDLQService) Replay(_ context.Context, req kafka.DLQReplayRequest) (kafka.DLQReplayResult, error) {
+	m.lastReplay = req
+	if m.replayErr != nil {
+		return kafka.DLQReplayResult{}, m.replayErr
+	}
+	return kafka.DLQReplayResult{Replayed: 2, Skipped: []string{"0:7"}}, nil
+}
+
+// adminToken registers the configured admin user and returns its JWT.
+func adminToken(t *testing.T, srv *APIServer) string {
+	t.Helper()
+	rec := doJSON(srv, "POST", "/api/auth/register", registerRequest{
+		Email: "admin@example.com", Password: "admin-pass-123",
+	}, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register admin status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	return decodeJSON(t, rec)["token"].(string)
+}
+
+func TestAdminListDLQ(t *testing.T) {
+	srv, _ := setupAdminTestServer(t, "admin@example.com")
+	mock := &mockDLQService{entries: []kafka.DLQEntry{{
+		ID: "0:3", Offset: 3,
+		DLQMessage: kafka.DLQMessage{OriginalTopic: "wikipedia.edits", Error: "redis: timeout", ConsumerGroup: "spike-detector"},
+	}}}
+	srv.SetDLQAdmin(mock)
+	token := adminToken(t, srv)
+
+	rec := doJSON(srv, "GET", "/api/admin/dlq?error=redis&group=spike-detector&ids=0:3,%200:4&limit=10", nil, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if mock.lastFilter.Error != "redis" || mock.lastFilter.ConsumerGroup != "spike-detector" {
+		t.Errorf("filter = %+v", mock.lastFilter)
+	}
+	if len(mock.lastFilter.IDs) != 2 || mock.lastFilter.IDs[1] != "0:4" {
+		t.Errorf("ids = %v, want [0:3 0:4]", mock.lastFilter.IDs)
+	}
+	if mock.lastLimit != 10 {
+		t.Errorf("limit = %d, want 10", mock.lastLimit)
+	}
+
+	result := decodeJSON(t, rec)
+	if int(result["total"].(float64)) != 1 {
+		t.Errorf("total = %v, want 1", result["total"])
+	}
+	msg := result["messages"].([]interface{})[0].(map[string]interface{})
+	if msg["id"] != "0:3" || msg["original_topic"] != "wikipedia.edits" || msg["consumer_group"] != "spike-detector" {
+		t.Errorf("unexpected message: %v", msg)
+	}
+}
+
+func TestAdminReplayDLQ(t *testing.T) {
+	srv, _ := setupAdminTestServer(t, "admin@example.com")
+	mock := &mockDLQService{}
+	srv.SetDLQAdmin(mock)
+	token := adminToken(t, srv)
+
+	rec := doJSON(srv, "POST", "/api/admin/dlq/replay", dlqReplayRequest{
+		Group: "spike-detector", TargetGroup: "spike-detector", Force: true,
+	}, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if mock.lastReplay.ConsumerGroup != "spike-detector" || mock.lastReplay.TargetGroup != "spike-detector" || !mock.lastReplay.Force {
+		t.Errorf("replay request = %+v", mock.lastReplay)
+	}
+	result := decodeJSON(t, rec)
+	if int(result["replayed"].(float64)) != 2 {
+		t.Errorf("replayed = %v, want 2", result["replayed"])
+	}
+
+	// Selection errors are the caller's fault
+	mock.replayErr = fmt.Errorf("%w: %q", kafka.ErrNoRetryTopic, "websocket-forwarder")
+	rec = doJSON(srv, "POST", "/api/admin/dlq/replay", dlqReplayRequest{All: true, TargetGroup: "websocket-forwarder"}, token)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestAdminDLQ_NotConfigured(t *testing.T) {
+	srv, _ := setupAdminTestServer(t, "admin@example.com")
+	token := adminToken(t, srv)
+
+	rec := doJSON(srv, "GET", "/api/admin/dlq", nil, token)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
+
+func TestAdminDLQ_NonAdminForbidden(t *testing.T) {
+	srv, _ := setupAdminTestServer(t, "admin@example.com")
+	srv.SetDLQAdmin(&mockDLQService{})
+
+	rec := doJSON(srv, "POST", "/api/auth/register", registerRequest{
+		Email: "regular@example.com", Password: "regular-pass-123",
+	}, "")
+	token := decodeJSON(t, rec)["token"].(string)
+
+	rec = doJSON(srv, "POST", "/api/admin/dlq/replay", dlqReplayRequest{All: true}, token)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+}
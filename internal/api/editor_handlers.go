@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+)
+
+// handleGetEditor returns one editor's activity profile on one wiki.
+// Path params:
+// - wiki: wiki database name, e.g. enwiki
+// - username: user name (underscores read as spaces), IP address or ip-<hash> pseudonym
+func (s *APIServer) handleGetEditor(w http.ResponseWriter, r *http.Request) {
+	wiki := r.PathValue("wiki")
+	user := strings.ReplaceAll(r.PathValue("username"), "_", " ")
+	if wiki == "" || user == "" {
+		writeAPIError(w, r, http.StatusBadRequest, "Missing wiki or username", ErrCodeInvalidParameter, "")
+		return
+	}
+
+	ctx := r.Context()
+	profile, err := s.editors.GetProfile(ctx, wiki, user)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get editor profile")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve editor profile", ErrCodeInternalError, "")
+		return
+	}
+	if profile == nil {
+		writeAPIError(w, r, http.StatusNotFound, "No activity recorded for this editor", ErrCodeNotFound, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// handleGetTopEditors returns the most active non-bot editors.
+// Query params:
+// - wiki: restrict to one wiki (optional, default: all wikis)
+// - limit: number of editors (optional, default: 20, max: 100)
+func (s *APIServer) handleGetTopEditors(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 20, 100)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-100)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+
+	ctx := r.Context()
+	editors, err := s.editors.GetTopEditors(ctx, r.URL.Query().Get("wiki"), limit)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get top editors")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve top editors", ErrCodeInternalError, "")
+		return
+	}
+	if editors == nil {
+		editors = []storage.EditorProfile{}
+	}
+
+	respondJSON(w, http.StatusOK, editors)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditors(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, a := range []storage.EditorActivity{
+		{Wiki: "enwiki", User: "Jane Doe", Title: "Alpha", ByteChange: 200, At: now},
+		{Wiki: "enwiki", User: "Jane Doe", Title: "Beta", ByteChange: -50, Revert: true, Reverted: []string{"Vandal"}, At: now},
+		{Wiki: "enwiki", User: "Vandal", Title: "Beta", ByteChange: 50, At: now},
+		{Wiki: "enwiki", User: "SweepBot", Title: "Gamma", Bot: true, At: now},
+	} {
+		require.NoError(t, srv.editors.Record(ctx, a))
+	}
+
+	rec := doRequest(srv, "GET", "/api/editors/enwiki/Jane_Doe")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var profile storage.EditorProfile
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+	assert.Equal(t, "Jane Doe", profile.User)
+	assert.Equal(t, int64(2), profile.Edits)
+	assert.Equal(t, int64(200), profile.BytesAdded)
+	assert.Equal(t, int64(1), profile.RevertsGiven)
+	assert.Len(t, profile.RecentPages, 2)
+	assert.Equal(t, now, profile.LastSeen)
+
+	rec = doRequest(srv, "GET", "/api/editors/enwiki/Nobody")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(srv, "GET", "/api/editors/top?wiki=enwiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var top []storage.EditorProfile
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &top))
+	require.Len(t, top, 2, "bots are not ranked")
+	assert.Equal(t, "Jane Doe", top[0].User)
+	assert.Equal(t, int64(1), top[1].RevertsReceived)
+
+	rec = doRequest(srv, "GET", "/api/editors/top?wiki=dewiki")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	rec = doRequest(srv, "GET", "/api/editors/top?limit=500")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -58,7 +58,7 @@ var (
 	ErrInvalidOffset    = &ValidationError{Field: "offset", Message: "offset must be non-negative and <= 10000", Code: ErrCodeInvalidParameter}
 	ErrInvalidTimeRange = &ValidationError{Field: "from/to", Message: "'from' must be before 'to'", Code: ErrCodeInvalidParameter}
 	ErrInvalidSeverity  = &ValidationError{Field: "severity", Message: "severity must be one of: low, medium, high, critical", Code: ErrCodeInvalidParameter}
-	ErrInvalidAlertType = &ValidationError{Field: "type", Message: "alert type must be one of: spike, edit_war", Code: ErrCodeInvalidParameter}
+	ErrInvalidAlertType = &ValidationError{Field: "type", Message: "alert type must be one of: spike, edit_war, wiki_surge, stream_drop, vandalism", Code: ErrCodeInvalidParameter}
 	ErrInvalidTimestamp = &ValidationError{Field: "timestamp", Message: "must be RFC3339 or Unix timestamp", Code: ErrCodeInvalidParameter}
 )
 
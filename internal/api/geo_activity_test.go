@@ -194,7 +194,7 @@ func TestLookupArticleCoordinates_CachedPositive(t *testing.T) {
 
 	// Pre-cache coordinates with source
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 48.8566, "lng": 2.3522, "source": "article"})
-	mr.Set("editwar:coords:Paris", string(coordData))
+	mr.Set("editwar:coords:enwiki:Paris", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "Paris", "https://en.wikipedia.org")
@@ -209,7 +209,7 @@ func TestLookupArticleCoordinates_CachedNegative(t *testing.T) {
 	srv, mr := testServer(t)
 
 	// Pre-cache negative result
-	mr.Set("editwar:coords:Quantum_mechanics", `{"lat":0,"lng":0}`)
+	mr.Set("editwar:coords:enwiki:Quantum_mechanics", `{"lat":0,"lng":0}`)
 
 	ctx := context.Background()
 	_, _, _, found := srv.lookupArticleCoordinates(ctx, "Quantum_mechanics", "https://en.wikipedia.org")
@@ -896,7 +896,7 @@ func TestLookupArticleCoordinates_CachedWikidataSource(t *testing.T) {
 	srv, mr := testServer(t)
 
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 23.685, "lng": 90.356, "source": "wikidata"})
-	mr.Set("editwar:coords:BengaliArticle", string(coordData))
+	mr.Set("editwar:coords:bnwiki:BengaliArticle", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "BengaliArticle", "https://bn.wikipedia.org")
@@ -911,7 +911,7 @@ func TestLookupArticleCoordinates_CachedSemanticSource(t *testing.T) {
 	srv, mr := testServer(t)
 
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 32.35, "lng": -89.40, "source": "semantic"})
-	mr.Set("editwar:coords:ForestHillHS", string(coordData))
+	mr.Set("editwar:coords:dewiki:ForestHillHS", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "ForestHillHS", "https://de.wikipedia.org")
@@ -926,7 +926,7 @@ func TestLookupArticleCoordinates_LegacyCacheNoSource(t *testing.T) {
 	srv, mr := testServer(t)
 
 	// Legacy cache entry without "source" field
-	mr.Set("editwar:coords:OldEntry", `{"lat":51.5074,"lng":-0.1278}`)
+	mr.Set("editwar:coords:enwiki:OldEntry", `{"lat":51.5074,"lng":-0.1278}`)
 
 	ctx := context.Background()
 	_, _, src, found := srv.lookupArticleCoordinates(ctx, "OldEntry", "https://en.wikipedia.org")
@@ -1313,7 +1313,7 @@ func TestLookupArticleCoordinates_CachedFromP27(t *testing.T) {
 	srv, mr := testServer(t)
 
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 38.9072, "lng": -77.0369, "source": "wikidata"})
-	mr.Set("editwar:coords:Майкл_Джордан", string(coordData))
+	mr.Set("editwar:coords:ruwiki:Майкл_Джордан", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "Майкл_Джордан", "https://ru.wikipedia.org")
@@ -1329,7 +1329,7 @@ func TestLookupArticleCoordinates_CachedFromP740(t *testing.T) {
 	srv, mr := testServer(t)
 
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 53.4084, "lng": -2.9916, "source": "wikidata"})
-	mr.Set("editwar:coords:ビートルズ", string(coordData))
+	mr.Set("editwar:coords:jawiki:ビートルズ", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "ビートルズ", "https://ja.wikipedia.org")
@@ -1345,7 +1345,7 @@ func TestLookupArticleCoordinates_CachedFromP495(t *testing.T) {
 	srv, mr := testServer(t)
 
 	coordData, _ := json.Marshal(map[string]interface{}{"lat": 37.5665, "lng": 126.978, "source": "wikidata"})
-	mr.Set("editwar:coords:오징어_게임", string(coordData))
+	mr.Set("editwar:coords:kowiki:오징어_게임", string(coordData))
 
 	ctx := context.Background()
 	lat, lng, src, found := srv.lookupArticleCoordinates(ctx, "오징어_게임", "https://ko.wikipedia.org")
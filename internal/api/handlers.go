@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/Agnikulu/WikiSurge/internal/storage"
 )
 
@@ -28,13 +30,17 @@ import (
 
 // TrendingPageResponse represents a single trending page.
 type TrendingPageResponse struct {
-	Title     string  `json:"title"`
-	Score     float64 `json:"score"`
-	Edits1h   int64   `json:"edits_1h"`
-	LastEdit  string  `json:"last_edit"`
-	Rank      int     `json:"rank"`
-	Language  string  `json:"language,omitempty"`
-	ServerURL string  `json:"server_url,omitempty"`
+	Title      string  `json:"title"`
+	Wiki       string  `json:"wiki,omitempty"`
+	Score      float64 `json:"score"`
+	Edits1h    int64   `json:"edits_1h"`
+	LastEdit   string  `json:"last_edit"`
+	Rank       int     `json:"rank"`
+	RankChange *int    `json:"rank_change,omitempty"` // places gained since change_window ago; omitted if not ranked then
+	PeakRank   *int    `json:"peak_rank,omitempty"`   // best rank within the history retention
+	Language   string  `json:"language,omitempty"`
+	Project    string  `json:"project,omitempty"`
+	ServerURL  string  `json:"server_url,omitempty"`
 }
 
 func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
@@ -50,7 +56,18 @@ func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entries, err := s.trending.GetTopTrending(params.Limit)
+	scope := storage.TrendingScope{
+		Wiki:      params.Wiki,
+		Language:  params.Language,
+		Project:   params.Project,
+		Namespace: params.Namespace,
+	}
+	entries, err := s.trending.GetTopTrendingIn(scope, params.Limit)
+	if errors.Is(err, storage.ErrTrendingScopeDisabled) {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Trending leaderboard not enabled for this filter", ErrCodeInvalidParameter, "")
+		return
+	}
 	if err != nil {
 		s.logger.Error().Err(err).
 			Str("request_id", GetRequestID(r.Context())).
@@ -64,21 +81,19 @@ func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
 	results := make([]TrendingPageResponse, 0, len(entries))
 
 	for i, e := range entries {
-		// Detect language from server_url or page title convention
-		lang := extractLanguageFromURL(e.ServerURL)
-		if lang == "" {
-			lang = extractLanguage(e.PageTitle)
-		}
+		key := models.NewPageKey(e.Wiki, e.PageTitle)
 
-		if params.Language != "" && lang != params.Language {
-			continue
+		// Detect language from the wiki, falling back to server_url
+		_, lang := models.ParseWikiDBName(key.Wiki)
+		if lang == "" {
+			lang = extractLanguageFromURL(e.ServerURL)
 		}
 
 		// Enrich with edit count from hot page tracker
 		var edits1h int64
 		var hotServerURL string
 		if s.hotPages != nil {
-			stats, err := s.hotPages.GetPageStats(ctx, e.PageTitle)
+			stats, err := s.hotPages.GetPageStats(ctx, key)
 			if err == nil && stats != nil {
 				edits1h = stats.EditsLastHour
 				if stats.ServerURL != "" {
@@ -92,6 +107,9 @@ func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
 		if serverURL == "" {
 			serverURL = hotServerURL
 		}
+		if serverURL == "" {
+			serverURL = models.ServerURLForWiki(key.Wiki)
+		}
 
 		lastEdit := ""
 		if e.LastUpdated > 0 {
@@ -100,15 +118,22 @@ func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
 
 		results = append(results, TrendingPageResponse{
 			Title:     e.PageTitle,
+			Wiki:      e.Wiki,
 			Score:     e.CurrentScore,
 			Edits1h:   edits1h,
 			LastEdit:  lastEdit,
 			Rank:      i + 1,
 			Language:  lang,
+			Project:   extractProjectFromURL(serverURL),
 			ServerURL: serverURL,
 		})
 	}
 
+	// History is kept for the global and per-wiki leaderboards
+	if scope.Language == "" && scope.Project == "" && scope.Namespace == nil {
+		s.addRankHistory(r, params.Wiki, results)
+	}
+
 	respondJSON(w, http.StatusOK, results)
 }
 
@@ -118,15 +143,17 @@ func (s *APIServer) handleGetTrending(w http.ResponseWriter, r *http.Request) {
 
 // StatsResponse is returned by GET /api/stats.
 type StatsResponse struct {
-	EditsPerSecond float64            `json:"edits_per_second"`
-	EditsToday     int                `json:"edits_today"`
-	HotPagesCount  int                `json:"hot_pages_count"`
-	TrendingCount  int                `json:"trending_count"`
-	ActiveAlerts   int64              `json:"active_alerts"`
-	Uptime         int64              `json:"uptime"`
-	TopLanguage    string             `json:"top_language,omitempty"`
-	TopLanguages   []LanguageStat     `json:"top_languages"`
-	EditsByType    *EditsByType       `json:"edits_by_type,omitempty"`
+	EditsPerSecond float64              `json:"edits_per_second"`
+	EditsToday     int                  `json:"edits_today"`
+	HotPagesCount  int                  `json:"hot_pages_count"`
+	TrendingCount  int                  `json:"trending_count"`
+	ActiveAlerts   int64                `json:"active_alerts"`
+	Uptime         int64                `json:"uptime"`
+	TopLanguage    string               `json:"top_language,omitempty"`
+	TopLanguages   []LanguageStat       `json:"top_languages"`
+	EditsByType    *EditsByType         `json:"edits_by_type,omitempty"`
+	Projects       []ProjectStat        `json:"projects,omitempty"`
+	BotRuns        *storage.BotRunStats `json:"bot_runs,omitempty"`
 }
 
 // EditsByType tracks human vs bot edit counts.
@@ -135,6 +162,13 @@ type EditsByType struct {
 	Bot   int `json:"bot"`
 }
 
+// ProjectStat is a single project family count.
+type ProjectStat struct {
+	Project    string  `json:"project"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
 // LanguageStat is a single language count.
 type LanguageStat struct {
 	Language   string  `json:"language"`
@@ -174,7 +208,7 @@ func (s *APIServer) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	// Active alerts
 	var activeAlerts int64
 	if s.alerts != nil {
-		alertTypes := []string{"spikes", "editwars", "trending", "vandalism"}
+		alertTypes := append([]string{"trending"}, liveAlertStreams...)
 		for _, t := range alertTypes {
 			streamName := fmt.Sprintf("alerts:%s", t)
 			length, err := s.redis.XLen(ctx, streamName).Result()
@@ -238,6 +272,22 @@ func (s *APIServer) handleGetStats(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		// Per-project breakdown (wikipedia, wikidata, wiktionary, ...).
+		projectCounts, projErr := s.statsTracker.GetProjectCounts(ctx)
+		if projErr == nil && len(projectCounts) > 0 {
+			var totalProjectEdits int64
+			for _, pc := range projectCounts {
+				totalProjectEdits += pc.Count
+			}
+			for _, pc := range projectCounts {
+				resp.Projects = append(resp.Projects, ProjectStat{
+					Project:    pc.Project,
+					Count:      int(pc.Count),
+					Percentage: math.Round(float64(pc.Count)/float64(totalProjectEdits)*1000) / 10,
+				})
+			}
+		}
+
 		// Get real human vs bot counts.
 		human, bot, typeErr := s.statsTracker.GetEditTypes(ctx)
 		if typeErr == nil && (human > 0 || bot > 0) {
@@ -248,6 +298,14 @@ func (s *APIServer) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Bursts collapsed into bot runs today
+	if s.botRuns != nil {
+		runStats, runErr := s.botRuns.GetStats(ctx, time.Now())
+		if runErr == nil && (runStats.Runs > 0 || runStats.Active > 0) {
+			resp.BotRuns = &runStats
+		}
+	}
+
 	// Fallback: if no real language data yet, use configured languages with weights.
 	if len(resp.TopLanguages) == 0 && s.config != nil && len(s.config.Ingestor.AllowedLanguages) > 0 {
 		langs := s.config.Ingestor.AllowedLanguages
@@ -350,13 +408,6 @@ func (s *APIServer) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	validTypes := map[string]string{
-		"spike":    "spikes",
-		"edit_war": "editwars",
-		"spikes":   "spikes",
-		"editwars": "editwars",
-	}
-
 	// Check cache
 	ck := cacheKey("alerts", params.AlertType, params.Severity, params.Since.Format(time.RFC3339),
 		strconv.Itoa(params.Limit), strconv.Itoa(params.Offset))
@@ -387,9 +438,9 @@ func (s *APIServer) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 	// Decide which streams to query
 	streams := []string{}
 	if params.AlertType == "" {
-		streams = []string{"spikes", "editwars"}
+		streams = liveAlertStreams
 	} else {
-		streams = []string{validTypes[params.AlertType]}
+		streams = []string{alertStreams[strings.ToLower(params.AlertType)]}
 	}
 
 	// Fetch with reasonable buffer for filtering
@@ -441,14 +492,13 @@ func (s *APIServer) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 		}
 		if wiki, ok := a.Data["wiki"].(string); ok {
 			entry.Wiki = wiki
+			entry.Project, _ = models.ParseWikiDBName(wiki)
 		}
 		// Derive server_url from wiki field, alert data, or page_title server_url
 		if serverURL, ok := a.Data["server_url"].(string); ok && serverURL != "" {
 			entry.ServerURL = serverURL
 		} else if entry.Wiki != "" {
-			if lang := strings.TrimSuffix(entry.Wiki, "wiki"); lang != "" {
-				entry.ServerURL = fmt.Sprintf("https://%s.wikipedia.org", lang)
-			}
+			entry.ServerURL = models.ServerURLForWiki(entry.Wiki)
 		}
 		if ratio, ok := a.Data["spike_ratio"].(float64); ok {
 			entry.SpikeRatio = ratio
@@ -465,6 +515,63 @@ func (s *APIServer) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 		} else if numEditors, ok := a.Data["num_editors"].(float64); ok {
 			entry.EditorCount = int(numEditors)
 		}
+		// Aggregate anomalies (wiki_surge, stream_drop)
+		if scope, ok := a.Data["scope"].(string); ok {
+			entry.Scope = scope
+		}
+		if ns, ok := a.Data["namespace"].(float64); ok {
+			n := int(ns)
+			entry.Namespace = &n
+		}
+		if rate, ok := a.Data["rate"].(float64); ok {
+			entry.Rate = rate
+		}
+		if expected, ok := a.Data["expected_rate"].(float64); ok {
+			entry.ExpectedRate = expected
+		}
+		if reason, ok := a.Data["reason"].(string); ok {
+			entry.Reason = reason
+		}
+		// Vandalism
+		if user, ok := a.Data["user"].(string); ok {
+			entry.User = user
+		}
+		if confidence, ok := a.Data["confidence"].(float64); ok {
+			entry.Confidence = confidence
+		}
+		if reasons, ok := a.Data["reasons"].([]interface{}); ok {
+			for _, r := range reasons {
+				if s, ok := r.(string); ok {
+					entry.Reasons = append(entry.Reasons, s)
+				}
+			}
+		}
+		if rc, ok := a.Data["revert_count"].(float64); ok {
+			entry.RevertCount = int(rc)
+		}
+		// Coordinated editing
+		if accounts, ok := a.Data["accounts"].([]interface{}); ok {
+			for _, acc := range accounts {
+				if s, ok := acc.(string); ok {
+					entry.Editors = append(entry.Editors, s)
+				}
+			}
+		}
+		if score, ok := a.Data["score"].(float64); ok {
+			entry.Confidence = score
+		}
+		if pages, ok := a.Data["shared_pages"].([]interface{}); ok {
+			for _, p := range pages {
+				if s, ok := p.(string); ok {
+					entry.SharedPages = append(entry.SharedPages, s)
+				}
+			}
+		}
+		if pairs, ok := a.Data["pairs"]; ok {
+			if raw, err := json.Marshal(pairs); err == nil {
+				_ = json.Unmarshal(raw, &entry.Pairs)
+			}
+		}
 		if participants, ok := a.Data["participants"].([]interface{}); ok {
 			eds := make([]string, 0, len(participants))
 			for _, p := range participants {
@@ -537,6 +644,9 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 			if pt, ok := w["page_title"].(string); ok {
 				entry.PageTitle = pt
 			}
+			if wiki, ok := w["wiki"].(string); ok {
+				entry.Wiki = wiki
+			}
 			if ec, ok := w["editor_count"].(int); ok {
 				entry.EditorCount = ec
 			}
@@ -560,12 +670,13 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 			}
 			if su, ok := w["server_url"].(string); ok {
 				entry.ServerURL = su
+				entry.Project = extractProjectFromURL(su)
 			}
 
 			// Embed cached analysis if available (populated by the processor
 			// at war start, every N edits, and at war end).
 			if entry.PageTitle != "" {
-				cacheKey := fmt.Sprintf("editwar:analysis:%s", entry.PageTitle)
+				cacheKey := fmt.Sprintf("editwar:analysis:%s", entry.pageKey())
 				if cached, cErr := s.redis.Get(ctx, cacheKey).Result(); cErr == nil && cached != "" {
 					var analysis interface{}
 					if json.Unmarshal([]byte(cached), &analysis) == nil {
@@ -574,6 +685,8 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			s.attachLogEvents(ctx, &entry)
+
 			results = append(results, entry)
 		}
 
@@ -600,11 +713,12 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 		activeWars = []map[string]interface{}{} // Continue with empty filter
 	}
 
-	// Build set of active page titles
-	activeTitles := make(map[string]bool)
+	// Build set of active pages
+	activePages := make(map[models.PageKey]bool)
 	for _, w := range activeWars {
 		if pt, ok := w["page_title"].(string); ok {
-			activeTitles[pt] = true
+			wiki, _ := w["wiki"].(string)
+			activePages[models.NewPageKey(wiki, pt)] = true
 		}
 	}
 
@@ -613,10 +727,9 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 		entry := EditWarEntry{Active: false}
 		if pt, ok := w["page_title"].(string); ok {
 			entry.PageTitle = pt
-			// Skip if this war is currently active
-			if activeTitles[pt] {
-				continue
-			}
+		}
+		if wiki, ok := w["wiki"].(string); ok {
+			entry.Wiki = wiki
 		}
 		if ec, ok := w["editor_count"].(float64); ok {
 			entry.EditorCount = int(ec)
@@ -647,12 +760,17 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 		}
 		if su, ok := w["server_url"].(string); ok {
 			entry.ServerURL = su
+			entry.Project = extractProjectFromURL(su)
+		}
+		// Skip if this war is currently active
+		if entry.PageTitle != "" && activePages[entry.pageKey()] {
+			continue
 		}
 
 		// Embed cached analysis if available (populated by the processor
 		// at war start, every N edits, and at war end).
 		if entry.PageTitle != "" {
-			cacheKey := fmt.Sprintf("editwar:analysis:%s", entry.PageTitle)
+			cacheKey := fmt.Sprintf("editwar:analysis:%s", entry.pageKey())
 			if cached, cErr := s.redis.Get(ctx, cacheKey).Result(); cErr == nil && cached != "" {
 				var analysis interface{}
 				if json.Unmarshal([]byte(cached), &analysis) == nil {
@@ -661,6 +779,8 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		s.attachLogEvents(ctx, &entry)
+
 		results = append(results, entry)
 		
 		// Stop if we've reached the requested limit
@@ -677,8 +797,8 @@ func (s *APIServer) handleGetEditWars(w http.ResponseWriter, r *http.Request) {
 // ---------------------------------------------------------------------------
 
 func (s *APIServer) handleGetEditWarAnalysis(w http.ResponseWriter, r *http.Request) {
-	pageTitle := r.URL.Query().Get("page")
-	if pageTitle == "" {
+	key, ok := parsePageKeyQuery(r)
+	if !ok {
 		writeAPIError(w, r, http.StatusBadRequest,
 			"Missing required 'page' query parameter", ErrCodeInvalidParameter, "field: page")
 		return
@@ -698,9 +818,9 @@ func (s *APIServer) handleGetEditWarAnalysis(w http.ResponseWriter, r *http.Requ
 	analysisCtx, analysisCancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer analysisCancel()
 
-	analysis, err := s.analysisService.Analyze(analysisCtx, pageTitle)
+	analysis, err := s.analysisService.Analyze(analysisCtx, key)
 	if err != nil {
-		s.logger.Error().Err(err).Str("page", pageTitle).
+		s.logger.Error().Err(err).Str("page", key.String()).
 			Str("request_id", GetRequestID(r.Context())).
 			Msg("Failed to analyze edit war")
 		writeAPIError(w, r, http.StatusInternalServerError,
@@ -714,18 +834,18 @@ func (s *APIServer) handleGetEditWarAnalysis(w http.ResponseWriter, r *http.Requ
 // handleGetEditWarTimeline returns the raw edit timeline stored in Redis
 // for a specific edit war page.
 func (s *APIServer) handleGetEditWarTimeline(w http.ResponseWriter, r *http.Request) {
-	pageTitle := r.URL.Query().Get("page")
-	if pageTitle == "" {
+	key, ok := parsePageKeyQuery(r)
+	if !ok {
 		writeAPIError(w, r, http.StatusBadRequest,
 			"Missing required 'page' query parameter", ErrCodeInvalidParameter, "field: page")
 		return
 	}
 
 	ctx := r.Context()
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	raw, err := s.redis.LRange(ctx, timelineKey, 0, -1).Result()
 	if err != nil {
-		s.logger.Error().Err(err).Str("page", pageTitle).
+		s.logger.Error().Err(err).Str("page", key.String()).
 			Str("request_id", GetRequestID(ctx)).
 			Msg("Failed to get edit war timeline")
 		writeAPIError(w, r, http.StatusInternalServerError,
@@ -734,11 +854,12 @@ func (s *APIServer) handleGetEditWarTimeline(w http.ResponseWriter, r *http.Requ
 	}
 
 	type TimelineEntry struct {
-		User       string `json:"user"`
-		Comment    string `json:"comment"`
-		ByteChange int    `json:"byte_change"`
-		Timestamp  int64  `json:"timestamp"`
-		RevisionID int64  `json:"revision_id,omitempty"`
+		User       string          `json:"user"`
+		Comment    string          `json:"comment"`
+		ByteChange int             `json:"byte_change"`
+		Timestamp  int64           `json:"timestamp"`
+		RevisionID int64           `json:"revision_id,omitempty"`
+		Revert     json.RawMessage `json:"revert,omitempty"` // who this edit reverted, as recorded by the processor
 	}
 
 	entries := make([]TimelineEntry, 0, len(raw))
@@ -752,6 +873,159 @@ func (s *APIServer) handleGetEditWarTimeline(w http.ResponseWriter, r *http.Requ
 	respondJSON(w, http.StatusOK, entries)
 }
 
+// handleGetEditWarViolations lists three-revert rule violations, newest
+// first, optionally narrowed to one wiki, page or editor.
+func (s *APIServer) handleGetEditWarViolations(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 20, 100)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-100)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+	since, err := parseTimeQuery(r, "since", time.Now().Add(-24*time.Hour))
+	if err != nil {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'since' parameter (must be RFC3339 or Unix timestamp)", ErrCodeInvalidParameter, "field: since")
+		return
+	}
+	q := r.URL.Query()
+	wiki, user := q.Get("wiki"), q.Get("user")
+	page, byPage := parsePageKeyQuery(r)
+
+	if s.alerts == nil {
+		respondJSON(w, http.StatusOK, []storage.ThreeRevertViolation{})
+		return
+	}
+
+	ctx := r.Context()
+	violations, err := s.alerts.GetThreeRevertViolations(ctx, since, 1000)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get 3RR violations")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve 3RR violations", ErrCodeInternalError, "")
+		return
+	}
+
+	results := make([]storage.ThreeRevertViolation, 0, limit)
+	for _, v := range violations {
+		if wiki != "" && v.Wiki != wiki {
+			continue
+		}
+		if byPage && (v.Wiki != page.Wiki || v.Title != page.Title) {
+			continue
+		}
+		if user != "" && v.User != user {
+			continue
+		}
+		results = append(results, v)
+		if len(results) == limit {
+			break
+		}
+	}
+
+	respondJSON(w, http.StatusOK, results)
+}
+
+// attachLogEvents embeds recent admin actions on the war's page, if any.
+func (s *APIServer) attachLogEvents(ctx context.Context, entry *EditWarEntry) {
+	if s.logEvents == nil || entry.PageTitle == "" {
+		return
+	}
+	key := entry.pageKey()
+	if key.Wiki == "" {
+		return
+	}
+	events, err := s.logEvents.GetPageEvents(ctx, key.Wiki, key.Title, 10)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("page", entry.PageTitle).Msg("failed to get log events for edit war")
+		return
+	}
+	entry.LogEvents = events
+}
+
+// ---------------------------------------------------------------------------
+// Log Events
+// ---------------------------------------------------------------------------
+
+// handleGetLogEvents returns recent MediaWiki log events (protections,
+// blocks, deletions, moves). With a 'page' parameter it returns that page's
+// history on the given wiki (default enwiki); otherwise the global feed,
+// optionally filtered by 'wiki' and 'type'.
+func (s *APIServer) handleGetLogEvents(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 50, 500)
+	if err != nil {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-500)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+
+	q := r.URL.Query()
+	wiki := q.Get("wiki")
+	ctx := r.Context()
+
+	var events []models.LogEvent
+	if page := q.Get("page"); page != "" {
+		if wiki == "" {
+			wiki = defaultWiki
+		}
+		events, err = s.logEvents.GetPageEvents(ctx, wiki, page, limit)
+	} else {
+		events, err = s.logEvents.GetRecent(ctx, wiki, q.Get("type"), limit)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get log events")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve log events", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
+
+// ---------------------------------------------------------------------------
+// Stories
+// ---------------------------------------------------------------------------
+
+// handleGetStories returns active cross-language stories: topics whose
+// articles spiked on several wikis, grouped by Wikidata item, with the spike
+// on each wiki. 'min_wikis' raises the number of wikis a story must span.
+func (s *APIServer) handleGetStories(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", 20, 100)
+	if err != nil || limit == 0 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'limit' parameter (must be 1-100)", ErrCodeInvalidParameter, "field: limit")
+		return
+	}
+
+	defaultMin := s.config.Processor.Stories.MinWikis
+	if defaultMin < 2 {
+		defaultMin = 2
+	}
+	minWikis, err := parseIntQuery(r, "min_wikis", defaultMin, 50)
+	if err != nil || minWikis < 2 {
+		writeAPIError(w, r, http.StatusBadRequest,
+			"Invalid 'min_wikis' parameter (must be 2-50)", ErrCodeInvalidParameter, "field: min_wikis")
+		return
+	}
+
+	ctx := r.Context()
+	stories, err := s.stories.GetStories(ctx, minWikis, limit)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get stories")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve stories", ErrCodeInternalError, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stories)
+}
+
 // ---------------------------------------------------------------------------
 // Search
 // ---------------------------------------------------------------------------
@@ -943,13 +1217,16 @@ func (s *APIServer) parseSearchResponse(result map[string]interface{}, query str
 					if v, ok := source["wiki"].(string); ok {
 						hit.Wiki = v
 						// Derive server_url from wiki field (e.g., "zhwiki" -> "https://zh.wikipedia.org")
-						if lang := strings.TrimSuffix(v, "wiki"); lang != "" {
-							hit.ServerURL = fmt.Sprintf("https://%s.wikipedia.org", lang)
-						}
+						hit.ServerURL = models.ServerURLForWiki(v)
+						// Documents indexed before project was recorded
+						hit.Project, _ = models.ParseWikiDBName(v)
 					}
 					if v, ok := source["language"].(string); ok {
 						hit.Language = v
 					}
+					if v, ok := source["project"].(string); ok && v != "" {
+						hit.Project = v
+					}
 					if v, ok := source["timestamp"].(string); ok {
 						hit.Timestamp = v
 					}
@@ -1054,6 +1331,9 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 				if pt, ok := aw["page_title"].(string); ok {
 					gw.PageTitle = pt
 				}
+				if wiki, ok := aw["wiki"].(string); ok {
+					gw.Wiki = wiki
+				}
 				if sev, ok := aw["severity"].(string); ok {
 					gw.Severity = sev
 				}
@@ -1107,7 +1387,7 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 					}
 
 					// Embed cached analysis snippet
-					ck := fmt.Sprintf("editwar:analysis:%s", gw.PageTitle)
+					ck := fmt.Sprintf("editwar:analysis:%s", pageKeyFor(gw.Wiki, gw.PageTitle, gw.ServerURL))
 					if cached, cErr := s.redis.Get(ctx, ck).Result(); cErr == nil && cached != "" {
 						var analysis map[string]interface{}
 						if json.Unmarshal([]byte(cached), &analysis) == nil {
@@ -1138,6 +1418,9 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 			if pt, ok := hw["page_title"].(string); ok {
 				gw.PageTitle = pt
 			}
+			if wiki, ok := hw["wiki"].(string); ok {
+				gw.Wiki = wiki
+			}
 			if sev, ok := hw["severity"].(string); ok {
 				gw.Severity = sev
 			}
@@ -1179,7 +1462,7 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 
 			// Embed cached analysis
 			if gw.PageTitle != "" {
-				ck := fmt.Sprintf("editwar:analysis:%s", gw.PageTitle)
+				ck := fmt.Sprintf("editwar:analysis:%s", pageKeyFor(gw.Wiki, gw.PageTitle, gw.ServerURL))
 				if cached, cErr := s.redis.Get(ctx, ck).Result(); cErr == nil && cached != "" {
 					var analysis map[string]interface{}
 					if json.Unmarshal([]byte(cached), &analysis) == nil {
@@ -1207,33 +1490,35 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 	if s.trending != nil {
 		trending, err := s.trending.GetTopTrending(20)
 		if err == nil {
-			// Build a set of war page titles so we skip duplicates
-			warTitles := make(map[string]bool, len(resp.Wars))
+			// Build a set of war pages so we skip duplicates
+			warPages := make(map[models.PageKey]bool, len(resp.Wars))
 			for _, w := range resp.Wars {
-				warTitles[w.PageTitle] = true
+				warPages[pageKeyFor(w.Wiki, w.PageTitle, w.ServerURL)] = true
 			}
 
 			// Build hotspot structs first (cheap)
 			hotspots := make([]GeoHotspot, 0, len(trending))
 			for i, entry := range trending {
-				if warTitles[entry.PageTitle] {
+				key := pageKeyFor(entry.Wiki, entry.PageTitle, entry.ServerURL)
+				if warPages[key] {
 					continue
 				}
 
-				lang := extractLanguageFromURL(entry.ServerURL)
+				_, lang := models.ParseWikiDBName(key.Wiki)
 				if lang == "" {
-					lang = extractLanguage(entry.PageTitle)
+					lang = extractLanguageFromURL(entry.ServerURL)
 				}
 
 				var edits1h int64
 				if s.hotPages != nil {
-					if stats, hErr := s.hotPages.GetPageStats(ctx, entry.PageTitle); hErr == nil && stats != nil {
+					if stats, hErr := s.hotPages.GetPageStats(ctx, key); hErr == nil && stats != nil {
 						edits1h = stats.EditsLastHour
 					}
 				}
 
 				hotspots = append(hotspots, GeoHotspot{
 					PageTitle: entry.PageTitle,
+					Wiki:      key.Wiki,
 					Score:     entry.CurrentScore,
 					Edits1h:   int(edits1h),
 					Language:  lang,
@@ -1304,7 +1589,7 @@ func (s *APIServer) handleGetGeoActivity(w http.ResponseWriter, r *http.Request)
 // Only called for active edit wars / hotspots (~2-20 at a time), so very cheap.
 func (s *APIServer) lookupArticleCoordinates(ctx context.Context, pageTitle, serverURL string) (float64, float64, string, bool) {
 	// Check Redis cache first
-	coordKey := fmt.Sprintf("editwar:coords:%s", pageTitle)
+	coordKey := fmt.Sprintf("editwar:coords:%s", pageKeyFor("", pageTitle, serverURL))
 	if cached, err := s.redis.Get(ctx, coordKey).Result(); err == nil && cached != "" {
 		var coords struct {
 			Lat    float64 `json:"lat"`
@@ -1755,20 +2040,19 @@ func extractLanguage(pageTitle string) string {
 	return ""
 }
 
-// extractLanguageFromURL extracts a language code from a Wikipedia server URL.
-// For example, "https://en.wikipedia.org" returns "en", "https://zh.wikipedia.org" returns "zh".
+// extractLanguageFromURL extracts a language code from a Wikimedia server URL.
+// For example, "https://en.wikipedia.org" returns "en", "https://zh.wiktionary.org" returns "zh".
+// Multilingual projects such as "https://www.wikidata.org" return "".
 func extractLanguageFromURL(serverURL string) string {
-	if serverURL == "" {
-		return ""
-	}
-	// Strip protocol
-	u := strings.TrimPrefix(serverURL, "https://")
-	u = strings.TrimPrefix(u, "http://")
-	// Extract subdomain before ".wikipedia.org"
-	if idx := strings.Index(u, ".wikipedia.org"); idx > 0 {
-		return u[:idx]
-	}
-	return ""
+	_, lang := models.ParseWikiDBName(models.WikiFromServerURL(serverURL))
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// extractProjectFromURL returns the project family of a Wikimedia server URL,
+// e.g. "wikipedia" for "https://en.wikipedia.org", or "" if unrecognised.
+func extractProjectFromURL(serverURL string) string {
+	project, _ := models.ParseWikiDBName(models.WikiFromServerURL(serverURL))
+	return project
 }
 
 // jitterOverlappingWars applies a small spiral offset to wars that share the
@@ -7,6 +7,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
 )
 
 // ErrorResponse is the legacy error envelope (kept for backward compatibility).
@@ -48,9 +51,32 @@ type SearchHit struct {
 	Wiki       string  `json:"wiki"`
 	Score      float64 `json:"score"`
 	Language   string  `json:"language,omitempty"`
+	Project    string  `json:"project,omitempty"`
 	ServerURL  string  `json:"server_url,omitempty"`
 }
 
+// alertStreams maps the alert types accepted by GET /api/alerts?type= to
+// their Redis stream (alerts:<stream>).
+var alertStreams = map[string]string{
+	"spike":               "spikes",
+	"spikes":              "spikes",
+	"edit_war":            "editwars",
+	"editwars":            "editwars",
+	"wiki_surge":          "wikisurges",
+	"wikisurges":          "wikisurges",
+	"stream_drop":         "streamdrops",
+	"streamdrops":         "streamdrops",
+	"vandalism":           "vandalism",
+	"3rr_violation":       "3rr",
+	"3rr":                 "3rr",
+	"coordinated_editing": "coordination",
+	"coordination":        "coordination",
+}
+
+// liveAlertStreams are the streams listed by GET /api/alerts and pushed to
+// /ws/alerts clients.
+var liveAlertStreams = []string{"spikes", "editwars", "wikisurges", "streamdrops", "vandalism", "3rr", "coordination"}
+
 // AlertsResponse is returned by GET /api/alerts.
 type AlertsResponse struct {
 	Alerts     []AlertEntry   `json:"alerts"`
@@ -69,14 +95,28 @@ type AlertEntry struct {
 	EditorCount  int      `json:"editor_count,omitempty"`
 	EditCount    int      `json:"edit_count,omitempty"`
 	RevertCount  int      `json:"revert_count,omitempty"`
-	Editors      []string `json:"editors,omitempty"`
+	Editors      []string `json:"editors,omitempty"` // edit_war: participants; coordinated_editing: the accounts
 	Wiki         string   `json:"wiki,omitempty"`
+	Project      string   `json:"project,omitempty"`
 	ServerURL    string   `json:"server_url,omitempty"`
+	Scope        string   `json:"scope,omitempty"`         // wiki_surge/stream_drop: global, wiki, namespace or new_pages
+	Namespace    *int     `json:"namespace,omitempty"`     // wiki_surge with namespace scope
+	Rate         float64  `json:"rate,omitempty"`          // edits/min over the detection window
+	ExpectedRate float64  `json:"expected_rate,omitempty"` // baseline edits/min
+	Reason       string   `json:"reason,omitempty"`        // stream_drop: rate_drop or stalled
+	User         string   `json:"user,omitempty"`          // vandalism, 3rr_violation: the editor
+	Confidence   float64  `json:"confidence,omitempty"`    // vandalism: 0-1; coordinated_editing: highest pair score
+	Reasons      []string `json:"reasons,omitempty"`       // vandalism: heuristics that fired
+	// Evidence of a coordinated_editing alert: the pages the accounts share
+	// and, per pair, their overlap, timing correlation and tag-team pages
+	SharedPages []string                  `json:"shared_pages,omitempty"`
+	Pairs       []storage.CoordinatedPair `json:"pairs,omitempty"`
 }
 
 // EditWarEntry is returned by GET /api/edit-wars.
 type EditWarEntry struct {
 	PageTitle   string      `json:"page_title"`
+	Wiki        string      `json:"wiki,omitempty"`
 	EditorCount int         `json:"editor_count"`
 	EditCount   int         `json:"edit_count"`
 	RevertCount int         `json:"revert_count"`
@@ -86,7 +126,25 @@ type EditWarEntry struct {
 	Editors     []string    `json:"editors"`
 	Active      bool        `json:"active"`
 	ServerURL   string      `json:"server_url,omitempty"`
+	Project     string      `json:"project,omitempty"`
 	Analysis    interface{} `json:"analysis,omitempty"`
+	// LogEvents lists protections, blocks and other admin actions on the
+	// page, newest first. These usually explain how a war ended.
+	LogEvents []models.LogEvent `json:"log_events,omitempty"`
+}
+
+// pageKey returns the war's page key.
+func (e *EditWarEntry) pageKey() models.PageKey {
+	return pageKeyFor(e.Wiki, e.PageTitle, e.ServerURL)
+}
+
+// pageKeyFor builds a page key, deriving the wiki from the server URL for
+// entries recorded before alerts carried it.
+func pageKeyFor(wiki, title, serverURL string) models.PageKey {
+	if wiki == "" {
+		wiki = models.WikiFromServerURL(serverURL)
+	}
+	return models.NewPageKey(wiki, title)
 }
 
 // ---------------------------------------------------------------------------
@@ -106,6 +164,7 @@ type GeoRegion struct {
 // GeoHotspot represents a single trending page geolocated on the map.
 type GeoHotspot struct {
 	PageTitle      string  `json:"page_title"`
+	Wiki           string  `json:"wiki,omitempty"`
 	Score          float64 `json:"score"`
 	Edits1h        int     `json:"edits_1h"`
 	Lat            float64 `json:"lat"`
@@ -119,6 +178,7 @@ type GeoHotspot struct {
 // GeoWar represents an active edit war with geographic coordinates.
 type GeoWar struct {
 	PageTitle      string      `json:"page_title"`
+	Wiki           string      `json:"wiki,omitempty"`
 	Severity       string      `json:"severity"`
 	EditorCount    int         `json:"editor_count"`
 	EditCount      int         `json:"edit_count"`
@@ -549,6 +609,25 @@ func parseTimeQuery(r *http.Request, name string, defaultVal time.Time) (time.Ti
 	return time.Unix(unix, 0), nil
 }
 
+// defaultWiki is assumed when a page-scoped request omits the 'wiki' param.
+const defaultWiki = "enwiki"
+
+// parsePageKeyQuery reads the 'page' and 'wiki' query params. The wiki
+// defaults to enwiki so title-only clients keep working. ok is false when
+// 'page' is missing.
+func parsePageKeyQuery(r *http.Request) (key models.PageKey, ok bool) {
+	q := r.URL.Query()
+	page := q.Get("page")
+	if page == "" {
+		return models.PageKey{}, false
+	}
+	wiki := q.Get("wiki")
+	if wiki == "" {
+		wiki = defaultWiki
+	}
+	return models.NewPageKey(wiki, page), true
+}
+
 // parseBoolQuery reads a boolean query param with a default value.
 func parseBoolQuery(r *http.Request, name string, defaultVal bool) bool {
 	raw := r.URL.Query().Get(name)
@@ -212,7 +212,7 @@ func TestTrendingEndpoint_Success(t *testing.T) {
 	srv, _ := newTestServer(t)
 
 	for i := 0; i < 5; i++ {
-		_ = srv.trending.IncrementScore(fmt.Sprintf("Page_%d", i), float64(10-i))
+		_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", fmt.Sprintf("Page_%d", i)), float64(10-i))
 	}
 
 	rec := doReq(srv, "GET", "/api/trending?limit=10")
@@ -227,11 +227,17 @@ func TestTrendingEndpoint_Success(t *testing.T) {
 func TestTrendingEndpoint_LanguageFilter(t *testing.T) {
 	srv, _ := newTestServer(t)
 
-	_ = srv.trending.IncrementScore("enwiki:TestPage", 10.0)
-	_ = srv.trending.IncrementScore("dewiki:DeutschePage", 8.0)
+	_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", "TestPage"), 10.0)
+	_ = srv.trending.IncrementScore(models.NewPageKey("dewiki", "DeutschePage"), 8.0)
 
 	rec := doReq(srv, "GET", "/api/trending?limit=10&language=en")
 	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var results []TrendingPageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "TestPage", results[0].Title)
+	assert.Equal(t, "enwiki", results[0].Wiki)
 }
 
 // ---------------------------------------------------------------------------
@@ -405,7 +411,7 @@ func TestGzipCompression_AcceptEncoding(t *testing.T) {
 
 	// Seed enough data to exceed 1KB threshold.
 	for i := 0; i < 50; i++ {
-		_ = srv.trending.IncrementScore(fmt.Sprintf("enwiki:LongPageTitle_%d_with_a_lot_of_text", i), float64(100-i))
+		_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", fmt.Sprintf("enwiki:LongPageTitle_%d_with_a_lot_of_text", i)), float64(100-i))
 	}
 
 	req := httptest.NewRequest("GET", "/api/trending?limit=50", nil)
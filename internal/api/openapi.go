@@ -34,6 +34,10 @@ tags:
     description: Spike and edit-war alerts
   - name: Edit Wars
     description: Edit war monitoring
+  - name: Editors
+    description: Per-editor activity profiles
+  - name: Bots
+    description: Bot runs and accounts suspected of being unflagged bots
   - name: Search
     description: Full-text search over indexed edits
   - name: WebSocket
@@ -109,7 +113,12 @@ paths:
     get:
       tags: [Trending]
       summary: Get trending pages
-      description: Returns top trending Wikipedia pages based on recent edit activity.
+      description: |
+        Returns top trending Wikipedia pages based on recent edit activity.
+        At most one of wiki, language, project and namespace may be given;
+        each selects its own leaderboard, so the full limit is returned.
+        Project and namespace leaderboards are only available when enabled
+        in the trending config.
       parameters:
         - name: limit
           in: query
@@ -119,11 +128,27 @@ paths:
             default: 20
             minimum: 1
             maximum: 100
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki, fiwiki, frwiktionary)
+          schema:
+            type: string
         - name: language
           in: query
-          description: Filter by language code (e.g. en, es, fr)
+          description: Language code across projects (e.g. en, es, fr)
           schema:
             type: string
+        - name: project
+          in: query
+          description: Project family across languages (e.g. wikipedia, wiktionary)
+          schema:
+            type: string
+        - name: namespace
+          in: query
+          description: Namespace number across wikis (e.g. 0 for articles)
+          schema:
+            type: integer
+            minimum: 0
       responses:
         '200':
           description: Successful response
@@ -147,6 +172,91 @@ paths:
         '503':
           $ref: '#/components/responses/ServiceUnavailable'
 
+  /api/trending/history:
+    get:
+      tags: [Trending]
+      summary: Trending pages at a past moment
+      description: |
+        Returns the latest snapshot of the global or a wiki's leaderboard
+        taken at or before the given time. Snapshots are taken every
+        history interval and kept for the history retention.
+      parameters:
+        - name: at
+          in: query
+          description: RFC3339 or Unix timestamp (default now)
+          schema:
+            type: string
+        - name: wiki
+          in: query
+          description: Wiki database name (default the global leaderboard)
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/TrendingSnapshot'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '404':
+          description: No snapshot at or before that time
+        '500':
+          $ref: '#/components/responses/InternalError'
+        '503':
+          $ref: '#/components/responses/ServiceUnavailable'
+
+  /api/trending/movers:
+    get:
+      tags: [Trending]
+      summary: Pages rising and falling in trending
+      description: |
+        Compares the global or a wiki's leaderboard now with its snapshot
+        from a window ago. Pages ranked in both are rising or falling,
+        biggest moves first; pages not in the snapshot are new.
+      parameters:
+        - name: window
+          in: query
+          description: How far back to compare, e.g. 30m (default the configured change_window)
+          schema:
+            type: string
+        - name: wiki
+          in: query
+          description: Wiki database name (default the global leaderboard)
+          schema:
+            type: string
+        - name: limit
+          in: query
+          description: Pages per list
+          schema:
+            type: integer
+            default: 10
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/TrendingMovers'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '404':
+          description: No snapshot that far back
+        '500':
+          $ref: '#/components/responses/InternalError'
+        '503':
+          $ref: '#/components/responses/ServiceUnavailable'
+
   /api/stats:
     get:
       tags: [Stats]
@@ -196,7 +306,7 @@ paths:
           description: Filter by alert type
           schema:
             type: string
-            enum: [spike, edit_war]
+            enum: [spike, edit_war, wiki_surge, stream_drop, vandalism, 3rr_violation, coordinated_editing]
       responses:
         '200':
           description: Successful response
@@ -242,6 +352,276 @@ paths:
         '500':
           $ref: '#/components/responses/InternalError'
 
+  /api/edit-wars/violations:
+    get:
+      tags: [Edit Wars]
+      summary: Get three-revert rule violations
+      description: |
+        Returns editors who reverted one page more often than the three-revert
+        rule allows (by default more than 3 times in 24 hours), newest first.
+        Only hot pages are tracked.
+      parameters:
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki)
+          schema:
+            type: string
+        - name: page
+          in: query
+          description: Page title; 'wiki' defaults to enwiki when set
+          schema:
+            type: string
+        - name: user
+          in: query
+          description: Only violations by this editor
+          schema:
+            type: string
+        - name: since
+          in: query
+          description: RFC3339 or Unix timestamp; defaults to 24 hours ago
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/ThreeRevertViolation'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+
+  /api/log-events:
+    get:
+      tags: [Edit Wars]
+      summary: Get log events
+      description: |
+        Returns recent MediaWiki log events (protections, blocks, deletions,
+        moves), newest first. With 'page' the page's own history is returned.
+      parameters:
+        - name: page
+          in: query
+          description: Page title; returns only this page's log events
+          schema:
+            type: string
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki). Defaults to enwiki when 'page' is set.
+          schema:
+            type: string
+        - name: type
+          in: query
+          description: Log type filter (protect, block, delete, move); ignored with 'page'
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 50
+            minimum: 1
+            maximum: 500
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/LogEvent'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+
+  /api/stories:
+    get:
+      tags: [Alerts]
+      summary: Get cross-language stories
+      description: |
+        Returns topics that spiked on several language editions at once,
+        grouped by the Wikidata item their articles are linked to, most
+        recently active first. Each story lists the spike on every wiki.
+      parameters:
+        - name: min_wikis
+          in: query
+          description: Minimum number of wikis a story must span
+          schema:
+            type: integer
+            default: 2
+            minimum: 2
+            maximum: 50
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Story'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+
+  /api/editors/top:
+    get:
+      tags: [Editors]
+      summary: Get the most active editors
+      description: |
+        Returns the non-bot editors with the most edits seen by WikiSurge,
+        on one wiki or across all wikis. Page lists are left out; fetch an
+        editor's profile for those.
+      parameters:
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki); all wikis if omitted
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/EditorProfile'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+
+  /api/editors/{wiki}/{username}:
+    get:
+      tags: [Editors]
+      summary: Get an editor's activity profile
+      description: |
+        Returns what one editor has been doing on one wiki since WikiSurge
+        first saw them. When IP pseudonymization is on, IP editors are
+        listed as ip-<hash>; looking up the address itself finds the same
+        profile.
+      parameters:
+        - name: wiki
+          in: path
+          required: true
+          description: Wiki database name (e.g. enwiki)
+          schema:
+            type: string
+        - name: username
+          in: path
+          required: true
+          description: User name (underscores read as spaces), IP address or pseudonym
+          schema:
+            type: string
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/EditorProfile'
+        '404':
+          description: No activity recorded for this editor
+        '500':
+          $ref: '#/components/responses/InternalError'
+
+  /api/bots/runs:
+    get:
+      tags: [Bots]
+      summary: Get bot runs
+      description: |
+        Returns bursts of rapid edits by one account, which the live feed
+        shows as bot_run summaries instead of individual edits. Runs still
+        in progress come first, then recently finished ones.
+      parameters:
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki); all wikis if omitted
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/BotRun'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+  /api/bots/suspected:
+    get:
+      tags: [Bots]
+      summary: Get suspected bots
+      description: |
+        Returns accounts without the bot flag whose editing cadence looks
+        automated, with the evidence from their latest run, most recently
+        flagged first.
+      parameters:
+        - name: wiki
+          in: query
+          description: Wiki database name (e.g. enwiki); all wikis if omitted
+          schema:
+            type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+            minimum: 1
+            maximum: 100
+      responses:
+        '200':
+          description: Successful response
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/SuspectedBot'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '500':
+          $ref: '#/components/responses/InternalError'
+
   /api/search:
     get:
       tags: [Search]
@@ -392,6 +772,9 @@ components:
       properties:
         title:
           type: string
+        wiki:
+          type: string
+          description: Wiki database name (e.g. enwiki); titles are only unique within a wiki
         score:
           type: number
           format: double
@@ -402,8 +785,80 @@ components:
           format: date-time
         rank:
           type: integer
+        rank_change:
+          type: integer
+          description: Places gained since change_window ago (global and per-wiki only; omitted if not ranked then)
+        peak_rank:
+          type: integer
+          description: Best rank within the history retention (global and per-wiki only)
         language:
           type: string
+        project:
+          type: string
+          description: Wikimedia project family (wikipedia, wiktionary, wikidata, ...)
+
+    TrendingSnapshot:
+      type: object
+      properties:
+        at:
+          type: string
+          format: date-time
+        wiki:
+          type: string
+          description: Empty for the global leaderboard
+        pages:
+          type: array
+          items:
+            type: object
+            properties:
+              rank:
+                type: integer
+              title:
+                type: string
+              wiki:
+                type: string
+              score:
+                type: number
+
+    TrendingMover:
+      type: object
+      properties:
+        title:
+          type: string
+        wiki:
+          type: string
+        rank:
+          type: integer
+        previous_rank:
+          type: integer
+          description: Omitted for new entries
+        rank_change:
+          type: integer
+          description: Places gained; negative when falling
+        score:
+          type: number
+
+    TrendingMovers:
+      type: object
+      properties:
+        wiki:
+          type: string
+        since:
+          type: string
+          format: date-time
+          description: When the snapshot compared against was taken
+        rising:
+          type: array
+          items:
+            $ref: '#/components/schemas/TrendingMover'
+        falling:
+          type: array
+          items:
+            $ref: '#/components/schemas/TrendingMover'
+        new:
+          type: array
+          items:
+            $ref: '#/components/schemas/TrendingMover'
 
     StatsResponse:
       type: object
@@ -423,6 +878,20 @@ components:
           type: array
           items:
             $ref: '#/components/schemas/LanguageStat'
+        projects:
+          type: array
+          items:
+            $ref: '#/components/schemas/ProjectStat'
+        bot_runs:
+          type: object
+          description: Today's bursts collapsed into bot runs
+          properties:
+            runs:
+              type: integer
+            edits:
+              type: integer
+            active:
+              type: integer
 
     LanguageStat:
       type: object
@@ -432,6 +901,16 @@ components:
         count:
           type: integer
 
+    ProjectStat:
+      type: object
+      properties:
+        project:
+          type: string
+        count:
+          type: integer
+        percentage:
+          type: number
+
     AlertsResponse:
       type: object
       properties:
@@ -449,9 +928,10 @@ components:
       properties:
         type:
           type: string
-          enum: [spike, edit_war]
+          enum: [spike, edit_war, wiki_surge, stream_drop, vandalism, 3rr_violation, coordinated_editing]
         page_title:
           type: string
+          description: Empty for wiki_surge and stream_drop, which are not about one page
         spike_ratio:
           type: number
         severity:
@@ -470,16 +950,84 @@ components:
           type: integer
         editors:
           type: array
+          description: Edit war participants, or the accounts of a coordinated_editing alert
           items:
             type: string
         wiki:
           type: string
+        scope:
+          type: string
+          enum: [global, wiki, namespace, new_pages]
+          description: What a wiki_surge or stream_drop alert is about
+        namespace:
+          type: integer
+        rate:
+          type: number
+          description: Edits per minute over the detection window
+        expected_rate:
+          type: number
+          description: Baseline edits per minute
+        reason:
+          type: string
+          enum: [rate_drop, stalled]
+        user:
+          type: string
+          description: Editor of a vandalism alert's edit, or who broke the three-revert rule
+        confidence:
+          type: number
+          description: Combined confidence of a vandalism alert, or the highest pair score of a coordinated_editing alert, 0-1
+        reasons:
+          type: array
+          description: Heuristics that fired for a vandalism alert
+          items:
+            type: string
+        shared_pages:
+          type: array
+          description: Pages shared by the accounts of a coordinated_editing alert
+          items:
+            type: string
+        pairs:
+          type: array
+          description: Evidence linking each pair of accounts of a coordinated_editing alert
+          items:
+            $ref: '#/components/schemas/CoordinatedPair'
+
+    CoordinatedPair:
+      type: object
+      description: Evidence that two accounts edit hot pages together
+      properties:
+        users:
+          type: array
+          items:
+            type: string
+        shared_pages:
+          type: array
+          items:
+            type: string
+        overlap:
+          type: number
+          description: Jaccard similarity of the hot pages each account edited, 0-1
+        timing_correlation:
+          type: number
+          description: Share of their edits to shared pages made within sync_window of the other's, 0-1
+        tag_team_pages:
+          type: array
+          description: Shared pages where both reverted the same editors
+          items:
+            type: string
+        score:
+          type: number
+        last_seen:
+          type: string
+          format: date-time
 
     EditWarEntry:
       type: object
       properties:
         page_title:
           type: string
+        wiki:
+          type: string
         editor_count:
           type: integer
         edit_count:
@@ -497,6 +1045,252 @@ components:
             type: string
         active:
           type: boolean
+        log_events:
+          type: array
+          items:
+            $ref: '#/components/schemas/LogEvent'
+
+    ThreeRevertViolation:
+      type: object
+      properties:
+        wiki:
+          type: string
+        title:
+          type: string
+        server_url:
+          type: string
+        user:
+          type: string
+          description: The editor who broke the rule
+        revert_count:
+          type: integer
+        limit:
+          type: integer
+        window_hours:
+          type: number
+        reverts:
+          type: array
+          description: The editor's reverts of the page in the window, oldest first
+          items:
+            type: object
+            properties:
+              revision_id:
+                type: integer
+              timestamp:
+                type: string
+                format: date-time
+              reverted_users:
+                type: array
+                items:
+                  type: string
+              reverted_revisions:
+                type: array
+                items:
+                  type: integer
+        severity:
+          type: string
+          enum: [high, critical]
+          description: critical when the page is also in an active edit war
+        at:
+          type: string
+          format: date-time
+
+    LogEvent:
+      type: object
+      properties:
+        log_id:
+          type: integer
+        wiki:
+          type: string
+        title:
+          type: string
+        log_type:
+          type: string
+        log_action:
+          type: string
+        user:
+          type: string
+        comment:
+          type: string
+        timestamp:
+          type: integer
+        summary:
+          type: string
+          description: Human-readable description, e.g. "page protected by Admin"
+
+    Story:
+      type: object
+      properties:
+        qid:
+          type: string
+          description: Wikidata item ID, e.g. Q42
+        title:
+          type: string
+          description: English title if the topic spiked on enwiki, else the top page's title
+        severity:
+          type: string
+        first_seen:
+          type: string
+          format: date-time
+        last_seen:
+          type: string
+          format: date-time
+        pages:
+          type: array
+          items:
+            $ref: '#/components/schemas/StoryPage'
+
+    StoryPage:
+      type: object
+      properties:
+        wiki:
+          type: string
+        title:
+          type: string
+        server_url:
+          type: string
+        spike_ratio:
+          type: number
+        edits_5min:
+          type: integer
+        severity:
+          type: string
+        spikes:
+          type: integer
+        first_spike:
+          type: string
+          format: date-time
+        last_spike:
+          type: string
+          format: date-time
+
+    BotRun:
+      type: object
+      properties:
+        id:
+          type: string
+          description: Stable for the life of the run; feed clients update the run in place
+        wiki:
+          type: string
+        user:
+          type: string
+        bot:
+          type: boolean
+          description: The account has the bot flag
+        active:
+          type: boolean
+        edits:
+          type: integer
+        pages:
+          type: integer
+          description: Distinct pages edited (HyperLogLog estimate)
+        sample_pages:
+          type: array
+          items:
+            type: string
+        top_summary:
+          type: string
+          description: Most common edit summary, with numbers replaced by #
+        top_summary_share:
+          type: number
+        edits_per_minute:
+          type: number
+        suspect_score:
+          type: number
+          description: Set when an account without the bot flag edits like a bot
+        start:
+          type: string
+          format: date-time
+        end:
+          type: string
+          format: date-time
+
+    SuspectedBot:
+      type: object
+      properties:
+        wiki:
+          type: string
+        user:
+          type: string
+        score:
+          type: number
+        reasons:
+          type: array
+          items:
+            type: string
+        edits:
+          type: integer
+        edits_per_minute:
+          type: number
+        interval_cv:
+          type: number
+          description: Coefficient of variation of the gaps between edits; -1 if too few edits
+        summary_share:
+          type: number
+        first_flagged:
+          type: string
+          format: date-time
+        last_flagged:
+          type: string
+          format: date-time
+
+    EditorProfile:
+      type: object
+      properties:
+        wiki:
+          type: string
+        user:
+          type: string
+          description: User name, or ip-<hash> for IP editors when pseudonymization is on
+        anonymous:
+          type: boolean
+          description: Logged-out (IP) editor
+        bot:
+          type: boolean
+        edits:
+          type: integer
+        new_pages:
+          type: integer
+        pages_touched:
+          type: integer
+          description: Distinct pages edited (HyperLogLog estimate)
+        bytes_added:
+          type: integer
+        bytes_removed:
+          type: integer
+        reverts_given:
+          type: integer
+          description: Edits whose summary marks them as a revert of someone else
+        reverts_received:
+          type: integer
+        edit_wars:
+          type: integer
+          description: Pages the editor edited during an edit war on them
+        edit_war_pages:
+          type: array
+          items:
+            type: string
+        edits_by_wiki:
+          type: object
+          additionalProperties:
+            type: integer
+          description: The account's edits on every wiki
+        recent_pages:
+          type: array
+          items:
+            type: object
+            properties:
+              title:
+                type: string
+              last_edit:
+                type: string
+                format: date-time
+        first_seen:
+          type: string
+          format: date-time
+        last_seen:
+          type: string
+          format: date-time
 
     SearchResponse:
       type: object
@@ -532,6 +1326,9 @@ components:
           type: number
         language:
           type: string
+        project:
+          type: string
+          description: Wikimedia project family (wikipedia, wiktionary, wikidata, ...)
 
     Pagination:
       type: object
@@ -588,7 +1385,7 @@ components:
       properties:
         type:
           type: string
-          enum: [edit, spike, edit_war]
+          enum: [edit, spike, edit_war, wiki_surge, stream_drop, vandalism, 3rr_violation, coordinated_editing]
         data:
           type: object
           description: Edit or alert payload
@@ -1056,7 +1056,7 @@ func TestConcurrentTrendingRequests(t *testing.T) {
 
 	// Seed some data.
 	for i := 0; i < 10; i++ {
-		_ = srv.trending.IncrementScore(fmt.Sprintf("ConcPage_%d", i), float64(10-i))
+		_ = srv.trending.IncrementScore(models.NewPageKey("enwiki", fmt.Sprintf("ConcPage_%d", i)), float64(10-i))
 	}
 
 	var wg sync.WaitGroup
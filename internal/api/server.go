@@ -26,6 +26,11 @@ type APIServer struct {
 	hotPages       *storage.HotPageTracker
 	alerts         *storage.RedisAlerts
 	statsTracker   *storage.StatsTracker
+	logEvents      *storage.LogEventStore
+	stories        *storage.StoryStore
+	editors        *storage.EditorStore
+	botRuns        *storage.BotRunStore
+	trendHistory   *storage.TrendingHistory
 	config         *config.Config
 	logger         zerolog.Logger
 	startTime      time.Time
@@ -36,6 +41,7 @@ type APIServer struct {
 	analysisService *llm.AnalysisService
 	userStore       *storage.UserStore
 	jwtService      *auth.JWTService
+	dlq             dlqService
 	version        string
 
 	// Edit relay cancellation
@@ -68,6 +74,11 @@ func NewAPIServer(
 		hotPages:     hotPages,
 		alerts:       alerts,
 		statsTracker: storage.NewStatsTracker(redisClient),
+		logEvents:    storage.NewLogEventStore(redisClient),
+		stories:      storage.NewStoryStore(redisClient, cfg.Processor.Stories.Window),
+		editors:      storage.NewEditorStore(redisClient, cfg.Processor.Editors),
+		botRuns:      storage.NewBotRunStore(redisClient, cfg.Processor.BotRuns),
+		trendHistory: storage.NewTrendingHistory(redisClient, cfg.Redis.Trending.History),
 		config:       cfg,
 		logger:       logger.With().Str("component", "api").Logger(),
 		startTime:    time.Now(),
@@ -124,11 +135,20 @@ func (s *APIServer) setupRoutes() {
 
 	// API routes
 	s.router.HandleFunc("GET /api/trending", s.handleGetTrending)
+	s.router.HandleFunc("GET /api/trending/history", s.handleGetTrendingHistory)
+	s.router.HandleFunc("GET /api/trending/movers", s.handleGetTrendingMovers)
 	s.router.HandleFunc("GET /api/stats", s.handleGetStats)
 	s.router.HandleFunc("GET /api/alerts", s.handleGetAlerts)
 	s.router.HandleFunc("GET /api/edit-wars", s.handleGetEditWars)
 	s.router.HandleFunc("GET /api/edit-wars/analysis", s.handleGetEditWarAnalysis)
 	s.router.HandleFunc("GET /api/edit-wars/timeline", s.handleGetEditWarTimeline)
+	s.router.HandleFunc("GET /api/edit-wars/violations", s.handleGetEditWarViolations)
+	s.router.HandleFunc("GET /api/log-events", s.handleGetLogEvents)
+	s.router.HandleFunc("GET /api/stories", s.handleGetStories)
+	s.router.HandleFunc("GET /api/editors/top", s.handleGetTopEditors)
+	s.router.HandleFunc("GET /api/editors/{wiki}/{username}", s.handleGetEditor)
+	s.router.HandleFunc("GET /api/bots/runs", s.handleGetBotRuns)
+	s.router.HandleFunc("GET /api/bots/suspected", s.handleGetSuspectedBots)
 	s.router.HandleFunc("GET /api/timeline", s.handleGetTimeline)
 	s.router.HandleFunc("GET /api/search", s.handleSearch)
 	s.router.HandleFunc("GET /api/geo-activity", s.handleGetGeoActivity)
@@ -160,11 +180,18 @@ func (s *APIServer) setupRoutes() {
 		s.router.Handle("PUT /api/user/preferences", authMw(http.HandlerFunc(s.handleUpdatePreferences)))
 		s.router.Handle("GET /api/user/watchlist", authMw(http.HandlerFunc(s.handleGetWatchlist)))
 		s.router.Handle("PUT /api/user/watchlist", authMw(http.HandlerFunc(s.handleUpdateWatchlist)))
+		s.router.Handle("GET /api/user/rules", authMw(http.HandlerFunc(s.handleListAlertRules)))
+		s.router.Handle("POST /api/user/rules", authMw(http.HandlerFunc(s.handleCreateAlertRule)))
+		s.router.Handle("PUT /api/user/rules/{id}", authMw(http.HandlerFunc(s.handleUpdateAlertRule)))
+		s.router.Handle("DELETE /api/user/rules/{id}", authMw(http.HandlerFunc(s.handleDeleteAlertRule)))
+		s.router.Handle("GET /api/user/alerts", authMw(http.HandlerFunc(s.handleGetUserAlerts)))
 
 		// Admin routes (protected by admin middleware — requires is_admin claim)
 		adminMw := auth.AdminMiddleware(s.jwtService)
 		s.router.Handle("GET /api/admin/users", adminMw(http.HandlerFunc(s.handleAdminListUsers)))
 		s.router.Handle("DELETE /api/admin/users/{id}", adminMw(http.HandlerFunc(s.handleAdminDeleteUser)))
+		s.router.Handle("GET /api/admin/dlq", adminMw(http.HandlerFunc(s.handleAdminListDLQ)))
+		s.router.Handle("POST /api/admin/dlq/replay", adminMw(http.HandlerFunc(s.handleAdminReplayDLQ)))
 	}
 }
 
@@ -248,12 +275,21 @@ func (s *APIServer) startEditRelayWithRestart(redisClient *redis.Client, restart
 				s.startEditRelayWithRestart(redisClient, restartCount+1)
 			}
 		}()
-		sub := redisClient.Subscribe(ctx, "wikisurge:edits:live")
+		sub := redisClient.Subscribe(ctx, "wikisurge:edits:live", "wikisurge:botruns:live")
 		defer sub.Close()
 		ch := sub.Channel()
 		s.logger.Info().Msg("Edit relay started — subscribing to Redis pub/sub for live edits")
 
 		for msg := range ch {
+			if msg.Channel == "wikisurge:botruns:live" {
+				var run storage.BotRun
+				if err := json.Unmarshal([]byte(msg.Payload), &run); err != nil {
+					s.logger.Warn().Err(err).Msg("Failed to unmarshal relayed bot run")
+					continue
+				}
+				s.wsHub.BroadcastBotRun(&run)
+				continue
+			}
 			var edit models.WikipediaEdit
 			if err := json.Unmarshal([]byte(msg.Payload), &edit); err != nil {
 				s.logger.Warn().Err(err).Msg("Failed to unmarshal relayed edit")
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+)
+
+// handleGetTrendingHistory returns a leaderboard as it was at a past moment:
+// the latest snapshot taken at or before it.
+// Query params:
+// - at: RFC3339 or Unix timestamp (optional, default: the latest snapshot)
+// - wiki: a wiki's leaderboard (optional, default: the global one)
+// - limit: number of pages (optional, default: 20, max: 100)
+func (s *APIServer) handleGetTrendingHistory(w http.ResponseWriter, r *http.Request) {
+	params, verr := ParseTrendingHistoryParams(r, 20)
+	if verr != nil {
+		writeValidationError(w, r, verr)
+		return
+	}
+	if !s.config.Redis.Trending.History.Enabled {
+		writeAPIError(w, r, http.StatusServiceUnavailable,
+			"Trending history not enabled", ErrCodeServiceUnavailable, "")
+		return
+	}
+
+	ctx := r.Context()
+	snap, err := s.trendHistory.At(ctx, params.Wiki, params.At)
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to get trending snapshot")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve trending history", ErrCodeInternalError, "")
+		return
+	}
+	if snap == nil {
+		writeAPIError(w, r, http.StatusNotFound,
+			"No trending snapshot at or before that time", ErrCodeNotFound, "field: at")
+		return
+	}
+	if len(snap.Pages) > params.Limit {
+		snap.Pages = snap.Pages[:params.Limit]
+	}
+
+	respondJSON(w, http.StatusOK, snap)
+}
+
+// handleGetTrendingMovers compares a leaderboard now with its snapshot from
+// a window before the latest one: the pages that rose, fell or are new to it.
+// Query params:
+// - window: how far back to compare, e.g. 30m (optional, default: change_window)
+// - wiki: a wiki's leaderboard (optional, default: the global one)
+// - limit: pages per list (optional, default: 10, max: 100)
+func (s *APIServer) handleGetTrendingMovers(w http.ResponseWriter, r *http.Request) {
+	params, verr := ParseTrendingHistoryParams(r, 10)
+	if verr != nil {
+		writeValidationError(w, r, verr)
+		return
+	}
+	cfg := s.config.Redis.Trending.History
+	if s.trending == nil || !cfg.Enabled {
+		writeAPIError(w, r, http.StatusServiceUnavailable,
+			"Trending history not enabled", ErrCodeServiceUnavailable, "")
+		return
+	}
+	if params.Window == 0 {
+		params.Window = cfg.ChangeWindow
+	}
+
+	ctx := r.Context()
+	snap, err := s.trendHistoryBefore(ctx, params.Wiki, params.Window)
+	if err == nil && snap == nil {
+		writeAPIError(w, r, http.StatusNotFound,
+			"No trending snapshot that far back", ErrCodeNotFound, "field: window")
+		return
+	}
+	var current []*storage.TrendingEntry
+	if err == nil {
+		// Snapshots hold the top N, so compare against as many
+		current, err = s.trending.GetTopTrendingIn(storage.TrendingScope{Wiki: params.Wiki}, cfg.TopN)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("request_id", GetRequestID(ctx)).
+			Msg("Failed to compare trending pages")
+		writeAPIError(w, r, http.StatusInternalServerError,
+			"Failed to retrieve trending movers", ErrCodeInternalError, "")
+		return
+	}
+
+	movers := storage.CompareTrending(snap, current)
+	movers.Rising = firstMovers(movers.Rising, params.Limit)
+	movers.Falling = firstMovers(movers.Falling, params.Limit)
+	movers.New = firstMovers(movers.New, params.Limit)
+
+	respondJSON(w, http.StatusOK, movers)
+}
+
+// trendHistoryBefore returns the snapshot of a leaderboard taken window
+// before its latest one, or nil if there is none that far back. Going by
+// the snapshots' own times keeps the comparison right when the aggregator's
+// event clock is behind the wall clock.
+func (s *APIServer) trendHistoryBefore(ctx context.Context, wiki string, window time.Duration) (*storage.TrendingSnapshot, error) {
+	latest, err := s.trendHistory.At(ctx, wiki, time.Time{})
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	return s.trendHistory.At(ctx, wiki, latest.At.Add(-window))
+}
+
+func firstMovers(m []storage.TrendingMover, n int) []storage.TrendingMover {
+	if len(m) > n {
+		return m[:n]
+	}
+	return m
+}
+
+// addRankHistory fills in rank_change and peak_rank on a global or per-wiki
+// leaderboard from the trending history. They are extras: if the history
+// cannot be read, the leaderboard is returned without them.
+func (s *APIServer) addRankHistory(r *http.Request, wiki string, results []TrendingPageResponse) {
+	cfg := s.config.Redis.Trending.History
+	if !cfg.Enabled || len(results) == 0 {
+		return
+	}
+
+	ctx := r.Context()
+	// Changes are measured back from the latest snapshot, see trendHistoryBefore
+	latest, err := s.trendHistory.At(ctx, wiki, time.Time{})
+	var prev *storage.TrendingSnapshot
+	var peaks map[models.PageKey]int
+	if err == nil && latest != nil {
+		prev, err = s.trendHistory.At(ctx, wiki, latest.At.Add(-cfg.ChangeWindow))
+		if err == nil {
+			peaks, err = s.trendHistory.PeakRanks(ctx, wiki, latest.At)
+		}
+	}
+	if err != nil {
+		s.logger.Warn().Err(err).Str("request_id", GetRequestID(ctx)).Msg("Failed to read trending history")
+		return
+	}
+
+	var before map[models.PageKey]int
+	if prev != nil {
+		before = prev.Ranks()
+	}
+	for i := range results {
+		res := &results[i]
+		key := models.NewPageKey(res.Wiki, res.Title)
+		if rank, ok := before[key]; ok {
+			change := rank - res.Rank
+			res.RankChange = &change
+		}
+		peak := res.Rank
+		if p, ok := peaks[key]; ok && p < peak {
+			peak = p
+		}
+		res.PeakRank = &peak
+	}
+}
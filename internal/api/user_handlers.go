@@ -365,6 +365,12 @@ func (s *APIServer) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request
 		writeAPIError(w, r, http.StatusNotFound, "User not found", "USER_NOT_FOUND", "")
 		return
 	}
+	if s.alerts != nil {
+		// The stream would otherwise linger until it expires
+		if err := s.alerts.DeleteUserAlerts(r.Context(), targetID); err != nil {
+			s.logger.Warn().Err(err).Str("target_id", targetID).Msg("admin: failed to delete user's alerts")
+		}
+	}
 
 	s.logger.Info().Str("admin_id", callerID).Str("deleted_id", targetID).Msg("Admin deleted user")
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
@@ -2,11 +2,13 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,13 +57,14 @@ func setupUserTestServer(t *testing.T) (*APIServer, *storage.UserStore) {
 				Enabled: true, MaxPages: 100, HalfLifeMinutes: 30, PruneInterval: 5 * time.Minute,
 			},
 		},
-		API:     config.API{Port: 8080, RateLimit: 10000},
-		Logging: config.Logging{Level: "error", Format: "json"},
-		Email:   config.EmailConfig{DashboardURL: "http://localhost:5173"},
+		API:       config.API{Port: 8080, RateLimit: 10000},
+		Processor: config.Processor{AlertRules: config.AlertRulesConfig{MaxRulesPerUser: 3}},
+		Logging:   config.Logging{Level: "error", Format: "json"},
+		Email:     config.EmailConfig{DashboardURL: "http://localhost:5173"},
 	}
 
 	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
-	srv := NewAPIServer(rc, nil, nil, nil, nil, userStore, jwtSvc, cfg, logger)
+	srv := NewAPIServer(rc, nil, nil, nil, storage.NewRedisAlerts(rc), userStore, jwtSvc, cfg, logger)
 
 	return srv, userStore
 }
@@ -476,7 +479,7 @@ func setupAdminTestServer(t *testing.T, adminEmail string) (*APIServer, *storage
 	}
 
 	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
-	srv := NewAPIServer(rc, nil, nil, nil, nil, userStore, jwtSvc, cfg, logger)
+	srv := NewAPIServer(rc, nil, nil, nil, storage.NewRedisAlerts(rc), userStore, jwtSvc, cfg, logger)
 
 	return srv, userStore
 }
@@ -629,6 +632,10 @@ func TestAdminDeleteUser_Success(t *testing.T) {
 	}, "")
 
 	victim, _ := userStore.GetUserByEmail("victim@example.com")
+	ctx := context.Background()
+	if err := srv.alerts.PublishRuleAlert(ctx, storage.RuleMatch{UserID: victim.ID, At: time.Now()}); err != nil {
+		t.Fatalf("PublishRuleAlert: %v", err)
+	}
 
 	// Delete the user
 	rec = doJSON(srv, "DELETE", "/api/admin/users/"+victim.ID, nil, adminToken)
@@ -641,6 +648,9 @@ func TestAdminDeleteUser_Success(t *testing.T) {
 	if gone != nil {
 		t.Error("user should be deleted from database")
 	}
+	if n, _ := srv.redis.Exists(ctx, "alerts:user:"+victim.ID).Result(); n != 0 {
+		t.Error("user's alert stream should be deleted")
+	}
 }
 
 func TestAdminDeleteUser_CannotDeleteSelf(t *testing.T) {
@@ -692,3 +702,135 @@ func TestAdminDeleteUser_NotFound(t *testing.T) {
 		t.Errorf("delete nonexistent status = %d, want 404", rec.Code)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Alert rules
+// ---------------------------------------------------------------------------
+
+func TestAlertRules_CRUD(t *testing.T) {
+	srv, _ := setupUserTestServer(t)
+	token := registerAndLogin(t, srv, "analyst@example.com", "password1234")
+
+	rec := doJSON(srv, "POST", "/api/user/rules", map[string]interface{}{
+		"name":       "Elections",
+		"expression": `wiki = enwiki and title ~ "^2026 .* election" and edits(10m) > 20 and editors(10m) > 3`,
+	}, token)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST rule: status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	created := decodeJSON(t, rec)
+	id := created["id"].(string)
+	if created["enabled"] != true {
+		t.Errorf("enabled = %v, want true by default", created["enabled"])
+	}
+
+	rec = doJSON(srv, "PUT", "/api/user/rules/"+id, map[string]interface{}{
+		"name": "Blanking", "expression": "watched and bytes < -5000", "enabled": false,
+	}, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT rule: status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doJSON(srv, "GET", "/api/user/rules", nil, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET rules: status = %d", rec.Code)
+	}
+	body := decodeJSON(t, rec)
+	rules := body["rules"].([]interface{})
+	if len(rules) != 1 || body["limit"].(float64) != 3 {
+		t.Fatalf("GET rules = %v, want 1 rule and a limit of 3", body)
+	}
+	rule := rules[0].(map[string]interface{})
+	if rule["name"] != "Blanking" || rule["expression"] != "watched and bytes < -5000" || rule["enabled"] != false {
+		t.Errorf("rule = %v, want the updated, disabled rule", rule)
+	}
+
+	// Rules belong to their owner
+	other := registerAndLogin(t, srv, "other@example.com", "password1234")
+	if rec = doJSON(srv, "DELETE", "/api/user/rules/"+id, nil, other); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE another user's rule: status = %d, want 404", rec.Code)
+	}
+
+	if rec = doJSON(srv, "DELETE", "/api/user/rules/"+id, nil, token); rec.Code != http.StatusOK {
+		t.Errorf("DELETE rule: status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if rec = doJSON(srv, "PUT", "/api/user/rules/"+id, map[string]interface{}{
+		"name": "Gone", "expression": "new",
+	}, token); rec.Code != http.StatusNotFound {
+		t.Errorf("PUT deleted rule: status = %d, want 404", rec.Code)
+	}
+
+	if rec = doJSON(srv, "GET", "/api/user/rules", nil, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET rules without token: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestAlertRules_Validation(t *testing.T) {
+	srv, _ := setupUserTestServer(t)
+	token := registerAndLogin(t, srv, "rules@example.com", "password1234")
+
+	for _, tt := range []struct {
+		name, expression, wantMsg string
+	}{
+		{"", "new", "Rule name"},
+		{"Typo", "edits(10m) > 20 or new", `Invalid rule: expected "and" after clause 1, got "or"`},
+		{"Regex", `title ~ "(election"`, "Invalid rule: clause 1: invalid title pattern"},
+	} {
+		rec := doJSON(srv, "POST", "/api/user/rules", map[string]string{
+			"name": tt.name, "expression": tt.expression,
+		}, token)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %q: status = %d, want 400", tt.expression, rec.Code)
+			continue
+		}
+		if msg := decodeJSON(t, rec)["error"].(map[string]interface{})["message"].(string); !strings.HasPrefix(msg, tt.wantMsg) {
+			t.Errorf("POST %q: message = %q, want prefix %q", tt.expression, msg, tt.wantMsg)
+		}
+	}
+
+	// max_rules_per_user is 3
+	for i := 0; i < 3; i++ {
+		rec := doJSON(srv, "POST", "/api/user/rules", map[string]string{"name": "New pages", "expression": "new"}, token)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST rule %d: status = %d", i, rec.Code)
+		}
+	}
+	rec := doJSON(srv, "POST", "/api/user/rules", map[string]string{"name": "One more", "expression": "new"}, token)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST past the limit: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestGetUserAlerts(t *testing.T) {
+	srv, store := setupUserTestServer(t)
+	token := registerAndLogin(t, srv, "alerts@example.com", "password1234")
+	user, _ := store.GetUserByEmail("alerts@example.com")
+
+	err := srv.alerts.PublishRuleAlert(context.Background(), storage.RuleMatch{
+		UserID: user.ID, RuleID: "r1", RuleName: "Blanking", Wiki: "enwiki", Title: "Paris",
+		ByteChange: -8000, At: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("PublishRuleAlert: %v", err)
+	}
+
+	rec := doJSON(srv, "GET", "/api/user/alerts", nil, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET alerts: status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	alerts := decodeJSON(t, rec)["alerts"].([]interface{})
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %v, want 1", alerts)
+	}
+	data := alerts[0].(map[string]interface{})["data"].(map[string]interface{})
+	if data["rule_name"] != "Blanking" || data["title"] != "Paris" {
+		t.Errorf("alert data = %v", data)
+	}
+
+	// Nobody else sees them
+	other := registerAndLogin(t, srv, "other@example.com", "password1234")
+	rec = doJSON(srv, "GET", "/api/user/alerts", nil, other)
+	if n := decodeJSON(t, rec)["count"].(float64); n != 0 {
+		t.Errorf("other user's count = %v, want 0", n)
+	}
+}
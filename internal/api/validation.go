@@ -3,9 +3,12 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
 )
 
 // ---------------------------------------------------------------------------
@@ -95,10 +98,14 @@ func ValidateSearchParams(params SearchParams) *ValidationError {
 // Trending parameter validation
 // ---------------------------------------------------------------------------
 
-// TrendingParams holds parsed trending parameters.
+// TrendingParams holds parsed trending parameters. At most one of Wiki,
+// Language, Project and Namespace is set, selecting that leaderboard.
 type TrendingParams struct {
-	Limit    int
-	Language string
+	Limit     int
+	Wiki      string
+	Language  string
+	Project   string
+	Namespace *int
 }
 
 // ParseTrendingParams extracts trending parameters from the request.
@@ -111,10 +118,115 @@ func ParseTrendingParams(r *http.Request) (TrendingParams, *ValidationError) {
 			Code:    ErrCodeInvalidParameter,
 		}
 	}
-	return TrendingParams{
+
+	q := r.URL.Query()
+	params := TrendingParams{
 		Limit:    limit,
-		Language: r.URL.Query().Get("language"),
-	}, nil
+		Wiki:     q.Get("wiki"),
+		Language: q.Get("language"),
+		Project:  q.Get("project"),
+	}
+
+	scopes := 0
+	if params.Wiki != "" {
+		scopes++
+		if project, _ := models.ParseWikiDBName(params.Wiki); project == "" {
+			return TrendingParams{}, &ValidationError{
+				Field:   "wiki",
+				Message: fmt.Sprintf("unknown wiki '%s'", params.Wiki),
+				Code:    ErrCodeInvalidParameter,
+			}
+		}
+	}
+	if params.Language != "" {
+		scopes++
+	}
+	if params.Project != "" {
+		scopes++
+		if !slices.Contains(models.KnownProjects, params.Project) {
+			return TrendingParams{}, &ValidationError{
+				Field:   "project",
+				Message: fmt.Sprintf("unknown project '%s'", params.Project),
+				Code:    ErrCodeInvalidParameter,
+			}
+		}
+	}
+	if raw := q.Get("namespace"); raw != "" {
+		scopes++
+		ns, err := strconv.Atoi(raw)
+		if err != nil || ns < 0 {
+			return TrendingParams{}, &ValidationError{
+				Field:   "namespace",
+				Message: "namespace must be a non-negative integer",
+				Code:    ErrCodeInvalidParameter,
+			}
+		}
+		params.Namespace = &ns
+	}
+	if scopes > 1 {
+		return TrendingParams{}, &ValidationError{
+			Field:   "wiki",
+			Message: "only one of wiki, language, project and namespace may be given",
+			Code:    ErrCodeInvalidParameter,
+		}
+	}
+	return params, nil
+}
+
+// TrendingHistoryParams holds parsed parameters of the trending history
+// and movers endpoints. Window is zero unless given.
+type TrendingHistoryParams struct {
+	Wiki   string
+	At     time.Time
+	Window time.Duration
+	Limit  int
+}
+
+// ParseTrendingHistoryParams extracts trending history parameters from the
+// request. History is kept for the global and per-wiki leaderboards only.
+func ParseTrendingHistoryParams(r *http.Request, defaultLimit int) (TrendingHistoryParams, *ValidationError) {
+	limit, err := parseIntQuery(r, "limit", defaultLimit, 100)
+	if err != nil || limit < 1 {
+		return TrendingHistoryParams{}, &ValidationError{
+			Field:   "limit",
+			Message: "limit must be an integer between 1 and 100",
+			Code:    ErrCodeInvalidParameter,
+		}
+	}
+
+	q := r.URL.Query()
+	params := TrendingHistoryParams{Wiki: q.Get("wiki"), Limit: limit}
+	if params.Wiki != "" {
+		if project, _ := models.ParseWikiDBName(params.Wiki); project == "" {
+			return TrendingHistoryParams{}, &ValidationError{
+				Field:   "wiki",
+				Message: fmt.Sprintf("unknown wiki '%s'", params.Wiki),
+				Code:    ErrCodeInvalidParameter,
+			}
+		}
+	}
+
+	// The default, zero, is the latest snapshot
+	params.At, err = parseTimeQuery(r, "at", time.Time{})
+	if err != nil {
+		return TrendingHistoryParams{}, &ValidationError{
+			Field:   "at",
+			Message: "at must be RFC3339 or Unix timestamp",
+			Code:    ErrCodeInvalidParameter,
+		}
+	}
+
+	if raw := q.Get("window"); raw != "" {
+		params.Window, err = time.ParseDuration(raw)
+		if err != nil || params.Window <= 0 {
+			return TrendingHistoryParams{}, &ValidationError{
+				Field:   "window",
+				Message: "window must be a positive duration such as 30m or 6h",
+				Code:    ErrCodeInvalidParameter,
+			}
+		}
+	}
+	return params, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -213,11 +325,10 @@ func ValidateSeverity(severity string) *ValidationError {
 
 // ValidateAlertType checks for valid alert types.
 func ValidateAlertType(alertType string) *ValidationError {
-	valid := map[string]bool{"spike": true, "edit_war": true, "spikes": true, "editwars": true}
-	if !valid[strings.ToLower(alertType)] {
+	if _, ok := alertStreams[strings.ToLower(alertType)]; !ok {
 		return &ValidationError{
 			Field:   "type",
-			Message: fmt.Sprintf("invalid alert type '%s'; valid types: spike, edit_war", alertType),
+			Message: fmt.Sprintf("invalid alert type '%s'; valid types: spike, edit_war, wiki_surge, stream_drop, vandalism, 3rr_violation, coordinated_editing", alertType),
 			Code:    ErrCodeInvalidParameter,
 		}
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Agnikulu/WikiSurge/internal/metrics"
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
@@ -129,6 +130,40 @@ func (f *EditFilter) Matches(edit *models.WikipediaEdit) bool {
 	return true
 }
 
+// MatchesRun returns true if a bot run summary passes the filter. A run
+// stands in for many edits, so it matches a page pattern if any of its
+// sample pages does; the byte change filter does not apply.
+func (f *EditFilter) MatchesRun(run *storage.BotRun) bool {
+	if f.ExcludeBots && run.Bot {
+		return false
+	}
+
+	if len(f.Languages) > 0 {
+		_, lang := models.ParseWikiDBName(run.Wiki)
+		found := false
+		for _, l := range f.Languages {
+			if strings.EqualFold(l, lang) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.compiledPattern != nil {
+		for _, title := range run.SamplePages {
+			if f.compiledPattern.MatchString(title) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
 // ---------------------------------------------------------------------------
 // Client — a single WebSocket connection
 // ---------------------------------------------------------------------------
@@ -527,6 +562,7 @@ func (h *WebSocketHub) BroadcastAlert(alertType string, data interface{}) {
 // ---------------------------------------------------------------------------
 
 // WebSocketFeed upgrades an HTTP connection to WebSocket and streams edits.
+// Bursts of edits by one account arrive as "bot_run" summaries instead.
 //
 // Query parameters:
 //
@@ -647,6 +683,34 @@ func (h *WebSocketHub) BroadcastEditFiltered(edit *models.WikipediaEdit) {
 	}
 }
 
+// BroadcastBotRun sends a bot_run summary, which replaces the run's
+// individual edits in the feed, to clients whose filter matches.
+func (h *WebSocketHub) BroadcastBotRun(run *storage.BotRun) {
+	msg := WSMessage{
+		Type: "bot_run",
+		Data: run,
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to marshal bot run for filtered broadcast")
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.filter != nil && !client.filter.MatchesRun(run) {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			h.logger.Warn().Str("client", client.id).Msg("Slow client during bot run broadcast")
+		}
+	}
+}
+
 // absInt returns the absolute value of n.
 func absInt(n int) int {
 	return int(math.Abs(float64(n)))
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
@@ -155,6 +156,20 @@ func TestEditFilter_CombinedFilters(t *testing.T) {
 	assert.False(t, f.Matches(small))
 }
 
+func TestEditFilter_MatchesRun(t *testing.T) {
+	run := &storage.BotRun{Wiki: "enwiki", User: "SweepBot", Bot: true, SamplePages: []string{"Foo", "Go (programming language)"}}
+
+	assert.True(t, (&EditFilter{MinByteChange: 500}).MatchesRun(run), "byte change does not apply to runs")
+	assert.False(t, (&EditFilter{ExcludeBots: true}).MatchesRun(run))
+	assert.True(t, (&EditFilter{Languages: []string{"EN"}}).MatchesRun(run))
+	assert.False(t, (&EditFilter{Languages: []string{"fr"}}).MatchesRun(run))
+
+	pattern := regexp.MustCompile("^Go.*language")
+	assert.True(t, (&EditFilter{compiledPattern: pattern}).MatchesRun(run), "any sample page may match")
+	run.SamplePages = []string{"Foo"}
+	assert.False(t, (&EditFilter{compiledPattern: pattern}).MatchesRun(run))
+}
+
 // ---------------------------------------------------------------------------
 // Hub lifecycle tests
 // ---------------------------------------------------------------------------
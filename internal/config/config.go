@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +19,7 @@ type Config struct {
 	Elasticsearch Elasticsearch `yaml:"elasticsearch"`
 	Redis         Redis         `yaml:"redis"`
 	Kafka         Kafka         `yaml:"kafka"`
+	Processor     Processor     `yaml:"processor"`
 	API           API           `yaml:"api"`
 	LLM           LLMConfig     `yaml:"llm"`
 	Auth          AuthConfig    `yaml:"auth"`
@@ -81,12 +84,53 @@ type Features struct {
 type Ingestor struct {
 	ExcludeBots       bool     `yaml:"exclude_bots"`
 	AllowedLanguages  []string `yaml:"allowed_languages"`
+	AllowedProjects   []string `yaml:"allowed_projects"` // Project families (wikipedia, wiktionary, wikidata, ...). Empty = all
 	AllowedNamespaces []int    `yaml:"allowed_namespaces"` // Wikipedia namespaces: 0=Main, 1=Talk, 2=User, etc. Empty = all
 	RateLimit         int      `yaml:"rate_limit"`
 	BurstLimit        int      `yaml:"burst_limit"`
 	ReconnectDelay    time.Duration `yaml:"reconnect_delay"`
 	MaxReconnectDelay time.Duration `yaml:"max_reconnect_delay"`
 	MetricsPort       int      `yaml:"metrics_port"`
+	Checkpoint        CheckpointConfig `yaml:"checkpoint"`
+	Source            SourceConfig     `yaml:"source"`
+	Capture           CaptureConfig    `yaml:"capture"`
+	LogEvents         LogEventsConfig  `yaml:"log_events"`
+}
+
+// LogEventsConfig controls forwarding of MediaWiki log events (protections,
+// blocks, deletions, moves, account creations) to their own Kafka topic.
+type LogEventsConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Types   []string `yaml:"types"` // log_type allowlist; empty = all
+}
+
+// CaptureConfig controls archiving of raw SSE payloads for later replay.
+type CaptureConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Dir            string        `yaml:"dir"`             // Archive directory (also holds index.json)
+	RotateInterval time.Duration `yaml:"rotate_interval"` // A new file is started every interval
+	Retention      time.Duration `yaml:"retention"`       // Files whose newest event is older are deleted
+}
+
+// SourceConfig selects where the ingestor reads raw events from.
+type SourceConfig struct {
+	Type  string    `yaml:"type"`  // "eventstreams" (default), "sse" or "replay"
+	URL   string    `yaml:"url"`   // sse: endpoint speaking the EventStreams protocol
+	Path  string    `yaml:"path"`  // replay: NDJSON file (plain or gzip), glob, or capture directory
+	Speed float64   `yaml:"speed"` // replay: multiplier over recorded pace; 0 = as fast as possible
+	From  time.Time `yaml:"from"`  // replay: skip events before this time (optional)
+	To    time.Time `yaml:"to"`    // replay: stop at events after this time (optional)
+}
+
+// CheckpointConfig controls durable persistence of the last SSE event ID so a
+// restarted ingestor can resume the stream via Last-Event-ID.
+type CheckpointConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Backend  string        `yaml:"backend"`   // "file" or "redis"
+	Path     string        `yaml:"path"`      // File backend: checkpoint file location
+	RedisKey string        `yaml:"redis_key"` // Redis backend: key holding the checkpoint
+	Interval time.Duration `yaml:"interval"`  // How often the checkpoint is flushed
+	MaxAge   time.Duration `yaml:"max_age"`   // Older checkpoints are discarded (replay horizon)
 }
 
 // Elasticsearch configuration
@@ -113,6 +157,18 @@ type Redis struct {
 	EvictionPolicy string       `yaml:"eviction_policy"`
 	HotPages      HotPages      `yaml:"hot_pages"`
 	Trending      TrendingConfig `yaml:"trending"`
+	// LegacyWiki is the wiki assumed for per-page state written before keys
+	// were wiki-qualified, when the page's wiki cannot be inferred, and for
+	// digest watchlist entries given as a bare title.
+	LegacyWiki string `yaml:"legacy_wiki"`
+	Dedup      DedupConfig `yaml:"dedup"`
+}
+
+// DedupConfig controls how long processors remember which revisions they have
+// already handled, so Kafka redelivery and stream replays are not counted twice.
+type DedupConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"` // How long a (wiki, revision) stays marked as seen
 }
 
 // HotPages configuration for tracking hot pages
@@ -127,10 +183,67 @@ type HotPages struct {
 
 // TrendingConfig for trending page functionality
 type TrendingConfig struct {
-	Enabled          bool          `yaml:"enabled"`
-	MaxPages         int           `yaml:"max_pages"`
-	HalfLifeMinutes  float64       `yaml:"half_life_minutes"`
-	PruneInterval    time.Duration `yaml:"prune_interval"`
+	Enabled         bool             `yaml:"enabled"`
+	MaxPages        int              `yaml:"max_pages"`
+	HalfLifeMinutes float64          `yaml:"half_life_minutes"`
+	PruneInterval   time.Duration    `yaml:"prune_interval"`
+	Model           string           `yaml:"model"` // "weighted" or "velocity"
+	Weights         TrendingWeights  `yaml:"weights"`
+	Velocity        TrendingVelocity `yaml:"velocity"`
+	// Per-wiki and per-language leaderboards are always kept next to the
+	// global one; these add one per project family and one per namespace.
+	ProjectSets   bool                  `yaml:"project_sets"`
+	NamespaceSets bool                  `yaml:"namespace_sets"`
+	History       TrendingHistoryConfig `yaml:"history"`
+}
+
+// TrendingHistoryConfig controls the periodic snapshots of the global and
+// per-wiki leaderboards that answer "what was trending at 3pm" and measure
+// how pages move up and down them.
+type TrendingHistoryConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Interval     time.Duration `yaml:"interval"`      // How often leaderboards are snapshotted
+	Retention    time.Duration `yaml:"retention"`     // How long snapshots and peak ranks are kept
+	TopN         int           `yaml:"top_n"`         // Pages kept per leaderboard in each snapshot
+	ChangeWindow time.Duration `yaml:"change_window"` // rank_change compares against the snapshot this long ago
+}
+
+// TrendingWeights tunes how much one edit adds to its page's trending score.
+// Multipliers compound: a large anonymous edit to a new article multiplies
+// Base by LargeEdit, Anonymous and NewPage. The defaults reproduce the
+// original fixed heuristic; UniqueEditor, Anonymous, Namespaces and
+// WikiNormalization are neutral until set.
+type TrendingWeights struct {
+	Base           float64 `yaml:"base"`
+	Bot            float64 `yaml:"bot"`
+	NewPage        float64 `yaml:"new_page"`
+	LargeEditBytes int     `yaml:"large_edit_bytes"` // |byte change| above which LargeEdit applies
+	LargeEdit      float64 `yaml:"large_edit"`
+	// UniqueEditor applies to an edit by someone who has not edited the page
+	// within EditorWindow, so ten people editing a page outrank one person
+	// saving ten times.
+	UniqueEditor float64       `yaml:"unique_editor"`
+	EditorWindow time.Duration `yaml:"editor_window"`
+	Anonymous    float64       `yaml:"anonymous"` // IP and temporary accounts
+	// Namespaces maps a namespace number to its multiplier; namespaces not
+	// listed count 1.
+	Namespaces map[int]float64 `yaml:"namespaces"`
+	// WikiNormalization (0-1) scales each edit by (reference rate / the
+	// wiki's edit rate) to this power, capped at MaxWikiBoost, so pages on
+	// small wikis can trend next to English Wikipedia. 0 disables it.
+	WikiNormalization float64 `yaml:"wiki_normalization"`
+	WikiReferenceRate float64 `yaml:"wiki_reference_rate"` // edits per minute
+	MaxWikiBoost      float64 `yaml:"max_wiki_boost"`
+}
+
+// TrendingVelocity configures the velocity model, which scores an edit by
+// how fast its page is being edited now relative to the page's own normal
+// rate, rather than by what the edit is.
+type TrendingVelocity struct {
+	Window        time.Duration `yaml:"window"`         // Half-life of the current rate
+	Baseline      time.Duration `yaml:"baseline"`       // Half-life of the page's normal rate
+	BaselineFloor float64       `yaml:"baseline_floor"` // Edits per hour assumed for pages with little history
+	MaxRatio      float64       `yaml:"max_ratio"`      // Cap on current / normal rate
 }
 
 // Kafka configuration
@@ -139,6 +252,63 @@ type Kafka struct {
 	ConsumerGroup  string        `yaml:"consumer_group"`
 	MaxPollRecords int           `yaml:"max_poll_records"`
 	SessionTimeout time.Duration `yaml:"session_timeout"`
+	LogEventsTopic string        `yaml:"log_events_topic"` // Topic for MediaWiki log events
+	Spill          SpillConfig   `yaml:"spill"`
+	Retry          KafkaRetry    `yaml:"retry"`
+}
+
+// KafkaRetry controls what consumers do with messages their handler fails
+// on: retry in place with backoff, then re-queue through delayed retry
+// topics, then give up to the dead letter queue. Errors marked
+// non-retryable skip straight to the DLQ.
+type KafkaRetry struct {
+	Enabled   bool                   `yaml:"enabled"`
+	Default   RetryPolicy            `yaml:"default"`
+	Consumers map[string]RetryPolicy `yaml:"consumers"` // Per consumer group overrides of Default
+}
+
+// RetryPolicy is the retry behaviour of one consumer group. In overrides,
+// zero fields inherit the default; an explicitly empty retry_delays list
+// disables retry topics for that group.
+type RetryPolicy struct {
+	Attempts       int             `yaml:"attempts"`        // In-place attempts, including the first
+	InitialBackoff time.Duration   `yaml:"initial_backoff"` // Delay before the first in-place retry
+	MaxBackoff     time.Duration   `yaml:"max_backoff"`     // Cap on the in-place backoff
+	RetryDelays    []time.Duration `yaml:"retry_delays"`    // One delayed retry topic per entry, in order
+}
+
+// PolicyFor returns the retry policy for a consumer group, applying its
+// overrides on top of the default.
+func (r KafkaRetry) PolicyFor(group string) RetryPolicy {
+	policy := r.Default
+	override, ok := r.Consumers[group]
+	if !ok {
+		return policy
+	}
+	if override.Attempts != 0 {
+		policy.Attempts = override.Attempts
+	}
+	if override.InitialBackoff != 0 {
+		policy.InitialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff != 0 {
+		policy.MaxBackoff = override.MaxBackoff
+	}
+	if override.RetryDelays != nil {
+		policy.RetryDelays = override.RetryDelays
+	}
+	return policy
+}
+
+// SpillConfig controls the producer's on-disk spill queue, which holds
+// messages while Kafka is slow or unavailable and replays them in order once
+// writes succeed again.
+type SpillConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Dir           string        `yaml:"dir"`            // Base directory; each topic gets a subdirectory
+	MaxBytes      int64         `yaml:"max_bytes"`      // Messages are dropped once the spill reaches this size
+	Fsync         string        `yaml:"fsync"`          // "always", "interval" or "never"
+	FsyncInterval time.Duration `yaml:"fsync_interval"` // interval policy: how often appends are synced
 }
 
 // API configuration
@@ -158,6 +328,200 @@ type APIRateLimiting struct {
 	Whitelist         []string `yaml:"whitelist"`
 }
 
+// Processor configures the stream processors.
+type Processor struct {
+	EventTime    EventTimeConfig        `yaml:"event_time"`
+	Spike        SpikeDetectionConfig   `yaml:"spike_detection"`
+	Anomaly      AnomalyDetectionConfig `yaml:"anomaly_detection"`
+	Stories      StoryClusteringConfig  `yaml:"stories"`
+	Vandalism    VandalismConfig        `yaml:"vandalism"`
+	Reverts      RevertDetectionConfig  `yaml:"reverts"`
+	Coordination CoordinationConfig     `yaml:"coordination"`
+	Editors      EditorProfilesConfig   `yaml:"editors"`
+	BotRuns      BotRunConfig           `yaml:"bot_runs"`
+	AlertRules   AlertRulesConfig       `yaml:"alert_rules"`
+}
+
+// EventTimeConfig controls whether processors window edits by the edit's own
+// timestamp rather than the time it is processed, so replaying a backlog
+// reproduces the original spikes and timelines.
+type EventTimeConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	MaxOutOfOrderness time.Duration `yaml:"max_out_of_orderness"` // How far the watermark trails the newest event seen
+	AllowedLateness   time.Duration `yaml:"allowed_lateness"`     // How far behind the watermark an event may be before it is late
+	LateEvents        string        `yaml:"late_events"`          // "accept" or "drop"
+}
+
+// SpikeDetectionConfig selects how the spike detector scores a hot page's
+// recent edit rate and when that score becomes an alert.
+type SpikeDetectionConfig struct {
+	Method          string        `yaml:"method"`            // "ratio", "ewma" or "seasonal"
+	MinimumEdits    int           `yaml:"minimum_edits"`     // Edits in the last 5 minutes before a page is scored at all
+	Cooldown        time.Duration `yaml:"cooldown"`          // Suppress repeat alerts for a page for this long
+	RatioThreshold  float64       `yaml:"ratio_threshold"`   // ratio: 5-minute rate over 1-hour rate
+	MinBaselineRate float64       `yaml:"min_baseline_rate"` // ratio: floor on the 1-hour rate, edits/min
+	ZScoreThreshold float64       `yaml:"zscore_threshold"`  // ewma/seasonal: standard deviations above the baseline
+	EWMAAlpha       float64       `yaml:"ewma_alpha"`        // ewma/seasonal: weight of each new sample, 0-1
+	MinSamples      int           `yaml:"min_samples"`       // ewma/seasonal: samples before the baseline is trusted; ratio is used until then
+	MinStdDev       float64       `yaml:"min_stddev"`        // ewma/seasonal: floor on the baseline's standard deviation, edits/min
+	BaselineTTL     time.Duration `yaml:"baseline_ttl"`      // How long an idle page's baseline is kept in Redis
+}
+
+// WithDefaults returns c with unset fields filled in. The defaults reproduce
+// the detector's original fixed rule: 5-minute rate at least 5x the 1-hour
+// rate, 3 edits minimum, 10 minute cooldown.
+func (c SpikeDetectionConfig) WithDefaults() SpikeDetectionConfig {
+	if c.Method == "" {
+		c.Method = "ratio"
+	}
+	if c.MinimumEdits == 0 {
+		c.MinimumEdits = 3
+	}
+	if c.Cooldown == 0 {
+		c.Cooldown = 10 * time.Minute
+	}
+	if c.RatioThreshold == 0 {
+		c.RatioThreshold = 5.0
+	}
+	if c.MinBaselineRate == 0 {
+		c.MinBaselineRate = 0.1
+	}
+	if c.ZScoreThreshold == 0 {
+		c.ZScoreThreshold = 4.0
+	}
+	if c.EWMAAlpha == 0 {
+		c.EWMAAlpha = 0.05
+	}
+	if c.MinSamples == 0 {
+		c.MinSamples = 30
+	}
+	if c.MinStdDev == 0 {
+		c.MinStdDev = 0.5
+	}
+	if c.BaselineTTL == 0 {
+		c.BaselineTTL = 35 * 24 * time.Hour
+	}
+	return c
+}
+
+// AnomalyDetectionConfig configures the detector that watches edit rates per
+// wiki, per namespace, for page creations and for the whole stream.
+type AnomalyDetectionConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Interval        time.Duration `yaml:"interval"`         // How often completed minutes are evaluated
+	Window          time.Duration `yaml:"window"`           // Rates are averaged over this many minutes
+	SurgeRatio      float64       `yaml:"surge_ratio"`      // wiki_surge: rate must be at least this multiple of the baseline...
+	ZScoreThreshold float64       `yaml:"zscore_threshold"` // ...and this many standard deviations above it
+	DropRatio       float64       `yaml:"drop_ratio"`       // stream_drop: global rate at or below this fraction of the baseline
+	StallAfter      time.Duration `yaml:"stall_after"`      // stream_drop: no new edits for this long
+	MinRate         float64       `yaml:"min_rate"`         // Ignore series whose baseline is below this many edits/min
+	EWMAAlpha       float64       `yaml:"ewma_alpha"`       // Weight of each minute in the baselines
+	MinSamples      int           `yaml:"min_samples"`      // Minutes of history before a series can alert
+	Cooldown        time.Duration `yaml:"cooldown"`         // Suppress repeat alerts for a series
+}
+
+// StoryClusteringConfig configures grouping spikes of the same topic across
+// language editions, using the Wikidata item each article is linked to.
+type StoryClusteringConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Window        time.Duration `yaml:"window"`         // Spikes this close together belong to the same story
+	MinWikis      int           `yaml:"min_wikis"`      // Wikis that must spike before a topic is a story
+	CacheTTL      time.Duration `yaml:"cache_ttl"`      // How long a title's Wikidata ID is cached
+	LookupTimeout time.Duration `yaml:"lookup_timeout"` // Per-request timeout for the Wikipedia API
+}
+
+// VandalismConfig sets the thresholds of the vandalism detector's heuristics.
+// Each heuristic that fires adds a reason; an alert is raised when their
+// combined confidence reaches MinConfidence.
+type VandalismConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	MinConfidence       float64       `yaml:"min_confidence"`         // Combined confidence needed to alert, 0-1
+	Cooldown            time.Duration `yaml:"cooldown"`               // Suppress repeat alerts for a page
+	LargeRemovalBytes   int           `yaml:"large_removal_bytes"`    // An edit removing at least this many bytes
+	BlankingMaxBytes    int           `yaml:"blanking_max_bytes"`     // A page left with at most this many bytes is blanked...
+	BlankingMinOldBytes int           `yaml:"blanking_min_old_bytes"` // ...if it had at least this many before
+	HotPageRemoval      float64       `yaml:"hot_page_removal"`       // Fraction of a hot page's content removed in one edit
+	RapidEdits          int           `yaml:"rapid_edits"`            // Edits by a new or anonymous user within RapidWindow
+	RapidWindow         time.Duration `yaml:"rapid_window"`           // Window for RapidEdits
+	NewAccountAge       time.Duration `yaml:"new_account_age"`        // An account registered less than this long ago is new (needs the newusers log)
+	CapsMinLetters      int           `yaml:"caps_min_letters"`       // Comments shorter than this are never "all caps"
+	ProfaneWords        []string      `yaml:"profane_words"`          // Replaces the built-in list when set
+}
+
+// RevertDetectionConfig controls how the edit war detector recognises
+// reverts. Edit summaries are always parsed; with VerifyHashes the page's
+// recent revisions are also fetched, for their change tags and to confirm
+// edits that restore an earlier revision's content exactly.
+//
+// ThreeRevertLimit and ThreeRevertWindow implement the three-revert rule: an
+// editor who reverts the same page more than ThreeRevertLimit times within
+// ThreeRevertWindow triggers a 3rr_violation alert.
+type RevertDetectionConfig struct {
+	VerifyHashes      bool          `yaml:"verify_hashes"`
+	HashLookback      int           `yaml:"hash_lookback"`  // Earlier revisions an edit's content hash is compared with
+	LookupTimeout     time.Duration `yaml:"lookup_timeout"` // Per-request timeout for the Wikipedia API
+	ThreeRevertLimit  int           `yaml:"three_revert_limit"`
+	ThreeRevertWindow time.Duration `yaml:"three_revert_window"`
+}
+
+// CoordinationConfig tunes the coordinated editing detector, which looks for
+// groups of accounts that keep turning up on the same hot pages. A pair of
+// editors is scored on how much their pages overlap, how closely their edits
+// follow each other and whether they revert the same opponents; pairs that
+// reach MinScore are linked, and each connected group is one alert.
+type CoordinationConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Window           time.Duration `yaml:"window"`             // How long co-occurrence is remembered
+	MinSharedPages   int           `yaml:"min_shared_pages"`   // Hot pages a pair must share before it is scored
+	SyncWindow       time.Duration `yaml:"sync_window"`        // Edits this close together on a page count as synchronized
+	MinScore         float64       `yaml:"min_score"`          // Pair score needed to link two accounts, 0-1
+	NewAccountAge    time.Duration `yaml:"new_account_age"`    // Accounts registered less than this long ago are new...
+	NewAccountWeight float64       `yaml:"new_account_weight"` // ...and multiply the pair score by this, once per new account
+	MaxCandidates    int           `yaml:"max_candidates"`     // Most recent co-editors of a page compared with each editor
+	Cooldown         time.Duration `yaml:"cooldown"`           // Suppress repeat alerts for the same group of accounts
+}
+
+// EditorProfilesConfig controls the per-editor activity aggregates served
+// under /api/editors. With PseudonymizeIPs, logged-out editors are stored and
+// served under a keyed hash of their IP address rather than the address.
+type EditorProfilesConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	TTL             time.Duration `yaml:"ttl"`              // Profiles of editors idle this long are dropped
+	RecentPages     int           `yaml:"recent_pages"`     // Most recently edited pages listed per profile
+	MaxRanked       int           `yaml:"max_ranked"`       // Editors kept in each top-editors ranking
+	PseudonymizeIPs bool          `yaml:"pseudonymize_ips"` // Replace IP editors' addresses with ip-<hash>
+	PseudonymKey    string        `yaml:"pseudonym_key"`    // HMAC key for the hash; overridden by EDITOR_PSEUDONYM_KEY
+}
+
+// BotRunConfig controls how bursts of edits by one account are collapsed in
+// the live feed. An account making MinEdits edits within RateWindow starts a
+// run; its edits are then replaced in the feed by a bot_run summary every
+// SummaryInterval, until IdleGap passes without an edit and the run ends.
+//
+// Runs by accounts without the bot flag are also scored on their cadence.
+// Those reaching SuspectScore are listed as suspected bots for review.
+type BotRunConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	MinEdits         int           `yaml:"min_edits"`          // Edits within rate_window that start a run
+	RateWindow       time.Duration `yaml:"rate_window"`        // Window the min_edits are counted over
+	IdleGap          time.Duration `yaml:"idle_gap"`           // A run ends after this long without an edit
+	SummaryInterval  time.Duration `yaml:"summary_interval"`   // How often an ongoing run is summarized in the feed
+	MinClassifyEdits int           `yaml:"min_classify_edits"` // Edits a run needs before its cadence is scored
+	SuspectScore     float64       `yaml:"suspect_score"`      // Cadence score, 0-1, that lists an account as a suspected bot
+	SuspectTTL       time.Duration `yaml:"suspect_ttl"`        // How long a suspected bot stays listed without a new run
+}
+
+// AlertRulesConfig controls users' personal alert rules (/api/user/rules).
+// The processor reloads the rules from the user database every
+// RefreshInterval and publishes matches to each owner's alerts:user:{id}
+// stream, at most once per rule and page every Cooldown.
+type AlertRulesConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	MaxRulesPerUser int           `yaml:"max_rules_per_user"` // Rules each user may create
+	RefreshInterval time.Duration `yaml:"refresh_interval"`   // How often new and changed rules are picked up
+	Cooldown        time.Duration `yaml:"cooldown"`           // Suppress repeat alerts from a rule for the same page
+}
+
 // Logging configuration
 type Logging struct {
 	Level  string `yaml:"level"`
@@ -210,6 +574,41 @@ func setDefaults(config *Config) {
 	if config.Ingestor.MetricsPort == 0 {
 		config.Ingestor.MetricsPort = 2112
 	}
+	if config.Ingestor.Checkpoint.Backend == "" {
+		config.Ingestor.Checkpoint.Backend = "file"
+	}
+	if config.Ingestor.Checkpoint.Path == "" {
+		config.Ingestor.Checkpoint.Path = "data/ingestor-checkpoint.json"
+	}
+	if config.Ingestor.Checkpoint.RedisKey == "" {
+		config.Ingestor.Checkpoint.RedisKey = "ingestor:sse:checkpoint"
+	}
+	if config.Ingestor.Checkpoint.Interval == 0 {
+		config.Ingestor.Checkpoint.Interval = 5 * time.Second
+	}
+	if config.Ingestor.Checkpoint.MaxAge == 0 {
+		// Edits older than a day are rejected by WikipediaEdit.Validate, so
+		// replaying further back than that only burns rate-limit budget.
+		config.Ingestor.Checkpoint.MaxAge = 24 * time.Hour
+	}
+	if config.Ingestor.Source.Type == "" {
+		config.Ingestor.Source.Type = "eventstreams"
+	}
+	if config.Ingestor.Capture.Dir == "" {
+		config.Ingestor.Capture.Dir = "data/capture"
+	}
+	if config.Ingestor.Capture.RotateInterval == 0 {
+		config.Ingestor.Capture.RotateInterval = 1 * time.Hour
+	}
+	if config.Ingestor.Capture.Retention == 0 {
+		config.Ingestor.Capture.Retention = 7 * 24 * time.Hour
+	}
+	if config.Ingestor.AllowedProjects == nil {
+		config.Ingestor.AllowedProjects = []string{models.ProjectWikipedia}
+	}
+	if config.Ingestor.LogEvents.Types == nil {
+		config.Ingestor.LogEvents.Types = []string{"protect", "block", "delete", "move", "newusers"}
+	}
 
 	// Elasticsearch defaults
 	if config.Elasticsearch.URL == "" {
@@ -259,6 +658,69 @@ func setDefaults(config *Config) {
 	if config.Redis.Trending.PruneInterval == 0 {
 		config.Redis.Trending.PruneInterval = 5 * time.Minute
 	}
+	if config.Redis.Trending.Model == "" {
+		config.Redis.Trending.Model = "weighted"
+	}
+	if config.Redis.Trending.Weights.Base == 0 {
+		config.Redis.Trending.Weights.Base = 1.0
+	}
+	if config.Redis.Trending.Weights.Bot == 0 {
+		config.Redis.Trending.Weights.Bot = 0.5
+	}
+	if config.Redis.Trending.Weights.NewPage == 0 {
+		config.Redis.Trending.Weights.NewPage = 2.0
+	}
+	if config.Redis.Trending.Weights.LargeEditBytes == 0 {
+		config.Redis.Trending.Weights.LargeEditBytes = 1000
+	}
+	if config.Redis.Trending.Weights.LargeEdit == 0 {
+		config.Redis.Trending.Weights.LargeEdit = 1.5
+	}
+	if config.Redis.Trending.Weights.UniqueEditor == 0 {
+		config.Redis.Trending.Weights.UniqueEditor = 1.0
+	}
+	if config.Redis.Trending.Weights.EditorWindow == 0 {
+		config.Redis.Trending.Weights.EditorWindow = time.Hour
+	}
+	if config.Redis.Trending.Weights.Anonymous == 0 {
+		config.Redis.Trending.Weights.Anonymous = 1.0
+	}
+	if config.Redis.Trending.Weights.WikiReferenceRate == 0 {
+		config.Redis.Trending.Weights.WikiReferenceRate = 100
+	}
+	if config.Redis.Trending.Weights.MaxWikiBoost == 0 {
+		config.Redis.Trending.Weights.MaxWikiBoost = 10
+	}
+	if config.Redis.Trending.Velocity.Window == 0 {
+		config.Redis.Trending.Velocity.Window = 10 * time.Minute
+	}
+	if config.Redis.Trending.Velocity.Baseline == 0 {
+		config.Redis.Trending.Velocity.Baseline = 24 * time.Hour
+	}
+	if config.Redis.Trending.Velocity.BaselineFloor == 0 {
+		config.Redis.Trending.Velocity.BaselineFloor = 1
+	}
+	if config.Redis.Trending.Velocity.MaxRatio == 0 {
+		config.Redis.Trending.Velocity.MaxRatio = 20
+	}
+	if config.Redis.Trending.History.Interval == 0 {
+		config.Redis.Trending.History.Interval = 5 * time.Minute
+	}
+	if config.Redis.Trending.History.Retention == 0 {
+		config.Redis.Trending.History.Retention = 7 * 24 * time.Hour
+	}
+	if config.Redis.Trending.History.TopN == 0 {
+		config.Redis.Trending.History.TopN = 50
+	}
+	if config.Redis.Trending.History.ChangeWindow == 0 {
+		config.Redis.Trending.History.ChangeWindow = time.Hour
+	}
+	if config.Redis.LegacyWiki == "" {
+		config.Redis.LegacyWiki = "enwiki"
+	}
+	if config.Redis.Dedup.Window == 0 {
+		config.Redis.Dedup.Window = 30 * time.Minute
+	}
 
 	// Kafka defaults
 	if len(config.Kafka.Brokers) == 0 {
@@ -273,6 +735,33 @@ func setDefaults(config *Config) {
 	if config.Kafka.SessionTimeout == 0 {
 		config.Kafka.SessionTimeout = 30 * time.Second
 	}
+	if config.Kafka.LogEventsTopic == "" {
+		config.Kafka.LogEventsTopic = "wikipedia.logevents"
+	}
+	if config.Kafka.Spill.Dir == "" {
+		config.Kafka.Spill.Dir = "data/spill"
+	}
+	if config.Kafka.Spill.MaxBytes == 0 {
+		config.Kafka.Spill.MaxBytes = 1 << 30 // 1 GiB
+	}
+	if config.Kafka.Spill.Fsync == "" {
+		config.Kafka.Spill.Fsync = "interval"
+	}
+	if config.Kafka.Spill.FsyncInterval == 0 {
+		config.Kafka.Spill.FsyncInterval = 1 * time.Second
+	}
+	if config.Kafka.Retry.Default.Attempts == 0 {
+		config.Kafka.Retry.Default.Attempts = 3
+	}
+	if config.Kafka.Retry.Default.InitialBackoff == 0 {
+		config.Kafka.Retry.Default.InitialBackoff = 200 * time.Millisecond
+	}
+	if config.Kafka.Retry.Default.MaxBackoff == 0 {
+		config.Kafka.Retry.Default.MaxBackoff = 2 * time.Second
+	}
+	if config.Kafka.Retry.Default.RetryDelays == nil {
+		config.Kafka.Retry.Default.RetryDelays = []time.Duration{30 * time.Second, 5 * time.Minute}
+	}
 
 	// API defaults
 	if config.API.Port == 0 {
@@ -352,6 +841,179 @@ func setDefaults(config *Config) {
 		config.LLM.CacheTTL = 25 * time.Hour
 	}
 
+	// Processor defaults
+	if config.Processor.EventTime.MaxOutOfOrderness == 0 {
+		config.Processor.EventTime.MaxOutOfOrderness = 10 * time.Second
+	}
+	if config.Processor.EventTime.AllowedLateness == 0 {
+		config.Processor.EventTime.AllowedLateness = 5 * time.Minute
+	}
+	if config.Processor.EventTime.LateEvents == "" {
+		config.Processor.EventTime.LateEvents = "accept"
+	}
+	config.Processor.Spike = config.Processor.Spike.WithDefaults()
+	if config.Processor.Anomaly.Interval == 0 {
+		config.Processor.Anomaly.Interval = time.Minute
+	}
+	if config.Processor.Anomaly.Window == 0 {
+		config.Processor.Anomaly.Window = 5 * time.Minute
+	}
+	if config.Processor.Anomaly.SurgeRatio == 0 {
+		config.Processor.Anomaly.SurgeRatio = 2.0
+	}
+	if config.Processor.Anomaly.ZScoreThreshold == 0 {
+		config.Processor.Anomaly.ZScoreThreshold = 4.0
+	}
+	if config.Processor.Anomaly.DropRatio == 0 {
+		config.Processor.Anomaly.DropRatio = 0.3
+	}
+	if config.Processor.Anomaly.StallAfter == 0 {
+		config.Processor.Anomaly.StallAfter = 5 * time.Minute
+	}
+	if config.Processor.Anomaly.MinRate == 0 {
+		config.Processor.Anomaly.MinRate = 5.0
+	}
+	if config.Processor.Anomaly.EWMAAlpha == 0 {
+		config.Processor.Anomaly.EWMAAlpha = 0.02
+	}
+	if config.Processor.Anomaly.MinSamples == 0 {
+		config.Processor.Anomaly.MinSamples = 60
+	}
+	if config.Processor.Anomaly.Cooldown == 0 {
+		config.Processor.Anomaly.Cooldown = 30 * time.Minute
+	}
+
+	// Story clustering defaults
+	if config.Processor.Stories.Window == 0 {
+		config.Processor.Stories.Window = 6 * time.Hour
+	}
+	if config.Processor.Stories.MinWikis == 0 {
+		config.Processor.Stories.MinWikis = 2
+	}
+	if config.Processor.Stories.CacheTTL == 0 {
+		config.Processor.Stories.CacheTTL = 30 * 24 * time.Hour
+	}
+	if config.Processor.Stories.LookupTimeout == 0 {
+		config.Processor.Stories.LookupTimeout = 3 * time.Second
+	}
+
+	// Vandalism detector defaults
+	if config.Processor.Vandalism.MinConfidence == 0 {
+		config.Processor.Vandalism.MinConfidence = 0.6
+	}
+	if config.Processor.Vandalism.Cooldown == 0 {
+		config.Processor.Vandalism.Cooldown = 10 * time.Minute
+	}
+	if config.Processor.Vandalism.LargeRemovalBytes == 0 {
+		config.Processor.Vandalism.LargeRemovalBytes = 2000
+	}
+	if config.Processor.Vandalism.BlankingMaxBytes == 0 {
+		config.Processor.Vandalism.BlankingMaxBytes = 100
+	}
+	if config.Processor.Vandalism.BlankingMinOldBytes == 0 {
+		config.Processor.Vandalism.BlankingMinOldBytes = 500
+	}
+	if config.Processor.Vandalism.HotPageRemoval == 0 {
+		config.Processor.Vandalism.HotPageRemoval = 0.5
+	}
+	if config.Processor.Vandalism.RapidEdits == 0 {
+		config.Processor.Vandalism.RapidEdits = 5
+	}
+	if config.Processor.Vandalism.RapidWindow == 0 {
+		config.Processor.Vandalism.RapidWindow = 2 * time.Minute
+	}
+	if config.Processor.Vandalism.NewAccountAge == 0 {
+		config.Processor.Vandalism.NewAccountAge = 24 * time.Hour
+	}
+	if config.Processor.Vandalism.CapsMinLetters == 0 {
+		config.Processor.Vandalism.CapsMinLetters = 10
+	}
+
+	// Revert detection defaults
+	if config.Processor.Reverts.HashLookback == 0 {
+		config.Processor.Reverts.HashLookback = 10
+	}
+	if config.Processor.Reverts.LookupTimeout == 0 {
+		config.Processor.Reverts.LookupTimeout = 3 * time.Second
+	}
+	if config.Processor.Reverts.ThreeRevertLimit == 0 {
+		config.Processor.Reverts.ThreeRevertLimit = 3
+	}
+	if config.Processor.Reverts.ThreeRevertWindow == 0 {
+		config.Processor.Reverts.ThreeRevertWindow = 24 * time.Hour
+	}
+
+	// Coordinated editing defaults
+	if config.Processor.Coordination.Window == 0 {
+		config.Processor.Coordination.Window = 7 * 24 * time.Hour
+	}
+	if config.Processor.Coordination.MinSharedPages == 0 {
+		config.Processor.Coordination.MinSharedPages = 3
+	}
+	if config.Processor.Coordination.SyncWindow == 0 {
+		config.Processor.Coordination.SyncWindow = 2 * time.Minute
+	}
+	if config.Processor.Coordination.MinScore == 0 {
+		config.Processor.Coordination.MinScore = 0.5
+	}
+	if config.Processor.Coordination.NewAccountAge == 0 {
+		config.Processor.Coordination.NewAccountAge = 7 * 24 * time.Hour
+	}
+	if config.Processor.Coordination.NewAccountWeight == 0 {
+		config.Processor.Coordination.NewAccountWeight = 1.25
+	}
+	if config.Processor.Coordination.MaxCandidates == 0 {
+		config.Processor.Coordination.MaxCandidates = 50
+	}
+	if config.Processor.Coordination.Cooldown == 0 {
+		config.Processor.Coordination.Cooldown = 6 * time.Hour
+	}
+
+	// Editor profile defaults
+	if config.Processor.Editors.TTL == 0 {
+		config.Processor.Editors.TTL = 30 * 24 * time.Hour
+	}
+	if config.Processor.Editors.RecentPages == 0 {
+		config.Processor.Editors.RecentPages = 50
+	}
+	if config.Processor.Editors.MaxRanked == 0 {
+		config.Processor.Editors.MaxRanked = 10000
+	}
+
+	// Bot run defaults
+	if config.Processor.BotRuns.MinEdits == 0 {
+		config.Processor.BotRuns.MinEdits = 10
+	}
+	if config.Processor.BotRuns.RateWindow == 0 {
+		config.Processor.BotRuns.RateWindow = time.Minute
+	}
+	if config.Processor.BotRuns.IdleGap == 0 {
+		config.Processor.BotRuns.IdleGap = 2 * time.Minute
+	}
+	if config.Processor.BotRuns.SummaryInterval == 0 {
+		config.Processor.BotRuns.SummaryInterval = 30 * time.Second
+	}
+	if config.Processor.BotRuns.MinClassifyEdits == 0 {
+		config.Processor.BotRuns.MinClassifyEdits = 30
+	}
+	if config.Processor.BotRuns.SuspectScore == 0 {
+		config.Processor.BotRuns.SuspectScore = 0.7
+	}
+	if config.Processor.BotRuns.SuspectTTL == 0 {
+		config.Processor.BotRuns.SuspectTTL = 7 * 24 * time.Hour
+	}
+
+	// Alert rule defaults
+	if config.Processor.AlertRules.MaxRulesPerUser == 0 {
+		config.Processor.AlertRules.MaxRulesPerUser = 20
+	}
+	if config.Processor.AlertRules.RefreshInterval == 0 {
+		config.Processor.AlertRules.RefreshInterval = 30 * time.Second
+	}
+	if config.Processor.AlertRules.Cooldown == 0 {
+		config.Processor.AlertRules.Cooldown = 30 * time.Minute
+	}
+
 	// Logging defaults
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
@@ -369,6 +1031,9 @@ func overrideWithEnv(config *Config) {
 	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
 		config.Redis.URL = redisURL
 	}
+	if trendingModel := os.Getenv("TRENDING_MODEL"); trendingModel != "" {
+		config.Redis.Trending.Model = trendingModel
+	}
 	if esURL := os.Getenv("ES_URL"); esURL != "" {
 		config.Elasticsearch.URL = esURL
 	}
@@ -400,6 +1065,9 @@ func overrideWithEnv(config *Config) {
 	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
 		config.Auth.AdminEmail = adminEmail
 	}
+	if pseudonymKey := os.Getenv("EDITOR_PSEUDONYM_KEY"); pseudonymKey != "" {
+		config.Processor.Editors.PseudonymKey = pseudonymKey
+	}
 
 	// Database overrides
 	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
@@ -446,6 +1114,34 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("kafka brokers must not be empty")
 	}
 
+	// Spill validation
+	if config.Kafka.Spill.Enabled {
+		if config.Kafka.Spill.MaxBytes <= 0 {
+			return fmt.Errorf("kafka spill max_bytes must be positive")
+		}
+		switch config.Kafka.Spill.Fsync {
+		case "always", "never":
+		case "interval":
+			if config.Kafka.Spill.FsyncInterval <= 0 {
+				return fmt.Errorf("kafka spill fsync_interval must be positive")
+			}
+		default:
+			return fmt.Errorf("kafka spill fsync must be 'always', 'interval' or 'never', got %q", config.Kafka.Spill.Fsync)
+		}
+	}
+
+	// Retry validation
+	if config.Kafka.Retry.Enabled {
+		if err := validateRetryPolicy("default", config.Kafka.Retry.Default); err != nil {
+			return err
+		}
+		for group := range config.Kafka.Retry.Consumers {
+			if err := validateRetryPolicy(group, config.Kafka.Retry.PolicyFor(group)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Redis URL validation
 	if config.Redis.URL == "" {
 		return fmt.Errorf("redis URL must not be empty")
@@ -466,6 +1162,252 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("hot pages max_tracked must be > 0 and < 100000")
 	}
 
+	if project, _ := models.ParseWikiDBName(config.Redis.LegacyWiki); project == "" {
+		return fmt.Errorf("redis legacy_wiki %q is not a recognised wiki database name", config.Redis.LegacyWiki)
+	}
+
+	if config.Redis.Dedup.Enabled && config.Redis.Dedup.Window < time.Minute {
+		return fmt.Errorf("redis dedup window must be at least 1m")
+	}
+
+	// Trending model validation. The model name is checked even with
+	// trending disabled, so a typo cannot lie in wait for it to be enabled.
+	if tr := config.Redis.Trending; tr.Model != "weighted" && tr.Model != "velocity" {
+		return fmt.Errorf("redis trending model must be \"weighted\" or \"velocity\", got %q", tr.Model)
+	}
+	if tr := config.Redis.Trending; tr.Enabled {
+		w := tr.Weights
+		if w.Base <= 0 || w.Bot <= 0 || w.NewPage <= 0 || w.LargeEdit <= 0 || w.UniqueEditor <= 0 || w.Anonymous <= 0 {
+			return fmt.Errorf("redis trending weights must be > 0")
+		}
+		if w.LargeEditBytes < 0 || w.EditorWindow <= 0 {
+			return fmt.Errorf("redis trending large_edit_bytes must not be negative and editor_window must be > 0")
+		}
+		for ns, m := range w.Namespaces {
+			if m < 0 {
+				return fmt.Errorf("redis trending weight for namespace %d must not be negative", ns)
+			}
+		}
+		if w.WikiNormalization < 0 || w.WikiNormalization > 1 {
+			return fmt.Errorf("redis trending wiki_normalization must be between 0 and 1")
+		}
+		if w.WikiReferenceRate <= 0 || w.MaxWikiBoost < 1 {
+			return fmt.Errorf("redis trending wiki_reference_rate must be > 0 and max_wiki_boost at least 1")
+		}
+		v := tr.Velocity
+		if v.Window <= 0 || v.Baseline <= v.Window {
+			return fmt.Errorf("redis trending velocity window must be > 0 and shorter than baseline")
+		}
+		if v.BaselineFloor <= 0 || v.MaxRatio < 1 {
+			return fmt.Errorf("redis trending velocity baseline_floor must be > 0 and max_ratio at least 1")
+		}
+		if h := tr.History; h.Enabled {
+			if h.Interval < time.Minute || h.Retention < h.Interval {
+				return fmt.Errorf("redis trending history interval must be at least 1m and retention at least the interval")
+			}
+			if h.TopN < 1 || h.TopN > tr.MaxPages {
+				return fmt.Errorf("redis trending history top_n must be between 1 and max_pages")
+			}
+			if h.ChangeWindow < h.Interval || h.ChangeWindow > h.Retention {
+				return fmt.Errorf("redis trending history change_window must be between interval and retention")
+			}
+		}
+	}
+
+	// Event time validation
+	if et := config.Processor.EventTime; et.Enabled {
+		if et.MaxOutOfOrderness < 0 || et.AllowedLateness < 0 {
+			return fmt.Errorf("processor event_time durations must not be negative")
+		}
+		if et.LateEvents != "accept" && et.LateEvents != "drop" {
+			return fmt.Errorf("processor event_time late_events must be \"accept\" or \"drop\", got %q", et.LateEvents)
+		}
+	}
+
+	// Spike detection validation
+	sp := config.Processor.Spike
+	switch sp.Method {
+	case "ratio", "ewma", "seasonal":
+	default:
+		return fmt.Errorf("processor spike_detection method must be \"ratio\", \"ewma\" or \"seasonal\", got %q", sp.Method)
+	}
+	if sp.EWMAAlpha <= 0 || sp.EWMAAlpha > 1 {
+		return fmt.Errorf("processor spike_detection ewma_alpha must be in (0, 1]")
+	}
+	if sp.RatioThreshold <= 1 || sp.ZScoreThreshold <= 0 {
+		return fmt.Errorf("processor spike_detection ratio_threshold must be above 1 and zscore_threshold positive")
+	}
+	if sp.MinimumEdits < 1 || sp.Cooldown < 0 {
+		return fmt.Errorf("processor spike_detection minimum_edits must be at least 1 and cooldown not negative")
+	}
+
+	// Aggregate anomaly detection validation
+	if an := config.Processor.Anomaly; an.Enabled {
+		if an.Interval <= 0 || an.Window < time.Minute {
+			return fmt.Errorf("processor anomaly_detection interval must be positive and window at least 1m")
+		}
+		if an.SurgeRatio <= 1 || an.DropRatio <= 0 || an.DropRatio >= 1 {
+			return fmt.Errorf("processor anomaly_detection surge_ratio must be above 1 and drop_ratio between 0 and 1")
+		}
+		if an.EWMAAlpha <= 0 || an.EWMAAlpha > 1 {
+			return fmt.Errorf("processor anomaly_detection ewma_alpha must be in (0, 1]")
+		}
+	}
+
+	// Story clustering validation
+	if st := config.Processor.Stories; st.Enabled {
+		if st.Window <= 0 || st.MinWikis < 2 {
+			return fmt.Errorf("processor stories window must be positive and min_wikis at least 2")
+		}
+		if st.LookupTimeout <= 0 {
+			return fmt.Errorf("processor stories lookup_timeout must be positive")
+		}
+	}
+
+	// Vandalism detector validation
+	if v := config.Processor.Vandalism; v.Enabled {
+		if v.MinConfidence <= 0 || v.MinConfidence > 1 {
+			return fmt.Errorf("processor vandalism min_confidence must be in (0, 1]")
+		}
+		if v.HotPageRemoval <= 0 || v.HotPageRemoval > 1 {
+			return fmt.Errorf("processor vandalism hot_page_removal must be in (0, 1]")
+		}
+		if v.RapidEdits < 2 || v.RapidWindow <= 0 {
+			return fmt.Errorf("processor vandalism rapid_edits must be at least 2 and rapid_window positive")
+		}
+	}
+
+	// Revert detection validation
+	if r := config.Processor.Reverts; r.VerifyHashes {
+		if r.HashLookback < 1 || r.HashLookback > 50 {
+			return fmt.Errorf("processor reverts hash_lookback must be between 1 and 50")
+		}
+		if r.LookupTimeout <= 0 {
+			return fmt.Errorf("processor reverts lookup_timeout must be positive")
+		}
+	}
+	if r := config.Processor.Reverts; r.ThreeRevertLimit < 1 || r.ThreeRevertWindow <= 0 {
+		return fmt.Errorf("processor reverts three_revert_limit must be at least 1 and three_revert_window positive")
+	}
+
+	// Coordinated editing validation
+	if c := config.Processor.Coordination; c.Enabled {
+		if c.MinSharedPages < 2 {
+			return fmt.Errorf("processor coordination min_shared_pages must be at least 2")
+		}
+		if c.MinScore <= 0 || c.MinScore > 1 {
+			return fmt.Errorf("processor coordination min_score must be in (0, 1]")
+		}
+		if c.Window <= 0 || c.SyncWindow <= 0 || c.SyncWindow >= c.Window {
+			return fmt.Errorf("processor coordination sync_window must be positive and shorter than window")
+		}
+		if c.NewAccountWeight < 1 {
+			return fmt.Errorf("processor coordination new_account_weight must be at least 1")
+		}
+		if c.MaxCandidates < 1 {
+			return fmt.Errorf("processor coordination max_candidates must be at least 1")
+		}
+	}
+
+	// Editor profile validation. The key is checked whenever pseudonymization
+	// is on, since the API hashes looked-up IPs even if this process doesn't
+	// build profiles.
+	if e := config.Processor.Editors; e.TTL <= 0 || e.RecentPages < 1 || e.MaxRanked < 1 {
+		return fmt.Errorf("processor editors ttl, recent_pages and max_ranked must be positive")
+	}
+	if e := config.Processor.Editors; e.PseudonymizeIPs && e.PseudonymKey == "" {
+		return fmt.Errorf("processor editors pseudonym_key is required when pseudonymize_ips is set")
+	}
+
+	// Bot run validation
+	if b := config.Processor.BotRuns; b.Enabled {
+		if b.MinEdits < 2 || b.RateWindow <= 0 {
+			return fmt.Errorf("processor bot_runs min_edits must be at least 2 and rate_window positive")
+		}
+		if b.IdleGap <= 0 || b.SummaryInterval <= 0 {
+			return fmt.Errorf("processor bot_runs idle_gap and summary_interval must be positive")
+		}
+		if b.MinClassifyEdits < b.MinEdits {
+			return fmt.Errorf("processor bot_runs min_classify_edits must be at least min_edits")
+		}
+		if b.SuspectScore <= 0 || b.SuspectScore > 1 {
+			return fmt.Errorf("processor bot_runs suspect_score must be in (0, 1]")
+		}
+		if b.SuspectTTL <= 0 {
+			return fmt.Errorf("processor bot_runs suspect_ttl must be positive")
+		}
+	}
+
+	// Alert rule validation
+	if a := config.Processor.AlertRules; a.Enabled && (a.MaxRulesPerUser < 1 || a.RefreshInterval <= 0 || a.Cooldown <= 0) {
+		return fmt.Errorf("processor alert_rules max_rules_per_user, refresh_interval and cooldown must be positive")
+	}
+
+	// Project allowlist validation
+	for _, p := range config.Ingestor.AllowedProjects {
+		if !slices.Contains(models.KnownProjects, p) {
+			return fmt.Errorf("ingestor allowed_projects contains unknown project %q (known: %s)",
+				p, strings.Join(models.KnownProjects, ", "))
+		}
+	}
+
+	// Checkpoint validation
+	if config.Ingestor.Checkpoint.Enabled {
+		switch config.Ingestor.Checkpoint.Backend {
+		case "file", "redis":
+		default:
+			return fmt.Errorf("ingestor checkpoint backend must be 'file' or 'redis', got %q", config.Ingestor.Checkpoint.Backend)
+		}
+	}
+
+	// Capture validation
+	if config.Ingestor.Capture.Enabled {
+		if config.Ingestor.Capture.RotateInterval < time.Minute {
+			return fmt.Errorf("ingestor capture rotate_interval must be at least 1m")
+		}
+		if config.Ingestor.Capture.Retention < config.Ingestor.Capture.RotateInterval {
+			return fmt.Errorf("ingestor capture retention must be at least one rotate_interval")
+		}
+	}
+
+	// Event source validation
+	switch config.Ingestor.Source.Type {
+	case "", "eventstreams":
+	case "sse":
+		if config.Ingestor.Source.URL == "" {
+			return fmt.Errorf("ingestor source url is required for the sse source")
+		}
+	case "replay":
+		if config.Ingestor.Source.Path == "" {
+			return fmt.Errorf("ingestor source path is required for the replay source")
+		}
+		if config.Ingestor.Source.Speed < 0 {
+			return fmt.Errorf("ingestor replay speed cannot be negative")
+		}
+		if !config.Ingestor.Source.From.IsZero() && !config.Ingestor.Source.To.IsZero() &&
+			config.Ingestor.Source.To.Before(config.Ingestor.Source.From) {
+			return fmt.Errorf("ingestor replay window 'to' must not be before 'from'")
+		}
+	default:
+		return fmt.Errorf("ingestor source type must be 'eventstreams', 'sse' or 'replay', got %q", config.Ingestor.Source.Type)
+	}
+
+	return nil
+}
+
+// validateRetryPolicy checks a resolved retry policy.
+func validateRetryPolicy(name string, policy RetryPolicy) error {
+	if policy.Attempts < 1 {
+		return fmt.Errorf("kafka retry %s: attempts must be at least 1", name)
+	}
+	if policy.InitialBackoff < 0 || policy.MaxBackoff < policy.InitialBackoff {
+		return fmt.Errorf("kafka retry %s: max_backoff must be at least initial_backoff", name)
+	}
+	for i, d := range policy.RetryDelays {
+		if d <= 0 {
+			return fmt.Errorf("kafka retry %s: retry_delays[%d] must be positive", name, i)
+		}
+	}
 	return nil
 }
 
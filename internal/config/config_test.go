@@ -76,6 +76,10 @@ func TestSetDefaults_AllZeroValues(t *testing.T) {
 	assert.Equal(t, 1*time.Second, cfg.Ingestor.ReconnectDelay)
 	assert.Equal(t, 60*time.Second, cfg.Ingestor.MaxReconnectDelay)
 	assert.Equal(t, 2112, cfg.Ingestor.MetricsPort)
+	assert.Equal(t, "file", cfg.Ingestor.Checkpoint.Backend)
+	assert.Equal(t, 5*time.Second, cfg.Ingestor.Checkpoint.Interval)
+	assert.Equal(t, 24*time.Hour, cfg.Ingestor.Checkpoint.MaxAge)
+	assert.Equal(t, []string{"protect", "block", "delete", "move", "newusers"}, cfg.Ingestor.LogEvents.Types)
 
 	// Elasticsearch
 	assert.Equal(t, "http://localhost:9200", cfg.Elasticsearch.URL)
@@ -89,11 +93,13 @@ func TestSetDefaults_AllZeroValues(t *testing.T) {
 	assert.Equal(t, 1000, cfg.Redis.HotPages.MaxTracked)
 	assert.Equal(t, 5, cfg.Redis.HotPages.PromotionThreshold)
 	assert.Equal(t, 15*time.Minute, cfg.Redis.HotPages.WindowDuration)
+	assert.Equal(t, "enwiki", cfg.Redis.LegacyWiki)
 
 	// Kafka
 	assert.Equal(t, []string{"localhost:9092"}, cfg.Kafka.Brokers)
 	assert.Equal(t, "wikisurge", cfg.Kafka.ConsumerGroup)
 	assert.Equal(t, 500, cfg.Kafka.MaxPollRecords)
+	assert.Equal(t, "wikipedia.logevents", cfg.Kafka.LogEventsTopic)
 
 	// API
 	assert.Equal(t, 8080, cfg.API.Port)
@@ -320,6 +326,394 @@ func TestValidateConfig_HotPagesZero(t *testing.T) {
 	assert.ErrorContains(t, validateConfig(cfg), "hot pages max_tracked")
 }
 
+func TestValidateConfig_BadLegacyWiki(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Redis.LegacyWiki = "frwiktionary"
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Redis.LegacyWiki = "en"
+	assert.ErrorContains(t, validateConfig(cfg), "redis legacy_wiki")
+}
+
+func TestValidateConfig_BadCheckpointBackend(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Ingestor.Checkpoint.Enabled = true
+	cfg.Ingestor.Checkpoint.Backend = "s3"
+	assert.ErrorContains(t, validateConfig(cfg), "ingestor checkpoint backend")
+}
+
+func TestValidateConfig_EventSource(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, "eventstreams", cfg.Ingestor.Source.Type)
+
+	cfg.Ingestor.Source.Type = "replay"
+	assert.ErrorContains(t, validateConfig(cfg), "source path is required")
+
+	cfg.Ingestor.Source.Path = "testdata/day.ndjson.gz"
+	cfg.Ingestor.Source.Speed = -1
+	assert.ErrorContains(t, validateConfig(cfg), "replay speed")
+
+	cfg.Ingestor.Source.Speed = 10
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Ingestor.Source.Type = "sse"
+	assert.ErrorContains(t, validateConfig(cfg), "source url is required")
+
+	cfg.Ingestor.Source.Type = "kinesis"
+	assert.ErrorContains(t, validateConfig(cfg), "ingestor source type")
+}
+
+func TestValidateConfig_AllowedProjects(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, []string{"wikipedia"}, cfg.Ingestor.AllowedProjects)
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Ingestor.AllowedProjects = []string{"wikipedia", "wiktionary", "wikidata"}
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Ingestor.AllowedProjects = []string{"wikipedia", "wikitionary"}
+	assert.ErrorContains(t, validateConfig(cfg), `unknown project "wikitionary"`)
+}
+
+func TestLoadConfig_EmptyAllowedProjectsMeansAll(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.yaml")
+	os.WriteFile(p, []byte(`
+ingestor:
+  allowed_projects: []
+kafka:
+  brokers: ["localhost:9092"]
+redis:
+  url: "redis://localhost:6379"
+`), 0644)
+
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Ingestor.AllowedProjects)
+}
+
+func TestValidateConfig_Capture(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, "data/capture", cfg.Ingestor.Capture.Dir)
+	assert.Equal(t, time.Hour, cfg.Ingestor.Capture.RotateInterval)
+
+	cfg.Ingestor.Capture.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Ingestor.Capture.Retention = 30 * time.Minute
+	assert.ErrorContains(t, validateConfig(cfg), "capture retention")
+}
+
+func TestValidateConfig_Spill(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, "data/spill", cfg.Kafka.Spill.Dir)
+	assert.Equal(t, int64(1<<30), cfg.Kafka.Spill.MaxBytes)
+	assert.Equal(t, "interval", cfg.Kafka.Spill.Fsync)
+	assert.Equal(t, time.Second, cfg.Kafka.Spill.FsyncInterval)
+
+	cfg.Kafka.Spill.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Kafka.Spill.Fsync = "sometimes"
+	assert.ErrorContains(t, validateConfig(cfg), "kafka spill fsync")
+
+	cfg.Kafka.Spill.Fsync = "always"
+	cfg.Kafka.Spill.MaxBytes = -1
+	assert.ErrorContains(t, validateConfig(cfg), "kafka spill max_bytes")
+}
+
+func TestValidateConfig_Dedup(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 30*time.Minute, cfg.Redis.Dedup.Window)
+
+	cfg.Redis.Dedup.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Redis.Dedup.Window = time.Second
+	assert.ErrorContains(t, validateConfig(cfg), "redis dedup window")
+}
+
+func TestValidateConfig_EventTime(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 10*time.Second, cfg.Processor.EventTime.MaxOutOfOrderness)
+	assert.Equal(t, 5*time.Minute, cfg.Processor.EventTime.AllowedLateness)
+	assert.Equal(t, "accept", cfg.Processor.EventTime.LateEvents)
+
+	cfg.Processor.EventTime.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.EventTime.LateEvents = "buffer"
+	assert.ErrorContains(t, validateConfig(cfg), "late_events")
+
+	cfg.Processor.EventTime.LateEvents = "drop"
+	cfg.Processor.EventTime.AllowedLateness = -time.Second
+	assert.ErrorContains(t, validateConfig(cfg), "must not be negative")
+}
+
+func TestValidateConfig_SpikeDetection(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, "ratio", cfg.Processor.Spike.Method)
+	assert.Equal(t, 5.0, cfg.Processor.Spike.RatioThreshold)
+	assert.Equal(t, 3, cfg.Processor.Spike.MinimumEdits)
+	assert.Equal(t, 10*time.Minute, cfg.Processor.Spike.Cooldown)
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Spike.Method = "seasonal"
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Spike.Method = "prophet"
+	assert.ErrorContains(t, validateConfig(cfg), "spike_detection method")
+
+	cfg.Processor.Spike.Method = "ewma"
+	cfg.Processor.Spike.EWMAAlpha = 1.5
+	assert.ErrorContains(t, validateConfig(cfg), "ewma_alpha")
+}
+
+func TestValidateConfig_AnomalyDetection(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 5*time.Minute, cfg.Processor.Anomaly.Window)
+	assert.Equal(t, 2.0, cfg.Processor.Anomaly.SurgeRatio)
+
+	cfg.Processor.Anomaly.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Anomaly.DropRatio = 1.5
+	assert.ErrorContains(t, validateConfig(cfg), "drop_ratio")
+}
+
+func TestValidateConfig_Stories(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 6*time.Hour, cfg.Processor.Stories.Window)
+	assert.Equal(t, 2, cfg.Processor.Stories.MinWikis)
+
+	cfg.Processor.Stories.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Stories.MinWikis = 1
+	assert.ErrorContains(t, validateConfig(cfg), "min_wikis")
+
+	cfg.Processor.Stories.MinWikis = 2
+	cfg.Processor.Stories.LookupTimeout = -time.Second
+	assert.ErrorContains(t, validateConfig(cfg), "lookup_timeout")
+}
+
+func TestValidateConfig_Vandalism(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 0.6, cfg.Processor.Vandalism.MinConfidence)
+	assert.Equal(t, 2000, cfg.Processor.Vandalism.LargeRemovalBytes)
+
+	cfg.Processor.Vandalism.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Vandalism.MinConfidence = 1.5
+	assert.ErrorContains(t, validateConfig(cfg), "min_confidence")
+}
+
+func TestValidateConfig_Reverts(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.Equal(t, 10, cfg.Processor.Reverts.HashLookback)
+
+	cfg.Processor.Reverts.VerifyHashes = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Processor.Reverts.HashLookback = 100
+	assert.ErrorContains(t, validateConfig(cfg), "hash_lookback")
+
+	cfg.Processor.Reverts.HashLookback = 10
+	assert.Equal(t, 3, cfg.Processor.Reverts.ThreeRevertLimit)
+	assert.Equal(t, 24*time.Hour, cfg.Processor.Reverts.ThreeRevertWindow)
+	cfg.Processor.Reverts.ThreeRevertLimit = -1
+	assert.ErrorContains(t, validateConfig(cfg), "three_revert_limit")
+}
+
+func TestValidateConfig_Coordination(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Processor.Coordination.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, 3, cfg.Processor.Coordination.MinSharedPages)
+
+	cfg.Processor.Coordination.SyncWindow = 30 * 24 * time.Hour
+	assert.ErrorContains(t, validateConfig(cfg), "sync_window")
+
+	cfg.Processor.Coordination.SyncWindow = time.Minute
+	cfg.Processor.Coordination.NewAccountWeight = 0.5
+	assert.ErrorContains(t, validateConfig(cfg), "new_account_weight")
+}
+
+func TestValidateConfig_EditorProfiles(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, 30*24*time.Hour, cfg.Processor.Editors.TTL)
+
+	cfg.Processor.Editors.PseudonymizeIPs = true
+	assert.ErrorContains(t, validateConfig(cfg), "pseudonym_key")
+
+	t.Setenv("EDITOR_PSEUDONYM_KEY", "s3cret")
+	overrideWithEnv(cfg)
+	assert.NoError(t, validateConfig(cfg))
+}
+
+func TestValidateConfig_BotRuns(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Processor.BotRuns.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, 10, cfg.Processor.BotRuns.MinEdits)
+
+	cfg.Processor.BotRuns.MinClassifyEdits = 5
+	assert.ErrorContains(t, validateConfig(cfg), "min_classify_edits")
+
+	cfg.Processor.BotRuns.MinClassifyEdits = 30
+	cfg.Processor.BotRuns.SuspectScore = 1.5
+	assert.ErrorContains(t, validateConfig(cfg), "suspect_score")
+}
+
+func TestValidateConfig_TrendingModel(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Redis.Trending.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, "weighted", cfg.Redis.Trending.Model)
+	assert.Equal(t, 1.5, cfg.Redis.Trending.Weights.LargeEdit)
+	assert.Equal(t, 24*time.Hour, cfg.Redis.Trending.Velocity.Baseline)
+
+	cfg.Redis.Trending.Model = "popularity"
+	assert.ErrorContains(t, validateConfig(cfg), "model")
+	cfg.Redis.Trending.Enabled = false
+	assert.ErrorContains(t, validateConfig(cfg), "model", "unknown models fail even with trending off")
+	cfg.Redis.Trending.Enabled = true
+
+	cfg.Redis.Trending.Model = "velocity"
+	cfg.Redis.Trending.Weights.WikiNormalization = 2
+	assert.ErrorContains(t, validateConfig(cfg), "wiki_normalization")
+
+	cfg.Redis.Trending.Weights.WikiNormalization = 0.5
+	cfg.Redis.Trending.Velocity.Window = 48 * time.Hour
+	assert.ErrorContains(t, validateConfig(cfg), "shorter than baseline")
+}
+
+func TestValidateConfig_TrendingHistory(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Redis.Trending.Enabled = true
+	cfg.Redis.Trending.History.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, 5*time.Minute, cfg.Redis.Trending.History.Interval)
+	assert.Equal(t, 50, cfg.Redis.Trending.History.TopN)
+
+	cfg.Redis.Trending.History.TopN = 5000
+	assert.ErrorContains(t, validateConfig(cfg), "top_n")
+
+	cfg.Redis.Trending.History.TopN = 50
+	cfg.Redis.Trending.History.ChangeWindow = 30 * 24 * time.Hour
+	assert.ErrorContains(t, validateConfig(cfg), "change_window")
+}
+
+func TestValidateConfig_AlertRules(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Processor.AlertRules.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, 20, cfg.Processor.AlertRules.MaxRulesPerUser)
+	assert.Equal(t, 30*time.Minute, cfg.Processor.AlertRules.Cooldown)
+
+	cfg.Processor.AlertRules.RefreshInterval = -time.Second
+	assert.ErrorContains(t, validateConfig(cfg), "alert_rules")
+}
+
+func TestLoadConfig_TrendingWeights(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.yaml")
+	os.WriteFile(p, []byte(`
+kafka:
+  brokers: ["localhost:9092"]
+redis:
+  url: "redis://localhost:6379"
+  trending:
+    enabled: true
+    weights:
+      unique_editor: 2
+      namespaces:
+        2: 0
+        4: 0.5
+`), 0644)
+
+	t.Setenv("TRENDING_MODEL", "velocity")
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+
+	tr := cfg.Redis.Trending
+	assert.Equal(t, "velocity", tr.Model)
+	assert.Equal(t, 2.0, tr.Weights.UniqueEditor)
+	assert.Equal(t, 0.5, tr.Weights.Bot)
+	assert.Equal(t, map[int]float64{2: 0, 4: 0.5}, tr.Weights.Namespaces)
+}
+
+func TestLoadConfig_RetryPolicyOverrides(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.yaml")
+	os.WriteFile(p, []byte(`
+kafka:
+  brokers: ["localhost:9092"]
+  retry:
+    enabled: true
+    consumers:
+      edit-war-detector:
+        attempts: 5
+      websocket-forwarder:
+        retry_delays: []
+redis:
+  url: "redis://localhost:6379"
+`), 0644)
+
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+
+	def := cfg.Kafka.Retry.PolicyFor("spike-detector")
+	assert.Equal(t, 3, def.Attempts)
+	assert.Equal(t, []time.Duration{30 * time.Second, 5 * time.Minute}, def.RetryDelays)
+
+	ew := cfg.Kafka.Retry.PolicyFor("edit-war-detector")
+	assert.Equal(t, 5, ew.Attempts)
+	assert.Equal(t, def.MaxBackoff, ew.MaxBackoff)
+	assert.Equal(t, def.RetryDelays, ew.RetryDelays)
+
+	ws := cfg.Kafka.Retry.PolicyFor("websocket-forwarder")
+	assert.Equal(t, 3, ws.Attempts)
+	assert.Empty(t, ws.RetryDelays)
+}
+
+func TestValidateConfig_Retry(t *testing.T) {
+	cfg := &Config{}
+	setDefaults(cfg)
+	cfg.Kafka.Retry.Enabled = true
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.Kafka.Retry.Consumers = map[string]RetryPolicy{
+		"spike-detector": {RetryDelays: []time.Duration{time.Minute, 0}},
+	}
+	assert.ErrorContains(t, validateConfig(cfg), "kafka retry spike-detector: retry_delays[1]")
+
+	cfg.Kafka.Retry.Consumers = nil
+	cfg.Kafka.Retry.Default.MaxBackoff = time.Millisecond
+	assert.ErrorContains(t, validateConfig(cfg), "max_backoff")
+}
+
 // ---------------------------------------------------------------------------
 // isValidMemorySize
 // ---------------------------------------------------------------------------
@@ -58,8 +58,8 @@ func setupTestCollectorWithRedis(t *testing.T) (*Collector, *redis.Client, *mini
 	return collector, rc, mr
 }
 
-// seedAnalysisArchive writes a pre-built LLM analysis directly into the
-// digest archive for a given date string (YYYY-MM-DD).
+// seedAnalysisArchive writes a pre-built LLM analysis for an enwiki page
+// directly into the digest archive for a given date string (YYYY-MM-DD).
 func seedAnalysisArchive(t *testing.T, rc *redis.Client, dateStr, pageTitle string, analysis map[string]interface{}) {
 	t.Helper()
 	ctx := context.Background()
@@ -79,10 +79,11 @@ func seedAnalysisArchive(t *testing.T, rc *redis.Client, dateStr, pageTitle stri
 		}
 	}
 
+	member := models.NewPageKey("enwiki", pageTitle).String()
 	pipe := rc.Pipeline()
-	pipe.HSet(ctx, hashKey, pageTitle, string(data))
+	pipe.HSet(ctx, hashKey, member, string(data))
 	pipe.Expire(ctx, hashKey, 8*24*time.Hour)
-	pipe.ZAdd(ctx, dateKey, redis.Z{Score: float64(editCount), Member: pageTitle})
+	pipe.ZAdd(ctx, dateKey, redis.Z{Score: float64(editCount), Member: member})
 	pipe.Expire(ctx, dateKey, 8*24*time.Hour)
 	if _, err := pipe.Exec(ctx); err != nil {
 		t.Fatalf("seed archive: %v", err)
@@ -136,7 +137,7 @@ func TestDailyDigest_LLMSummaryFromEphemeralCache(t *testing.T) {
 		[]string{"Alice", "Bob", "Charlie"},
 	)
 	analysisJSON, _ := json.Marshal(analysis)
-	rc.Set(ctx, "editwar:analysis:Climate_change", string(analysisJSON), 25*time.Hour)
+	rc.Set(ctx, "editwar:analysis:enwiki:Climate_change", string(analysisJSON), 25*time.Hour)
 
 	// Collect daily digest
 	data, err := collector.CollectGlobal(ctx, "daily")
@@ -215,6 +216,39 @@ func TestDailyDigest_FallbackToArchive(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Test: Archive entries written before wiki-qualified keys are still recovered
+// ---------------------------------------------------------------------------
+
+func TestDailyDigest_FallbackToLegacyArchiveEntry(t *testing.T) {
+	collector, rc, _ := setupTestCollectorWithRedis(t)
+	ctx := context.Background()
+
+	seedEditWarAlert(t, rc, "Ethereum", 150)
+
+	// Legacy archives use the bare title as hash field.
+	data, _ := json.Marshal(makeTestAnalysis(
+		"Editors disagree on the proof-of-stake migration.",
+		"Consensus mechanism",
+		"low",
+		150,
+		[]string{"Staker"},
+	))
+	today := time.Now().UTC().Format("2006-01-02")
+	rc.HSet(ctx, "digest:war_analyses:"+today+":data", "Ethereum", string(data))
+
+	digest, err := collector.CollectGlobal(ctx, "daily")
+	if err != nil {
+		t.Fatalf("CollectGlobal: %v", err)
+	}
+	if len(digest.EditWarHighlights) == 0 {
+		t.Fatal("expected at least 1 edit war highlight")
+	}
+	if !strings.Contains(digest.EditWarHighlights[0].LLMSummary, "proof-of-stake") {
+		t.Errorf("unexpected LLM summary: %s", digest.EditWarHighlights[0].LLMSummary)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Test: Weekly digest — all ephemeral data expired, archive from past days
 // ---------------------------------------------------------------------------
@@ -358,7 +392,7 @@ func TestDailyDigest_OnDemandAnalyzerFillsCacheOnMiss(t *testing.T) {
 	// Attach a mock analyzer that writes an analysis to the ephemeral cache
 	// (simulating what the real AnalysisService.Analyze does).
 	analyzerCalled := false
-	collector.SetAnalyzer(func(ctx context.Context, pageTitle string) error {
+	collector.SetAnalyzer(func(ctx context.Context, key models.PageKey) error {
 		analyzerCalled = true
 		// Simulate what AnalysisService.Analyze does: write to cache
 		analysis := makeTestAnalysis(
@@ -369,7 +403,7 @@ func TestDailyDigest_OnDemandAnalyzerFillsCacheOnMiss(t *testing.T) {
 			[]string{"EthicsProf", "Pragmatist"},
 		)
 		data, _ := json.Marshal(analysis)
-		rc.Set(ctx, "editwar:analysis:"+pageTitle, string(data), 25*time.Hour)
+		rc.Set(ctx, "editwar:analysis:"+key.String(), string(data), 25*time.Hour)
 		return nil
 	})
 
@@ -406,7 +440,7 @@ func TestDailyDigest_AnalyzerFailsFallsBackToArchive(t *testing.T) {
 	seedEditWarAlert(t, rc, "Quantum_Computing", 180)
 
 	// Attach an analyzer that fails
-	collector.SetAnalyzer(func(ctx context.Context, pageTitle string) error {
+	collector.SetAnalyzer(func(ctx context.Context, key models.PageKey) error {
 		return fmt.Errorf("LLM API timeout")
 	})
 
@@ -453,7 +487,7 @@ func TestDailyDigest_MixedEnrichmentSources(t *testing.T) {
 	// Page_Cached: has ephemeral cache
 	cachedAnalysis := makeTestAnalysis("Cached summary for Page_Cached.", "Topic A", "high", 400, []string{"Ed1"})
 	data, _ := json.Marshal(cachedAnalysis)
-	rc.Set(ctx, "editwar:analysis:Page_Cached", string(data), 25*time.Hour)
+	rc.Set(ctx, "editwar:analysis:enwiki:Page_Cached", string(data), 25*time.Hour)
 
 	// Page_Archived: only in archive (ephemeral expired)
 	today := time.Now().UTC().Format("2006-01-02")
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strconv"
 	"time"
 
 	"github.com/Agnikulu/WikiSurge/internal/models"
@@ -35,6 +34,7 @@ type DigestData struct {
 type GlobalHighlight struct {
 	Rank       int     `json:"rank"`
 	Title      string  `json:"title"`
+	Wiki       string  `json:"wiki,omitempty"`
 	EditCount  int     `json:"edit_count"`
 	EventType  string  `json:"event_type"` // "spike", "edit_war", "trending"
 	SpikeRatio float64 `json:"spike_ratio,omitempty"`
@@ -50,6 +50,16 @@ type GlobalHighlight struct {
 	ContentArea string   `json:"content_area,omitempty"` // topic area of disagreement
 }
 
+// pageKey returns the highlight's page key, deriving the wiki from the
+// server URL when the source alert predates wiki-qualified keys.
+func (h *GlobalHighlight) pageKey() models.PageKey {
+	wiki := h.Wiki
+	if wiki == "" {
+		wiki = models.WikiFromServerURL(h.ServerURL)
+	}
+	return models.NewPageKey(wiki, h.Title)
+}
+
 // WatchlistEvent is a per-user event for a page they track.
 type WatchlistEvent struct {
 	Title      string  `json:"title"`
@@ -82,7 +92,7 @@ type LanguageStat struct {
 // demand. The collector only needs the side effect (result cached in Redis);
 // the return value is ignored. Assign llm.AnalysisService.Analyze (wrapped)
 // to keep the digest package decoupled from the LLM implementation.
-type EditWarAnalyzeFunc func(ctx context.Context, pageTitle string) error
+type EditWarAnalyzeFunc func(ctx context.Context, key models.PageKey) error
 
 // Collector gathers digest data from Redis storage layers.
 type Collector struct {
@@ -92,6 +102,7 @@ type Collector struct {
 	stats    *storage.StatsTracker
 	redis    *redis.Client
 	analyzer EditWarAnalyzeFunc // optional: generates analysis on cache miss
+	wiki     string             // wiki of watchlist entries that name none
 	logger   zerolog.Logger
 }
 
@@ -138,6 +149,13 @@ func (c *Collector) SetAnalyzer(fn EditWarAnalyzeFunc) {
 	c.analyzer = fn
 }
 
+// SetWatchlistWiki sets the wiki whose edit counts are reported for
+// watchlist entries given as a bare title, e.g. "Paris" rather than
+// "frwiki:Paris".
+func (c *Collector) SetWatchlistWiki(wiki string) {
+	c.wiki = wiki
+}
+
 // CollectGlobal gathers data that is shared across all users in a digest.
 // This should be called once per digest run, not per-user.
 func (c *Collector) CollectGlobal(ctx context.Context, period string) (*DigestData, error) {
@@ -224,7 +242,7 @@ func (c *Collector) ShouldSendToUser(data *DigestData, user *models.User) bool {
 
 func (c *Collector) collectHighlights(ctx context.Context, since time.Time) ([]GlobalHighlight, error) {
 	// Gather spikes and edit wars from alert streams
-	seen := make(map[string]*GlobalHighlight) // dedupe by title
+	seen := make(map[models.PageKey]*GlobalHighlight) // dedupe by page
 
 	// 1. Edit war alerts
 	editWars, err := c.alerts.GetAlertsSince(ctx, "editwars", since, "", 100)
@@ -239,6 +257,12 @@ func (c *Collector) collectHighlights(ctx context.Context, since time.Time) ([]G
 		if title == "" {
 			continue
 		}
+		alertWiki := stringFromData(a.Data, "wiki")
+		alertServerURL := stringFromData(a.Data, "server_url")
+		if alertWiki == "" {
+			alertWiki = models.WikiFromServerURL(alertServerURL)
+		}
+		key := models.NewPageKey(alertWiki, title)
 		alertEditorCount := intFromData(a.Data, "editor_count")
 		alertRevertCount := intFromData(a.Data, "revert_count")
 		alertEditCount := intFromData(a.Data, "edit_count")
@@ -257,7 +281,7 @@ func (c *Collector) collectHighlights(ctx context.Context, since time.Time) ([]G
 			}
 		}
 
-		if existing, ok := seen[title]; ok {
+		if existing, ok := seen[key]; ok {
 			// Upgrade to edit_war type and use highest counts
 			existing.EventType = "edit_war"
 			existing.Summary = "Edit war detected"
@@ -282,12 +306,13 @@ func (c *Collector) collectHighlights(ctx context.Context, since time.Time) ([]G
 				existing.ContentArea = alertContentArea
 			}
 		} else {
-			seen[title] = &GlobalHighlight{
+			seen[key] = &GlobalHighlight{
 				Title:       title,
+				Wiki:        alertWiki,
 				EditCount:   alertEditCount,
 				EventType:   "edit_war",
 				Summary:     "Edit war detected",
-				ServerURL:   stringFromData(a.Data, "server_url"),
+				ServerURL:   alertServerURL,
 				EditorCount: alertEditorCount,
 				Editors:     alertEditors,
 				RevertCount: alertRevertCount,
@@ -305,18 +330,20 @@ func (c *Collector) collectHighlights(ctx context.Context, since time.Time) ([]G
 			c.logger.Warn().Err(err).Msg("could not fetch trending pages")
 		}
 		for _, t := range trending {
-			if _, exists := seen[t.PageTitle]; !exists {
+			key := models.NewPageKey(t.Wiki, t.PageTitle)
+			if _, exists := seen[key]; !exists {
 				// Only add if trending entry is from our period
 				if t.LastUpdated >= since.Unix() {
 					var edits int64
 					if c.hotPages != nil {
-						stats, err := c.hotPages.GetPageStats(ctx, t.PageTitle)
+						stats, err := c.hotPages.GetPageStats(ctx, key)
 						if err == nil && stats != nil {
 							edits = stats.EditsLastHour
 						}
 					}
-					seen[t.PageTitle] = &GlobalHighlight{
+					seen[key] = &GlobalHighlight{
 						Title:     t.PageTitle,
+						Wiki:      t.Wiki,
 						EditCount: int(edits),
 						EventType: "trending",
 						Summary:   fmt.Sprintf("Trending (score: %.0f)", t.CurrentScore),
@@ -364,16 +391,17 @@ func (c *Collector) enrichEditWars(ctx context.Context, wars []GlobalHighlight)
 
 	for i := range wars {
 		title := wars[i].Title
+		key := wars[i].pageKey()
 
 		// 1. Fetch cached LLM analysis (or regenerate on miss)
-		cacheKey := fmt.Sprintf("editwar:analysis:%s", title)
+		cacheKey := fmt.Sprintf("editwar:analysis:%s", key)
 		cached, err := c.redis.Get(ctx, cacheKey).Result()
 
 		// If cache is empty and we have an analyzer, generate fresh analysis.
 		// The Analyze call caches its result in Redis, so re-read afterwards.
 		if (err != nil || cached == "") && c.analyzer != nil {
 			analyzeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			if aErr := c.analyzer(analyzeCtx, title); aErr != nil {
+			if aErr := c.analyzer(analyzeCtx, key); aErr != nil {
 				c.logger.Warn().Err(aErr).Str("page", title).Msg("on-demand LLM analysis failed for digest")
 			} else {
 				c.logger.Info().Str("page", title).Msg("generated fresh LLM analysis for digest")
@@ -430,7 +458,7 @@ func (c *Collector) enrichEditWars(ctx context.Context, wars []GlobalHighlight)
 		for _, ed := range wars[i].Editors {
 			editorSet[ed] = true
 		}
-		editorsKey := fmt.Sprintf("editwar:editors:%s", title)
+		editorsKey := fmt.Sprintf("editwar:editors:%s", key)
 		editorMap, err := c.redis.HGetAll(ctx, editorsKey).Result()
 		if err == nil && len(editorMap) > 0 {
 			for editor := range editorMap {
@@ -447,20 +475,12 @@ func (c *Collector) enrichEditWars(ctx context.Context, wars []GlobalHighlight)
 			wars[i].EditorCount = len(editors)
 		}
 
-		// 3. Count reverts from changes list using proper alternating-sign
-		// pattern detection (matching the edit war detector's algorithm).
-		// The existing RevertCount from alert stream data is used as a floor.
-		alertRevertCount := wars[i].RevertCount
-		changesKey := fmt.Sprintf("editwar:changes:%s", title)
-		changes, err := c.redis.LRange(ctx, changesKey, 0, -1).Result()
-		if err == nil && len(changes) >= 2 {
-			revertCount := countRevertsFromChanges(changes)
-			// Use whichever source gives a higher count — the alert stream
-			// captured the count at detection time, but the changes list may
-			// have accumulated more reverts since then.
-			if revertCount > alertRevertCount {
-				wars[i].RevertCount = revertCount
-			}
+		// 3. Count the reverts the processor recognised. The RevertCount
+		// from alert stream data is used as a floor — it was captured at
+		// detection time, while the list may have grown since then.
+		revertsKey := fmt.Sprintf("editwar:reverts:%s", key)
+		if n, err := c.redis.LLen(ctx, revertsKey).Result(); err == nil && int(n) > wars[i].RevertCount {
+			wars[i].RevertCount = int(n)
 		}
 
 		// 4. Update the summary to use LLM summary if available
@@ -493,11 +513,11 @@ func (c *Collector) fillFromDigestArchive(ctx context.Context, wars []GlobalHigh
 		return
 	}
 
-	// Collect titles that still need enrichment.
-	needsLLM := make(map[string]int) // title → index in wars slice
+	// Collect pages that still need enrichment.
+	needsLLM := make(map[string]int) // page key → index in wars slice
 	for i := range wars {
 		if wars[i].LLMSummary == "" {
-			needsLLM[wars[i].Title] = i
+			needsLLM[wars[i].pageKey().String()] = i
 		}
 	}
 	if len(needsLLM) == 0 {
@@ -509,10 +529,15 @@ func (c *Collector) fillFromDigestArchive(ctx context.Context, wars []GlobalHigh
 		dateStr := time.Now().UTC().AddDate(0, 0, -d).Format("2006-01-02")
 		hashKey := fmt.Sprintf("digest:war_analyses:%s:data", dateStr)
 
-		for title, idx := range needsLLM {
-			raw, err := c.redis.HGet(ctx, hashKey, title).Result()
+		for member, idx := range needsLLM {
+			raw, err := c.redis.HGet(ctx, hashKey, member).Result()
 			if err != nil || raw == "" {
-				continue
+				// Archives written before page keys were wiki-qualified
+				// are keyed by bare title.
+				raw, err = c.redis.HGet(ctx, hashKey, wars[idx].Title).Result()
+				if err != nil || raw == "" {
+					continue
+				}
 			}
 
 			var analysis struct {
@@ -566,12 +591,12 @@ func (c *Collector) fillFromDigestArchive(ctx context.Context, wars []GlobalHigh
 			}
 
 			c.logger.Info().
-				Str("title", title).
+				Str("page", member).
 				Str("archive_date", dateStr).
 				Msg("recovered LLM analysis from digest archive")
 
 			// Found it — remove from needsLLM so we don't keep searching.
-			delete(needsLLM, title)
+			delete(needsLLM, member)
 		}
 
 		if len(needsLLM) == 0 {
@@ -615,14 +640,14 @@ func (c *Collector) collectFunStats(ctx context.Context, since time.Time) (FunSt
 	if err != nil {
 		c.logger.Warn().Err(err).Msg("could not get edit war alerts for stats")
 	} else {
-		// Deduplicate by title to count unique edit wars
-		ewSeen := make(map[string]bool)
+		// Deduplicate by page to count unique edit wars
+		ewSeen := make(map[models.PageKey]bool)
 		for _, a := range editWars {
 			title := stringFromData(a.Data, "title")
 			if title == "" {
 				title = stringFromData(a.Data, "page_title")
 			}
-			ewSeen[title] = true
+			ewSeen[models.NewPageKey(stringFromData(a.Data, "wiki"), title)] = true
 		}
 		stats.EditWars = len(ewSeen)
 	}
@@ -662,7 +687,11 @@ func (c *Collector) collectWatchlistEvents(ctx context.Context, user *models.Use
 
 		// Otherwise query per-page daily edit counters (persistent, 8-day TTL)
 		if c.stats != nil {
-			editCount, err := c.stats.GetPageEditCount(ctx, pageTitle, global.PeriodStart)
+			page := models.ParsePageKey(pageTitle)
+			if page.IsLegacy() {
+				page.Wiki = c.wiki
+			}
+			editCount, err := c.stats.GetPageEditCount(ctx, page, global.PeriodStart)
 			if err == nil && editCount > 0 {
 				ev.EditCount = int(editCount)
 				// Consider "notable" if > 10 edits in the period
@@ -733,59 +762,3 @@ func intFromData(data map[string]interface{}, key string) int {
 	return 0
 }
 
-// countRevertsFromChanges detects reversions by analyzing byte change patterns,
-// mirroring the algorithm from processor.EditWarDetector.countReverts().
-// It looks for consecutive edits with opposite signs and similar magnitudes.
-func countRevertsFromChanges(changesStr []string) int {
-	if len(changesStr) < 2 {
-		return 0
-	}
-
-	changes := make([]int, 0, len(changesStr))
-	for _, cs := range changesStr {
-		val, err := strconv.Atoi(cs)
-		if err != nil {
-			continue
-		}
-		changes = append(changes, val)
-	}
-
-	if len(changes) < 2 {
-		return 0
-	}
-
-	revertCount := 0
-	for i := 1; i < len(changes); i++ {
-		prev := changes[i-1]
-		curr := changes[i]
-
-		// Two consecutive zero-byte edits often indicate reverts
-		if prev == 0 && curr == 0 {
-			revertCount++
-			continue
-		}
-
-		// Check for opposite signs
-		if (prev > 0 && curr < 0) || (prev < 0 && curr > 0) {
-			// For very small edits, be lenient
-			absPrev := math.Abs(float64(prev))
-			absCurr := math.Abs(float64(curr))
-
-			if absPrev < 10 && absCurr < 10 {
-				revertCount++
-				continue
-			}
-
-			// If magnitudes are within 40% of each other, likely a revert
-			ratio := absCurr / absPrev
-			if ratio > 1 {
-				ratio = absPrev / absCurr
-			}
-			if ratio >= 0.6 {
-				revertCount++
-			}
-		}
-	}
-
-	return revertCount
-}
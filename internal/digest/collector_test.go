@@ -41,6 +41,7 @@ func setupTestCollector(t *testing.T) (*Collector, *redis.Client, *miniredis.Min
 
 	logger := zerolog.Nop()
 	collector := NewCollector(trending, alerts, hotPages, stats, logger)
+	collector.SetWatchlistWiki("enwiki")
 
 	t.Cleanup(func() {
 		trending.Stop()
@@ -90,6 +91,7 @@ func seedEditWarAlert(t *testing.T, rc *redis.Client, title string, changeVolume
 		Data: map[string]interface{}{
 			"title":      title,
 			"page_title": title,
+			"wiki":       "enwiki",
 			"edit_count": changeVolume,
 			"server_url": "https://en.wikipedia.org",
 		},
@@ -119,15 +121,16 @@ func seedStats(t *testing.T, rc *redis.Client) {
 	rc.HSet(ctx, langKey, "__total__", 64000)
 }
 
-// seedPageEdits populates per-page daily counters for the given page across days.
-func seedPageEdits(t *testing.T, rc *redis.Client, title string, editsPerDay []int) {
+// seedPageEdits populates per-page daily counters for the given page, in
+// "wiki:title" form, across days.
+func seedPageEdits(t *testing.T, rc *redis.Client, page string, editsPerDay []int) {
 	t.Helper()
 	ctx := context.Background()
 
 	for i, edits := range editsPerDay {
 		d := time.Now().UTC().Add(-time.Duration(i) * 24 * time.Hour)
 		dateStr := d.Format("2006-01-02")
-		key := "stats:page:" + title + ":" + dateStr
+		key := "stats:page:" + page + ":" + dateStr
 		rc.HSet(ctx, key, "edits", edits)
 	}
 }
@@ -414,7 +417,7 @@ func TestWatchlist_PageCountersActive(t *testing.T) {
 	ctx := context.Background()
 
 	// Seed page edits: 25 edits today → should be "active" (> 10)
-	seedPageEdits(t, rc, "Bitcoin", []int{25})
+	seedPageEdits(t, rc, "enwiki:Bitcoin", []int{25})
 
 	global, _ := collector.CollectGlobal(ctx, "daily")
 
@@ -444,12 +447,37 @@ func TestWatchlist_PageCountersActive(t *testing.T) {
 	}
 }
 
+func TestWatchlist_PageCountersPerWiki(t *testing.T) {
+	collector, rc, _ := setupTestCollector(t)
+	ctx := context.Background()
+
+	seedPageEdits(t, rc, "enwiki:Paris", []int{2})
+	seedPageEdits(t, rc, "frwiki:Paris", []int{30})
+
+	global, _ := collector.CollectGlobal(ctx, "daily")
+
+	// A bare title is the page on the watchlist wiki
+	user := &models.User{
+		ID:            "user-1",
+		Watchlist:     []string{"Paris", "frwiki:Paris"},
+		DigestContent: models.DigestContentAll,
+	}
+
+	counts := map[string]int{}
+	for _, ev := range collector.PersonalizeForUser(ctx, global, user).WatchlistEvents {
+		counts[ev.Title] = ev.EditCount
+	}
+	if counts["Paris"] != 2 || counts["frwiki:Paris"] != 30 {
+		t.Errorf("edit counts = %v, want Paris 2 and frwiki:Paris 30", counts)
+	}
+}
+
 func TestWatchlist_PageCountersQuiet(t *testing.T) {
 	collector, rc, _ := setupTestCollector(t)
 	ctx := context.Background()
 
 	// Seed 3 edits today — should be "quiet" (< 10)
-	seedPageEdits(t, rc, "Knitting", []int{3})
+	seedPageEdits(t, rc, "enwiki:Knitting", []int{3})
 
 	global, _ := collector.CollectGlobal(ctx, "daily")
 
@@ -508,7 +536,7 @@ func TestWatchlist_WeeklyAggregatesMultipleDays(t *testing.T) {
 	ctx := context.Background()
 
 	// Seed 7 days of edits: [10, 20, 15, 5, 30, 10, 5] from today backward
-	seedPageEdits(t, rc, "Climate_change", []int{10, 20, 15, 5, 30, 10, 5})
+	seedPageEdits(t, rc, "enwiki:Climate_change", []int{10, 20, 15, 5, 30, 10, 5})
 
 	global, _ := collector.CollectGlobal(ctx, "weekly")
 
@@ -539,7 +567,7 @@ func TestWatchlist_GlobalHighlightTakesPrecedence(t *testing.T) {
 
 	// Bitcoin appears as edit war AND has page counters
 	seedEditWarAlert(t, rc, "Bitcoin", 200)
-	seedPageEdits(t, rc, "Bitcoin", []int{50})
+	seedPageEdits(t, rc, "enwiki:Bitcoin", []int{50})
 
 	global, _ := collector.CollectGlobal(ctx, "daily")
 
@@ -570,8 +598,8 @@ func TestWatchlist_MixedPagesSort(t *testing.T) {
 
 	// One page is edit war (notable from global), one is active, one is quiet
 	seedEditWarAlert(t, rc, "Bitcoin", 200)
-	seedPageEdits(t, rc, "Ethereum", []int{50})
-	seedPageEdits(t, rc, "Knitting", []int{2})
+	seedPageEdits(t, rc, "enwiki:Ethereum", []int{50})
+	seedPageEdits(t, rc, "enwiki:Knitting", []int{2})
 
 	global, _ := collector.CollectGlobal(ctx, "daily")
 
@@ -662,7 +690,7 @@ func TestEnrichEditWars_WithCachedAnalysis(t *testing.T) {
 		},
 	}
 	analysisJSON, _ := json.Marshal(analysis)
-	rc.Set(ctx, "editwar:analysis:Climate_change", string(analysisJSON), 0)
+	rc.Set(ctx, "editwar:analysis:enwiki:Climate_change", string(analysisJSON), 0)
 
 	data, err := collector.CollectGlobal(ctx, "daily")
 	if err != nil {
@@ -699,8 +727,8 @@ func TestEnrichEditWars_FallbackToEditorHash(t *testing.T) {
 	seedEditWarAlert(t, rc, "Bitcoin", 100)
 
 	// No LLM analysis cached, but set editor hash
-	rc.HSet(ctx, "editwar:editors:Bitcoin", "Alice", "5")
-	rc.HSet(ctx, "editwar:editors:Bitcoin", "Bob", "3")
+	rc.HSet(ctx, "editwar:editors:enwiki:Bitcoin", "Alice", "5")
+	rc.HSet(ctx, "editwar:editors:enwiki:Bitcoin", "Bob", "3")
 
 	data, err := collector.CollectGlobal(ctx, "daily")
 	if err != nil {
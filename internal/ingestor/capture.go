@@ -0,0 +1,309 @@
+package ingestor
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// captureIndexFile lists every archive file in the capture directory.
+	captureIndexFile = "index.json"
+
+	// captureFlushInterval bounds how much buffered data (and index state)
+	// a crash can lose.
+	captureFlushInterval = time.Second
+
+	captureFileTimeFormat = "20060102T150405Z"
+)
+
+// CaptureFile describes one archive file in the capture index.
+type CaptureFile struct {
+	Name       string    `json:"name"`
+	FirstEvent time.Time `json:"first_event"`
+	LastEvent  time.Time `json:"last_event"`
+	Events     int64     `json:"events"`
+	Opened     time.Time `json:"opened"`
+	Closed     time.Time `json:"closed,omitempty"` // zero while being written or after a crash
+}
+
+// CaptureIndex is the on-disk index of a capture directory, in the order
+// the files were written.
+type CaptureIndex struct {
+	Files []CaptureFile `json:"files"`
+}
+
+// LoadCaptureIndex reads the index of a capture directory. A directory
+// without an index yields an empty one.
+func LoadCaptureIndex(dir string) (*CaptureIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, captureIndexFile))
+	if os.IsNotExist(err) {
+		return &CaptureIndex{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture index: %w", err)
+	}
+
+	var idx CaptureIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse capture index: %w", err)
+	}
+	return &idx, nil
+}
+
+// Overlapping returns the files that may contain events between from and
+// to. A zero bound is open. Files with no recorded event times (e.g. cut
+// short by a crash before the first index flush) are always included.
+func (idx *CaptureIndex) Overlapping(from, to time.Time) []CaptureFile {
+	var files []CaptureFile
+	for _, f := range idx.Files {
+		if f.Events > 0 {
+			if !from.IsZero() && f.LastEvent.Before(from) {
+				continue
+			}
+			if !to.IsZero() && f.FirstEvent.After(to) {
+				continue
+			}
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func (idx *CaptureIndex) save(dir string) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal capture index: %w", err)
+	}
+	path := filepath.Join(dir, captureIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write capture index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace capture index: %w", err)
+	}
+	return nil
+}
+
+// Capture archives raw SSE payloads into time-rotated, gzip-compressed
+// NDJSON files. The archive directory can be fed straight back to the
+// ingestor as a replay source.
+type Capture struct {
+	dir       string
+	rotate    time.Duration
+	retention time.Duration
+	logger    zerolog.Logger
+	now       func() time.Time
+
+	mu        sync.Mutex
+	index     *CaptureIndex
+	file      *os.File
+	gz        *gzip.Writer
+	current   int       // index.Files position of the open file, -1 if none
+	fileEnd   time.Time // rotation deadline of the open file
+	lastFlush time.Time
+}
+
+// NewCapture opens the capture directory and applies the retention policy
+// to files left over from previous runs.
+func NewCapture(cfg config.CaptureConfig, logger zerolog.Logger) (*Capture, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+	idx, err := LoadCaptureIndex(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capture{
+		dir:       cfg.Dir,
+		rotate:    cfg.RotateInterval,
+		retention: cfg.Retention,
+		logger:    logger.With().Str("component", "sse-capture").Logger(),
+		now:       time.Now,
+		index:     idx,
+		current:   -1,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(c.now())
+	if err := c.index.save(c.dir); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Write appends one raw payload to the current archive file, rotating first
+// if the file's interval has elapsed.
+func (c *Capture) Write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.gz == nil || !now.Before(c.fileEnd) {
+		if err := c.rotateLocked(now); err != nil {
+			metrics.SSECaptureEventsTotal.WithLabelValues("error").Inc()
+			return err
+		}
+	}
+
+	_, err := c.gz.Write(data)
+	if err == nil {
+		_, err = c.gz.Write([]byte{'\n'})
+	}
+	if err != nil {
+		metrics.SSECaptureEventsTotal.WithLabelValues("error").Inc()
+		return fmt.Errorf("failed to write capture file: %w", err)
+	}
+
+	eventTime := payloadTime(data)
+	if eventTime.IsZero() {
+		eventTime = now
+	}
+	entry := &c.index.Files[c.current]
+	if entry.FirstEvent.IsZero() || eventTime.Before(entry.FirstEvent) {
+		entry.FirstEvent = eventTime
+	}
+	if eventTime.After(entry.LastEvent) {
+		entry.LastEvent = eventTime
+	}
+	entry.Events++
+	metrics.SSECaptureEventsTotal.WithLabelValues("success").Inc()
+
+	if now.Sub(c.lastFlush) >= captureFlushInterval {
+		c.flushLocked(now)
+	}
+	return nil
+}
+
+// Close finishes the open archive file and writes the final index.
+func (c *Capture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.closeFileLocked(c.now()); err != nil {
+		return err
+	}
+	return c.index.save(c.dir)
+}
+
+// flushLocked pushes buffered gzip data to disk and refreshes the index so
+// a crash loses at most one flush interval of events.
+func (c *Capture) flushLocked(now time.Time) {
+	c.lastFlush = now
+	if err := c.gz.Flush(); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to flush capture file")
+	}
+	if err := c.index.save(c.dir); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to write capture index")
+	}
+}
+
+// rotateLocked closes the current file, expires old ones and opens a file
+// for the interval containing now. Files are named after their interval
+// start. A restart within the same interval never appends to the existing
+// file, whose last gzip member may have been cut short by a crash and would
+// end replay there; it opens the next free sequence-suffixed name instead,
+// e.g. events-20240101T100000Z-1.ndjson.gz.
+func (c *Capture) rotateLocked(now time.Time) error {
+	if err := c.closeFileLocked(now); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to close capture file")
+	}
+	c.expire(now)
+
+	start := now.UTC().Truncate(c.rotate)
+	base := "events-" + start.Format(captureFileTimeFormat)
+
+	var name string
+	var f *os.File
+	for seq := 0; ; seq++ {
+		name = base + ".ndjson.gz"
+		if seq > 0 {
+			name = fmt.Sprintf("%s-%d.ndjson.gz", base, seq)
+		}
+		var err error
+		f, err = os.OpenFile(filepath.Join(c.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to open capture file: %w", err)
+		}
+	}
+
+	c.index.Files = append(c.index.Files, CaptureFile{Name: name, Opened: now})
+	c.current = len(c.index.Files) - 1
+
+	c.file = f
+	c.gz = gzip.NewWriter(f)
+	c.fileEnd = start.Add(c.rotate)
+	c.lastFlush = now
+	metrics.SSECaptureFilesTotal.WithLabelValues("opened").Inc()
+
+	if err := c.index.save(c.dir); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to write capture index")
+	}
+	c.logger.Info().Str("file", name).Time("rotates_at", c.fileEnd).Msg("Opened capture file")
+	return nil
+}
+
+func (c *Capture) closeFileLocked(now time.Time) error {
+	if c.gz == nil {
+		return nil
+	}
+	gzErr := c.gz.Close()
+	fileErr := c.file.Close()
+	c.index.Files[c.current].Closed = now
+	c.gz, c.file, c.current = nil, nil, -1
+	metrics.SSECaptureFilesTotal.WithLabelValues("closed").Inc()
+
+	if gzErr != nil {
+		return fmt.Errorf("failed to finish capture file: %w", gzErr)
+	}
+	if fileErr != nil {
+		return fmt.Errorf("failed to close capture file: %w", fileErr)
+	}
+	return nil
+}
+
+// expire deletes closed files that ended more than the retention period
+// ago. Retention is measured in wall-clock time rather than event time so
+// that capturing a replay of old traffic does not delete it immediately.
+// Files never marked closed (left by a crash) age from when they opened.
+// Must be called with no file open, since it reorders the index.
+func (c *Capture) expire(now time.Time) {
+	if c.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-c.retention)
+
+	kept := c.index.Files[:0]
+	for _, f := range c.index.Files {
+		ended := f.Closed
+		if ended.IsZero() {
+			ended = f.Opened
+		}
+		if !ended.Before(cutoff) {
+			kept = append(kept, f)
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name)); err != nil && !os.IsNotExist(err) {
+			c.logger.Warn().Err(err).Str("file", f.Name).Msg("Failed to delete expired capture file")
+			kept = append(kept, f)
+			continue
+		}
+		metrics.SSECaptureFilesTotal.WithLabelValues("expired").Inc()
+		c.logger.Info().Str("file", f.Name).Msg("Deleted expired capture file")
+	}
+	c.index.Files = kept
+}
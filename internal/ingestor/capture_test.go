@@ -0,0 +1,255 @@
+package ingestor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func newTestCapture(t *testing.T, dir string, clock *time.Time) *Capture {
+	t.Helper()
+	c, err := NewCapture(config.CaptureConfig{
+		Dir:            dir,
+		RotateInterval: time.Hour,
+		Retention:      24 * time.Hour,
+	}, zerolog.New(nil))
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+	c.now = func() time.Time { return *clock }
+	return c
+}
+
+func replayAll(t *testing.T, src *ReplaySource) []string {
+	t.Helper()
+	var got []string
+	err := src.Subscribe(context.Background(), nil, func(e *Event) {
+		got = append(got, string(e.Data))
+	})
+	if !errors.Is(err, ErrSourceExhausted) {
+		t.Fatalf("Subscribe() error = %v, want ErrSourceExhausted", err)
+	}
+	return got
+}
+
+func TestCapture_RotatesAndIndexes(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 59, 0, 0, time.UTC)
+	c := newTestCapture(t, dir, &clock)
+
+	first := recordedEdit(1, clock.Add(-2*time.Second))
+	if err := c.Write([]byte(first)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Raw payloads are captured even if they are not valid edits.
+	if err := c.Write([]byte(`{"not":"an edit"}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	clock = clock.Add(2 * time.Minute) // crosses the 11:00 boundary
+	second := recordedEdit(2, clock)
+	if err := c.Write([]byte(second)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	idx, err := LoadCaptureIndex(dir)
+	if err != nil {
+		t.Fatalf("LoadCaptureIndex() error = %v", err)
+	}
+	if len(idx.Files) != 2 {
+		t.Fatalf("index has %d files, want 2", len(idx.Files))
+	}
+	if idx.Files[0].Name != "events-20240501T100000Z.ndjson.gz" || idx.Files[0].Events != 2 {
+		t.Errorf("first file = %+v", idx.Files[0])
+	}
+	if !idx.Files[0].FirstEvent.Equal(time.Date(2024, 5, 1, 10, 58, 58, 0, time.UTC)) {
+		t.Errorf("first file first_event = %v", idx.Files[0].FirstEvent)
+	}
+	for _, f := range idx.Files {
+		if f.Closed.IsZero() {
+			t.Errorf("file %s not marked closed", f.Name)
+		}
+	}
+
+	got := replayAll(t, NewReplaySource(dir, 0))
+	want := []string{first, `{"not":"an edit"}`, second}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCapture_ReplayWindowUsesIndex(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := newTestCapture(t, dir, &clock)
+
+	for i := int64(0); i < 3; i++ {
+		if err := c.Write([]byte(recordedEdit(i+1, clock))); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		clock = clock.Add(time.Hour)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Remove the first file: if the index is honoured it is never opened.
+	os.Remove(filepath.Join(dir, "events-20240501T100000Z.ndjson.gz"))
+
+	src := NewReplaySource(dir, 0)
+	src.SetWindow(time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 11, 30, 0, 0, time.UTC))
+	got := replayAll(t, src)
+	if len(got) != 1 || got[0] != recordedEdit(2, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("windowed replay = %v, want only the 11:00 event", got)
+	}
+}
+
+func TestCapture_Retention(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := newTestCapture(t, dir, &clock)
+	if err := c.Write([]byte(recordedEdit(1, clock))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Two days later the next rotation drops the old file.
+	clock = clock.Add(48 * time.Hour)
+	if err := c.Write([]byte(recordedEdit(2, clock))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "events-20240501T100000Z.ndjson.gz")); !os.IsNotExist(err) {
+		t.Errorf("expired file still present (stat err = %v)", err)
+	}
+	idx, _ := LoadCaptureIndex(dir)
+	if len(idx.Files) != 1 || idx.Files[0].Name != "events-20240503T100000Z.ndjson.gz" {
+		t.Errorf("index after retention = %+v", idx.Files)
+	}
+}
+
+func TestCapture_CrashedFileIsReplayable(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := newTestCapture(t, dir, &clock)
+
+	if err := c.Write([]byte(recordedEdit(1, clock))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	clock = clock.Add(captureFlushInterval)
+	if err := c.Write([]byte(recordedEdit(2, clock))); err != nil { // triggers a flush
+		t.Fatalf("Write() error = %v", err)
+	}
+	// No Close: the gzip stream has no trailer, as after a crash.
+
+	got := replayAll(t, NewReplaySource(dir, 0))
+	if len(got) != 2 {
+		t.Errorf("replayed %d events from unfinished file, want 2", len(got))
+	}
+}
+
+func TestCapture_RestartAfterTruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := newTestCapture(t, dir, &clock)
+
+	if err := c.Write([]byte(recordedEdit(1, clock))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	clock = clock.Add(captureFlushInterval)
+	if err := c.Write([]byte(recordedEdit(2, clock))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Crash mid-write: the file ends partway through a gzip block.
+	crashed := filepath.Join(dir, "events-20240501T100000Z.ndjson.gz")
+	info, err := os.Stat(crashed)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if err := os.Truncate(crashed, info.Size()-3); err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+
+	// Restart within the same hour. Retention is off so that NewCapture,
+	// which runs before the test clock is installed, keeps the old file.
+	clock = clock.Add(time.Minute)
+	restarted, err := NewCapture(config.CaptureConfig{Dir: dir, RotateInterval: time.Hour}, zerolog.New(nil))
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+	restarted.now = func() time.Time { return clock }
+	later := recordedEdit(3, clock)
+	if err := restarted.Write([]byte(later)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := restarted.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	idx, err := LoadCaptureIndex(dir)
+	if err != nil {
+		t.Fatalf("LoadCaptureIndex() error = %v", err)
+	}
+	if len(idx.Files) != 2 || idx.Files[1].Name != "events-20240501T100000Z-1.ndjson.gz" {
+		t.Fatalf("index after restart = %+v", idx.Files)
+	}
+
+	got := replayAll(t, NewReplaySource(dir, 0))
+	if len(got) == 0 || got[len(got)-1] != later {
+		t.Errorf("replay = %v, want it to end with the event written after the restart", got)
+	}
+
+	// Without an index, the suffixed file still sorts after the crashed one.
+	if err := os.Remove(filepath.Join(dir, captureIndexFile)); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	got = replayAll(t, NewReplaySource(dir, 0))
+	if len(got) == 0 || got[len(got)-1] != later {
+		t.Errorf("replay without index = %v, want it to end with the event written after the restart", got)
+	}
+}
+
+func TestWikiStreamClient_CapturesBeforeFiltering(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Ingestor: config.Ingestor{
+			RateLimit:  1000,
+			BurstLimit: 1000,
+			Capture:    config.CaptureConfig{Enabled: true, Dir: dir, RotateInterval: time.Hour, Retention: 24 * time.Hour},
+		},
+	}
+	producer := newMockProducer()
+	client := NewWikiStreamClient(cfg, zerolog.New(nil), producer)
+
+	stale := recordedEdit(1, time.Now().Add(-48*time.Hour)) // rejected by Validate
+	client.processEvent(&Event{Data: []byte(stale)})
+	client.processEvent(&Event{Data: []byte(`garbage`)})
+	client.Stop()
+
+	if n := len(producer.GetProducedEdits()); n != 0 {
+		t.Fatalf("produced %d edits, want 0", n)
+	}
+	got := replayAll(t, NewReplaySource(dir, 0))
+	if len(got) != 2 || got[0] != stale {
+		t.Errorf("captured %v, want both raw payloads", got)
+	}
+}
@@ -0,0 +1,186 @@
+package ingestor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Checkpoint is the persisted resume position of the SSE stream.
+type Checkpoint struct {
+	EventID string    `json:"event_id"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// CheckpointStore persists the last processed SSE event ID so that a
+// restarted ingestor can resume via Last-Event-ID instead of silently
+// skipping the events emitted while it was down.
+type CheckpointStore interface {
+	// Load returns the stored checkpoint, or nil if none has been written.
+	Load(ctx context.Context) (*Checkpoint, error)
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+// NewCheckpointStore builds the store selected by cfg.Ingestor.Checkpoint.
+func NewCheckpointStore(cfg *config.Config) (CheckpointStore, error) {
+	cpCfg := cfg.Ingestor.Checkpoint
+	switch cpCfg.Backend {
+	case "", "file":
+		return NewFileCheckpointStore(cpCfg.Path), nil
+	case "redis":
+		opt, err := redis.ParseURL(cfg.Redis.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		return NewRedisCheckpointStore(redis.NewClient(opt), cpCfg.RedisKey, cpCfg.MaxAge), nil
+	default:
+		return nil, fmt.Errorf("unknown checkpoint backend %q", cpCfg.Backend)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// File backend
+// ---------------------------------------------------------------------------
+
+// FileCheckpointStore keeps the checkpoint in a local JSON file. Writes go
+// to a temp file that is renamed into place so a crash mid-write never
+// leaves a truncated checkpoint behind.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a file-backed checkpoint store.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load reads the checkpoint file.
+func (f *FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save atomically replaces the checkpoint file.
+func (f *FileCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	if dir := filepath.Dir(f.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Redis backend
+// ---------------------------------------------------------------------------
+
+// RedisCheckpointStore keeps the checkpoint in a single Redis key. The key
+// expires after maxAge, since a checkpoint that old would be discarded on
+// load anyway.
+type RedisCheckpointStore struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+}
+
+// NewRedisCheckpointStore creates a Redis-backed checkpoint store.
+func NewRedisCheckpointStore(client *redis.Client, key string, maxAge time.Duration) *RedisCheckpointStore {
+	return &RedisCheckpointStore{client: client, key: key, ttl: maxAge}
+}
+
+// Load reads the checkpoint key.
+func (r *RedisCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := r.client.Get(ctx, r.key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint key: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint key: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save overwrites the checkpoint key.
+func (r *RedisCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := r.client.Set(ctx, r.key, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write checkpoint key: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// eventIDTime extracts the newest position timestamp from an EventStreams
+// event ID. IDs are JSON arrays of per-partition positions, e.g.
+// [{"topic":"eqiad.mediawiki.recentchange","partition":0,"timestamp":1700000000123}].
+// Offset-only IDs carry no time, in which case ok is false.
+func eventIDTime(id string) (t time.Time, ok bool) {
+	var positions []struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(id), &positions); err != nil {
+		return time.Time{}, false
+	}
+
+	var newest int64
+	for _, p := range positions {
+		if p.Timestamp > newest {
+			newest = p.Timestamp
+		}
+	}
+	if newest <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(newest), true
+}
+
+// checkpointAge returns how far behind "now" a checkpoint is. The event
+// timestamp embedded in the ID is preferred because it reflects the actual
+// stream position; SavedAt is the fallback for offset-only IDs.
+func checkpointAge(cp *Checkpoint, now time.Time) time.Duration {
+	if t, ok := eventIDTime(cp.EventID); ok {
+		return now.Sub(t)
+	}
+	return now.Sub(cp.SavedAt)
+}
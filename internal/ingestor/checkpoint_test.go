@@ -0,0 +1,183 @@
+package ingestor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// memCheckpointStore is an in-memory CheckpointStore for client tests.
+type memCheckpointStore struct {
+	cp      *Checkpoint
+	saves   int
+	loadErr error
+}
+
+func (m *memCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	if m.loadErr != nil {
+		return nil, m.loadErr
+	}
+	return m.cp, nil
+}
+
+func (m *memCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	c := *cp
+	m.cp = &c
+	m.saves++
+	return nil
+}
+
+func eventIDAt(t time.Time) string {
+	return fmt.Sprintf(`[{"topic":"eqiad.mediawiki.recentchange","partition":0,"timestamp":%d}]`, t.UnixMilli())
+}
+
+func TestFileCheckpointStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "checkpoint.json")
+	store := NewFileCheckpointStore(path)
+	ctx := context.Background()
+
+	cp, err := store.Load(ctx)
+	if err != nil || cp != nil {
+		t.Fatalf("Load() on missing file = %v, %v; want nil, nil", cp, err)
+	}
+
+	saved := &Checkpoint{EventID: eventIDAt(time.Now()), SavedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := store.Save(ctx, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	cp, err = store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cp.EventID != saved.EventID || !cp.SavedAt.Equal(saved.SavedAt) {
+		t.Errorf("Load() = %+v, want %+v", cp, saved)
+	}
+}
+
+func TestRedisCheckpointStore_RoundTrip(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	store := NewRedisCheckpointStore(client, "ingestor:sse:checkpoint", time.Hour)
+	ctx := context.Background()
+
+	cp, err := store.Load(ctx)
+	if err != nil || cp != nil {
+		t.Fatalf("Load() on missing key = %v, %v; want nil, nil", cp, err)
+	}
+
+	if err := store.Save(ctx, &Checkpoint{EventID: "abc", SavedAt: time.Now()}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cp, err = store.Load(ctx)
+	if err != nil || cp == nil || cp.EventID != "abc" {
+		t.Fatalf("Load() = %v, %v; want event ID abc", cp, err)
+	}
+
+	if ttl := mr.TTL("ingestor:sse:checkpoint"); ttl != time.Hour {
+		t.Errorf("checkpoint TTL = %v, want %v", ttl, time.Hour)
+	}
+}
+
+func TestEventIDTime(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+
+	got, ok := eventIDTime(`[{"topic":"a","partition":0,"timestamp":1700000000000},{"topic":"b","partition":0,"timestamp":1700000000123}]`)
+	if !ok || !got.Equal(ts) {
+		t.Errorf("eventIDTime() = %v, %v; want %v, true", got, ok, ts)
+	}
+
+	if _, ok := eventIDTime(`[{"topic":"a","partition":0,"offset":42}]`); ok {
+		t.Error("eventIDTime() should not find a time in offset-only IDs")
+	}
+	if _, ok := eventIDTime("not json"); ok {
+		t.Error("eventIDTime() should reject malformed IDs")
+	}
+}
+
+func TestWikiStreamClient_RestoreCheckpoint(t *testing.T) {
+	logger := zerolog.New(nil)
+	cfg := &config.Config{
+		Ingestor: config.Ingestor{
+			Checkpoint: config.CheckpointConfig{MaxAge: 24 * time.Hour},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		cp       *Checkpoint
+		expectID bool
+	}{
+		{"no checkpoint", nil, false},
+		{"recent checkpoint", &Checkpoint{EventID: eventIDAt(time.Now().Add(-time.Minute)), SavedAt: time.Now()}, true},
+		{"beyond replay horizon", &Checkpoint{EventID: eventIDAt(time.Now().Add(-48 * time.Hour)), SavedAt: time.Now()}, false},
+		{"offset-only ID uses saved time", &Checkpoint{EventID: `[{"offset":1}]`, SavedAt: time.Now().Add(-48 * time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewWikiStreamClient(cfg, logger, newMockProducer())
+			client.SetCheckpointStore(&memCheckpointStore{cp: tt.cp})
+
+			got := string(client.lastEventID)
+			if tt.expectID && got != tt.cp.EventID {
+				t.Errorf("lastEventID = %q, want %q", got, tt.cp.EventID)
+			}
+			if !tt.expectID && got != "" {
+				t.Errorf("lastEventID = %q, want empty (start from now)", got)
+			}
+		})
+	}
+}
+
+func TestWikiStreamClient_SaveCheckpointOnlyWhenMoved(t *testing.T) {
+	logger := zerolog.New(nil)
+	client := NewWikiStreamClient(&config.Config{}, logger, newMockProducer())
+	store := &memCheckpointStore{}
+	client.SetCheckpointStore(store)
+
+	client.saveCheckpoint()
+	if store.saves != 0 {
+		t.Fatalf("saves = %d before any event, want 0", store.saves)
+	}
+
+	client.lastEventID = []byte("id-1")
+	client.saveCheckpoint()
+	client.saveCheckpoint()
+	if store.saves != 1 {
+		t.Errorf("saves = %d after unchanged ID, want 1", store.saves)
+	}
+
+	client.lastEventID = []byte("id-2")
+	client.saveCheckpoint()
+	if store.saves != 2 || store.cp.EventID != "id-2" {
+		t.Errorf("saves = %d, stored = %q; want 2, id-2", store.saves, store.cp.EventID)
+	}
+}
+
+func TestWikiStreamClient_RestoreRetriedAfterLoadError(t *testing.T) {
+	logger := zerolog.New(nil)
+	client := NewWikiStreamClient(&config.Config{}, logger, newMockProducer())
+	store := &memCheckpointStore{loadErr: fmt.Errorf("redis down")}
+	client.SetCheckpointStore(store)
+
+	if client.checkpointLoaded {
+		t.Fatal("checkpointLoaded should stay false after a load error")
+	}
+
+	store.loadErr = nil
+	store.cp = &Checkpoint{EventID: eventIDAt(time.Now()), SavedAt: time.Now()}
+	client.restoreCheckpoint()
+	if string(client.lastEventID) != store.cp.EventID {
+		t.Errorf("lastEventID = %q after retry, want %q", client.lastEventID, store.cp.EventID)
+	}
+}
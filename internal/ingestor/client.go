@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -15,10 +16,8 @@ import (
 	"github.com/Agnikulu/WikiSurge/internal/kafka"
 	"github.com/Agnikulu/WikiSurge/internal/metrics"
 	"github.com/Agnikulu/WikiSurge/internal/models"
-	"github.com/r3labs/sse/v2"
 	"github.com/rs/zerolog"
 	"golang.org/x/time/rate"
-	"gopkg.in/cenkalti/backoff.v1"
 )
 
 const (
@@ -47,20 +46,32 @@ func sseTransport() *http.Transport {
 	}
 }
 
-// WikiStreamClient handles connection to Wikipedia's SSE stream
+// WikiStreamClient reads recent changes from an EventSource (by default
+// Wikipedia's SSE stream) and forwards them to Kafka
 type WikiStreamClient struct {
-	sseClient         *sse.Client
+	source            EventSource
 	config            *config.Config
 	logger            zerolog.Logger
 	rateLimiter       *rate.Limiter
 	producer          kafka.ProducerInterface
+	logProducer       kafka.ProducerInterface // MediaWiki log events; nil = dropped
 	stopChan          chan struct{}
+	doneChan          chan struct{} // closed when the event loop exits
 	reconnectDelay    time.Duration
 	wg                sync.WaitGroup
 	mu                sync.RWMutex
 	isRunning         bool
 	rateLimitHitCount int64
 	lastEventID       []byte // tracks the last SSE event ID for gap-free reconnects
+
+	// Raw payload archive (nil when disabled)
+	capture *Capture
+
+	// Durable resume checkpoint (nil when disabled)
+	checkpoint         CheckpointStore
+	checkpointLoaded   bool
+	lastSavedEventID   string
+	lastCheckpointTime time.Time
 }
 
 // NewWikiStreamClient creates a new Wikipedia SSE client
@@ -68,23 +79,187 @@ func NewWikiStreamClient(cfg *config.Config, logger zerolog.Logger, producer kaf
 	// Create rate limiter with configured limits
 	rateLimiter := rate.NewLimiter(rate.Limit(cfg.Ingestor.RateLimit), cfg.Ingestor.BurstLimit)
 	
-	// Create SSE client with robust transport
-	client := sse.NewClient(WikipediaSSEURL)
-	client.Connection.Transport = sseTransport()
-	client.Headers = map[string]string{
-		"Accept":     "text/event-stream",
-		"User-Agent": UserAgent,
-	}
-	
-	return &WikiStreamClient{
-		sseClient:      client,
+	w := &WikiStreamClient{
 		config:         cfg,
 		logger:         logger.With().Str("component", "sse-client").Logger(),
 		rateLimiter:    rateLimiter,
 		producer:       producer,
 		stopChan:       make(chan struct{}),
+		doneChan:       make(chan struct{}),
 		reconnectDelay: cfg.Ingestor.ReconnectDelay,
 	}
+
+	source, err := NewEventSource(cfg)
+	if err != nil {
+		w.logger.Error().Err(err).Msg("Invalid event source, falling back to Wikipedia EventStreams")
+		source = NewSSESource(WikipediaSSEURL)
+	}
+	w.source = source
+
+	if cfg.Ingestor.Capture.Enabled {
+		capture, err := NewCapture(cfg.Ingestor.Capture, logger)
+		if err != nil {
+			w.logger.Error().Err(err).Msg("Failed to open capture directory, raw events will not be archived")
+		} else {
+			w.capture = capture
+		}
+	}
+
+	// Restore the durable resume checkpoint so the first stream request
+	// carries Last-Event-ID and replays what we missed while down. Replays
+	// carry no event IDs, so there is nothing to checkpoint for them.
+	if cfg.Ingestor.Checkpoint.Enabled && source.Live() {
+		store, err := NewCheckpointStore(cfg)
+		if err != nil {
+			w.logger.Error().Err(err).Msg("Failed to create checkpoint store, resuming from now")
+		} else {
+			w.checkpoint = store
+			w.restoreCheckpoint()
+		}
+	}
+
+	return w
+}
+
+// SetLogEventProducer enables forwarding of MediaWiki log events (page
+// protections, blocks, deletions, moves) to their own Kafka topic. Without
+// it log events are filtered out like any other non-edit type.
+func (w *WikiStreamClient) SetLogEventProducer(producer kafka.ProducerInterface) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logProducer = producer
+}
+
+// SetEventSource overrides the event source. Must be called before Start.
+func (w *WikiStreamClient) SetEventSource(source EventSource) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.source = source
+}
+
+// SetCheckpointStore overrides the checkpoint store and reloads the resume
+// position from it. Must be called before Start.
+func (w *WikiStreamClient) SetCheckpointStore(store CheckpointStore) {
+	w.mu.Lock()
+	w.checkpoint = store
+	w.checkpointLoaded = false
+	w.mu.Unlock()
+	w.restoreCheckpoint()
+}
+
+// restoreCheckpoint loads the stored event ID into lastEventID. A
+// checkpoint older than the configured max age is discarded so the stream
+// starts from "now" rather than asking EventStreams for a position it no
+// longer retains (or for edits Validate would reject as too old).
+func (w *WikiStreamClient) restoreCheckpoint() {
+	if w.checkpoint == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cp, err := w.checkpoint.Load(ctx)
+	if err != nil {
+		metrics.SSECheckpointRestoresTotal.WithLabelValues("error").Inc()
+		w.logger.Warn().Err(err).Msg("Failed to load SSE checkpoint, will retry on next connect")
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checkpointLoaded = true
+
+	if cp == nil || cp.EventID == "" {
+		metrics.SSECheckpointRestoresTotal.WithLabelValues("missing").Inc()
+		w.logger.Info().Msg("No SSE checkpoint found, starting from now")
+		return
+	}
+
+	age := checkpointAge(cp, time.Now())
+	if maxAge := w.config.Ingestor.Checkpoint.MaxAge; maxAge > 0 && age > maxAge {
+		metrics.SSECheckpointRestoresTotal.WithLabelValues("expired").Inc()
+		w.logger.Warn().
+			Dur("age", age).
+			Dur("max_age", maxAge).
+			Str("last_event_id", cp.EventID).
+			Msg("SSE checkpoint is past the replay horizon, starting from now")
+		return
+	}
+
+	w.lastEventID = []byte(cp.EventID)
+	w.lastSavedEventID = cp.EventID
+	w.lastCheckpointTime = cp.SavedAt
+	metrics.SSECheckpointRestoresTotal.WithLabelValues("resumed").Inc()
+	w.logger.Info().
+		Dur("age", age).
+		Str("last_event_id", cp.EventID).
+		Msg("Restored SSE checkpoint")
+}
+
+// checkpointLoop periodically flushes lastEventID to the checkpoint store.
+// Writes are skipped when the ID has not moved since the last flush.
+func (w *WikiStreamClient) checkpointLoop() {
+	defer w.wg.Done()
+
+	interval := w.config.Ingestor.Checkpoint.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			w.saveCheckpoint()
+		}
+	}
+}
+
+// saveCheckpoint writes the current lastEventID if it changed and refreshes
+// the checkpoint age gauge.
+func (w *WikiStreamClient) saveCheckpoint() {
+	w.mu.RLock()
+	store := w.checkpoint
+	eventID := string(w.lastEventID)
+	lastSaved := w.lastSavedEventID
+	lastTime := w.lastCheckpointTime
+	w.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	if eventID == "" || eventID == lastSaved {
+		if !lastTime.IsZero() {
+			metrics.SSECheckpointAgeSeconds.WithLabelValues().Set(time.Since(lastTime).Seconds())
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	if err := store.Save(ctx, &Checkpoint{EventID: eventID, SavedAt: now}); err != nil {
+		metrics.SSECheckpointWritesTotal.WithLabelValues("error").Inc()
+		w.logger.Warn().Err(err).Msg("Failed to persist SSE checkpoint")
+		if !lastTime.IsZero() {
+			metrics.SSECheckpointAgeSeconds.WithLabelValues().Set(time.Since(lastTime).Seconds())
+		}
+		return
+	}
+
+	w.mu.Lock()
+	w.lastSavedEventID = eventID
+	w.lastCheckpointTime = now
+	w.mu.Unlock()
+
+	metrics.SSECheckpointWritesTotal.WithLabelValues("success").Inc()
+	metrics.SSECheckpointAgeSeconds.WithLabelValues().Set(0)
 }
 
 // Connect establishes the SSE connection to Wikipedia EventStreams
@@ -96,31 +271,17 @@ func (w *WikiStreamClient) Connect() error {
 		return fmt.Errorf("client is already running")
 	}
 	
-	w.logger.Info().Msg("Establishing SSE connection to Wikipedia EventStreams")
+	w.logger.Info().Str("source", w.source.Name()).Msg("Checking event source")
 	
-	// Test connection with a simple HTTP request first
+	// Test the source before starting the event loop
 	ctx, cancel := context.WithTimeout(context.Background(), ConnectionTimeout)
 	defer cancel()
 	
-	req, err := http.NewRequestWithContext(ctx, "GET", WikipediaSSEURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create test request: %w", err)
+	if err := w.source.Preflight(ctx); err != nil {
+		return fmt.Errorf("failed to connect to event source %s: %w", w.source.Name(), err)
 	}
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("User-Agent", UserAgent)
 	
-	client := &http.Client{Timeout: ConnectionTimeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Wikipedia SSE: %w", err)
-	}
-	resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-	
-	w.logger.Info().Msg("SSE connection established successfully")
+	w.logger.Info().Str("source", w.source.Name()).Msg("Event source reachable")
 	return nil
 }
 
@@ -134,17 +295,32 @@ func (w *WikiStreamClient) Start() error {
 	w.isRunning = true
 	w.mu.Unlock()
 	
-	w.logger.Info().Msg("Starting Wikipedia SSE client")
+	w.logger.Info().
+		Str("source", w.source.Name()).
+		Bool("live", w.source.Live()).
+		Msg("Starting Wikipedia SSE client")
 	
 	w.wg.Add(1)
 	go w.eventLoop()
+
+	if w.checkpoint != nil {
+		w.wg.Add(1)
+		go w.checkpointLoop()
+	}
 	
 	return nil
 }
 
+// Done returns a channel that is closed when the event loop exits, either
+// after Stop or once a finite source such as a replay file is exhausted.
+func (w *WikiStreamClient) Done() <-chan struct{} {
+	return w.doneChan
+}
+
 // eventLoop is the main processing loop that handles SSE events and reconnections
 func (w *WikiStreamClient) eventLoop() {
 	defer w.wg.Done()
+	defer close(w.doneChan)
 	defer func() {
 		w.mu.Lock()
 		w.isRunning = false
@@ -158,6 +334,17 @@ func (w *WikiStreamClient) eventLoop() {
 			return
 		default:
 			if err := w.processStream(); err != nil {
+				// Finite sources are not restarted: reopening a replay
+				// would deliver every event a second time.
+				if !w.source.Live() {
+					if errors.Is(err, ErrSourceExhausted) {
+						w.logger.Info().Str("source", w.source.Name()).Msg("Event source exhausted, stopping event loop")
+					} else {
+						w.logger.Error().Err(err).Str("source", w.source.Name()).Msg("Event source failed, stopping event loop")
+					}
+					return
+				}
+
 				w.logger.Error().Err(err).Msg("Stream processing failed, will reconnect")
 				metrics.SSEReconnectionsTotal.WithLabelValues().Inc()
 
@@ -203,31 +390,19 @@ func (w *WikiStreamClient) eventLoop() {
 	}
 }
 
-// checkConnectivity does a lightweight HTTP HEAD to the SSE endpoint to verify
-// network reachability.  This catches DNS failures, firewalls, and HTTP errors
-// that the r3labs/sse library would otherwise silently swallow.
+// checkConnectivity runs the source's preflight check.  For SSE sources
+// this is a lightweight GET that catches DNS failures, firewalls, and HTTP
+// errors that the r3labs/sse library would otherwise silently swallow.
 func (w *WikiStreamClient) checkConnectivity() error {
-	client := &http.Client{Timeout: ConnectionTimeout}
-	req, err := http.NewRequest("GET", WikipediaSSEURL, nil)
-	if err != nil {
-		return fmt.Errorf("build request: %w", err)
-	}
-	req.Header.Set("User-Agent", UserAgent)
-	req.Header.Set("Accept", "text/event-stream")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		w.logger.Error().Err(err).Str("url", WikipediaSSEURL).Msg("Cannot reach Wikipedia SSE endpoint")
-		return fmt.Errorf("HTTP request failed: %w", err)
-	}
-	resp.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), ConnectionTimeout)
+	defer cancel()
 
-	if resp.StatusCode >= 400 {
-		w.logger.Error().Int("status", resp.StatusCode).Str("url", WikipediaSSEURL).Msg("Wikipedia SSE endpoint returned error")
-		return fmt.Errorf("HTTP %d from SSE endpoint", resp.StatusCode)
+	if err := w.source.Preflight(ctx); err != nil {
+		w.logger.Error().Err(err).Str("source", w.source.Name()).Msg("Event source preflight failed")
+		return err
 	}
 
-	w.logger.Info().Int("status", resp.StatusCode).Msg("Preflight connectivity check passed")
+	w.logger.Info().Str("source", w.source.Name()).Msg("Preflight connectivity check passed")
 	return nil
 }
 
@@ -239,121 +414,132 @@ func (w *WikiStreamClient) processStream() error {
 		return fmt.Errorf("preflight check failed: %w", err)
 	}
 
-	// Grab the last event ID we tracked from the previous stream session.
-	// Wikipedia EventStreams uses JSON-array IDs (not timestamps), so
-	// we pass them via the Last-Event-ID header (handled by r3labs/sse)
-	// rather than the ?since= query parameter.
+	// If the checkpoint could not be read at startup (e.g. Redis was not
+	// up yet), try again before the first stream request goes out.
+	w.mu.RLock()
+	needRestore := w.checkpoint != nil && !w.checkpointLoaded && len(w.lastEventID) == 0
+	w.mu.RUnlock()
+	if needRestore {
+		w.restoreCheckpoint()
+	}
+
+	// Grab the last event ID we tracked from the previous stream session
+	// so the source can resume without a gap.
 	w.mu.RLock()
 	lastID := w.lastEventID
 	w.mu.RUnlock()
 
-	// Create a fresh SSE client for each stream attempt to avoid stale
-	// internal state from the r3labs/sse library's own retry logic.
-	sseClient := sse.NewClient(WikipediaSSEURL)
-	transport := sseTransport()
-	sseClient.Connection.Transport = transport
-	sseClient.Headers = map[string]string{
-		"Accept":     "text/event-stream",
-		"User-Agent": UserAgent,
-	}
-
-	// Close this transport's idle connections when the stream attempt ends.
-	// Without this, each reconnect (~every 3-7 min) leaks a transport with
-	// its TCP/TLS connection pool, causing FD exhaustion after days.
-	defer transport.CloseIdleConnections()
-
-	// Seed the library's LastEventID so the Last-Event-ID header is sent
-	// on the initial request, enabling Wikipedia to replay missed events.
-	if len(lastID) > 0 {
-		sseClient.LastEventID.Store(lastID)
+	if len(lastID) > 0 && w.source.Live() {
 		w.logger.Info().
 			Str("last_event_id", string(lastID)).
 			Msg("Resuming stream from last known event ID")
 	}
 
-	// Disable the library's internal auto-reconnect so our outer loop
-	// controls reconnection with proper backoff and idle detection.
-	sseClient.ReconnectStrategy = &backoff.StopBackOff{}
-
-	// Use a cancellable context so we can tear down the SSE subscription
+	// Use a cancellable context so we can tear down the subscription
 	// when we detect an idle timeout or a stop signal.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// eventChan bridges the callback-based SubscribeWithContext into our
-	// select loop.  We use SubscribeWithContext instead of
-	// SubscribeChanWithContext because the latter returns immediately
-	// with nil error when StopBackOff is set, never delivering events.
-	eventChan := make(chan *sse.Event, 64)
+	// eventChan bridges the callback-based Subscribe into our select loop.
+	eventChan := make(chan *Event, 64)
 
-	// subDone signals when SubscribeWithContext returns, meaning the
-	// underlying HTTP connection was lost or the stream ended.
+	// subDone signals when Subscribe returns, meaning the underlying
+	// connection was lost or the source ended.
 	subDone := make(chan error, 1)
 
 	go func() {
-		err := sseClient.SubscribeWithContext(ctx, "message", func(msg *sse.Event) {
+		subDone <- w.source.Subscribe(ctx, lastID, func(event *Event) {
 			select {
-			case eventChan <- msg:
+			case eventChan <- event:
 			case <-ctx.Done():
 			}
 		})
-		subDone <- err
 	}()
 
 	// Idle timeout: if no events arrive within this duration, assume the
 	// stream is stalled and force a reconnect.
+	// Finite sources pace themselves and may legitimately pause longer
+	// than this, so the watchdog only applies to live streams.
 	const idleTimeout = 2 * time.Minute
 	idleTimer := time.NewTimer(idleTimeout)
 	defer idleTimer.Stop()
+	if !w.source.Live() {
+		idleTimer.Stop()
+	}
 
-	w.logger.Info().Msg("Started processing SSE events")
+	w.logger.Info().Str("source", w.source.Name()).Msg("Started processing SSE events")
 
 	for {
 		select {
 		case <-w.stopChan:
 			return nil
 		case subErr := <-subDone:
-			// The SSE subscription goroutine exited — the stream is dead.
+			// The subscription goroutine exited — the stream is dead.
+			// Drain events it handed over before returning so a finite
+			// source's tail is not lost.
+			for drained := false; !drained; {
+				select {
+				case event := <-eventChan:
+					w.handleEvent(event)
+				default:
+					drained = true
+				}
+			}
 			if subErr != nil {
-				return fmt.Errorf("SSE subscription ended: %w", subErr)
+				return subErr
 			}
-			return fmt.Errorf("SSE subscription ended unexpectedly")
+			return fmt.Errorf("event source %s ended unexpectedly", w.source.Name())
 		case <-idleTimer.C:
 			return fmt.Errorf("SSE stream idle for %v, forcing reconnect", idleTimeout)
 		case event := <-eventChan:
 			// Reset idle timer on every event
-			if !idleTimer.Stop() {
-				select {
-				case <-idleTimer.C:
-				default:
+			if w.source.Live() {
+				if !idleTimer.Stop() {
+					select {
+					case <-idleTimer.C:
+					default:
+					}
 				}
-			}
-			idleTimer.Reset(idleTimeout)
-
-			// Track the last event ID for gap-free reconnects.
-			// Wikipedia EventStreams sends a JSON-array-style ID
-			// (e.g. [{"topic":"...","partition":0,"offset":123}]).
-			if len(event.ID) > 0 {
-				w.mu.Lock()
-				w.lastEventID = make([]byte, len(event.ID))
-				copy(w.lastEventID, event.ID)
-				w.mu.Unlock()
+				idleTimer.Reset(idleTimeout)
 			}
 
-			if err := w.processEvent(event); err != nil {
-				w.logger.Error().Err(err).Msg("Failed to process event")
-				// Continue processing other events even if one fails
-			}
+			w.handleEvent(event)
 		}
 	}
 }
 
-// processEvent processes a single SSE event
-func (w *WikiStreamClient) processEvent(event *sse.Event) error {
+// handleEvent records the event's resume position and processes it
+func (w *WikiStreamClient) handleEvent(event *Event) {
+	// Track the last event ID for gap-free reconnects.
+	// Wikipedia EventStreams sends a JSON-array-style ID
+	// (e.g. [{"topic":"...","partition":0,"offset":123}]).
+	if len(event.ID) > 0 {
+		w.mu.Lock()
+		w.lastEventID = make([]byte, len(event.ID))
+		copy(w.lastEventID, event.ID)
+		w.mu.Unlock()
+	}
+
+	if err := w.processEvent(event); err != nil {
+		w.logger.Error().Err(err).Msg("Failed to process event")
+		// Continue processing other events even if one fails
+	}
+}
+
+// processEvent processes a single raw event
+func (w *WikiStreamClient) processEvent(event *Event) error {
 	if event == nil || event.Data == nil {
 		return nil
 	}
 	
+	// Archive the raw payload before anything can drop it, so the capture
+	// reflects exactly what the stream delivered
+	if w.capture != nil {
+		if err := w.capture.Write(event.Data); err != nil {
+			w.logger.Warn().Err(err).Msg("Failed to capture raw event")
+		}
+	}
+	
 	// Apply rate limiting
 	if err := w.rateLimiter.Wait(context.Background()); err != nil {
 		return fmt.Errorf("rate limiter error: %w", err)
@@ -378,8 +564,13 @@ func (w *WikiStreamClient) processEvent(event *sse.Event) error {
 		return nil // Skip invalid JSON rather than failing
 	}
 	
-	// Validate edit structure
-	if err := edit.Validate(); err != nil {
+	// Validate edit structure. Replayed edits keep their original
+	// timestamps, so only live edits are held to the recency window.
+	validate := edit.Validate
+	if !w.source.Live() {
+		validate = edit.ValidateFields
+	}
+	if err := validate(); err != nil {
 		w.logger.Debug().
 			Err(err).
 			Int64("edit_id", edit.ID).
@@ -387,6 +578,11 @@ func (w *WikiStreamClient) processEvent(event *sse.Event) error {
 		return nil // Skip invalid edits
 	}
 	
+	// Log events take their own path and topic
+	if edit.IsLogEvent() && w.logProducer != nil {
+		return w.processLogEvent(&edit)
+	}
+	
 	// Apply filters
 	if !w.shouldProcess(&edit) {
 		w.logger.Debug().
@@ -428,6 +624,96 @@ func (w *WikiStreamClient) processEvent(event *sse.Event) error {
 	return nil
 }
 
+// processLogEvent filters a log event and sends it to the log events topic
+func (w *WikiStreamClient) processLogEvent(edit *models.WikipediaEdit) error {
+	if !w.ShouldProcessLogEvent(edit) {
+		w.logger.Debug().
+			Int64("log_id", edit.LogID).
+			Str("log_type", edit.LogType).
+			Str("log_action", edit.LogAction).
+			Str("title", edit.Title).
+			Msg("Log event filtered out")
+		return nil
+	}
+	
+	metrics.LogEventsIngestedTotal.WithLabelValues(edit.LogType).Inc()
+	
+	if err := w.logProducer.Produce(edit); err != nil {
+		w.logger.Error().
+			Err(err).
+			Int64("log_id", edit.LogID).
+			Str("title", edit.Title).
+			Msg("Failed to send log event to Kafka")
+		metrics.ProduceErrorsTotal.WithLabelValues("produce").Inc()
+	}
+	
+	return nil
+}
+
+// ShouldProcessLogEvent applies the project, language and log type filters
+// to a log event. Namespace and title-prefix filters are deliberately not
+// applied: blocks target User: pages, and they are as much an outcome of an
+// edit war as a protection of the article itself.
+func (w *WikiStreamClient) ShouldProcessLogEvent(edit *models.WikipediaEdit) bool {
+	if !w.projectAllowed(edit) {
+		metrics.EditsFilteredTotal.WithLabelValues("project").Inc()
+		return false
+	}
+	
+	if len(w.config.Ingestor.AllowedLanguages) > 0 && !isMultilingual(edit) {
+		editLang := edit.Language()
+		allowed := false
+		for _, lang := range w.config.Ingestor.AllowedLanguages {
+			if editLang == lang {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			metrics.EditsFilteredTotal.WithLabelValues("language").Inc()
+			return false
+		}
+	}
+	
+	if len(w.config.Ingestor.LogEvents.Types) > 0 {
+		allowed := false
+		for _, t := range w.config.Ingestor.LogEvents.Types {
+			if edit.LogType == t {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			metrics.EditsFilteredTotal.WithLabelValues("log_type").Inc()
+			return false
+		}
+	}
+	
+	return true
+}
+
+// projectAllowed reports whether the edit's project family is in the
+// allowed_projects list. An empty list accepts every project.
+func (w *WikiStreamClient) projectAllowed(edit *models.WikipediaEdit) bool {
+	if len(w.config.Ingestor.AllowedProjects) == 0 {
+		return true
+	}
+	project := edit.Project()
+	for _, p := range w.config.Ingestor.AllowedProjects {
+		if project == p {
+			return true
+		}
+	}
+	return false
+}
+
+// isMultilingual reports whether the edit comes from a recognised project
+// that has no per-language editions, such as Wikidata or Commons.
+func isMultilingual(edit *models.WikipediaEdit) bool {
+	project, lang := models.ParseWikiDBName(edit.Wiki)
+	return project != "" && lang == ""
+}
+
 // shouldProcess applies filters to determine if an edit should be processed
 func (w *WikiStreamClient) shouldProcess(edit *models.WikipediaEdit) bool {
 	return w.ShouldProcess(edit)
@@ -472,15 +758,16 @@ func (w *WikiStreamClient) ShouldProcess(edit *models.WikipediaEdit) bool {
 		return false
 	}
 
-	// Only accept edits from actual Wikipedia projects (*.wikipedia.org).
-	// This excludes Wikidata, Wiktionary, Commons, Meta, etc.
-	if !strings.Contains(edit.ServerURL, "wikipedia.org") {
-		metrics.EditsFilteredTotal.WithLabelValues("non_wikipedia").Inc()
+	// Only accept edits from the configured project families. The default
+	// is Wikipedia only, which excludes Wikidata, Wiktionary, Commons, etc.
+	if !w.projectAllowed(edit) {
+		metrics.EditsFilteredTotal.WithLabelValues("project").Inc()
 		return false
 	}
 	
-	// Filter by language
-	if len(w.config.Ingestor.AllowedLanguages) > 0 {
+	// Filter by language. Multilingual projects (Wikidata, Commons, ...)
+	// have no language edition and are governed by the project filter alone.
+	if len(w.config.Ingestor.AllowedLanguages) > 0 && !isMultilingual(edit) {
 		editLang := edit.Language()
 		allowed := false
 		for _, lang := range w.config.Ingestor.AllowedLanguages {
@@ -539,16 +826,23 @@ func (w *WikiStreamClient) Stop() {
 			w.logger.Error().Err(err).Msg("Failed to close Kafka producer")
 		}
 	}
-	
-	// Close SSE client
-	if w.sseClient != nil {
-		if transport, ok := w.sseClient.Connection.Transport.(*http.Transport); ok {
-			transport.CloseIdleConnections()
+	if w.logProducer != nil {
+		if err := w.logProducer.Close(); err != nil {
+			w.logger.Error().Err(err).Msg("Failed to close log event producer")
 		}
 	}
 	
 	// Wait for goroutine to complete
 	w.wg.Wait()
+
+	// Flush the final resume position so a restart picks up exactly here
+	w.saveCheckpoint()
+
+	if w.capture != nil {
+		if err := w.capture.Close(); err != nil {
+			w.logger.Error().Err(err).Msg("Failed to close capture archive")
+		}
+	}
 	
 	w.logger.Info().Msg("Wikipedia SSE client stopped successfully")
 }
\ No newline at end of file
@@ -194,6 +194,48 @@ func TestWikiStreamClient_shouldProcess_LanguageFilter(t *testing.T) {
 	}
 }
 
+func TestWikiStreamClient_shouldProcess_ProjectFilter(t *testing.T) {
+	logger := zerolog.New(nil).With().Timestamp().Logger()
+	mockProd := newMockProducer()
+
+	tests := []struct {
+		name             string
+		allowedProjects  []string
+		allowedLanguages []string
+		editWiki         string
+		expected         bool
+	}{
+		{"no project filter accepts wikidata", nil, nil, "wikidatawiki", true},
+		{"wikipedia only drops wiktionary", []string{"wikipedia"}, nil, "enwiktionary", false},
+		{"wikipedia only keeps wikipedia", []string{"wikipedia"}, nil, "enwiki", true},
+		{"wiktionary allowed", []string{"wikipedia", "wiktionary"}, nil, "enwiktionary", true},
+		{"language filter applies to sister projects", []string{"wiktionary"}, []string{"en"}, "frwiktionary", false},
+		{"language filter does not apply to multilingual projects", []string{"wikidata"}, []string{"en"}, "wikidatawiki", true},
+		{"unrecognised wiki dropped", []string{"wikipedia"}, nil, "bogus", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Ingestor: config.Ingestor{
+					AllowedProjects:  tt.allowedProjects,
+					AllowedLanguages: tt.allowedLanguages,
+				},
+			}
+			client := NewWikiStreamClient(cfg, logger, mockProd)
+
+			edit := &models.WikipediaEdit{
+				Wiki:      tt.editWiki,
+				Type:      "edit",
+				ServerURL: models.ServerURLForWiki(tt.editWiki),
+			}
+			if got := client.shouldProcess(edit); got != tt.expected {
+				t.Errorf("shouldProcess() = %t, expected %t for wiki %s", got, tt.expected, tt.editWiki)
+			}
+		})
+	}
+}
+
 func TestWikiStreamClient_shouldProcess_TypeFilter(t *testing.T) {
 	logger := zerolog.New(nil).With().Timestamp().Logger()
 	mockProd := newMockProducer()
@@ -1041,4 +1083,45 @@ func TestSSETransport_RepeatedCreateAndClose(t *testing.T) {
 		tr.CloseIdleConnections()
 	}
 	// If we get here without OOM or FD exhaustion, the cleanup works.
-}
\ No newline at end of file
+}
+func logEventPayload(id int64, logType, logAction, title string, ns int) string {
+	return fmt.Sprintf(`{"id":%d,"type":"log","ns":%d,"title":%q,"user":"Admin","bot":false,`+
+		`"wiki":"enwiki","server_url":"https://en.wikipedia.org","timestamp":%d,`+
+		`"log_id":%d,"log_type":%q,"log_action":%q,"log_params":{"expiry":"infinite"},"log_action_comment":"x"}`,
+		id, ns, title, time.Now().Unix(), id+1000, logType, logAction)
+}
+
+func TestProcessEvent_RoutesLogEvents(t *testing.T) {
+	cfg := &config.Config{
+		Ingestor: config.Ingestor{
+			RateLimit:         1000,
+			BurstLimit:        1000,
+			AllowedNamespaces: []int{0},
+			LogEvents:         config.LogEventsConfig{Enabled: true, Types: []string{"protect", "block"}},
+		},
+	}
+	edits := newMockProducer()
+	logs := newMockProducer()
+	client := NewWikiStreamClient(cfg, zerolog.New(nil), edits)
+	client.SetLogEventProducer(logs)
+
+	client.processEvent(&Event{Data: []byte(recordedEdit(1, time.Now()))})
+	client.processEvent(&Event{Data: []byte(logEventPayload(2, "protect", "protect", "Contested page", 0))})
+	// Blocks target User: pages; the namespace filter must not drop them.
+	client.processEvent(&Event{Data: []byte(logEventPayload(3, "block", "block", "User:Vandal", 2))})
+	client.processEvent(&Event{Data: []byte(logEventPayload(4, "newusers", "create", "User:New", 2))})
+
+	if n := len(edits.GetProducedEdits()); n != 1 {
+		t.Errorf("edits producer got %d messages, want 1", n)
+	}
+	got := logs.GetProducedEdits()
+	if len(got) != 2 {
+		t.Fatalf("log producer got %d messages, want 2", len(got))
+	}
+	if got[0].LogType != "protect" || got[1].Title != "User:Vandal" {
+		t.Errorf("unexpected log events: %+v, %+v", got[0], got[1])
+	}
+	if string(got[0].LogParams) != `{"expiry":"infinite"}` {
+		t.Errorf("log_params = %s", got[0].LogParams)
+	}
+}
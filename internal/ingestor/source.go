@@ -0,0 +1,433 @@
+package ingestor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/r3labs/sse/v2"
+	"gopkg.in/cenkalti/backoff.v1"
+)
+
+// Event is a single raw event delivered by an EventSource.
+type Event struct {
+	ID   []byte // Resume position (SSE id); empty for sources that cannot resume
+	Data []byte // Raw recentchange JSON payload
+}
+
+// ErrSourceExhausted is returned by finite sources once every event has been
+// delivered.
+var ErrSourceExhausted = errors.New("event source exhausted")
+
+// EventSource produces raw recentchange events for the WikiStreamClient.
+type EventSource interface {
+	// Name identifies the source in logs.
+	Name() string
+
+	// Live reports whether the source is an unbounded real-time stream. Live
+	// sources are reconnected on failure, watched for idleness and subject
+	// to the recency check in Validate; finite sources end the event loop
+	// once exhausted and keep their original timestamps.
+	Live() bool
+
+	// Preflight verifies that the source is reachable.
+	Preflight(ctx context.Context) error
+
+	// Subscribe delivers events to handle until ctx is cancelled or the
+	// source ends. lastEventID is the resume position for sources that
+	// support it.
+	Subscribe(ctx context.Context, lastEventID []byte, handle func(*Event)) error
+}
+
+// NewEventSource builds the source selected by cfg.Ingestor.Source.
+func NewEventSource(cfg *config.Config) (EventSource, error) {
+	srcCfg := cfg.Ingestor.Source
+	switch srcCfg.Type {
+	case "", "eventstreams":
+		return NewSSESource(WikipediaSSEURL), nil
+	case "sse":
+		if srcCfg.URL == "" {
+			return nil, fmt.Errorf("sse source requires a URL")
+		}
+		return NewSSESource(srcCfg.URL), nil
+	case "replay":
+		if srcCfg.Path == "" {
+			return nil, fmt.Errorf("replay source requires a path")
+		}
+		src := NewReplaySource(srcCfg.Path, srcCfg.Speed)
+		src.SetWindow(srcCfg.From, srcCfg.To)
+		return src, nil
+	default:
+		return nil, fmt.Errorf("unknown event source type %q", srcCfg.Type)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// SSE source
+// ---------------------------------------------------------------------------
+
+// SSESource streams events from a Server-Sent Events endpoint. With
+// WikipediaSSEURL it is the live EventStreams feed; any other URL can point
+// at a local stand-in that speaks the same protocol.
+type SSESource struct {
+	url string
+}
+
+// NewSSESource creates a source for the given SSE endpoint.
+func NewSSESource(url string) *SSESource {
+	return &SSESource{url: url}
+}
+
+// Name returns the endpoint URL.
+func (s *SSESource) Name() string { return s.url }
+
+// Live is always true for SSE endpoints.
+func (s *SSESource) Live() bool { return true }
+
+// Preflight does a lightweight GET to the endpoint. This catches DNS
+// failures, firewalls, and HTTP errors that the r3labs/sse library would
+// otherwise silently swallow.
+func (s *SSESource) Preflight(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/event-stream")
+
+	client := &http.Client{Timeout: ConnectionTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d from SSE endpoint", resp.StatusCode)
+	}
+	return nil
+}
+
+// Subscribe opens the stream and blocks until it ends.
+func (s *SSESource) Subscribe(ctx context.Context, lastEventID []byte, handle func(*Event)) error {
+	// Create a fresh SSE client for each stream attempt to avoid stale
+	// internal state from the r3labs/sse library's own retry logic.
+	sseClient := sse.NewClient(s.url)
+	transport := sseTransport()
+	sseClient.Connection.Transport = transport
+	sseClient.Headers = map[string]string{
+		"Accept":     "text/event-stream",
+		"User-Agent": UserAgent,
+	}
+
+	// Close this transport's idle connections when the stream attempt ends.
+	// Without this, each reconnect (~every 3-7 min) leaks a transport with
+	// its TCP/TLS connection pool, causing FD exhaustion after days.
+	defer transport.CloseIdleConnections()
+
+	// Seed the library's LastEventID so the Last-Event-ID header is sent
+	// on the initial request, enabling Wikipedia to replay missed events.
+	// Wikipedia EventStreams uses JSON-array IDs (not timestamps), so
+	// they go in the header rather than the ?since= query parameter.
+	if len(lastEventID) > 0 {
+		sseClient.LastEventID.Store(lastEventID)
+	}
+
+	// Disable the library's internal auto-reconnect so the client's outer
+	// loop controls reconnection with proper backoff and idle detection.
+	sseClient.ReconnectStrategy = &backoff.StopBackOff{}
+
+	// We use SubscribeWithContext instead of SubscribeChanWithContext
+	// because the latter returns immediately with nil error when
+	// StopBackOff is set, never delivering events.
+	err := sseClient.SubscribeWithContext(ctx, "message", func(msg *sse.Event) {
+		handle(&Event{ID: msg.ID, Data: msg.Data})
+	})
+	if err != nil {
+		return fmt.Errorf("SSE subscription ended: %w", err)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("SSE subscription ended unexpectedly")
+}
+
+// ---------------------------------------------------------------------------
+// Replay source
+// ---------------------------------------------------------------------------
+
+// maxReplayLineSize bounds a single NDJSON line; recentchange payloads are a
+// few KB, but long edit summaries and log params can push past the 64KB
+// bufio default.
+const maxReplayLineSize = 1 << 20
+
+// ReplaySource reads recorded recentchange payloads from NDJSON files, one
+// event per line, optionally gzip-compressed. The path may name a single
+// file, a glob, or a capture directory (see Capture), whose files are read
+// in recording order. Events are paced by their original timestamps
+// divided by speed; a speed of 0 or less replays as fast as possible.
+// Payloads are delivered unmodified.
+type ReplaySource struct {
+	path  string
+	speed float64
+	from  time.Time
+	to    time.Time
+}
+
+// NewReplaySource creates a replay source for the file(s) at path.
+func NewReplaySource(path string, speed float64) *ReplaySource {
+	return &ReplaySource{path: path, speed: speed}
+}
+
+// SetWindow restricts the replay to events recorded between from and to.
+// A zero bound is open. For capture directories the index is used to skip
+// files entirely outside the window.
+func (r *ReplaySource) SetWindow(from, to time.Time) {
+	r.from = from
+	r.to = to
+}
+
+// Name identifies the replay path.
+func (r *ReplaySource) Name() string { return "replay:" + r.path }
+
+// Live is always false; a replay ends when its files do.
+func (r *ReplaySource) Live() bool { return false }
+
+// Preflight checks that the path resolves to at least one readable file.
+func (r *ReplaySource) Preflight(ctx context.Context) error {
+	files, err := r.files()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	return f.Close()
+}
+
+// Subscribe replays every file from the beginning. lastEventID is ignored.
+func (r *ReplaySource) Subscribe(ctx context.Context, lastEventID []byte, handle func(*Event)) error {
+	files, err := r.files()
+	if err != nil {
+		return err
+	}
+
+	pacer := newReplayPacer(r.speed)
+	for _, path := range files {
+		if err := r.replayFile(ctx, path, pacer, handle); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return ErrSourceExhausted
+}
+
+// replayFile delivers the events of a single file. A cancelled context ends
+// the file early without an error.
+func (r *ReplaySource) replayFile(ctx context.Context, path string, pacer *replayPacer, handle func(*Event)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	reader, err := maybeGzip(f)
+	if err != nil {
+		return fmt.Errorf("failed to open replay file %s: %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		eventTime := payloadTime(line)
+		if !eventTime.IsZero() {
+			if !r.from.IsZero() && eventTime.Before(r.from) {
+				continue
+			}
+			if !r.to.IsZero() && eventTime.After(r.to) {
+				continue
+			}
+		}
+
+		if err := pacer.wait(ctx, eventTime); err != nil {
+			return nil
+		}
+
+		// The scanner reuses its buffer, so hand the handler its own copy.
+		data := make([]byte, len(line))
+		copy(data, line)
+		handle(&Event{Data: data})
+	}
+
+	// A capture file cut short by a crash ends mid gzip block. Everything
+	// before the last flush is intact, so treat the truncation as EOF.
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read replay file %s: %w", path, err)
+	}
+	return nil
+}
+
+// files resolves the replay path into the ordered list of files to read.
+func (r *ReplaySource) files() ([]string, error) {
+	info, err := os.Stat(r.path)
+	switch {
+	case err == nil && !info.IsDir():
+		return []string{r.path}, nil
+	case err == nil:
+		return r.captureFiles()
+	case os.IsNotExist(err):
+		matches, globErr := filepath.Glob(r.path)
+		if globErr != nil {
+			return nil, fmt.Errorf("invalid replay pattern %q: %w", r.path, globErr)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no replay files match %q", r.path)
+		}
+		sortReplayFiles(matches)
+		return matches, nil
+	default:
+		return nil, fmt.Errorf("failed to stat replay path: %w", err)
+	}
+}
+
+// captureFiles lists a capture directory in recording order, using its
+// index when present and falling back to the time-ordered file names.
+func (r *ReplaySource) captureFiles() ([]string, error) {
+	idx, err := LoadCaptureIndex(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if len(idx.Files) > 0 {
+		for _, f := range idx.Overlapping(r.from, r.to) {
+			files = append(files, filepath.Join(r.path, f.Name))
+		}
+	} else {
+		matches, err := filepath.Glob(filepath.Join(r.path, "*.ndjson*"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list replay directory: %w", err)
+		}
+		sortReplayFiles(matches)
+		files = matches
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no replay files in %s for the requested window", r.path)
+	}
+	return files, nil
+}
+
+// sortReplayFiles orders file names by their name without extensions, so a
+// capture file sorts before the sequence-suffixed files opened after it in
+// the same interval (events-T.ndjson.gz, then events-T-1.ndjson.gz).
+func sortReplayFiles(names []string) {
+	stem := func(name string) string {
+		base := filepath.Base(name)
+		if i := strings.IndexByte(base, '.'); i >= 0 {
+			base = base[:i]
+		}
+		return filepath.Join(filepath.Dir(name), base)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return stem(names[i]) < stem(names[j])
+	})
+}
+
+// maybeGzip wraps r in a gzip reader if the stream starts with the gzip
+// magic bytes, so both plain and compressed files are accepted regardless
+// of their extension.
+func maybeGzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// payloadTime extracts the original event time from a recentchange payload,
+// preferring the millisecond-precision meta.dt over the seconds timestamp.
+// The zero time is returned when neither is present.
+func payloadTime(data []byte) time.Time {
+	var probe struct {
+		Timestamp int64 `json:"timestamp"`
+		Meta      struct {
+			Dt string `json:"dt"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return time.Time{}
+	}
+	if probe.Meta.Dt != "" {
+		if t, err := time.Parse(time.RFC3339Nano, probe.Meta.Dt); err == nil {
+			return t
+		}
+	}
+	if probe.Timestamp > 0 {
+		return time.Unix(probe.Timestamp, 0)
+	}
+	return time.Time{}
+}
+
+// replayPacer schedules events relative to the first event seen, so that
+// sleep overshoot does not accumulate into drift over a long replay.
+type replayPacer struct {
+	speed     float64
+	firstTime time.Time
+	startWall time.Time
+}
+
+func newReplayPacer(speed float64) *replayPacer {
+	return &replayPacer{speed: speed}
+}
+
+// wait blocks until the wall-clock moment at which an event recorded at
+// eventTime is due. Events without a timestamp, or out of order, are due
+// immediately. It returns ctx.Err() if cancelled while waiting.
+func (p *replayPacer) wait(ctx context.Context, eventTime time.Time) error {
+	if p.speed <= 0 || eventTime.IsZero() {
+		return ctx.Err()
+	}
+	if p.firstTime.IsZero() {
+		p.firstTime = eventTime
+		p.startWall = time.Now()
+		return ctx.Err()
+	}
+
+	offset := time.Duration(float64(eventTime.Sub(p.firstTime)) / p.speed)
+	delay := time.Until(p.startWall.Add(offset))
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
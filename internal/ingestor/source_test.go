@@ -0,0 +1,194 @@
+package ingestor
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// recordedEdit returns a recentchange payload as EventStreams would emit it.
+func recordedEdit(id int64, at time.Time) string {
+	return fmt.Sprintf(`{"id":%d,"type":"edit","ns":0,"title":"Page %d","user":"Alice","bot":false,`+
+		`"wiki":"enwiki","server_url":"https://en.wikipedia.org","timestamp":%d,`+
+		`"meta":{"dt":%q},"length":{"old":100,"new":150},"revision":{"old":1,"new":2}}`,
+		id, id, at.Unix(), at.UTC().Format(time.RFC3339Nano))
+}
+
+func writeReplayFile(t *testing.T, name string, lines []string, compress bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create replay file: %v", err)
+	}
+	defer f.Close()
+
+	body := strings.Join(lines, "\n") + "\n"
+	if !compress {
+		if _, err := f.WriteString(body); err != nil {
+			t.Fatalf("write replay file: %v", err)
+		}
+		return path
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(body)); err != nil {
+		t.Fatalf("write replay file: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip writer: %v", err)
+	}
+	return path
+}
+
+func TestReplaySource_DeliversAllEvents(t *testing.T) {
+	base := time.Now().Add(-7 * 24 * time.Hour)
+	lines := []string{recordedEdit(1, base), "", recordedEdit(2, base.Add(time.Second)), recordedEdit(3, base.Add(2*time.Second))}
+
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", compress), func(t *testing.T) {
+			src := NewReplaySource(writeReplayFile(t, "day.ndjson", lines, compress), 0)
+			if err := src.Preflight(context.Background()); err != nil {
+				t.Fatalf("Preflight() error = %v", err)
+			}
+
+			var got []string
+			err := src.Subscribe(context.Background(), nil, func(e *Event) {
+				got = append(got, string(e.Data))
+			})
+			if !errors.Is(err, ErrSourceExhausted) {
+				t.Fatalf("Subscribe() error = %v, want ErrSourceExhausted", err)
+			}
+			if len(got) != 3 {
+				t.Fatalf("delivered %d events, want 3", len(got))
+			}
+			if got[1] != lines[2] {
+				t.Errorf("payload was modified: got %s, want %s", got[1], lines[2])
+			}
+		})
+	}
+}
+
+func TestReplaySource_SpeedMultiplier(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	lines := []string{recordedEdit(1, base), recordedEdit(2, base.Add(time.Second)), recordedEdit(3, base.Add(2*time.Second))}
+	path := writeReplayFile(t, "paced.ndjson", lines, false)
+
+	start := time.Now()
+	err := NewReplaySource(path, 10).Subscribe(context.Background(), nil, func(*Event) {})
+	elapsed := time.Since(start)
+	if !errors.Is(err, ErrSourceExhausted) {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	// 2s of recorded traffic at 10x should take ~200ms.
+	if elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("10x replay of 2s took %v, want ~200ms", elapsed)
+	}
+
+	start = time.Now()
+	_ = NewReplaySource(path, 0).Subscribe(context.Background(), nil, func(*Event) {})
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("unpaced replay took %v, want near-instant", elapsed)
+	}
+}
+
+func TestReplaySource_StopsOnCancel(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	path := writeReplayFile(t, "slow.ndjson", []string{recordedEdit(1, base), recordedEdit(2, base.Add(time.Hour))}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	count := 0
+	if err := NewReplaySource(path, 1).Subscribe(ctx, nil, func(*Event) { count++ }); err != nil {
+		t.Fatalf("Subscribe() error = %v, want nil on cancel", err)
+	}
+	if count != 1 {
+		t.Errorf("delivered %d events before cancel, want 1", count)
+	}
+}
+
+func TestPayloadTime(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+	if got := payloadTime([]byte(recordedEdit(1, at))); !got.Equal(at) {
+		t.Errorf("payloadTime() = %v, want %v (meta.dt)", got, at)
+	}
+	if got := payloadTime([]byte(`{"timestamp":1714564800}`)); !got.Equal(time.Unix(1714564800, 0)) {
+		t.Errorf("payloadTime() = %v, want timestamp fallback", got)
+	}
+	if got := payloadTime([]byte(`not json`)); !got.IsZero() {
+		t.Errorf("payloadTime() = %v, want zero", got)
+	}
+}
+
+func TestSSESource_Subscribe(t *testing.T) {
+	var gotLastEventID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLastEventID = r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: message\nid: [{\"offset\":1}]\ndata: %s\n\n", recordedEdit(1, time.Now()))
+		fmt.Fprintf(w, "event: message\nid: [{\"offset\":2}]\ndata: %s\n\n", recordedEdit(2, time.Now()))
+		w.(http.Flusher).Flush()
+	}))
+	defer srv.Close()
+
+	src := NewSSESource(srv.URL)
+	if err := src.Preflight(context.Background()); err != nil {
+		t.Fatalf("Preflight() error = %v", err)
+	}
+
+	var ids []string
+	_ = src.Subscribe(context.Background(), []byte(`[{"offset":0}]`), func(e *Event) {
+		ids = append(ids, string(e.ID))
+	})
+
+	if gotLastEventID != `[{"offset":0}]` {
+		t.Errorf("Last-Event-ID header = %q, want resume position", gotLastEventID)
+	}
+	if len(ids) != 2 || ids[1] != `[{"offset":2}]` {
+		t.Errorf("event IDs = %v, want two events ending at offset 2", ids)
+	}
+}
+
+func TestWikiStreamClient_ReplayKeepsOriginalTimestamps(t *testing.T) {
+	base := time.Now().Add(-30 * 24 * time.Hour) // well past Validate's recency window
+	path := writeReplayFile(t, "month-old.ndjson.gz", []string{recordedEdit(1, base), recordedEdit(2, base.Add(time.Second))}, true)
+
+	cfg := &config.Config{
+		Ingestor: config.Ingestor{
+			RateLimit:  1000,
+			BurstLimit: 1000,
+			Source:     config.SourceConfig{Type: "replay", Path: path},
+		},
+	}
+	producer := newMockProducer()
+	client := NewWikiStreamClient(cfg, zerolog.New(nil), producer)
+
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("replay did not finish")
+	}
+	client.Stop()
+
+	edits := producer.GetProducedEdits()
+	if len(edits) != 2 {
+		t.Fatalf("produced %d edits, want 2", len(edits))
+	}
+	if edits[0].Timestamp != base.Unix() {
+		t.Errorf("timestamp = %d, want original %d", edits[0].Timestamp, base.Unix())
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Agnikulu/WikiSurge/internal/config"
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/resilience"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
@@ -30,6 +31,16 @@ type Consumer struct {
 	wg       sync.WaitGroup
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	// Failure handling; see ConsumerConfig.Retry
+	group        string
+	topic        string
+	brokers      []string
+	retry        *config.RetryPolicy
+	poison       *PoisonMessageHandler
+	dlq          *DeadLetterProducer
+	retryWriter  WriterInterface
+	retryReaders []*kafka.Reader // retryReaders[i] consumes retry level i+1
 }
 
 // ConsumerMetrics contains Prometheus metrics for the consumer
@@ -38,6 +49,7 @@ type ConsumerMetrics struct {
 	ProcessingErrors  prometheus.Counter
 	ConsumerLag       prometheus.Gauge
 	ProcessingTime    prometheus.Histogram
+	MessagesRouted    *prometheus.CounterVec
 }
 
 // Shared metrics registered once across all consumers
@@ -75,12 +87,20 @@ func getSharedConsumerMetrics() *ConsumerMetrics {
 					Buckets: prometheus.ExponentialBuckets(0.001, 2, 10),
 				},
 			),
+			MessagesRouted: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "kafka_messages_routed_total",
+					Help: "Failed messages re-queued to a retry topic, sent to the DLQ or dropped",
+				},
+				[]string{"consumer_group", "destination"},
+			),
 		}
 		prometheus.MustRegister(
 			sharedMetrics.MessagesProcessed,
 			sharedMetrics.ProcessingErrors,
 			sharedMetrics.ConsumerLag,
 			sharedMetrics.ProcessingTime,
+			sharedMetrics.MessagesRouted,
 		)
 	})
 	return sharedMetrics
@@ -96,6 +116,15 @@ type ConsumerConfig struct {
 	CommitInterval time.Duration
 	StartOffset   int64
 	MaxWait       time.Duration
+
+	// Retry is the policy for messages the handler fails on: in-place
+	// retries, then one delayed retry topic per RetryDelays entry, then
+	// DeadLetter. Nil keeps the old behaviour of logging the error and
+	// committing anyway.
+	Retry *config.RetryPolicy
+	// DeadLetter receives poison messages and messages that exhausted their
+	// retries. Without it they are logged and dropped.
+	DeadLetter *DeadLetterProducer
 }
 
 // NewConsumer creates a new Kafka consumer instance
@@ -142,7 +171,7 @@ func NewConsumer(cfg *config.Config, consumerCfg ConsumerConfig, handler Message
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Consumer{
+	c := &Consumer{
 		reader:   reader,
 		handler:  handler,
 		config:   cfg,
@@ -151,15 +180,63 @@ func NewConsumer(cfg *config.Config, consumerCfg ConsumerConfig, handler Message
 		stopChan: make(chan struct{}),
 		ctx:      ctx,
 		cancel:   cancel,
-	}, nil
+		group:    consumerCfg.GroupID,
+		topic:    consumerCfg.Topic,
+		brokers:  consumerCfg.Brokers,
+		retry:    consumerCfg.Retry,
+		dlq:      consumerCfg.DeadLetter,
+	}
+
+	if c.dlq != nil {
+		c.poison = NewPoisonMessageHandler(handler, c.dlq, c.group, logger)
+	}
+
+	// Each retry level has its own topic and reader. The reader's group is
+	// named after the topic, since retry topics belong to a single group.
+	if c.retry != nil && len(c.retry.RetryDelays) > 0 {
+		c.retryWriter = &kafka.Writer{
+			Addr:                   kafka.TCP(consumerCfg.Brokers...),
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           10 * time.Millisecond,
+			WriteTimeout:           DefaultWriteTimeout,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		}
+		for level := 1; level <= len(c.retry.RetryDelays); level++ {
+			retryCfg := readerConfig
+			retryCfg.Topic = RetryTopic(c.topic, c.group, level)
+			retryCfg.GroupID = retryCfg.Topic
+			retryCfg.StartOffset = kafka.FirstOffset
+			c.retryReaders = append(c.retryReaders, kafka.NewReader(retryCfg))
+		}
+	}
+
+	return c, nil
 }
 
 // Start begins consuming messages from Kafka
 func (c *Consumer) Start() error {
 	c.logger.Info().Msg("Starting Kafka consumer")
 	
+	if len(c.retryReaders) > 0 {
+		topics := make([]string, len(c.retryReaders))
+		for i, r := range c.retryReaders {
+			topics[i] = r.Config().Topic
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+		if err := EnsureTopics(ctx, c.brokers, topics...); err != nil {
+			c.logger.Warn().Err(err).Strs("topics", topics).Msg("Could not create retry topics; they must exist or be auto-created")
+		}
+		cancel()
+	}
+	
 	c.wg.Add(1)
-	go c.consumeLoop()
+	go c.consumeLoop(c.reader, 0)
+	
+	for i, r := range c.retryReaders {
+		c.wg.Add(1)
+		go c.consumeLoop(r, i+1)
+	}
 	
 	return nil
 }
@@ -175,6 +252,18 @@ func (c *Consumer) Stop() error {
 	// Wait for consumer goroutine to finish
 	c.wg.Wait()
 	
+	// Close retry readers and writer
+	for _, r := range c.retryReaders {
+		if err := r.Close(); err != nil {
+			c.logger.Error().Err(err).Str("topic", r.Config().Topic).Msg("Error closing retry reader")
+		}
+	}
+	if c.retryWriter != nil {
+		if err := c.retryWriter.Close(); err != nil {
+			c.logger.Error().Err(err).Msg("Error closing retry writer")
+		}
+	}
+	
 	// Close reader
 	if err := c.reader.Close(); err != nil {
 		c.logger.Error().Err(err).Msg("Error closing Kafka reader")
@@ -185,8 +274,10 @@ func (c *Consumer) Stop() error {
 	return nil
 }
 
-// consumeLoop is the main consumption loop
-func (c *Consumer) consumeLoop() {
+// consumeLoop is the main consumption loop. Level 0 reads the source topic;
+// higher levels read the matching retry topic and hold each message until it
+// is due.
+func (c *Consumer) consumeLoop(reader *kafka.Reader, level int) {
 	defer c.wg.Done()
 	
 	for {
@@ -199,47 +290,130 @@ func (c *Consumer) consumeLoop() {
 			return
 		default:
 			// Fetch message with timeout
-			message, err := c.reader.FetchMessage(c.ctx)
+			message, err := reader.FetchMessage(c.ctx)
 			if err != nil {
 				if err == context.Canceled || err == context.DeadlineExceeded {
 					// Context cancelled or timeout - check if we should stop
 					continue
 				}
-				c.logger.Error().Err(err).Msg("Error fetching message")
+				c.logger.Error().Err(err).Int("retry_level", level).Msg("Error fetching message")
 				c.metrics.ProcessingErrors.Inc()
 				time.Sleep(time.Second) // Avoid tight loop on persistent errors
 				continue
 			}
 
-			// Process the message
-			if err := c.processMessage(message); err != nil {
-				c.logger.Error().Err(err).Bytes("message_key", message.Key).Msg("Error processing message")
-				c.metrics.ProcessingErrors.Inc()
-				c.metrics.MessagesProcessed.WithLabelValues(
-					c.reader.Config().GroupID,
-					c.reader.Config().Topic,
-					"error",
-				).Inc()
-			} else {
-				c.metrics.MessagesProcessed.WithLabelValues(
-					c.reader.Config().GroupID,
-					c.reader.Config().Topic,
-					"success",
-				).Inc()
+			// Retry topics are FIFO with a fixed delay, so waiting for the
+			// head message never holds back one that is already due
+			if level > 0 && !c.waitUntilDue(message) {
+				continue
 			}
 
-			// Commit the message (whether processing succeeded or failed)
-			if err := c.reader.CommitMessages(c.ctx, message); err != nil {
+			// Process the message; if it is not finished with (shutdown
+			// interrupted a retry) it stays uncommitted and is redelivered
+			if !c.handleMessage(message, level) {
+				continue
+			}
+
+			if err := reader.CommitMessages(c.ctx, message); err != nil {
 				c.logger.Error().Err(err).Msg("Error committing message")
 				// Continue processing even if commit fails
 			}
 
 			// Update lag metric periodically
-			c.updateLagMetric()
+			if level == 0 {
+				c.updateLagMetric()
+			}
 		}
 	}
 }
 
+// handleMessage processes a message, applying the retry policy if that
+// fails. It reports whether the message can be committed.
+func (c *Consumer) handleMessage(message kafka.Message, level int) bool {
+	var err error
+	if c.retry == nil {
+		err = c.processMessage(message)
+	} else {
+		err = resilience.RetryWithBackoff(c.ctx, inPlaceRetryConfig(*c.retry, c.group), func(ctx context.Context) error {
+			return c.processMessage(message)
+		})
+	}
+
+	if err == nil {
+		c.metrics.MessagesProcessed.WithLabelValues(c.group, c.topic, "success").Inc()
+		return true
+	}
+	if c.retry != nil && c.ctx.Err() != nil {
+		return false
+	}
+
+	c.logger.Error().Err(err).Bytes("message_key", message.Key).Int("retry_level", level).Msg("Error processing message")
+	c.metrics.ProcessingErrors.Inc()
+	c.metrics.MessagesProcessed.WithLabelValues(c.group, c.topic, "error").Inc()
+
+	if c.retry == nil {
+		return true
+	}
+	return c.routeFailure(message, level, err)
+}
+
+// routeFailure sends a message that failed in place to the next retry topic,
+// or to the DLQ if its retries are exhausted or the error is not retryable.
+// Routing is retried until it succeeds, holding up the partition rather than
+// losing the message; it reports false only if the consumer is stopping.
+func (c *Consumer) routeFailure(message kafka.Message, level int, procErr error) bool {
+	var destination string
+	var route func(ctx context.Context) error
+
+	next := level + 1
+	switch {
+	case resilience.IsRetryable(procErr) && c.retryWriter != nil && next <= len(c.retry.RetryDelays):
+		destination = fmt.Sprintf("retry_%d", next)
+		msg := retryMessage(message, RetryTopic(c.topic, c.group, next), next, c.retry.RetryDelays[next-1], procErr, time.Now())
+		route = func(ctx context.Context) error {
+			return c.retryWriter.WriteMessages(ctx, msg)
+		}
+	case c.dlq != nil:
+		destination = "dlq"
+		route = func(ctx context.Context) error {
+			return c.dlq.SendToDLQ(ctx, message, procErr, c.group)
+		}
+	default:
+		c.logger.Error().Err(procErr).Bytes("message_key", message.Key).Msg("No dead letter queue configured, dropping failed message")
+		c.metrics.MessagesRouted.WithLabelValues(c.group, "dropped").Inc()
+		return true
+	}
+
+	err := resilience.RetryForever(c.ctx, resilience.RetryConfig{
+		MaxDelay:      30 * time.Second,
+		Logger:        &c.logger,
+		OperationName: "route to " + destination,
+	}, route)
+	if err != nil {
+		return false
+	}
+
+	c.metrics.MessagesRouted.WithLabelValues(c.group, destination).Inc()
+	return true
+}
+
+// waitUntilDue blocks until a retry-topic message is due, returning false if
+// the consumer stops first.
+func (c *Consumer) waitUntilDue(message kafka.Message) bool {
+	wait := time.Until(retryDue(message))
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
 // processMessage handles a single Kafka message
 func (c *Consumer) processMessage(message kafka.Message) error {
 	start := time.Now()
@@ -247,10 +421,18 @@ func (c *Consumer) processMessage(message kafka.Message) error {
 		c.metrics.ProcessingTime.Observe(time.Since(start).Seconds())
 	}()
 
-	// Unmarshal message to WikipediaEdit
+	// With a DLQ, decoding and poison handling go through the poison handler
+	if c.poison != nil {
+		if err := c.poison.HandleMessage(c.ctx, message); err != nil {
+			return fmt.Errorf("handler failed to process edit: %w", err)
+		}
+		return nil
+	}
+
+	// Unmarshal message to WikipediaEdit; retrying cannot fix a bad payload
 	var edit models.WikipediaEdit
 	if err := json.Unmarshal(message.Value, &edit); err != nil {
-		return fmt.Errorf("failed to unmarshal WikipediaEdit: %w", err)
+		return resilience.NewNonRetryableError(fmt.Errorf("failed to unmarshal WikipediaEdit: %w", err))
 	}
 
 	// Call handler to process the edit
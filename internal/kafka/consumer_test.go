@@ -9,6 +9,8 @@ import (
 
 	"github.com/Agnikulu/WikiSurge/internal/config"
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/resilience"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
@@ -275,3 +277,183 @@ func TestMockHandlerSatisfiesInterface(t *testing.T) {
 	var h MessageHandler = &mockHandler{}
 	assert.NotNil(t, h)
 }
+
+// ---------------------------------------------------------------------------
+// Retry and dead-letter routing
+// ---------------------------------------------------------------------------
+
+// flakyHandler fails its first `failures` calls with err
+type flakyHandler struct {
+	calls    int
+	failures int
+	err      error
+}
+
+func (f *flakyHandler) ProcessEdit(_ context.Context, _ *models.WikipediaEdit) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func newTestDLQ() (*DeadLetterProducer, *mockKafkaWriter) {
+	w := newMockKafkaWriter()
+	return &DeadLetterProducer{
+		writer: w,
+		logger: zerolog.Nop(),
+		metrics: &dlqMetrics{
+			messagesTotal: prometheus.NewCounter(prometheus.CounterOpts{Name: "test_dlq_messages_total"}),
+			writeErrors:   prometheus.NewCounter(prometheus.CounterOpts{Name: "test_dlq_write_errors_total"}),
+			queueSize:     prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_dlq_queue_size"}),
+		},
+	}, w
+}
+
+// newRetryTestConsumer builds a consumer with a retry policy of 2 in-place
+// attempts and the given retry topic delays, backed by mock writers.
+func newRetryTestConsumer(handler MessageHandler, delays ...time.Duration) (*Consumer, *mockKafkaWriter, *mockKafkaWriter) {
+	c := newTestConsumer(handler)
+	c.group = "spike-detector"
+	c.topic = "wikipedia.edits"
+	c.retry = &config.RetryPolicy{
+		Attempts:       2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		RetryDelays:    delays,
+	}
+	dlq, dlqWriter := newTestDLQ()
+	c.dlq = dlq
+	c.poison = NewPoisonMessageHandler(handler, dlq, c.group, zerolog.Nop())
+
+	retryWriter := newMockKafkaWriter()
+	if len(delays) > 0 {
+		c.retryWriter = retryWriter
+	}
+	return c, retryWriter, dlqWriter
+}
+
+func testEditMessage(t *testing.T) kafka.Message {
+	t.Helper()
+	data, err := json.Marshal(models.WikipediaEdit{Title: "Paris", Wiki: "frwiki"})
+	require.NoError(t, err)
+	return kafka.Message{Topic: "wikipedia.edits", Partition: 2, Offset: 41, Key: []byte("Paris"), Value: data}
+}
+
+func TestRetryTopic(t *testing.T) {
+	assert.Equal(t, "wikipedia.edits.spike-detector.retry.1", RetryTopic("wikipedia.edits", "spike-detector", 1))
+}
+
+func TestRetryMessage_Headers(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	orig := testEditMessage(t)
+	orig.Headers = []kafka.Header{{Key: "wiki", Value: []byte("frwiki")}}
+
+	first := retryMessage(orig, "r1", 1, 30*time.Second, errors.New("redis down"), now)
+	assert.Equal(t, "r1", first.Topic)
+	assert.Equal(t, orig.Value, first.Value)
+	assert.Equal(t, "frwiki", headerValue(first.Headers, "wiki"))
+	assert.Equal(t, "1", headerValue(first.Headers, HeaderRetryLevel))
+	assert.Equal(t, "redis down", headerValue(first.Headers, HeaderRetryError))
+	assert.Equal(t, now.Add(30*time.Second), retryDue(first))
+
+	// Consumed from the retry topic and re-queued, it keeps its origin
+	first.Partition, first.Offset = 0, 7
+	second := retryMessage(first, "r2", 2, 5*time.Minute, errors.New("still down"), now)
+	assert.Len(t, second.Headers, len(first.Headers))
+	assert.Equal(t, "2", headerValue(second.Headers, HeaderRetryLevel))
+
+	src := originalSource(second)
+	assert.Equal(t, "wikipedia.edits", src.Topic)
+	assert.Equal(t, 2, src.Partition)
+	assert.Equal(t, int64(41), src.Offset)
+}
+
+func TestHandleMessage_RetriesInPlace(t *testing.T) {
+	h := &flakyHandler{failures: 1, err: errors.New("transient")}
+	c, retryWriter, dlqWriter := newRetryTestConsumer(h, time.Minute)
+	defer c.cancel()
+
+	assert.True(t, c.handleMessage(testEditMessage(t), 0))
+	assert.Equal(t, 2, h.calls)
+	assert.Empty(t, retryWriter.GetMessages())
+	assert.Empty(t, dlqWriter.GetMessages())
+}
+
+func TestHandleMessage_RoutesToRetryTopic(t *testing.T) {
+	h := &flakyHandler{failures: 100, err: errors.New("redis down")}
+	c, retryWriter, dlqWriter := newRetryTestConsumer(h, time.Minute, 10*time.Minute)
+	defer c.cancel()
+
+	assert.True(t, c.handleMessage(testEditMessage(t), 0))
+	assert.Equal(t, 2, h.calls, "in-place attempts come first")
+
+	msgs := retryWriter.GetMessages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "wikipedia.edits.spike-detector.retry.1", msgs[0].Topic)
+	assert.Empty(t, dlqWriter.GetMessages())
+
+	// Failing again at the second level moves on to the third topic
+	assert.True(t, c.handleMessage(msgs[0], 1))
+	msgs = retryWriter.GetMessages()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "wikipedia.edits.spike-detector.retry.2", msgs[1].Topic)
+}
+
+func TestHandleMessage_ExhaustedRetriesGoToDLQ(t *testing.T) {
+	h := &flakyHandler{failures: 100, err: errors.New("redis down")}
+	c, retryWriter, dlqWriter := newRetryTestConsumer(h, time.Minute)
+	defer c.cancel()
+
+	retried := retryMessage(testEditMessage(t), RetryTopic(c.topic, c.group, 1), 1, 0, errors.New("redis down"), time.Now())
+	assert.True(t, c.handleMessage(retried, 1))
+	assert.Empty(t, retryWriter.GetMessages())
+
+	msgs := dlqWriter.GetMessages()
+	require.Len(t, msgs, 1)
+	var dlqMsg DLQMessage
+	require.NoError(t, json.Unmarshal(msgs[0].Value, &dlqMsg))
+	assert.Equal(t, "wikipedia.edits", dlqMsg.OriginalTopic)
+	assert.Equal(t, int64(41), dlqMsg.OriginalOffset)
+	assert.Equal(t, "spike-detector", dlqMsg.ConsumerGroup)
+	assert.Contains(t, dlqMsg.Error, "redis down")
+}
+
+func TestHandleMessage_NonRetryableSkipsRetryTopics(t *testing.T) {
+	h := &flakyHandler{failures: 100, err: resilience.NewNonRetryableError(errors.New("invalid edit"))}
+	c, retryWriter, dlqWriter := newRetryTestConsumer(h, time.Minute)
+	defer c.cancel()
+
+	assert.True(t, c.handleMessage(testEditMessage(t), 0))
+	assert.Equal(t, 1, h.calls)
+	assert.Empty(t, retryWriter.GetMessages())
+	assert.Len(t, dlqWriter.GetMessages(), 1)
+}
+
+func TestHandleMessage_PoisonMessageGoesToDLQ(t *testing.T) {
+	h := &flakyHandler{}
+	c, _, dlqWriter := newRetryTestConsumer(h, time.Minute)
+	defer c.cancel()
+
+	assert.True(t, c.handleMessage(kafka.Message{Topic: "wikipedia.edits", Value: []byte("not-json")}, 0))
+	assert.Equal(t, 0, h.calls)
+	assert.Len(t, dlqWriter.GetMessages(), 1)
+}
+
+func TestHandleMessage_ShutdownLeavesMessageUncommitted(t *testing.T) {
+	h := &flakyHandler{failures: 100, err: errors.New("redis down")}
+	c, _, dlqWriter := newRetryTestConsumer(h)
+	dlqWriter.SetShouldError(true)
+	c.cancel()
+
+	assert.False(t, c.handleMessage(testEditMessage(t), 0))
+}
+
+func TestHandleMessage_NoRetryPolicyCommits(t *testing.T) {
+	h := &mockHandler{err: errors.New("processing failure")}
+	c := newTestConsumer(h)
+	defer c.cancel()
+
+	assert.True(t, c.handleMessage(testEditMessage(t), 0))
+	assert.Len(t, h.edits, 1)
+}
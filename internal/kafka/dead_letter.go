@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
@@ -16,7 +18,7 @@ import (
 // DeadLetterProducer writes corrupted / un-processable messages to a dead
 // letter queue (DLQ) topic for later inspection or replay.
 type DeadLetterProducer struct {
-	writer  *kafka.Writer
+	writer  WriterInterface
 	logger  zerolog.Logger
 	metrics *dlqMetrics
 
@@ -35,6 +37,7 @@ type DLQMessage struct {
 	Error             string `json:"error"`
 	Timestamp         string `json:"timestamp"`
 	ConsumerGroup     string `json:"consumer_group"`
+	ReplayCount       int    `json:"replay_count,omitempty"` // Times the message was replayed out of the DLQ before landing here again
 }
 
 type dlqMetrics struct {
@@ -62,6 +65,8 @@ func NewDeadLetterProducer(brokers []string, logger zerolog.Logger) (*DeadLetter
 		WriteTimeout: 10 * time.Second,
 		RequiredAcks: kafka.RequireAll,
 		Async:        false,
+		// Brokers that auto-create topics make the DLQ appear on first use
+		AllowAutoTopicCreation: true,
 	}
 
 	dlq := &DeadLetterProducer{
@@ -96,7 +101,13 @@ func NewDeadLetterProducer(brokers []string, logger zerolog.Logger) (*DeadLetter
 }
 
 // SendToDLQ writes a poison message to the DLQ with error context.
+//
+// Messages arriving from a retry topic are recorded against the topic,
+// partition and offset they were first consumed from, and the replay count of
+// a message replayed out of the DLQ is carried over.
 func (d *DeadLetterProducer) SendToDLQ(ctx context.Context, originalMsg kafka.Message, processingErr error, consumerGroup string) error {
+	originalMsg = originalSource(originalMsg)
+	replayCount := ReplayCount(originalMsg)
 	dlqMsg := DLQMessage{
 		OriginalTopic:     originalMsg.Topic,
 		OriginalPartition: originalMsg.Partition,
@@ -106,6 +117,7 @@ func (d *DeadLetterProducer) SendToDLQ(ctx context.Context, originalMsg kafka.Me
 		Error:             processingErr.Error(),
 		Timestamp:         time.Now().UTC().Format(time.RFC3339),
 		ConsumerGroup:     consumerGroup,
+		ReplayCount:       replayCount,
 	}
 
 	value, err := json.Marshal(dlqMsg)
@@ -123,6 +135,9 @@ func (d *DeadLetterProducer) SendToDLQ(ctx context.Context, originalMsg kafka.Me
 			{Key: "consumer_group", Value: []byte(consumerGroup)},
 		},
 	}
+	if replayCount > 0 {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderReplayCount, Value: []byte(strconv.Itoa(replayCount))})
+	}
 
 	if err := d.writer.WriteMessages(ctx, msg); err != nil {
 		d.metrics.writeErrors.Inc()
@@ -178,6 +193,31 @@ type poisonMetrics struct {
 	dlqRateThreshold int64
 }
 
+// Poison metrics are shared by the handlers of every consumer group
+var (
+	sharedPoisonMetrics     *poisonMetrics
+	sharedPoisonMetricsOnce sync.Once
+)
+
+func getSharedPoisonMetrics() *poisonMetrics {
+	sharedPoisonMetricsOnce.Do(func() {
+		sharedPoisonMetrics = &poisonMetrics{
+			poisonMessages: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "poison_messages_total",
+				Help: "Total poison messages detected and sent to DLQ",
+			}),
+			highDLQAlerts: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "poison_high_dlq_rate_alerts_total",
+				Help: "Alerts fired for high DLQ rate",
+			}),
+			dlqRateThreshold: 100, // alert if > 100 DLQ messages
+		}
+		prometheus.Register(sharedPoisonMetrics.poisonMessages)
+		prometheus.Register(sharedPoisonMetrics.highDLQAlerts)
+	})
+	return sharedPoisonMetrics
+}
+
 // NewPoisonMessageHandler creates a handler that wraps inner with DLQ support.
 func NewPoisonMessageHandler(
 	inner MessageHandler,
@@ -185,35 +225,21 @@ func NewPoisonMessageHandler(
 	consumerGroup string,
 	logger zerolog.Logger,
 ) *PoisonMessageHandler {
-	pmh := &PoisonMessageHandler{
-		inner:  inner,
-		dlq:    dlq,
-		logger: logger.With().Str("component", "poison-handler").Logger(),
-		group:  consumerGroup,
+	return &PoisonMessageHandler{
+		inner:   inner,
+		dlq:     dlq,
+		logger:  logger.With().Str("component", "poison-handler").Logger(),
+		group:   consumerGroup,
+		metrics: getSharedPoisonMetrics(),
 	}
-
-	pmh.metrics = &poisonMetrics{
-		poisonMessages: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "poison_messages_total",
-			Help: "Total poison messages detected and sent to DLQ",
-		}),
-		highDLQAlerts: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "poison_high_dlq_rate_alerts_total",
-			Help: "Alerts fired for high DLQ rate",
-		}),
-		dlqRateThreshold: 100, // alert if > 100 DLQ messages
-	}
-	prometheus.Register(pmh.metrics.poisonMessages)
-	prometheus.Register(pmh.metrics.highDLQAlerts)
-
-	return pmh
 }
 
-// HandleMessage tries to process the message. On failure it sends to DLQ and
-// returns nil so the consumer can continue.
+// HandleMessage decodes the message and passes it to the inner handler. A
+// message that cannot be decoded is sent to the DLQ and nil is returned so
+// the consumer can continue; errors from the inner handler are returned for
+// the consumer's retry policy to deal with.
 func (p *PoisonMessageHandler) HandleMessage(ctx context.Context, msg kafka.Message) error {
-	// Try to parse and process.
-	var edit struct{} // minimal parse check
+	var edit models.WikipediaEdit
 	if err := json.Unmarshal(msg.Value, &edit); err != nil {
 		// Corrupted message — send to DLQ.
 		p.logger.Error().
@@ -232,7 +258,7 @@ func (p *PoisonMessageHandler) HandleMessage(ctx context.Context, msg kafka.Mess
 		return nil // don't block consumer
 	}
 
-	return nil // actual processing happens through the inner handler
+	return p.inner.ProcessEdit(ctx, &edit)
 }
 
 func (p *PoisonMessageHandler) checkDLQRate() {
@@ -0,0 +1,401 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// HeaderReplayCount counts how many times a message has been replayed
+	// out of the DLQ. It survives retry topics and is copied into the DLQ
+	// record when the message fails again.
+	HeaderReplayCount = "replay_count"
+
+	// DefaultMaxReplays is how many times a message may be replayed before
+	// the DLQ admin refuses, so a message that can never succeed does not
+	// cycle through the pipeline forever.
+	DefaultMaxReplays = 3
+
+	// dlqListMax caps how many entries a single listing returns.
+	dlqListMax = 1000
+
+	// dlqReplayedKey is the Redis set of DLQ entry IDs already replayed.
+	// IDs are removed once their record leaves the DLQ topic.
+	dlqReplayedKey = "dlq:replayed"
+
+	// dlqReadTimeout bounds each read from a DLQ partition, in case the
+	// offsets below the high watermark are never delivered.
+	dlqReadTimeout = 10 * time.Second
+)
+
+// ErrNoReplaySelection is returned by Replay when neither IDs, a filter nor
+// All was given, to avoid replaying the whole DLQ by accident.
+var ErrNoReplaySelection = errors.New("no DLQ messages selected: pass ids, a filter, or all")
+
+// ErrNoRetryTopic is returned by Replay when the target consumer group has no
+// retry topic to replay into.
+var ErrNoRetryTopic = errors.New("consumer group has no retry topic to replay into")
+
+// DLQEntry is one DLQ record together with its position in the DLQ topic.
+type DLQEntry struct {
+	ID        string `json:"id"` // "partition:offset" within the DLQ topic
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Replayed  bool   `json:"replayed"` // already replayed by the DLQ admin
+	DLQMessage
+}
+
+// DLQFilter selects DLQ entries. Empty fields match everything.
+type DLQFilter struct {
+	Error         string   // case-insensitive substring of the error
+	ConsumerGroup string   // exact consumer group
+	IDs           []string // entry IDs
+}
+
+// IsEmpty reports whether the filter matches every entry.
+func (f DLQFilter) IsEmpty() bool {
+	return f.Error == "" && f.ConsumerGroup == "" && len(f.IDs) == 0
+}
+
+func (f DLQFilter) matches(e DLQEntry) bool {
+	if f.ConsumerGroup != "" && e.ConsumerGroup != f.ConsumerGroup {
+		return false
+	}
+	if f.Error != "" && !strings.Contains(strings.ToLower(e.Error), strings.ToLower(f.Error)) {
+		return false
+	}
+	if len(f.IDs) > 0 {
+		for _, id := range f.IDs {
+			if id == e.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// DLQReplayRequest selects DLQ entries and where to send them. With no
+// TargetGroup, messages go back to their original topic and every consumer
+// group sees them again; with one, they go to that group's first retry topic
+// and only it reprocesses them. Entries replayed before are skipped unless
+// Force is set.
+type DLQReplayRequest struct {
+	DLQFilter
+	All         bool
+	TargetGroup string
+	Force       bool
+}
+
+// DLQReplayResult reports the outcome of a replay.
+type DLQReplayResult struct {
+	Replayed        int      `json:"replayed"`
+	Skipped         []string `json:"skipped,omitempty"`          // IDs over the replay limit
+	AlreadyReplayed []string `json:"already_replayed,omitempty"` // IDs replayed before, without Force
+}
+
+// dlqReader reads every record currently in the DLQ topic.
+type dlqReader interface {
+	ReadDLQ(ctx context.Context) ([]kafka.Message, error)
+}
+
+// DLQAdmin lists and replays dead-lettered messages. The IDs it has
+// replayed are kept in Redis, since the DLQ records themselves cannot be
+// changed.
+type DLQAdmin struct {
+	reader     dlqReader
+	writer     WriterInterface
+	redis      *redis.Client
+	retry      config.KafkaRetry
+	maxReplays int
+	logger     zerolog.Logger
+}
+
+// NewDLQAdmin creates a DLQ admin for the given brokers. The retry config is
+// used to check that a replay target group actually has a retry topic, and
+// redisClient records which entries have been replayed.
+func NewDLQAdmin(brokers []string, retry config.KafkaRetry, redisClient *redis.Client, logger zerolog.Logger) (*DLQAdmin, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers provided for DLQ admin")
+	}
+	return &DLQAdmin{
+		reader: &topicDLQReader{brokers: brokers, topic: DefaultDLQTopic},
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: 10 * time.Millisecond,
+			WriteTimeout: DefaultWriteTimeout,
+			RequiredAcks: kafka.RequireAll,
+		},
+		redis:      redisClient,
+		retry:      retry,
+		maxReplays: DefaultMaxReplays,
+		logger:     logger.With().Str("component", "dlq-admin").Logger(),
+	}, nil
+}
+
+// List returns up to limit DLQ entries matching filter, oldest first, and the
+// total number that matched.
+func (a *DLQAdmin) List(ctx context.Context, filter DLQFilter, limit int) ([]DLQEntry, int, error) {
+	if limit <= 0 || limit > dlqListMax {
+		limit = dlqListMax
+	}
+	entries, err := a.load(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(entries)
+	if total > limit {
+		entries = entries[:limit]
+	}
+	return entries, total, nil
+}
+
+// Replay re-publishes the selected DLQ entries. Kafka topics are append-only,
+// so replayed entries stay in the DLQ; their IDs are recorded instead, and a
+// later replay skips them unless req.Force is set. A message that fails
+// again is dead-lettered as a new entry carrying its replay count, and
+// entries already replayed MaxReplays times are skipped even when forced.
+func (a *DLQAdmin) Replay(ctx context.Context, req DLQReplayRequest) (DLQReplayResult, error) {
+	var result DLQReplayResult
+	if req.DLQFilter.IsEmpty() && !req.All {
+		return result, ErrNoReplaySelection
+	}
+	if req.TargetGroup != "" {
+		if !a.retry.Enabled || len(a.retry.PolicyFor(req.TargetGroup).RetryDelays) == 0 {
+			return result, fmt.Errorf("%w: %q", ErrNoRetryTopic, req.TargetGroup)
+		}
+	}
+
+	entries, err := a.load(ctx, req.DLQFilter)
+	if err != nil {
+		return result, err
+	}
+
+	selected := make([]DLQEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.ReplayCount >= a.maxReplays {
+			result.Skipped = append(result.Skipped, e.ID)
+			continue
+		}
+		selected = append(selected, e)
+	}
+	if len(selected) == 0 {
+		return result, nil
+	}
+
+	// Claim the entries before sending them, so two concurrent replays do
+	// not both send an entry.
+	pipe := a.redis.Pipeline()
+	claims := make([]*redis.IntCmd, len(selected))
+	for i, e := range selected {
+		claims[i] = pipe.SAdd(ctx, dlqReplayedKey, e.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return result, fmt.Errorf("failed to record replayed DLQ messages: %w", err)
+	}
+
+	now := time.Now()
+	msgs := make([]kafka.Message, 0, len(selected))
+	var claimed []interface{}
+	for i, e := range selected {
+		first := claims[i].Val() == 1
+		if first {
+			claimed = append(claimed, e.ID)
+		} else if !req.Force {
+			result.AlreadyReplayed = append(result.AlreadyReplayed, e.ID)
+			continue
+		}
+		msgs = append(msgs, replayMessage(e, req.TargetGroup, now))
+	}
+	if len(msgs) == 0 {
+		return result, nil
+	}
+
+	if err := a.writer.WriteMessages(ctx, msgs...); err != nil {
+		if len(claimed) > 0 {
+			_ = a.redis.SRem(context.WithoutCancel(ctx), dlqReplayedKey, claimed...).Err()
+		}
+		return result, fmt.Errorf("failed to replay DLQ messages: %w", err)
+	}
+	result.Replayed = len(msgs)
+
+	a.logger.Info().
+		Int("replayed", result.Replayed).
+		Int("skipped", len(result.Skipped)).
+		Int("already_replayed", len(result.AlreadyReplayed)).
+		Str("target_group", req.TargetGroup).
+		Msg("Replayed DLQ messages")
+	return result, nil
+}
+
+// Close releases the admin's Kafka writer.
+func (a *DLQAdmin) Close() error {
+	return a.writer.Close()
+}
+
+// load reads and decodes the DLQ, keeping entries that match filter. It
+// also forgets replayed IDs whose records have left the DLQ topic.
+func (a *DLQAdmin) load(ctx context.Context, filter DLQFilter) ([]DLQEntry, error) {
+	msgs, err := a.reader.ReadDLQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replayed, err := a.redis.SMembers(ctx, dlqReplayedKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replayed DLQ messages: %w", err)
+	}
+	stale := make(map[string]bool, len(replayed))
+	for _, id := range replayed {
+		stale[id] = true
+	}
+
+	entries := make([]DLQEntry, 0, len(msgs))
+	for _, m := range msgs {
+		e := DLQEntry{
+			ID:        fmt.Sprintf("%d:%d", m.Partition, m.Offset),
+			Partition: m.Partition,
+			Offset:    m.Offset,
+		}
+		e.Replayed = stale[e.ID]
+		delete(stale, e.ID)
+		if err := json.Unmarshal(m.Value, &e.DLQMessage); err != nil {
+			a.logger.Warn().Err(err).Str("id", e.ID).Msg("Skipping undecodable DLQ record")
+			continue
+		}
+		if filter.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	if len(stale) > 0 {
+		ids := make([]interface{}, 0, len(stale))
+		for id := range stale {
+			ids = append(ids, id)
+		}
+		if err := a.redis.SRem(ctx, dlqReplayedKey, ids...).Err(); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to forget expired replayed DLQ messages")
+		}
+	}
+	return entries, nil
+}
+
+// replayMessage rebuilds the original message from a DLQ entry, addressed to
+// its source topic or to targetGroup's first retry topic (due immediately).
+func replayMessage(e DLQEntry, targetGroup string, now time.Time) kafka.Message {
+	msg := kafka.Message{
+		Topic: e.OriginalTopic,
+		Key:   []byte(e.OriginalKey),
+		Value: []byte(e.OriginalValue),
+	}
+	if targetGroup != "" {
+		msg.Topic = RetryTopic(e.OriginalTopic, targetGroup, 1)
+		msg.Headers = append(msg.Headers,
+			kafka.Header{Key: HeaderOriginalTopic, Value: []byte(e.OriginalTopic)},
+			kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(e.OriginalPartition))},
+			kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(e.OriginalOffset, 10))},
+			kafka.Header{Key: HeaderRetryLevel, Value: []byte("1")},
+			kafka.Header{Key: HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(now.UnixMilli(), 10))},
+		)
+	}
+	msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderReplayCount, Value: []byte(strconv.Itoa(e.ReplayCount + 1))})
+	return msg
+}
+
+// ReplayCount returns the replay count header of msg, or 0.
+func ReplayCount(msg kafka.Message) int {
+	n, err := strconv.Atoi(headerValue(msg.Headers, HeaderReplayCount))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// topicDLQReader reads the DLQ topic partition by partition, from the oldest
+// retained offset up to the high watermark at the time of the call.
+type topicDLQReader struct {
+	brokers []string
+	topic   string
+}
+
+func (r *topicDLQReader) ReadDLQ(ctx context.Context) ([]kafka.Message, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", r.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(r.topic)
+	conn.Close()
+	if errors.Is(err, kafka.UnknownTopicOrPartition) {
+		return nil, nil // nothing has been dead-lettered yet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ partitions: %w", err)
+	}
+
+	var msgs []kafka.Message
+	for _, p := range partitions {
+		partMsgs, err := r.readPartition(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, partMsgs...)
+	}
+	return msgs, nil
+}
+
+func (r *topicDLQReader) readPartition(ctx context.Context, partition int) ([]kafka.Message, error) {
+	leader, err := kafka.DialLeader(ctx, "tcp", r.brokers[0], r.topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DLQ partition %d leader: %w", partition, err)
+	}
+	first, last, err := leader.ReadOffsets()
+	leader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ partition %d offsets: %w", partition, err)
+	}
+	if first >= last {
+		return nil, nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     r.topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10 * 1024 * 1024,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(first); err != nil {
+		return nil, fmt.Errorf("failed to seek DLQ partition %d: %w", partition, err)
+	}
+
+	// Offsets below the high watermark are not all delivered (compacted or
+	// transaction control records), so stop once the reader has caught up,
+	// or when a read times out without ctx being done.
+	msgs := make([]kafka.Message, 0, last-first)
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, dlqReadTimeout)
+		m, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return msgs, nil
+			}
+			return nil, fmt.Errorf("failed to read DLQ partition %d: %w", partition, err)
+		}
+		msgs = append(msgs, m)
+		if m.Offset >= last-1 || reader.Lag() <= 0 {
+			return msgs, nil
+		}
+	}
+}
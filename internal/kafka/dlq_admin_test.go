@@ -0,0 +1,186 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDLQReader struct {
+	msgs []kafka.Message
+}
+
+func (f *fakeDLQReader) ReadDLQ(context.Context) ([]kafka.Message, error) {
+	return f.msgs, nil
+}
+
+func dlqRecord(t *testing.T, offset int64, msg DLQMessage) kafka.Message {
+	t.Helper()
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	return kafka.Message{Topic: DefaultDLQTopic, Offset: offset, Value: data}
+}
+
+func newTestDLQAdmin(t *testing.T) (*DLQAdmin, *mockKafkaWriter) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	w := newMockKafkaWriter()
+	reader := &fakeDLQReader{msgs: []kafka.Message{
+		dlqRecord(t, 0, DLQMessage{OriginalTopic: "wikipedia.edits", OriginalOffset: 10, OriginalKey: "Paris", OriginalValue: `{"title":"Paris"}`, Error: "redis: connection refused", ConsumerGroup: "spike-detector"}),
+		dlqRecord(t, 1, DLQMessage{OriginalTopic: "wikipedia.edits", OriginalOffset: 11, OriginalKey: "Berlin", OriginalValue: `{"title":"Berlin"}`, Error: "unmarshal error", ConsumerGroup: "trending-aggregator"}),
+		{Topic: DefaultDLQTopic, Offset: 2, Value: []byte("garbage")},
+		dlqRecord(t, 3, DLQMessage{OriginalTopic: "wikipedia.edits", OriginalOffset: 12, OriginalKey: "Rome", OriginalValue: `{"title":"Rome"}`, Error: "Redis: timeout", ConsumerGroup: "spike-detector", ReplayCount: DefaultMaxReplays}),
+	}}
+	return &DLQAdmin{
+		reader: reader,
+		writer: w,
+		redis:  client,
+		retry: config.KafkaRetry{
+			Enabled: true,
+			Default: config.RetryPolicy{Attempts: 1, RetryDelays: []time.Duration{time.Minute}},
+			Consumers: map[string]config.RetryPolicy{
+				"websocket-forwarder": {RetryDelays: []time.Duration{}},
+			},
+		},
+		maxReplays: DefaultMaxReplays,
+		logger:     zerolog.Nop(),
+	}, w
+}
+
+func TestDLQAdmin_List(t *testing.T) {
+	admin, _ := newTestDLQAdmin(t)
+	ctx := context.Background()
+
+	entries, total, err := admin.List(ctx, DLQFilter{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total, "undecodable records are skipped")
+	assert.Equal(t, "0:0", entries[0].ID)
+	assert.Equal(t, "Paris", entries[0].OriginalKey)
+
+	entries, total, err = admin.List(ctx, DLQFilter{Error: "redis", ConsumerGroup: "spike-detector"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "0:0", entries[0].ID)
+
+	entries, _, err = admin.List(ctx, DLQFilter{IDs: []string{"0:3"}}, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Rome", entries[0].OriginalKey)
+}
+
+func TestDLQAdmin_ReplayToSourceTopic(t *testing.T) {
+	admin, w := newTestDLQAdmin(t)
+
+	result, err := admin.Replay(context.Background(), DLQReplayRequest{DLQFilter: DLQFilter{ConsumerGroup: "spike-detector"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+	assert.Equal(t, []string{"0:3"}, result.Skipped, "entries at the replay limit are skipped")
+
+	msgs := w.GetMessages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "wikipedia.edits", msgs[0].Topic)
+	assert.Equal(t, "Paris", string(msgs[0].Key))
+	assert.Equal(t, `{"title":"Paris"}`, string(msgs[0].Value))
+	assert.Equal(t, 1, ReplayCount(msgs[0]))
+}
+
+func TestDLQAdmin_ReplayToGroup(t *testing.T) {
+	admin, w := newTestDLQAdmin(t)
+
+	result, err := admin.Replay(context.Background(), DLQReplayRequest{
+		DLQFilter:   DLQFilter{IDs: []string{"0:1"}},
+		TargetGroup: "edit-war-detector",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+
+	msgs := w.GetMessages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "wikipedia.edits.edit-war-detector.retry.1", msgs[0].Topic)
+	assert.False(t, retryDue(msgs[0]).After(time.Now()), "replayed messages are due immediately")
+
+	src := originalSource(msgs[0])
+	assert.Equal(t, "wikipedia.edits", src.Topic)
+	assert.Equal(t, int64(11), src.Offset)
+}
+
+func TestDLQAdmin_ReplayValidation(t *testing.T) {
+	admin, w := newTestDLQAdmin(t)
+	ctx := context.Background()
+
+	_, err := admin.Replay(ctx, DLQReplayRequest{})
+	assert.True(t, errors.Is(err, ErrNoReplaySelection))
+
+	_, err = admin.Replay(ctx, DLQReplayRequest{All: true, TargetGroup: "websocket-forwarder"})
+	assert.True(t, errors.Is(err, ErrNoRetryTopic))
+
+	result, err := admin.Replay(ctx, DLQReplayRequest{All: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Replayed)
+	assert.Len(t, w.GetMessages(), 2)
+}
+
+func TestDLQAdmin_ReplaySkipsReplayedEntries(t *testing.T) {
+	admin, w := newTestDLQAdmin(t)
+	ctx := context.Background()
+
+	result, err := admin.Replay(ctx, DLQReplayRequest{All: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Replayed)
+
+	entries, _, err := admin.List(ctx, DLQFilter{IDs: []string{"0:0"}}, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Replayed)
+
+	// Replaying everything again must not send the same entries twice.
+	result, err = admin.Replay(ctx, DLQReplayRequest{All: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Replayed)
+	assert.ElementsMatch(t, []string{"0:0", "0:1"}, result.AlreadyReplayed)
+	assert.Len(t, w.GetMessages(), 2)
+
+	result, err = admin.Replay(ctx, DLQReplayRequest{DLQFilter: DLQFilter{IDs: []string{"0:1"}}, Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+	assert.Len(t, w.GetMessages(), 3)
+
+	// Once a record leaves the DLQ topic its ID is forgotten.
+	admin.reader.(*fakeDLQReader).msgs = admin.reader.(*fakeDLQReader).msgs[1:]
+	_, _, err = admin.List(ctx, DLQFilter{}, 0)
+	require.NoError(t, err)
+	ids, err := admin.redis.SMembers(ctx, dlqReplayedKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0:1"}, ids)
+}
+
+func TestSendToDLQ_CarriesReplayCount(t *testing.T) {
+	dlq, w := newTestDLQ()
+	msg := testEditMessage(t)
+	msg.Headers = []kafka.Header{{Key: HeaderReplayCount, Value: []byte("2")}}
+
+	require.NoError(t, dlq.SendToDLQ(context.Background(), msg, errors.New("still failing"), "spike-detector"))
+
+	out := w.GetMessages()
+	require.Len(t, out, 1)
+	var dlqMsg DLQMessage
+	require.NoError(t, json.Unmarshal(out[0].Value, &dlqMsg))
+	assert.Equal(t, 2, dlqMsg.ReplayCount)
+	assert.Equal(t, 2, ReplayCount(out[0]))
+}
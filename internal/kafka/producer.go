@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -36,12 +37,20 @@ const (
 	DefaultFlushInterval = 100 * time.Millisecond
 	DefaultWriteTimeout  = 10 * time.Second
 	DefaultReadTimeout   = 10 * time.Second
+
+	// Spill replay backs off between failed attempts, doubling up to the max.
+	spillReplayBackoff    = 1 * time.Second
+	spillReplayMaxBackoff = 30 * time.Second
+	// spillReplayBatches caps how many batches are replayed per flush tick so
+	// a large backlog does not starve the live batch.
+	spillReplayBatches = 10
 )
 
 // Producer handles asynchronous message production to Kafka
 type Producer struct {
 	writer        WriterInterface
 	config        *config.Config
+	topic         string
 	logger        zerolog.Logger
 	buffer        chan *models.WikipediaEdit
 	batchSize     int
@@ -51,6 +60,12 @@ type Producer struct {
 	mu            sync.RWMutex
 	isRunning     bool
 	droppedCount  int64
+
+	// spill absorbs messages while Kafka is unavailable; nil when disabled.
+	// It is replayed from the batching loop, which owns the backoff fields.
+	spill         *SpillQueue
+	replayAfter   time.Time
+	replayBackoff time.Duration
 }
 
 // NewProducer creates a new Kafka producer instance
@@ -82,6 +97,7 @@ func NewProducer(brokers []string, topic string, cfg *config.Config, logger zero
 	producer := &Producer{
 		writer:        writer,
 		config:        cfg,
+		topic:         topic,
 		logger:        logger.With().Str("component", "kafka-producer").Logger(),
 		buffer:        make(chan *models.WikipediaEdit, DefaultBufferSize),
 		batchSize:     DefaultBatchSize,
@@ -89,6 +105,21 @@ func NewProducer(brokers []string, topic string, cfg *config.Config, logger zero
 		stopChan:      make(chan struct{}),
 	}
 	
+	if cfg != nil && cfg.Kafka.Spill.Enabled {
+		spill, err := NewSpillQueue(cfg.Kafka.Spill, topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open spill queue: %w", err)
+		}
+		producer.spill = spill
+		metrics.KafkaSpillBytes.WithLabelValues(topic).Set(float64(spill.Size()))
+		if pending := spill.Len(); pending > 0 {
+			producer.logger.Warn().
+				Int("pending", pending).
+				Int64("bytes", spill.Size()).
+				Msg("Spill queue has messages from a previous run; they will be replayed")
+		}
+	}
+	
 	producer.logger.Info().
 		Strs("brokers", brokers).
 		Str("topic", topic).
@@ -140,7 +171,7 @@ func (p *Producer) batchingLoop() {
 		case <-p.stopChan:
 			// Flush remaining messages before shutdown
 			if len(batch) > 0 {
-				if err := p.writeBatch(batch); err != nil {
+				if err := p.flush(batch); err != nil {
 					p.logger.Error().Err(err).Int("batch_size", len(batch)).Msg("Failed to flush remaining batch during shutdown")
 				}
 			}
@@ -160,7 +191,7 @@ func (p *Producer) batchingLoop() {
 			
 			// Flush if batch size reached
 			if len(batch) >= p.batchSize {
-				if err := p.writeBatch(batch); err != nil {
+				if err := p.flush(batch); err != nil {
 					p.logger.Error().Err(err).Int("batch_size", len(batch)).Msg("Failed to write batch")
 				}
 				batch = batch[:0] // Reset slice while keeping capacity
@@ -169,11 +200,12 @@ func (p *Producer) batchingLoop() {
 		case <-ticker.C:
 			// Flush on timer if batch not empty
 			if len(batch) > 0 {
-				if err := p.writeBatch(batch); err != nil {
+				if err := p.flush(batch); err != nil {
 					p.logger.Error().Err(err).Int("batch_size", len(batch)).Msg("Failed to write timed batch")
 				}
 				batch = batch[:0] // Reset slice while keeping capacity
 			}
+			p.replaySpill()
 		}
 	}
 }
@@ -192,7 +224,22 @@ func (p *Producer) Produce(edit *models.WikipediaEdit) error {
 	case p.buffer <- edit:
 		return nil
 	default:
-		// Buffer is full, drop message and increment metric
+		// Buffer is full: spill to disk if enabled. Edits still in the buffer
+		// were produced earlier but will be written after this one, so order
+		// is only preserved from here on, not across the overflow point.
+		if p.spill != nil {
+			message, err := p.editToKafkaMessage(edit)
+			if err != nil {
+				metrics.ProduceErrorsTotal.WithLabelValues("serialization").Inc()
+				return err
+			}
+			if p.spillMessages([]kafka.Message{message}, "buffer_full") == 0 {
+				return fmt.Errorf("producer buffer and spill queue full, message dropped")
+			}
+			return nil
+		}
+		
+		// Drop message and increment metric
 		p.mu.Lock()
 		p.droppedCount++
 		dropCount := p.droppedCount
@@ -211,6 +258,110 @@ func (p *Producer) Produce(edit *models.WikipediaEdit) error {
 	}
 }
 
+// flush writes a batch to Kafka, going through the spill queue when one is
+// configured. While the spill holds a backlog the batch is appended behind it
+// rather than written directly, so replay keeps messages in order; a batch
+// whose write fails is spilled too.
+func (p *Producer) flush(batch []kafka.Message) error {
+	if p.spill == nil {
+		return p.writeBatch(batch)
+	}
+	
+	if p.spill.Len() > 0 {
+		if n := p.spillMessages(batch, "backlog"); n < len(batch) {
+			return fmt.Errorf("spill queue full, %d messages dropped", len(batch)-n)
+		}
+		return nil
+	}
+	
+	if err := p.writeBatch(batch); err != nil {
+		p.backoffReplay()
+		if n := p.spillMessages(batch, "write_failed"); n < len(batch) {
+			return fmt.Errorf("%w; spill queue full, %d messages dropped", err, len(batch)-n)
+		}
+		p.logger.Warn().Int("batch_size", len(batch)).Msg("Kafka write failed, batch spilled to disk")
+	}
+	return nil
+}
+
+// spillMessages appends messages to the spill queue and returns how many were
+// accepted. Messages that do not fit are counted as dropped.
+func (p *Producer) spillMessages(msgs []kafka.Message, reason string) int {
+	n, err := p.spill.Append(msgs...)
+	if n > 0 {
+		metrics.KafkaSpillMessagesTotal.WithLabelValues(p.topic, reason).Add(float64(n))
+		metrics.KafkaSpillBytes.WithLabelValues(p.topic).Set(float64(p.spill.Size()))
+	}
+	if err != nil {
+		dropped := len(msgs) - n
+		p.mu.Lock()
+		p.droppedCount += int64(dropped)
+		p.mu.Unlock()
+		
+		if errors.Is(err, ErrSpillFull) {
+			metrics.MessagesDroppedTotal.WithLabelValues("spill_full").Add(float64(dropped))
+		} else {
+			metrics.MessagesDroppedTotal.WithLabelValues("spill_error").Add(float64(dropped))
+			p.logger.Error().Err(err).Int("dropped", dropped).Msg("Failed to write to spill queue")
+		}
+	}
+	return n
+}
+
+// replaySpill writes spilled messages back to Kafka, oldest first, a batch
+// at a time. Each batch is acknowledged only after Kafka accepts it, so a
+// crash mid-replay re-sends at most one batch. After a failed write, replay
+// waits out an exponential backoff before trying again.
+func (p *Producer) replaySpill() {
+	if p.spill == nil {
+		return
+	}
+	p.spill.syncIfDue(time.Now())
+	if p.spill.Len() == 0 || time.Now().Before(p.replayAfter) {
+		return
+	}
+	
+	for i := 0; i < spillReplayBatches; i++ {
+		msgs, cursor, err := p.spill.Read(p.batchSize)
+		if err != nil {
+			p.logger.Error().Err(err).Msg("Failed to read spill queue")
+			p.backoffReplay()
+			return
+		}
+		if len(msgs) == 0 {
+			return
+		}
+		if err := p.writeBatch(msgs); err != nil {
+			p.backoffReplay()
+			return
+		}
+		if err := p.spill.Ack(cursor); err != nil {
+			p.logger.Error().Err(err).Msg("Failed to acknowledge replayed spill messages")
+		}
+		p.replayBackoff = 0
+		metrics.KafkaSpillReplayedTotal.WithLabelValues(p.topic).Add(float64(len(msgs)))
+		metrics.KafkaSpillBytes.WithLabelValues(p.topic).Set(float64(p.spill.Size()))
+		
+		if p.spill.Len() == 0 {
+			p.logger.Info().Msg("Spill queue fully replayed")
+			return
+		}
+	}
+}
+
+// backoffReplay delays the next replay attempt after a failed write.
+func (p *Producer) backoffReplay() {
+	if p.replayBackoff == 0 {
+		p.replayBackoff = spillReplayBackoff
+	} else {
+		p.replayBackoff *= 2
+		if p.replayBackoff > spillReplayMaxBackoff {
+			p.replayBackoff = spillReplayMaxBackoff
+		}
+	}
+	p.replayAfter = time.Now().Add(p.replayBackoff)
+}
+
 // editToKafkaMessage converts a WikipediaEdit to a Kafka message
 func (p *Producer) editToKafkaMessage(edit *models.WikipediaEdit) (kafka.Message, error) {
 	// Marshal edit to JSON
@@ -226,6 +377,7 @@ func (p *Producer) editToKafkaMessage(edit *models.WikipediaEdit) (kafka.Message
 		Headers: []kafka.Header{
 			{Key: "wiki", Value: []byte(edit.Wiki)},
 			{Key: "language", Value: []byte(edit.Language())},
+			{Key: "project", Value: []byte(edit.Project())},
 			{Key: "timestamp", Value: []byte(fmt.Sprintf("%d", edit.Timestamp))},
 		},
 	}
@@ -294,6 +446,13 @@ func (p *Producer) Close() error {
 	// Close the buffer channel
 	close(p.buffer)
 	
+	// Anything left in the spill is replayed on the next start
+	if p.spill != nil {
+		if err := p.spill.Close(); err != nil {
+			p.logger.Error().Err(err).Msg("Error closing spill queue")
+		}
+	}
+	
 	// Close the Kafka writer
 	if err := p.writer.Close(); err != nil {
 		p.logger.Error().Err(err).Msg("Error closing Kafka writer")
@@ -312,7 +471,7 @@ func (p *Producer) GetStats() map[string]interface{} {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"is_running":     p.isRunning,
 		"buffer_size":    len(p.buffer),
 		"buffer_cap":     cap(p.buffer),
@@ -320,4 +479,9 @@ func (p *Producer) GetStats() map[string]interface{} {
 		"batch_size":     p.batchSize,
 		"flush_interval": p.flushInterval.String(),
 	}
+	if p.spill != nil {
+		stats["spill_pending"] = p.spill.Len()
+		stats["spill_bytes"] = p.spill.Size()
+	}
+	return stats
 }
\ No newline at end of file
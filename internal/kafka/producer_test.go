@@ -357,6 +357,7 @@ func TestEditToKafkaMessage(t *testing.T) {
 	expectedHeaders := map[string]string{
 		"wiki":      "enwiki",
 		"language":  "en",
+		"project":   "wikipedia",
 		"timestamp": "1640995200",
 		"bot":       "false",
 	}
@@ -515,4 +516,113 @@ func TestNilEditHandling(t *testing.T) {
 	if !strings.Contains(err.Error(), "cannot be nil") {
 		t.Errorf("Expected 'cannot be nil' error, got: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// createSpillTestProducer creates a mock-backed producer with a spill queue
+func createSpillTestProducer(t *testing.T, bufferSize int) (*Producer, *mockKafkaWriter) {
+	t.Helper()
+	producer, mockWriter := createTestProducer(5, 20*time.Millisecond)
+	producer.topic = "wikipedia.edits"
+	producer.buffer = make(chan *models.WikipediaEdit, bufferSize)
+	
+	spill, err := NewSpillQueue(testSpillConfig(t), producer.topic)
+	if err != nil {
+		t.Fatalf("Failed to open spill queue: %v", err)
+	}
+	producer.spill = spill
+	
+	return producer, mockWriter
+}
+
+func spillTestEdit(i int) *models.WikipediaEdit {
+	return &models.WikipediaEdit{
+		ID:        int64(i),
+		Type:      "edit",
+		Title:     fmt.Sprintf("Spill Page %d", i),
+		User:      "TestUser",
+		Wiki:      "enwiki",
+		ServerURL: "en.wikipedia.org",
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// TestSpillOnWriteFailure verifies failed batches are spilled and replayed in order
+func TestSpillOnWriteFailure(t *testing.T) {
+	producer, mockWriter := createSpillTestProducer(t, DefaultBufferSize)
+	mockWriter.SetShouldError(true)
+	
+	if err := producer.Start(); err != nil {
+		t.Fatalf("Failed to start producer: %v", err)
+	}
+	defer producer.Close()
+	
+	// The first batch fails and is spilled
+	for i := 0; i < 3; i++ {
+		if err := producer.Produce(spillTestEdit(i)); err != nil {
+			t.Fatalf("Failed to produce edit %d: %v", i, err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	
+	if producer.spill.Len() != 3 {
+		t.Fatalf("Expected 3 spilled messages, got %d", producer.spill.Len())
+	}
+	
+	// Later batches queue behind the backlog instead of being written
+	calls := mockWriter.GetWriteCallCount()
+	for i := 3; i < 5; i++ {
+		if err := producer.Produce(spillTestEdit(i)); err != nil {
+			t.Fatalf("Failed to produce edit %d: %v", i, err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	
+	if producer.spill.Len() != 5 {
+		t.Fatalf("Expected 5 spilled messages, got %d", producer.spill.Len())
+	}
+	if mockWriter.GetWriteCallCount() != calls {
+		t.Errorf("Expected no writes during replay backoff, got %d", mockWriter.GetWriteCallCount()-calls)
+	}
+	
+	// Once Kafka recovers the backlog is replayed after the backoff
+	mockWriter.SetShouldError(false)
+	deadline := time.Now().Add(3 * time.Second)
+	for producer.spill.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	
+	messages := mockWriter.GetMessages()
+	if len(messages) != 5 {
+		t.Fatalf("Expected 5 replayed messages, got %d", len(messages))
+	}
+	for i, msg := range messages {
+		if want := fmt.Sprintf("Spill Page %d", i); string(msg.Key) != want {
+			t.Errorf("Message %d: expected key %q, got %q", i, want, string(msg.Key))
+		}
+	}
+	
+	stats := producer.GetStats()
+	if stats["spill_pending"].(int) != 0 {
+		t.Errorf("Expected spill_pending 0, got %v", stats["spill_pending"])
+	}
+}
+
+// TestSpillOnBufferFull verifies a full buffer spills instead of dropping
+func TestSpillOnBufferFull(t *testing.T) {
+	producer, _ := createSpillTestProducer(t, 2)
+	defer producer.spill.Close()
+	
+	// Don't start the producer so the buffer stays full
+	for i := 0; i < 3; i++ {
+		if err := producer.Produce(spillTestEdit(i)); err != nil {
+			t.Fatalf("Failed to produce edit %d: %v", i, err)
+		}
+	}
+	
+	if producer.spill.Len() != 1 {
+		t.Errorf("Expected 1 spilled message, got %d", producer.spill.Len())
+	}
+	if producer.droppedCount != 0 {
+		t.Errorf("Expected no drops, got %d", producer.droppedCount)
+	}
+}
@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/resilience"
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers set on messages re-queued to a retry topic. The original_* headers
+// record where the message was first consumed from so it can be traced back
+// (and replayed) once it reaches the DLQ.
+const (
+	HeaderRetryLevel        = "retry_level"
+	HeaderRetryNotBefore    = "retry_not_before" // Unix milliseconds
+	HeaderRetryError        = "retry_error"
+	HeaderOriginalTopic     = "original_topic"
+	HeaderOriginalPartition = "original_partition"
+	HeaderOriginalOffset    = "original_offset"
+)
+
+// RetryTopic returns the name of a consumer group's retry topic for the given
+// level (1-based). Retry topics are per group because every group consumes
+// the source topic independently: a message one group failed on must not be
+// redelivered to the others.
+func RetryTopic(sourceTopic, group string, level int) string {
+	return fmt.Sprintf("%s.%s.retry.%d", sourceTopic, group, level)
+}
+
+// retryMessage builds the message that re-queues msg to a retry topic. It
+// keeps the key and value, replaces any previous retry headers, and records
+// when the message becomes due.
+func retryMessage(msg kafka.Message, topic string, level int, delay time.Duration, procErr error, now time.Time) kafka.Message {
+	src := originalSource(msg)
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	for _, h := range msg.Headers {
+		if !isRetryHeader(h.Key) {
+			headers = append(headers, h)
+		}
+	}
+	headers = append(headers,
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(src.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(src.Partition))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(src.Offset, 10))},
+		kafka.Header{Key: HeaderRetryLevel, Value: []byte(strconv.Itoa(level))},
+		kafka.Header{Key: HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(now.Add(delay).UnixMilli(), 10))},
+		kafka.Header{Key: HeaderRetryError, Value: []byte(procErr.Error())},
+	)
+	return kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// originalSource returns msg with its topic, partition and offset replaced by
+// the original_* headers, if it came from a retry topic.
+func originalSource(msg kafka.Message) kafka.Message {
+	if topic := headerValue(msg.Headers, HeaderOriginalTopic); topic != "" {
+		msg.Topic = topic
+		if p, err := strconv.Atoi(headerValue(msg.Headers, HeaderOriginalPartition)); err == nil {
+			msg.Partition = p
+		}
+		if o, err := strconv.ParseInt(headerValue(msg.Headers, HeaderOriginalOffset), 10, 64); err == nil {
+			msg.Offset = o
+		}
+	}
+	return msg
+}
+
+// retryDue returns when a retry-topic message should be processed. Messages
+// without the header are due immediately.
+func retryDue(msg kafka.Message) time.Time {
+	ms, err := strconv.ParseInt(headerValue(msg.Headers, HeaderRetryNotBefore), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func isRetryHeader(key string) bool {
+	switch key {
+	case HeaderRetryLevel, HeaderRetryNotBefore, HeaderRetryError,
+		HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset:
+		return true
+	}
+	return false
+}
+
+func headerValue(headers []kafka.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// inPlaceRetryConfig maps a consumer's retry policy onto
+// resilience.RetryWithBackoff.
+func inPlaceRetryConfig(policy config.RetryPolicy, group string) resilience.RetryConfig {
+	return resilience.RetryConfig{
+		MaxAttempts:   policy.Attempts,
+		InitialDelay:  policy.InitialBackoff,
+		MaxDelay:      policy.MaxBackoff,
+		OperationName: group + " process",
+	}
+}
+
+// EnsureTopics creates any of the given topics that do not exist yet, with a
+// single partition and the broker's default replication factor. Retry and DLQ
+// traffic is low, and one partition keeps the delay ordering of a retry topic
+// intact.
+func EnsureTopics(ctx context.Context, brokers []string, topics ...string) error {
+	if len(brokers) == 0 {
+		return fmt.Errorf("no Kafka brokers provided")
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find Kafka controller: %w", err)
+	}
+	ctrl, err := kafka.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka controller: %w", err)
+	}
+	defer ctrl.Close()
+
+	configs := make([]kafka.TopicConfig, len(topics))
+	for i, topic := range topics {
+		configs[i] = kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: -1}
+	}
+	if err := ctrl.CreateTopics(configs...); err != nil {
+		return fmt.Errorf("failed to create topics: %w", err)
+	}
+	return nil
+}
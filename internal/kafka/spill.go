@@ -0,0 +1,555 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// spillSegmentBytes is the size at which a new segment file is started.
+	// Fully replayed segments are deleted, so this bounds how much replayed
+	// data can linger on disk.
+	spillSegmentBytes = 8 << 20
+
+	spillSegmentExt    = ".spill"
+	spillCursorFile    = "cursor.json"
+	spillRecordHeader  = 8 // uint32 length + uint32 CRC-32
+	spillMaxRecordSize = 16 << 20
+)
+
+// Spill fsync policies.
+const (
+	SpillFsyncAlways   = "always"
+	SpillFsyncInterval = "interval"
+	SpillFsyncNever    = "never"
+)
+
+var (
+	// ErrSpillFull is returned by Append when a message would take the
+	// spill queue past its size limit.
+	ErrSpillFull = errors.New("spill queue full")
+
+	errSpillCorrupt = errors.New("corrupt spill record")
+)
+
+// SpillQueue is a disk-backed FIFO of Kafka messages. It is a write-ahead log
+// split into segment files: records are appended to the newest segment and
+// read from a persisted cursor, and segments are deleted once every record in
+// them has been acknowledged.
+//
+// Each record is framed as a little-endian uint32 payload length, a CRC-32 of
+// the payload, and the JSON-encoded message. A record torn by a crash fails
+// its length or checksum check and is truncated when the queue is reopened.
+type SpillQueue struct {
+	dir           string
+	maxBytes      int64
+	fsync         string
+	fsyncInterval time.Duration
+
+	mu       sync.Mutex
+	segments []uint64 // segment IDs on disk, oldest first
+	sizes    map[uint64]int64
+	nextID   uint64
+	tail     *os.File // open segment receiving appends, nil until the first append
+	tailID   uint64
+	size     int64 // bytes across all segment files
+	pending  int   // records appended but not yet acknowledged
+	read     SpillCursor
+	dirty    bool // appended data not yet fsynced
+	lastSync time.Time
+}
+
+// SpillCursor marks a position in the spill queue. Read returns the cursor
+// just past the messages it returned; passing it to Ack consumes them.
+type SpillCursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+	count   int
+}
+
+// spillRecord is the on-disk form of a Kafka message.
+type spillRecord struct {
+	Key     []byte         `json:"key,omitempty"`
+	Value   []byte         `json:"value"`
+	Headers []kafka.Header `json:"headers,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// NewSpillQueue opens (or creates) the spill queue for topic under cfg.Dir.
+// Segments left by a previous run are scanned so their messages are replayed,
+// and a torn record at the end of a segment is truncated.
+func NewSpillQueue(cfg config.SpillConfig, topic string) (*SpillQueue, error) {
+	dir := filepath.Join(cfg.Dir, topic)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	q := &SpillQueue{
+		dir:           dir,
+		maxBytes:      cfg.MaxBytes,
+		fsync:         cfg.Fsync,
+		fsyncInterval: cfg.FsyncInterval,
+		sizes:         make(map[uint64]int64),
+		lastSync:      time.Now(),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load rebuilds the in-memory state from the segment files and cursor.
+func (q *SpillQueue) load() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spill directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, spillSegmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, spillSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, id)
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+
+	cursor, err := q.loadCursor()
+	if err != nil {
+		return err
+	}
+
+	// Segments before the cursor were fully replayed but not yet deleted.
+	for len(q.segments) > 0 && q.segments[0] < cursor.Segment {
+		if err := os.Remove(q.segmentPath(q.segments[0])); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove replayed spill segment: %w", err)
+		}
+		q.segments = q.segments[1:]
+	}
+	if len(q.segments) > 0 && q.segments[0] != cursor.Segment {
+		cursor = SpillCursor{Segment: q.segments[0]}
+	}
+	q.read = cursor
+	q.nextID = cursor.Segment + 1
+
+	for _, id := range q.segments {
+		from := int64(0)
+		if id == cursor.Segment {
+			from = cursor.Offset
+		}
+		size, count, err := q.scanSegment(id, from)
+		if err != nil {
+			return err
+		}
+		q.sizes[id] = size
+		q.size += size
+		q.pending += count
+		if id >= q.nextID {
+			q.nextID = id + 1
+		}
+	}
+	return nil
+}
+
+// scanSegment validates a segment, truncating it after the last intact
+// record, and counts the records at or after offset from.
+func (q *SpillQueue) scanSegment(id uint64, from int64) (int64, int, error) {
+	path := q.segmentPath(id)
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open spill segment: %w", err)
+	}
+	defer f.Close()
+
+	var offset int64
+	count := 0
+	for {
+		_, n, err := readSpillRecord(f)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				if err := f.Truncate(offset); err != nil {
+					return 0, 0, fmt.Errorf("failed to truncate spill segment: %w", err)
+				}
+			}
+			break
+		}
+		if offset >= from {
+			count++
+		}
+		offset += n
+	}
+	return offset, count, nil
+}
+
+// Append writes msgs to the end of the queue and returns how many were
+// written. It stops with ErrSpillFull at the first message that does not fit.
+func (q *SpillQueue) Append(msgs ...kafka.Message) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	written := 0
+	for _, msg := range msgs {
+		payload, err := json.Marshal(spillRecord{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: msg.Headers,
+			Time:    msg.Time,
+		})
+		if err != nil {
+			return written, fmt.Errorf("failed to marshal spill record: %w", err)
+		}
+		recLen := int64(spillRecordHeader + len(payload))
+		if q.size+recLen > q.maxBytes {
+			return written, ErrSpillFull
+		}
+
+		if q.tail == nil || (q.sizes[q.tailID] > 0 && q.sizes[q.tailID]+recLen > spillSegmentBytes) {
+			if err := q.rotateLocked(recLen); err != nil {
+				return written, err
+			}
+		}
+
+		buf := make([]byte, recLen)
+		binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+		binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+		copy(buf[spillRecordHeader:], payload)
+		if _, err := q.tail.Write(buf); err != nil {
+			// Drop any partial record so later appends stay readable.
+			_ = q.tail.Truncate(q.sizes[q.tailID])
+			return written, fmt.Errorf("failed to write spill record: %w", err)
+		}
+
+		q.sizes[q.tailID] += recLen
+		q.size += recLen
+		q.pending++
+		q.dirty = true
+		written++
+	}
+
+	switch q.fsync {
+	case SpillFsyncAlways:
+		if err := q.syncLocked(); err != nil {
+			return written, err
+		}
+	case SpillFsyncInterval:
+		q.syncIfDueLocked(time.Now())
+	}
+	return written, nil
+}
+
+// Read returns up to n messages from the head of the queue without consuming
+// them, along with the cursor to acknowledge once they have been delivered.
+func (q *SpillQueue) Read(n int) ([]kafka.Message, SpillCursor, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cursor := q.read
+	if q.pending == 0 || n <= 0 {
+		return nil, cursor, nil
+	}
+
+	var msgs []kafka.Message
+	for i := q.segmentIndex(cursor.Segment); i >= 0 && i < len(q.segments) && len(msgs) < n; i++ {
+		id := q.segments[i]
+		if id != cursor.Segment {
+			cursor = SpillCursor{Segment: id, count: cursor.count}
+		}
+		if cursor.Offset >= q.sizes[id] {
+			continue
+		}
+
+		f, err := os.Open(q.segmentPath(id))
+		if err != nil {
+			return nil, q.read, fmt.Errorf("failed to open spill segment: %w", err)
+		}
+		if _, err := f.Seek(cursor.Offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, q.read, fmt.Errorf("failed to seek spill segment: %w", err)
+		}
+		for len(msgs) < n && cursor.Offset < q.sizes[id] {
+			rec, size, err := readSpillRecord(f)
+			if err != nil {
+				f.Close()
+				return nil, q.read, fmt.Errorf("failed to read spill segment %d at offset %d: %w", id, cursor.Offset, err)
+			}
+			msgs = append(msgs, kafka.Message{
+				Key:     rec.Key,
+				Value:   rec.Value,
+				Headers: rec.Headers,
+				Time:    rec.Time,
+			})
+			cursor.Offset += size
+			cursor.count++
+		}
+		f.Close()
+	}
+	return msgs, cursor, nil
+}
+
+// Ack consumes every message up to cursor. Segments that have been fully
+// read are deleted, and once the queue is empty all files are removed.
+func (q *SpillQueue) Ack(cursor SpillCursor) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if cursor.count == 0 {
+		return nil
+	}
+	q.read = SpillCursor{Segment: cursor.Segment, Offset: cursor.Offset}
+	q.pending -= cursor.count
+	if q.pending < 0 {
+		q.pending = 0
+	}
+
+	if q.pending == 0 {
+		return q.resetLocked()
+	}
+
+	for len(q.segments) > 1 && q.segments[0] < q.read.Segment {
+		if err := q.removeHeadLocked(); err != nil {
+			return err
+		}
+	}
+	return q.saveCursorLocked()
+}
+
+// Len returns the number of messages waiting to be replayed.
+func (q *SpillQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
+
+// Size returns the bytes currently held on disk.
+func (q *SpillQueue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Sync flushes appended records to stable storage.
+func (q *SpillQueue) Sync() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.syncLocked()
+}
+
+// syncIfDue applies the interval fsync policy. It is called periodically so
+// a quiet queue does not keep unsynced records indefinitely.
+func (q *SpillQueue) syncIfDue(now time.Time) {
+	if q.fsync != SpillFsyncInterval {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.syncIfDueLocked(now)
+}
+
+// Close syncs and closes the open segment and records the read position.
+func (q *SpillQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.tail != nil {
+		if q.fsync != SpillFsyncNever {
+			if err := q.syncLocked(); err != nil {
+				return err
+			}
+		}
+		if err := q.tail.Close(); err != nil {
+			return fmt.Errorf("failed to close spill segment: %w", err)
+		}
+		q.tail = nil
+	}
+	if q.pending == 0 {
+		return nil
+	}
+	return q.saveCursorLocked()
+}
+
+func (q *SpillQueue) syncIfDueLocked(now time.Time) {
+	if q.dirty && now.Sub(q.lastSync) >= q.fsyncInterval {
+		// Best effort: a failed interval sync leaves the records dirty and
+		// it is retried on the next append or tick.
+		_ = q.syncLocked()
+	}
+}
+
+func (q *SpillQueue) syncLocked() error {
+	if q.tail == nil || !q.dirty {
+		return nil
+	}
+	if err := q.tail.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spill segment: %w", err)
+	}
+	q.dirty = false
+	q.lastSync = time.Now()
+	return nil
+}
+
+// rotateLocked closes the current segment and starts a new one. With no
+// segment open, as after a restart, the newest segment is reused if the next
+// record still fits in it.
+func (q *SpillQueue) rotateLocked(recLen int64) error {
+	if q.tail != nil {
+		if q.fsync != SpillFsyncNever {
+			if err := q.syncLocked(); err != nil {
+				return err
+			}
+		}
+		if err := q.tail.Close(); err != nil {
+			return fmt.Errorf("failed to close spill segment: %w", err)
+		}
+		q.tail = nil
+	} else if n := len(q.segments); n > 0 && q.sizes[q.segments[n-1]]+recLen <= spillSegmentBytes {
+		id := q.segments[n-1]
+		f, err := os.OpenFile(q.segmentPath(id), os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open spill segment: %w", err)
+		}
+		q.tail = f
+		q.tailID = id
+		return nil
+	}
+
+	id := q.nextID
+	q.nextID++
+	f, err := os.OpenFile(q.segmentPath(id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create spill segment: %w", err)
+	}
+	if len(q.segments) == 0 {
+		q.read = SpillCursor{Segment: id}
+	}
+	q.segments = append(q.segments, id)
+	q.sizes[id] = 0
+	q.tail = f
+	q.tailID = id
+	return nil
+}
+
+// removeHeadLocked deletes the oldest segment.
+func (q *SpillQueue) removeHeadLocked() error {
+	id := q.segments[0]
+	if err := os.Remove(q.segmentPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove replayed spill segment: %w", err)
+	}
+	q.size -= q.sizes[id]
+	delete(q.sizes, id)
+	q.segments = q.segments[1:]
+	return nil
+}
+
+// resetLocked drops every segment once the queue has been fully replayed.
+func (q *SpillQueue) resetLocked() error {
+	if q.tail != nil {
+		q.tail.Close()
+		q.tail = nil
+	}
+	for len(q.segments) > 0 {
+		if err := q.removeHeadLocked(); err != nil {
+			return err
+		}
+	}
+	q.size = 0
+	q.dirty = false
+	q.read = SpillCursor{Segment: q.nextID}
+	if err := os.Remove(filepath.Join(q.dir, spillCursorFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spill cursor: %w", err)
+	}
+	return nil
+}
+
+func (q *SpillQueue) loadCursor() (SpillCursor, error) {
+	data, err := os.ReadFile(filepath.Join(q.dir, spillCursorFile))
+	if os.IsNotExist(err) {
+		var cursor SpillCursor
+		if len(q.segments) > 0 {
+			cursor.Segment = q.segments[0]
+		}
+		return cursor, nil
+	}
+	if err != nil {
+		return SpillCursor{}, fmt.Errorf("failed to read spill cursor: %w", err)
+	}
+	var cursor SpillCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return SpillCursor{}, fmt.Errorf("failed to parse spill cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (q *SpillQueue) saveCursorLocked() error {
+	data, err := json.Marshal(q.read)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spill cursor: %w", err)
+	}
+	path := filepath.Join(q.dir, spillCursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write spill cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace spill cursor: %w", err)
+	}
+	return nil
+}
+
+func (q *SpillQueue) segmentPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, spillSegmentExt))
+}
+
+func (q *SpillQueue) segmentIndex(id uint64) int {
+	for i, s := range q.segments {
+		if s >= id {
+			return i
+		}
+	}
+	return -1
+}
+
+// readSpillRecord decodes one record and returns it with its framed size.
+// io.EOF means a clean end of segment; any other error is a torn or corrupt
+// record.
+func readSpillRecord(r io.Reader) (spillRecord, int64, error) {
+	var header [spillRecordHeader]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return spillRecord{}, 0, io.EOF
+		}
+		return spillRecord{}, 0, errSpillCorrupt
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if length == 0 || length > spillMaxRecordSize {
+		return spillRecord{}, 0, errSpillCorrupt
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return spillRecord{}, 0, errSpillCorrupt
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return spillRecord{}, 0, errSpillCorrupt
+	}
+	var rec spillRecord
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return spillRecord{}, 0, errSpillCorrupt
+	}
+	return rec, int64(spillRecordHeader) + int64(length), nil
+}
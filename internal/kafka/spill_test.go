@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/segmentio/kafka-go"
+)
+
+func testSpillConfig(t *testing.T) config.SpillConfig {
+	t.Helper()
+	return config.SpillConfig{
+		Enabled:       true,
+		Dir:           t.TempDir(),
+		MaxBytes:      1 << 20,
+		Fsync:         SpillFsyncAlways,
+		FsyncInterval: time.Second,
+	}
+}
+
+func spillTestMessage(i int) kafka.Message {
+	return kafka.Message{
+		Key:     []byte(fmt.Sprintf("Page %d", i)),
+		Value:   []byte(fmt.Sprintf(`{"id":%d}`, i)),
+		Headers: []kafka.Header{{Key: "wiki", Value: []byte("enwiki")}},
+	}
+}
+
+func appendSpillMessages(t *testing.T, q *SpillQueue, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if _, err := q.Append(spillTestMessage(i)); err != nil {
+			t.Fatalf("Append(%d) failed: %v", i, err)
+		}
+	}
+}
+
+// readSpillKeys reads and acknowledges the whole queue, n messages at a time.
+func readSpillKeys(t *testing.T, q *SpillQueue, n int) []string {
+	t.Helper()
+	var keys []string
+	for q.Len() > 0 {
+		msgs, cursor, err := q.Read(n)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if len(msgs) == 0 {
+			t.Fatalf("Read returned nothing with %d pending", q.Len())
+		}
+		for _, m := range msgs {
+			keys = append(keys, string(m.Key))
+		}
+		if err := q.Ack(cursor); err != nil {
+			t.Fatalf("Ack failed: %v", err)
+		}
+	}
+	return keys
+}
+
+func assertSpillKeys(t *testing.T, got []string, from, to int) {
+	t.Helper()
+	if len(got) != to-from {
+		t.Fatalf("Expected %d messages, got %d", to-from, len(got))
+	}
+	for i, key := range got {
+		if want := fmt.Sprintf("Page %d", from+i); key != want {
+			t.Errorf("Message %d: expected key %q, got %q", i, want, key)
+		}
+	}
+}
+
+func TestSpillQueueOrder(t *testing.T) {
+	q, err := NewSpillQueue(testSpillConfig(t), "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("NewSpillQueue failed: %v", err)
+	}
+	defer q.Close()
+
+	appendSpillMessages(t, q, 0, 10)
+	if q.Len() != 10 {
+		t.Fatalf("Expected 10 pending, got %d", q.Len())
+	}
+
+	// Reading without acknowledging does not consume
+	msgs, _, err := q.Read(3)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(msgs) != 3 || q.Len() != 10 {
+		t.Fatalf("Expected 3 messages and 10 pending, got %d and %d", len(msgs), q.Len())
+	}
+	if string(msgs[0].Headers[0].Value) != "enwiki" {
+		t.Errorf("Expected headers to round trip, got %+v", msgs[0].Headers)
+	}
+
+	assertSpillKeys(t, readSpillKeys(t, q, 3), 0, 10)
+
+	if q.Size() != 0 {
+		t.Errorf("Expected empty queue to hold no bytes, got %d", q.Size())
+	}
+	files, _ := filepath.Glob(filepath.Join(q.dir, "*"+spillSegmentExt))
+	if len(files) != 0 {
+		t.Errorf("Expected segments to be removed once replayed, found %v", files)
+	}
+
+	// The queue is reusable after draining
+	appendSpillMessages(t, q, 10, 12)
+	assertSpillKeys(t, readSpillKeys(t, q, 100), 10, 12)
+}
+
+func TestSpillQueueReopen(t *testing.T) {
+	cfg := testSpillConfig(t)
+	q, err := NewSpillQueue(cfg, "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("NewSpillQueue failed: %v", err)
+	}
+	appendSpillMessages(t, q, 0, 6)
+
+	// Consume the first two, then restart
+	_, cursor, err := q.Read(2)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := q.Ack(cursor); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	q, err = NewSpillQueue(cfg, "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer q.Close()
+	if q.Len() != 4 {
+		t.Fatalf("Expected 4 pending after reopen, got %d", q.Len())
+	}
+
+	appendSpillMessages(t, q, 6, 8)
+	assertSpillKeys(t, readSpillKeys(t, q, 3), 2, 8)
+}
+
+func TestSpillQueueMaxBytes(t *testing.T) {
+	cfg := testSpillConfig(t)
+	cfg.MaxBytes = 300
+	q, err := NewSpillQueue(cfg, "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("NewSpillQueue failed: %v", err)
+	}
+	defer q.Close()
+
+	batch := make([]kafka.Message, 10)
+	for i := range batch {
+		batch[i] = spillTestMessage(i)
+	}
+	n, err := q.Append(batch...)
+	if !errors.Is(err, ErrSpillFull) {
+		t.Fatalf("Expected ErrSpillFull, got %v", err)
+	}
+	if n == 0 || n >= len(batch) {
+		t.Fatalf("Expected a partial append, got %d of %d", n, len(batch))
+	}
+	if q.Size() > cfg.MaxBytes {
+		t.Errorf("Queue size %d exceeds limit %d", q.Size(), cfg.MaxBytes)
+	}
+
+	// Replaying frees space again
+	assertSpillKeys(t, readSpillKeys(t, q, 100), 0, n)
+	if _, err := q.Append(spillTestMessage(99)); err != nil {
+		t.Errorf("Expected append to succeed after draining, got %v", err)
+	}
+}
+
+func TestSpillQueueTornTail(t *testing.T) {
+	cfg := testSpillConfig(t)
+	q, err := NewSpillQueue(cfg, "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("NewSpillQueue failed: %v", err)
+	}
+	appendSpillMessages(t, q, 0, 3)
+	size := q.Size()
+	segment := q.segmentPath(q.tailID)
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Simulate a crash partway through writing a fourth record
+	f, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open segment: %v", err)
+	}
+	f.Write([]byte{0x40, 0, 0, 0, 1, 2, 3, 4, '{', '"'})
+	f.Close()
+
+	q, err = NewSpillQueue(cfg, "wikipedia.edits")
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer q.Close()
+	if q.Len() != 3 {
+		t.Fatalf("Expected torn record to be discarded, got %d pending", q.Len())
+	}
+	if q.Size() != size {
+		t.Errorf("Expected segment truncated to %d bytes, got %d", size, q.Size())
+	}
+
+	appendSpillMessages(t, q, 3, 5)
+	assertSpillKeys(t, readSpillKeys(t, q, 2), 0, 5)
+}
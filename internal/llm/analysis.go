@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 )
@@ -38,6 +39,7 @@ type Side struct {
 // Analysis is the LLM-generated narrative returned to the frontend.
 type Analysis struct {
 	PageTitle      string      `json:"page_title"`
+	Wiki           string      `json:"wiki,omitempty"`
 	Summary        string      `json:"summary"`         // 2-3 sentence conflict explanation
 	Sides          []Side      `json:"sides"`            // opposing sides with grouped editors
 	ContentArea    string      `json:"content_area"`     // topic area of disagreement
@@ -76,20 +78,20 @@ func NewAnalysisService(llm *Client, redisClient *redis.Client, cacheTTL time.Du
 // and if not cached, calls the LLM.
 // Reanalyze invalidates any cached analysis and runs a fresh LLM (or heuristic)
 // analysis. Use this for periodic re-analysis when new edits arrive.
-func (s *AnalysisService) Reanalyze(ctx context.Context, pageTitle string) (*Analysis, error) {
-	cacheKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+func (s *AnalysisService) Reanalyze(ctx context.Context, key models.PageKey) (*Analysis, error) {
+	cacheKey := fmt.Sprintf("editwar:analysis:%s", key)
 	_ = s.redis.Del(ctx, cacheKey).Err()
-	return s.Analyze(ctx, pageTitle)
+	return s.Analyze(ctx, key)
 }
 
 // FinalizeAnalysis runs a final analysis when an edit war becomes inactive.
 // The result is cached with a 7-day TTL (matching history retention) so that
 // the analysis survives well beyond the timeline data's 12h TTL.
-func (s *AnalysisService) FinalizeAnalysis(ctx context.Context, pageTitle string) (*Analysis, error) {
-	cacheKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+func (s *AnalysisService) FinalizeAnalysis(ctx context.Context, key models.PageKey) (*Analysis, error) {
+	cacheKey := fmt.Sprintf("editwar:analysis:%s", key)
 	_ = s.redis.Del(ctx, cacheKey).Err()
 
-	analysis, err := s.Analyze(ctx, pageTitle)
+	analysis, err := s.Analyze(ctx, key)
 	if err != nil {
 		return nil, err
 	}
@@ -105,9 +107,11 @@ func (s *AnalysisService) FinalizeAnalysis(ctx context.Context, pageTitle string
 	return analysis, nil
 }
 
-func (s *AnalysisService) Analyze(ctx context.Context, pageTitle string) (*Analysis, error) {
+func (s *AnalysisService) Analyze(ctx context.Context, key models.PageKey) (*Analysis, error) {
+	pageTitle := key.Title
+
 	// 1. Check cache
-	cacheKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+	cacheKey := fmt.Sprintf("editwar:analysis:%s", key)
 	if cached, err := s.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
 		var analysis Analysis
 		if err := json.Unmarshal([]byte(cached), &analysis); err == nil {
@@ -117,15 +121,16 @@ func (s *AnalysisService) Analyze(ctx context.Context, pageTitle string) (*Analy
 	}
 
 	// 2. Get timeline from Redis
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	raw, err := s.redis.LRange(ctx, timelineKey, 0, -1).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read timeline for %s: %w", pageTitle, err)
+		return nil, fmt.Errorf("failed to read timeline for %s: %w", key, err)
 	}
 
 	if len(raw) == 0 {
 		return &Analysis{
 			PageTitle:      pageTitle,
+			Wiki:           key.Wiki,
 			Summary:        "No edit timeline data available for this page. The edit war may have recently started or timeline tracking was not yet active when the conflict began.",
 			Sides:          []Side{},
 			ContentArea:    "unknown",
@@ -148,6 +153,7 @@ func (s *AnalysisService) Analyze(ctx context.Context, pageTitle string) (*Analy
 	if len(entries) == 0 {
 		return &Analysis{
 			PageTitle:      pageTitle,
+			Wiki:           key.Wiki,
 			Summary:        "Timeline entries could not be parsed.",
 			Sides:          []Side{},
 			ContentArea:    "unknown",
@@ -162,13 +168,14 @@ func (s *AnalysisService) Analyze(ctx context.Context, pageTitle string) (*Analy
 	// 3. If LLM is not enabled, return a heuristic-only summary
 	if !s.llm.Enabled() {
 		analysis := s.heuristicAnalysis(pageTitle, entries)
+		analysis.Wiki = key.Wiki
 		s.persistForDigest(ctx, analysis)
 		return analysis, nil
 	}
 
 	// 4. Fetch diffs from Wikipedia API (lazy — nothing stored in Redis).
 	//    We only need a server URL from any entry to know which wiki to call.
-	diffMap := s.fetchDiffs(ctx, pageTitle, entries)
+	diffMap := s.fetchDiffs(ctx, key, entries)
 
 	// 5. Build prompt
 	systemPrompt, userPrompt := s.buildPrompt(pageTitle, entries, diffMap)
@@ -176,14 +183,16 @@ func (s *AnalysisService) Analyze(ctx context.Context, pageTitle string) (*Analy
 	// 6. Call LLM
 	response, err := s.llm.Complete(ctx, systemPrompt, userPrompt)
 	if err != nil {
-		s.logger.Warn().Err(err).Str("page", pageTitle).Msg("LLM call failed, falling back to heuristic")
+		s.logger.Warn().Err(err).Str("page", key.String()).Msg("LLM call failed, falling back to heuristic")
 		analysis := s.heuristicAnalysis(pageTitle, entries)
+		analysis.Wiki = key.Wiki
 		s.persistForDigest(ctx, analysis)
 		return analysis, nil
 	}
 
 	// 7. Parse LLM response
 	analysis := s.parseLLMResponse(pageTitle, response, len(entries))
+	analysis.Wiki = key.Wiki
 
 	// 8. Cache it
 	if data, err := json.Marshal(analysis); err == nil {
@@ -210,15 +219,16 @@ func (s *AnalysisService) persistForDigest(ctx context.Context, a *Analysis) {
 		return
 	}
 	dateKey := fmt.Sprintf("digest:war_analyses:%s", time.Now().UTC().Format("2006-01-02"))
-	// Use page title as the member so later analyses for the same page
+	// Use the page key as the member so later analyses for the same page
 	// on the same day simply overwrite the earlier one (keeping freshest).
+	member := models.NewPageKey(a.Wiki, a.PageTitle).String()
 	pipe := s.redis.Pipeline()
-	// Store the JSON payload in a companion hash keyed by page title.
+	// Store the JSON payload in a companion hash keyed by page key.
 	hashKey := dateKey + ":data"
-	pipe.HSet(ctx, hashKey, a.PageTitle, string(data))
+	pipe.HSet(ctx, hashKey, member, string(data))
 	pipe.Expire(ctx, hashKey, 8*24*time.Hour)
 	// Sorted set for ranking (score = edit count).
-	pipe.ZAdd(ctx, dateKey, redis.Z{Score: float64(a.EditCount), Member: a.PageTitle})
+	pipe.ZAdd(ctx, dateKey, redis.Z{Score: float64(a.EditCount), Member: member})
 	pipe.Expire(ctx, dateKey, 8*24*time.Hour)
 	if _, pErr := pipe.Exec(ctx); pErr != nil {
 		s.logger.Warn().Err(pErr).Str("page", a.PageTitle).Msg("Failed to persist analysis for digest archive")
@@ -228,7 +238,7 @@ func (s *AnalysisService) persistForDigest(ctx context.Context, a *Analysis) {
 // fetchDiffs retrieves diffs from the Wikipedia API for timeline entries that
 // have a revision ID and server URL.  Returns a map of revisionID → plain-text
 // diff.  Best-effort: entries without diffs are simply omitted from the map.
-func (s *AnalysisService) fetchDiffs(ctx context.Context, pageTitle string, entries []EditTimelineEntry) map[int64]string {
+func (s *AnalysisService) fetchDiffs(ctx context.Context, key models.PageKey, entries []EditTimelineEntry) map[int64]string {
 	diffMap := make(map[int64]string)
 
 	// Determine the wiki's server URL from the first entry that has one.
@@ -242,13 +252,18 @@ func (s *AnalysisService) fetchDiffs(ctx context.Context, pageTitle string, entr
 
 	// Fallback: look up the server URL from Redis (set by the edit war detector).
 	if serverURL == "" {
-		urlKey := fmt.Sprintf("editwar:serverurl:%s", pageTitle)
+		urlKey := fmt.Sprintf("editwar:serverurl:%s", key)
 		if val, err := s.redis.Get(ctx, urlKey).Result(); err == nil && val != "" {
 			serverURL = val
 			s.logger.Info().Str("server_url", serverURL).Msg("Using server_url from Redis fallback")
 		}
 	}
 
+	// The page key's wiki determines the server for any recognised wiki.
+	if serverURL == "" {
+		serverURL = models.ServerURLForWiki(key.Wiki)
+	}
+
 	// Last resort: default to English Wikipedia.
 	if serverURL == "" {
 		serverURL = "https://en.wikipedia.org"
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
@@ -22,9 +23,9 @@ func setupTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
 }
 
 // seedTimeline pushes edit timeline entries into a miniredis instance.
-func seedTimeline(t *testing.T, client *redis.Client, pageTitle string, entries []EditTimelineEntry) {
+func seedTimeline(t *testing.T, client *redis.Client, page models.PageKey, entries []EditTimelineEntry) {
 	ctx := context.Background()
-	key := "editwar:timeline:" + pageTitle
+	key := "editwar:timeline:" + page.String()
 	for _, e := range entries {
 		data, _ := json.Marshal(e)
 		client.RPush(ctx, key, string(data))
@@ -53,9 +54,9 @@ func TestAnalysisService_HeuristicFallback_ClearEditWar(t *testing.T) {
 		{User: "Bob", Comment: "Revert: CNN source doesn't support the claim made", ByteChange: -510, Timestamp: time.Now().Add(-3 * time.Minute).Unix()},
 	}
 
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
-	analysis, err := svc.Analyze(context.Background(), pageTitle)
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	require.NotNil(t, analysis)
 
@@ -95,7 +96,7 @@ func TestAnalysisService_EmptyTimeline(t *testing.T) {
 	llmClient := NewClient(Config{Provider: ProviderOpenAI}, zerolog.Nop())
 	svc := NewAnalysisService(llmClient, redisClient, 5*time.Minute, zerolog.Nop())
 
-	analysis, err := svc.Analyze(context.Background(), "Nonexistent_Page")
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", "Nonexistent_Page"))
 	require.NoError(t, err)
 	assert.Equal(t, 0, analysis.EditCount)
 	assert.Contains(t, analysis.Summary, "No edit timeline data")
@@ -113,21 +114,21 @@ func TestAnalysisService_Caching(t *testing.T) {
 		{User: "Ed1", Comment: "Added info", ByteChange: 300, Timestamp: time.Now().Unix()},
 		{User: "Ed2", Comment: "Reverted", ByteChange: -290, Timestamp: time.Now().Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
 	// First call — should not be cached
-	a1, err := svc.Analyze(context.Background(), pageTitle)
+	a1, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	assert.False(t, a1.CacheHit)
 
 	// Manually seed cache (as heuristic mode doesn't cache by default since
 	// it's instant, but the LLM path does)
-	cacheKey := "editwar:analysis:" + pageTitle
+	cacheKey := "editwar:analysis:enwiki:" + pageTitle
 	data, _ := json.Marshal(a1)
 	redisClient.Set(context.Background(), cacheKey, string(data), 5*time.Minute)
 
 	// Second call — should be cache hit
-	a2, err := svc.Analyze(context.Background(), pageTitle)
+	a2, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	assert.True(t, a2.CacheHit)
 	assert.Equal(t, a1.Summary, a2.Summary)
@@ -187,9 +188,9 @@ func TestAnalysisService_LLMIntegration_PoliticalConflict(t *testing.T) {
 		{User: "Editor_B", Comment: "POV pushing, must present both sides per WP:NPOV", ByteChange: -780, Timestamp: time.Now().Add(-7 * time.Minute).Unix()},
 		{User: "Editor_A", Comment: "Not POV - UN is default reliable source per WP:RS", ByteChange: 830, Timestamp: time.Now().Add(-6 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
-	analysis, err := svc.Analyze(context.Background(), pageTitle)
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 
 	// Verify the LLM response was parsed correctly
@@ -245,9 +246,9 @@ func TestAnalysisService_LLMIntegration_BiographyDispute(t *testing.T) {
 		{User: "BioEditor", Comment: "Restored - multiple RS covered this, meets WP:WEIGHT", ByteChange: 1180, Timestamp: time.Now().Add(-12 * time.Minute).Unix()},
 		{User: "BLPPatrol", Comment: "Reverted. Take it to talk page. BLP violation.", ByteChange: -1170, Timestamp: time.Now().Add(-11 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
-	analysis, err := svc.Analyze(context.Background(), pageTitle)
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 
 	assert.Contains(t, analysis.Summary, "allegations")
@@ -281,10 +282,10 @@ func TestAnalysisService_LLMFailure_FallsBackToHeuristic(t *testing.T) {
 		{User: "UserY", Comment: "Reverting vandalism", ByteChange: -280, Timestamp: time.Now().Unix()},
 		{User: "UserX", Comment: "Not vandalism, legitimate edit", ByteChange: 290, Timestamp: time.Now().Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
 	// Should not error — should fall back to heuristic
-	analysis, err := svc.Analyze(context.Background(), pageTitle)
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	assert.Equal(t, 3, analysis.EditCount)
 	assert.Contains(t, analysis.Summary, "edit war")
@@ -458,9 +459,9 @@ func TestAccuracy_VandalismVsLegitimateEdit(t *testing.T) {
 		{User: "PageGuardian", Comment: "Rv again - take to talk page first", ByteChange: -890, Timestamp: time.Now().Add(-17 * time.Minute).Unix()},
 		{User: "PolicyCritic", Comment: "Added with WSJ source too, per WP:BRD I am discussing", ByteChange: 920, Timestamp: time.Now().Add(-15 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, "Barack_Obama", entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", "Barack_Obama"), entries)
 
-	analysis, err := svc.Analyze(context.Background(), "Barack_Obama")
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", "Barack_Obama"))
 	require.NoError(t, err)
 
 	// Structural correctness
@@ -515,9 +516,9 @@ func TestAccuracy_NoComments(t *testing.T) {
 		{User: "IP_Editor_1", Comment: "", ByteChange: 410, Timestamp: time.Now().Add(-3 * time.Minute).Unix()},
 		{User: "IP_Editor_2", Comment: "", ByteChange: -395, Timestamp: time.Now().Add(-2 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, "Mystery_Page", entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", "Mystery_Page"), entries)
 
-	analysis, err := svc.Analyze(context.Background(), "Mystery_Page")
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", "Mystery_Page"))
 	require.NoError(t, err)
 
 	assert.Equal(t, 4, analysis.EditCount)
@@ -565,9 +566,9 @@ func TestAccuracy_MultiPartyConflict(t *testing.T) {
 		{User: "CompanyFan", Comment: "Reverted all controversy additions", ByteChange: -890, Timestamp: time.Now().Add(-6 * time.Minute).Unix()},
 		{User: "DataNerd", Comment: "Re-added BBB data with FTC source", ByteChange: 520, Timestamp: time.Now().Add(-5 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, "Big_Tech_Company", entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", "Big_Tech_Company"), entries)
 
-	analysis, err := svc.Analyze(context.Background(), "Big_Tech_Company")
+	analysis, err := svc.Analyze(context.Background(), models.NewPageKey("enwiki", "Big_Tech_Company"))
 	require.NoError(t, err)
 
 	assert.Equal(t, 6, analysis.EditCount)
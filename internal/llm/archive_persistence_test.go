@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,10 +34,10 @@ func TestPersistForDigest_WritesToArchive(t *testing.T) {
 		{User: "Alice", Comment: "Restored", ByteChange: 490, Timestamp: time.Now().Add(-3 * time.Minute).Unix()},
 		{User: "Bob", Comment: "Reverted again", ByteChange: -495, Timestamp: time.Now().Add(-2 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
 	// Call Analyze — should produce heuristic analysis AND persist to archive.
-	analysis, err := svc.Analyze(ctx, pageTitle)
+	analysis, err := svc.Analyze(ctx, models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	require.NotNil(t, analysis)
 	assert.NotEmpty(t, analysis.Summary)
@@ -45,7 +46,7 @@ func TestPersistForDigest_WritesToArchive(t *testing.T) {
 	//    ephemeral cache (only the LLM path does, at step 8). This is fine:
 	//    the archive is the durable store, and on the next call Analyze will
 	//    regenerate and cache it.
-	cacheKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+	cacheKey := fmt.Sprintf("editwar:analysis:enwiki:%s", pageTitle)
 	_, err = redisClient.Get(ctx, cacheKey).Result()
 	// Expect redis.Nil — heuristic path skips ephemeral cache.
 	assert.Error(t, err, "heuristic path should not write ephemeral cache")
@@ -55,7 +56,7 @@ func TestPersistForDigest_WritesToArchive(t *testing.T) {
 	hashKey := dateKey + ":data"
 
 	// Hash should contain the page's analysis JSON.
-	raw, err := redisClient.HGet(ctx, hashKey, pageTitle).Result()
+	raw, err := redisClient.HGet(ctx, hashKey, "enwiki:"+pageTitle).Result()
 	assert.NoError(t, err, "archive hash should contain the analysis")
 	assert.NotEmpty(t, raw)
 
@@ -67,7 +68,7 @@ func TestPersistForDigest_WritesToArchive(t *testing.T) {
 	assert.Equal(t, analysis.EditCount, archived.EditCount)
 
 	// Sorted set should contain the page with score = edit count.
-	score, err := redisClient.ZScore(ctx, dateKey, pageTitle).Result()
+	score, err := redisClient.ZScore(ctx, dateKey, "enwiki:"+pageTitle).Result()
 	assert.NoError(t, err)
 	assert.Equal(t, float64(analysis.EditCount), score)
 
@@ -97,15 +98,15 @@ func TestPersistForDigest_FinalizeAlsoWritesToArchive(t *testing.T) {
 		{User: "Y", Comment: "Reverted - unsourced", ByteChange: -290, Timestamp: time.Now().Add(-9 * time.Minute).Unix()},
 		{User: "X", Comment: "Added source", ByteChange: 310, Timestamp: time.Now().Add(-8 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries)
 
 	// FinalizeAnalysis should also persist to archive.
-	analysis, err := svc.FinalizeAnalysis(ctx, pageTitle)
+	analysis, err := svc.FinalizeAnalysis(ctx, models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	require.NotNil(t, analysis)
 
 	// The finalized analysis should have a 7-day TTL on the ephemeral cache.
-	cacheKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+	cacheKey := fmt.Sprintf("editwar:analysis:enwiki:%s", pageTitle)
 	ttl, err := redisClient.TTL(ctx, cacheKey).Result()
 	require.NoError(t, err)
 	assert.Greater(t, ttl, 6*24*time.Hour, "finalized cache TTL should be ~7 days")
@@ -114,7 +115,7 @@ func TestPersistForDigest_FinalizeAlsoWritesToArchive(t *testing.T) {
 	dateKey := fmt.Sprintf("digest:war_analyses:%s", time.Now().UTC().Format("2006-01-02"))
 	hashKey := dateKey + ":data"
 
-	raw, err := redisClient.HGet(ctx, hashKey, pageTitle).Result()
+	raw, err := redisClient.HGet(ctx, hashKey, "enwiki:"+pageTitle).Result()
 	assert.NoError(t, err)
 	assert.NotEmpty(t, raw)
 
@@ -136,7 +137,7 @@ func TestPersistForDigest_SkipsEmptyAnalysis(t *testing.T) {
 	ctx := context.Background()
 
 	// Analyze a page with no timeline → returns "no data available" analysis
-	analysis, err := svc.Analyze(ctx, "Empty_Page")
+	analysis, err := svc.Analyze(ctx, models.NewPageKey("enwiki", "Empty_Page"))
 	require.NoError(t, err)
 	require.NotNil(t, analysis)
 	assert.Equal(t, 0, analysis.EditCount)
@@ -151,7 +152,7 @@ func TestPersistForDigest_SkipsEmptyAnalysis(t *testing.T) {
 
 	// For empty timeline pages, the archive may or may not be written depending
 	// on summary content. The key check: no panic, no error.
-	_, _ = redisClient.HGet(ctx, hashKey, "Empty_Page").Result()
+	_, _ = redisClient.HGet(ctx, hashKey, "enwiki:Empty_Page").Result()
 	// No assertion on presence — the behavior is defined by summary content.
 }
 
@@ -170,14 +171,14 @@ func TestPersistForDigest_OverwritesSameDaySamePage(t *testing.T) {
 		{User: "A", Comment: "First version", ByteChange: 100, Timestamp: time.Now().Add(-10 * time.Minute).Unix()},
 		{User: "B", Comment: "Reverted", ByteChange: -90, Timestamp: time.Now().Add(-9 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries1)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries1)
 
-	a1, err := svc.Analyze(ctx, pageTitle)
+	a1, err := svc.Analyze(ctx, models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 
 	// Clear cache and timeline, seed new data for re-analysis.
-	redisClient.Del(ctx, "editwar:analysis:"+pageTitle)
-	redisClient.Del(ctx, "editwar:timeline:"+pageTitle)
+	redisClient.Del(ctx, "editwar:analysis:enwiki:"+pageTitle)
+	redisClient.Del(ctx, "editwar:timeline:enwiki:"+pageTitle)
 
 	entries2 := []EditTimelineEntry{
 		{User: "A", Comment: "First version", ByteChange: 100, Timestamp: time.Now().Add(-10 * time.Minute).Unix()},
@@ -186,9 +187,9 @@ func TestPersistForDigest_OverwritesSameDaySamePage(t *testing.T) {
 		{User: "B", Comment: "Reverted yet again", ByteChange: -92, Timestamp: time.Now().Add(-7 * time.Minute).Unix()},
 		{User: "C", Comment: "New contributor weighs in", ByteChange: 200, Timestamp: time.Now().Add(-6 * time.Minute).Unix()},
 	}
-	seedTimeline(t, redisClient, pageTitle, entries2)
+	seedTimeline(t, redisClient, models.NewPageKey("enwiki", pageTitle), entries2)
 
-	a2, err := svc.Analyze(ctx, pageTitle)
+	a2, err := svc.Analyze(ctx, models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 
 	// Second analysis should have more edits.
@@ -198,7 +199,7 @@ func TestPersistForDigest_OverwritesSameDaySamePage(t *testing.T) {
 	dateKey := fmt.Sprintf("digest:war_analyses:%s", time.Now().UTC().Format("2006-01-02"))
 	hashKey := dateKey + ":data"
 
-	raw, err := redisClient.HGet(ctx, hashKey, pageTitle).Result()
+	raw, err := redisClient.HGet(ctx, hashKey, "enwiki:"+pageTitle).Result()
 	require.NoError(t, err)
 
 	var archived Analysis
@@ -208,7 +209,7 @@ func TestPersistForDigest_OverwritesSameDaySamePage(t *testing.T) {
 	assert.Equal(t, a2.Summary, archived.Summary)
 
 	// Score in sorted set should reflect latest edit count.
-	score, err := redisClient.ZScore(ctx, dateKey, pageTitle).Result()
+	score, err := redisClient.ZScore(ctx, dateKey, "enwiki:"+pageTitle).Result()
 	assert.NoError(t, err)
 	assert.Equal(t, float64(a2.EditCount), score)
 }
@@ -255,11 +256,11 @@ func TestPersistForDigest_MultiplePages(t *testing.T) {
 	}
 
 	for _, p := range pages {
-		seedTimeline(t, redisClient, p.title, p.entries)
-		_, err := svc.Analyze(ctx, p.title)
+		seedTimeline(t, redisClient, models.NewPageKey("enwiki", p.title), p.entries)
+		_, err := svc.Analyze(ctx, models.NewPageKey("enwiki", p.title))
 		require.NoError(t, err)
 		// Clear ephemeral cache between pages to force fresh analysis.
-		redisClient.Del(ctx, "editwar:analysis:"+p.title)
+		redisClient.Del(ctx, "editwar:analysis:enwiki:"+p.title)
 	}
 
 	// Verify all three are in today's archive.
@@ -271,7 +272,7 @@ func TestPersistForDigest_MultiplePages(t *testing.T) {
 	assert.Len(t, members, 3, "archive sorted set should have 3 pages")
 
 	for _, p := range pages {
-		raw, err := redisClient.HGet(ctx, hashKey, p.title).Result()
+		raw, err := redisClient.HGet(ctx, hashKey, "enwiki:"+p.title).Result()
 		assert.NoError(t, err, "archive should have %s", p.title)
 		assert.NotEmpty(t, raw, "archive data for %s should not be empty", p.title)
 
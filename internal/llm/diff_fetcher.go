@@ -493,6 +493,77 @@ func (df *DiffFetcher) fetchRevisionContent(ctx context.Context, serverURL strin
 	return "", fmt.Errorf("no content in response")
 }
 
+// ─── Revision history ───────────────────────────────────────────────────────
+
+// RevisionMeta describes one revision of a page without its content: enough
+// to tell whether it restored an earlier revision and how it was tagged.
+type RevisionMeta struct {
+	RevisionID int64    `json:"revid"`
+	ParentID   int64    `json:"parentid"`
+	User       string   `json:"user"`
+	SHA1       string   `json:"sha1"` // content hash; empty if the revision is hidden
+	Tags       []string `json:"tags"` // change tags, e.g. mw-undo, mw-rollback
+}
+
+// FetchRevisionHistory returns up to limit revisions of a page, newest first,
+// starting at revision startID and going back in time.
+func (df *DiffFetcher) FetchRevisionHistory(ctx context.Context, serverURL, title string, startID int64, limit int) ([]RevisionMeta, error) {
+	apiURL := strings.TrimRight(serverURL, "/") + "/w/api.php"
+	params := url.Values{
+		"action":    {"query"},
+		"prop":      {"revisions"},
+		"titles":    {title},
+		"rvprop":    {"ids|user|sha1|tags"},
+		"rvstartid": {strconv.FormatInt(startID, 10)},
+		"rvdir":     {"older"},
+		"rvlimit":   {strconv.Itoa(limit)},
+		"format":    {"json"},
+	}
+
+	reqURL := apiURL + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build revision history request: %w", err)
+	}
+	req.Header.Set("User-Agent", "WikiSurge/1.0 (edit war analysis; contact: wikisurge@example.com)")
+
+	resp, err := df.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revision history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision history: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("revision history returned http %d", resp.StatusCode)
+	}
+
+	var hResp struct {
+		Query struct {
+			Pages map[string]struct {
+				Revisions []RevisionMeta `json:"revisions"`
+			} `json:"pages"`
+		} `json:"query"`
+		Error *struct {
+			Code string `json:"code"`
+			Info string `json:"info"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &hResp); err != nil {
+		return nil, fmt.Errorf("failed to decode revision history: %w", err)
+	}
+	if hResp.Error != nil {
+		return nil, fmt.Errorf("revision history api: %s", hResp.Error.Info)
+	}
+	for _, page := range hResp.Query.Pages {
+		return page.Revisions, nil
+	}
+	return nil, nil
+}
+
 // simpleDiff produces a concise line-level diff between two texts, keeping
 // only changed lines with ± markers.  This is intentionally simple — we don't
 // need a perfect Myers diff, just enough signal for the LLM.
@@ -243,3 +243,40 @@ func TestDiffFetcher_FetchDiffs_TruncatesLongDiff(t *testing.T) {
 	// Should be truncated to MaxDiffChars + "…" (3 bytes in UTF-8).
 	assert.LessOrEqual(t, len(results[0].DiffText), MaxDiffChars+10)
 }
+
+// ─── Revision history ───────────────────────────────────────────────────────
+
+func TestDiffFetcher_FetchRevisionHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "Paris", q.Get("titles"))
+		assert.Equal(t, "300", q.Get("rvstartid"))
+		assert.Equal(t, "3", q.Get("rvlimit"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"query":{"pages":{"42":{"title":"Paris","revisions":[
+			{"revid":300,"parentid":200,"user":"Bob","sha1":"aaa","tags":["mw-undo"]},
+			{"revid":200,"parentid":100,"user":"Alice","sha1":"bbb","tags":[]},
+			{"revid":100,"parentid":0,"user":"Bob","sha1":"aaa","tags":[]}]}}}}`)
+	}))
+	defer server.Close()
+
+	df := NewDiffFetcher(zerolog.Nop())
+	revs, err := df.FetchRevisionHistory(context.Background(), server.URL, "Paris", 300, 3)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	assert.Equal(t, int64(300), revs[0].RevisionID)
+	assert.Equal(t, []string{"mw-undo"}, revs[0].Tags)
+	assert.Equal(t, revs[0].SHA1, revs[2].SHA1)
+	assert.Equal(t, "Alice", revs[1].User)
+}
+
+func TestDiffFetcher_FetchRevisionHistory_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error":{"code":"badid_startid","info":"No revision was found for parameter \"rvstartid\"."}}`)
+	}))
+	defer server.Close()
+
+	df := NewDiffFetcher(zerolog.Nop())
+	_, err := df.FetchRevisionHistory(context.Background(), server.URL, "Paris", 1, 3)
+	assert.ErrorContains(t, err, "rvstartid")
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
@@ -85,7 +86,7 @@ func TestLive_PoliticalEditWar(t *testing.T) {
 	svc := NewAnalysisService(client, rdb, 5*time.Minute, zerolog.New(zerolog.NewTestWriter(t)))
 
 	ctx := context.Background()
-	page := "Israel-Palestine_conflict"
+	page := models.NewPageKey("enwiki", "Israel-Palestine_conflict")
 	key := fmt.Sprintf("editwar:timeline:%s", page)
 
 	edits := []EditTimelineEntry{
@@ -140,7 +141,7 @@ func TestLive_USElection(t *testing.T) {
 	svc := NewAnalysisService(client, rdb, 5*time.Minute, zerolog.New(zerolog.NewTestWriter(t)))
 
 	ctx := context.Background()
-	page := "2024_United_States_presidential_election"
+	page := models.NewPageKey("enwiki", "2024_United_States_presidential_election")
 	key := fmt.Sprintf("editwar:timeline:%s", page)
 
 	edits := []EditTimelineEntry{
@@ -190,7 +191,7 @@ func TestLive_BLPDispute(t *testing.T) {
 	svc := NewAnalysisService(client, rdb, 5*time.Minute, zerolog.New(zerolog.NewTestWriter(t)))
 
 	ctx := context.Background()
-	page := "Elon_Musk"
+	page := models.NewPageKey("enwiki", "Elon_Musk")
 	key := fmt.Sprintf("editwar:timeline:%s", page)
 
 	edits := []EditTimelineEntry{
@@ -234,7 +235,7 @@ func TestLive_VandalismVsLegit(t *testing.T) {
 	svc := NewAnalysisService(client, rdb, 5*time.Minute, zerolog.New(zerolog.NewTestWriter(t)))
 
 	ctx := context.Background()
-	page := "Climate_change"
+	page := models.NewPageKey("enwiki", "Climate_change")
 	key := fmt.Sprintf("editwar:timeline:%s", page)
 
 	edits := []EditTimelineEntry{
@@ -280,7 +281,7 @@ func TestLive_Caching(t *testing.T) {
 	svc := NewAnalysisService(client, rdb, 5*time.Minute, zerolog.New(zerolog.NewTestWriter(t)))
 
 	ctx := context.Background()
-	page := "Test_Caching_Page"
+	page := models.NewPageKey("enwiki", "Test_Caching_Page")
 	key := fmt.Sprintf("editwar:timeline:%s", page)
 
 	edits := []EditTimelineEntry{
@@ -24,6 +24,14 @@ var (
 		[]string{"reason"},
 	)
 
+	LogEventsIngestedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_events_ingested_total",
+			Help: "MediaWiki log events (protections, blocks, ...) forwarded to Kafka",
+		},
+		[]string{"log_type"},
+	)
+
 	KafkaProduceErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "kafka_produce_errors_total",
@@ -64,6 +72,30 @@ var (
 		[]string{"type"},
 	)
 
+	KafkaSpillMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_spill_messages_total",
+			Help: "Messages written to the producer's disk spill queue, by reason",
+		},
+		[]string{"topic", "reason"},
+	)
+
+	KafkaSpillReplayedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_spill_replayed_total",
+			Help: "Spilled messages successfully replayed to Kafka",
+		},
+		[]string{"topic"},
+	)
+
+	KafkaSpillBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_spill_bytes",
+			Help: "Bytes currently held in the producer's disk spill queue",
+		},
+		[]string{"topic"},
+	)
+
 	EditsProcessedTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "edits_processed_total",
@@ -80,6 +112,114 @@ var (
 		[]string{"consumer"},
 	)
 
+	EditsDedupCheckedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "edits_dedup_checked_total",
+			Help: "Edits checked against the revision dedup window, per consumer",
+		},
+		[]string{"consumer"},
+	)
+
+	EditsDuplicateTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "edits_duplicate_total",
+			Help: "Edits skipped because the consumer had already processed the revision",
+		},
+		[]string{"consumer"},
+	)
+
+	LateEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "late_events_total",
+			Help: "Edits that arrived further behind a processor's watermark than the allowed lateness",
+		},
+		[]string{"consumer", "action"},
+	)
+
+	EventTimeWatermark = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "event_time_watermark_seconds",
+			Help: "Current event-time watermark of each processor, as a Unix timestamp",
+		},
+		[]string{"consumer"},
+	)
+
+	AggregateAnomaliesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aggregate_anomalies_total",
+			Help: "Wiki surge and stream drop alerts raised by the aggregate anomaly detector",
+		},
+		[]string{"type", "scope"},
+	)
+
+	WikidataLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wikidata_lookups_total",
+			Help: "Page to Wikidata ID lookups by result (cached, resolved, not_found, error)",
+		},
+		[]string{"result"},
+	)
+
+	VandalismAlertsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vandalism_alerts_total",
+			Help: "Vandalism alerts raised, by the reasons that fired",
+		},
+		[]string{"reason"},
+	)
+
+	RevertsDetectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "reverts_detected_total",
+			Help: "Reverts recognised on hot pages, by how they were recognised (comment, tag, hash)",
+		},
+		[]string{"method"},
+	)
+
+	ThreeRevertViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "three_revert_violations_total",
+			Help: "Editors caught reverting one page more often than the three-revert rule allows, by wiki",
+		},
+		[]string{"wiki"},
+	)
+
+	CoordinatedGroupsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "coordinated_groups_detected_total",
+			Help: "Groups of accounts flagged for coordinated editing of hot pages",
+		},
+	)
+
+	BotRunsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_runs_total",
+			Help: "Finished runs of rapid edits by one account, by whether the account has the bot flag (flagged, unflagged)",
+		},
+		[]string{"account"},
+	)
+
+	SuspectedBotsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "suspected_bots_total",
+			Help: "Runs by accounts without the bot flag whose cadence scored them as a suspected bot",
+		},
+	)
+
+	RuleAlertsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rule_alerts_total",
+			Help: "Personal alerts sent to users whose alert rules an edit met",
+		},
+	)
+
+	StoriesDetectedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "stories_detected_total",
+			Help: "Topics that spiked on enough wikis to become a cross-language story",
+		},
+	)
+
 	DocsIndexedTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "docs_indexed_total",
@@ -168,6 +308,38 @@ var (
 		[]string{},
 	)
 
+	SSECheckpointWritesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sse_checkpoint_writes_total",
+			Help: "SSE resume checkpoint writes by status",
+		},
+		[]string{"status"},
+	)
+
+	SSECheckpointRestoresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sse_checkpoint_restores_total",
+			Help: "SSE resume checkpoint loads by result (resumed, expired, missing, error)",
+		},
+		[]string{"result"},
+	)
+
+	SSECaptureEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sse_capture_events_total",
+			Help: "Raw SSE payloads written to the capture archive by status",
+		},
+		[]string{"status"},
+	)
+
+	SSECaptureFilesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sse_capture_files_total",
+			Help: "Capture archive files by lifecycle action (opened, closed, expired)",
+		},
+		[]string{"action"},
+	)
+
 	// API-specific counters (Task 17.8)
 	APIErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -314,6 +486,14 @@ var (
 		[]string{},
 	)
 
+	SSECheckpointAgeSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sse_checkpoint_age_seconds",
+			Help: "Seconds since the SSE resume checkpoint was last persisted",
+		},
+		[]string{},
+	)
+
 	APIRequestsInFlight = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "api_requests_in_flight",
@@ -385,6 +565,9 @@ func InitMetrics() {
 	prometheus.MustRegister(EditsFilteredTotal)
 	metricsRegistry["edits_filtered_total"] = EditsFilteredTotal
 
+	prometheus.MustRegister(LogEventsIngestedTotal)
+	metricsRegistry["log_events_ingested_total"] = LogEventsIngestedTotal
+
 	prometheus.MustRegister(KafkaProduceErrorsTotal)
 	metricsRegistry["kafka_produce_errors_total"] = KafkaProduceErrorsTotal
 
@@ -400,12 +583,63 @@ func InitMetrics() {
 	prometheus.MustRegister(ProduceErrorsTotal)
 	metricsRegistry["produce_errors_total"] = ProduceErrorsTotal
 
+	prometheus.MustRegister(KafkaSpillMessagesTotal)
+	metricsRegistry["kafka_spill_messages_total"] = KafkaSpillMessagesTotal
+
+	prometheus.MustRegister(KafkaSpillReplayedTotal)
+	metricsRegistry["kafka_spill_replayed_total"] = KafkaSpillReplayedTotal
+
+	prometheus.MustRegister(KafkaSpillBytes)
+	metricsRegistry["kafka_spill_bytes"] = KafkaSpillBytes
+
 	prometheus.MustRegister(EditsProcessedTotal)
 	metricsRegistry["edits_processed_total"] = EditsProcessedTotal
 
 	prometheus.MustRegister(ProcessingErrorsTotal)
 	metricsRegistry["processing_errors_total"] = ProcessingErrorsTotal
 
+	prometheus.MustRegister(EditsDedupCheckedTotal)
+	metricsRegistry["edits_dedup_checked_total"] = EditsDedupCheckedTotal
+
+	prometheus.MustRegister(EditsDuplicateTotal)
+	metricsRegistry["edits_duplicate_total"] = EditsDuplicateTotal
+
+	prometheus.MustRegister(LateEventsTotal)
+	metricsRegistry["late_events_total"] = LateEventsTotal
+
+	prometheus.MustRegister(EventTimeWatermark)
+	metricsRegistry["event_time_watermark_seconds"] = EventTimeWatermark
+
+	prometheus.MustRegister(AggregateAnomaliesTotal)
+	metricsRegistry["aggregate_anomalies_total"] = AggregateAnomaliesTotal
+
+	prometheus.MustRegister(WikidataLookupsTotal)
+	metricsRegistry["wikidata_lookups_total"] = WikidataLookupsTotal
+
+	prometheus.MustRegister(VandalismAlertsTotal)
+	metricsRegistry["vandalism_alerts_total"] = VandalismAlertsTotal
+
+	prometheus.MustRegister(RevertsDetectedTotal)
+	metricsRegistry["reverts_detected_total"] = RevertsDetectedTotal
+
+	prometheus.MustRegister(ThreeRevertViolationsTotal)
+	metricsRegistry["three_revert_violations_total"] = ThreeRevertViolationsTotal
+
+	prometheus.MustRegister(CoordinatedGroupsTotal)
+	metricsRegistry["coordinated_groups_detected_total"] = CoordinatedGroupsTotal
+
+	prometheus.MustRegister(BotRunsTotal)
+	metricsRegistry["bot_runs_total"] = BotRunsTotal
+
+	prometheus.MustRegister(SuspectedBotsTotal)
+	metricsRegistry["suspected_bots_total"] = SuspectedBotsTotal
+
+	prometheus.MustRegister(RuleAlertsTotal)
+	metricsRegistry["rule_alerts_total"] = RuleAlertsTotal
+
+	prometheus.MustRegister(StoriesDetectedTotal)
+	metricsRegistry["stories_detected_total"] = StoriesDetectedTotal
+
 	prometheus.MustRegister(DocsIndexedTotal)
 	metricsRegistry["docs_indexed_total"] = DocsIndexedTotal
 
@@ -439,6 +673,18 @@ func InitMetrics() {
 	prometheus.MustRegister(SSEReconnectionsTotal)
 	metricsRegistry["sse_reconnections_total"] = SSEReconnectionsTotal
 
+	prometheus.MustRegister(SSECheckpointWritesTotal)
+	metricsRegistry["sse_checkpoint_writes_total"] = SSECheckpointWritesTotal
+
+	prometheus.MustRegister(SSECheckpointRestoresTotal)
+	metricsRegistry["sse_checkpoint_restores_total"] = SSECheckpointRestoresTotal
+
+	prometheus.MustRegister(SSECaptureEventsTotal)
+	metricsRegistry["sse_capture_events_total"] = SSECaptureEventsTotal
+
+	prometheus.MustRegister(SSECaptureFilesTotal)
+	metricsRegistry["sse_capture_files_total"] = SSECaptureFilesTotal
+
 	prometheus.MustRegister(APIErrorsTotal)
 	metricsRegistry["api_errors_total"] = APIErrorsTotal
 
@@ -494,6 +740,9 @@ func InitMetrics() {
 	prometheus.MustRegister(WebSocketConnectionsActive)
 	metricsRegistry["websocket_connections_active"] = WebSocketConnectionsActive
 
+	prometheus.MustRegister(SSECheckpointAgeSeconds)
+	metricsRegistry["sse_checkpoint_age_seconds"] = SSECheckpointAgeSeconds
+
 	prometheus.MustRegister(APIRequestsInFlight)
 	metricsRegistry["api_requests_in_flight"] = APIRequestsInFlight
 
@@ -0,0 +1,414 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits on alert rules, so one user's rules stay cheap to evaluate against
+// every edit.
+const (
+	MaxRuleNameLength = 100
+	MaxRuleLength     = 500
+	MaxRuleClauses    = 10
+	MaxRulePattern    = 200
+	MinRuleWindow     = time.Minute
+	MaxRuleWindow     = 24 * time.Hour
+)
+
+// AlertRule is a user's personal alert: a condition in the rule language
+// that, when an edit meets it, sends the user an alert.
+type AlertRule struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CompiledRule is a parsed alert rule expression.
+//
+// A rule is one or more clauses joined by "and", all of which an edit must
+// meet:
+//
+//	watched                   the page is on the user's watchlist
+//	new                       the edit created the page
+//	wiki = enwiki             also !=
+//	title ~ "regex"           RE2, matched anywhere in the title
+//	user = "Name"             also !=
+//	bot = true                also !=, true or false
+//	namespace = 0             any comparison
+//	bytes < -5000             byte change; negative for removals
+//	edits(10m) > 20           edits to the page in the last 10 minutes
+//	editors(10m) > 3          distinct editors of the page in that time
+//
+// Comparisons are <, <=, >, >=, = and !=. Strings are double-quoted, with
+// backslash escaping a quote or a backslash; wiki names, user names without
+// spaces and numbers may be left bare.
+//
+// edits() and editors() count the edits that meet the rule's other clauses,
+// this one included, so "bytes < -1000 and edits(1h) >= 3" is three large
+// removals from a page within an hour. Windows run from 1m to 24h.
+type CompiledRule struct {
+	clauses []ruleClause
+}
+
+type ruleClause struct {
+	field  string
+	op     string
+	num    int
+	str    string
+	re     *regexp.Regexp
+	window time.Duration
+}
+
+// WindowCounts is a page's activity over one window of a rule.
+type WindowCounts struct {
+	Edits   int
+	Editors int
+}
+
+// ParseRule compiles an alert rule expression, reporting the first problem
+// with it in terms a user can act on.
+func ParseRule(expr string) (*CompiledRule, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("rule is empty")
+	}
+	if len(expr) > MaxRuleLength {
+		return nil, fmt.Errorf("rule is longer than %d characters", MaxRuleLength)
+	}
+	tokens, err := lexRule(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ruleParser{tokens: tokens}
+	rule := &CompiledRule{}
+	for {
+		c, err := p.clause()
+		if err != nil {
+			return nil, fmt.Errorf("clause %d: %w", len(rule.clauses)+1, err)
+		}
+		rule.clauses = append(rule.clauses, c)
+		if len(rule.clauses) > MaxRuleClauses {
+			return nil, fmt.Errorf("rule has more than %d clauses", MaxRuleClauses)
+		}
+		if p.done() {
+			return rule, nil
+		}
+		if tok := p.next(); tok.text != "and" || tok.kind != tokWord {
+			return nil, fmt.Errorf("expected \"and\" after clause %d, got %s", len(rule.clauses), tok)
+		}
+	}
+}
+
+// UsesWatchlist reports whether the rule has a watched clause.
+func (r *CompiledRule) UsesWatchlist() bool {
+	return slices.ContainsFunc(r.clauses, func(c ruleClause) bool { return c.field == "watched" })
+}
+
+// Windows returns the distinct windows of the rule's edits() and editors()
+// clauses, shortest first.
+func (r *CompiledRule) Windows() []time.Duration {
+	var windows []time.Duration
+	for _, c := range r.clauses {
+		if c.window > 0 && !slices.Contains(windows, c.window) {
+			windows = append(windows, c.window)
+		}
+	}
+	slices.Sort(windows)
+	return windows
+}
+
+// MatchEdit reports whether edit meets every clause of the rule except the
+// edits() and editors() ones. watched says whether the page is on the rule
+// owner's watchlist.
+func (r *CompiledRule) MatchEdit(edit *WikipediaEdit, watched bool) bool {
+	for _, c := range r.clauses {
+		var ok bool
+		switch c.field {
+		case "watched":
+			ok = watched
+		case "new":
+			ok = edit.Type == "new"
+		case "wiki":
+			ok = compareStrings(edit.Wiki, c.op, c.str)
+		case "user":
+			ok = compareStrings(edit.User, c.op, c.str)
+		case "bot":
+			ok = compareStrings(strconv.FormatBool(edit.Bot), c.op, c.str)
+		case "title":
+			ok = c.re.MatchString(edit.Title)
+		case "namespace":
+			ok = compareInts(edit.Namespace, c.op, c.num)
+		case "bytes":
+			ok = compareInts(edit.ByteChange(), c.op, c.num)
+		default: // edits, editors
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchCounts reports whether the page activity in counts, keyed by window,
+// meets the rule's edits() and editors() clauses.
+func (r *CompiledRule) MatchCounts(counts map[time.Duration]WindowCounts) bool {
+	for _, c := range r.clauses {
+		switch c.field {
+		case "edits":
+			if !compareInts(counts[c.window].Edits, c.op, c.num) {
+				return false
+			}
+		case "editors":
+			if !compareInts(counts[c.window].Editors, c.op, c.num) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareStrings(v, op, want string) bool {
+	if op == "!=" {
+		return v != want
+	}
+	return v == want
+}
+
+func compareInts(v int, op string, want int) bool {
+	switch op {
+	case "<":
+		return v < want
+	case "<=":
+		return v <= want
+	case ">":
+		return v > want
+	case ">=":
+		return v >= want
+	case "!=":
+		return v != want
+	default:
+		return v == want
+	}
+}
+
+type ruleTokenKind int
+
+const (
+	tokWord ruleTokenKind = iota
+	tokString
+	tokOp
+	tokParen
+	tokEnd
+)
+
+type ruleToken struct {
+	kind ruleTokenKind
+	text string
+}
+
+func (t ruleToken) String() string {
+	if t.kind == tokEnd {
+		return "end of rule"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexRule splits a rule into words, quoted strings, operators and
+// parentheses.
+func lexRule(expr string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, ruleToken{tokParen, string(c)})
+			i++
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(expr) && expr[j] != '"'; j++ {
+				if expr[j] == '\\' && j+1 < len(expr) {
+					j++
+				}
+				sb.WriteByte(expr[j])
+			}
+			if j == len(expr) {
+				return nil, fmt.Errorf("unterminated string starting at character %d", i+1)
+			}
+			tokens = append(tokens, ruleToken{tokString, sb.String()})
+			i = j + 1
+		case strings.IndexByte("<>=!~", c) >= 0:
+			op := string(c)
+			if i+1 < len(expr) && expr[i+1] == '=' && c != '=' && c != '~' {
+				op += "="
+			}
+			tokens = append(tokens, ruleToken{tokOp, op})
+			i += len(op)
+		default:
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t\n\r()\"<>=!~", rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, ruleToken{tokWord, expr[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+}
+
+func (p *ruleParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ruleParser) next() ruleToken {
+	if p.done() {
+		return ruleToken{kind: tokEnd}
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *ruleParser) peek() ruleToken {
+	if p.done() {
+		return ruleToken{kind: tokEnd}
+	}
+	return p.tokens[p.pos]
+}
+
+// clause parses one clause.
+func (p *ruleParser) clause() (ruleClause, error) {
+	tok := p.next()
+	if tok.kind != tokWord {
+		return ruleClause{}, fmt.Errorf("expected a field, got %s", tok)
+	}
+	c := ruleClause{field: tok.text}
+
+	switch c.field {
+	case "watched", "new":
+		return c, nil
+
+	case "wiki", "user":
+		op, err := p.op("=", "!=")
+		if err != nil {
+			return c, err
+		}
+		c.op = op
+		if c.str, err = p.value(); err != nil {
+			return c, err
+		}
+		if c.field == "wiki" {
+			if project, _ := ParseWikiDBName(c.str); project == "" {
+				return c, fmt.Errorf("unknown wiki %q", c.str)
+			}
+		}
+		return c, nil
+
+	case "bot":
+		op, err := p.op("=", "!=")
+		if err != nil {
+			return c, err
+		}
+		c.op = op
+		v := p.next()
+		if v.kind != tokWord || (v.text != "true" && v.text != "false") {
+			return c, fmt.Errorf("expected true or false after bot %s, got %s", op, v)
+		}
+		c.str = v.text
+		return c, nil
+
+	case "title":
+		if _, err := p.op("~"); err != nil {
+			return c, err
+		}
+		pattern := p.next()
+		if pattern.kind != tokString {
+			return c, fmt.Errorf("expected a quoted pattern after title ~, got %s", pattern)
+		}
+		if len(pattern.text) > MaxRulePattern {
+			return c, fmt.Errorf("title pattern is longer than %d characters", MaxRulePattern)
+		}
+		re, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return c, fmt.Errorf("invalid title pattern: %w", err)
+		}
+		c.op, c.re = "~", re
+		return c, nil
+
+	case "namespace", "bytes":
+		return p.comparison(c)
+
+	case "edits", "editors":
+		if open := p.next(); open.text != "(" || open.kind != tokParen {
+			return c, fmt.Errorf("expected %s(window), e.g. %s(10m)", c.field, c.field)
+		}
+		w := p.next()
+		window, err := time.ParseDuration(w.text)
+		if w.kind != tokWord || err != nil {
+			return c, fmt.Errorf("expected a window like 10m or 1h in %s(), got %s", c.field, w)
+		}
+		if window < MinRuleWindow || window > MaxRuleWindow {
+			return c, fmt.Errorf("%s() window must be between %s and %s", c.field, MinRuleWindow, MaxRuleWindow)
+		}
+		if closing := p.next(); closing.text != ")" || closing.kind != tokParen {
+			return c, fmt.Errorf("expected ) after %s(%s, got %s", c.field, w.text, closing)
+		}
+		c.window = window
+		return p.comparison(c)
+
+	default:
+		return c, fmt.Errorf("unknown field %s", tok)
+	}
+}
+
+// comparison parses the operator and number of a numeric clause.
+func (p *ruleParser) comparison(c ruleClause) (ruleClause, error) {
+	op, err := p.op("<", "<=", ">", ">=", "=", "!=")
+	if err != nil {
+		return c, err
+	}
+	c.op = op
+	v := p.next()
+	n, err := strconv.Atoi(v.text)
+	if v.kind != tokWord || err != nil {
+		return c, fmt.Errorf("expected a whole number after %s %s, got %s", c.field, op, v)
+	}
+	c.num = n
+	return c, nil
+}
+
+// op reads an operator, which must be one of allowed.
+func (p *ruleParser) op(allowed ...string) (string, error) {
+	tok := p.peek()
+	if tok.kind != tokOp || !slices.Contains(allowed, tok.text) {
+		return "", fmt.Errorf("expected %s, got %s", strings.Join(allowed, " or "), tok)
+	}
+	p.pos++
+	return tok.text, nil
+}
+
+// value reads a quoted string or a bare word.
+func (p *ruleParser) value() (string, error) {
+	tok := p.next()
+	if tok.kind != tokString && tok.kind != tokWord {
+		return "", fmt.Errorf("expected a value, got %s", tok)
+	}
+	return tok.text, nil
+}
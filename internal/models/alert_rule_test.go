@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRule_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"", "rule is empty"},
+		{"edits(10m) > 20 or watched", `expected "and" after clause 1, got "or"`},
+		{"pageviews > 10", `unknown field "pageviews"`},
+		{"wiki = xxwikifoo", `unknown wiki "xxwikifoo"`},
+		{"wiki > enwiki", `expected = or !=, got ">"`},
+		{`title = "Paris"`, `expected ~, got "="`},
+		{"title ~ Paris", "expected a quoted pattern"},
+		{`title ~ "(unclosed"`, "invalid title pattern"},
+		{`title ~ "unterminated`, "unterminated string"},
+		{"bytes < five", `expected a whole number after bytes <, got "five"`},
+		{"bot = maybe", "expected true or false"},
+		{"edits > 3", "expected edits(window)"},
+		{"edits(10) > 3", "expected a window like 10m or 1h"},
+		{"editors(30s) > 3", "between 1m0s and 24h0m0s"},
+		{"edits(48h) > 3", "between 1m0s and 24h0m0s"},
+		{"watched and", "clause 2: expected a field, got end of rule"},
+		{"watched " + strings.Repeat("and new ", MaxRuleClauses), "more than 10 clauses"},
+		{"watched and " + strings.Repeat("x", MaxRuleLength), "longer than 500 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseRule(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseRule(%q) succeeded, want error containing %q", tt.expr, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRule(%q) error = %q, want it to contain %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRule_MatchEdit(t *testing.T) {
+	edit := func(wiki, title, user string, bytes int) *WikipediaEdit {
+		e := &WikipediaEdit{Type: "edit", Wiki: wiki, Title: title, User: user}
+		e.Length.Old, e.Length.New = 10000, 10000+bytes
+		return e
+	}
+
+	tests := []struct {
+		expr    string
+		edit    *WikipediaEdit
+		watched bool
+		want    bool
+	}{
+		{`wiki = enwiki and title ~ "^2026 .* election"`, edit("enwiki", "2026 Brazilian general election", "A", 10), false, true},
+		{`wiki = enwiki and title ~ "^2026 .* election"`, edit("dewiki", "2026 Brazilian general election", "A", 10), false, false},
+		{`wiki=enwiki and title~"^2026 .* election"`, edit("enwiki", "2024 United States election", "A", 10), false, false},
+		{"watched and bytes < -5000", edit("enwiki", "Paris", "A", -6000), true, true},
+		{"watched and bytes < -5000", edit("enwiki", "Paris", "A", -6000), false, false},
+		{"watched and bytes < -5000", edit("enwiki", "Paris", "A", -5000), true, false},
+		{`user = "Jimbo Wales" and namespace != 2`, edit("enwiki", "Paris", "Jimbo Wales", 1), false, true},
+		{`user != Jimbo`, edit("enwiki", "Paris", "Jimbo", 1), false, false},
+		{"bot = false and bytes >= 0", edit("enwiki", "Paris", "A", 0), false, true},
+		{"new", edit("enwiki", "Paris", "A", 0), false, false},
+		{`title ~ "say \"hi\""`, edit("enwiki", `They say "hi"`, "A", 0), false, true},
+		{"edits(10m) > 20 and editors(10m) > 3", edit("enwiki", "Paris", "A", 0), false, true},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseRule(%q): %v", tt.expr, err)
+		}
+		if got := rule.MatchEdit(tt.edit, tt.watched); got != tt.want {
+			t.Errorf("%q on %s:%s (watched=%v) = %v, want %v", tt.expr, tt.edit.Wiki, tt.edit.Title, tt.watched, got, tt.want)
+		}
+	}
+}
+
+func TestParseRule_Windows(t *testing.T) {
+	rule, err := ParseRule("edits(1h) >= 5 and editors(10m) > 3 and edits(10m) > 20 and watched")
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+	if got := rule.Windows(); len(got) != 2 || got[0] != 10*time.Minute || got[1] != time.Hour {
+		t.Errorf("Windows() = %v, want [10m 1h]", got)
+	}
+	if !rule.UsesWatchlist() {
+		t.Error("UsesWatchlist() = false, want true")
+	}
+
+	counts := map[time.Duration]WindowCounts{
+		10 * time.Minute: {Edits: 21, Editors: 4},
+		time.Hour:        {Edits: 30, Editors: 9},
+	}
+	if !rule.MatchCounts(counts) {
+		t.Errorf("MatchCounts(%v) = false, want true", counts)
+	}
+	counts[10*time.Minute] = WindowCounts{Edits: 21, Editors: 3}
+	if rule.MatchCounts(counts) {
+		t.Errorf("MatchCounts(%v) = true, want false with 3 editors", counts)
+	}
+}
@@ -19,6 +19,7 @@ type EditDocument struct {
 	ByteChange    int       `json:"byte_change"`
 	Comment       string    `json:"comment"`
 	Language      string    `json:"language"`
+	Project       string    `json:"project"`
 	IndexedReason string    `json:"indexed_reason"`
 }
 
@@ -38,9 +39,10 @@ func FromWikipediaEdit(edit *WikipediaEdit, reason string) *EditDocument {
 	// Parse timestamp from Unix seconds and convert to UTC
 	timestamp := time.Unix(edit.Timestamp, 0).UTC()
 
-	// Extract language from wiki field (e.g., "enwiki" -> "en")
-	language := edit.Language()
-	if language == "" {
+	// Extract project and language from wiki field (e.g., "enwiki" -> "wikipedia", "en").
+	// Multilingual projects such as Wikidata have no language.
+	project, language := ParseWikiDBName(edit.Wiki)
+	if project == "" {
 		// Fallback: try to extract from wiki field
 		if len(edit.Wiki) >= 2 {
 			language = strings.ToLower(edit.Wiki[:2])
@@ -59,6 +61,7 @@ func FromWikipediaEdit(edit *WikipediaEdit, reason string) *EditDocument {
 		ByteChange:    edit.ByteChange(),
 		Comment:       edit.Comment,
 		Language:      language,
+		Project:       project,
 		IndexedReason: reason,
 	}
 }
@@ -84,6 +87,7 @@ func (d *EditDocument) MarshalJSON() ([]byte, error) {
 		ByteChange    int    `json:"byte_change"`
 		Comment       string `json:"comment"`
 		Language      string `json:"language"`
+		Project       string `json:"project"`
 		IndexedReason string `json:"indexed_reason"`
 	}{
 		ID:            d.ID,
@@ -95,6 +99,7 @@ func (d *EditDocument) MarshalJSON() ([]byte, error) {
 		ByteChange:    d.ByteChange,
 		Comment:       d.Comment,
 		Language:      d.Language,
+		Project:       d.Project,
 		IndexedReason: d.IndexedReason,
 	})
 }
\ No newline at end of file
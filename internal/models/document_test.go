@@ -50,6 +50,19 @@ func TestFromWikipediaEdit_DeterministicID(t *testing.T) {
 	assert.Equal(t, doc1.ID, doc2.ID) // same inputs → same hash
 }
 
+func TestFromWikipediaEdit_Project(t *testing.T) {
+	doc := FromWikipediaEdit(&WikipediaEdit{Wiki: "enwiki", Title: "X", Timestamp: time.Now().Unix()}, "test")
+	assert.Equal(t, "wikipedia", doc.Project)
+
+	doc = FromWikipediaEdit(&WikipediaEdit{Wiki: "wikidatawiki", Title: "Q42", Timestamp: time.Now().Unix()}, "test")
+	assert.Equal(t, "wikidata", doc.Project)
+	assert.Equal(t, "", doc.Language)
+
+	data, err := doc.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"project":"wikidata"`)
+}
+
 func TestFromWikipediaEdit_EmptyWiki(t *testing.T) {
 	edit := &WikipediaEdit{Wiki: "", Title: "X", Timestamp: time.Now().Unix()}
 	doc := FromWikipediaEdit(edit, "test")
@@ -59,7 +72,7 @@ func TestFromWikipediaEdit_EmptyWiki(t *testing.T) {
 func TestFromWikipediaEdit_ShortWiki(t *testing.T) {
 	edit := &WikipediaEdit{Wiki: "de", Title: "X", Timestamp: time.Now().Unix()}
 	doc := FromWikipediaEdit(edit, "test")
-	// Language() returns strings.TrimSuffix("de","wiki") = "de"
+	// Unrecognised wiki names fall back to their first two characters
 	assert.Equal(t, "de", doc.Language)
 }
 
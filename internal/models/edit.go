@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 )
@@ -27,6 +28,28 @@ type WikipediaEdit struct {
 		New int64 `json:"new"`
 	} `json:"revision"`
 	Comment string `json:"comment"`
+
+	// Log event fields, only present when Type is "log"
+	LogID            int64           `json:"log_id,omitempty"`
+	LogType          string          `json:"log_type,omitempty"`   // e.g. protect, block, delete, move
+	LogAction        string          `json:"log_action,omitempty"` // e.g. protect, unprotect, modify
+	LogParams        json.RawMessage `json:"log_params,omitempty"` // Action-specific, object or array
+	LogActionComment string          `json:"log_action_comment,omitempty"`
+}
+
+// IsLogEvent returns true if this event is a MediaWiki log entry (protection,
+// block, deletion, move, ...) rather than an edit
+func (e *WikipediaEdit) IsLogEvent() bool {
+	return e.Type == "log"
+}
+
+// EventTime returns when the edit happened according to Wikipedia, or the
+// zero time if the event carries no timestamp.
+func (e *WikipediaEdit) EventTime() time.Time {
+	if e.Timestamp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(e.Timestamp, 0)
 }
 
 // ByteChange calculates the change in bytes for this edit
@@ -35,12 +58,23 @@ func (e *WikipediaEdit) ByteChange() int {
 }
 
 // Language extracts the language code from the wiki field
-// For example, "enwiki" returns "en", "eswiki" returns "es", "simplewiki" returns "simple"
+// For example, "enwiki" returns "en", "eswiktionary" returns "es", "simplewiki" returns "simple".
+// Wikis without a language edition, such as "wikidatawiki", return "".
 func (e *WikipediaEdit) Language() string {
-	if len(e.Wiki) < 2 {
-		return ""
-	}
-	return strings.TrimSuffix(e.Wiki, "wiki")
+	_, lang := ParseWikiDBName(e.Wiki)
+	return lang
+}
+
+// Project returns the Wikimedia project family of the edit's wiki, such as
+// "wikipedia", "wiktionary" or "wikidata", or "" if it is not recognised.
+func (e *WikipediaEdit) Project() string {
+	project, _ := ParseWikiDBName(e.Wiki)
+	return project
+}
+
+// PageKey returns the wiki-qualified key of the edited page
+func (e *WikipediaEdit) PageKey() PageKey {
+	return NewPageKey(e.Wiki, e.Title)
 }
 
 // IsMainNamespace returns true if this edit is in the main article namespace (ns=0)
@@ -48,6 +82,21 @@ func (e *WikipediaEdit) IsMainNamespace() bool {
 	return e.Namespace == 0
 }
 
+// IsAnonymous returns true if the edit was made logged out (see IsAnonymousUser)
+func (e *WikipediaEdit) IsAnonymous() bool {
+	return IsAnonymousUser(e.User)
+}
+
+// IsAnonymousUser reports whether a user name belongs to a logged-out
+// editor: an IP address or a temporary account (names like ~2025-12345-67).
+// It is the one definition of "anonymous" used across WikiSurge.
+func IsAnonymousUser(user string) bool {
+	if net.ParseIP(user) != nil {
+		return true
+	}
+	return len(user) > 1 && user[0] == '~' && user[1] >= '0' && user[1] <= '9'
+}
+
 // IsSignificant returns true if the absolute byte change is greater than 100
 func (e *WikipediaEdit) IsSignificant() bool {
 	byteChange := e.ByteChange()
@@ -67,8 +116,32 @@ func (e *WikipediaEdit) ToJSON() []byte {
 	return data
 }
 
-// Validate checks if the edit has all required fields and valid values
+// Validate checks if the edit has all required fields and valid values,
+// and that it is a recent change (not in the future, at most a day old)
 func (e *WikipediaEdit) Validate() error {
+	if err := e.ValidateFields(); err != nil {
+		return err
+	}
+	
+	// Check if timestamp is reasonable (not in the future, not too far in the past)
+	// Wikipedia sends timestamps in seconds (Unix timestamp), not milliseconds
+	now := time.Now().Unix()
+	if e.Timestamp > now+3600 { // Allow up to 1 hour in the future to account for timezone differences
+		return fmt.Errorf("Timestamp cannot be in the future")
+	}
+	
+	// Check timestamp is not more than 1 day ago (should be recent changes)
+	oneDayAgo := now - (24 * 60 * 60)
+	if e.Timestamp < oneDayAgo {
+		return fmt.Errorf("Timestamp is too far in the past")
+	}
+	
+	return nil
+}
+
+// ValidateFields performs the structural checks of Validate without the
+// recency window, for historical edits such as those read from a replay file
+func (e *WikipediaEdit) ValidateFields() error {
 	if e.ID == 0 {
 		return fmt.Errorf("ID is required and cannot be zero")
 	}
@@ -101,19 +174,6 @@ func (e *WikipediaEdit) Validate() error {
 		return fmt.Errorf("Timestamp is required and cannot be zero")
 	}
 	
-	// Check if timestamp is reasonable (not in the future, not too far in the past)
-	// Wikipedia sends timestamps in seconds (Unix timestamp), not milliseconds
-	now := time.Now().Unix()
-	if e.Timestamp > now+3600 { // Allow up to 1 hour in the future to account for timezone differences
-		return fmt.Errorf("Timestamp cannot be in the future")
-	}
-	
-	// Check timestamp is not more than 1 day ago (should be recent changes)
-	oneDayAgo := now - (24 * 60 * 60)
-	if e.Timestamp < oneDayAgo {
-		return fmt.Errorf("Timestamp is too far in the past")
-	}
-	
 	if e.Length.Old < 0 || e.Length.New < 0 {
 		return fmt.Errorf("Length values cannot be negative")
 	}
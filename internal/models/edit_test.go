@@ -75,6 +75,16 @@ func TestWikipediaEdit_Language(t *testing.T) {
 			wiki:     "dewiki",
 			expected: "de",
 		},
+		{
+			name:     "french wiktionary",
+			wiki:     "frwiktionary",
+			expected: "fr",
+		},
+		{
+			name:     "wikidata has no language",
+			wiki:     "wikidatawiki",
+			expected: "",
+		},
 		{
 			name:     "empty wiki",
 			wiki:     "",
@@ -98,6 +108,30 @@ func TestWikipediaEdit_Language(t *testing.T) {
 	}
 }
 
+func TestWikipediaEdit_IsAnonymous(t *testing.T) {
+	tests := []struct {
+		user     string
+		expected bool
+	}{
+		{"Alice", false},
+		{"192.0.2.7", true},
+		{"2001:db8::1", true},
+		{"~2025-12345-67", true},
+		{"~Tilde", false},
+		{"192.0.2.1 (Wikipe)", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			edit := WikipediaEdit{User: tt.user}
+			if result := edit.IsAnonymous(); result != tt.expected {
+				t.Errorf("IsAnonymous() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestWikipediaEdit_IsSignificant(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -332,4 +366,28 @@ func TestWikipediaEdit_Validate(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestWikipediaEdit_ValidateFields(t *testing.T) {
+	edit := WikipediaEdit{
+		ID:        12345,
+		Type:      "edit",
+		Title:     "Test Page",
+		User:      "TestUser",
+		Wiki:      "enwiki",
+		ServerURL: "en.wikipedia.org",
+		Timestamp: time.Now().Add(-30 * 24 * time.Hour).Unix(), // recorded a month ago
+	}
+
+	if err := edit.Validate(); err == nil {
+		t.Error("Validate() should reject an edit older than a day")
+	}
+	if err := edit.ValidateFields(); err != nil {
+		t.Errorf("ValidateFields() unexpected error for historical edit: %v", err)
+	}
+
+	edit.Title = ""
+	if err := edit.ValidateFields(); err == nil {
+		t.Error("ValidateFields() expected error for empty title")
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LogEvent is a MediaWiki log entry affecting a page or user, such as a page
+// protection, user block, deletion or page move. It is derived from a
+// recentchange event of type "log".
+type LogEvent struct {
+	LogID     int64           `json:"log_id"`
+	Wiki      string          `json:"wiki"`
+	ServerURL string          `json:"server_url"`
+	Title     string          `json:"title"`
+	Namespace int             `json:"ns"`
+	LogType   string          `json:"log_type"`
+	LogAction string          `json:"log_action"`
+	LogParams json.RawMessage `json:"log_params,omitempty"`
+	User      string          `json:"user"`
+	Comment   string          `json:"comment"`
+	Timestamp int64           `json:"timestamp"`
+	Summary   string          `json:"summary"`
+}
+
+// NewLogEvent extracts the log entry from a recentchange event. It returns
+// nil if the event is not a log event.
+func NewLogEvent(e *WikipediaEdit) *LogEvent {
+	if !e.IsLogEvent() {
+		return nil
+	}
+	ev := &LogEvent{
+		LogID:     e.LogID,
+		Wiki:      e.Wiki,
+		ServerURL: e.ServerURL,
+		Title:     e.Title,
+		Namespace: e.Namespace,
+		LogType:   e.LogType,
+		LogAction: e.LogAction,
+		LogParams: e.LogParams,
+		User:      e.User,
+		Comment:   e.Comment,
+		Timestamp: e.Timestamp,
+	}
+	ev.Summary = ev.Describe()
+	return ev
+}
+
+// logActionVerbs maps (log_type, log_action) to a past-tense description
+var logActionVerbs = map[string]string{
+	"protect/protect":   "page protected",
+	"protect/modify":    "protection changed",
+	"protect/unprotect": "page unprotected",
+	"protect/move_prot": "protection moved",
+	"block/block":       "user blocked",
+	"block/reblock":     "block changed",
+	"block/unblock":     "user unblocked",
+	"delete/delete":     "page deleted",
+	"delete/restore":    "page restored",
+	"delete/revision":   "revision visibility changed",
+	"move/move":         "page moved",
+	"move/move_redir":   "page moved over redirect",
+	"merge/merge":       "page histories merged",
+}
+
+// Describe returns a short human-readable summary such as
+// "page protected by Admin" or "page moved to New title by Admin".
+func (l *LogEvent) Describe() string {
+	verb, ok := logActionVerbs[l.LogType+"/"+l.LogAction]
+	if !ok {
+		verb = fmt.Sprintf("%s/%s", l.LogType, l.LogAction)
+	}
+	if target := l.MoveTarget(); target != "" {
+		verb += " to " + target
+	}
+	return fmt.Sprintf("%s by %s", verb, l.User)
+}
+
+// MoveTarget returns the destination title of a page move, or "" for other
+// log types.
+func (l *LogEvent) MoveTarget() string {
+	if l.LogType != "move" || len(l.LogParams) == 0 {
+		return ""
+	}
+	var params struct {
+		Target string `json:"target"`
+	}
+	if err := json.Unmarshal(l.LogParams, &params); err != nil {
+		return ""
+	}
+	return params.Target
+}
+
+// CreatedAccount returns the name of the account a newusers entry registered,
+// or "" for other log types. Accounts auto-created for an existing global
+// account when it first visits a wiki are not new and return "" too.
+func (l *LogEvent) CreatedAccount() string {
+	if l.LogType != "newusers" || l.LogAction == "autocreate" || l.Namespace != 2 {
+		return ""
+	}
+	// The title is the account's user page, with a localized namespace prefix
+	_, name, ok := strings.Cut(l.Title, ":")
+	if !ok {
+		return ""
+	}
+	return name
+}
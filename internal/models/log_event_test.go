@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewLogEvent(t *testing.T) {
+	if ev := NewLogEvent(&WikipediaEdit{Type: "edit"}); ev != nil {
+		t.Errorf("NewLogEvent(edit) = %+v, want nil", ev)
+	}
+
+	tests := []struct {
+		name   string
+		edit   WikipediaEdit
+		expect string
+	}{
+		{
+			name:   "protection",
+			edit:   WikipediaEdit{Type: "log", LogType: "protect", LogAction: "protect", User: "Admin"},
+			expect: "page protected by Admin",
+		},
+		{
+			name: "move with target",
+			edit: WikipediaEdit{Type: "log", LogType: "move", LogAction: "move", User: "Mover",
+				LogParams: json.RawMessage(`{"noredir":"0","target":"New title"}`)},
+			expect: "page moved to New title by Mover",
+		},
+		{
+			name: "params as array",
+			edit: WikipediaEdit{Type: "log", LogType: "move", LogAction: "move", User: "Mover",
+				LogParams: json.RawMessage(`[]`)},
+			expect: "page moved by Mover",
+		},
+		{
+			name:   "unknown action",
+			edit:   WikipediaEdit{Type: "log", LogType: "abusefilter", LogAction: "hit", User: "Bob"},
+			expect: "abusefilter/hit by Bob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := NewLogEvent(&tt.edit)
+			if ev == nil {
+				t.Fatal("NewLogEvent() = nil")
+			}
+			if ev.Summary != tt.expect {
+				t.Errorf("Summary = %q, want %q", ev.Summary, tt.expect)
+			}
+		})
+	}
+}
+
+func TestLogEventCreatedAccount(t *testing.T) {
+	tests := []struct {
+		edit   WikipediaEdit
+		expect string
+	}{
+		{WikipediaEdit{Type: "log", LogType: "newusers", LogAction: "create", Namespace: 2, Title: "User:Capyfan2026"}, "Capyfan2026"},
+		{WikipediaEdit{Type: "log", LogType: "newusers", LogAction: "create2", Namespace: 2, Title: "Benutzer:Neu"}, "Neu"},
+		{WikipediaEdit{Type: "log", LogType: "newusers", LogAction: "autocreate", Namespace: 2, Title: "User:Globetrotter"}, ""},
+		{WikipediaEdit{Type: "log", LogType: "block", LogAction: "block", Namespace: 2, Title: "User:Vandal"}, ""},
+	}
+	for _, tt := range tests {
+		if got := NewLogEvent(&tt.edit).CreatedAccount(); got != tt.expect {
+			t.Errorf("CreatedAccount(%s/%s %q) = %q, want %q", tt.edit.LogType, tt.edit.LogAction, tt.edit.Title, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+)
+
+// PageKey identifies a page across wikis. Titles are only unique within a
+// wiki ("Paris" on enwiki and frwiki are different articles), so all per-page
+// state is keyed by both.
+type PageKey struct {
+	Wiki  string `json:"wiki"`
+	Title string `json:"title"`
+}
+
+// NewPageKey returns the key for title on the given wiki database.
+func NewPageKey(wiki, title string) PageKey {
+	return PageKey{Wiki: wiki, Title: title}
+}
+
+// String returns the "wiki:title" form used in Redis key names and sorted
+// set members, e.g. "enwiki:Paris". A key without a wiki renders as the bare
+// title, which is also how state written before page keys existed looks.
+func (k PageKey) String() string {
+	if k.Wiki == "" {
+		return k.Title
+	}
+	return k.Wiki + ":" + k.Title
+}
+
+// IsLegacy reports whether the key has no wiki, i.e. it came from state
+// written before page keys were wiki-qualified.
+func (k PageKey) IsLegacy() bool {
+	return k.Wiki == ""
+}
+
+// ParsePageKey parses the String form of a page key. Wiki database names never
+// contain a colon, so the text before the first colon is taken as the wiki if
+// it is a recognised database name. Anything else, including titles with a
+// namespace prefix such as "Talk:Paris", is returned as a legacy key with an
+// empty wiki.
+func ParsePageKey(s string) PageKey {
+	wiki, title, ok := strings.Cut(s, ":")
+	if ok && title != "" {
+		if project, _ := ParseWikiDBName(wiki); project != "" {
+			return PageKey{Wiki: wiki, Title: title}
+		}
+	}
+	return PageKey{Title: s}
+}
@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestPageKey_String(t *testing.T) {
+	tests := []struct {
+		key  PageKey
+		want string
+	}{
+		{NewPageKey("enwiki", "Paris"), "enwiki:Paris"},
+		{NewPageKey("frwiki", "Paris"), "frwiki:Paris"},
+		{NewPageKey("enwiki", "Talk:Paris"), "enwiki:Talk:Paris"},
+		{NewPageKey("", "Paris"), "Paris"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParsePageKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PageKey
+	}{
+		{"enwiki:Paris", PageKey{Wiki: "enwiki", Title: "Paris"}},
+		{"frwiktionary:chat", PageKey{Wiki: "frwiktionary", Title: "chat"}},
+		{"enwiki:Talk:Paris", PageKey{Wiki: "enwiki", Title: "Talk:Paris"}},
+		{"wikidatawiki:Q90", PageKey{Wiki: "wikidatawiki", Title: "Q90"}},
+		// Legacy, title-only keys
+		{"Paris", PageKey{Title: "Paris"}},
+		{"Talk:Paris", PageKey{Title: "Talk:Paris"}},
+		{"Star Wars: Andor", PageKey{Title: "Star Wars: Andor"}},
+		{"enwiki:", PageKey{Title: "enwiki:"}},
+	}
+	for _, tt := range tests {
+		got := ParsePageKey(tt.in)
+		if got != tt.want {
+			t.Errorf("ParsePageKey(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("ParsePageKey(%q).String() = %q, want round trip", tt.in, got.String())
+		}
+		if got.IsLegacy() != (tt.want.Wiki == "") {
+			t.Errorf("ParsePageKey(%q).IsLegacy() = %v", tt.in, got.IsLegacy())
+		}
+	}
+}
+
+func TestWikipediaEdit_PageKey(t *testing.T) {
+	edit := &WikipediaEdit{Wiki: "dewiki", Title: "Berlin"}
+	if got := edit.PageKey(); got != NewPageKey("dewiki", "Berlin") {
+		t.Errorf("PageKey() = %#v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"strings"
+)
+
+// Wikimedia project families, as reported by Project() and accepted by the
+// ingestor's allowed_projects filter.
+const (
+	ProjectWikipedia   = "wikipedia"
+	ProjectWiktionary  = "wiktionary"
+	ProjectWikibooks   = "wikibooks"
+	ProjectWikinews    = "wikinews"
+	ProjectWikiquote   = "wikiquote"
+	ProjectWikisource  = "wikisource"
+	ProjectWikiversity = "wikiversity"
+	ProjectWikivoyage  = "wikivoyage"
+	ProjectWikidata    = "wikidata"
+	ProjectCommons     = "commons"
+	ProjectSpecies     = "species"
+	ProjectMeta        = "meta"
+	ProjectMediaWiki   = "mediawiki"
+	ProjectWikimedia   = "wikimedia" // chapter, foundation and other *.wikimedia.org wikis
+)
+
+// languageProjects are the families with one wiki per language. Their
+// database names are "<lang><suffix>" (e.g. "enwiktionary") and their hosts
+// "<lang>.<project>.org". Wikipedia's suffix is plain "wiki", so it must be
+// checked after the others.
+var languageProjects = []struct {
+	suffix  string
+	project string
+}{
+	{"wiktionary", ProjectWiktionary},
+	{"wikibooks", ProjectWikibooks},
+	{"wikinews", ProjectWikinews},
+	{"wikiquote", ProjectWikiquote},
+	{"wikisource", ProjectWikisource},
+	{"wikiversity", ProjectWikiversity},
+	{"wikivoyage", ProjectWikivoyage},
+	{"wikimedia", ProjectWikimedia},
+	{"wiki", ProjectWikipedia},
+}
+
+// singleWikis are database names ending in "wiki" that are not language
+// editions of Wikipedia.
+var singleWikis = map[string]struct {
+	project string
+	host    string
+}{
+	"wikidatawiki":      {ProjectWikidata, "www.wikidata.org"},
+	"testwikidatawiki":  {ProjectWikidata, "test.wikidata.org"},
+	"commonswiki":       {ProjectCommons, "commons.wikimedia.org"},
+	"specieswiki":       {ProjectSpecies, "species.wikimedia.org"},
+	"metawiki":          {ProjectMeta, "meta.wikimedia.org"},
+	"mediawikiwiki":     {ProjectMediaWiki, "www.mediawiki.org"},
+	"sourceswiki":       {ProjectWikisource, "wikisource.org"},
+	"wikifunctionswiki": {ProjectWikimedia, "www.wikifunctions.org"},
+	"foundationwiki":    {ProjectWikimedia, "foundation.wikimedia.org"},
+	"outreachwiki":      {ProjectWikimedia, "outreach.wikimedia.org"},
+	"incubatorwiki":     {ProjectWikimedia, "incubator.wikimedia.org"},
+	"loginwiki":         {ProjectWikimedia, "login.wikimedia.org"},
+	"wikimaniawiki":     {ProjectWikimedia, "wikimania.wikimedia.org"},
+}
+
+// KnownProjects lists every project name ParseWikiDBName can return.
+var KnownProjects = []string{
+	ProjectWikipedia, ProjectWiktionary, ProjectWikibooks, ProjectWikinews,
+	ProjectWikiquote, ProjectWikisource, ProjectWikiversity, ProjectWikivoyage,
+	ProjectWikidata, ProjectCommons, ProjectSpecies, ProjectMeta,
+	ProjectMediaWiki, ProjectWikimedia,
+}
+
+// ParseWikiDBName splits a wiki database name into its project family and
+// language code. For example "enwiki" returns ("wikipedia", "en"),
+// "frwiktionary" returns ("wiktionary", "fr") and "wikidatawiki" returns
+// ("wikidata", ""). Language codes keep the database form, so
+// "zh_min_nanwiki" yields "zh_min_nan". Unrecognised names return ("", "").
+func ParseWikiDBName(dbname string) (project, language string) {
+	if s, ok := singleWikis[dbname]; ok {
+		return s.project, ""
+	}
+	for _, p := range languageProjects {
+		if lang, ok := strings.CutSuffix(dbname, p.suffix); ok && lang != "" {
+			return p.project, lang
+		}
+	}
+	return "", ""
+}
+
+// ServerURLForWiki returns the canonical server URL of a wiki database,
+// e.g. "https://en.wiktionary.org" for "enwiktionary". It returns "" for
+// unrecognised names.
+func ServerURLForWiki(dbname string) string {
+	if s, ok := singleWikis[dbname]; ok {
+		return "https://" + s.host
+	}
+	project, lang := ParseWikiDBName(dbname)
+	if project == "" {
+		return ""
+	}
+	return "https://" + strings.ReplaceAll(lang, "_", "-") + "." + project + ".org"
+}
+
+// WikiFromServerURL maps a server URL back to its wiki database name, e.g.
+// "https://en.wikipedia.org" to "enwiki". It returns "" for unrecognised
+// hosts.
+func WikiFromServerURL(serverURL string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(serverURL, "https://"), "http://")
+	host = strings.TrimSuffix(host, "/")
+	if host == "" {
+		return ""
+	}
+	for dbname, s := range singleWikis {
+		if s.host == host {
+			return dbname
+		}
+	}
+	lang, rest, ok := strings.Cut(host, ".")
+	if !ok || lang == "" {
+		return ""
+	}
+	for _, p := range languageProjects {
+		if rest == p.project+".org" {
+			return strings.ReplaceAll(lang, "-", "_") + p.suffix
+		}
+	}
+	return ""
+}
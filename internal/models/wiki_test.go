@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func TestParseWikiDBName(t *testing.T) {
+	tests := []struct {
+		dbname   string
+		project  string
+		language string
+	}{
+		{"enwiki", ProjectWikipedia, "en"},
+		{"simplewiki", ProjectWikipedia, "simple"},
+		{"zh_min_nanwiki", ProjectWikipedia, "zh_min_nan"},
+		{"frwiktionary", ProjectWiktionary, "fr"},
+		{"enwikinews", ProjectWikinews, "en"},
+		{"dewikisource", ProjectWikisource, "de"},
+		{"itwikivoyage", ProjectWikivoyage, "it"},
+		{"wikidatawiki", ProjectWikidata, ""},
+		{"commonswiki", ProjectCommons, ""},
+		{"metawiki", ProjectMeta, ""},
+		{"mediawikiwiki", ProjectMediaWiki, ""},
+		{"sourceswiki", ProjectWikisource, ""},
+		{"dewikimedia", ProjectWikimedia, "de"},
+		{"wiki", "", ""},
+		{"e", "", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dbname, func(t *testing.T) {
+			project, lang := ParseWikiDBName(tt.dbname)
+			if project != tt.project || lang != tt.language {
+				t.Errorf("ParseWikiDBName(%q) = (%q, %q), want (%q, %q)",
+					tt.dbname, project, lang, tt.project, tt.language)
+			}
+		})
+	}
+}
+
+func TestServerURLForWiki(t *testing.T) {
+	tests := map[string]string{
+		"enwiki":         "https://en.wikipedia.org",
+		"zh_min_nanwiki": "https://zh-min-nan.wikipedia.org",
+		"enwiktionary":   "https://en.wiktionary.org",
+		"wikidatawiki":   "https://www.wikidata.org",
+		"commonswiki":    "https://commons.wikimedia.org",
+		"bogus":          "",
+	}
+	for dbname, want := range tests {
+		if got := ServerURLForWiki(dbname); got != want {
+			t.Errorf("ServerURLForWiki(%q) = %q, want %q", dbname, got, want)
+		}
+		// Recognised wikis round-trip through their server URL.
+		if want != "" {
+			if back := WikiFromServerURL(want); back != dbname {
+				t.Errorf("WikiFromServerURL(%q) = %q, want %q", want, back, dbname)
+			}
+		}
+	}
+
+	if got := WikiFromServerURL("https://example.org"); got != "" {
+		t.Errorf("WikiFromServerURL(example.org) = %q, want empty", got)
+	}
+	if got := WikiFromServerURL(""); got != "" {
+		t.Errorf("WikiFromServerURL(\"\") = %q, want empty", got)
+	}
+}
@@ -0,0 +1,418 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// anomalyBaselinesKey holds one baselineStat per series.
+	anomalyBaselinesKey = "anomaly:baselines"
+
+	// anomalyCooldownsKey holds the time of each series' last alert, by
+	// alert type and series.
+	anomalyCooldownsKey = "anomaly:cooldowns"
+
+	// anomalyStateKey holds the detector's progress: the last minute
+	// evaluated and what it last saw of the stream.
+	anomalyStateKey = "anomaly:state"
+
+	// anomalyLockPrefix claims one interval's check for one processor.
+	anomalyLockPrefix = "anomaly:lock:"
+
+	// anomalySettle is how long after a minute ends before it is evaluated,
+	// so slightly out-of-order edits are counted in it.
+	anomalySettle = time.Minute
+
+	// maxAnomalyCatchUp bounds how many minutes one tick evaluates after a
+	// restart or a pause.
+	maxAnomalyCatchUp = 60 * time.Minute
+)
+
+// AggregateAnomalyDetector watches edit rates for whole wikis, namespaces,
+// page creations and the global stream, using the per-minute counters the
+// trending aggregator records in StatsTracker. It raises wiki_surge alerts
+// when a series runs far above its baseline, and stream_drop alerts when the
+// global rate collapses or the stream stops advancing.
+//
+// Unlike the per-page detectors it is not a Kafka consumer: it runs on a
+// ticker and evaluates each minute once it is complete. Every processor runs
+// one; each interval is claimed in Redis by whichever ticks first, and the
+// baselines, cooldowns and progress are kept there for the next.
+type AggregateAnomalyDetector struct {
+	stats  *storage.StatsTracker
+	alerts *storage.RedisAlerts
+	redis  *redis.Client
+	cfg    config.AnomalyDetectionConfig
+	clock  storage.TimeProvider // decides which minutes are complete
+	wall   storage.TimeProvider // claims intervals and times stalls
+	logger zerolog.Logger
+
+	mu sync.Mutex
+}
+
+// anomalyState is what one check hands on to the next.
+type anomalyState struct {
+	baselines    map[string]*baselineStat
+	cooldowns    map[string]time.Time // alert type|series -> last alert time
+	lastMinute   time.Time            // start of the last minute evaluated
+	streamEdits  int64                // the stream's edit count when it last moved
+	streamMoved  time.Time            // wall time the edit count last moved
+	stallAlerted bool
+}
+
+// NewAggregateAnomalyDetector creates the detector. Call Start to run it.
+func NewAggregateAnomalyDetector(stats *storage.StatsTracker, alerts *storage.RedisAlerts, redisClient *redis.Client, cfg *config.Config, logger zerolog.Logger) *AggregateAnomalyDetector {
+	return &AggregateAnomalyDetector{
+		stats:  stats,
+		alerts: alerts,
+		redis:  redisClient,
+		cfg:    cfg.Processor.Anomaly,
+		clock:  &storage.RealTimeProvider{},
+		wall:   &storage.RealTimeProvider{},
+		logger: logger.With().Str("component", "aggregate-anomaly-detector").Logger(),
+	}
+}
+
+// SetTimeProvider sets the clock that decides which minutes are complete.
+// Pass the trending aggregator's event clock so minutes are evaluated as the
+// stream reaches them, which is also what makes replays reproduce alerts.
+func (d *AggregateAnomalyDetector) SetTimeProvider(tp storage.TimeProvider) {
+	d.clock = tp
+}
+
+// Start evaluates completed minutes every cfg.Interval until ctx is cancelled.
+func (d *AggregateAnomalyDetector) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.cfg.Interval)
+		defer ticker.Stop()
+
+		d.logger.Info().Dur("interval", d.cfg.Interval).Dur("window", d.cfg.Window).Msg("Aggregate anomaly detector started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				d.logger.Info().Msg("Aggregate anomaly detector stopped")
+				return
+			case <-ticker.C:
+				if err := d.tick(ctx); err != nil {
+					d.logger.Warn().Err(err).Msg("Aggregate anomaly check failed")
+				}
+			}
+		}
+	}()
+}
+
+// tick evaluates every minute completed since the previous check, unless
+// another processor has claimed this interval. A check that fails releases
+// the interval for the next tick.
+func (d *AggregateAnomalyDetector) tick(ctx context.Context) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wallNow := d.wall.Now()
+	lockKey := anomalyLockPrefix + strconv.FormatInt(wallNow.Truncate(d.cfg.Interval).Unix(), 10)
+	claimed, err := d.redis.SetNX(ctx, lockKey, 1, d.cfg.Interval).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim anomaly check: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+	defer func() {
+		if err != nil {
+			d.redis.Del(context.WithoutCancel(ctx), lockKey)
+		}
+	}()
+
+	st, err := d.loadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := d.clock.Now()
+	if err := d.checkStall(ctx, st, now, wallNow); err != nil {
+		return err
+	}
+
+	// Minutes before end are complete
+	end := now.Add(-anomalySettle).Truncate(time.Minute)
+	start := st.lastMinute.Add(time.Minute)
+	if st.lastMinute.IsZero() {
+		start = end.Add(-time.Minute)
+	}
+	if end.Sub(start) > maxAnomalyCatchUp {
+		start = end.Add(-maxAnomalyCatchUp)
+	}
+	if start.Before(end) {
+		window := int(d.cfg.Window / time.Minute)
+		buckets, err := d.stats.GetMinuteCounts(ctx, start.Add(-time.Duration(window-1)*time.Minute), end)
+		if err != nil {
+			return err
+		}
+
+		for i := window - 1; i < len(buckets); i++ {
+			rates := make(map[string]float64)
+			for _, b := range buckets[i-window+1 : i+1] {
+				for series, n := range b.Counts {
+					rates[series] += float64(n) / float64(window)
+				}
+			}
+			d.evaluate(ctx, st, buckets[i].Minute.Add(time.Minute), rates)
+		}
+		st.lastMinute = end.Add(-time.Minute)
+	}
+
+	for key, last := range st.cooldowns {
+		if now.Sub(last) >= d.cfg.Cooldown {
+			delete(st.cooldowns, key)
+		}
+	}
+	return d.saveState(ctx, st)
+}
+
+// evaluate compares each series' rate over the window ending at with its
+// baseline, raises alerts, then folds the rate into the baseline.
+func (d *AggregateAnomalyDetector) evaluate(ctx context.Context, st *anomalyState, at time.Time, rates map[string]float64) {
+	baselines := st.baselines
+	// A series with a baseline but no edits in the window has rate zero
+	for series := range baselines {
+		if _, ok := rates[series]; !ok {
+			rates[series] = 0
+		}
+	}
+	if _, ok := rates[storage.SeriesTotal]; !ok {
+		rates[storage.SeriesTotal] = 0
+	}
+
+	window := int(d.cfg.Window / time.Minute)
+	for series, rate := range rates {
+		stat, ok := baselines[series]
+		if !ok {
+			stat = &baselineStat{}
+			baselines[series] = stat
+		}
+
+		if stat.N >= int64(d.cfg.MinSamples) && stat.Mean >= d.cfg.MinRate {
+			// The variance of a rate averaged over the window is at least
+			// that of a Poisson process, which keeps a very steady series
+			// from alerting on noise.
+			stddev := math.Max(math.Sqrt(stat.Var), math.Sqrt(stat.Mean/float64(window)))
+			z := (rate - stat.Mean) / stddev
+			anomaly := storage.AggregateAnomaly{
+				Rate:          rate,
+				ExpectedRate:  stat.Mean,
+				ZScore:        z,
+				WindowMinutes: window,
+				At:            at,
+			}
+			describeSeries(series, &anomaly)
+
+			switch {
+			case rate >= d.cfg.SurgeRatio*stat.Mean && z >= d.cfg.ZScoreThreshold:
+				anomaly.Severity = surgeSeverity(rate / stat.Mean)
+				d.publish(ctx, st, storage.AlertTypeWikiSurge, series, anomaly)
+			case series == storage.SeriesTotal && rate <= d.cfg.DropRatio*stat.Mean:
+				anomaly.Severity = "high"
+				if rate <= 0.05*stat.Mean {
+					anomaly.Severity = "critical"
+				}
+				anomaly.Reason = "rate_drop"
+				d.publish(ctx, st, storage.AlertTypeStreamDrop, series, anomaly)
+			}
+		}
+
+		stat.observe(rate, d.cfg.EWMAAlpha)
+		stat.Last = at.Unix() / 60
+	}
+}
+
+// checkStall raises a stream_drop when no processor has counted an edit for
+// StallAfter of wall time. The rate check cannot see this with the event
+// clock, because no more minutes complete, so it watches the stream's edit
+// count rather than either clock.
+func (d *AggregateAnomalyDetector) checkStall(ctx context.Context, st *anomalyState, now, wallNow time.Time) error {
+	edits, err := d.stats.StreamEditCount(ctx)
+	if err != nil {
+		return err
+	}
+	if edits != st.streamEdits || st.streamMoved.IsZero() {
+		st.streamEdits = edits
+		st.streamMoved = wallNow
+		st.stallAlerted = false
+		return nil
+	}
+	if st.stallAlerted || wallNow.Sub(st.streamMoved) < d.cfg.StallAfter {
+		return nil
+	}
+	global := st.baselines[storage.SeriesTotal]
+	if global == nil || global.N < int64(d.cfg.MinSamples) {
+		return nil // no evidence the stream was ever flowing
+	}
+
+	st.stallAlerted = true
+	d.publish(ctx, st, storage.AlertTypeStreamDrop, storage.SeriesTotal+":stalled", storage.AggregateAnomaly{
+		Scope:         "global",
+		ExpectedRate:  global.Mean,
+		WindowMinutes: int(wallNow.Sub(st.streamMoved) / time.Minute),
+		Severity:      "critical",
+		Reason:        "stalled",
+		At:            now,
+	})
+	return nil
+}
+
+// publish sends an alert unless the same series alerted of the same type
+// within the cooldown.
+func (d *AggregateAnomalyDetector) publish(ctx context.Context, st *anomalyState, alertType, series string, anomaly storage.AggregateAnomaly) {
+	key := alertType + "|" + series
+	if last, ok := st.cooldowns[key]; ok && anomaly.At.Sub(last) < d.cfg.Cooldown {
+		return
+	}
+	st.cooldowns[key] = anomaly.At
+
+	var err error
+	if alertType == storage.AlertTypeStreamDrop {
+		err = d.alerts.PublishStreamDropAlert(ctx, anomaly)
+	} else {
+		err = d.alerts.PublishWikiSurgeAlert(ctx, anomaly)
+	}
+	if err != nil {
+		d.logger.Error().Err(err).Str("type", alertType).Str("series", series).Msg("Failed to publish aggregate anomaly alert")
+		return
+	}
+
+	metrics.AggregateAnomaliesTotal.WithLabelValues(alertType, anomaly.Scope).Inc()
+	d.logger.Info().
+		Str("type", alertType).
+		Str("series", series).
+		Float64("rate", anomaly.Rate).
+		Float64("expected", anomaly.ExpectedRate).
+		Float64("z", anomaly.ZScore).
+		Msg("Aggregate anomaly detected")
+}
+
+// describeSeries fills in the scope, wiki and namespace of a series name
+// from StatsTracker's minute counters.
+func describeSeries(series string, a *storage.AggregateAnomaly) {
+	switch {
+	case series == storage.SeriesTotal:
+		a.Scope = "global"
+	case series == storage.SeriesNewPages:
+		a.Scope = "new_pages"
+	case strings.HasPrefix(series, "wiki:"):
+		a.Scope = "wiki"
+		a.Wiki = strings.TrimPrefix(series, "wiki:")
+	case strings.HasPrefix(series, "new:"):
+		a.Scope = "new_pages"
+		a.Wiki = strings.TrimPrefix(series, "new:")
+	case strings.HasPrefix(series, "ns:"):
+		a.Scope = "namespace"
+		if ns, err := strconv.Atoi(strings.TrimPrefix(series, "ns:")); err == nil {
+			a.Namespace = &ns
+		}
+	}
+}
+
+// surgeSeverity grades a surge by how many times the baseline the rate is.
+func surgeSeverity(ratio float64) string {
+	switch {
+	case ratio >= 8:
+		return "critical"
+	case ratio >= 4:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
+// loadState reads what the previous check left in Redis.
+func (d *AggregateAnomalyDetector) loadState(ctx context.Context) (*anomalyState, error) {
+	pipe := d.redis.Pipeline()
+	baselinesCmd := pipe.HGetAll(ctx, anomalyBaselinesKey)
+	cooldownsCmd := pipe.HGetAll(ctx, anomalyCooldownsKey)
+	stateCmd := pipe.HGetAll(ctx, anomalyStateKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load anomaly state: %w", err)
+	}
+
+	st := &anomalyState{
+		baselines: make(map[string]*baselineStat),
+		cooldowns: make(map[string]time.Time),
+	}
+	for series, v := range baselinesCmd.Val() {
+		if stat, err := parseBaselineStat(v); err == nil {
+			st.baselines[series] = stat
+		}
+	}
+	for key, v := range cooldownsCmd.Val() {
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			st.cooldowns[key] = time.Unix(ts, 0)
+		}
+	}
+	state := stateCmd.Val()
+	if ts, err := strconv.ParseInt(state["last_minute"], 10, 64); err == nil {
+		st.lastMinute = time.Unix(ts, 0)
+	}
+	if ts, err := strconv.ParseInt(state["stream_moved"], 10, 64); err == nil {
+		st.streamMoved = time.Unix(ts, 0)
+	}
+	st.streamEdits, _ = strconv.ParseInt(state["stream_edits"], 10, 64)
+	st.stallAlerted = state["stall_alerted"] == "1"
+	return st, nil
+}
+
+// saveState writes the state back for the next check, dropping baselines
+// that have decayed to nothing (a wiki nobody edits any more) so the hash
+// stays bounded.
+func (d *AggregateAnomalyDetector) saveState(ctx context.Context, st *anomalyState) error {
+	values := make([]interface{}, 0, 2*len(st.baselines))
+	var stale []string
+	for series, stat := range st.baselines {
+		if series != storage.SeriesTotal && stat.N >= int64(d.cfg.MinSamples) && stat.Mean < 0.001 {
+			stale = append(stale, series)
+			continue
+		}
+		values = append(values, series, stat.String())
+	}
+
+	pipe := d.redis.TxPipeline()
+	if len(values) > 0 {
+		pipe.HSet(ctx, anomalyBaselinesKey, values...)
+	}
+	if len(stale) > 0 {
+		pipe.HDel(ctx, anomalyBaselinesKey, stale...)
+	}
+	pipe.Del(ctx, anomalyCooldownsKey)
+	for key, last := range st.cooldowns {
+		pipe.HSet(ctx, anomalyCooldownsKey, key, last.Unix())
+	}
+	stallAlerted := 0
+	if st.stallAlerted {
+		stallAlerted = 1
+	}
+	state := []interface{}{
+		"stream_edits", st.streamEdits,
+		"stream_moved", st.streamMoved.Unix(),
+		"stall_alerted", stallAlerted,
+	}
+	if !st.lastMinute.IsZero() {
+		state = append(state, "last_minute", st.lastMinute.Unix())
+	}
+	pipe.HSet(ctx, anomalyStateKey, state...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save anomaly state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type anomalyTestRig struct {
+	detector *AggregateAnomalyDetector
+	replicas []*AggregateAnomalyDetector // further processors' detectors, if any
+	stats    *storage.StatsTracker
+	alerts   *storage.RedisAlerts
+	clock    *storage.MockTimeProvider
+	wall     *storage.MockTimeProvider
+	start    time.Time
+}
+
+// setupAnomalyDetector creates a detector, and replicas more sharing its
+// Redis and clocks as other processors would.
+func setupAnomalyDetector(t *testing.T, replicas int) *anomalyTestRig {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{Processor: config.Processor{Anomaly: config.AnomalyDetectionConfig{
+		Enabled:         true,
+		Interval:        time.Minute,
+		Window:          5 * time.Minute,
+		SurgeRatio:      2.0,
+		ZScoreThreshold: 4.0,
+		DropRatio:       0.3,
+		StallAfter:      5 * time.Minute,
+		MinRate:         5.0,
+		EWMAAlpha:       0.1,
+		MinSamples:      20,
+		Cooldown:        30 * time.Minute,
+	}}}
+
+	stats := storage.NewStatsTracker(client)
+	alerts := storage.NewRedisAlerts(client)
+	d := NewAggregateAnomalyDetector(stats, alerts, client, cfg, zerolog.New(zerolog.NewTestWriter(t)))
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	clock, wall := storage.NewMockTimeProvider(), storage.NewMockTimeProvider()
+	clock.SetTime(start)
+	wall.SetTime(start)
+	d.SetTimeProvider(clock)
+	d.wall = wall
+
+	rig := &anomalyTestRig{detector: d, stats: stats, alerts: alerts, clock: clock, wall: wall, start: start}
+	for i := 0; i < replicas; i++ {
+		replica := NewAggregateAnomalyDetector(stats, alerts, client, cfg, zerolog.New(zerolog.NewTestWriter(t)))
+		replica.SetTimeProvider(clock)
+		replica.wall = wall
+		rig.replicas = append(rig.replicas, replica)
+	}
+	return rig
+}
+
+// runMinutes records edits per wiki for each minute from minute `from`, then
+// advances both clocks past the minute and ticks the detector.
+func (r *anomalyTestRig) runMinutes(t *testing.T, from, n int, perWiki map[string]int, isNew bool) {
+	t.Helper()
+	ctx := context.Background()
+	for i := from; i < from+n; i++ {
+		minute := r.start.Add(time.Duration(i) * time.Minute)
+		for wiki, count := range perWiki {
+			for e := 0; e < count; e++ {
+				require.NoError(t, r.stats.RecordStreamEditAt(ctx, wiki, 0, isNew, minute.Add(time.Duration(e)*time.Second)))
+			}
+		}
+		now := minute.Add(time.Minute + anomalySettle)
+		r.clock.SetTime(now)
+		r.wall.SetTime(now)
+		// Processors take turns to tick first
+		detectors := append([]*AggregateAnomalyDetector{r.detector}, r.replicas...)
+		for j := range detectors {
+			require.NoError(t, detectors[(i+j)%len(detectors)].tick(ctx))
+		}
+	}
+}
+
+func (r *anomalyTestRig) recentAlerts(t *testing.T, stream string) []storage.Alert {
+	t.Helper()
+	alerts, err := r.alerts.GetRecentAlerts(context.Background(), stream, 50)
+	require.NoError(t, err)
+	return alerts
+}
+
+func TestAggregateAnomaly_WikiSurge(t *testing.T) {
+	rig := setupAnomalyDetector(t, 0)
+
+	rig.runMinutes(t, 0, 30, map[string]int{"enwiki": 10, "dewiki": 10}, false)
+	assert.Empty(t, rig.recentAlerts(t, "wikisurges"), "steady traffic must not alert")
+
+	// English Wikipedia quadruples, German stays put
+	rig.runMinutes(t, 30, 5, map[string]int{"enwiki": 40, "dewiki": 10}, false)
+
+	surges := rig.recentAlerts(t, "wikisurges")
+	require.NotEmpty(t, surges)
+
+	byScope := map[string]storage.Alert{}
+	for _, a := range surges {
+		assert.Equal(t, storage.AlertTypeWikiSurge, a.Type)
+		assert.NotEqual(t, "dewiki", a.Data["wiki"], "dewiki did not surge")
+		key := a.Data["scope"].(string)
+		if wiki, ok := a.Data["wiki"].(string); ok {
+			key += ":" + wiki
+		}
+		byScope[key] = a
+	}
+
+	en, ok := byScope["wiki:enwiki"]
+	require.True(t, ok, "expected a wiki_surge for enwiki, got %v", byScope)
+	assert.InDelta(t, 10.0, en.Data["expected_rate"].(float64), 1.0)
+	assert.NotEmpty(t, en.Data["severity"])
+
+	// Repeat alerts for the same series are suppressed during the cooldown
+	count := len(surges)
+	rig.runMinutes(t, 35, 2, map[string]int{"enwiki": 40, "dewiki": 10}, false)
+	assert.Len(t, rig.recentAlerts(t, "wikisurges"), count)
+}
+
+func TestAggregateAnomaly_NewPageBurst(t *testing.T) {
+	rig := setupAnomalyDetector(t, 0)
+
+	rig.runMinutes(t, 0, 25, map[string]int{"enwiki": 6}, true)
+	rig.runMinutes(t, 25, 5, map[string]int{"enwiki": 30}, true)
+
+	found := false
+	for _, a := range rig.recentAlerts(t, "wikisurges") {
+		if a.Data["scope"] == "new_pages" {
+			found = true
+		}
+	}
+	assert.True(t, found, "expected a new_pages surge")
+}
+
+func TestAggregateAnomaly_StreamDrop(t *testing.T) {
+	rig := setupAnomalyDetector(t, 0)
+
+	rig.runMinutes(t, 0, 30, map[string]int{"enwiki": 10, "dewiki": 10}, false)
+	// The ingestor breaks: minutes keep completing but carry no edits, as
+	// they do when the clock is the wall clock
+	rig.runMinutes(t, 30, 5, nil, false)
+
+	drops := rig.recentAlerts(t, "streamdrops")
+	require.Len(t, drops, 2)
+	drop, stall := drops[1], drops[0] // newest first
+	assert.Equal(t, storage.AlertTypeStreamDrop, drop.Type)
+	assert.Equal(t, "global", drop.Data["scope"])
+	assert.Equal(t, "rate_drop", drop.Data["reason"])
+	// Nothing has been counted for stall_after either
+	assert.Equal(t, "stalled", stall.Data["reason"])
+	assert.Empty(t, rig.recentAlerts(t, "wikisurges"))
+}
+
+func TestAggregateAnomaly_StalledEventClock(t *testing.T) {
+	rig := setupAnomalyDetector(t, 0)
+	ctx := context.Background()
+
+	rig.runMinutes(t, 0, 30, map[string]int{"enwiki": 10}, false)
+
+	// No edits arrive, so the event clock stops while wall time moves on
+	for i := 1; i <= 6; i++ {
+		rig.wall.AdvanceTime(time.Minute)
+		require.NoError(t, rig.detector.tick(ctx))
+	}
+
+	drops := rig.recentAlerts(t, "streamdrops")
+	require.Len(t, drops, 1)
+	assert.Equal(t, "stalled", drops[0].Data["reason"])
+	assert.Equal(t, "critical", drops[0].Data["severity"])
+}
+
+func TestAggregateAnomaly_ReplicasShareChecks(t *testing.T) {
+	single := setupAnomalyDetector(t, 0)
+	shared := setupAnomalyDetector(t, 2)
+
+	for _, rig := range []*anomalyTestRig{single, shared} {
+		rig.runMinutes(t, 0, 30, map[string]int{"enwiki": 10, "dewiki": 10}, false)
+		rig.runMinutes(t, 30, 7, map[string]int{"enwiki": 40, "dewiki": 10}, false)
+	}
+
+	// Each minute is evaluated once, by whichever processor claimed it, so
+	// three processors raise the same alerts as one
+	want := single.recentAlerts(t, "wikisurges")
+	got := shared.recentAlerts(t, "wikisurges")
+	require.NotEmpty(t, want)
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Data, got[i].Data)
+	}
+
+	// A processor ticking in an interval another has claimed leaves it be,
+	// even if its clock says more minutes are complete
+	ctx := context.Background()
+	before, err := shared.detector.redis.HGetAll(ctx, anomalyStateKey).Result()
+	require.NoError(t, err)
+	shared.clock.AdvanceTime(3 * time.Minute)
+	require.NoError(t, shared.replicas[0].tick(ctx))
+	after, err := shared.detector.redis.HGetAll(ctx, anomalyStateKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestAggregateAnomaly_StalledWallClock(t *testing.T) {
+	rig := setupAnomalyDetector(t, 0)
+	ctx := context.Background()
+	// Without event time the detector runs on the wall clock
+	rig.detector.SetTimeProvider(rig.wall)
+
+	rig.runMinutes(t, 0, 30, map[string]int{"enwiki": 10}, false)
+	for i := 1; i <= 6; i++ {
+		rig.wall.AdvanceTime(time.Minute)
+		require.NoError(t, rig.detector.tick(ctx))
+	}
+
+	var stalls int
+	for _, a := range rig.recentAlerts(t, "streamdrops") {
+		if a.Data["reason"] == "stalled" {
+			stalls++
+		}
+	}
+	assert.Equal(t, 1, stalls)
+}
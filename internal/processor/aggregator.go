@@ -25,6 +25,8 @@ type TrendingAggregator struct {
 	config       *config.Config
 	metrics      *AggregatorMetrics
 	logger       zerolog.Logger
+	dedup        *storage.EditDeduplicator
+	clock        *storage.EventClock
 }
 
 // AggregatorMetrics contains metrics for the trending aggregator
@@ -93,9 +95,16 @@ func newTrendingAggregator(scorer *storage.TrendingScorer, statsTracker *storage
 		config:       cfg,
 		metrics:      metrics,
 		logger:       logger.With().Str("component", "trending-aggregator").Logger(),
+		clock:        storage.NewEventClock("trending-aggregator", cfg.Processor.EventTime),
 	}
 }
 
+// Clock returns the aggregator's event clock, which the trending scorer uses
+// for decay so scores age with the stream rather than the wall clock.
+func (t *TrendingAggregator) Clock() *storage.EventClock {
+	return t.clock
+}
+
 // ProcessMessage processes a single Kafka message containing Wikipedia edit data
 func (t *TrendingAggregator) ProcessMessage(message []byte) error {
 	timer := prometheus.NewTimer(t.metrics.UpdateLatency)
@@ -125,23 +134,49 @@ func (t *TrendingAggregator) ProcessMessage(message []byte) error {
 
 // ProcessEdit updates trending scores for a single edit (implements MessageHandler)
 func (t *TrendingAggregator) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return t.dedup.Process(ctx, "trending-aggregator", edit, func() error {
+		return t.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit ignore replayed revisions so trending
+// scores and the stats timeline only count each edit once.
+func (t *TrendingAggregator) SetDeduplicator(d *storage.EditDeduplicator) {
+	t.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (t *TrendingAggregator) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	at, ok := t.clock.Observe(edit)
+	if !ok {
+		return nil // late edit, dropped per the event time config
+	}
+
 	// Process the edit through the scorer
 	if err := t.scorer.ProcessEdit(edit); err != nil {
 		return fmt.Errorf("failed to update trending score: %w", err)
 	}
 
-	// Record per-language and timeline stats
+	// Record per-project, per-language and timeline stats
 	if t.statsTracker != nil {
-		lang := edit.Language()
+		project, lang := models.ParseWikiDBName(edit.Wiki)
+		if project == "" {
+			project = "unknown"
+		}
 		if lang == "" {
 			lang = "unknown"
 		}
-		if err := t.statsTracker.RecordEdit(ctx, lang, edit.Bot); err != nil {
+		if err := t.statsTracker.RecordEditAt(ctx, project, lang, edit.Bot, at); err != nil {
 			t.logger.Warn().Err(err).Msg("Failed to record edit stats")
 		}
+		// Per-minute wiki, namespace and page creation counts for the
+		// aggregate anomaly detector
+		if err := t.statsTracker.RecordStreamEditAt(ctx, edit.Wiki, edit.Namespace, edit.Type == "new", at); err != nil {
+			t.logger.Warn().Err(err).Msg("Failed to record stream minute counts")
+		}
 		// Record per-page daily counter for digest watchlist
-		if err := t.statsTracker.RecordPageEdit(ctx, edit.Title); err != nil {
-			t.logger.Warn().Err(err).Str("title", edit.Title).Msg("Failed to record page edit stats")
+		if err := t.statsTracker.RecordPageEditAt(ctx, edit.PageKey(), at); err != nil {
+			t.logger.Warn().Err(err).Str("page", edit.PageKey().String()).Msg("Failed to record page edit stats")
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -184,6 +185,58 @@ func TestTrendingAggregator_ProcessEdit(t *testing.T) {
 	assert.Equal(t, 0.5, entries[3].CurrentScore)
 }
 
+func TestTrendingAggregator_SkipsDuplicateRevisions(t *testing.T) {
+	aggregator, mr := setupTestTrendingAggregator(t)
+	defer mr.Close()
+	defer aggregator.scorer.Stop()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	aggregator.SetDeduplicator(storage.NewEditDeduplicator(client, &config.DedupConfig{Enabled: true, Window: time.Minute}))
+
+	edit := &models.WikipediaEdit{Title: "Replayed Page", Type: "edit", Wiki: "enwiki"}
+	edit.Revision.New = 1001
+	edit.Length.Old, edit.Length.New = 100, 200
+
+	// Kafka redelivery: the same revision arrives three times
+	for i := 0; i < 3; i++ {
+		require.NoError(t, aggregator.ProcessEdit(context.Background(), edit))
+	}
+
+	entries, err := aggregator.scorer.GetTopTrending(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1.0, entries[0].CurrentScore, "a replayed revision is scored once")
+}
+
+func TestTrendingAggregator_ReplayUsesEventTime(t *testing.T) {
+	aggregator, mr := setupTestTrendingAggregator(t)
+	defer mr.Close()
+	defer aggregator.scorer.Stop()
+
+	aggregator.clock = storage.NewEventClock("trending-aggregator", config.EventTimeConfig{
+		Enabled:    true,
+		LateEvents: "accept",
+	})
+	aggregator.scorer.SetTimeProvider(aggregator.Clock())
+
+	// Replaying yesterday: two edits half an hour apart, one half-life.
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Minute)
+	for _, at := range []time.Time{start, start.Add(30 * time.Minute)} {
+		edit := &models.WikipediaEdit{Title: "Replayed Page", Type: "edit", Wiki: "enwiki", Timestamp: at.Unix()}
+		edit.Length.Old, edit.Length.New = 100, 200
+		require.NoError(t, aggregator.ProcessEdit(context.Background(), edit))
+	}
+
+	assert.Equal(t, start.Add(30*time.Minute), aggregator.Clock().Now(), "watermark follows the replayed edits")
+
+	entries, err := aggregator.scorer.GetTopTrending(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	// The first edit decayed by one half-life of event time before the second
+	assert.InDelta(t, 1.5, entries[0].CurrentScore, 0.001)
+}
+
 func TestTrendingAggregator_GetMetrics(t *testing.T) {
 	aggregator, mr := setupTestTrendingAggregator(t)
 	defer mr.Close()
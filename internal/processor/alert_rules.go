@@ -0,0 +1,262 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// RuleEvaluator is a Kafka MessageHandler that checks every edit against
+// users' alert rules and publishes each match to the rule owner's personal
+// alert stream.
+//
+// Rules and the watchlists they refer to are read from the user database
+// and reloaded every refresh interval, so edits are never held up on it.
+// For rules with edits() or editors() clauses, the matching edits of each
+// page are kept in Redis for the rule's longest window: a sorted set of
+// revisions and one of editors, both scored by edit time. A rule that
+// matches many pages keeps a pair for each, so such rules should be
+// narrowed by wiki or title.
+type RuleEvaluator struct {
+	users  *storage.UserStore
+	alerts *storage.RedisAlerts
+	redis  *redis.Client
+	cfg    config.AlertRulesConfig
+	dedup  *storage.EditDeduplicator
+	clock  *storage.EventClock
+	logger zerolog.Logger
+
+	mu       sync.Mutex
+	rules    []activeRule
+	loadedAt time.Time
+}
+
+// activeRule is an enabled rule ready to evaluate.
+type activeRule struct {
+	*models.AlertRule
+	compiled  *models.CompiledRule
+	watchlist map[string]bool // owner's watchlist, for rules using it
+}
+
+// NewRuleEvaluator creates an evaluator for the rules in users.
+func NewRuleEvaluator(users *storage.UserStore, redisClient *redis.Client, alerts *storage.RedisAlerts, cfg *config.Config, logger zerolog.Logger) *RuleEvaluator {
+	return &RuleEvaluator{
+		users:  users,
+		alerts: alerts,
+		redis:  redisClient,
+		cfg:    cfg.Processor.AlertRules,
+		clock:  storage.NewEventClock("rule-evaluator", cfg.Processor.EventTime),
+		logger: logger.With().Str("component", "rule-evaluator").Logger(),
+	}
+}
+
+// ProcessEdit implements kafka.MessageHandler.
+func (re *RuleEvaluator) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return re.dedup.Process(ctx, "rule-evaluator", edit, func() error {
+		return re.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit skip revisions already evaluated.
+func (re *RuleEvaluator) SetDeduplicator(d *storage.EditDeduplicator) {
+	re.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (re *RuleEvaluator) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	if edit.Type != "edit" && edit.Type != "new" {
+		return nil
+	}
+
+	at, ok := re.clock.Observe(edit)
+	if !ok {
+		return nil
+	}
+	for _, r := range re.activeRules() {
+		if !r.compiled.MatchEdit(edit, r.watchlist[edit.Title]) {
+			continue
+		}
+		counts, err := re.windowCounts(ctx, r, edit, at)
+		if err != nil {
+			return err
+		}
+		if !r.compiled.MatchCounts(counts) {
+			continue
+		}
+		if err := re.alert(ctx, r, edit, at, counts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeRules returns the rules to evaluate, reloading them first when the
+// refresh interval has passed. If the reload fails the previous rules are
+// kept until the next one.
+func (re *RuleEvaluator) activeRules() []activeRule {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	if now := time.Now(); now.Sub(re.loadedAt) >= re.cfg.RefreshInterval {
+		re.loadedAt = now
+		rules, err := re.loadRules()
+		if err != nil {
+			re.logger.Warn().Err(err).Msg("Failed to reload alert rules")
+		} else {
+			re.rules = rules
+		}
+	}
+	return re.rules
+}
+
+// loadRules reads and compiles every enabled rule. Rules that no longer
+// parse, or whose owner's watchlist cannot be read, are skipped.
+func (re *RuleEvaluator) loadRules() ([]activeRule, error) {
+	rules, err := re.users.ListEnabledAlertRules()
+	if err != nil {
+		return nil, err
+	}
+
+	watchlists := make(map[string]map[string]bool)
+	active := make([]activeRule, 0, len(rules))
+	for _, r := range rules {
+		compiled, err := models.ParseRule(r.Expression)
+		if err != nil {
+			re.logger.Warn().Err(err).Str("rule_id", r.ID).Msg("Skipping invalid alert rule")
+			continue
+		}
+		a := activeRule{AlertRule: r, compiled: compiled}
+		if compiled.UsesWatchlist() {
+			wl, ok := watchlists[r.UserID]
+			if !ok {
+				user, err := re.users.GetUserByID(r.UserID)
+				if err != nil {
+					re.logger.Warn().Err(err).Str("rule_id", r.ID).Str("user_id", r.UserID).Msg("Skipping alert rule: failed to read watchlist")
+					continue
+				}
+				wl = make(map[string]bool)
+				if user != nil {
+					for _, title := range user.Watchlist {
+						wl[title] = true
+					}
+				}
+				watchlists[r.UserID] = wl
+			}
+			a.watchlist = wl
+		}
+		active = append(active, a)
+	}
+	return active, nil
+}
+
+// windowCounts records edit as a match of r on its page and returns the
+// page's matching edits and editors over each of r's windows ending at at.
+// It returns nil for rules without windows.
+func (re *RuleEvaluator) windowCounts(ctx context.Context, r activeRule, edit *models.WikipediaEdit, at time.Time) (map[time.Duration]models.WindowCounts, error) {
+	windows := r.compiled.Windows()
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	page := edit.PageKey()
+	editsKey := fmt.Sprintf("rules:%s:edits:%s", r.ID, page)
+	editorsKey := fmt.Sprintf("rules:%s:editors:%s", r.ID, page)
+	revision := edit.Revision.New
+	if revision == 0 {
+		revision = edit.ID
+	}
+	ts := at.Unix()
+	longest := windows[len(windows)-1]
+	cutoff := "(" + strconv.FormatInt(ts-int64(longest/time.Second), 10)
+
+	pipe := re.redis.Pipeline()
+	pipe.ZAdd(ctx, editsKey, redis.Z{Score: float64(ts), Member: revision})
+	// An editor counts from their latest edit, even if this one arrived late
+	pipe.ZAddArgs(ctx, editorsKey, redis.ZAddArgs{GT: true, Members: []redis.Z{{Score: float64(ts), Member: edit.User}}})
+	for _, key := range []string{editsKey, editorsKey} {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", cutoff)
+		pipe.Expire(ctx, key, longest)
+	}
+	edits := make([]*redis.IntCmd, len(windows))
+	editors := make([]*redis.IntCmd, len(windows))
+	for i, w := range windows {
+		from := strconv.FormatInt(ts-int64(w/time.Second), 10)
+		edits[i] = pipe.ZCount(ctx, editsKey, from, "+inf")
+		editors[i] = pipe.ZCount(ctx, editorsKey, from, "+inf")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count alert rule matches: %w", err)
+	}
+
+	counts := make(map[time.Duration]models.WindowCounts, len(windows))
+	for i, w := range windows {
+		counts[w] = models.WindowCounts{Edits: int(edits[i].Val()), Editors: int(editors[i].Val())}
+	}
+	return counts, nil
+}
+
+// alert publishes a match of r to its owner, unless r has already alerted
+// on the page within the cooldown.
+func (re *RuleEvaluator) alert(ctx context.Context, r activeRule, edit *models.WikipediaEdit, at time.Time, counts map[time.Duration]models.WindowCounts) error {
+	cooldownKey := fmt.Sprintf("rules:%s:cooldown:%s", r.ID, edit.PageKey())
+	first, err := re.redis.SetNX(ctx, cooldownKey, 1, re.cfg.Cooldown).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check alert rule cooldown: %w", err)
+	}
+	if !first {
+		return nil
+	}
+
+	m := storage.RuleMatch{
+		UserID:     r.UserID,
+		RuleID:     r.ID,
+		RuleName:   r.Name,
+		Expression: r.Expression,
+		Wiki:       edit.Wiki,
+		Title:      edit.Title,
+		ServerURL:  edit.ServerURL,
+		User:       edit.User,
+		RevisionID: edit.Revision.New,
+		ByteChange: edit.ByteChange(),
+		At:         at.UTC(),
+	}
+	if len(counts) > 0 {
+		m.Counts = make(map[string]int, 2*len(counts))
+		for w, c := range counts {
+			m.Counts["edits_"+windowLabel(w)] = c.Edits
+			m.Counts["editors_"+windowLabel(w)] = c.Editors
+		}
+	}
+
+	if err := re.alerts.PublishRuleAlert(ctx, m); err != nil {
+		return fmt.Errorf("failed to publish rule alert: %w", err)
+	}
+	metrics.RuleAlertsTotal.Inc()
+	re.logger.Debug().
+		Str("rule_id", r.ID).
+		Str("user_id", r.UserID).
+		Str("page", edit.PageKey().String()).
+		Msg("Alert rule matched")
+	return nil
+}
+
+// windowLabel writes a rule window compactly, e.g. 10m or 1h.
+func windowLabel(w time.Duration) string {
+	switch {
+	case w%time.Hour == 0:
+		return fmt.Sprintf("%dh", w/time.Hour)
+	case w%time.Minute == 0:
+		return fmt.Sprintf("%dm", w/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", w/time.Second)
+	}
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleEvaluator_ProcessEdit(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	users, err := storage.NewUserStore(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { users.Close() })
+
+	analyst, err := users.CreateUser("analyst@example.com", "pw")
+	require.NoError(t, err)
+	require.NoError(t, users.UpdateWatchlist(analyst.ID, []string{"Paris"}))
+	elections, err := users.CreateAlertRule(analyst.ID, "Elections",
+		`wiki = enwiki and title ~ "^2026 .* election" and edits(10m) > 20 and editors(10m) > 3`, true)
+	require.NoError(t, err)
+	_, err = users.CreateAlertRule(analyst.ID, "Blanking", "watched and bytes < -5000", true)
+	require.NoError(t, err)
+	_, err = users.CreateAlertRule(analyst.ID, "Paused", "new", false)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Processor: config.Processor{
+		EventTime: config.EventTimeConfig{Enabled: true, AllowedLateness: time.Hour},
+		AlertRules: config.AlertRulesConfig{
+			Enabled: true, MaxRulesPerUser: 20, RefreshInterval: time.Minute, Cooldown: 30 * time.Minute,
+		},
+	}}
+	alerts := storage.NewRedisAlerts(client)
+	evaluator := NewRuleEvaluator(users, client, alerts, cfg, zerolog.Nop())
+	ctx := context.Background()
+
+	start := time.Now().Add(-20 * time.Minute).Unix()
+	electionEdit := func(i int, user string) *models.WikipediaEdit {
+		e := makeEdit(int64(1000+2*i), "2026 Brazilian general election", user, 100, 120)
+		e.Timestamp = start + int64(i)*20
+		return e
+	}
+
+	// An edit before the window, then 21 in it by three editors: not enough editors
+	require.NoError(t, evaluator.ProcessEdit(ctx, electionEdit(-40, "Dora")))
+	for i := 0; i < 21; i++ {
+		require.NoError(t, evaluator.ProcessEdit(ctx, electionEdit(i, []string{"Ann", "Ben", "Cal"}[i%3])))
+	}
+	// Other wikis and titles are not counted
+	other := electionEdit(21, "Dora")
+	other.Wiki = "dewiki"
+	require.NoError(t, evaluator.ProcessEdit(ctx, other))
+	require.NoError(t, evaluator.ProcessEdit(ctx, makeEdit(2000, "2024 United States election", "Dora", 0, 10)))
+
+	got, err := alerts.GetUserAlerts(ctx, analyst.ID, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// A fourth editor makes it a match; later edits are within the cooldown
+	require.NoError(t, evaluator.ProcessEdit(ctx, electionEdit(22, "Dora")))
+	require.NoError(t, evaluator.ProcessEdit(ctx, electionEdit(23, "Eve")))
+
+	// Large removals from watched pages only
+	require.NoError(t, evaluator.ProcessEdit(ctx, makeEdit(3000, "Berlin", "Vandal", 9000, 1000)))
+	require.NoError(t, evaluator.ProcessEdit(ctx, makeEdit(3002, "Paris", "Vandal", 9000, 1000)))
+
+	got, err = alerts.GetUserAlerts(ctx, analyst.ID, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	blanking, election := got[0], got[1] // newest first
+	assert.Equal(t, storage.AlertTypeRuleMatch, election.Type)
+	assert.Equal(t, elections.ID, election.Data["rule_id"])
+	assert.Equal(t, "Dora", election.Data["user"])
+	assert.Equal(t, map[string]interface{}{"edits_10m": float64(22), "editors_10m": float64(4)}, election.Data["counts"])
+	assert.Equal(t, "Blanking", blanking.Data["rule_name"])
+	assert.Equal(t, "Paris", blanking.Data["title"])
+	assert.Equal(t, float64(-8000), blanking.Data["byte_change"])
+
+	// Disabled rules are never evaluated, and deleted ones stop at the next reload
+	mr.FlushAll()
+	require.NoError(t, users.DeleteAlertRule(analyst.ID, elections.ID))
+	evaluator.loadedAt = time.Time{}
+	created := makeEdit(4000, "2026 Fijian general election", "Fay", 0, 500)
+	created.Type = "new"
+	for i := 0; i < 25; i++ {
+		created.Revision.New = int64(4001 + i)
+		created.User = fmt.Sprintf("Editor %d", i)
+		require.NoError(t, evaluator.ProcessEdit(ctx, created))
+	}
+	got, err = alerts.GetUserAlerts(ctx, analyst.ID, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestRuleEvaluator_SkipsRulesOfUnreadableUsers(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	dbPath := filepath.Join(t.TempDir(), "users.db")
+	users, err := storage.NewUserStore(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { users.Close() })
+
+	good, err := users.CreateUser("good@example.com", "pw")
+	require.NoError(t, err)
+	require.NoError(t, users.UpdateWatchlist(good.ID, []string{"Paris"}))
+	_, err = users.CreateAlertRule(good.ID, "Blanking", "watched and bytes < -5000", true)
+	require.NoError(t, err)
+	broken, err := users.CreateUser("broken@example.com", "pw")
+	require.NoError(t, err)
+	_, err = users.CreateAlertRule(broken.ID, "Blanking", "watched and bytes < -5000", true)
+	require.NoError(t, err)
+
+	// A row that no longer scans makes the owner's watchlist unreadable
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`UPDATE users SET spike_threshold = 'high' WHERE id = ?`, broken.ID)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Processor: config.Processor{AlertRules: config.AlertRulesConfig{
+		Enabled: true, MaxRulesPerUser: 20, RefreshInterval: time.Minute, Cooldown: 30 * time.Minute,
+	}}}
+	alerts := storage.NewRedisAlerts(client)
+	evaluator := NewRuleEvaluator(users, client, alerts, cfg, zerolog.Nop())
+	ctx := context.Background()
+
+	require.NoError(t, evaluator.ProcessEdit(ctx, makeEdit(3000, "Paris", "Vandal", 9000, 1000)))
+	require.Len(t, evaluator.rules, 1)
+
+	got, err := alerts.GetUserAlerts(ctx, good.ID, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
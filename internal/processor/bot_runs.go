@@ -0,0 +1,288 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Cadence signals for accounts editing like a bot without the bot flag.
+// Like the vandalism signals they combine as 1 - Π(1 - weight): speed or a
+// repeated summary alone is what a busy human looks like too, but speed at a
+// near-constant interval is not.
+const (
+	weightBotRate      = 0.35
+	weightBotRegular   = 0.55
+	weightBotSummary   = 0.35
+	weightBotSustained = 0.3
+
+	botRateThreshold   = 6.0 // edits per minute, over the whole run
+	botMaxIntervalCV   = 0.5 // humans' gaps between edits vary far more
+	botMinSummaryShare = 0.8
+	botSustainedRun    = 30 * time.Minute
+
+	maxRunSummaryLen = 100
+)
+
+var summaryDigits = regexp.MustCompile(`[0-9]+`)
+
+// BotRunTracker collapses bursts of edits by one account into bot runs for
+// the live feed. The WebSocketForwarder hands it every edit; once an account
+// makes MinEdits edits within RateWindow, its edits are absorbed into a run
+// and the feed gets a bot_run summary instead: when the run starts, every
+// SummaryInterval while it continues, and once more when IdleGap passes
+// without an edit and it ends.
+//
+// Runs by accounts without the bot flag are scored on their cadence, and
+// accounts that edit like bots are listed for review as suspected bots.
+type BotRunTracker struct {
+	store   *storage.BotRunStore
+	cfg     config.BotRunConfig
+	clock   *storage.EventClock
+	publish func(run *storage.BotRun)
+	logger  zerolog.Logger
+}
+
+// NewBotRunTracker creates a bot run tracker. Runs are not published until
+// SetPublisher is called.
+func NewBotRunTracker(store *storage.BotRunStore, cfg *config.Config, logger zerolog.Logger) *BotRunTracker {
+	return &BotRunTracker{
+		store:   store,
+		cfg:     cfg.Processor.BotRuns,
+		clock:   storage.NewEventClock("bot-run-tracker", cfg.Processor.EventTime),
+		publish: func(*storage.BotRun) {},
+		logger:  logger.With().Str("component", "bot-run-tracker").Logger(),
+	}
+}
+
+// SetPublisher sets the function that delivers run summaries to the feed.
+func (t *BotRunTracker) SetPublisher(publish func(run *storage.BotRun)) {
+	t.publish = publish
+}
+
+// Track counts an edit towards its account's cadence and reports whether it
+// was absorbed into a bot run, in which case it must not be sent to the feed
+// on its own.
+func (t *BotRunTracker) Track(ctx context.Context, edit *models.WikipediaEdit) (bool, error) {
+	at, ok := t.clock.Observe(edit)
+	if !ok || edit.User == "" || (edit.Type != "edit" && edit.Type != "new") {
+		return false, nil
+	}
+
+	count, active, err := t.store.CountEdit(ctx, edit.Wiki, edit.User, at)
+	if err != nil {
+		return false, err
+	}
+	if !active && count < int64(t.cfg.MinEdits) {
+		return false, nil
+	}
+
+	started, summarized, err := t.store.AddEdit(ctx, storage.BotRunEdit{
+		Wiki:    edit.Wiki,
+		User:    edit.User,
+		Title:   edit.Title,
+		Bot:     edit.Bot,
+		Summary: normalizeRunSummary(edit.Comment),
+		At:      at,
+	})
+	if err != nil {
+		return false, err
+	}
+	if started {
+		t.logger.Debug().Str("wiki", edit.Wiki).Str("user", edit.User).Msg("Bot run started")
+	}
+	if started || at.Sub(summarized) >= t.cfg.SummaryInterval {
+		if err := t.summarize(ctx, edit.Wiki, edit.User, at); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// summarize publishes the current state of a run in progress.
+func (t *BotRunTracker) summarize(ctx context.Context, wiki, user string, at time.Time) error {
+	run, err := t.store.GetRun(ctx, wiki, user)
+	if err != nil || run == nil {
+		return err
+	}
+	if err := t.classify(ctx, run, at); err != nil {
+		return err
+	}
+	if err := t.store.MarkSummarized(ctx, wiki, user, at); err != nil {
+		return err
+	}
+	t.publish(run)
+	return nil
+}
+
+// Start finishes idle runs every quarter of IdleGap until ctx is cancelled.
+func (t *BotRunTracker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(t.cfg.IdleGap / 4)
+		defer ticker.Stop()
+
+		t.logger.Info().Dur("idle_gap", t.cfg.IdleGap).Msg("Bot run sweeper started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				t.logger.Info().Msg("Bot run sweeper stopped")
+				return
+			case <-ticker.C:
+				if err := t.sweep(ctx); err != nil {
+					t.logger.Warn().Err(err).Msg("Bot run sweep failed")
+				}
+			}
+		}
+	}()
+}
+
+// sweep finishes every run without an edit in the last IdleGap, publishing
+// its final summary.
+func (t *BotRunTracker) sweep(ctx context.Context) error {
+	runs, err := t.store.IdleRuns(ctx, t.clock.Now().Add(-t.cfg.IdleGap))
+	if err != nil {
+		return err
+	}
+
+	for i := range runs {
+		run := &runs[i]
+		if err := t.classify(ctx, run, run.End); err != nil {
+			return err
+		}
+		if err := t.store.FinishRun(ctx, run); err != nil {
+			return err
+		}
+		t.publish(run)
+
+		account := "flagged"
+		if !run.Bot {
+			account = "unflagged"
+		}
+		metrics.BotRunsTotal.WithLabelValues(account).Inc()
+		t.logger.Info().
+			Str("wiki", run.Wiki).
+			Str("user", run.User).
+			Int64("edits", run.Edits).
+			Dur("duration", run.End.Sub(run.Start)).
+			Msg("Bot run finished")
+	}
+	return nil
+}
+
+// classify scores the cadence of a run by an account without the bot flag
+// and, if it edits like a bot, lists the account as a suspected bot.
+func (t *BotRunTracker) classify(ctx context.Context, run *storage.BotRun, at time.Time) error {
+	if run.Bot || run.Edits < int64(t.cfg.MinClassifyEdits) {
+		return nil
+	}
+	times, err := t.store.EditTimes(ctx, run.Wiki, run.User)
+	if err != nil {
+		return err
+	}
+
+	suspect := scoreCadence(run, times)
+	if suspect.Score < t.cfg.SuspectScore {
+		return nil
+	}
+	suspect.LastFlagged = at
+	if err := t.store.FlagSuspect(ctx, suspect); err != nil {
+		return err
+	}
+
+	if run.SuspectScore == 0 {
+		metrics.SuspectedBotsTotal.Inc()
+		t.logger.Info().
+			Str("wiki", run.Wiki).
+			Str("user", run.User).
+			Float64("score", suspect.Score).
+			Strs("reasons", suspect.Reasons).
+			Msg("Account without bot flag is editing like a bot")
+	}
+	run.SuspectScore = suspect.Score
+	return nil
+}
+
+// scoreCadence weighs the evidence that a run was made by a bot: its rate,
+// how regular the gaps between its edits are, how often it repeats one edit
+// summary and how long it went on.
+func scoreCadence(run *storage.BotRun, times []time.Time) *storage.SuspectedBot {
+	s := &storage.SuspectedBot{
+		Wiki:           run.Wiki,
+		User:           run.User,
+		Edits:          run.Edits,
+		EditsPerMinute: run.EditsPerMinute,
+		IntervalCV:     -1,
+		SummaryShare:   run.TopSummaryShare,
+	}
+	clean := 1.0
+	fire := func(weight float64, reason string) {
+		clean *= 1 - weight
+		s.Reasons = append(s.Reasons, reason)
+	}
+
+	if run.EditsPerMinute >= botRateThreshold {
+		fire(weightBotRate, fmt.Sprintf("%.1f edits per minute", run.EditsPerMinute))
+	}
+	if cv, ok := intervalCV(times); ok {
+		s.IntervalCV = math.Round(cv*1000) / 1000
+		if cv <= botMaxIntervalCV {
+			fire(weightBotRegular, fmt.Sprintf("regular gaps between edits (CV %.2f)", cv))
+		}
+	}
+	if run.TopSummaryShare >= botMinSummaryShare {
+		fire(weightBotSummary, fmt.Sprintf("%.0f%% of edits share one summary", 100*run.TopSummaryShare))
+	}
+	if d := run.End.Sub(run.Start); d >= botSustainedRun {
+		fire(weightBotSustained, fmt.Sprintf("editing without a break for %s", d.Round(time.Minute)))
+	}
+
+	s.Score = math.Round((1-clean)*1000) / 1000
+	return s
+}
+
+// intervalCV returns the coefficient of variation (standard deviation over
+// mean) of the gaps between consecutive edit times. It needs at least three
+// gaps to say anything.
+func intervalCV(times []time.Time) (float64, bool) {
+	if len(times) < 4 {
+		return 0, false
+	}
+	gaps := make([]float64, 0, len(times)-1)
+	var sum float64
+	for i := 1; i < len(times); i++ {
+		g := math.Abs(times[i].Sub(times[i-1]).Seconds())
+		gaps = append(gaps, g)
+		sum += g
+	}
+	mean := sum / float64(len(gaps))
+	if mean == 0 {
+		return 0, true
+	}
+	var variance float64
+	for _, g := range gaps {
+		variance += (g - mean) * (g - mean)
+	}
+	return math.Sqrt(variance/float64(len(gaps))) / mean, true
+}
+
+// normalizeRunSummary reduces an edit summary to what a bot's summaries
+// have in common: the section prefix is dropped and numbers are replaced by
+// #, so "Removing 3 dead links" and "Removing 12 dead links" count as one.
+func normalizeRunSummary(comment string) string {
+	s := strings.TrimSpace(summaryDigits.ReplaceAllString(stripSectionPrefix(comment), "#"))
+	if utf8.RuneCountInString(s) > maxRunSummaryLen {
+		s = string([]rune(s)[:maxRunSummaryLen])
+	}
+	return s
+}
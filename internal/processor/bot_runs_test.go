@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotRunTracker_CollapsesRuns(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{Processor: config.Processor{
+		EventTime: config.EventTimeConfig{Enabled: true},
+		BotRuns: config.BotRunConfig{
+			Enabled: true, MinEdits: 5, RateWindow: time.Minute, IdleGap: 2 * time.Minute,
+			SummaryInterval: 30 * time.Second, MinClassifyEdits: 20, SuspectScore: 0.7, SuspectTTL: 24 * time.Hour,
+		},
+	}}
+	store := storage.NewBotRunStore(client, cfg.Processor.BotRuns)
+	tracker := NewBotRunTracker(store, cfg, zerolog.Nop())
+	bc := &mockBroadcaster{}
+	f := NewWebSocketForwarder(bc, client, zerolog.Nop())
+	f.SetBotRunTracker(tracker)
+	ctx := context.Background()
+
+	base := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+	id := int64(1000)
+	edit := func(user string, bot bool, at time.Time, comment string) {
+		t.Helper()
+		id += 2
+		e := makeEdit(id, fmt.Sprintf("Page %d", id), user, 100, 120)
+		e.Bot, e.Timestamp, e.Comment = bot, at.Unix(), comment
+		require.NoError(t, f.ProcessEdit(ctx, e))
+	}
+
+	// An account without the bot flag edits every 5s with the same summary,
+	// a flagged bot makes a short run, and a human edits now and then
+	for i := 0; i < 40; i++ {
+		at := base.Add(time.Duration(i) * 5 * time.Second)
+		edit("Stealth", false, at, fmt.Sprintf("/* Links */ Removing %d dead links", i%7+1))
+		if i < 10 {
+			edit("SweepBot", true, at, "tagging")
+		}
+		if i%10 == 0 {
+			edit("Alice", false, at, "copyedit")
+		}
+	}
+
+	// Only the edits before each run started reach the feed on their own
+	assert.Len(t, bc.edits, 4+4+4)
+	require.NotEmpty(t, bc.botRuns)
+	first := bc.botRuns[0]
+	assert.True(t, first.Active)
+	assert.Equal(t, int64(1), first.Edits)
+
+	// An edit ten minutes on moves the event clock past both runs' idle gap
+	edit("Alice", false, base.Add(10*time.Minute), "copyedit")
+	require.NoError(t, tracker.sweep(ctx))
+
+	final := map[string]*storage.BotRun{}
+	for _, run := range bc.botRuns {
+		if !run.Active {
+			final[run.User] = run
+		}
+	}
+	require.Len(t, final, 2)
+
+	stealth := final["Stealth"]
+	assert.Equal(t, int64(36), stealth.Edits)
+	assert.Equal(t, "Removing # dead links", stealth.TopSummary)
+	assert.Equal(t, 1.0, stealth.TopSummaryShare)
+	assert.GreaterOrEqual(t, stealth.SuspectScore, 0.7)
+	assert.Equal(t, first.ID, stealth.ID)
+
+	sweep := final["SweepBot"]
+	assert.True(t, sweep.Bot)
+	assert.Equal(t, int64(6), sweep.Edits)
+	assert.Zero(t, sweep.SuspectScore, "flagged bots are not scored")
+
+	suspects, err := store.GetSuspects(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, suspects, 1)
+	assert.Equal(t, "Stealth", suspects[0].User)
+	assert.Contains(t, suspects[0].Reasons, "regular gaps between edits (CV 0.00)")
+
+	stats, err := store.GetStats(ctx, base)
+	require.NoError(t, err)
+	assert.Equal(t, storage.BotRunStats{Runs: 2, Edits: 42, Active: 0}, stats)
+
+	// Both runs are finished and listed
+	runs, err := store.GetRuns(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.False(t, runs[0].Active)
+}
+
+func TestScoreCadence(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	run := &storage.BotRun{
+		Wiki: "enwiki", User: "Busy", Edits: 40, EditsPerMinute: 8, TopSummaryShare: 0.9,
+		Start: start, End: start.Add(5 * time.Minute),
+	}
+
+	// A human working fast: the gaps between edits vary widely
+	var times []time.Time
+	at := start
+	for i := 0; i < 40; i++ {
+		at = at.Add(time.Duration(1+(i*7)%23) * time.Second)
+		times = append(times, at)
+	}
+	human := scoreCadence(run, times)
+	assert.Less(t, human.Score, 0.7)
+	assert.Greater(t, human.IntervalCV, botMaxIntervalCV)
+
+	// The same rate at a fixed interval is a bot
+	times = times[:0]
+	for i := 0; i < 40; i++ {
+		times = append(times, start.Add(time.Duration(i)*7500*time.Millisecond))
+	}
+	bot := scoreCadence(run, times)
+	assert.InDelta(t, 1-(1-weightBotRate)*(1-weightBotRegular)*(1-weightBotSummary), bot.Score, 0.001)
+	assert.Len(t, bot.Reasons, 3)
+
+	assert.Equal(t, "Removing # dead links", normalizeRunSummary("/* Links */ Removing 12 dead links"))
+}
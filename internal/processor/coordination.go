@@ -0,0 +1,473 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// Weights of the coordination signals, each scaled by its strength and
+// combined as independent evidence the way vandalism signals are. Sharing
+// pages alone stays under the default min_score: regulars of a topic share
+// pages too. It takes synchronized edits, tag-team reverts or new accounts.
+const (
+	weightCoOccurrence = 0.4  // times the Jaccard overlap of the pair's hot pages
+	weightSyncTiming   = 0.45 // times their timing correlation
+	weightTagTeam      = 0.6  // times the tag-team pages, saturating at two
+)
+
+// Caps on the history kept per editor, so a prolific account costs no more
+// than a handful of hot pages' worth of Redis.
+const (
+	maxCoordinationPages = 500
+	maxCoordinationEdits = 1000
+)
+
+// CoordinationDetector maintains which editors edit which hot pages, and
+// when, and links pairs of accounts whose editing looks coordinated: they
+// keep turning up on the same pages, edit them within minutes of each other,
+// or revert the same opponents. Linked accounts form groups, and each new or
+// grown group is published as a coordinated_editing alert with the evidence.
+//
+// Pairs who revert each other are opponents, not a team, and are never
+// linked, however much their pages and timing overlap.
+type CoordinationDetector struct {
+	alerts   *storage.RedisAlerts
+	hotPages *storage.HotPageTracker
+	redis    *redis.Client
+	accounts *storage.AccountRegistry
+	cfg      config.CoordinationConfig
+	clock    *storage.EventClock
+	dedup    *storage.EditDeduplicator
+	logger   zerolog.Logger
+}
+
+// editorActivity is what the detector knows about one editor on one wiki.
+type editorActivity struct {
+	pages map[string]bool
+	edits map[string][]int64 // page title -> edit times
+	new   bool
+}
+
+// NewCoordinationDetector creates a coordinated editing detector.
+func NewCoordinationDetector(alerts *storage.RedisAlerts, hotPages *storage.HotPageTracker, redisClient *redis.Client, cfg *config.Config, logger zerolog.Logger) *CoordinationDetector {
+	return &CoordinationDetector{
+		alerts:   alerts,
+		hotPages: hotPages,
+		redis:    redisClient,
+		accounts: storage.NewAccountRegistry(redisClient, cfg.Processor.Coordination.NewAccountAge),
+		cfg:      cfg.Processor.Coordination,
+		clock:    storage.NewEventClock("coordination-detector", cfg.Processor.EventTime),
+		logger:   logger.With().Str("component", "coordination-detector").Logger(),
+	}
+}
+
+// ProcessEdit implements kafka.MessageHandler.
+func (cd *CoordinationDetector) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return cd.dedup.Process(ctx, "coordination-detector", edit, func() error {
+		return cd.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit skip revisions already recorded.
+func (cd *CoordinationDetector) SetDeduplicator(d *storage.EditDeduplicator) {
+	cd.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (cd *CoordinationDetector) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	at, ok := cd.clock.Observe(edit)
+	if !ok {
+		return nil
+	}
+	if edit.Bot || edit.IsLogEvent() || edit.User == "" {
+		return nil
+	}
+	if edit.Type != "edit" && edit.Type != "new" {
+		return nil
+	}
+
+	key := edit.PageKey()
+	hot, err := cd.hotPages.IsHot(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to check hot page: %w", err)
+	}
+	if !hot {
+		return nil
+	}
+
+	if err := cd.record(ctx, edit, at); err != nil {
+		return err
+	}
+
+	// The page's most recent editors are the candidates to pair with
+	coEditors, err := cd.redis.ZRevRange(ctx, coordKey("page", key.Wiki, key.Title), 0, int64(cd.cfg.MaxCandidates)-1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get co-editors: %w", err)
+	}
+	coEditors = slices.DeleteFunc(coEditors, func(u string) bool { return u == edit.User })
+	if len(coEditors) == 0 {
+		return nil
+	}
+
+	pairs, err := cd.scorePairs(ctx, edit.Wiki, edit.User, coEditors, at)
+	if err != nil || len(pairs) == 0 {
+		return err
+	}
+
+	pairsKey := coordKey("pairs", edit.Wiki)
+	pipe := cd.redis.Pipeline()
+	for _, p := range pairs {
+		raw, _ := json.Marshal(p)
+		pipe.HSet(ctx, pairsKey, pairKey(p.Users[0], p.Users[1]), string(raw))
+	}
+	pipe.Expire(ctx, pairsKey, cd.cfg.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to link coordinated accounts: %w", err)
+	}
+
+	group, err := cd.group(ctx, edit.Wiki, edit.User, at)
+	if err != nil {
+		return err
+	}
+	return cd.publish(ctx, group)
+}
+
+// record adds the edit to the page's editors and the editor's pages and edit
+// times, and notes whom it reverted.
+func (cd *CoordinationDetector) record(ctx context.Context, edit *models.WikipediaEdit, at time.Time) error {
+	ts := float64(at.Unix())
+	expired := "(" + strconv.FormatInt(at.Add(-cd.cfg.Window).Unix(), 10)
+
+	pipe := cd.redis.Pipeline()
+	pageKey := coordKey("page", edit.Wiki, edit.Title)
+	pipe.ZAdd(ctx, pageKey, redis.Z{Score: ts, Member: edit.User})
+	pipe.ZRemRangeByScore(ctx, pageKey, "-inf", expired)
+	pipe.Expire(ctx, pageKey, cd.cfg.Window)
+
+	pagesKey := coordKey("pages", edit.Wiki, edit.User)
+	pipe.ZAdd(ctx, pagesKey, redis.Z{Score: ts, Member: edit.Title})
+	pipe.ZRemRangeByScore(ctx, pagesKey, "-inf", expired)
+	pipe.ZRemRangeByRank(ctx, pagesKey, 0, -maxCoordinationPages-1)
+	pipe.Expire(ctx, pagesKey, cd.cfg.Window)
+
+	// Titles cannot contain "|", so it separates the revision from the title
+	editsKey := coordKey("edits", edit.Wiki, edit.User)
+	pipe.ZAdd(ctx, editsKey, redis.Z{Score: ts, Member: fmt.Sprintf("%d|%s", edit.Revision.New, edit.Title)})
+	pipe.ZRemRangeByScore(ctx, editsKey, "-inf", expired)
+	pipe.ZRemRangeByRank(ctx, editsKey, 0, -maxCoordinationEdits-1)
+	pipe.Expire(ctx, editsKey, cd.cfg.Window)
+
+	if rv := parseRevertComment(edit.Comment); rv != nil {
+		revertsKey := coordKey("reverts", edit.Wiki, edit.Title)
+		for _, victim := range rv.RevertedUsers {
+			if victim != edit.User {
+				pipe.ZAdd(ctx, revertsKey, redis.Z{Score: ts, Member: pairKey(edit.User, victim)})
+			}
+		}
+		pipe.ZRemRangeByScore(ctx, revertsKey, "-inf", expired)
+		pipe.Expire(ctx, revertsKey, cd.cfg.Window)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record co-occurrence: %w", err)
+	}
+	return nil
+}
+
+// scorePairs scores user against each co-editor and returns the pairs that
+// reach MinScore.
+func (cd *CoordinationDetector) scorePairs(ctx context.Context, wiki, user string, coEditors []string, at time.Time) ([]storage.CoordinatedPair, error) {
+	editors := append([]string{user}, coEditors...)
+	activity, err := cd.activity(ctx, wiki, editors, at)
+	if err != nil {
+		return nil, err
+	}
+	self := activity[user]
+
+	// Reverts on every page user shares with a candidate
+	shared := make(map[string][]string, len(coEditors))
+	reverts := make(map[string]*redis.StringSliceCmd)
+	pipe := cd.redis.Pipeline()
+	for _, other := range coEditors {
+		var pages []string
+		for page := range activity[other].pages {
+			if self.pages[page] {
+				pages = append(pages, page)
+			}
+		}
+		if len(pages) < cd.cfg.MinSharedPages {
+			continue
+		}
+		slices.Sort(pages)
+		shared[other] = pages
+		for _, page := range pages {
+			if reverts[page] == nil {
+				reverts[page] = pipe.ZRange(ctx, coordKey("reverts", wiki, page), 0, -1)
+			}
+		}
+	}
+	if len(shared) == 0 {
+		return nil, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get reverts: %w", err)
+	}
+	revertsOn := make(map[string][]string, len(reverts))
+	for page, cmd := range reverts {
+		revertsOn[page] = cmd.Val()
+	}
+
+	var pairs []storage.CoordinatedPair
+	for _, other := range coEditors {
+		pages, ok := shared[other]
+		if !ok {
+			continue
+		}
+		p, ok := cd.scorePair(user, other, self, activity[other], pages, revertsOn)
+		if !ok || p.Score < cd.cfg.MinScore {
+			continue
+		}
+		p.LastSeen = at
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// scorePair weighs the evidence that a and b edit together. It reports false
+// for a pair who have reverted each other on a shared page.
+func (cd *CoordinationDetector) scorePair(a, b string, actA, actB *editorActivity, shared []string, revertsOn map[string][]string) (storage.CoordinatedPair, bool) {
+	p := storage.CoordinatedPair{Users: [2]string{a, b}, SharedPages: shared}
+	if b < a {
+		p.Users = [2]string{b, a}
+	}
+
+	union := len(actA.pages) + len(actB.pages) - len(shared)
+	p.Overlap = float64(len(shared)) / float64(union)
+
+	sync := int64(cd.cfg.SyncWindow / time.Second)
+	p.TimingCorrelation = (syncedShare(actA, actB, shared, sync) + syncedShare(actB, actA, shared, sync)) / 2
+
+	for _, page := range shared {
+		victimsA, victimsB := map[string]bool{}, map[string]bool{}
+		for _, member := range revertsOn[page] {
+			reverter, victim, _ := strings.Cut(member, "|")
+			switch {
+			case reverter == a && victim == b, reverter == b && victim == a:
+				return p, false // opponents
+			case reverter == a:
+				victimsA[victim] = true
+			case reverter == b:
+				victimsB[victim] = true
+			}
+		}
+		for victim := range victimsA {
+			if victimsB[victim] {
+				p.TagTeamPages = append(p.TagTeamPages, page)
+				break
+			}
+		}
+	}
+
+	clean := (1 - weightCoOccurrence*p.Overlap) *
+		(1 - weightSyncTiming*p.TimingCorrelation) *
+		(1 - weightTagTeam*math.Min(1, float64(len(p.TagTeamPages))/2))
+	score := 1 - clean
+	for _, act := range []*editorActivity{actA, actB} {
+		if act.new {
+			score *= cd.cfg.NewAccountWeight
+		}
+	}
+	p.Score = math.Min(1, score)
+	return p, true
+}
+
+// syncedShare is the fraction of a's edits to the shared pages made within
+// sync seconds of an edit by b to the same page.
+func syncedShare(a, b *editorActivity, shared []string, sync int64) float64 {
+	total, synced := 0, 0
+	for _, page := range shared {
+		for _, ta := range a.edits[page] {
+			total++
+			for _, tb := range b.edits[page] {
+				if ta-tb <= sync && tb-ta <= sync {
+					synced++
+					break
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(synced) / float64(total)
+}
+
+// activity loads the pages and edit times of each editor, and whether the
+// account is known to be new.
+func (cd *CoordinationDetector) activity(ctx context.Context, wiki string, editors []string, at time.Time) (map[string]*editorActivity, error) {
+	pipe := cd.redis.Pipeline()
+	pages := make([]*redis.StringSliceCmd, len(editors))
+	edits := make([]*redis.ZSliceCmd, len(editors))
+	for i, u := range editors {
+		pages[i] = pipe.ZRange(ctx, coordKey("pages", wiki, u), 0, -1)
+		edits[i] = pipe.ZRangeWithScores(ctx, coordKey("edits", wiki, u), 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get editor activity: %w", err)
+	}
+	created, err := cd.accounts.CreatedAt(ctx, wiki, editors...)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make(map[string]*editorActivity, len(editors))
+	for i, u := range editors {
+		act := &editorActivity{pages: make(map[string]bool), edits: make(map[string][]int64)}
+		for _, page := range pages[i].Val() {
+			act.pages[page] = true
+		}
+		for _, z := range edits[i].Val() {
+			member, _ := z.Member.(string)
+			if _, page, ok := strings.Cut(member, "|"); ok {
+				act.edits[page] = append(act.edits[page], int64(z.Score))
+			}
+		}
+		if first, ok := created[u]; ok {
+			act.new = at.Sub(first) < cd.cfg.NewAccountAge
+		}
+		activity[u] = act
+	}
+	return activity, nil
+}
+
+// group collects the accounts linked to user, directly or through others,
+// with the evidence for each link. Links older than the window are dropped.
+func (cd *CoordinationDetector) group(ctx context.Context, wiki, user string, at time.Time) (storage.CoordinatedGroup, error) {
+	pairsKey := coordKey("pairs", wiki)
+	raw, err := cd.redis.HGetAll(ctx, pairsKey).Result()
+	if err != nil {
+		return storage.CoordinatedGroup{}, fmt.Errorf("failed to get coordinated pairs: %w", err)
+	}
+
+	links := make(map[string][]storage.CoordinatedPair)
+	var stale []string
+	for field, v := range raw {
+		var p storage.CoordinatedPair
+		if json.Unmarshal([]byte(v), &p) != nil || at.Sub(p.LastSeen) > cd.cfg.Window {
+			stale = append(stale, field)
+			continue
+		}
+		links[p.Users[0]] = append(links[p.Users[0]], p)
+		links[p.Users[1]] = append(links[p.Users[1]], p)
+	}
+	if len(stale) > 0 {
+		_ = cd.redis.HDel(ctx, pairsKey, stale...).Err()
+	}
+
+	g := storage.CoordinatedGroup{Wiki: wiki, At: at}
+	members := map[string]bool{user: true}
+	inGroup := map[[2]string]bool{}
+	pages := map[string]bool{}
+	for queue := []string{user}; len(queue) > 0; queue = queue[1:] {
+		for _, p := range links[queue[0]] {
+			if inGroup[p.Users] {
+				continue
+			}
+			inGroup[p.Users] = true
+			g.Pairs = append(g.Pairs, p)
+			g.Score = math.Max(g.Score, p.Score)
+			for _, page := range p.SharedPages {
+				pages[page] = true
+			}
+			for _, u := range p.Users {
+				if !members[u] {
+					members[u] = true
+					queue = append(queue, u)
+				}
+			}
+		}
+	}
+
+	for u := range members {
+		g.Accounts = append(g.Accounts, u)
+	}
+	slices.Sort(g.Accounts)
+	for page := range pages {
+		g.SharedPages = append(g.SharedPages, page)
+	}
+	slices.Sort(g.SharedPages)
+	slices.SortFunc(g.Pairs, func(x, y storage.CoordinatedPair) int {
+		return strings.Compare(pairKey(x.Users[0], x.Users[1]), pairKey(y.Users[0], y.Users[1]))
+	})
+
+	activity, err := cd.activity(ctx, wiki, g.Accounts, at)
+	if err != nil {
+		return g, err
+	}
+	for _, u := range g.Accounts {
+		if activity[u].new {
+			g.NewAccounts = append(g.NewAccounts, u)
+		}
+	}
+
+	switch {
+	case g.Score >= 0.9:
+		g.Severity = "critical"
+	case g.Score >= 0.7 || len(g.Accounts) >= 4:
+		g.Severity = "high"
+	default:
+		g.Severity = "medium"
+	}
+	return g, nil
+}
+
+// publish alerts on a group unless the same accounts were reported within
+// the cooldown. A group that gains an account is reported again.
+func (cd *CoordinationDetector) publish(ctx context.Context, g storage.CoordinatedGroup) error {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(g.Accounts, "|")))
+	cooldownKey := coordKey("alerted", g.Wiki, strconv.FormatUint(h.Sum64(), 16))
+	fresh, err := cd.redis.SetNX(ctx, cooldownKey, g.At.Unix(), cd.cfg.Cooldown).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check coordination cooldown: %w", err)
+	}
+	if !fresh {
+		return nil
+	}
+
+	if err := cd.alerts.PublishCoordinationAlert(ctx, g); err != nil {
+		return fmt.Errorf("failed to publish coordination alert: %w", err)
+	}
+	metrics.CoordinatedGroupsTotal.Inc()
+	cd.logger.Info().
+		Str("wiki", g.Wiki).
+		Strs("accounts", g.Accounts).
+		Int("shared_pages", len(g.SharedPages)).
+		Float64("score", g.Score).
+		Msg("Possible coordinated editing detected")
+	return nil
+}
+
+// coordKey builds a coord:{kind}:{parts...} Redis key.
+func coordKey(kind string, parts ...string) string {
+	return "coord:" + kind + ":" + strings.Join(parts, ":")
+}
+
+// pairKey joins two user names; "|" is not allowed in user names.
+func pairKey(a, b string) string {
+	return a + "|" + b
+}
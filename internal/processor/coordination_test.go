@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCoordinationDetector(t *testing.T) (*CoordinationDetector, *storage.RedisAlerts, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Redis: config.Redis{HotPages: config.HotPages{
+			MaxTracked: 100, PromotionThreshold: 3, WindowDuration: 15 * time.Minute,
+			MaxMembersPerPage: 50, HotThreshold: 2, CleanupInterval: 5 * time.Minute,
+		}},
+		Processor: config.Processor{
+			EventTime: config.EventTimeConfig{Enabled: true, AllowedLateness: time.Hour},
+			Coordination: config.CoordinationConfig{
+				Enabled:          true,
+				Window:           7 * 24 * time.Hour,
+				MinSharedPages:   3,
+				SyncWindow:       2 * time.Minute,
+				MinScore:         0.5,
+				NewAccountAge:    7 * 24 * time.Hour,
+				NewAccountWeight: 1.25,
+				MaxCandidates:    50,
+				Cooldown:         6 * time.Hour,
+			},
+		},
+	}
+
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	alerts := storage.NewRedisAlerts(client)
+	return NewCoordinationDetector(alerts, hotPages, client, cfg, zerolog.Nop()), alerts, mr
+}
+
+// playSockpuppets has Sock1 and Sock2 undo Honest's edits within a minute of
+// each other on three contentious pages, while Regular edits the same pages
+// hours apart among many others and Bystander only shares two of them. If
+// newSocks is set the socks registered the day before; everyone else is a
+// long-standing editor.
+func playSockpuppets(t *testing.T, cd *CoordinationDetector, mr *miniredis.Miniredis, base time.Time, newSocks bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	if newSocks {
+		for _, user := range []string{"Sock1", "Sock2"} {
+			require.NoError(t, cd.accounts.RecordCreated(ctx, "enwiki", user, base.Add(-24*time.Hour)))
+		}
+	}
+
+	pages := []string{"Contested A", "Contested B", "Contested C"}
+	for _, p := range append(pages, "Other 1", "Other 2", "Other 3", "Other 4", "Other 5") {
+		mr.ZAdd("hot:window:enwiki:"+p, float64(base.Unix()), "seed")
+	}
+
+	id := int64(1000)
+	edit := func(page, user, victim string, at time.Time) {
+		t.Helper()
+		e := makeEdit(id, page, user, 1000, 1200)
+		if victim != "" {
+			e = makeRevert(id, page, user, victim, 1200, 1000)
+		}
+		e.Timestamp = at.Unix()
+		id += 2
+		require.NoError(t, cd.ProcessEdit(ctx, e))
+	}
+
+	for i, p := range pages {
+		t0 := base.Add(time.Duration(i) * time.Hour)
+		edit(p, "Honest", "", t0)
+		edit(p, "Sock1", "Honest", t0.Add(time.Minute))
+		edit(p, "Sock2", "Honest", t0.Add(90*time.Second))
+		if i < 2 {
+			edit(p, "Bystander", "", t0.Add(2*time.Minute))
+		}
+	}
+	for i, p := range []string{"Other 1", "Other 2", "Other 3", "Other 4", "Other 5", "Contested A", "Contested B", "Contested C"} {
+		edit(p, "Regular", "", base.Add(time.Duration(5+i)*time.Hour))
+	}
+}
+
+func TestCoordinationDetector_FlagsTagTeam(t *testing.T) {
+	cd, alerts, mr := setupCoordinationDetector(t)
+	base := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	playSockpuppets(t, cd, mr, base, true)
+
+	published, err := alerts.GetRecentAlerts(context.Background(), "coordination", 10)
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+
+	alert := published[0]
+	assert.Equal(t, storage.AlertTypeCoordinatedEditing, alert.Type)
+	assert.Equal(t, []interface{}{"Sock1", "Sock2"}, alert.Data["accounts"])
+	assert.Equal(t, []interface{}{"Sock1", "Sock2"}, alert.Data["new_accounts"])
+	assert.Equal(t, []interface{}{"Contested A", "Contested B", "Contested C"}, alert.Data["shared_pages"])
+	assert.Equal(t, 1.0, alert.Data["score"])
+	assert.Equal(t, "critical", alert.Data["severity"])
+
+	pairs := alert.Data["pairs"].([]interface{})
+	require.Len(t, pairs, 1)
+	pair := pairs[0].(map[string]interface{})
+	assert.Equal(t, 1.0, pair["overlap"])
+	assert.Equal(t, 1.0, pair["timing_correlation"])
+	assert.Len(t, pair["tag_team_pages"], 3)
+}
+
+func TestCoordinationDetector_NewAccountsWeighMore(t *testing.T) {
+	cd, alerts, mr := setupCoordinationDetector(t)
+	base := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	playSockpuppets(t, cd, mr, base, false)
+
+	published, err := alerts.GetRecentAlerts(context.Background(), "coordination", 10)
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+	assert.Nil(t, published[0].Data["new_accounts"])
+	// 1 - (1-0.4)(1-0.45)(1-0.6), without the new-account weighting
+	assert.InDelta(t, 0.868, published[0].Data["score"].(float64), 1e-9)
+	assert.Equal(t, "high", published[0].Data["severity"])
+}
+
+func TestCoordinationDetector_IgnoresOpponents(t *testing.T) {
+	cd, _, _ := setupCoordinationDetector(t)
+	sync := int64(120)
+
+	both := func() *editorActivity {
+		return &editorActivity{
+			pages: map[string]bool{"A": true, "B": true, "C": true},
+			edits: map[string][]int64{"A": {100}, "B": {200}, "C": {300}},
+		}
+	}
+	shared := []string{"A", "B", "C"}
+
+	// Edit for edit on the same pages, but Alice reverted Bob: a war, not a team
+	_, linked := cd.scorePair("Alice", "Bob", both(), both(), shared, map[string][]string{"B": {"Alice|Bob"}})
+	assert.False(t, linked)
+
+	p, linked := cd.scorePair("Alice", "Bob", both(), both(), shared, nil)
+	require.True(t, linked)
+	assert.Equal(t, [2]string{"Alice", "Bob"}, p.Users)
+	assert.Equal(t, 1.0, syncedShare(both(), both(), shared, sync))
+	assert.InDelta(t, 1-0.6*0.55, p.Score, 1e-9)
+}
@@ -33,17 +33,21 @@ type SpikeDetector struct {
 	config               *config.Config
 	alertStream          string
 	metrics              *SpikeDetectorMetrics
-	spikeRatioThreshold  float64
+	scorer               SpikeScorer
 	minimumEdits         int
 	logger               zerolog.Logger
 	mu                   sync.RWMutex
-	cooldowns            map[string]time.Time // page -> last alert time
+	cooldowns            map[models.PageKey]time.Time // page -> last alert time
 	cooldownDuration     time.Duration
+	dedup                *storage.EditDeduplicator
+	clock                *storage.EventClock // event-time watermark for windows and cooldowns
+	stories              *StoryClusterer     // optional: groups spikes across wikis by Wikidata item
 }
 
 // SpikeAlert represents a detected spike event
 type SpikeAlert struct {
 	PageTitle      string    `json:"page_title"`
+	Wiki           string    `json:"wiki,omitempty"`
 	SpikeRatio     float64   `json:"spike_ratio"`
 	Edits5Min      int64     `json:"edits_5min"`
 	Edits1Hour     int64     `json:"edits_1hour"`
@@ -51,6 +55,9 @@ type SpikeAlert struct {
 	Timestamp      time.Time `json:"timestamp"`
 	UniqueEditors  int       `json:"unique_editors"`
 	ServerURL      string    `json:"server_url,omitempty"`
+	Method         string    `json:"method,omitempty"`        // scoring method that raised the alert
+	ZScore         float64   `json:"z_score,omitempty"`       // ewma/seasonal only
+	ExpectedRate   float64   `json:"expected_rate,omitempty"` // baseline edits per minute
 }
 
 // SpikeDetectorMetrics contains Prometheus metrics for spike detection
@@ -110,38 +117,84 @@ func NewSpikeDetector(hotPages *storage.HotPageTracker, redis *redis.Client, cfg
 		)
 	})
 
+	logger = logger.With().Str("component", "spike_detector").Logger()
+	spikeCfg := cfg.Processor.Spike.WithDefaults()
+	scorer, err := NewSpikeScorer(redis, spikeCfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid spike detection method, using the ratio rule")
+		spikeCfg.Method = "ratio"
+		scorer, _ = NewSpikeScorer(redis, spikeCfg)
+	}
+
 	return &SpikeDetector{
 		hotPages:             hotPages,
 		redis:                redis,
 		config:               cfg,
 		alertStream:          "alerts:spikes",
 		metrics:              sharedSpikeMetrics,
-		spikeRatioThreshold:  5.0, // Default threshold
-		minimumEdits:         3,   // Minimum edits in 5 minutes to consider
-		logger:               logger.With().Str("component", "spike_detector").Logger(),
-		cooldowns:            make(map[string]time.Time),
-		cooldownDuration:     10 * time.Minute, // Suppress duplicate alerts for 10 minutes per page
+		scorer:               scorer,
+		minimumEdits:         spikeCfg.MinimumEdits, // Minimum edits in 5 minutes to consider
+		logger:               logger,
+		cooldowns:            make(map[models.PageKey]time.Time),
+		cooldownDuration:     spikeCfg.Cooldown, // Suppress duplicate alerts per page
+		clock:                storage.NewEventClock("spike-detector", cfg.Processor.EventTime),
 	}
 }
 
+// SetScorer replaces the spike scoring strategy chosen from the config.
+func (sd *SpikeDetector) SetScorer(scorer SpikeScorer) {
+	sd.scorer = scorer
+}
+
+// SetStoryClusterer files every spike alert under its cross-language story.
+func (sd *SpikeDetector) SetStoryClusterer(c *StoryClusterer) {
+	sd.stories = c
+}
+
+// Clock returns the detector's event clock. The hot page tracker should use it
+// too, so page stats are measured back from the same watermark.
+func (sd *SpikeDetector) Clock() *storage.EventClock {
+	return sd.clock
+}
+
 // ProcessEdit analyzes each edit for spike potential - handler for Kafka consumer
 func (sd *SpikeDetector) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return sd.dedup.Process(ctx, "spike-detector", edit, func() error {
+		return sd.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit ignore replayed revisions, so a
+// redelivered edit does not bump the page's activity counters twice.
+func (sd *SpikeDetector) SetDeduplicator(d *storage.EditDeduplicator) {
+	sd.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (sd *SpikeDetector) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
 	start := time.Now()
 	defer func() {
 		sd.metrics.ProcessingTime.Observe(time.Since(start).Seconds())
 		sd.metrics.ProcessedEdits.Inc()
 	}()
 
+	// Advance the watermark; late edits are dropped if so configured
+	if _, ok := sd.clock.Observe(edit); !ok {
+		return nil
+	}
+
+	key := edit.PageKey()
+
 	// Update hot page tracker with this edit
 	if err := sd.hotPages.ProcessEdit(ctx, edit); err != nil {
-		sd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to process edit in hot page tracker")
+		sd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to process edit in hot page tracker")
 		return err
 	}
 
 	// Check if page is hot (promoted to detailed tracking)
-	isHot, err := sd.hotPages.IsHot(ctx, edit.Title)
+	isHot, err := sd.hotPages.IsHot(ctx, key)
 	if err != nil {
-		sd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to check if page is hot")
+		sd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to check if page is hot")
 		return err
 	}
 
@@ -151,28 +204,32 @@ func (sd *SpikeDetector) ProcessEdit(ctx context.Context, edit *models.Wikipedia
 	}
 
 	// Get page statistics for spike detection
-	stats, err := sd.hotPages.GetPageStats(ctx, edit.Title)
+	stats, err := sd.hotPages.GetPageStats(ctx, key)
 	if err != nil {
-		sd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to get page statistics")
+		sd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to get page statistics")
 		return err
 	}
 
 	// Detect spike
-	alert := sd.detectSpike(edit.Title, stats)
+	alert, err := sd.detectSpike(ctx, key, stats)
+	if err != nil {
+		sd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to score page activity")
+		return err
+	}
 	if alert != nil {
 		// Check cooldown to prevent duplicate alerts
+		now := sd.clock.Now()
 		sd.mu.Lock()
-		if lastAlert, exists := sd.cooldowns[edit.Title]; exists && time.Since(lastAlert) < sd.cooldownDuration {
+		if lastAlert, exists := sd.cooldowns[key]; exists && now.Sub(lastAlert) < sd.cooldownDuration {
 			sd.mu.Unlock()
 			return nil // Still in cooldown, suppress duplicate
 		}
-		sd.cooldowns[edit.Title] = time.Now()
+		sd.cooldowns[key] = now
 		// Clean up expired cooldowns every 100 entries, and hard-cap the
 		// map to maxCooldownEntries. When the cap is hit we rebuild the
 		// map from scratch so Go releases the old backing array — plain
 		// delete() never shrinks the internal hash table.
 		if len(sd.cooldowns) > 100 {
-			now := time.Now()
 			for page, t := range sd.cooldowns {
 				if now.Sub(t) > sd.cooldownDuration {
 					delete(sd.cooldowns, page)
@@ -181,8 +238,7 @@ func (sd *SpikeDetector) ProcessEdit(ctx context.Context, edit *models.Wikipedia
 		}
 		if len(sd.cooldowns) > maxCooldownEntries {
 			// Rebuild map to reclaim memory from Go's non-shrinking map.
-			newMap := make(map[string]time.Time, len(sd.cooldowns)/2)
-			now := time.Now()
+			newMap := make(map[models.PageKey]time.Time, len(sd.cooldowns)/2)
 			for page, t := range sd.cooldowns {
 				if now.Sub(t) <= sd.cooldownDuration {
 					newMap[page] = t
@@ -194,77 +250,68 @@ func (sd *SpikeDetector) ProcessEdit(ctx context.Context, edit *models.Wikipedia
 
 		// Spike detected - publish alert
 		if err := sd.publishAlert(ctx, alert); err != nil {
-			sd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to publish spike alert")
+			sd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to publish spike alert")
 			return err
 		}
 
 		// Mark page as spiking for ES indexing strategy
-		if err := sd.markPageSpiking(ctx, edit.Title, edit.Wiki); err != nil {
-			sd.logger.Warn().Err(err).Str("page", edit.Title).Msg("Failed to mark page as spiking")
+		if err := sd.markPageSpiking(ctx, key); err != nil {
+			sd.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to mark page as spiking")
 			// Don't fail the entire operation for this
 		}
 
+		if sd.stories != nil {
+			if err := sd.stories.AddSpike(ctx, alert); err != nil {
+				sd.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to add spike to its story")
+			}
+		}
+
 		sd.metrics.SpikesDetected.WithLabelValues(alert.Severity).Inc()
 		sd.metrics.SpikeRatioGauge.Set(alert.SpikeRatio)
-		sd.logger.Info().Str("page", edit.Title).Float64("ratio", alert.SpikeRatio).Str("severity", alert.Severity).Msg("Spike detected")
+		sd.logger.Info().Str("page", key.String()).Float64("ratio", alert.SpikeRatio).Str("severity", alert.Severity).Msg("Spike detected")
 	}
 
 	return nil
 }
 
-// detectSpike performs statistical spike detection
-func (sd *SpikeDetector) detectSpike(pageTitle string, stats *storage.PageStats) *SpikeAlert {
+// detectSpike scores the page with the configured strategy and returns an
+// alert if it is spiking. The scorer's baseline sees the page's rate either
+// way, so it is not skewed towards the pages' busiest minutes.
+func (sd *SpikeDetector) detectSpike(ctx context.Context, key models.PageKey, stats *storage.PageStats) (*SpikeAlert, error) {
+	now := sd.clock.Now()
+
 	// Check minimum edits threshold
-	if stats.EditsLast5Min < int64(sd.minimumEdits) {
-		return nil // Not significant enough
+	var score *SpikeScore
+	if stats.EditsLast5Min >= int64(sd.minimumEdits) {
+		var err error
+		if score, err = sd.scorer.Score(ctx, key, stats, now); err != nil {
+			return nil, err
+		}
 	}
-
-	// Calculate rates (edits per minute)
-	rate1h := float64(stats.EditsLastHour) / 60.0  // edits per minute over last hour
-	rate5m := float64(stats.EditsLast5Min) / 5.0   // edits per minute over last 5 minutes
-
-	// Calculate baseline to avoid division by zero
-	baseline := rate1h
-	if baseline < 0.1 {
-		baseline = 0.1 // Minimum baseline to prevent false positives
+	if err := sd.scorer.Observe(ctx, key, stats, now); err != nil {
+		return nil, err
 	}
-
-	// Calculate spike ratio
-	ratio := rate5m / baseline
-
-	// Check if ratio exceeds threshold
-	if ratio < sd.spikeRatioThreshold {
-		return nil // No spike detected
+	if score == nil || !score.Spike {
+		return nil, nil // Not significant enough, or no spike detected
 	}
 
 	// Create spike alert
 	alert := &SpikeAlert{
-		PageTitle:     pageTitle,
-		SpikeRatio:    ratio,
+		PageTitle:     key.Title,
+		Wiki:          key.Wiki,
+		SpikeRatio:    score.Ratio,
 		Edits5Min:     stats.EditsLast5Min,
 		Edits1Hour:    stats.EditsLastHour,
-		Severity:      sd.calculateSeverity(ratio),
-		Timestamp:     time.Now(),
+		Severity:      score.Severity,
+		Timestamp:     now,
 		UniqueEditors: len(stats.UniqueEditors),
 		ServerURL:     stats.ServerURL,
+		Method:        score.Method,
+		ZScore:        score.ZScore,
+		ExpectedRate:  score.Expected,
 	}
 
-	return alert
-}
-
-// calculateSeverity determines spike severity based on ratio.
-// Thresholds: 5x=medium, 10x=high, 20x=critical.
-func (sd *SpikeDetector) calculateSeverity(ratio float64) string {
-	switch {
-	case ratio >= 20:
-		return "critical"
-	case ratio >= 10:
-		return "high"
-	case ratio >= 5:
-		return "medium"
-	default:
-		return "low"
-	}
+	return alert, nil
 }
 
 // publishAlert stores alert in Redis stream for API consumption
@@ -284,6 +331,7 @@ func (sd *SpikeDetector) publishAlert(ctx context.Context, alert *SpikeAlert) er
 			"data":     string(alertData),
 			"severity": alert.Severity,
 			"page":     alert.PageTitle,
+			"wiki":     alert.Wiki,
 		},
 	}
 
@@ -297,8 +345,8 @@ func (sd *SpikeDetector) publishAlert(ctx context.Context, alert *SpikeAlert) er
 }
 
 // markPageSpiking marks a page as currently spiking for indexing strategy
-func (sd *SpikeDetector) markPageSpiking(ctx context.Context, pageTitle string, wiki string) error {
-	spikeKey := fmt.Sprintf("spike:%s:%s", wiki, pageTitle)
+func (sd *SpikeDetector) markPageSpiking(ctx context.Context, key models.PageKey) error {
+	spikeKey := fmt.Sprintf("spike:%s", key)
 	return sd.redis.Set(ctx, spikeKey, 1, time.Hour).Err() // 1 hour TTL
 }
 
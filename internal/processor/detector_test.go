@@ -252,10 +252,6 @@ func TestSpikeDetectionScenarios(t *testing.T) {
 
 // TestSeverityCalculation tests the severity calculation logic
 func TestSeverityCalculation(t *testing.T) {
-	cfg := &config.Config{}
-	logger := zerolog.New(nil).Level(zerolog.Disabled)
-	detector := NewSpikeDetector(nil, nil, cfg, logger)
-
 	tests := []struct {
 		ratio    float64
 		expected string
@@ -273,7 +269,7 @@ func TestSeverityCalculation(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("ratio_%.1f", test.ratio), func(t *testing.T) {
-			severity := detector.calculateSeverity(test.ratio)
+			severity := ratioSeverity(test.ratio)
 			assert.Equal(t, test.expected, severity)
 		})
 	}
@@ -339,7 +335,7 @@ func BenchmarkSpikeDetection(b *testing.B) {
 // internal hash table, memory was permanently leaked.
 func TestSpikeDetector_CooldownMapBounded(t *testing.T) {
 	sd := &SpikeDetector{
-		cooldowns:        make(map[string]time.Time),
+		cooldowns:        make(map[models.PageKey]time.Time),
 		cooldownDuration: 10 * time.Minute,
 	}
 
@@ -348,7 +344,7 @@ func TestSpikeDetector_CooldownMapBounded(t *testing.T) {
 	now := time.Now()
 	totalEntries := maxCooldownEntries + 2000
 	for i := 0; i < totalEntries; i++ {
-		page := fmt.Sprintf("Page_%d", i)
+		page := models.NewPageKey("enwiki", fmt.Sprintf("Page_%d", i))
 		sd.mu.Lock()
 		sd.cooldowns[page] = now // All entries are fresh (not expired)
 		// Trigger the same cleanup logic as ProcessEdit:
@@ -360,7 +356,7 @@ func TestSpikeDetector_CooldownMapBounded(t *testing.T) {
 			}
 		}
 		if len(sd.cooldowns) > maxCooldownEntries {
-			newMap := make(map[string]time.Time, len(sd.cooldowns)/2)
+			newMap := make(map[models.PageKey]time.Time, len(sd.cooldowns)/2)
 			for p, t := range sd.cooldowns {
 				if now.Sub(t) <= sd.cooldownDuration {
 					newMap[p] = t
@@ -390,20 +386,20 @@ func TestSpikeDetector_CooldownMapBounded(t *testing.T) {
 // much lower than the old 500. This ensures faster reclamation.
 func TestSpikeDetector_CooldownMapExpiredCleanup(t *testing.T) {
 	sd := &SpikeDetector{
-		cooldowns:        make(map[string]time.Time),
+		cooldowns:        make(map[models.PageKey]time.Time),
 		cooldownDuration: 10 * time.Minute,
 	}
 
 	// Insert 200 entries, all expired (11 minutes ago).
 	expired := time.Now().Add(-11 * time.Minute)
 	for i := 0; i < 200; i++ {
-		sd.cooldowns[fmt.Sprintf("Old_Page_%d", i)] = expired
+		sd.cooldowns[models.NewPageKey("enwiki", fmt.Sprintf("Old_Page_%d", i))] = expired
 	}
 
 	// Now insert one fresh entry — this should trigger cleanup at len > 100.
 	now := time.Now()
 	sd.mu.Lock()
-	sd.cooldowns["Fresh_Page"] = now
+	sd.cooldowns[models.NewPageKey("enwiki", "Fresh_Page")] = now
 	if len(sd.cooldowns) > 100 {
 		for page, t := range sd.cooldowns {
 			if now.Sub(t) > sd.cooldownDuration {
@@ -427,14 +423,14 @@ func TestSpikeDetector_CooldownMapExpiredCleanup(t *testing.T) {
 // we verify the rebuild path creates a distinct map object.
 func TestSpikeDetector_CooldownMapRebuildReleasesMemory(t *testing.T) {
 	sd := &SpikeDetector{
-		cooldowns:        make(map[string]time.Time),
+		cooldowns:        make(map[models.PageKey]time.Time),
 		cooldownDuration: 10 * time.Minute,
 	}
 
 	// Fill just past the cap with fresh entries.
 	now := time.Now()
 	for i := 0; i <= maxCooldownEntries; i++ {
-		sd.cooldowns[fmt.Sprintf("Rebuild_Page_%d", i)] = now
+		sd.cooldowns[models.NewPageKey("enwiki", fmt.Sprintf("Rebuild_Page_%d", i))] = now
 	}
 
 	originalMap := sd.cooldowns
@@ -442,7 +438,7 @@ func TestSpikeDetector_CooldownMapRebuildReleasesMemory(t *testing.T) {
 	// Trigger rebuild.
 	sd.mu.Lock()
 	if len(sd.cooldowns) > maxCooldownEntries {
-		newMap := make(map[string]time.Time, len(sd.cooldowns)/2)
+		newMap := make(map[models.PageKey]time.Time, len(sd.cooldowns)/2)
 		for page, t := range sd.cooldowns {
 			if now.Sub(t) <= sd.cooldownDuration {
 				newMap[page] = t
@@ -464,7 +460,7 @@ func TestSpikeDetector_CooldownMapRebuildReleasesMemory(t *testing.T) {
 // -race to detect issues.
 func TestSpikeDetector_CooldownConcurrentAccess(t *testing.T) {
 	sd := &SpikeDetector{
-		cooldowns:        make(map[string]time.Time),
+		cooldowns:        make(map[models.PageKey]time.Time),
 		cooldownDuration: 10 * time.Minute,
 	}
 
@@ -477,7 +473,7 @@ func TestSpikeDetector_CooldownConcurrentAccess(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			for i := 0; i < opsPerGoroutine; i++ {
-				page := fmt.Sprintf("Concurrent_Page_%d_%d", id, i)
+				page := models.NewPageKey("enwiki", fmt.Sprintf("Concurrent_Page_%d_%d", id, i))
 				now := time.Now()
 
 				sd.mu.Lock()
@@ -490,7 +486,7 @@ func TestSpikeDetector_CooldownConcurrentAccess(t *testing.T) {
 					}
 				}
 				if len(sd.cooldowns) > maxCooldownEntries {
-					newMap := make(map[string]time.Time, len(sd.cooldowns)/2)
+					newMap := make(map[models.PageKey]time.Time, len(sd.cooldowns)/2)
 					for p, t := range sd.cooldowns {
 						if now.Sub(t) <= sd.cooldownDuration {
 							newMap[p] = t
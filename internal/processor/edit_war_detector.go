@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -50,15 +49,20 @@ type EditWarDetector struct {
 	timeWindow       time.Duration
 	logger           zerolog.Logger
 	mu               sync.RWMutex
-	cooldowns        map[string]time.Time // page -> last alert time
+	cooldowns        map[models.PageKey]time.Time // page -> last alert time
 	cooldownDuration time.Duration
 	reanalyzeEvery   int // re-run LLM analysis every N edits on active wars (0=disabled)
 	analysisSem      chan struct{} // semaphore bounding concurrent LLM goroutines
+	dedup            *storage.EditDeduplicator
+	clock            *storage.EventClock // event-time watermark for cooldowns and start times
+	reverts          *RevertClassifier
+	alerts           *storage.RedisAlerts // 3RR violations
 }
 
 // EditWarAlert represents a detected edit war event
 type EditWarAlert struct {
 	PageTitle   string    `json:"page_title"`
+	Wiki        string    `json:"wiki,omitempty"`
 	EditorCount int       `json:"editor_count"`
 	EditCount   int       `json:"edit_count"`
 	RevertCount int       `json:"revert_count"`
@@ -137,10 +141,13 @@ func NewEditWarDetector(hotPages *storage.HotPageTracker, redisClient *redis.Cli
 		minReverts:       2,
 		timeWindow:       10 * time.Minute,
 		logger:           logger.With().Str("component", "edit_war_detector").Logger(),
-		cooldowns:        make(map[string]time.Time),
+		cooldowns:        make(map[models.PageKey]time.Time),
 		cooldownDuration: 5 * time.Minute, // Suppress duplicate alerts for 5 minutes per page
 		reanalyzeEvery:   cfg.LLM.ReanalyzeEvery,
 		analysisSem:      make(chan struct{}, maxConcurrentAnalyses),
+		clock:            storage.NewEventClock("edit-war-detector", cfg.Processor.EventTime),
+		reverts:          NewRevertClassifier(cfg.Processor.Reverts, logger),
+		alerts:           storage.NewRedisAlerts(redisClient),
 	}
 }
 
@@ -152,18 +159,53 @@ func (ewd *EditWarDetector) SetAnalysisService(svc *llm.AnalysisService) {
 
 // ProcessEdit analyzes each edit for edit war patterns - handler for Kafka consumer
 func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return ewd.dedup.Process(ctx, "edit-war-detector", edit, func() error {
+		return ewd.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit ignore replayed revisions. Without it a
+// redelivered edit is counted again in the page's editor and revert tallies.
+func (ewd *EditWarDetector) SetDeduplicator(d *storage.EditDeduplicator) {
+	ewd.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (ewd *EditWarDetector) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
 	start := time.Now()
 	defer func() {
 		ewd.metrics.ProcessingTime.Observe(time.Since(start).Seconds())
 		ewd.metrics.ProcessedEdits.Inc()
 	}()
 
+	if _, ok := ewd.clock.Observe(edit); !ok {
+		return nil // late edit, dropped per the event time config
+	}
+
+	key := edit.PageKey()
+
 	// Check if page is hot (only check hot pages)
-	isHot, err := ewd.hotPages.IsHot(ctx, edit.Title)
+	isHot, err := ewd.hotPages.IsHot(ctx, key)
 	if err != nil {
-		ewd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to check if page is hot")
+		ewd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to check if page is hot")
 		return err
 	}
+
+	// The three-revert rule applies to every page, and a slow revert war
+	// seldom makes a page hot. Quiet pages are classified by summary only,
+	// sparing them the revision history request. A failure here must not
+	// stop edit war detection for the page.
+	var revert *Revert
+	if isHot {
+		revert = ewd.reverts.Classify(ctx, edit)
+	} else {
+		revert = ewd.reverts.ClassifySummary(edit)
+	}
+	if revert != nil {
+		if err := ewd.checkThreeRevertRule(ctx, edit, revert); err != nil {
+			ewd.logger.Warn().Err(err).Str("page", key.String()).Str("user", edit.User).Msg("Failed to check three-revert rule")
+		}
+	}
 	if !isHot {
 		return nil
 	}
@@ -172,45 +214,63 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 	// If an edit war is already active (12h marker exists), use the longer 12h
 	// TTL so that counters survive gaps longer than the 10-min detection window.
 	// Otherwise, use the short detection-window TTL.
+	// These TTLs are the detection window, and Redis expires them on the
+	// wall clock even with event time enabled: a replay faster than real
+	// time counts edits further apart than the window as one burst. Only
+	// the start times and cooldowns derived here follow the event clock.
 	trackingTTL := ewd.timeWindow
-	editWarKey := fmt.Sprintf("editwar:%s", edit.Title)
+	editWarKey := fmt.Sprintf("editwar:%s", key)
 	if ex, _ := ewd.redis.Exists(ctx, editWarKey).Result(); ex > 0 {
 		trackingTTL = 12 * time.Hour
 	}
 
 	// Update editor tracking: HINCRBY for editor's edit count
-	editorsKey := fmt.Sprintf("editwar:editors:%s", edit.Title)
+	editorsKey := fmt.Sprintf("editwar:editors:%s", key)
 	pipe := ewd.redis.Pipeline()
 	pipe.HIncrBy(ctx, editorsKey, edit.User, 1)
 	pipe.Expire(ctx, editorsKey, trackingTTL)
 
-	// Update byte change tracking for revert detection
-	changesKey := fmt.Sprintf("editwar:changes:%s", edit.Title)
+	// Update byte change tracking
+	changesKey := fmt.Sprintf("editwar:changes:%s", key)
 	byteChange := edit.ByteChange()
 	pipe.RPush(ctx, changesKey, byteChange)
-	pipe.LTrim(ctx, changesKey, -500, -1) // Keep last 500 changes for better revert detection
+	pipe.LTrim(ctx, changesKey, -500, -1)
 	pipe.Expire(ctx, changesKey, trackingTTL)
 
+	// Record who this edit reverted, if anyone. The reverts list is what
+	// countReverts counts, over the same window as the editor hash.
+	if revert != nil {
+		revertsKey := fmt.Sprintf("editwar:reverts:%s", key)
+		revertEntry, _ := json.Marshal(revert)
+		pipe.RPush(ctx, revertsKey, string(revertEntry))
+		pipe.LTrim(ctx, revertsKey, -500, -1)
+		pipe.Expire(ctx, revertsKey, trackingTTL)
+	}
+
 	// Track edit timeline (user, comment, byte change, timestamp) for LLM analysis.
 	// Only stored for hot pages already in the edit-war tracking path.
 	// Use 12h TTL (matching the edit war marker) so timeline data remains
 	// available for LLM analysis as long as the edit war is shown as active.
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", edit.Title)
-	timelineEntry, _ := json.Marshal(map[string]interface{}{
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
+	entry := map[string]interface{}{
 		"user":        edit.User,
 		"comment":     edit.Comment,
 		"byte_change": byteChange,
 		"timestamp":   edit.Timestamp,
 		"revision_id": edit.Revision.New,
 		"server_url":  edit.ServerURL,
-	})
+	}
+	if revert != nil {
+		entry["revert"] = revert
+	}
+	timelineEntry, _ := json.Marshal(entry)
 	pipe.RPush(ctx, timelineKey, string(timelineEntry))
 	pipe.LTrim(ctx, timelineKey, -100, -1) // Keep last 100 entries
 	pipe.Expire(ctx, timelineKey, 12*time.Hour)
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		ewd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to update editor/change tracking")
+		ewd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to update editor/change tracking")
 		return err
 	}
 
@@ -219,17 +279,12 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 	// editing activity stops, but we keep it alive as long as edits arrive.
 	if ex, _ := ewd.redis.Exists(ctx, editWarKey).Result(); ex > 0 {
 		_ = ewd.redis.Expire(ctx, editWarKey, 30*time.Minute).Err()
-		// Also refresh the wiki-specific marker if present
-		if edit.Wiki != "" {
-			wikiKey := fmt.Sprintf("editwar:%s:%s", edit.Wiki, edit.Title)
-			_ = ewd.redis.Expire(ctx, wikiKey, 30*time.Minute).Err()
-		}
 	}
 
 	// Get editor hash to check counts
 	editorMap, err := ewd.redis.HGetAll(ctx, editorsKey).Result()
 	if err != nil {
-		ewd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to get editor hash")
+		ewd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to get editor hash")
 		return err
 	}
 
@@ -243,28 +298,28 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 
 	// Check if conditions warrant edit war analysis
 	if uniqueEditors >= ewd.minEditors && totalEdits >= ewd.minEdits {
-		alert, err := ewd.detectEditWar(ctx, edit.Title)
+		alert, err := ewd.detectEditWar(ctx, key)
 		if err != nil {
-			ewd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to detect edit war")
+			ewd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to detect edit war")
 			return err
 		}
 
 		if alert != nil {
 			// Check cooldown to prevent duplicate alerts for the same page
+			now := ewd.clock.Now()
 			ewd.mu.Lock()
-			if lastAlert, exists := ewd.cooldowns[edit.Title]; exists && time.Since(lastAlert) < ewd.cooldownDuration {
+			if lastAlert, exists := ewd.cooldowns[key]; exists && now.Sub(lastAlert) < ewd.cooldownDuration {
 				ewd.mu.Unlock()
 				// War already known — check if it's time for periodic re-analysis
-				ewd.maybeReanalyze(ctx, edit.Title)
+				ewd.maybeReanalyze(ctx, key)
 				return nil // Still in cooldown, suppress duplicate
 			}
-			ewd.cooldowns[edit.Title] = time.Now()
+			ewd.cooldowns[key] = now
 			// Clean up expired cooldowns every 100 entries, and hard-cap the
 			// map to maxEditWarCooldownEntries. When the cap is hit we rebuild
 			// the map from scratch so Go releases the old backing array —
 			// plain delete() never shrinks the internal hash table.
 			if len(ewd.cooldowns) > 100 {
-				now := time.Now()
 				for page, t := range ewd.cooldowns {
 					if now.Sub(t) > ewd.cooldownDuration {
 						delete(ewd.cooldowns, page)
@@ -272,8 +327,7 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 				}
 			}
 			if len(ewd.cooldowns) > maxEditWarCooldownEntries {
-				newMap := make(map[string]time.Time, len(ewd.cooldowns)/2)
-				now := time.Now()
+				newMap := make(map[models.PageKey]time.Time, len(ewd.cooldowns)/2)
 				for page, t := range ewd.cooldowns {
 					if now.Sub(t) <= ewd.cooldownDuration {
 						newMap[page] = t
@@ -284,15 +338,15 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 			ewd.mu.Unlock()
 
 			alert.ServerURL = edit.ServerURL
-			if err := ewd.publishEditWarAlert(ctx, alert, edit.Wiki); err != nil {
-				ewd.logger.Error().Err(err).Str("page", edit.Title).Msg("Failed to publish edit war alert")
+			if err := ewd.publishEditWarAlert(ctx, alert); err != nil {
+				ewd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to publish edit war alert")
 				return err
 			}
 
 			ewd.metrics.EditWarsDetected.WithLabelValues(alert.Severity).Inc()
 			metrics.EditWarsDetectedTotal.WithLabelValues().Inc()
 			ewd.logger.Info().
-				Str("page", edit.Title).
+				Str("page", key.String()).
 				Int("editors", alert.EditorCount).
 				Int("reverts", alert.RevertCount).
 				Str("severity", alert.Severity).
@@ -304,8 +358,8 @@ func (ewd *EditWarDetector) ProcessEdit(ctx context.Context, edit *models.Wikipe
 }
 
 // detectEditWar performs statistical analysis for edit war patterns
-func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string) (*EditWarAlert, error) {
-	editorsKey := fmt.Sprintf("editwar:editors:%s", pageTitle)
+func (ewd *EditWarDetector) detectEditWar(ctx context.Context, key models.PageKey) (*EditWarAlert, error) {
+	editorsKey := fmt.Sprintf("editwar:editors:%s", key)
 
 	// Get all editors and their edit counts
 	editorMap, err := ewd.redis.HGetAll(ctx, editorsKey).Result()
@@ -332,19 +386,16 @@ func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string)
 		return nil, nil
 	}
 
-	// Count reverts using byte change pattern analysis
-	revertCount, err := ewd.countReverts(ctx, pageTitle)
+	// Count the reverts recognised in the window
+	revertCount, err := ewd.countReverts(ctx, key)
 	if err != nil {
-		ewd.logger.Warn().Err(err).Str("page", pageTitle).Msg("Failed to count reverts, defaulting to 0")
+		ewd.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to count reverts, defaulting to 0")
 		revertCount = 0
 	}
 
-	// Also check edit comments for revert indicators
-	// (this is a simple heuristic complement)
-
 	if revertCount < ewd.minReverts {
 		ewd.logger.Debug().
-			Str("page", pageTitle).
+			Str("page", key.String()).
 			Int("editors", uniqueEditors).
 			Int("edits", totalEdits).
 			Int("reverts", revertCount).
@@ -358,8 +409,8 @@ func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string)
 
 	// Derive start time from the first timeline entry's actual edit timestamp.
 	// This gives the real time the first edit occurred, not when we detected it.
-	startTime := time.Now().Add(-ewd.timeWindow) // fallback
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+	startTime := ewd.clock.Now().Add(-ewd.timeWindow) // fallback
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	if firstRaw, tlErr := ewd.redis.LIndex(ctx, timelineKey, 0).Result(); tlErr == nil && firstRaw != "" {
 		var firstEntry struct {
 			Timestamp int64 `json:"timestamp"`
@@ -370,7 +421,7 @@ func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string)
 	}
 
 	// Also check for a persisted first-seen timestamp (set on initial detection)
-	startKey := fmt.Sprintf("editwar:start:%s", pageTitle)
+	startKey := fmt.Sprintf("editwar:start:%s", key)
 	if s, sErr := ewd.redis.Get(ctx, startKey).Result(); sErr == nil && s != "" {
 		if t, pErr := time.Parse(time.RFC3339, s); pErr == nil {
 			// Use the earlier of the two — persisted vs timeline-derived
@@ -381,7 +432,8 @@ func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string)
 	}
 
 	alert := &EditWarAlert{
-		PageTitle:   pageTitle,
+		PageTitle:   key.Title,
+		Wiki:        key.Wiki,
 		EditorCount: uniqueEditors,
 		EditCount:   totalEdits,
 		RevertCount: revertCount,
@@ -393,76 +445,15 @@ func (ewd *EditWarDetector) detectEditWar(ctx context.Context, pageTitle string)
 	return alert, nil
 }
 
-// countReverts detects reversions in the edit sequence by analyzing byte change patterns
-func (ewd *EditWarDetector) countReverts(ctx context.Context, pageTitle string) (int, error) {
-	changesKey := fmt.Sprintf("editwar:changes:%s", pageTitle)
-
-	// Get all byte changes in chronological order
-	changesStr, err := ewd.redis.LRange(ctx, changesKey, 0, -1).Result()
+// countReverts returns how many reverts by one editor of another's work the
+// revert classifier has recorded on the page within the tracking window.
+func (ewd *EditWarDetector) countReverts(ctx context.Context, key models.PageKey) (int, error) {
+	revertsKey := fmt.Sprintf("editwar:reverts:%s", key)
+	n, err := ewd.redis.LLen(ctx, revertsKey).Result()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get byte changes: %w", err)
-	}
-
-	if len(changesStr) < 2 {
-		return 0, nil
-	}
-
-	// Parse byte changes
-	changes := make([]int, 0, len(changesStr))
-	for _, cs := range changesStr {
-		val, err := strconv.Atoi(cs)
-		if err != nil {
-			continue
-		}
-		changes = append(changes, val)
+		return 0, fmt.Errorf("failed to count reverts: %w", err)
 	}
-
-	if len(changes) < 2 {
-		return 0, nil
-	}
-
-	revertCount := 0
-
-	// Look for alternating sign patterns with similar magnitude
-	// Edit 1: +500 bytes
-	// Edit 2: -480 bytes → potential revert (opposite sign, similar magnitude)
-	// Edit 3: +490 bytes → potential revert of revert
-	for i := 1; i < len(changes); i++ {
-		prev := changes[i-1]
-		curr := changes[i]
-
-		// For very small changes (including zeros), count alternating patterns
-		if prev == 0 && curr == 0 {
-			// Two consecutive zero-byte edits often indicate reverts
-			revertCount++
-			continue
-		}
-
-		// Check for opposite signs
-		if (prev > 0 && curr < 0) || (prev < 0 && curr > 0) {
-			// For very small edits, be lenient
-			if math.Abs(float64(prev)) < 10 && math.Abs(float64(curr)) < 10 {
-				revertCount++
-				continue
-			}
-
-			// Check if magnitudes are similar (within 30% tolerance for better detection)
-			absPrev := math.Abs(float64(prev))
-			absCurr := math.Abs(float64(curr))
-
-			ratio := absCurr / absPrev
-			if ratio > 1 {
-				ratio = absPrev / absCurr
-			}
-
-			// If magnitudes are within 30% of each other, likely a revert
-			if ratio >= 0.6 {
-				revertCount++
-			}
-		}
-	}
-
-	return revertCount, nil
+	return int(n), nil
 }
 
 // calculateEditWarSeverity determines severity based on edit patterns
@@ -484,7 +475,9 @@ func (ewd *EditWarDetector) calculateEditWarSeverity(editCount, editorCount, rev
 }
 
 // publishEditWarAlert stores the alert in a Redis stream and marks the page
-func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *EditWarAlert, wiki string) error {
+func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *EditWarAlert) error {
+	key := models.NewPageKey(alert.Wiki, alert.PageTitle)
+
 	// Serialize to JSON
 	alertData, err := json.Marshal(alert)
 	if err != nil {
@@ -500,6 +493,7 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 			"data":     string(alertData),
 			"severity": alert.Severity,
 			"page":     alert.PageTitle,
+			"wiki":     alert.Wiki,
 		},
 	}
 
@@ -514,16 +508,15 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 	// for 30 min the marker expires and the war moves to history.
 	// Data keys (editors, timeline, changes, start, serverurl) keep a
 	// longer 12h TTL so LLM analysis data is preserved across gaps.
-	editWarKey := fmt.Sprintf("editwar:%s", alert.PageTitle)
+	// The marker doubles as the indexing strategy's edit war flag.
+	editWarKey := fmt.Sprintf("editwar:%s", key)
 	if err := ewd.redis.Set(ctx, editWarKey, 1, 30*time.Minute).Err(); err != nil {
-		ewd.logger.Warn().Err(err).Str("page", alert.PageTitle).Msg("Failed to set editwar marker key")
+		ewd.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to set editwar marker key")
 	}
 
 	// Persist server_url so the frontend can build correct wiki links
-	if wiki != "" {
-		lang := strings.TrimSuffix(wiki, "wiki")
-		serverURL := fmt.Sprintf("https://%s.wikipedia.org", lang)
-		urlKey := fmt.Sprintf("editwar:serverurl:%s", alert.PageTitle)
+	if serverURL := models.ServerURLForWiki(alert.Wiki); serverURL != "" {
+		urlKey := fmt.Sprintf("editwar:serverurl:%s", key)
 		_ = ewd.redis.Set(ctx, urlKey, serverURL, 12*time.Hour).Err()
 	}
 
@@ -533,11 +526,11 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 	// with the marker when the war ends.
 	// Persist the actual start time from the alert (derived from edit data)
 	// rather than time.Now() so that duration tracking reflects reality.
-	startKey := fmt.Sprintf("editwar:start:%s", alert.PageTitle)
+	startKey := fmt.Sprintf("editwar:start:%s", key)
 	firstSeen := alert.StartTime.UTC().Format(time.RFC3339)
 	set, err := ewd.redis.SetNX(ctx, startKey, firstSeen, 12*time.Hour).Result()
 	if err != nil {
-		ewd.logger.Warn().Err(err).Str("page", alert.PageTitle).Msg("Failed to set editwar start key")
+		ewd.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to set editwar start key")
 	} else if !set {
 		// Key already exists — refresh TTL to keep it alive while war remains active
 		_ = ewd.redis.Expire(ctx, startKey, 12*time.Hour).Err()
@@ -545,24 +538,17 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 
 	// Refresh the timeline key TTL to match the 12h edit war marker so
 	// LLM analysis data stays available as long as the war is active.
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", alert.PageTitle)
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	_ = ewd.redis.Expire(ctx, timelineKey, 12*time.Hour).Err()
 
-	// Also extend editors and changes TTLs to 12h so counters survive
-	// gaps between edits (prevents the 1-edit/1-editor reset problem).
-	editorsRefreshKey := fmt.Sprintf("editwar:editors:%s", alert.PageTitle)
+	// Also extend editors, changes and reverts TTLs to 12h so counters
+	// survive gaps between edits (prevents the 1-edit/1-editor reset problem).
+	editorsRefreshKey := fmt.Sprintf("editwar:editors:%s", key)
 	_ = ewd.redis.Expire(ctx, editorsRefreshKey, 12*time.Hour).Err()
-	changesRefreshKey := fmt.Sprintf("editwar:changes:%s", alert.PageTitle)
+	changesRefreshKey := fmt.Sprintf("editwar:changes:%s", key)
 	_ = ewd.redis.Expire(ctx, changesRefreshKey, 12*time.Hour).Err()
-
-	// Also set editwar:{wiki}:{title} for indexing strategy compatibility
-	// Uses the same short 30-min TTL as the main marker.
-	if wiki != "" {
-		editWarWikiKey := fmt.Sprintf("editwar:%s:%s", wiki, alert.PageTitle)
-		if err := ewd.redis.Set(ctx, editWarWikiKey, 1, 30*time.Minute).Err(); err != nil {
-			ewd.logger.Warn().Err(err).Str("page", alert.PageTitle).Msg("Failed to set editwar wiki marker key")
-		}
-	}
+	revertsRefreshKey := fmt.Sprintf("editwar:reverts:%s", key)
+	_ = ewd.redis.Expire(ctx, revertsRefreshKey, 12*time.Hour).Err()
 
 	ewd.metrics.AlertsPublished.Inc()
 
@@ -573,18 +559,18 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 	if ewd.analysisService != nil {
 		select {
 		case ewd.analysisSem <- struct{}{}:
-			go func(page string) {
+			go func(page models.PageKey) {
 				defer func() { <-ewd.analysisSem }()
 				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 				defer cancel()
 				if _, err := ewd.analysisService.Analyze(ctx, page); err != nil {
-					ewd.logger.Warn().Err(err).Str("page", page).Msg("Auto-analysis failed for new edit war")
+					ewd.logger.Warn().Err(err).Str("page", page.String()).Msg("Auto-analysis failed for new edit war")
 				} else {
-					ewd.logger.Info().Str("page", page).Msg("Auto-analysis completed for edit war")
+					ewd.logger.Info().Str("page", page.String()).Msg("Auto-analysis completed for edit war")
 				}
-			}(alert.PageTitle)
+			}(key)
 		default:
-			ewd.logger.Warn().Str("page", alert.PageTitle).Msg("Skipping auto-analysis: too many concurrent analyses")
+			ewd.logger.Warn().Str("page", key.String()).Msg("Skipping auto-analysis: too many concurrent analyses")
 		}
 	}
 
@@ -594,12 +580,12 @@ func (ewd *EditWarDetector) publishEditWarAlert(ctx context.Context, alert *Edit
 // maybeReanalyze triggers a fresh LLM re-analysis every N edits on an active
 // edit war, using a Redis counter. This keeps the analysis current as the
 // conflict evolves without calling the LLM on every single edit.
-func (ewd *EditWarDetector) maybeReanalyze(ctx context.Context, pageTitle string) {
+func (ewd *EditWarDetector) maybeReanalyze(ctx context.Context, key models.PageKey) {
 	if ewd.analysisService == nil || ewd.reanalyzeEvery <= 0 {
 		return
 	}
 
-	counterKey := fmt.Sprintf("editwar:reanalyze_ctr:%s", pageTitle)
+	counterKey := fmt.Sprintf("editwar:reanalyze_ctr:%s", key)
 	count, err := ewd.redis.Incr(ctx, counterKey).Result()
 	if err != nil {
 		return
@@ -615,18 +601,18 @@ func (ewd *EditWarDetector) maybeReanalyze(ctx context.Context, pageTitle string
 
 	select {
 	case ewd.analysisSem <- struct{}{}:
-		go func(page string) {
+		go func(page models.PageKey) {
 			defer func() { <-ewd.analysisSem }()
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 			if _, err := ewd.analysisService.Reanalyze(ctx, page); err != nil {
-				ewd.logger.Warn().Err(err).Str("page", page).Msg("Periodic re-analysis failed")
+				ewd.logger.Warn().Err(err).Str("page", page.String()).Msg("Periodic re-analysis failed")
 			} else {
-				ewd.logger.Info().Str("page", page).Int64("edit_num", count).Msg("Periodic re-analysis completed")
+				ewd.logger.Info().Str("page", page.String()).Int64("edit_num", count).Msg("Periodic re-analysis completed")
 			}
-		}(pageTitle)
+		}(key)
 	default:
-		ewd.logger.Warn().Str("page", pageTitle).Msg("Skipping re-analysis: too many concurrent analyses")
+		ewd.logger.Warn().Str("page", key.String()).Msg("Skipping re-analysis: too many concurrent analyses")
 	}
 }
 
@@ -713,6 +699,7 @@ func (ewd *EditWarDetector) sweepDeactivatedWars(ctx context.Context, trackingKe
 		if currentlyActive[page] {
 			continue // still active, nothing to do
 		}
+		key := models.ParsePageKey(page)
 
 		// This war just became inactive.
 		ewd.logger.Info().Str("page", page).Msg("Edit war deactivated, running final analysis + snapshot")
@@ -721,7 +708,7 @@ func (ewd *EditWarDetector) sweepDeactivatedWars(ctx context.Context, trackingKe
 		// so the analysis is cached and can be embedded in the stream entry.
 		// This makes the snapshot fully self-contained for digests.
 		fCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
-		if _, err := ewd.analysisService.FinalizeAnalysis(fCtx, page); err != nil {
+		if _, err := ewd.analysisService.FinalizeAnalysis(fCtx, key); err != nil {
 			ewd.logger.Warn().Err(err).Str("page", page).Msg("Final analysis failed for deactivated edit war")
 		} else {
 			ewd.logger.Info().Str("page", page).Msg("Final analysis completed for deactivated edit war")
@@ -731,7 +718,7 @@ func (ewd *EditWarDetector) sweepDeactivatedWars(ctx context.Context, trackingKe
 		// Write a final snapshot to the stream with accumulated lifetime
 		// counts + embedded LLM analysis. This becomes the authoritative
 		// historical record — superseding the stale mid-war alert entries.
-		ewd.writeFinalSnapshot(ctx, page)
+		ewd.writeFinalSnapshot(ctx, key)
 
 		// Remove from tracking set so we don't re-trigger.
 		_ = ewd.redis.SRem(ctx, trackingKey, page).Err()
@@ -743,13 +730,13 @@ func (ewd *EditWarDetector) sweepDeactivatedWars(ctx context.Context, trackingKe
 // alerts:editwars stream. This snapshot contains the true total counts
 // (editors, edits, reverts) over the war's entire lifetime and supersedes
 // the stale mid-war alert entries in the stream.
-func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle string) {
+func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, key models.PageKey) {
 	// --- Editors and edit counts ---
-	editorsKey := fmt.Sprintf("editwar:editors:%s", pageTitle)
+	editorsKey := fmt.Sprintf("editwar:editors:%s", key)
 	editorMap, err := ewd.redis.HGetAll(ctx, editorsKey).Result()
 	if err != nil || len(editorMap) == 0 {
 		// Try reconstructing from timeline as fallback
-		editorMap = ewd.editorsFromTimeline(ctx, pageTitle)
+		editorMap = ewd.editorsFromTimeline(ctx, key)
 	}
 
 	editors := make([]string, 0, len(editorMap))
@@ -761,26 +748,26 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 	}
 
 	if len(editors) == 0 {
-		ewd.logger.Warn().Str("page", pageTitle).Msg("Final snapshot: no editor data found, skipping")
+		ewd.logger.Warn().Str("page", key.String()).Msg("Final snapshot: no editor data found, skipping")
 		return
 	}
 
 	// --- Revert count ---
-	revertCount, _ := ewd.countReverts(ctx, pageTitle)
+	revertCount, _ := ewd.countReverts(ctx, key)
 
 	// --- Severity (recompute from final totals) ---
 	severity := ewd.calculateEditWarSeverity(totalEdits, len(editors), revertCount)
 
 	// --- Start time ---
-	startTime := time.Now()
-	startKey := fmt.Sprintf("editwar:start:%s", pageTitle)
+	startTime := ewd.clock.Now()
+	startKey := fmt.Sprintf("editwar:start:%s", key)
 	if s, sErr := ewd.redis.Get(ctx, startKey).Result(); sErr == nil && s != "" {
 		if t, pErr := time.Parse(time.RFC3339, s); pErr == nil {
 			startTime = t
 		}
 	} else {
 		// Fallback: first timeline entry
-		timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+		timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 		if firstRaw, tlErr := ewd.redis.LIndex(ctx, timelineKey, 0).Result(); tlErr == nil && firstRaw != "" {
 			var firstEntry struct {
 				Timestamp int64 `json:"timestamp"`
@@ -793,14 +780,14 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 
 	// --- Server URL ---
 	serverURL := ""
-	urlKey := fmt.Sprintf("editwar:serverurl:%s", pageTitle)
+	urlKey := fmt.Sprintf("editwar:serverurl:%s", key)
 	if u, uErr := ewd.redis.Get(ctx, urlKey).Result(); uErr == nil && u != "" {
 		serverURL = u
 	}
 
 	// --- LLM analysis (already cached by FinalizeAnalysis above) ---
 	var llmSummary, contentArea string
-	analysisKey := fmt.Sprintf("editwar:analysis:%s", pageTitle)
+	analysisKey := fmt.Sprintf("editwar:analysis:%s", key)
 	if cached, cErr := ewd.redis.Get(ctx, analysisKey).Result(); cErr == nil && cached != "" {
 		var analysis struct {
 			Summary     string `json:"summary"`
@@ -814,7 +801,8 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 
 	// --- Build the final alert and write to stream ---
 	finalAlert := &EditWarAlert{
-		PageTitle:   pageTitle,
+		PageTitle:   key.Title,
+		Wiki:        key.Wiki,
 		EditorCount: len(editors),
 		EditCount:   totalEdits,
 		RevertCount: revertCount,
@@ -828,7 +816,7 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 
 	alertData, err := json.Marshal(finalAlert)
 	if err != nil {
-		ewd.logger.Warn().Err(err).Str("page", pageTitle).Msg("Final snapshot: marshal failed")
+		ewd.logger.Warn().Err(err).Str("page", key.String()).Msg("Final snapshot: marshal failed")
 		return
 	}
 
@@ -839,18 +827,19 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 		Values: map[string]interface{}{
 			"data":     string(alertData),
 			"severity": severity,
-			"page":     pageTitle,
+			"page":     key.Title,
+			"wiki":     key.Wiki,
 			"final":    "true",
 		},
 	}
 
 	if _, err := ewd.redis.XAdd(ctx, args).Result(); err != nil {
-		ewd.logger.Warn().Err(err).Str("page", pageTitle).Msg("Final snapshot: failed to write to stream")
+		ewd.logger.Warn().Err(err).Str("page", key.String()).Msg("Final snapshot: failed to write to stream")
 		return
 	}
 
 	ewd.logger.Info().
-		Str("page", pageTitle).
+		Str("page", key.String()).
 		Int("editors", len(editors)).
 		Int("edits", totalEdits).
 		Int("reverts", revertCount).
@@ -860,8 +849,8 @@ func (ewd *EditWarDetector) writeFinalSnapshot(ctx context.Context, pageTitle st
 
 // editorsFromTimeline reconstructs editor counts from the timeline list
 // when the editors hash has expired.
-func (ewd *EditWarDetector) editorsFromTimeline(ctx context.Context, pageTitle string) map[string]string {
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+func (ewd *EditWarDetector) editorsFromTimeline(ctx context.Context, key models.PageKey) map[string]string {
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	entries, err := ewd.redis.LRange(ctx, timelineKey, 0, -1).Result()
 	if err != nil || len(entries) == 0 {
 		return nil
@@ -896,11 +885,11 @@ func (ewd *EditWarDetector) GetActiveEditWars(ctx context.Context) ([]*EditWarAl
 		}
 
 		for _, key := range keys {
-			// Extract page title from key
-			pageTitle := strings.TrimPrefix(key, "editwar:editors:")
+			// Extract page key from the editors key
+			pageKey := models.ParsePageKey(strings.TrimPrefix(key, "editwar:editors:"))
 
 			// Check if this page is actually marked as having an edit war
-			editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+			editWarKey := fmt.Sprintf("editwar:%s", pageKey)
 			exists, err := ewd.redis.Exists(ctx, editWarKey).Result()
 			if err != nil || exists == 0 {
 				continue
@@ -920,13 +909,13 @@ func (ewd *EditWarDetector) GetActiveEditWars(ctx context.Context) ([]*EditWarAl
 				totalEdits += count
 			}
 
-			revertCount, _ := ewd.countReverts(ctx, pageTitle)
+			revertCount, _ := ewd.countReverts(ctx, pageKey)
 
 			severity := ewd.calculateEditWarSeverity(totalEdits, len(editors), revertCount)
 
 			// Derive start time from persisted key or TTL
-			warStart := time.Now().Add(-ewd.timeWindow) // fallback
-			startKey := fmt.Sprintf("editwar:start:%s", pageTitle)
+			warStart := ewd.clock.Now().Add(-ewd.timeWindow) // fallback
+			startKey := fmt.Sprintf("editwar:start:%s", pageKey)
 			if s, sErr := ewd.redis.Get(ctx, startKey).Result(); sErr == nil && s != "" {
 				if t, pErr := time.Parse(time.RFC3339, s); pErr == nil {
 					warStart = t
@@ -936,12 +925,13 @@ func (ewd *EditWarDetector) GetActiveEditWars(ctx context.Context) ([]*EditWarAl
 				ttl, ttlErr := ewd.redis.TTL(ctx, key).Result()
 				if ttlErr == nil && ttl > 0 && ttl <= ewd.timeWindow {
 					elapsed := ewd.timeWindow - ttl
-					warStart = time.Now().Add(-elapsed)
+					warStart = ewd.clock.Now().Add(-elapsed)
 				}
 			}
 
 			activeWars = append(activeWars, &EditWarAlert{
-				PageTitle:   pageTitle,
+				PageTitle:   pageKey.Title,
+				Wiki:        pageKey.Wiki,
 				EditorCount: len(editors),
 				EditCount:   totalEdits,
 				RevertCount: revertCount,
@@ -991,20 +981,21 @@ func (ewd *EditWarDetector) GetRecentAlerts(ctx context.Context, since time.Time
 
 // TimelineEntry represents a single edit in the edit war timeline, stored in Redis.
 type TimelineEntry struct {
-	User       string `json:"user"`
-	Comment    string `json:"comment"`
-	ByteChange int    `json:"byte_change"`
-	Timestamp  int64  `json:"timestamp"`
-	RevisionID int64  `json:"revision_id,omitempty"`
-	ServerURL  string `json:"server_url,omitempty"`
+	User       string  `json:"user"`
+	Comment    string  `json:"comment"`
+	ByteChange int     `json:"byte_change"`
+	Timestamp  int64   `json:"timestamp"`
+	RevisionID int64   `json:"revision_id,omitempty"`
+	ServerURL  string  `json:"server_url,omitempty"`
+	Revert     *Revert `json:"revert,omitempty"` // set when the edit reverted other editors
 }
 
 // GetTimeline retrieves the edit timeline for a page's edit war from Redis.
-func (ewd *EditWarDetector) GetTimeline(ctx context.Context, pageTitle string) ([]TimelineEntry, error) {
-	timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+func (ewd *EditWarDetector) GetTimeline(ctx context.Context, key models.PageKey) ([]TimelineEntry, error) {
+	timelineKey := fmt.Sprintf("editwar:timeline:%s", key)
 	raw, err := ewd.redis.LRange(ctx, timelineKey, 0, -1).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get timeline for %s: %w", pageTitle, err)
+		return nil, fmt.Errorf("failed to get timeline for %s: %w", key, err)
 	}
 
 	entries := make([]TimelineEntry, 0, len(raw))
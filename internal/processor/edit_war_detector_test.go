@@ -9,6 +9,7 @@ import (
 	"github.com/Agnikulu/WikiSurge/internal/config"
 	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
@@ -71,7 +72,7 @@ func promotePageToHot(t *testing.T, ctx context.Context, hotPages *storage.HotPa
 		require.NoError(t, err)
 	}
 	// Verify promoted
-	isHot, err := hotPages.IsHot(ctx, pageTitle)
+	isHot, err := hotPages.IsHot(ctx, models.NewPageKey("enwiki", pageTitle))
 	require.NoError(t, err)
 	require.True(t, isHot, "Page %s should be hot after promotion edits", pageTitle)
 }
@@ -97,6 +98,14 @@ func makeEdit(id int64, title, user string, oldLen, newLen int) *models.Wikipedi
 	}
 }
 
+// makeRevert is makeEdit for an undo of victim's revision, with the summary
+// MediaWiki writes for it.
+func makeRevert(id int64, title, user, victim string, oldLen, newLen int) *models.WikipediaEdit {
+	edit := makeEdit(id, title, user, oldLen, newLen)
+	edit.Comment = fmt.Sprintf("Undid revision %d by [[Special:Contributions/%s|%s]] ([[User talk:%s|talk]])", id-1, victim, victim, victim)
+	return edit
+}
+
 // TestScenario1_ClearEditWar tests detection of a clear edit war pattern
 func TestScenario1_ClearEditWar(t *testing.T) {
 	detector, hotPages, redisClient := setupEditWarTestComponents(t)
@@ -108,15 +117,15 @@ func TestScenario1_ClearEditWar(t *testing.T) {
 	// Promote page to hot
 	promotePageToHot(t, ctx, hotPages, pageTitle)
 
-	// Simulate clear edit war pattern:
-	// User A: +500, User B: -480, User A: +490, User B: -495, User A: +500
+	// Simulate clear edit war pattern: after A's first edit, A and B
+	// undo each other
 	edits := []*models.WikipediaEdit{
-		makeEdit(1001, pageTitle, "UserA", 1000, 1500), // +500
-		makeEdit(1002, pageTitle, "UserB", 1500, 1020), // -480
-		makeEdit(1003, pageTitle, "UserA", 1020, 1510), // +490
-		makeEdit(1004, pageTitle, "UserB", 1510, 1015), // -495
-		makeEdit(1005, pageTitle, "UserA", 1015, 1515), // +500
-		makeEdit(1006, pageTitle, "UserB", 1515, 1025), // -490
+		makeEdit(1001, pageTitle, "UserA", 1000, 1500),            // +500
+		makeRevert(1002, pageTitle, "UserB", "UserA", 1500, 1020), // -480
+		makeRevert(1003, pageTitle, "UserA", "UserB", 1020, 1510), // +490
+		makeRevert(1004, pageTitle, "UserB", "UserA", 1510, 1015), // -495
+		makeRevert(1005, pageTitle, "UserA", "UserB", 1015, 1515), // +500
+		makeRevert(1006, pageTitle, "UserB", "UserA", 1515, 1025), // -490
 	}
 
 	for _, edit := range edits {
@@ -125,7 +134,7 @@ func TestScenario1_ClearEditWar(t *testing.T) {
 	}
 
 	// Verify edit war was detected
-	editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+	editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 	exists, err := redisClient.Exists(ctx, editWarKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), exists, "Expected editwar marker key to exist")
@@ -171,7 +180,7 @@ func TestScenario2_CollaborativeEditing(t *testing.T) {
 	}
 
 	// Verify no edit war detected
-	editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+	editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 	exists, err := redisClient.Exists(ctx, editWarKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), exists, "Expected no editwar marker for collaborative editing")
@@ -191,7 +200,7 @@ func TestScenario3_SingleVandal(t *testing.T) {
 	// but only 1 revert pair, so under the threshold
 	edits := []*models.WikipediaEdit{
 		makeEdit(3001, pageTitle, "Vandal", 5000, 100),  // -4900 (vandalism)
-		makeEdit(3002, pageTitle, "Admin", 100, 5000),    // +4900 (revert)
+		makeRevert(3002, pageTitle, "Admin", "Vandal", 100, 5000), // +4900 (revert)
 		makeEdit(3003, pageTitle, "Admin", 5000, 5050),   // +50 (minor cleanup)
 		makeEdit(3004, pageTitle, "Admin", 5050, 5100),   // +50
 		makeEdit(3005, pageTitle, "Admin", 5100, 5120),   // +20
@@ -203,7 +212,7 @@ func TestScenario3_SingleVandal(t *testing.T) {
 	}
 
 	// With only 1 revert pair: should be under minReverts=2
-	editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+	editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 	exists, err := redisClient.Exists(ctx, editWarKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), exists,
@@ -225,7 +234,7 @@ func TestScenario4_ThresholdTesting(t *testing.T) {
 		// 4 edits from 2 users with reverts but below minEdits=5
 		edits := []*models.WikipediaEdit{
 			makeEdit(4001, pageTitle, "UserX", 1000, 1500), // +500
-			makeEdit(4002, pageTitle, "UserY", 1500, 1020), // -480
+			makeRevert(4002, pageTitle, "UserY", "UserX", 1500, 1020), // -480
 		}
 
 		for _, edit := range edits {
@@ -233,7 +242,7 @@ func TestScenario4_ThresholdTesting(t *testing.T) {
 			require.NoError(t, err)
 		}
 
-		editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+		editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 		exists, err := redisClient.Exists(ctx, editWarKey).Result()
 		require.NoError(t, err)
 		assert.Equal(t, int64(0), exists, "Expected no editwar for below-threshold scenario")
@@ -246,12 +255,12 @@ func TestScenario4_ThresholdTesting(t *testing.T) {
 
 		// 6+ edits, 2 users, alternating reverts
 		edits := []*models.WikipediaEdit{
-			makeEdit(5001, pageTitle, "UserX", 1000, 1500), // +500
-			makeEdit(5002, pageTitle, "UserY", 1500, 1020), // -480
-			makeEdit(5003, pageTitle, "UserX", 1020, 1510), // +490
-			makeEdit(5004, pageTitle, "UserY", 1510, 1030), // -480
-			makeEdit(5005, pageTitle, "UserX", 1030, 1520), // +490
-			makeEdit(5006, pageTitle, "UserY", 1520, 1040), // -480
+			makeEdit(5001, pageTitle, "UserX", 1000, 1500),            // +500
+			makeRevert(5002, pageTitle, "UserY", "UserX", 1500, 1020), // -480
+			makeRevert(5003, pageTitle, "UserX", "UserY", 1020, 1510), // +490
+			makeRevert(5004, pageTitle, "UserY", "UserX", 1510, 1030), // -480
+			makeRevert(5005, pageTitle, "UserX", "UserY", 1030, 1520), // +490
+			makeRevert(5006, pageTitle, "UserY", "UserX", 1520, 1040), // -480
 		}
 
 		for _, edit := range edits {
@@ -259,62 +268,56 @@ func TestScenario4_ThresholdTesting(t *testing.T) {
 			require.NoError(t, err)
 		}
 
-		editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+		editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 		exists, err := redisClient.Exists(ctx, editWarKey).Result()
 		require.NoError(t, err)
 		assert.Equal(t, int64(1), exists, "Expected editwar marker for above-threshold scenario")
 	})
 }
 
-// TestCountReverts tests the revert detection heuristic
+// TestCountReverts tests that only recognised reverts are counted
 func TestCountReverts(t *testing.T) {
-	detector, _, redisClient := setupEditWarTestComponents(t)
+	detector, hotPages, redisClient := setupEditWarTestComponents(t)
 	defer redisClient.Close()
 
 	ctx := context.Background()
 
-	t.Run("Alternating reverts", func(t *testing.T) {
-		page := "revert_test_1"
-		changesKey := fmt.Sprintf("editwar:changes:%s", page)
+	t.Run("Undos are counted", func(t *testing.T) {
+		pageTitle := "revert_test_1"
+		promotePageToHot(t, ctx, hotPages, pageTitle)
 
-		// Push alternating changes: +500, -480, +490, -495, +500
-		changes := []int{500, -480, 490, -495, 500}
-		for _, c := range changes {
-			redisClient.RPush(ctx, changesKey, c)
+		edits := []*models.WikipediaEdit{
+			makeEdit(6001, pageTitle, "UserA", 1000, 1500),
+			makeRevert(6002, pageTitle, "UserB", "UserA", 1500, 1000),
+			makeRevert(6003, pageTitle, "UserA", "UserB", 1000, 1500),
 		}
-
-		count, err := detector.countReverts(ctx, page)
-		require.NoError(t, err)
-		assert.Equal(t, 4, count, "Expected 4 reverts in alternating pattern")
-	})
-
-	t.Run("No reverts - all positive", func(t *testing.T) {
-		page := "revert_test_2"
-		changesKey := fmt.Sprintf("editwar:changes:%s", page)
-
-		changes := []int{100, 200, 50, 150, 300}
-		for _, c := range changes {
-			redisClient.RPush(ctx, changesKey, c)
+		for _, edit := range edits {
+			require.NoError(t, detector.ProcessEdit(ctx, edit))
 		}
 
-		count, err := detector.countReverts(ctx, page)
+		count, err := detector.countReverts(ctx, models.NewPageKey("enwiki", pageTitle))
 		require.NoError(t, err)
-		assert.Equal(t, 0, count, "Expected 0 reverts for all-positive changes")
+		assert.Equal(t, 2, count, "Expected the 2 undos to be counted")
 	})
 
-	t.Run("Opposite signs but different magnitudes", func(t *testing.T) {
-		page := "revert_test_3"
-		changesKey := fmt.Sprintf("editwar:changes:%s", page)
+	t.Run("Back-and-forth without reverts", func(t *testing.T) {
+		pageTitle := "revert_test_2"
+		promotePageToHot(t, ctx, hotPages, pageTitle)
 
-		// +500, -100 (not similar magnitude)
-		changes := []int{500, -100, 300, -50}
-		for _, c := range changes {
-			redisClient.RPush(ctx, changesKey, c)
+		// Alternating signs of similar size, but ordinary copy-editing
+		edits := []*models.WikipediaEdit{
+			makeEdit(6101, pageTitle, "UserA", 1000, 1500),
+			makeEdit(6102, pageTitle, "UserB", 1500, 1020),
+			makeEdit(6103, pageTitle, "UserA", 1020, 1510),
+			makeEdit(6104, pageTitle, "UserB", 1510, 1030),
+		}
+		for _, edit := range edits {
+			require.NoError(t, detector.ProcessEdit(ctx, edit))
 		}
 
-		count, err := detector.countReverts(ctx, page)
+		count, err := detector.countReverts(ctx, models.NewPageKey("enwiki", pageTitle))
 		require.NoError(t, err)
-		assert.Equal(t, 0, count, "Expected 0 reverts for dissimilar magnitudes")
+		assert.Equal(t, 0, count, "Expected no reverts without revert evidence")
 	})
 }
 
@@ -408,12 +411,12 @@ func TestEditWarIntegration(t *testing.T) {
 	// Process edits simulating an edit war
 	edits := []*models.WikipediaEdit{
 		makeEdit(7001, pageTitle, "Editor1", 2000, 2500),
-		makeEdit(7002, pageTitle, "Editor2", 2500, 2020),
-		makeEdit(7003, pageTitle, "Editor1", 2020, 2490),
-		makeEdit(7004, pageTitle, "Editor2", 2490, 2010),
-		makeEdit(7005, pageTitle, "Editor1", 2010, 2510),
-		makeEdit(7006, pageTitle, "Editor2", 2510, 2020),
-		makeEdit(7007, pageTitle, "Editor3", 2020, 2505),
+		makeRevert(7002, pageTitle, "Editor2", "Editor1", 2500, 2020),
+		makeRevert(7003, pageTitle, "Editor1", "Editor2", 2020, 2490),
+		makeRevert(7004, pageTitle, "Editor2", "Editor1", 2490, 2010),
+		makeRevert(7005, pageTitle, "Editor1", "Editor2", 2010, 2510),
+		makeRevert(7006, pageTitle, "Editor2", "Editor1", 2510, 2020),
+		makeRevert(7007, pageTitle, "Editor3", "Editor2", 2020, 2505),
 	}
 
 	for _, edit := range edits {
@@ -426,26 +429,26 @@ func TestEditWarIntegration(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, len(alerts) > 0, "Expected alert in stream")
 
-	// Verify: page marked with editwar key
-	editWarKey := fmt.Sprintf("editwar:%s", pageTitle)
+	// Verify: page marked with the wiki-qualified editwar key, which is
+	// also what the indexing strategy checks
+	editWarKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
 	exists, err := redisClient.Exists(ctx, editWarKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), exists, "Expected editwar marker")
 
-	// Verify: editwar wiki key set for indexing strategy
-	editWarWikiKey := fmt.Sprintf("editwar:enwiki:%s", pageTitle)
-	wikiExists, err := redisClient.Exists(ctx, editWarWikiKey).Result()
+	// Verify: no title-only marker is written any more
+	legacyExists, err := redisClient.Exists(ctx, fmt.Sprintf("editwar:%s", pageTitle)).Result()
 	require.NoError(t, err)
-	assert.Equal(t, int64(1), wikiExists, "Expected editwar wiki marker for indexing strategy")
+	assert.Equal(t, int64(0), legacyExists, "Expected no legacy editwar marker")
 
 	// Verify: editor tracking hash exists
-	editorsKey := fmt.Sprintf("editwar:editors:%s", pageTitle)
+	editorsKey := fmt.Sprintf("editwar:editors:enwiki:%s", pageTitle)
 	editorCount, err := redisClient.HLen(ctx, editorsKey).Result()
 	require.NoError(t, err)
 	assert.True(t, editorCount >= 2, "Expected at least 2 editors tracked")
 
 	// Verify: byte changes list exists
-	changesKey := fmt.Sprintf("editwar:changes:%s", pageTitle)
+	changesKey := fmt.Sprintf("editwar:changes:enwiki:%s", pageTitle)
 	changeCount, err := redisClient.LLen(ctx, changesKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(len(edits)), changeCount, "Expected %d byte changes stored", len(edits))
@@ -463,8 +466,48 @@ func TestProcessEditNonHotPage(t *testing.T) {
 	require.NoError(t, err)
 
 	// No editor tracking should exist
-	editorsKey := fmt.Sprintf("editwar:editors:%s", "NonHot_Page")
+	editorsKey := fmt.Sprintf("editwar:editors:enwiki:%s", "NonHot_Page")
 	exists, err := redisClient.Exists(ctx, editorsKey).Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), exists, "Expected no tracking for non-hot page")
 }
+
+func TestEditWarStartTimes_FollowEventClockOnReplay(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Processor: config.Processor{EventTime: config.EventTimeConfig{Enabled: true}},
+	}
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	detector := NewEditWarDetector(hotPages, client, cfg, zerolog.Nop())
+
+	// Replaying an edit from last year moves the clock back to it.
+	recorded := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	edit := makeEdit(1, "Replayed_War", "UserA", 1000, 1500)
+	edit.Timestamp = recorded.Unix()
+	_, ok := detector.clock.Observe(edit)
+	require.True(t, ok)
+
+	ctx := context.Background()
+	key := edit.PageKey()
+	require.NoError(t, client.Set(ctx, fmt.Sprintf("editwar:%s", key), 1, 30*time.Minute).Err())
+	require.NoError(t, client.HSet(ctx, fmt.Sprintf("editwar:editors:%s", key), "UserA", 3, "UserB", 3).Err())
+
+	// Without a persisted start or a timeline, start times fall back to
+	// the event clock rather than the wall clock.
+	wars, err := detector.GetActiveEditWars(ctx)
+	require.NoError(t, err)
+	require.Len(t, wars, 1)
+	assert.WithinDuration(t, recorded, wars[0].StartTime, detector.timeWindow)
+
+	detector.writeFinalSnapshot(ctx, key)
+	alerts, err := detector.GetRecentAlerts(ctx, time.Unix(0, 0), 10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.True(t, alerts[0].StartTime.Equal(recorded), "final snapshot start = %v, want %v", alerts[0].StartTime, recorded)
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// EditorProfiler is a Kafka MessageHandler that folds every edit into its
+// editor's profile: edits per wiki, pages touched, bytes added and removed,
+// reverts given and received, and pages edited during an edit war.
+//
+// Reverts are recognised from edit summaries only. The edit war detector's
+// history lookups are too costly to repeat for every edit on every wiki.
+type EditorProfiler struct {
+	store  *storage.EditorStore
+	redis  *redis.Client
+	dedup  *storage.EditDeduplicator
+	logger zerolog.Logger
+}
+
+// NewEditorProfiler creates a profiler writing to store.
+func NewEditorProfiler(store *storage.EditorStore, redisClient *redis.Client, logger zerolog.Logger) *EditorProfiler {
+	return &EditorProfiler{
+		store:  store,
+		redis:  redisClient,
+		logger: logger.With().Str("component", "editor-profiler").Logger(),
+	}
+}
+
+// ProcessEdit implements kafka.MessageHandler.
+func (ep *EditorProfiler) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return ep.dedup.Process(ctx, "editor-profiler", edit, func() error {
+		return ep.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit skip revisions already counted.
+func (ep *EditorProfiler) SetDeduplicator(d *storage.EditDeduplicator) {
+	ep.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (ep *EditorProfiler) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	if edit.User == "" || (edit.Type != "edit" && edit.Type != "new") {
+		return nil
+	}
+
+	at := edit.EventTime()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	a := storage.EditorActivity{
+		Wiki:       edit.Wiki,
+		User:       edit.User,
+		Title:      edit.Title,
+		Bot:        edit.Bot,
+		NewPage:    edit.Type == "new",
+		ByteChange: edit.ByteChange(),
+		At:         at,
+	}
+
+	if rv := parseRevertComment(edit.Comment); rv != nil {
+		for _, u := range rv.RevertedUsers {
+			if u != edit.User {
+				a.Reverted = append(a.Reverted, u)
+			}
+		}
+		// Undoing only one's own edits is not a revert of anyone
+		a.Revert = len(rv.RevertedUsers) == 0 || len(a.Reverted) > 0
+	}
+
+	inWar, err := ep.redis.Exists(ctx, fmt.Sprintf("editwar:%s", edit.PageKey())).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check edit war: %w", err)
+	}
+	a.InEditWar = inWar > 0
+
+	if err := ep.store.Record(ctx, a); err != nil {
+		ep.logger.Error().Err(err).
+			Str("wiki", edit.Wiki).
+			Str("user", ep.store.Name(edit.User)).
+			Msg("Failed to record editor activity")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorProfiler_ProcessEdit(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	store := storage.NewEditorStore(client, config.EditorProfilesConfig{
+		TTL: 30 * 24 * time.Hour, RecentPages: 10, MaxRanked: 100,
+	})
+	profiler := NewEditorProfiler(store, client, zerolog.Nop())
+	ctx := context.Background()
+
+	mr.Set("editwar:enwiki:Contested", "1")
+
+	created := makeEdit(100, "Fresh", "Alice", 0, 800)
+	created.Type = "new"
+	for _, e := range []*models.WikipediaEdit{
+		created,
+		makeEdit(102, "Contested", "Alice", 1000, 1300),
+		makeRevert(104, "Contested", "Bob", "Alice", 1300, 1000),
+		makeRevert(106, "Fresh", "Alice", "Alice", 800, 700), // her own edit: not a revert
+		{ID: 108, Type: "log", Wiki: "enwiki", Title: "Contested", User: "Admin", LogType: "protect"},
+	} {
+		require.NoError(t, profiler.ProcessEdit(ctx, e))
+	}
+
+	alice, err := store.GetProfile(ctx, "enwiki", "Alice")
+	require.NoError(t, err)
+	require.NotNil(t, alice)
+	assert.Equal(t, int64(3), alice.Edits)
+	assert.Equal(t, int64(1), alice.NewPages)
+	assert.Equal(t, int64(2), alice.PagesTouched)
+	assert.Equal(t, int64(1100), alice.BytesAdded)
+	assert.Equal(t, int64(100), alice.BytesRemoved)
+	assert.Zero(t, alice.RevertsGiven)
+	assert.Equal(t, int64(1), alice.RevertsReceived)
+	assert.Equal(t, []string{"Contested"}, alice.EditWarPages)
+
+	bob, err := store.GetProfile(ctx, "enwiki", "Bob")
+	require.NoError(t, err)
+	require.NotNil(t, bob)
+	assert.Equal(t, int64(1), bob.RevertsGiven)
+	assert.Equal(t, int64(300), bob.BytesRemoved)
+	assert.Equal(t, int64(1), bob.EditWars)
+
+	admin, err := store.GetProfile(ctx, "enwiki", "Admin")
+	require.NoError(t, err)
+	assert.Nil(t, admin, "log events are not edits")
+}
@@ -56,6 +56,8 @@ type SelectiveIndexer struct {
 
 	// Drop tracking
 	dropCount atomic.Int64
+
+	dedup *storage.EditDeduplicator
 }
 
 // NewSelectiveIndexer creates a new selective Elasticsearch indexer consumer
@@ -206,6 +208,19 @@ func NewSelectiveIndexerForTest(
 // ProcessEdit implements the kafka.MessageHandler interface.
 // It decides whether an edit should be indexed and buffers it if so.
 func (si *SelectiveIndexer) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return si.dedup.Process(ctx, "elasticsearch-indexer", edit, func() error {
+		return si.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit skip revisions that were already
+// considered for indexing.
+func (si *SelectiveIndexer) SetDeduplicator(d *storage.EditDeduplicator) {
+	si.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (si *SelectiveIndexer) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
 	si.metrics.EditsReceived.Inc()
 
 	// Make indexing decision
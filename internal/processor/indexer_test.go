@@ -111,7 +111,7 @@ func TestSelectiveIndexer_TrendingPage(t *testing.T) {
 
 	// Make the page trending by giving it a high score
 	for i := 0; i < 50; i++ {
-		err := scorer.IncrementScore(pageTitle, 10.0)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), 10.0)
 		require.NoError(t, err)
 	}
 
@@ -226,7 +226,7 @@ func TestSelectiveIndexer_MultipleEdits(t *testing.T) {
 	// Setup: Make one page trending
 	trendingPage := "Popular_Page"
 	for i := 0; i < 50; i++ {
-		require.NoError(t, scorer.IncrementScore(trendingPage, 10.0))
+		require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", trendingPage), 10.0))
 	}
 
 	// Setup: Make one page spiking
@@ -443,7 +443,7 @@ func TestSelectiveIndexer_IndexingStatsUpdate(t *testing.T) {
 	// Now process a trending edit (should be indexed)
 	trendingPage := "Hot_Topic"
 	for i := 0; i < 50; i++ {
-		require.NoError(t, scorer.IncrementScore(trendingPage, 10.0))
+		require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", trendingPage), 10.0))
 	}
 
 	edit2 := makeTestEdit(trendingPage, "ActiveUser")
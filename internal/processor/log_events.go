@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// LogEventRecorder is a Kafka MessageHandler for the log events topic. It
+// stores protections, blocks, deletions and moves so the API can show them
+// alongside the edit wars they usually conclude, and notes account
+// creations so detectors can tell new accounts from established ones.
+type LogEventRecorder struct {
+	store    *storage.LogEventStore
+	accounts *storage.AccountRegistry
+	logger   zerolog.Logger
+	dedup    *storage.EditDeduplicator
+}
+
+// NewLogEventRecorder creates a recorder writing to store.
+func NewLogEventRecorder(store *storage.LogEventStore, logger zerolog.Logger) *LogEventRecorder {
+	return &LogEventRecorder{
+		store:  store,
+		logger: logger.With().Str("component", "log-event-recorder").Logger(),
+	}
+}
+
+// ProcessEdit implements kafka.MessageHandler. Messages that are not log
+// events are ignored.
+func (r *LogEventRecorder) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return r.dedup.Process(ctx, "log-event-recorder", edit, func() error {
+		return r.processEdit(ctx, edit)
+	})
+}
+
+// SetAccountRegistry makes the recorder note account creations in accounts
+// instead of storing them with the other log events.
+func (r *LogEventRecorder) SetAccountRegistry(accounts *storage.AccountRegistry) {
+	r.accounts = accounts
+}
+
+// SetDeduplicator makes ProcessEdit skip log entries already recorded.
+func (r *LogEventRecorder) SetDeduplicator(d *storage.EditDeduplicator) {
+	r.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (r *LogEventRecorder) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	ev := models.NewLogEvent(edit)
+	if ev == nil {
+		return nil
+	}
+
+	if account := ev.CreatedAccount(); account != "" && r.accounts != nil {
+		at := edit.EventTime()
+		if at.IsZero() {
+			at = time.Now()
+		}
+		if err := r.accounts.RecordCreated(ctx, ev.Wiki, account, at); err != nil {
+			r.logger.Error().Err(err).Str("wiki", ev.Wiki).Str("user", account).Msg("Failed to record account creation")
+			return err
+		}
+		return nil
+	}
+
+	if err := r.store.Record(ctx, ev); err != nil {
+		r.logger.Error().Err(err).
+			Str("wiki", ev.Wiki).
+			Str("page", ev.Title).
+			Str("log_type", ev.LogType).
+			Msg("Failed to record log event")
+		return err
+	}
+
+	r.logger.Debug().
+		Str("wiki", ev.Wiki).
+		Str("page", ev.Title).
+		Str("summary", ev.Summary).
+		Msg("Recorded log event")
+	return nil
+}
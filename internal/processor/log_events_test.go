@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogEventRecorder_ProcessEdit(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := storage.NewLogEventStore(client)
+	recorder := NewLogEventRecorder(store, zerolog.New(zerolog.NewTestWriter(t)))
+	ctx := context.Background()
+
+	// Regular edits are ignored.
+	require.NoError(t, recorder.ProcessEdit(ctx, &models.WikipediaEdit{Type: "edit", Wiki: "enwiki", Title: "Foo"}))
+
+	require.NoError(t, recorder.ProcessEdit(ctx, &models.WikipediaEdit{
+		ID: 1, Type: "log", Wiki: "enwiki", Title: "Foo", User: "Admin",
+		LogID: 99, LogType: "protect", LogAction: "protect",
+	}))
+
+	events, err := store.GetPageEvents(ctx, "enwiki", "Foo", 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(99), events[0].LogID)
+	assert.Equal(t, "page protected by Admin", events[0].Summary)
+
+	// Account creations go to the account registry instead
+	accounts := storage.NewAccountRegistry(client, 24*time.Hour)
+	recorder.SetAccountRegistry(accounts)
+	created := time.Unix(1772442000, 0)
+	require.NoError(t, recorder.ProcessEdit(ctx, &models.WikipediaEdit{
+		ID: 2, Type: "log", Wiki: "enwiki", Namespace: 2, Title: "User:Capyfan2026", User: "Capyfan2026",
+		LogID: 100, LogType: "newusers", LogAction: "create", Timestamp: created.Unix(),
+	}))
+
+	isNew, err := accounts.IsNew(ctx, "enwiki", "Capyfan2026", created.Add(time.Hour), 24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	events, err = store.GetPageEvents(ctx, "enwiki", "User:Capyfan2026", 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
@@ -19,13 +20,18 @@ import (
 // ---------------------------------------------------------------------------
 
 type mockBroadcaster struct {
-	edits []*models.WikipediaEdit
+	edits   []*models.WikipediaEdit
+	botRuns []*storage.BotRun
 }
 
 func (m *mockBroadcaster) BroadcastEditFiltered(edit *models.WikipediaEdit) {
 	m.edits = append(m.edits, edit)
 }
 
+func (m *mockBroadcaster) BroadcastBotRun(run *storage.BotRun) {
+	m.botRuns = append(m.botRuns, run)
+}
+
 // ---------------------------------------------------------------------------
 // WebSocketForwarder
 // ---------------------------------------------------------------------------
@@ -0,0 +1,279 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/llm"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/rs/zerolog"
+)
+
+// How a revert was recognised, from weakest to strongest evidence.
+const (
+	RevertMethodComment = "comment" // the edit summary says it is a revert
+	RevertMethodTag     = "tag"     // MediaWiki tagged the revision as one
+	RevertMethodHash    = "hash"    // its content is identical to an earlier revision's
+)
+
+// revertTags are the change tags MediaWiki puts on reverting revisions.
+var revertTags = map[string]bool{
+	"mw-undo":          true,
+	"mw-rollback":      true,
+	"mw-manual-revert": true,
+}
+
+// Revert records one revision undoing other editors' work on a page.
+type Revert struct {
+	RevisionID        int64    `json:"revision_id"`
+	Reverter          string   `json:"reverter"`
+	RevertedUsers     []string `json:"reverted_users,omitempty"`     // empty when the evidence does not name them
+	RevertedRevisions []int64  `json:"reverted_revisions,omitempty"` // revisions undone, if known
+	RestoredRevision  int64    `json:"restored_revision,omitempty"`  // revision whose content was restored, if known
+	Method            string   `json:"method"`
+	Timestamp         int64    `json:"timestamp"`
+}
+
+// revertSummaryFormats are the edit summaries MediaWiki (undo, rollback) and
+// the common patrolling tools (Twinkle, Huggle, ClueBot) write, in several
+// languages. {rev} is the undone revision, {restored} the restored one and
+// {user} the editor whose work was reverted.
+var revertSummaryFormats = []string{
+	// English, including Twinkle, Huggle and ClueBot NG
+	`undid revision {rev} by {user}`,
+	`(?:partial(?:ly)? )?revert(?:ed|ing)? (?:\d+ )?(?:good[ -]faith )?(?:possible vandalism|edits?|changes?) by {user}`,
+	`restored revision {restored}`,
+	// German
+	`änderung {rev} von {user} rückgängig gemacht`,
+	`änderungen von {user} .*zurückgesetzt`,
+	// French
+	`annulation d(?:e la|es) modifications? {rev} de {user}`,
+	`révocation des modifications de {user}`,
+	// Spanish
+	`deshecha la edición {rev} de {user}`,
+	`revertidos los cambios de {user}`,
+	// Italian
+	`annullata la modifica {rev} di {user}`,
+	`annullate le modifiche di {user}`,
+	// Portuguese
+	`desfeita a edição {rev} de {user}`,
+	`revertidas edições por {user}`,
+	// Russian
+	`отмена правки {rev},? (?:сделанной |участника )?{user}`,
+	`откат правок (?:участника )?{user}`,
+	// Dutch
+	`(?:wijziging|versie) {rev} van {user} (?:is )?ongedaan gemaakt`,
+	`wijzigingen door {user} hersteld`,
+	// Hand-written reverts that name no one
+	`(?:rvv?|revert(?:ed)?|undo|undid|restored|zurückgesetzt|révoqué|revertido|annullato)(?:[\s:;,.!(]|$)`,
+}
+
+// summaryUser matches the user named in a summary: a wikilink such as
+// [[Special:Contributions/Foo|Foo]] or a bare name.
+const summaryUser = `(\[\[[^\]]+\]\]|[^\s\[\]()]+)`
+
+var revertSummaries = compileRevertSummaries(revertSummaryFormats)
+
+func compileRevertSummaries(formats []string) []*regexp.Regexp {
+	r := strings.NewReplacer(
+		"{rev}", `(?P<rev>\d+)`,
+		"{restored}", `(?P<restored>\d+)`,
+		"{user}", `(?P<user>`+summaryUser+`)`,
+	)
+	res := make([]*regexp.Regexp, len(formats))
+	for i, f := range formats {
+		res[i] = regexp.MustCompile(`(?i)^\s*` + r.Replace(f))
+	}
+	return res
+}
+
+// parseRevertComment recognises a revert from its edit summary alone.
+func parseRevertComment(comment string) *Revert {
+	for _, re := range revertSummaries {
+		m := re.FindStringSubmatch(comment)
+		if m == nil {
+			continue
+		}
+		rv := &Revert{Method: RevertMethodComment}
+		if i := re.SubexpIndex("user"); i > 0 {
+			if user := summaryUserName(m[i]); user != "" {
+				rv.RevertedUsers = []string{user}
+			}
+		}
+		if i := re.SubexpIndex("rev"); i > 0 {
+			if id, err := strconv.ParseInt(m[i], 10, 64); err == nil {
+				rv.RevertedRevisions = []int64{id}
+			}
+		}
+		if i := re.SubexpIndex("restored"); i > 0 {
+			rv.RestoredRevision, _ = strconv.ParseInt(m[i], 10, 64)
+		}
+		return rv
+	}
+	return nil
+}
+
+// isRevertComment reports whether an edit summary describes a revert.
+func isRevertComment(comment string) bool {
+	return parseRevertComment(comment) != nil
+}
+
+// summaryUserName extracts the user name from a summary's user link or word.
+func summaryUserName(s string) string {
+	if strings.HasPrefix(s, "[[") {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "[["), "]]")
+		if i := strings.IndexByte(s, '|'); i >= 0 {
+			s = s[:i]
+		}
+		// Special:Contributions/Name (IPv6 addresses contain colons), or User:Name
+		if i := strings.LastIndexByte(s, '/'); i >= 0 {
+			s = s[i+1:]
+		} else if i := strings.IndexByte(s, ':'); i >= 0 {
+			s = s[i+1:]
+		}
+	} else {
+		s = strings.TrimRight(s, ":;,.")
+	}
+	return strings.TrimSpace(strings.ReplaceAll(s, "_", " "))
+}
+
+// RevertClassifier decides whether an edit reverted earlier edits, and whose.
+// The edit summary is always parsed. With a DiffFetcher the page's recent
+// revisions are fetched too, unless the summary already named the reverted
+// editor: a revision whose content hash matches an earlier one is an identity
+// revert of everything in between, and MediaWiki's revert tags catch undos
+// whose summary was rewritten. The fetch is synchronous, so it costs the
+// consumer one API round trip per such edit.
+type RevertClassifier struct {
+	fetcher  *llm.DiffFetcher // nil to use edit summaries only
+	lookback int
+	timeout  time.Duration
+	logger   zerolog.Logger
+}
+
+// NewRevertClassifier creates a revert classifier. Revision histories are
+// only fetched when cfg.VerifyHashes is set.
+func NewRevertClassifier(cfg config.RevertDetectionConfig, logger zerolog.Logger) *RevertClassifier {
+	rc := &RevertClassifier{
+		lookback: cfg.HashLookback,
+		timeout:  cfg.LookupTimeout,
+		logger:   logger.With().Str("component", "revert-classifier").Logger(),
+	}
+	if cfg.VerifyHashes {
+		rc.fetcher = llm.NewDiffFetcher(logger)
+	}
+	return rc
+}
+
+// Classify returns the revert made by edit, or nil if it is not a revert.
+// Undoing only one's own edits is not counted.
+func (rc *RevertClassifier) Classify(ctx context.Context, edit *models.WikipediaEdit) *Revert {
+	rv := parseRevertComment(edit.Comment)
+
+	// A summary that names whose work was undone settles it; the history
+	// is only worth a request when the summary says nothing or too little.
+	conclusive := rv != nil && len(rv.RevertedUsers) > 0
+	if rc.fetcher != nil && !conclusive && edit.Type == "edit" && edit.Revision.New > 0 && edit.ServerURL != "" {
+		history, err := rc.history(ctx, edit)
+		if err != nil {
+			rc.logger.Debug().Err(err).Str("page", edit.PageKey().String()).Msg("Revision history unavailable, classifying by edit summary")
+		} else if found := revertFromHistory(history, rv); found != nil {
+			rv = found
+		}
+	}
+	return rc.attribute(edit, rv)
+}
+
+// ClassifySummary is Classify from the edit summary alone, for edits not
+// worth a revision history request.
+func (rc *RevertClassifier) ClassifySummary(edit *models.WikipediaEdit) *Revert {
+	return rc.attribute(edit, parseRevertComment(edit.Comment))
+}
+
+// attribute fills in who made rv and when, and drops self-reverts.
+func (rc *RevertClassifier) attribute(edit *models.WikipediaEdit, rv *Revert) *Revert {
+	if rv == nil {
+		return nil
+	}
+
+	rv.RevisionID = edit.Revision.New
+	rv.Reverter = edit.User
+	rv.Timestamp = edit.Timestamp
+	named := len(rv.RevertedUsers) > 0
+	rv.RevertedUsers = slices.DeleteFunc(rv.RevertedUsers, func(u string) bool { return u == edit.User })
+	if named && len(rv.RevertedUsers) == 0 {
+		return nil // self-revert
+	}
+
+	metrics.RevertsDetectedTotal.WithLabelValues(rv.Method).Inc()
+	return rv
+}
+
+// history fetches the edit's revision and the lookback revisions before it.
+func (rc *RevertClassifier) history(ctx context.Context, edit *models.WikipediaEdit) ([]llm.RevisionMeta, error) {
+	ctx, cancel := context.WithTimeout(ctx, rc.timeout)
+	defer cancel()
+
+	history, err := rc.fetcher.FetchRevisionHistory(ctx, edit.ServerURL, edit.Title, edit.Revision.New, rc.lookback+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 || history[0].RevisionID != edit.Revision.New {
+		return nil, fmt.Errorf("revision %d is not in the page history yet", edit.Revision.New)
+	}
+	return history, nil
+}
+
+// revertFromHistory looks for a revert in a page history, newest revision
+// first, where history[0] is the edit being classified. fromComment, if not
+// nil, is what its summary said and fills in who a tagged revert undid.
+func revertFromHistory(history []llm.RevisionMeta, fromComment *Revert) *Revert {
+	if len(history) < 2 {
+		return nil
+	}
+	self := history[0]
+
+	// Identity revert: the content matches an earlier revision. A match
+	// with the parent is a null edit, not a revert.
+	if self.SHA1 != "" && history[1].SHA1 != self.SHA1 {
+		for k := 2; k < len(history); k++ {
+			if history[k].SHA1 != self.SHA1 {
+				continue
+			}
+			rv := &Revert{Method: RevertMethodHash, RestoredRevision: history[k].RevisionID}
+			for _, r := range history[1:k] {
+				rv.RevertedRevisions = append(rv.RevertedRevisions, r.RevisionID)
+				if r.User != "" && !slices.Contains(rv.RevertedUsers, r.User) {
+					rv.RevertedUsers = append(rv.RevertedUsers, r.User)
+				}
+			}
+			return rv
+		}
+	}
+
+	for _, tag := range self.Tags {
+		if !revertTags[tag] {
+			continue
+		}
+		rv := &Revert{Method: RevertMethodTag}
+		if fromComment != nil {
+			rv.RevertedUsers = fromComment.RevertedUsers
+			rv.RevertedRevisions = fromComment.RevertedRevisions
+			rv.RestoredRevision = fromComment.RestoredRevision
+		}
+		// Undo and rollback revert the latest edits, so at least the parent
+		if len(rv.RevertedUsers) == 0 && history[1].User != "" {
+			rv.RevertedUsers = []string{history[1].User}
+			rv.RevertedRevisions = []int64{history[1].RevisionID}
+		}
+		return rv
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/llm"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRevertComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		users   []string
+		rev     int64
+	}{
+		// MediaWiki undo and rollback
+		{"Undid revision 1234567 by [[Special:Contributions/Foo Bar|Foo Bar]] ([[User talk:Foo Bar|talk]])", []string{"Foo Bar"}, 1234567},
+		{"Reverted edits by [[Special:Contributions/203.0.113.7|203.0.113.7]] ([[User talk:203.0.113.7|talk]]) to last version by Bar", []string{"203.0.113.7"}, 0},
+		{"Reverted edits by [[Special:Contribs/2001:db8::1|2001:db8::1]] (talk) (HG) (3.4.12)", []string{"2001:db8::1"}, 0},
+		// Twinkle and ClueBot NG
+		{"Reverted 2 edits by [[Special:Contributions/Foo|Foo]] ([[User talk:Foo|talk]]): Unsourced", []string{"Foo"}, 0},
+		{"Reverted good faith edits by Foo (talk): Not an improvement", []string{"Foo"}, 0},
+		{"Reverting possible vandalism by [[User:Foo|Foo]] to version by Bar. Report False Positive? Thanks, [[WP:CBNG|ClueBot NG]]. (4312)", []string{"Foo"}, 0},
+		// Other languages
+		{"Änderung 98765 von [[Spezial:Beiträge/Foo|Foo]] rückgängig gemacht; Quelle fehlt", []string{"Foo"}, 98765},
+		{"Änderungen von [[Spezial:Beiträge/Foo|Foo]] ([[Benutzer Diskussion:Foo|Diskussion]]) auf die letzte Version von Bar zurückgesetzt", []string{"Foo"}, 0},
+		{"Annulation de la modification 555 de [[Spécial:Contributions/Foo|Foo]] ([[Discussion utilisateur:Foo|d]])", []string{"Foo"}, 555},
+		{"Révocation des modifications de [[Spécial:Contributions/Foo|Foo]] (retour à la dernière version de Bar)", []string{"Foo"}, 0},
+		{"Deshecha la edición 42 de [[Especial:Contribuciones/Foo|Foo]] ([[Usuario discusión:Foo|disc.]])", []string{"Foo"}, 42},
+		{"Annullata la modifica 77 di [[Speciale:Contributi/Foo|Foo]] ([[Discussioni utente:Foo|discussione]])", []string{"Foo"}, 77},
+		{"Desfeita a edição 88 de [[Special:Contributions/Foo|Foo]]", []string{"Foo"}, 88},
+		{"Отмена правки 1001, сделанной [[Special:Contributions/Foo|Foo]] ([[ОУ:Foo|обс.]])", []string{"Foo"}, 1001},
+		{"Откат правок [[Служебная:Вклад/Foo|Foo]] ([[ОУ:Foo|обс.]]) к версии Bar", []string{"Foo"}, 0},
+		{"Wijzigingen door [[Speciaal:Bijdragen/Foo|Foo]] hersteld tot de laatste versie door Bar", []string{"Foo"}, 0},
+		// Hand-written
+		{"rv vandalism", nil, 0},
+		{"Revert: not supported by the source", nil, 0},
+		{"révoqué", nil, 0},
+	}
+	for _, tt := range tests {
+		rv := parseRevertComment(tt.comment)
+		if !assert.NotNil(t, rv, tt.comment) {
+			continue
+		}
+		assert.Equal(t, RevertMethodComment, rv.Method)
+		assert.Equal(t, tt.users, rv.RevertedUsers, tt.comment)
+		if tt.rev != 0 {
+			assert.Equal(t, []int64{tt.rev}, rv.RevertedRevisions, tt.comment)
+		}
+	}
+
+	for _, comment := range []string{
+		"",
+		"Copyedit",
+		"/* History */ revised dates",
+		"Reverting the order of sections would be confusing, keeping it",
+		"Added the revert rule to the policy summary",
+		"rvalue references explained",
+	} {
+		assert.Nil(t, parseRevertComment(comment), comment)
+	}
+}
+
+func TestRevertFromHistory(t *testing.T) {
+	rev := func(id int64, user, sha1 string, tags ...string) llm.RevisionMeta {
+		return llm.RevisionMeta{RevisionID: id, User: user, SHA1: sha1, Tags: tags}
+	}
+
+	t.Run("identity revert of several edits", func(t *testing.T) {
+		rv := revertFromHistory([]llm.RevisionMeta{
+			rev(5, "Carol", "aaa"),
+			rev(4, "Bob", "ccc"),
+			rev(3, "Alice", "bbb"),
+			rev(2, "Bob", "aaa"),
+			rev(1, "Dave", "zzz"),
+		}, nil)
+		require.NotNil(t, rv)
+		assert.Equal(t, RevertMethodHash, rv.Method)
+		assert.Equal(t, int64(2), rv.RestoredRevision)
+		assert.Equal(t, []int64{4, 3}, rv.RevertedRevisions)
+		assert.Equal(t, []string{"Bob", "Alice"}, rv.RevertedUsers)
+	})
+
+	t.Run("null edit", func(t *testing.T) {
+		assert.Nil(t, revertFromHistory([]llm.RevisionMeta{
+			rev(3, "Alice", "aaa"), rev(2, "Bob", "aaa"), rev(1, "Carol", "aaa"),
+		}, nil))
+	})
+
+	t.Run("tagged partial undo", func(t *testing.T) {
+		rv := revertFromHistory([]llm.RevisionMeta{
+			rev(3, "Alice", "ccc", "mw-undo"), rev(2, "Bob", "bbb"), rev(1, "Carol", "aaa"),
+		}, nil)
+		require.NotNil(t, rv)
+		assert.Equal(t, RevertMethodTag, rv.Method)
+		assert.Equal(t, []string{"Bob"}, rv.RevertedUsers)
+	})
+
+	t.Run("no evidence", func(t *testing.T) {
+		assert.Nil(t, revertFromHistory([]llm.RevisionMeta{
+			rev(3, "Alice", "ccc", "mobile edit"), rev(2, "Bob", "bbb"), rev(1, "Carol", "aaa"),
+		}, nil))
+	})
+}
+
+// fakeHistoryAPI serves the revision history of one page, newest first.
+func fakeHistoryAPI(t *testing.T, history string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"query":{"pages":{"1":{"revisions":%s}}}}`, history)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEditWarDetector_RecordsRevertPairs(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Redis: config.Redis{HotPages: config.HotPages{
+			MaxTracked: 100, PromotionThreshold: 3, WindowDuration: time.Hour,
+			MaxMembersPerPage: 50, HotThreshold: 2, CleanupInterval: 5 * time.Minute,
+		}},
+		Processor: config.Processor{
+			Reverts: config.RevertDetectionConfig{VerifyHashes: true, HashLookback: 10, LookupTimeout: time.Second},
+		},
+	}
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	detector := NewEditWarDetector(hotPages, client, cfg, zerolog.Nop())
+	ctx := context.Background()
+
+	page := models.NewPageKey("enwiki", "Contested")
+	mr.ZAdd("hot:window:"+page.String(), float64(time.Now().Unix()), "seed")
+
+	// Bob silently restores the version Alice replaced; the summary gives
+	// nothing away but the content hash does
+	api := fakeHistoryAPI(t, `[
+		{"revid":12,"parentid":11,"user":"Bob","sha1":"v1","tags":[]},
+		{"revid":11,"parentid":10,"user":"Alice","sha1":"v2","tags":[]},
+		{"revid":10,"parentid":9,"user":"Bob","sha1":"v1","tags":[]}]`)
+	edit := makeEdit(11, page.Title, "Bob", 1400, 1000)
+	edit.ServerURL = api.URL
+	edit.Comment = "fix wording"
+	require.NoError(t, detector.ProcessEdit(ctx, edit))
+
+	// Carol's copyedit is not a revert, whatever its size
+	api = fakeHistoryAPI(t, `[
+		{"revid":14,"parentid":13,"user":"Carol","sha1":"v3","tags":[]},
+		{"revid":13,"parentid":12,"user":"Bob","sha1":"v1","tags":[]}]`)
+	edit = makeEdit(13, page.Title, "Carol", 1000, 1400)
+	edit.ServerURL = api.URL
+	require.NoError(t, detector.ProcessEdit(ctx, edit))
+
+	count, err := detector.countReverts(ctx, page)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	timeline, err := detector.GetTimeline(ctx, page)
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+	require.NotNil(t, timeline[0].Revert)
+	assert.Equal(t, "Bob", timeline[0].Revert.Reverter)
+	assert.Equal(t, []string{"Alice"}, timeline[0].Revert.RevertedUsers)
+	assert.Equal(t, []int64{11}, timeline[0].Revert.RevertedRevisions)
+	assert.Equal(t, RevertMethodHash, timeline[0].Revert.Method)
+	assert.Nil(t, timeline[1].Revert)
+}
+
+func TestRevertClassifier_IgnoresSelfReverts(t *testing.T) {
+	rc := NewRevertClassifier(config.RevertDetectionConfig{}, zerolog.Nop())
+	edit := makeEdit(100, "Page", "Alice", 1500, 1000)
+
+	edit.Comment = "Undid revision 100 by [[Special:Contributions/Alice|Alice]] ([[User talk:Alice|talk]])"
+	assert.Nil(t, rc.Classify(context.Background(), edit))
+
+	edit.Comment = "Undid revision 100 by [[Special:Contributions/Bob|Bob]] ([[User talk:Bob|talk]])"
+	rv := rc.Classify(context.Background(), edit)
+	require.NotNil(t, rv)
+	assert.Equal(t, "Alice", rv.Reverter)
+	assert.Equal(t, []string{"Bob"}, rv.RevertedUsers)
+	assert.Equal(t, int64(101), rv.RevisionID)
+}
+
+func TestRevertClassifier_SkipsHistoryForNamedReverts(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"query":{"pages":{"1":{"revisions":[
+			{"revid":101,"parentid":100,"user":"Alice","sha1":"v1","tags":[]},
+			{"revid":100,"parentid":99,"user":"Bob","sha1":"v2","tags":[]}]}}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	rc := NewRevertClassifier(config.RevertDetectionConfig{VerifyHashes: true, HashLookback: 10, LookupTimeout: time.Second}, zerolog.Nop())
+	edit := makeEdit(100, "Page", "Alice", 1500, 1000)
+	edit.ServerURL = srv.URL
+
+	edit.Comment = "Undid revision 100 by [[Special:Contributions/Bob|Bob]] ([[User talk:Bob|talk]])"
+	rv := rc.Classify(context.Background(), edit)
+	require.NotNil(t, rv)
+	assert.Equal(t, RevertMethodComment, rv.Method)
+	assert.Equal(t, 0, requests, "a summary naming the reverted editor needs no lookup")
+
+	edit.Comment = "copyedit"
+	assert.Nil(t, rc.Classify(context.Background(), edit))
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,395 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+// SpikeScorer decides whether a hot page's recent activity is a spike. For
+// every edit to a hot page, after the edit has been added to the page's
+// window, the spike detector calls Score if the page has enough recent edits
+// to matter and then Observe whether it has or not, so baselines learn from
+// quiet minutes as well as busy ones.
+type SpikeScorer interface {
+	// Name identifies the method in alerts and logs.
+	Name() string
+	// Score compares stats with the page's baseline as of now.
+	Score(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) (*SpikeScore, error)
+	// Observe adds the page's current rate to its baseline.
+	Observe(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) error
+}
+
+// SpikeScore is a scorer's verdict on a page.
+type SpikeScore struct {
+	Spike    bool
+	Method   string
+	Ratio    float64 // 5-minute rate over the expected rate
+	ZScore   float64 // standard deviations above the baseline; 0 for the ratio rule
+	Expected float64 // expected edits per minute
+	Severity string
+}
+
+// NewSpikeScorer builds the scorer selected by cfg.Method. Baselines for the
+// statistical methods are kept in Redis.
+func NewSpikeScorer(client *redis.Client, cfg config.SpikeDetectionConfig) (SpikeScorer, error) {
+	cfg = cfg.WithDefaults()
+	ratio := &ratioScorer{threshold: cfg.RatioThreshold, floor: cfg.MinBaselineRate}
+	switch cfg.Method {
+	case "ratio":
+		return ratio, nil
+	case "ewma":
+		return &ewmaScorer{baselines: newBaselineStore(client, cfg), fallback: ratio}, nil
+	case "seasonal":
+		return &seasonalScorer{baselines: newBaselineStore(client, cfg), fallback: ratio}, nil
+	default:
+		return nil, fmt.Errorf("unknown spike detection method %q", cfg.Method)
+	}
+}
+
+// ratioScorer is the original rule: the 5-minute rate is at least threshold
+// times the 1-hour rate, with the 1-hour rate floored so a page that was
+// silent for an hour does not spike on its first few edits.
+type ratioScorer struct {
+	threshold float64
+	floor     float64
+}
+
+func (r *ratioScorer) Name() string { return "ratio" }
+
+// Observe does nothing: the ratio rule has no baseline of its own.
+func (r *ratioScorer) Observe(context.Context, models.PageKey, *storage.PageStats, time.Time) error {
+	return nil
+}
+
+func (r *ratioScorer) Score(_ context.Context, _ models.PageKey, stats *storage.PageStats, _ time.Time) (*SpikeScore, error) {
+	rate5m := float64(stats.EditsLast5Min) / 5.0
+	baseline := math.Max(float64(stats.EditsLastHour)/60.0, r.floor)
+	ratio := rate5m / baseline
+	return &SpikeScore{
+		Spike:    ratio >= r.threshold,
+		Method:   r.Name(),
+		Ratio:    ratio,
+		Expected: baseline,
+		Severity: ratioSeverity(ratio),
+	}, nil
+}
+
+// ratioSeverity grades a spike ratio: 5x=medium, 10x=high, 20x=critical.
+func ratioSeverity(ratio float64) string {
+	switch {
+	case ratio >= 20:
+		return "critical"
+	case ratio >= 10:
+		return "high"
+	case ratio >= 5:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// zSeverity grades a z-score relative to the alert threshold: at the
+// threshold is medium, twice it high, four times it critical.
+func zSeverity(z, threshold float64) string {
+	switch {
+	case z >= 4*threshold:
+		return "critical"
+	case z >= 2*threshold:
+		return "high"
+	case z >= threshold:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// ewmaScorer compares the 5-minute rate with an exponentially weighted
+// moving mean and variance of the page's own past rates. A busy page has a
+// high mean, so it needs a real surge to alert; a tiny page's few edits stay
+// within the standard deviation floor. Until the baseline has MinSamples
+// samples the ratio rule decides.
+type ewmaScorer struct {
+	baselines *baselineStore
+	fallback  SpikeScorer
+}
+
+func (e *ewmaScorer) Name() string { return "ewma" }
+
+func (e *ewmaScorer) Score(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) (*SpikeScore, error) {
+	stat, err := e.baseline(ctx, key, now)
+	if err != nil {
+		return nil, err
+	}
+	rate := float64(stats.EditsLast5Min) / 5.0
+	return e.baselines.score(ctx, e.Name(), stat, rate, key, stats, now, e.fallback)
+}
+
+func (e *ewmaScorer) Observe(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) error {
+	stat, err := e.baseline(ctx, key, now)
+	if err != nil {
+		return err
+	}
+	minute := now.Unix() / 60
+	if stat.N > 0 && minute <= stat.Last {
+		return nil // already sampled this minute
+	}
+	stat.observe(float64(stats.EditsLast5Min)/5.0, e.baselines.cfg.EWMAAlpha)
+	stat.Last = minute
+	return e.baselines.save(ctx, e.baselines.pageKey(key), map[string]*baselineStat{"ewma": stat})
+}
+
+// baseline loads the page's baseline and decays it up to now. The page is
+// sampled on every edit while it is hot, so minutes without a sample had
+// next to no edits: each counts as a zero.
+func (e *ewmaScorer) baseline(ctx context.Context, key models.PageKey, now time.Time) (*baselineStat, error) {
+	stat, err := e.baselines.load(ctx, e.baselines.pageKey(key), "ewma")
+	if err != nil {
+		return nil, err
+	}
+	if minute := now.Unix() / 60; stat.N > 0 && minute > stat.Last+1 {
+		stat.decay(minute-stat.Last-1, e.baselines.cfg.EWMAAlpha)
+	}
+	return stat, nil
+}
+
+// seasonalScorer keeps a baseline per hour of the week, so a page that is
+// always busy on Monday mornings (or a wiki whose edits follow one time
+// zone) is compared with its usual Monday morning. A page with few samples
+// in the current slot leans on the wiki's baseline for that slot: every hot
+// page's per-minute samples feed it, so it describes a typical hot page on
+// the wiki at that hour rather than the wiki as a whole.
+type seasonalScorer struct {
+	baselines *baselineStore
+	fallback  SpikeScorer
+}
+
+func (s *seasonalScorer) Name() string { return "seasonal" }
+
+func (s *seasonalScorer) Score(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) (*SpikeScore, error) {
+	page, wiki, err := s.baseline(ctx, key, now)
+	if err != nil {
+		return nil, err
+	}
+	rate := float64(stats.EditsLast5Min) / 5.0
+	return s.baselines.score(ctx, s.Name(), blendBaselines(page, wiki, s.baselines.cfg.MinSamples), rate, key, stats, now, s.fallback)
+}
+
+func (s *seasonalScorer) Observe(ctx context.Context, key models.PageKey, stats *storage.PageStats, now time.Time) error {
+	page, wiki, err := s.baseline(ctx, key, now)
+	if err != nil {
+		return err
+	}
+	minute := now.Unix() / 60
+	if page.N > 0 && minute <= page.Last {
+		return nil // already sampled this minute
+	}
+
+	field := hourOfWeekField(now)
+	rate := float64(stats.EditsLast5Min) / 5.0
+	alpha := s.baselines.cfg.EWMAAlpha
+	page.observe(rate, alpha)
+	page.Last = minute
+	wiki.observe(rate, alpha)
+	wiki.Last = minute
+	if err := s.baselines.save(ctx, s.baselines.pageKey(key), map[string]*baselineStat{field: page}); err != nil {
+		return err
+	}
+	return s.baselines.save(ctx, s.baselines.wikiKey(key.Wiki), map[string]*baselineStat{field: wiki})
+}
+
+// baseline loads the page's and the wiki's baselines for now's slot, with
+// the page's decayed for the minutes of the slot it went unsampled, as the
+// EWMA scorer does. The wiki's is not decayed: it is a baseline of page
+// samples, not of time.
+func (s *seasonalScorer) baseline(ctx context.Context, key models.PageKey, now time.Time) (page, wiki *baselineStat, err error) {
+	field := hourOfWeekField(now)
+	page, err = s.baselines.load(ctx, s.baselines.pageKey(key), field)
+	if err != nil {
+		return nil, nil, err
+	}
+	wiki, err = s.baselines.load(ctx, s.baselines.wikiKey(key.Wiki), field)
+	if err != nil {
+		return nil, nil, err
+	}
+	if minute := now.Unix() / 60; page.N > 0 && minute > page.Last+1 {
+		page.decay(slotGap(page.Last, minute), s.baselines.cfg.EWMAAlpha)
+	}
+	return page, wiki, nil
+}
+
+// slotGap counts the minutes strictly between last and minute that fall in
+// the same hour of the week as both: the rest of last's hour, any whole
+// weeks' worth of that hour in between, and the start of minute's hour.
+func slotGap(last, minute int64) int64 {
+	const week = 7 * 24 * 60
+	lastHour, hour := last-last%60, minute-minute%60
+	if lastHour == hour {
+		return minute - last - 1
+	}
+	skipped := (hour-lastHour)/week - 1
+	return (lastHour + 60 - last - 1) + skipped*60 + (minute - hour)
+}
+
+// hourOfWeekField names the seasonal slot for t: "how:0" is Sunday 00:00 UTC.
+func hourOfWeekField(t time.Time) string {
+	t = t.UTC()
+	return "how:" + strconv.Itoa(int(t.Weekday())*24+t.Hour())
+}
+
+// blendBaselines mixes a page's baseline with its wiki's, weighting the page
+// by how many samples it has relative to minSamples.
+func blendBaselines(page, wiki *baselineStat, minSamples int) *baselineStat {
+	if wiki.N == 0 {
+		return page
+	}
+	w := float64(page.N) / float64(page.N+int64(minSamples))
+	return &baselineStat{
+		Mean: w*page.Mean + (1-w)*wiki.Mean,
+		Var:  w*page.Var + (1-w)*wiki.Var,
+		N:    page.N + wiki.N,
+	}
+}
+
+// maxGapSamples bounds the zero samples added for a quiet gap. With the
+// default alpha the baseline has decayed to nothing long before this.
+const maxGapSamples = 500
+
+// baselineStat is an exponentially weighted mean and variance of a rate in
+// edits per minute. Last is the minute of the last sample, so a page is
+// sampled at most once a minute however fast it is edited.
+type baselineStat struct {
+	Mean float64
+	Var  float64
+	N    int64
+	Last int64
+}
+
+// decay adds n zero samples, up to maxGapSamples, for minutes in which the
+// page had no edits.
+func (b *baselineStat) decay(n int64, alpha float64) {
+	for i := int64(0); i < n && i < maxGapSamples; i++ {
+		b.observe(0, alpha)
+	}
+}
+
+// observe adds sample x with weight alpha.
+func (b *baselineStat) observe(x, alpha float64) {
+	if b.N == 0 {
+		b.Mean, b.Var = x, 0
+	} else {
+		diff := x - b.Mean
+		incr := alpha * diff
+		b.Mean += incr
+		b.Var = (1 - alpha) * (b.Var + diff*incr)
+	}
+	b.N++
+}
+
+// String encodes the stat compactly as "mean,var,n,last".
+func (b *baselineStat) String() string {
+	return strconv.FormatFloat(b.Mean, 'g', 6, 64) + "," +
+		strconv.FormatFloat(b.Var, 'g', 6, 64) + "," +
+		strconv.FormatInt(b.N, 10) + "," +
+		strconv.FormatInt(b.Last, 10)
+}
+
+func parseBaselineStat(s string) (*baselineStat, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("malformed baseline %q", s)
+	}
+	var b baselineStat
+	var err error
+	if b.Mean, err = strconv.ParseFloat(parts[0], 64); err != nil {
+		return nil, fmt.Errorf("malformed baseline mean %q: %w", s, err)
+	}
+	if b.Var, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return nil, fmt.Errorf("malformed baseline variance %q: %w", s, err)
+	}
+	if b.N, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return nil, fmt.Errorf("malformed baseline count %q: %w", s, err)
+	}
+	if b.Last, err = strconv.ParseInt(parts[3], 10, 64); err != nil {
+		return nil, fmt.Errorf("malformed baseline minute %q: %w", s, err)
+	}
+	return &b, nil
+}
+
+// baselineStore keeps baselines in Redis hashes, one per page
+// (spike:baseline:page:{wiki}:{title}) and one per wiki
+// (spike:baseline:wiki:{wiki}), with a field per baseline. Each hash expires
+// after BaselineTTL without updates.
+type baselineStore struct {
+	redis *redis.Client
+	cfg   config.SpikeDetectionConfig
+}
+
+func newBaselineStore(client *redis.Client, cfg config.SpikeDetectionConfig) *baselineStore {
+	return &baselineStore{redis: client, cfg: cfg}
+}
+
+func (s *baselineStore) pageKey(key models.PageKey) string {
+	return fmt.Sprintf("spike:baseline:page:%s", key)
+}
+
+func (s *baselineStore) wikiKey(wiki string) string {
+	return fmt.Sprintf("spike:baseline:wiki:%s", wiki)
+}
+
+// load returns the stored baseline, or an empty one if there is none yet.
+func (s *baselineStore) load(ctx context.Context, key, field string) (*baselineStat, error) {
+	raw, err := s.redis.HGet(ctx, key, field).Result()
+	if err == redis.Nil {
+		return &baselineStat{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spike baseline %s: %w", key, err)
+	}
+	stat, err := parseBaselineStat(raw)
+	if err != nil {
+		// A corrupt baseline is relearned rather than blocking detection
+		return &baselineStat{}, nil
+	}
+	return stat, nil
+}
+
+func (s *baselineStore) save(ctx context.Context, key string, fields map[string]*baselineStat) error {
+	values := make([]interface{}, 0, 2*len(fields))
+	for field, stat := range fields {
+		values = append(values, field, stat.String())
+	}
+	pipe := s.redis.Pipeline()
+	pipe.HSet(ctx, key, values...)
+	pipe.Expire(ctx, key, s.cfg.BaselineTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save spike baseline %s: %w", key, err)
+	}
+	return nil
+}
+
+// score compares rate with baseline, deferring to fallback while the
+// baseline has too few samples to be trusted.
+func (s *baselineStore) score(ctx context.Context, method string, baseline *baselineStat, rate float64, key models.PageKey, stats *storage.PageStats, now time.Time, fallback SpikeScorer) (*SpikeScore, error) {
+	if baseline.N < int64(s.cfg.MinSamples) {
+		return fallback.Score(ctx, key, stats, now)
+	}
+	stddev := math.Max(math.Sqrt(baseline.Var), s.cfg.MinStdDev)
+	z := (rate - baseline.Mean) / stddev
+	return &SpikeScore{
+		Spike:    z >= s.cfg.ZScoreThreshold,
+		Method:   method,
+		Ratio:    rate / math.Max(baseline.Mean, s.cfg.MinBaselineRate),
+		ZScore:   z,
+		Expected: baseline.Mean,
+		Severity: zSeverity(z, s.cfg.ZScoreThreshold),
+	}, nil
+}
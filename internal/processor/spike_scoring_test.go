@@ -0,0 +1,234 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSpikeScorer(t *testing.T, method string) (SpikeScorer, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	scorer, err := NewSpikeScorer(client, config.SpikeDetectionConfig{Method: method})
+	require.NoError(t, err)
+	return scorer, client
+}
+
+// pageStats builds stats for a page editing at ratePerMin for the last five
+// minutes and hourlyPerMin over the hour.
+func pageStats(ratePerMin, hourlyPerMin float64) *storage.PageStats {
+	return &storage.PageStats{
+		EditsLast5Min: int64(ratePerMin * 5),
+		EditsLastHour: int64(hourlyPerMin * 60),
+	}
+}
+
+// warmUp scores and feeds the scorer one sample a minute for the given number
+// of minutes, as the spike detector does.
+func warmUp(t *testing.T, scorer SpikeScorer, key models.PageKey, start time.Time, minutes int, rate func(i int) float64) time.Time {
+	t.Helper()
+	now := start
+	for i := 0; i < minutes; i++ {
+		r := rate(i)
+		_, err := scorer.Score(context.Background(), key, pageStats(r, r), now)
+		require.NoError(t, err)
+		require.NoError(t, scorer.Observe(context.Background(), key, pageStats(r, r), now))
+		now = now.Add(time.Minute)
+	}
+	return now
+}
+
+func TestRatioScorer_MatchesOriginalRule(t *testing.T) {
+	scorer, _ := setupSpikeScorer(t, "ratio")
+	key := models.PageKey{Wiki: "enwiki", Title: "Quiet Page"}
+
+	// 5 edits in 5 minutes on a page silent for the hour: 1/min over a 0.1 floor
+	score, err := scorer.Score(context.Background(), key, pageStats(1, 0), time.Now())
+	require.NoError(t, err)
+	assert.True(t, score.Spike)
+	assert.InDelta(t, 10.0, score.Ratio, 0.001)
+	assert.Equal(t, "high", score.Severity)
+
+	// A busy page doubling its rate is not a 5x spike
+	score, err = scorer.Score(context.Background(), key, pageStats(20, 10), time.Now())
+	require.NoError(t, err)
+	assert.False(t, score.Spike)
+}
+
+func TestEWMAScorer_BusyAndTinyPages(t *testing.T) {
+	scorer, client := setupSpikeScorer(t, "ewma")
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// An always-busy page at ~10 edits/min surging to 20/min: only 2x its
+	// hourly rate, so the ratio rule misses it, but it is far outside its
+	// usual variation.
+	busy := models.PageKey{Wiki: "enwiki", Title: "Portal:Current events"}
+	now := warmUp(t, scorer, busy, start, 60, func(i int) float64 { return 10 + float64(i%3) - 1 })
+	score, err := scorer.Score(ctx, busy, pageStats(20, 11), now)
+	require.NoError(t, err)
+	assert.Equal(t, "ewma", score.Method)
+	assert.True(t, score.Spike, "z = %.1f", score.ZScore)
+	assert.InDelta(t, 10.0, score.Expected, 0.5)
+
+	// A tiny page that usually sees an edit every few minutes getting a
+	// handful: a 5x ratio, but within the standard deviation floor.
+	tiny := models.PageKey{Wiki: "enwiki", Title: "Obscure village"}
+	now = warmUp(t, scorer, tiny, start, 60, func(i int) float64 { return 0.2 })
+	score, err = scorer.Score(ctx, tiny, pageStats(1, 0.2), now)
+	require.NoError(t, err)
+	assert.False(t, score.Spike, "z = %.1f", score.ZScore)
+
+	// Baselines are stored compactly with a TTL
+	raw, err := client.HGet(ctx, "spike:baseline:page:enwiki:Obscure village", "ewma").Result()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9.e-]+,[0-9.e-]+,\d+,\d+$`, raw)
+	assert.Greater(t, client.TTL(ctx, "spike:baseline:page:enwiki:Obscure village").Val(), 30*24*time.Hour)
+}
+
+func TestEWMAScorer_FallsBackUntilWarm(t *testing.T) {
+	scorer, _ := setupSpikeScorer(t, "ewma")
+	key := models.PageKey{Wiki: "enwiki", Title: "New Page"}
+
+	score, err := scorer.Score(context.Background(), key, pageStats(1, 0), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "ratio", score.Method)
+	assert.True(t, score.Spike)
+}
+
+func TestEWMAScorer_QuietGapsDecayBaseline(t *testing.T) {
+	scorer, client := setupSpikeScorer(t, "ewma")
+	ctx := context.Background()
+	key := models.PageKey{Wiki: "enwiki", Title: "Went quiet"}
+
+	now := warmUp(t, scorer, key, time.Now(), 40, func(int) float64 { return 10 })
+	// Two hours without edits: the page is scored against the decayed
+	// baseline, and the decay is kept with the next sample
+	later := now.Add(2 * time.Hour)
+	score, err := scorer.Score(ctx, key, pageStats(10, 1), later)
+	require.NoError(t, err)
+	assert.True(t, score.Spike, "z = %.1f", score.ZScore)
+	require.NoError(t, scorer.Observe(ctx, key, pageStats(1, 1), later))
+
+	raw, err := client.HGet(ctx, "spike:baseline:page:enwiki:Went quiet", "ewma").Result()
+	require.NoError(t, err)
+	stat, err := parseBaselineStat(raw)
+	require.NoError(t, err)
+	assert.Less(t, stat.Mean, 1.0, "the quiet gap should pull the baseline down")
+}
+
+func TestSeasonalScorer_HourOfWeek(t *testing.T) {
+	scorer, client := setupSpikeScorer(t, "seasonal")
+	ctx := context.Background()
+	key := models.PageKey{Wiki: "enwiki", Title: "Weekly Show"}
+
+	// Busy every Monday 09:00 for four weeks, quiet at 15:00
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for week := 0; week < 4; week++ {
+		base := monday.AddDate(0, 0, 7*week)
+		warmUp(t, scorer, key, base, 60, func(i int) float64 { return 8 + float64(i%3) - 1 })
+		warmUp(t, scorer, key, base.Add(6*time.Hour), 60, func(int) float64 { return 0.4 })
+	}
+
+	// 8 edits/min is normal for Monday morning...
+	nextMonday := monday.AddDate(0, 0, 28)
+	score, err := scorer.Score(ctx, key, pageStats(8, 1), nextMonday)
+	require.NoError(t, err)
+	assert.Equal(t, "seasonal", score.Method)
+	assert.False(t, score.Spike, "z = %.1f", score.ZScore)
+
+	// ...but a spike on Monday afternoon
+	score, err = scorer.Score(ctx, key, pageStats(8, 1), nextMonday.Add(6*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, score.Spike, "z = %.1f", score.ZScore)
+
+	// The wiki-wide slot baseline is kept too
+	n, err := client.HLen(ctx, "spike:baseline:wiki:enwiki").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+}
+
+func TestSeasonalScorer_DecaysUnsampledSlotMinutes(t *testing.T) {
+	scorer, client := setupSpikeScorer(t, "seasonal")
+	ctx := context.Background()
+	key := models.PageKey{Wiki: "enwiki", Title: "Monday Only"}
+
+	// Busy for the first half of one Monday 09:00, then not sampled in
+	// that slot again until a week later
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	warmUp(t, scorer, key, monday, 30, func(int) float64 { return 10 })
+	require.NoError(t, scorer.Observe(ctx, key, pageStats(1, 1), monday.AddDate(0, 0, 7)))
+
+	raw, err := client.HGet(ctx, "spike:baseline:page:enwiki:Monday Only", hourOfWeekField(monday)).Result()
+	require.NoError(t, err)
+	stat, err := parseBaselineStat(raw)
+	require.NoError(t, err)
+	assert.EqualValues(t, 30+30+1, stat.N, "the rest of last week's slot counts as quiet")
+	assert.Less(t, stat.Mean, 3.0)
+}
+
+func TestSlotGap(t *testing.T) {
+	const week = 7 * 24 * 60
+	hour := int64(29500000 / 60 * 60)
+	assert.EqualValues(t, 0, slotGap(hour+5, hour+6))
+	assert.EqualValues(t, 4, slotGap(hour+5, hour+10))
+	assert.EqualValues(t, 54+10, slotGap(hour+5, hour+week+10))
+	assert.EqualValues(t, 54+60+10, slotGap(hour+5, hour+2*week+10))
+}
+
+func TestSpikeDetector_ObservesBelowMinimumEdits(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{Processor: config.Processor{Spike: config.SpikeDetectionConfig{Method: "ewma", MinimumEdits: 3}}}
+	sd := NewSpikeDetector(nil, client, cfg, zerolog.Nop())
+	key := models.PageKey{Wiki: "enwiki", Title: "Slow Hot Page"}
+
+	// One edit in five minutes is too few to score, but still a sample
+	alert, err := sd.detectSpike(context.Background(), key, pageStats(0.2, 0.2))
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+
+	raw, err := client.HGet(context.Background(), "spike:baseline:page:enwiki:Slow Hot Page", "ewma").Result()
+	require.NoError(t, err)
+	stat, err := parseBaselineStat(raw)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stat.N)
+	assert.InDelta(t, 0.2, stat.Mean, 1e-6)
+}
+
+func TestBaselineStat_RoundTrip(t *testing.T) {
+	b := &baselineStat{}
+	for _, x := range []float64{1, 2, 3, 2, 1} {
+		b.observe(x, 0.2)
+	}
+	b.Last = 29500000
+
+	parsed, err := parseBaselineStat(b.String())
+	require.NoError(t, err)
+	assert.InDelta(t, b.Mean, parsed.Mean, 1e-5)
+	assert.InDelta(t, b.Var, parsed.Var, 1e-5)
+	assert.Equal(t, b.N, parsed.N)
+	assert.Equal(t, b.Last, parsed.Last)
+
+	_, err = parseBaselineStat("1,2,3")
+	assert.Error(t, err)
+}
@@ -0,0 +1,284 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// qidNotFound caches pages without a Wikidata item, so a spiking
+	// unlinked article is not looked up on every alert.
+	qidNotFound = "-"
+
+	// qidNegativeTTL is short because new articles are usually linked to
+	// Wikidata within hours of creation.
+	qidNegativeTTL = 6 * time.Hour
+
+	// qidErrorTTL holds off retrying a page whose lookup failed, so an
+	// unreachable wiki API is not asked again on every spike.
+	qidErrorTTL = 10 * time.Minute
+
+	// maxConcurrentQIDLookups bounds the lookups running in the background.
+	// Spikes of uncached pages beyond it are not filed under a story.
+	maxConcurrentQIDLookups = 4
+)
+
+// QIDResolver maps a page to the Wikidata item (QID) it is linked to, via the
+// page's own wiki API, caching answers in Redis under wikidata:qid:{page}.
+type QIDResolver struct {
+	redis    *redis.Client
+	http     *http.Client
+	cacheTTL time.Duration
+	apiURL   func(serverURL string) string
+}
+
+// NewQIDResolver creates a resolver.
+func NewQIDResolver(redisClient *redis.Client, cfg config.StoryClusteringConfig) *QIDResolver {
+	return &QIDResolver{
+		redis:    redisClient,
+		http:     &http.Client{Timeout: cfg.LookupTimeout},
+		cacheTTL: cfg.CacheTTL,
+		apiURL: func(serverURL string) string {
+			return strings.TrimRight(serverURL, "/") + "/w/api.php"
+		},
+	}
+}
+
+func qidCacheKey(key models.PageKey) string {
+	return fmt.Sprintf("wikidata:qid:%s", key)
+}
+
+// Cached returns the page's QID if the cache has an answer for it: ok is
+// false on a miss, and qid is "" for pages not linked to Wikidata.
+func (r *QIDResolver) Cached(ctx context.Context, key models.PageKey) (qid string, ok bool, err error) {
+	cached, err := r.redis.Get(ctx, qidCacheKey(key)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read cached wikidata id: %w", err)
+	}
+	metrics.WikidataLookupsTotal.WithLabelValues("cached").Inc()
+	if cached == qidNotFound {
+		return "", true, nil
+	}
+	return cached, true, nil
+}
+
+// Resolve returns the page's QID, or "" if it is not linked to Wikidata.
+// serverURL is the page's wiki, e.g. https://de.wikipedia.org. A cache miss
+// costs a request to the wiki API; failed requests are cached briefly as
+// "not linked".
+func (r *QIDResolver) Resolve(ctx context.Context, key models.PageKey, serverURL string) (string, error) {
+	if qid, ok, err := r.Cached(ctx, key); err != nil || ok {
+		return qid, err
+	}
+	if serverURL == "" {
+		return "", nil
+	}
+
+	cacheKey := qidCacheKey(key)
+	qid, err := r.lookup(ctx, key.Title, serverURL)
+	if err != nil {
+		metrics.WikidataLookupsTotal.WithLabelValues("error").Inc()
+		r.redis.Set(ctx, cacheKey, qidNotFound, qidErrorTTL)
+		return "", err
+	}
+
+	if qid == "" {
+		metrics.WikidataLookupsTotal.WithLabelValues("not_found").Inc()
+		r.redis.Set(ctx, cacheKey, qidNotFound, qidNegativeTTL)
+		return "", nil
+	}
+	metrics.WikidataLookupsTotal.WithLabelValues("resolved").Inc()
+	r.redis.Set(ctx, cacheKey, qid, r.cacheTTL)
+	return qid, nil
+}
+
+// lookup asks the wiki for the page's wikibase_item, following redirects.
+func (r *QIDResolver) lookup(ctx context.Context, title, serverURL string) (string, error) {
+	reqURL := fmt.Sprintf("%s?action=query&prop=pageprops&ppprop=wikibase_item&redirects=1&titles=%s&format=json",
+		r.apiURL(serverURL), url.QueryEscape(title))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build wikidata lookup: %w", err)
+	}
+	req.Header.Set("User-Agent", "WikiSurge/1.0 (https://github.com/Agnikulu/WikiSurge)")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up wikidata id: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("wikidata lookup returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Query struct {
+			Pages map[string]struct {
+				Pageprops struct {
+					WikibaseItem string `json:"wikibase_item"`
+				} `json:"pageprops"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode wikidata lookup: %w", err)
+	}
+	for _, page := range result.Query.Pages {
+		if isQID(page.Pageprops.WikibaseItem) {
+			return page.Pageprops.WikibaseItem, nil
+		}
+	}
+	return "", nil
+}
+
+// isQID reports whether s looks like a Wikidata item ID (Q followed by digits).
+func isQID(s string) bool {
+	if len(s) < 2 || s[0] != 'Q' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// StoryClusterer groups spike alerts about the same topic in different
+// language editions into stories. Each spiking page is resolved to its
+// Wikidata item; spikes sharing an item within the window form one story,
+// which becomes a "global story" once minWikis wikis have spiked.
+type StoryClusterer struct {
+	resolver  *QIDResolver
+	store     *storage.StoryStore
+	minWikis  int
+	logger    zerolog.Logger
+	lookupSem chan struct{}  // semaphore bounding background QID lookups
+	lookups   sync.WaitGroup // background lookups in flight
+	ctx       context.Context
+	cancel    context.CancelFunc // cancels background lookups on Stop
+}
+
+// NewStoryClusterer creates a story clusterer.
+func NewStoryClusterer(redisClient *redis.Client, cfg *config.Config, logger zerolog.Logger) *StoryClusterer {
+	st := cfg.Processor.Stories
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StoryClusterer{
+		resolver:  NewQIDResolver(redisClient, st),
+		store:     storage.NewStoryStore(redisClient, st.Window),
+		minWikis:  st.MinWikis,
+		logger:    logger.With().Str("component", "story-clusterer").Logger(),
+		lookupSem: make(chan struct{}, maxConcurrentQIDLookups),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Stop cancels the background lookups and waits for them to return, so none
+// writes to Redis after it is closed. Spikes added after Stop are ignored
+// unless their QID is cached.
+func (c *StoryClusterer) Stop() {
+	c.cancel()
+	c.lookups.Wait()
+}
+
+// AddSpike files a spike alert under its page's story. Pages without a
+// Wikidata item are ignored. If the page's QID is not cached it is looked
+// up in the background and the spike filed once it is known, so the spike
+// consumer never waits on the wiki API.
+func (c *StoryClusterer) AddSpike(ctx context.Context, alert *SpikeAlert) error {
+	key := models.NewPageKey(alert.Wiki, alert.PageTitle)
+	qid, ok, err := c.resolver.Cached(ctx, key)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return c.fileSpike(ctx, qid, key, alert)
+	}
+	if alert.ServerURL == "" || c.ctx.Err() != nil {
+		return nil
+	}
+
+	select {
+	case c.lookupSem <- struct{}{}:
+		c.lookups.Add(1)
+		go func() {
+			defer c.lookups.Done()
+			defer func() { <-c.lookupSem }()
+			ctx := c.ctx
+			qid, err := c.resolver.Resolve(ctx, key, alert.ServerURL)
+			if err == nil {
+				err = c.fileSpike(ctx, qid, key, alert)
+			}
+			if err != nil {
+				c.logger.Warn().Err(err).Str("page", key.String()).Msg("Failed to add spike to its story")
+			}
+		}()
+	default:
+		c.logger.Debug().Str("page", key.String()).Msg("Wikidata lookups busy, spike not added to a story")
+	}
+	return nil
+}
+
+// fileSpike records the spike under the story for qid, announcing the story
+// when it first reaches minWikis wikis.
+func (c *StoryClusterer) fileSpike(ctx context.Context, qid string, key models.PageKey, alert *SpikeAlert) error {
+	if qid == "" {
+		return nil
+	}
+
+	story, err := c.store.AddSpike(ctx, qid, storage.StoryPage{
+		Wiki:       key.Wiki,
+		Title:      key.Title,
+		ServerURL:  alert.ServerURL,
+		SpikeRatio: alert.SpikeRatio,
+		Edits5Min:  alert.Edits5Min,
+		Severity:   alert.Severity,
+		Spikes:     1,
+		LastSpike:  alert.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	if story == nil || len(story.Pages) != c.minWikis {
+		return nil
+	}
+	// Announce the story once: when this wiki's first spike brings it to
+	// minWikis, not on later spikes of the same pages
+	wikis := make([]string, len(story.Pages))
+	newWiki := false
+	for i, p := range story.Pages {
+		wikis[i] = p.Wiki
+		if p.Wiki == key.Wiki && p.Spikes == 1 {
+			newWiki = true
+		}
+	}
+	if newWiki {
+		metrics.StoriesDetectedTotal.Inc()
+		c.logger.Info().
+			Str("qid", qid).
+			Str("title", story.Title).
+			Strs("wikis", wikis).
+			Msg("Global story detected")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWikiAPI serves pageprops for a fixed set of titles and counts requests.
+func fakeWikiAPI(t *testing.T, items map[string]string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		title := r.URL.Query().Get("titles")
+		if qid, ok := items[title]; ok {
+			fmt.Fprintf(w, `{"query":{"pages":{"1":{"title":%q,"pageprops":{"wikibase_item":%q}}}}}`, title, qid)
+			return
+		}
+		fmt.Fprintf(w, `{"query":{"pages":{"-1":{"title":%q,"missing":""}}}}`, title)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func setupStoryClusterer(t *testing.T, apiURL string) (*StoryClusterer, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{Processor: config.Processor{Stories: config.StoryClusteringConfig{
+		Enabled:       true,
+		Window:        6 * time.Hour,
+		MinWikis:      2,
+		CacheTTL:      24 * time.Hour,
+		LookupTimeout: time.Second,
+	}}}
+	c := NewStoryClusterer(client, cfg, zerolog.Nop())
+	c.resolver.apiURL = func(string) string { return apiURL }
+	return c, client
+}
+
+func TestQIDResolver_CachesAnswers(t *testing.T) {
+	api, calls := fakeWikiAPI(t, map[string]string{"Earthquake": "Q7944"})
+	c, client := setupStoryClusterer(t, api.URL)
+	ctx := context.Background()
+
+	key := models.NewPageKey("enwiki", "Earthquake")
+	for i := 0; i < 3; i++ {
+		qid, err := c.resolver.Resolve(ctx, key, "https://en.wikipedia.org")
+		require.NoError(t, err)
+		assert.Equal(t, "Q7944", qid)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+	assert.Equal(t, "Q7944", client.Get(ctx, "wikidata:qid:enwiki:Earthquake").Val())
+
+	// Unlinked pages are cached too, for less time
+	unlinked := models.NewPageKey("enwiki", "Sandbox draft")
+	for i := 0; i < 2; i++ {
+		qid, err := c.resolver.Resolve(ctx, unlinked, "https://en.wikipedia.org")
+		require.NoError(t, err)
+		assert.Empty(t, qid)
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+	assert.LessOrEqual(t, client.TTL(ctx, "wikidata:qid:enwiki:Sandbox draft").Val(), qidNegativeTTL)
+
+	// Failed lookups are not retried on every spike either
+	c.resolver.apiURL = func(string) string { return "http://127.0.0.1:1" }
+	broken := models.NewPageKey("enwiki", "Unreachable")
+	_, err := c.resolver.Resolve(ctx, broken, "https://en.wikipedia.org")
+	assert.Error(t, err)
+	qid, err := c.resolver.Resolve(ctx, broken, "https://en.wikipedia.org")
+	require.NoError(t, err)
+	assert.Empty(t, qid)
+	assert.LessOrEqual(t, client.TTL(ctx, "wikidata:qid:enwiki:Unreachable").Val(), qidErrorTTL)
+}
+
+func TestStoryClusterer_LooksUpInBackground(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, `{"query":{"pages":{"1":{"title":"Earthquake","pageprops":{"wikibase_item":"Q7944"}}}}}`)
+	}))
+	t.Cleanup(srv.Close)
+	c, _ := setupStoryClusterer(t, srv.URL)
+	ctx := context.Background()
+
+	// The spike is accepted while the wiki API has yet to answer
+	require.NoError(t, c.AddSpike(ctx, &SpikeAlert{
+		PageTitle: "Earthquake", Wiki: "enwiki", ServerURL: "https://en.wikipedia.org",
+		SpikeRatio: 10, Severity: "high", Timestamp: time.Now(),
+	}))
+	close(release)
+	c.lookups.Wait()
+
+	story, err := c.store.GetStory(ctx, "Q7944")
+	require.NoError(t, err)
+	require.NotNil(t, story)
+	assert.Equal(t, "enwiki", story.Pages[0].Wiki)
+}
+
+func TestStoryClusterer_StopCancelsLookups(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-r.Context().Done() // the wiki API never answers
+	}))
+	t.Cleanup(srv.Close)
+	c, client := setupStoryClusterer(t, srv.URL)
+	c.resolver.http.Timeout = time.Minute
+	ctx := context.Background()
+
+	spike := &SpikeAlert{
+		PageTitle: "Earthquake", Wiki: "enwiki", ServerURL: "https://en.wikipedia.org",
+		SpikeRatio: 10, Severity: "high", Timestamp: time.Now(),
+	}
+	require.NoError(t, c.AddSpike(ctx, spike))
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the lookup in flight")
+	}
+
+	// Spikes after Stop start no lookups
+	require.NoError(t, c.AddSpike(ctx, spike))
+	c.lookups.Wait()
+	assert.LessOrEqual(t, atomic.LoadInt32(&calls), int32(1))
+	assert.Zero(t, client.Exists(ctx, "story:Q7944").Val())
+}
+
+func TestStoryClusterer_GroupsLanguagesByQID(t *testing.T) {
+	api, _ := fakeWikiAPI(t, map[string]string{
+		"Earthquake": "Q7944",
+		"Erdbeben":   "Q7944",
+		"Terremoto":  "Q7944",
+		"Unrelated":  "Q1",
+	})
+	c, _ := setupStoryClusterer(t, api.URL)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	spike := func(wiki, title, severity string, at time.Time) {
+		require.NoError(t, c.AddSpike(ctx, &SpikeAlert{
+			PageTitle: title, Wiki: wiki, ServerURL: "https://example.org",
+			SpikeRatio: 10, Edits5Min: 20, Severity: severity, Timestamp: at,
+		}))
+		c.lookups.Wait()
+	}
+	spike("dewiki", "Erdbeben", "high", now)
+	spike("enwiki", "Unrelated", "medium", now)
+	spike("enwiki", "Earthquake", "critical", now.Add(5*time.Minute))
+	spike("eswiki", "Terremoto", "medium", now.Add(10*time.Minute))
+
+	stories, err := c.store.GetStories(ctx, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, stories, 1)
+
+	story := stories[0]
+	assert.Equal(t, "Q7944", story.QID)
+	assert.Equal(t, "Earthquake", story.Title)
+	assert.Equal(t, "critical", story.Severity)
+	wikis := make([]string, len(story.Pages))
+	for i, p := range story.Pages {
+		wikis[i] = p.Wiki
+	}
+	assert.Equal(t, []string{"enwiki", "dewiki", "eswiki"}, wikis)
+}
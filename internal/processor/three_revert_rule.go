@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxThreeRevertEntries caps the reverts kept per page for the three-revert
+// rule. A page reverted more often than this in a day is beyond 3RR anyway.
+const maxThreeRevertEntries = 500
+
+// checkThreeRevertRule records rv against its reverter in the page's rolling
+// 3RR window and publishes a 3rr_violation alert when the editor's reverts of
+// the page in the window first exceed the limit. The window follows the
+// edits' own timestamps, so a replayed backlog reports the same violations.
+//
+// Unlike the rest of the edit war tracking it sees every page, though on
+// pages that are not hot reverts are only recognised by their summary.
+func (ewd *EditWarDetector) checkThreeRevertRule(ctx context.Context, edit *models.WikipediaEdit, rv *Revert) error {
+	key := edit.PageKey()
+	window := ewd.config.Processor.Reverts.ThreeRevertWindow
+	limit := ewd.config.Processor.Reverts.ThreeRevertLimit
+
+	entry, err := json.Marshal(rv)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revert: %w", err)
+	}
+	cutoff := rv.Timestamp - int64(window/time.Second)
+
+	rrKey := fmt.Sprintf("editwar:3rr:%s", key)
+	pipe := ewd.redis.Pipeline()
+	pipe.ZAdd(ctx, rrKey, redis.Z{Score: float64(rv.Timestamp), Member: string(entry)})
+	pipe.ZRemRangeByScore(ctx, rrKey, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	pipe.ZRemRangeByRank(ctx, rrKey, 0, -maxThreeRevertEntries-1)
+	pipe.Expire(ctx, rrKey, window)
+	inWindow := pipe.ZRange(ctx, rrKey, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to track reverts for 3RR: %w", err)
+	}
+
+	var reverts []Revert
+	for _, raw := range inWindow.Val() {
+		var r Revert
+		if json.Unmarshal([]byte(raw), &r) != nil || r.Reverter != rv.Reverter {
+			continue
+		}
+		reverts = append(reverts, r)
+	}
+	// Only the revert that crosses the limit alerts; later ones in the same
+	// window are part of the violation already reported
+	if len(reverts) != limit+1 {
+		return nil
+	}
+
+	v := storage.ThreeRevertViolation{
+		Wiki:        key.Wiki,
+		Title:       key.Title,
+		ServerURL:   edit.ServerURL,
+		User:        rv.Reverter,
+		RevertCount: len(reverts),
+		Limit:       limit,
+		WindowHours: window.Hours(),
+		Severity:    "high",
+		At:          time.Unix(rv.Timestamp, 0).UTC(),
+	}
+	for _, r := range reverts {
+		v.Reverts = append(v.Reverts, storage.ViolationRevert{
+			RevisionID:        r.RevisionID,
+			Timestamp:         time.Unix(r.Timestamp, 0).UTC(),
+			RevertedUsers:     r.RevertedUsers,
+			RevertedRevisions: r.RevertedRevisions,
+		})
+	}
+	// Breaking 3RR in the middle of a detected edit war is the case
+	// administrators act on first
+	if ex, _ := ewd.redis.Exists(ctx, fmt.Sprintf("editwar:%s", key)).Result(); ex > 0 {
+		v.Severity = "critical"
+	}
+
+	if err := ewd.alerts.PublishThreeRevertViolation(ctx, v); err != nil {
+		return fmt.Errorf("failed to publish 3RR violation: %w", err)
+	}
+	metrics.ThreeRevertViolationsTotal.WithLabelValues(key.Wiki).Inc()
+	ewd.logger.Info().
+		Str("page", key.String()).
+		Str("user", v.User).
+		Int("reverts", v.RevertCount).
+		Str("severity", v.Severity).
+		Msg("Three-revert rule violation")
+	return nil
+}
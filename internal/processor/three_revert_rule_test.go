@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditWarDetector_ThreeRevertRule(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Redis: config.Redis{HotPages: config.HotPages{
+			MaxTracked: 100, PromotionThreshold: 3, WindowDuration: time.Hour,
+			MaxMembersPerPage: 50, HotThreshold: 2, CleanupInterval: 5 * time.Minute,
+		}},
+		Processor: config.Processor{
+			Reverts: config.RevertDetectionConfig{ThreeRevertLimit: 3, ThreeRevertWindow: 24 * time.Hour},
+		},
+	}
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	detector := NewEditWarDetector(hotPages, client, cfg, zerolog.Nop())
+	alerts := storage.NewRedisAlerts(client)
+	ctx := context.Background()
+
+	page := models.NewPageKey("enwiki", "Contested")
+	mr.ZAdd("hot:window:"+page.String(), float64(time.Now().Unix()), "seed")
+
+	base := time.Now().Add(-30 * time.Hour).Truncate(time.Second)
+	id := int64(100)
+	revert := func(user, victim string, after time.Duration) {
+		t.Helper()
+		edit := makeRevert(id, page.Title, user, victim, 1500, 1000)
+		edit.Timestamp = base.Add(after).Unix()
+		id += 2
+		require.NoError(t, detector.ProcessEdit(ctx, edit))
+	}
+
+	// Bob's first revert falls out of the window before his fourth
+	revert("Bob", "Alice", 0)
+	revert("Bob", "Alice", 25*time.Hour)
+	revert("Carol", "Bob", 25*time.Hour+10*time.Minute)
+	revert("Bob", "Carol", 26*time.Hour)
+	revert("Carol", "Bob", 26*time.Hour+10*time.Minute)
+	revert("Bob", "Carol", 27*time.Hour)
+	revert("Carol", "Bob", 27*time.Hour+10*time.Minute) // Carol stops at the limit
+
+	published, err := alerts.GetRecentAlerts(ctx, "3rr", 10)
+	require.NoError(t, err)
+	assert.Empty(t, published)
+
+	revert("Bob", "Carol", 28*time.Hour)
+	revert("Bob", "Carol", 29*time.Hour) // same violation, not alerted again
+
+	violations, err := alerts.GetThreeRevertViolations(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+
+	v := violations[0]
+	assert.Equal(t, "Bob", v.User)
+	assert.Equal(t, "enwiki", v.Wiki)
+	assert.Equal(t, "Contested", v.Title)
+	assert.Equal(t, 4, v.RevertCount)
+	assert.Equal(t, "critical", v.Severity) // the reverting also set off an edit war alert
+	assert.Equal(t, base.Add(28*time.Hour).UTC(), v.At)
+	require.Len(t, v.Reverts, 4)
+	assert.Equal(t, base.Add(25*time.Hour).UTC(), v.Reverts[0].Timestamp)
+	assert.Equal(t, int64(103), v.Reverts[0].RevisionID)
+	assert.Equal(t, []string{"Alice"}, v.Reverts[0].RevertedUsers)
+	assert.Equal(t, []int64{101}, v.Reverts[0].RevertedRevisions)
+	assert.Equal(t, []string{"Carol"}, v.Reverts[3].RevertedUsers)
+}
+
+func TestEditWarDetector_ThreeRevertRuleOnQuietPage(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Redis: config.Redis{HotPages: config.HotPages{
+			MaxTracked: 100, PromotionThreshold: 3, WindowDuration: time.Hour,
+			MaxMembersPerPage: 50, HotThreshold: 2, CleanupInterval: 5 * time.Minute,
+		}},
+		Processor: config.Processor{
+			Reverts: config.RevertDetectionConfig{ThreeRevertLimit: 3, ThreeRevertWindow: 24 * time.Hour},
+		},
+	}
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	detector := NewEditWarDetector(hotPages, client, cfg, zerolog.Nop())
+	alerts := storage.NewRedisAlerts(client)
+	ctx := context.Background()
+
+	// Four reverts spread over most of a day never make the page hot
+	base := time.Now().Add(-20 * time.Hour).Truncate(time.Second)
+	for i := 0; i < 4; i++ {
+		edit := makeRevert(int64(200+2*i), "Slow burn", "Bob", "Alice", 1500, 1000)
+		edit.Timestamp = base.Add(time.Duration(i) * 6 * time.Hour).Unix()
+		require.NoError(t, detector.ProcessEdit(ctx, edit))
+	}
+
+	violations, err := alerts.GetThreeRevertViolations(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Bob", violations[0].User)
+	assert.Equal(t, "Slow burn", violations[0].Title)
+	assert.Equal(t, "high", violations[0].Severity)
+
+	// The page's edit war tracking is still left to hot pages
+	assert.False(t, mr.Exists("editwar:editors:enwiki:Slow burn"))
+	assert.False(t, mr.Exists("editwar:reverts:enwiki:Slow burn"))
+}
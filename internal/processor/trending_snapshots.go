@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// TrendingSnapshotter records the trending leaderboards into the trending
+// history once per history interval. It checks several times an interval,
+// so a late tick does not skip one; TrendingHistory makes sure each
+// interval is recorded once, however many processors are running.
+type TrendingSnapshotter struct {
+	scorer  *storage.TrendingScorer
+	history *storage.TrendingHistory
+	cfg     config.TrendingHistoryConfig
+	clock   storage.TimeProvider
+	logger  zerolog.Logger
+}
+
+// NewTrendingSnapshotter creates the snapshotter. Call Start to run it.
+func NewTrendingSnapshotter(scorer *storage.TrendingScorer, history *storage.TrendingHistory, cfg *config.Config, logger zerolog.Logger) *TrendingSnapshotter {
+	return &TrendingSnapshotter{
+		scorer:  scorer,
+		history: history,
+		cfg:     cfg.Redis.Trending.History,
+		clock:   &storage.RealTimeProvider{},
+		logger:  logger.With().Str("component", "trending-snapshotter").Logger(),
+	}
+}
+
+// SetTimeProvider sets the clock snapshots are taken and stamped by. Use
+// the trending scorer's, so snapshot scores match the leaderboard's.
+func (s *TrendingSnapshotter) SetTimeProvider(tp storage.TimeProvider) {
+	s.clock = tp
+}
+
+// Start snapshots the leaderboards until ctx is cancelled.
+func (s *TrendingSnapshotter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval / 4)
+		defer ticker.Stop()
+
+		s.logger.Info().Dur("interval", s.cfg.Interval).Int("top_n", s.cfg.TopN).Msg("Trending snapshotter started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.logger.Info().Msg("Trending snapshotter stopped")
+				return
+			case <-ticker.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+}
+
+// tick takes the current interval's snapshot if nobody has yet.
+func (s *TrendingSnapshotter) tick(ctx context.Context) {
+	recorded, err := s.history.Snapshot(ctx, s.scorer, s.clock.Now())
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Trending snapshot failed")
+		return
+	}
+	if recorded > 0 {
+		s.logger.Debug().Int("leaderboards", recorded).Msg("Recorded trending snapshot")
+	}
+}
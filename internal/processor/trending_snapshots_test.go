@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendingSnapshotter_Tick(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{Redis: config.Redis{Trending: config.TrendingConfig{
+		Enabled: true, MaxPages: 100, HalfLifeMinutes: 30,
+		History: config.TrendingHistoryConfig{Enabled: true, Interval: 5 * time.Minute, Retention: time.Hour, TopN: 10},
+	}}}
+	start := time.Date(2026, 6, 1, 15, 0, 0, 0, time.UTC)
+	clock := storage.NewMockTimeProvider()
+	clock.SetTime(start)
+
+	scorer := storage.NewTrendingScorerForTest(client, &cfg.Redis.Trending)
+	t.Cleanup(scorer.Stop)
+	scorer.SetTimeProvider(clock)
+	history := storage.NewTrendingHistory(client, cfg.Redis.Trending.History)
+	s := NewTrendingSnapshotter(scorer, history, cfg, zerolog.Nop())
+	s.SetTimeProvider(clock)
+	ctx := context.Background()
+
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("dewiki", "Berlin"), 5))
+	s.tick(ctx)
+
+	// Later ticks in the same interval leave its snapshot alone
+	clock.FastForward(2 * time.Minute)
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("dewiki", "Hamburg"), 50))
+	s.tick(ctx)
+
+	snap, err := history.At(ctx, "dewiki", clock.Now())
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	assert.Equal(t, start, snap.At)
+	require.Len(t, snap.Pages, 1)
+	assert.Equal(t, "Berlin", snap.Pages[0].Title)
+
+	clock.FastForward(3 * time.Minute)
+	s.tick(ctx)
+	snap, err = history.At(ctx, "dewiki", clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "Hamburg", snap.Pages[0].Title)
+}
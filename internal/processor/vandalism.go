@@ -0,0 +1,299 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// Weights of the vandalism signals. Signals are combined as independent
+// evidence, confidence = 1 - Π(1 - weight), so no single weak signal alerts
+// on its own but two or three together do.
+const (
+	weightAnonymous      = 0.25
+	weightLargeRemoval   = 0.35
+	weightBlanking       = 0.5
+	weightHotPageRemoval = 0.5
+	weightProfaneComment = 0.35
+	weightCapsComment    = 0.2
+	weightRapidNewUser   = 0.4
+)
+
+// defaultProfaneWords are matched as whole words in edit summaries.
+var defaultProfaneWords = []string{
+	"fuck", "fucking", "shit", "crap", "poop", "penis", "butt", "ass",
+	"stupid", "idiot", "dumb", "sucks", "lol", "lmao", "haha",
+}
+
+// VandalismSignal is one heuristic that fired for an edit.
+type VandalismSignal struct {
+	Code   string  // stable identifier, used as a metric label
+	Reason string  // human-readable explanation for the alert
+	Weight float64 // contribution to the confidence
+}
+
+// VandalismDetector scores each edit with explainable heuristics and
+// publishes a vandalism alert, with the reasons that fired, when their
+// combined confidence reaches the configured threshold.
+type VandalismDetector struct {
+	alerts   *storage.RedisAlerts
+	hotPages *storage.HotPageTracker
+	redis    *redis.Client
+	cfg      config.VandalismConfig
+	profane  *regexp.Regexp
+	accounts *storage.AccountRegistry
+	clock    *storage.EventClock
+	dedup    *storage.EditDeduplicator
+	logger   zerolog.Logger
+
+	mu        sync.Mutex
+	cooldowns map[models.PageKey]time.Time
+}
+
+// NewVandalismDetector creates a vandalism detector. hotPages may be nil, in
+// which case the hot page removal signal is not used.
+func NewVandalismDetector(alerts *storage.RedisAlerts, hotPages *storage.HotPageTracker, redisClient *redis.Client, cfg *config.Config, logger zerolog.Logger) *VandalismDetector {
+	words := cfg.Processor.Vandalism.ProfaneWords
+	if len(words) == 0 {
+		words = defaultProfaneWords
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(w))
+	}
+
+	return &VandalismDetector{
+		alerts:    alerts,
+		hotPages:  hotPages,
+		redis:     redisClient,
+		cfg:       cfg.Processor.Vandalism,
+		profane:   regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`),
+		accounts:  storage.NewAccountRegistry(redisClient, cfg.Processor.Vandalism.NewAccountAge),
+		clock:     storage.NewEventClock("vandalism-detector", cfg.Processor.EventTime),
+		logger:    logger.With().Str("component", "vandalism-detector").Logger(),
+		cooldowns: make(map[models.PageKey]time.Time),
+	}
+}
+
+// ProcessEdit implements kafka.MessageHandler.
+func (vd *VandalismDetector) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return vd.dedup.Process(ctx, "vandalism-detector", edit, func() error {
+		return vd.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator makes ProcessEdit skip revisions already scored, so a
+// redelivered edit does not count twice towards a user's rapid edits.
+func (vd *VandalismDetector) SetDeduplicator(d *storage.EditDeduplicator) {
+	vd.dedup = d
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (vd *VandalismDetector) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	at, ok := vd.clock.Observe(edit)
+	if !ok {
+		return nil
+	}
+	// Articles only; bots and log entries are not vandalism candidates
+	if edit.Bot || edit.IsLogEvent() || !edit.IsMainNamespace() {
+		return nil
+	}
+	if edit.Type != "edit" && edit.Type != "new" {
+		return nil
+	}
+
+	signals, err := vd.score(ctx, edit, at)
+	if err != nil {
+		return err
+	}
+	confidence := combineSignals(signals)
+	if confidence < vd.cfg.MinConfidence {
+		return nil
+	}
+
+	key := edit.PageKey()
+	vd.mu.Lock()
+	if last, ok := vd.cooldowns[key]; ok && at.Sub(last) < vd.cfg.Cooldown {
+		vd.mu.Unlock()
+		return nil
+	}
+	vd.cooldowns[key] = at
+	if len(vd.cooldowns) > maxCooldownEntries {
+		fresh := make(map[models.PageKey]time.Time, len(vd.cooldowns)/2)
+		for k, t := range vd.cooldowns {
+			if at.Sub(t) < vd.cfg.Cooldown {
+				fresh[k] = t
+			}
+		}
+		vd.cooldowns = fresh
+	}
+	vd.mu.Unlock()
+
+	reasons := make([]string, len(signals))
+	for i, s := range signals {
+		reasons[i] = s.Reason
+	}
+	if err := vd.alerts.PublishVandalismAlert(ctx, edit, confidence, reasons); err != nil {
+		vd.logger.Error().Err(err).Str("page", key.String()).Msg("Failed to publish vandalism alert")
+		return err
+	}
+
+	for _, s := range signals {
+		metrics.VandalismAlertsTotal.WithLabelValues(s.Code).Inc()
+	}
+	vd.logger.Info().
+		Str("page", key.String()).
+		Str("user", edit.User).
+		Float64("confidence", confidence).
+		Strs("reasons", reasons).
+		Msg("Possible vandalism detected")
+	return nil
+}
+
+// score runs every heuristic over the edit and returns those that fired.
+func (vd *VandalismDetector) score(ctx context.Context, edit *models.WikipediaEdit, at time.Time) ([]VandalismSignal, error) {
+	var signals []VandalismSignal
+
+	anonymous := edit.IsAnonymous()
+	if anonymous {
+		signals = append(signals, VandalismSignal{"anonymous", "anonymous editor", weightAnonymous})
+	}
+
+	// Content removal, unless the edit is itself a revert: reverting
+	// vandalism often removes a lot of text, which must not count against
+	// the patroller doing it
+	if !isRevertComment(edit.Comment) {
+		oldLen, newLen := edit.Length.Old, edit.Length.New
+		removed := oldLen - newLen
+		switch {
+		case oldLen >= vd.cfg.BlankingMinOldBytes && newLen <= vd.cfg.BlankingMaxBytes:
+			signals = append(signals, VandalismSignal{"blanking", fmt.Sprintf("page blanked (%d → %d bytes)", oldLen, newLen), weightBlanking})
+		case removed >= vd.cfg.LargeRemovalBytes:
+			signals = append(signals, VandalismSignal{"large_removal", fmt.Sprintf("large removal (%d bytes)", removed), weightLargeRemoval})
+		}
+
+		if removed > 0 && oldLen > 0 && float64(removed)/float64(oldLen) >= vd.cfg.HotPageRemoval && vd.hotPages != nil {
+			hot, err := vd.hotPages.IsHot(ctx, edit.PageKey())
+			if err != nil {
+				return nil, fmt.Errorf("failed to check hot page: %w", err)
+			}
+			if hot {
+				pct := 100 * removed / oldLen
+				signals = append(signals, VandalismSignal{"hot_page_removal", fmt.Sprintf("removed %d%% of a hot page", pct), weightHotPageRemoval})
+			}
+		}
+	}
+
+	summary := stripSectionPrefix(edit.Comment)
+	if word := vd.profane.FindString(summary); word != "" {
+		signals = append(signals, VandalismSignal{"profane_comment", fmt.Sprintf("profanity in edit summary (%q)", strings.ToLower(word)), weightProfaneComment})
+	} else if isShouting(summary, vd.cfg.CapsMinLetters) {
+		signals = append(signals, VandalismSignal{"caps_comment", "edit summary in all caps", weightCapsComment})
+	}
+
+	// Rapid editing only matters for users without a track record
+	newUser := anonymous
+	if !anonymous {
+		var err error
+		if newUser, err = vd.isNewUser(ctx, edit, at); err != nil {
+			return nil, err
+		}
+	}
+	if newUser {
+		n, err := vd.countRecentEdits(ctx, edit, at)
+		if err != nil {
+			return nil, err
+		}
+		if n >= int64(vd.cfg.RapidEdits) {
+			who := "new account"
+			if anonymous {
+				who = "anonymous editor"
+			}
+			reason := fmt.Sprintf("%d edits in %s by a %s", n, vd.cfg.RapidWindow, who)
+			signals = append(signals, VandalismSignal{"rapid_new_user", reason, weightRapidNewUser})
+		}
+	}
+
+	return signals, nil
+}
+
+// isNewUser reports whether the account registered less than NewAccountAge
+// ago. The stream carries no account age, so this relies on the newusers
+// log; an account WikiSurge did not see registering counts as established.
+func (vd *VandalismDetector) isNewUser(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (bool, error) {
+	return vd.accounts.IsNew(ctx, edit.Wiki, edit.User, at, vd.cfg.NewAccountAge)
+}
+
+// countRecentEdits counts the user's edits in the RapidWindow of event time
+// ending at this one, including it. Edits are kept in a sorted set by time,
+// so the window rolls with each edit rather than resetting on a boundary.
+func (vd *VandalismDetector) countRecentEdits(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (int64, error) {
+	key := fmt.Sprintf("vandalism:rate:%s:%s", edit.Wiki, edit.User)
+	revision := edit.Revision.New
+	if revision == 0 {
+		revision = edit.ID
+	}
+	ts := at.Unix()
+	from := strconv.FormatInt(ts-int64(vd.cfg.RapidWindow/time.Second), 10)
+
+	pipe := vd.redis.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(ts), Member: revision})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+from)
+	count := pipe.ZCount(ctx, key, from, strconv.FormatInt(ts, 10))
+	pipe.Expire(ctx, key, 2*vd.cfg.RapidWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count user edits: %w", err)
+	}
+	return count.Val(), nil
+}
+
+// combineSignals returns the confidence that an edit is vandalism given the
+// signals that fired.
+func combineSignals(signals []VandalismSignal) float64 {
+	clean := 1.0
+	for _, s := range signals {
+		clean *= 1 - s.Weight
+	}
+	return 1 - clean
+}
+
+// isShouting reports whether a comment with at least minLetters capital
+// letters has no lower-case ones.
+func isShouting(comment string, minLetters int) bool {
+	upper := 0
+	for _, r := range comment {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	// Scripts without case (CJK, Arabic, ...) never count as shouting
+	return upper >= minLetters
+}
+
+// stripSectionPrefix drops the "/* Section */" MediaWiki prepends to the
+// summary of a section edit; the heading is page text, not the editor's words.
+func stripSectionPrefix(comment string) string {
+	trimmed := strings.TrimSpace(comment)
+	if !strings.HasPrefix(trimmed, "/*") {
+		return comment
+	}
+	if i := strings.Index(trimmed, "*/"); i >= 0 {
+		return strings.TrimSpace(trimmed[i+2:])
+	}
+	return comment
+}
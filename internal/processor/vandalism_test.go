@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vandalismCase is one recorded scenario from testdata/vandalism_edits.json.
+type vandalismCase struct {
+	Name        string                 `json:"name"`
+	HotPages    []string               `json:"hot_pages"`    // {wiki}:{title} keys promoted before the edits
+	NewAccounts []string               `json:"new_accounts"` // accounts registered an hour before the first edit
+	Edits       []models.WikipediaEdit `json:"edits"`
+	Expect      struct {
+		Alerts  int      `json:"alerts"`
+		Reasons []string `json:"reasons"` // prefixes of the last alert's reasons, in order
+	} `json:"expect"`
+}
+
+func setupVandalismDetector(t *testing.T) (*VandalismDetector, *storage.RedisAlerts, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{
+		Redis: config.Redis{HotPages: config.HotPages{
+			MaxTracked: 100, PromotionThreshold: 3, WindowDuration: 15 * time.Minute,
+			MaxMembersPerPage: 50, HotThreshold: 2, CleanupInterval: 5 * time.Minute,
+		}},
+		Processor: config.Processor{
+			// Windows follow the recorded timestamps, not the wall clock
+			EventTime: config.EventTimeConfig{Enabled: true, AllowedLateness: time.Hour},
+			Vandalism: config.VandalismConfig{
+				Enabled:             true,
+				MinConfidence:       0.6,
+				Cooldown:            10 * time.Minute,
+				LargeRemovalBytes:   2000,
+				BlankingMaxBytes:    100,
+				BlankingMinOldBytes: 500,
+				HotPageRemoval:      0.5,
+				RapidEdits:          5,
+				RapidWindow:         2 * time.Minute,
+				NewAccountAge:       24 * time.Hour,
+				CapsMinLetters:      10,
+			},
+		},
+	}
+
+	hotPages := storage.NewHotPageTracker(client, &cfg.Redis.HotPages)
+	t.Cleanup(hotPages.Shutdown)
+	alerts := storage.NewRedisAlerts(client)
+	return NewVandalismDetector(alerts, hotPages, client, cfg, zerolog.Nop()), alerts, mr
+}
+
+func TestVandalismDetector_RecordedEdits(t *testing.T) {
+	raw, err := os.ReadFile("testdata/vandalism_edits.json")
+	require.NoError(t, err)
+	var cases []vandalismCase
+	require.NoError(t, json.Unmarshal(raw, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			vd, alerts, mr := setupVandalismDetector(t)
+			ctx := context.Background()
+
+			first := tc.Edits[0].EventTime()
+			for _, page := range tc.HotPages {
+				mr.ZAdd("hot:window:"+page, float64(first.Unix()), "seed")
+			}
+			for _, user := range tc.NewAccounts {
+				require.NoError(t, vd.accounts.RecordCreated(ctx, tc.Edits[0].Wiki, user, first.Add(-time.Hour)))
+			}
+
+			for i := range tc.Edits {
+				require.NoError(t, vd.ProcessEdit(ctx, &tc.Edits[i]))
+			}
+
+			published, err := alerts.GetRecentAlerts(ctx, "vandalism", 50)
+			require.NoError(t, err)
+			require.Len(t, published, tc.Expect.Alerts)
+			if tc.Expect.Alerts == 0 {
+				return
+			}
+
+			alert := published[0]
+			assert.Equal(t, storage.AlertTypeVandalism, alert.Type)
+			assert.GreaterOrEqual(t, alert.Data["confidence"].(float64), 0.6)
+
+			if tc.Expect.Reasons == nil {
+				return
+			}
+			var reasons []string
+			for _, r := range alert.Data["reasons"].([]interface{}) {
+				reasons = append(reasons, r.(string))
+			}
+			require.Len(t, reasons, len(tc.Expect.Reasons), "reasons: %q", reasons)
+			for i, want := range tc.Expect.Reasons {
+				assert.True(t, strings.HasPrefix(reasons[i], want), "reason %d: got %q, want %q...", i, reasons[i], want)
+			}
+		})
+	}
+}
+
+func TestVandalismDetector_RapidEditsRollingWindow(t *testing.T) {
+	vd, _, _ := setupVandalismDetector(t)
+	ctx := context.Background()
+
+	// Five edits in 45s across a two-minute boundary all count together
+	start := time.Unix(1772442090, 0)
+	var n int64
+	for i := 0; i < 5; i++ {
+		edit := makeEdit(int64(100+i), "Capybara", "192.0.2.8", 8000, 8040)
+		var err error
+		n, err = vd.countRecentEdits(ctx, edit, start.Add(time.Duration(i)*11*time.Second))
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 5, n)
+
+	// Two minutes after the third, only it and the two after are left
+	n, err := vd.countRecentEdits(ctx, makeEdit(105, "Capybara", "192.0.2.8", 8000, 8040), start.Add(22*time.Second+2*time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, n)
+}
+
+func TestCombineSignals(t *testing.T) {
+	assert.Equal(t, 0.0, combineSignals(nil))
+	assert.InDelta(t, 0.25, combineSignals([]VandalismSignal{{Weight: weightAnonymous}}), 1e-9)
+	// Anonymous blanking alerts; either alone does not
+	assert.InDelta(t, 0.625, combineSignals([]VandalismSignal{{Weight: weightAnonymous}, {Weight: weightBlanking}}), 1e-9)
+}
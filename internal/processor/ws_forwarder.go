@@ -5,15 +5,20 @@ import (
 	"encoding/json"
 
 	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/Agnikulu/WikiSurge/internal/storage"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 )
 
-const editsPubSubChannel = "wikisurge:edits:live"
+const (
+	editsPubSubChannel   = "wikisurge:edits:live"
+	botRunsPubSubChannel = "wikisurge:botruns:live"
+)
 
 // EditBroadcaster defines the interface for broadcasting edits (e.g. to WebSocket clients).
 type EditBroadcaster interface {
 	BroadcastEditFiltered(edit *models.WikipediaEdit)
+	BroadcastBotRun(run *storage.BotRun)
 }
 
 // WebSocketForwarder is a Kafka MessageHandler that forwards edits to a WebSocket hub
@@ -22,6 +27,8 @@ type WebSocketForwarder struct {
 	broadcaster EditBroadcaster
 	redis       *redis.Client
 	logger      zerolog.Logger
+	dedup       *storage.EditDeduplicator
+	botRuns     *BotRunTracker
 }
 
 // NewWebSocketForwarder creates a forwarder that sends every consumed edit to the hub
@@ -36,7 +43,37 @@ func NewWebSocketForwarder(broadcaster EditBroadcaster, redisClient *redis.Clien
 
 // ProcessEdit implements kafka.MessageHandler. It broadcasts the edit to all
 // matching WebSocket clients and publishes to Redis pub/sub for the API process.
-func (f *WebSocketForwarder) ProcessEdit(_ context.Context, edit *models.WikipediaEdit) error {
+func (f *WebSocketForwarder) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	return f.dedup.Process(ctx, "websocket-forwarder", edit, func() error {
+		return f.processEdit(ctx, edit)
+	})
+}
+
+// SetDeduplicator stops replayed revisions from being broadcast twice.
+func (f *WebSocketForwarder) SetDeduplicator(d *storage.EditDeduplicator) {
+	f.dedup = d
+}
+
+// SetBotRunTracker collapses bursts of edits by one account into bot_run
+// summaries instead of forwarding each edit.
+func (f *WebSocketForwarder) SetBotRunTracker(t *BotRunTracker) {
+	f.botRuns = t
+	t.SetPublisher(f.publishBotRun)
+}
+
+// processEdit does the work of ProcessEdit for an edit not seen before.
+func (f *WebSocketForwarder) processEdit(ctx context.Context, edit *models.WikipediaEdit) error {
+	if f.botRuns != nil {
+		absorbed, err := f.botRuns.Track(ctx, edit)
+		if err != nil {
+			// Better a noisy feed than a gap in it
+			f.logger.Warn().Err(err).Str("user", edit.User).Msg("Failed to track bot run")
+		}
+		if absorbed {
+			return nil
+		}
+	}
+
 	// Broadcast to local hub (processor-side).
 	f.broadcaster.BroadcastEditFiltered(edit)
 
@@ -50,3 +87,15 @@ func (f *WebSocketForwarder) ProcessEdit(_ context.Context, edit *models.Wikiped
 
 	return nil
 }
+
+// publishBotRun sends a bot run summary to the hub and to Redis pub/sub.
+func (f *WebSocketForwarder) publishBotRun(run *storage.BotRun) {
+	f.broadcaster.BroadcastBotRun(run)
+
+	if f.redis != nil {
+		data, err := json.Marshal(run)
+		if err == nil {
+			f.redis.Publish(context.Background(), botRunsPubSubChannel, data)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+)
+
+func clockTestEdit(at time.Time) *models.WikipediaEdit {
+	return &models.WikipediaEdit{Type: "edit", Title: "Paris", Wiki: "enwiki", Timestamp: at.Unix()}
+}
+
+func newTestEventClock(lateEvents string) (*EventClock, *MockTimeProvider) {
+	wall := NewMockTimeProvider()
+	wall.SetTime(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	c := NewEventClock("test", config.EventTimeConfig{
+		Enabled:           true,
+		MaxOutOfOrderness: 10 * time.Second,
+		AllowedLateness:   time.Minute,
+		LateEvents:        lateEvents,
+	})
+	c.SetWallClock(wall)
+	return c, wall
+}
+
+func TestEventClock_WatermarkFollowsEvents(t *testing.T) {
+	c, wall := newTestEventClock("accept")
+
+	// Before any event the clock reads wall time
+	if got := c.Now(); !got.Equal(wall.Now()) {
+		t.Errorf("Now() = %v before any event, want wall time %v", got, wall.Now())
+	}
+
+	// Replaying edits from yesterday moves the watermark to yesterday
+	base := wall.Now().Add(-24 * time.Hour)
+	for i := 0; i < 5; i++ {
+		at, ok := c.Observe(clockTestEdit(base.Add(time.Duration(i) * time.Minute)))
+		if !ok {
+			t.Fatalf("edit %d dropped", i)
+		}
+		if !at.Equal(base.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("edit %d accounted at %v", i, at)
+		}
+	}
+	want := base.Add(4*time.Minute - 10*time.Second)
+	if got := c.Now(); !got.Equal(want) {
+		t.Errorf("watermark = %v, want %v", got, want)
+	}
+
+	// An out-of-order edit does not move the watermark back
+	c.Observe(clockTestEdit(base.Add(time.Minute)))
+	if got := c.Now(); !got.Equal(want) {
+		t.Errorf("watermark moved back to %v", got)
+	}
+}
+
+func TestEventClock_LateEvents(t *testing.T) {
+	c, wall := newTestEventClock("drop")
+	base := wall.Now().Add(-time.Hour)
+	c.Observe(clockTestEdit(base))
+
+	// Within out-of-orderness plus allowed lateness: kept
+	if _, ok := c.Observe(clockTestEdit(base.Add(-30 * time.Second))); !ok {
+		t.Error("edit within allowed lateness was dropped")
+	}
+	// Beyond it: dropped
+	if _, ok := c.Observe(clockTestEdit(base.Add(-5 * time.Minute))); ok {
+		t.Error("late edit was not dropped")
+	}
+
+	// With the accept policy the same edit is kept
+	c, _ = newTestEventClock("accept")
+	c.Observe(clockTestEdit(base))
+	if _, ok := c.Observe(clockTestEdit(base.Add(-5 * time.Minute))); !ok {
+		t.Error("late edit dropped under the accept policy")
+	}
+}
+
+func TestEventClock_ClampsFutureAndMissingTimestamps(t *testing.T) {
+	c, wall := newTestEventClock("drop")
+
+	at, _ := c.Observe(clockTestEdit(wall.Now().Add(time.Hour)))
+	if !at.Equal(wall.Now()) {
+		t.Errorf("future edit accounted at %v, want wall time %v", at, wall.Now())
+	}
+	// A skewed timestamp must not make current edits late
+	if _, ok := c.Observe(clockTestEdit(wall.Now().Add(-20 * time.Second))); !ok {
+		t.Error("current edit dropped after a future timestamp")
+	}
+
+	// Edits without a timestamp are accounted at the watermark
+	at, ok := c.Observe(&models.WikipediaEdit{Type: "edit", Title: "Paris"})
+	if !ok || !at.Equal(c.Now()) {
+		t.Errorf("untimestamped edit: at = %v ok = %v, want watermark %v", at, ok, c.Now())
+	}
+}
+
+func TestEventClock_Disabled(t *testing.T) {
+	c := NewEventClock("test", config.EventTimeConfig{})
+	wall := NewMockTimeProvider()
+	c.SetWallClock(wall)
+
+	at, ok := c.Observe(clockTestEdit(wall.Now().Add(-24 * time.Hour)))
+	if !ok || !at.Equal(wall.Now()) {
+		t.Errorf("Observe = %v, %v, want wall time", at, ok)
+	}
+	if !c.Now().Equal(wall.Now()) {
+		t.Errorf("Now() = %v, want wall time", c.Now())
+	}
+}
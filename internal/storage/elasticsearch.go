@@ -180,6 +180,9 @@ func (es *ElasticsearchClient) SetupILM() error {
 					"language": map[string]interface{}{
 						"type": "keyword",
 					},
+					"project": map[string]interface{}{
+						"type": "keyword",
+					},
 					"indexed_reason": map[string]interface{}{
 						"type": "keyword",
 					},
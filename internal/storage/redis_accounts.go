@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AccountRegistry remembers when accounts were registered, as seen in the
+// newusers log, for as long as they may still count as new. The edit stream
+// carries no account age, so an account the registry does not know is
+// treated as established: it was registered before WikiSurge was watching,
+// or long enough ago to have been forgotten.
+type AccountRegistry struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewAccountRegistry creates a registry that remembers each account for ttl
+// after its registration.
+func NewAccountRegistry(client *redis.Client, ttl time.Duration) *AccountRegistry {
+	return &AccountRegistry{client: client, ttl: ttl}
+}
+
+func accountCreatedKey(wiki, user string) string {
+	return fmt.Sprintf("accounts:created:%s:%s", wiki, user)
+}
+
+// RecordCreated notes that user registered on wiki at at.
+func (r *AccountRegistry) RecordCreated(ctx context.Context, wiki, user string, at time.Time) error {
+	if err := r.client.Set(ctx, accountCreatedKey(wiki, user), at.Unix(), r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to record account creation: %w", err)
+	}
+	return nil
+}
+
+// CreatedAt returns the registration time of each of users the registry
+// knows; the others are left out.
+func (r *AccountRegistry) CreatedAt(ctx context.Context, wiki string, users ...string) (map[string]time.Time, error) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(users))
+	for i, u := range users {
+		keys[i] = accountCreatedKey(wiki, u)
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account creations: %w", err)
+	}
+
+	created := make(map[string]time.Time, len(users))
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+			created[users[i]] = time.Unix(ts, 0)
+		}
+	}
+	return created, nil
+}
+
+// IsNew reports whether user is known to have registered on wiki less than
+// age before at.
+func (r *AccountRegistry) IsNew(ctx context.Context, wiki, user string, at time.Time, age time.Duration) (bool, error) {
+	created, err := r.CreatedAt(ctx, wiki, user)
+	if err != nil {
+		return false, err
+	}
+	first, ok := created[user]
+	return ok && at.Sub(first) < age, nil
+}
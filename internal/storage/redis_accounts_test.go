@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestAccountRegistry(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rc.Close() })
+
+	reg := NewAccountRegistry(rc, 7*24*time.Hour)
+	ctx := context.Background()
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	if err := reg.RecordCreated(ctx, "enwiki", "Capyfan2026", created); err != nil {
+		t.Fatalf("RecordCreated: %v", err)
+	}
+
+	for _, tt := range []struct {
+		wiki, user string
+		at         time.Time
+		want       bool
+	}{
+		{"enwiki", "Capyfan2026", created.Add(time.Hour), true},
+		{"enwiki", "Capyfan2026", created.Add(48 * time.Hour), false},
+		{"dewiki", "Capyfan2026", created.Add(time.Hour), false},
+		{"enwiki", "Kaldari", created.Add(time.Hour), false}, // never seen registering
+	} {
+		got, err := reg.IsNew(ctx, tt.wiki, tt.user, tt.at, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("IsNew: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsNew(%s, %s, +%s) = %v, want %v", tt.wiki, tt.user, tt.at.Sub(created), got, tt.want)
+		}
+	}
+
+	if ttl := mr.TTL(accountCreatedKey("enwiki", "Capyfan2026")); ttl != 7*24*time.Hour {
+		t.Errorf("TTL = %s, want 168h", ttl)
+	}
+}
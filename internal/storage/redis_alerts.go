@@ -35,12 +35,129 @@ type Alert struct {
 
 // AlertType constants for different alert types
 const (
-	AlertTypeSpike     = "spike"
-	AlertTypeEditWar   = "edit_war"
-	AlertTypeTrending  = "trending"
-	AlertTypeVandalism = "vandalism"
+	AlertTypeSpike      = "spike"
+	AlertTypeEditWar    = "edit_war"
+	AlertTypeTrending   = "trending"
+	AlertTypeVandalism  = "vandalism"
+	AlertTypeWikiSurge  = "wiki_surge"
+	AlertTypeStreamDrop = "stream_drop"
+
+	AlertTypeThreeRevertRule    = "3rr_violation"
+	AlertTypeCoordinatedEditing = "coordinated_editing"
+	AlertTypeRuleMatch          = "rule_match"
 )
 
+// userAlertsPrefix is the stream name prefix of users' personal alerts, kept
+// as alerts:user:{id}.
+const userAlertsPrefix = "user:"
+
+// userAlertsTTL is how long a user's personal alert stream is kept after its
+// latest alert, so the streams of users whose rules stop matching, or who
+// are gone, do not stay in Redis for good.
+const userAlertsTTL = 7 * 24 * time.Hour
+
+// RuleMatch is an edit that met one of a user's alert rules.
+type RuleMatch struct {
+	UserID     string
+	RuleID     string
+	RuleName   string
+	Expression string
+	Wiki       string
+	Title      string
+	ServerURL  string
+	User       string
+	RevisionID int64
+	ByteChange int
+	Counts     map[string]int // page activity the rule's windows saw, e.g. "edits_10m"
+	At         time.Time      // time of the matching edit
+}
+
+// CoordinatedGroup is a set of accounts whose editing of hot pages looks
+// coordinated, with the evidence linking each pair of them.
+type CoordinatedGroup struct {
+	Wiki        string            `json:"wiki"`
+	Accounts    []string          `json:"accounts"`
+	NewAccounts []string          `json:"new_accounts,omitempty"` // accounts first seen recently, which weighed more
+	SharedPages []string          `json:"shared_pages"`           // pages edited by at least two of the accounts
+	Pairs       []CoordinatedPair `json:"pairs"`
+	Score       float64           `json:"score"` // highest pair score, 0-1
+	Severity    string            `json:"severity"`
+	At          time.Time         `json:"at"`
+}
+
+// CoordinatedPair is the evidence that two accounts edit together.
+type CoordinatedPair struct {
+	Users             [2]string `json:"users"`
+	SharedPages       []string  `json:"shared_pages"`
+	Overlap           float64   `json:"overlap"`                  // Jaccard similarity of the hot pages they edited
+	TimingCorrelation float64   `json:"timing_correlation"`       // share of their edits to shared pages made close to the other's
+	TagTeamPages      []string  `json:"tag_team_pages,omitempty"` // pages where both reverted the same editors
+	Score             float64   `json:"score"`
+	LastSeen          time.Time `json:"last_seen"`
+}
+
+// ThreeRevertViolation is one editor reverting a page more often than the
+// three-revert rule allows within its window.
+type ThreeRevertViolation struct {
+	Wiki        string            `json:"wiki"`
+	Title       string            `json:"title"`
+	ServerURL   string            `json:"server_url,omitempty"`
+	User        string            `json:"user"`
+	RevertCount int               `json:"revert_count"`
+	Limit       int               `json:"limit"`
+	WindowHours float64           `json:"window_hours"`
+	Reverts     []ViolationRevert `json:"reverts"` // oldest first, the violating revert last
+	Severity    string            `json:"severity"`
+	At          time.Time         `json:"at"` // time of the violating revert
+}
+
+// ViolationRevert is one of the reverts counted towards a 3RR violation.
+type ViolationRevert struct {
+	RevisionID        int64     `json:"revision_id"`
+	Timestamp         time.Time `json:"timestamp"`
+	RevertedUsers     []string  `json:"reverted_users,omitempty"`
+	RevertedRevisions []int64   `json:"reverted_revisions,omitempty"`
+}
+
+// AggregateAnomaly describes an unusual edit rate for a whole wiki, a
+// namespace, page creations or the global stream, rather than one page.
+type AggregateAnomaly struct {
+	Scope         string  // "global", "wiki", "namespace" or "new_pages"
+	Wiki          string  // set for wiki scope and per-wiki page creations
+	Namespace     *int    // set for namespace scope
+	Rate          float64 // edits per minute over the window
+	ExpectedRate  float64 // baseline edits per minute
+	ZScore        float64
+	WindowMinutes int
+	Severity      string
+	Reason        string    // stream drops: "rate_drop" or "stalled"
+	At            time.Time // end of the window, in event time
+}
+
+func (a AggregateAnomaly) data() map[string]interface{} {
+	data := map[string]interface{}{
+		"scope":          a.Scope,
+		"rate":           a.Rate,
+		"expected_rate":  a.ExpectedRate,
+		"z_score":        a.ZScore,
+		"window_minutes": a.WindowMinutes,
+		"severity":       a.Severity,
+	}
+	if a.ExpectedRate > 0 {
+		data["ratio"] = a.Rate / a.ExpectedRate
+	}
+	if a.Wiki != "" {
+		data["wiki"] = a.Wiki
+	}
+	if a.Namespace != nil {
+		data["namespace"] = *a.Namespace
+	}
+	if a.Reason != "" {
+		data["reason"] = a.Reason
+	}
+	return data
+}
+
 // PublishSpikeAlert publishes an alert when a page experiences a spike in activity
 func (r *RedisAlerts) PublishSpikeAlert(ctx context.Context, wiki, title, serverURL string, spikeRatio float64, editCount int) error {
 	alert := Alert{
@@ -117,6 +234,151 @@ func (r *RedisAlerts) PublishVandalismAlert(ctx context.Context, edit *models.Wi
 	return r.publishAlert(ctx, "alerts:vandalism", alert)
 }
 
+// PublishWikiSurgeAlert publishes an alert when a wiki, namespace, page
+// creations or the whole stream is being edited far above its usual rate.
+func (r *RedisAlerts) PublishWikiSurgeAlert(ctx context.Context, a AggregateAnomaly) error {
+	alert := Alert{
+		ID:        fmt.Sprintf("wikisurge-%d", time.Now().UnixNano()),
+		Type:      AlertTypeWikiSurge,
+		Timestamp: a.At,
+		Data:      a.data(),
+	}
+
+	return r.publishAlert(ctx, "alerts:wikisurges", alert)
+}
+
+// PublishStreamDropAlert publishes an alert when the global edit stream falls
+// far below its usual rate or stops advancing, which usually means the
+// ingestor or Wikimedia EventStreams is broken.
+func (r *RedisAlerts) PublishStreamDropAlert(ctx context.Context, a AggregateAnomaly) error {
+	alert := Alert{
+		ID:        fmt.Sprintf("streamdrop-%d", time.Now().UnixNano()),
+		Type:      AlertTypeStreamDrop,
+		Timestamp: a.At,
+		Data:      a.data(),
+	}
+
+	return r.publishAlert(ctx, "alerts:streamdrops", alert)
+}
+
+// PublishThreeRevertViolation publishes an alert when an editor breaks the
+// three-revert rule on a page.
+func (r *RedisAlerts) PublishThreeRevertViolation(ctx context.Context, v ThreeRevertViolation) error {
+	alert := Alert{
+		ID:        fmt.Sprintf("3rr-%d", time.Now().UnixNano()),
+		Type:      AlertTypeThreeRevertRule,
+		Timestamp: v.At,
+		Data: map[string]interface{}{
+			"wiki":         v.Wiki,
+			"title":        v.Title,
+			"server_url":   v.ServerURL,
+			"user":         v.User,
+			"revert_count": v.RevertCount,
+			"limit":        v.Limit,
+			"window_hours": v.WindowHours,
+			"reverts":      v.Reverts,
+			"severity":     v.Severity,
+			"at":           v.At,
+		},
+	}
+
+	return r.publishAlert(ctx, "alerts:3rr", alert)
+}
+
+// PublishCoordinationAlert publishes an alert for a group of accounts that
+// appear to be editing in concert.
+func (r *RedisAlerts) PublishCoordinationAlert(ctx context.Context, g CoordinatedGroup) error {
+	alert := Alert{
+		ID:        fmt.Sprintf("coordination-%d", time.Now().UnixNano()),
+		Type:      AlertTypeCoordinatedEditing,
+		Timestamp: g.At,
+		Data: map[string]interface{}{
+			"wiki":         g.Wiki,
+			"accounts":     g.Accounts,
+			"new_accounts": g.NewAccounts,
+			"shared_pages": g.SharedPages,
+			"pairs":        g.Pairs,
+			"score":        g.Score,
+			"severity":     g.Severity,
+		},
+	}
+
+	return r.publishAlert(ctx, "alerts:coordination", alert)
+}
+
+// PublishRuleAlert publishes a personal alert to the stream of the user
+// whose rule matched.
+func (r *RedisAlerts) PublishRuleAlert(ctx context.Context, m RuleMatch) error {
+	data := map[string]interface{}{
+		"rule_id":     m.RuleID,
+		"rule_name":   m.RuleName,
+		"expression":  m.Expression,
+		"wiki":        m.Wiki,
+		"title":       m.Title,
+		"server_url":  m.ServerURL,
+		"user":        m.User,
+		"revision_id": m.RevisionID,
+		"byte_change": m.ByteChange,
+	}
+	if len(m.Counts) > 0 {
+		data["counts"] = m.Counts
+	}
+	alert := Alert{
+		ID:        fmt.Sprintf("rule-%d", time.Now().UnixNano()),
+		Type:      AlertTypeRuleMatch,
+		Timestamp: m.At,
+		Data:      data,
+	}
+
+	stream := "alerts:" + userAlertsPrefix + m.UserID
+	if err := r.publishAlert(ctx, stream, alert); err != nil {
+		return err
+	}
+	if err := r.client.Expire(ctx, stream, userAlertsTTL).Err(); err != nil {
+		return fmt.Errorf("failed to set user alerts expiry: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserAlerts removes a user's personal alert stream, for when the
+// user is deleted.
+func (r *RedisAlerts) DeleteUserAlerts(ctx context.Context, userID string) error {
+	if err := r.client.Del(ctx, "alerts:"+userAlertsPrefix+userID).Err(); err != nil {
+		return fmt.Errorf("failed to delete user alerts: %w", err)
+	}
+	return nil
+}
+
+// GetUserAlerts returns up to count of a user's personal alerts published
+// since the given time, newest first.
+func (r *RedisAlerts) GetUserAlerts(ctx context.Context, userID string, since time.Time, count int64) ([]Alert, error) {
+	return r.GetAlertsSince(ctx, userAlertsPrefix+userID, since, "", count)
+}
+
+// GetThreeRevertViolations returns up to count 3RR violations published
+// since the given time, newest first.
+func (r *RedisAlerts) GetThreeRevertViolations(ctx context.Context, since time.Time, count int64) ([]ThreeRevertViolation, error) {
+	alerts, err := r.GetAlertsSince(ctx, "3rr", since, "", count)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]ThreeRevertViolation, 0, len(alerts))
+	for _, a := range alerts {
+		raw, err := json.Marshal(a.Data)
+		if err != nil {
+			continue
+		}
+		var v ThreeRevertViolation
+		if err := json.Unmarshal(raw, &v); err != nil {
+			log.Printf("Failed to parse 3RR violation %s: %v", a.ID, err)
+			continue
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
 // SubscribeToAlerts subscribes to alert streams and calls the provided handler for each alert
 func (r *RedisAlerts) SubscribeToAlerts(ctx context.Context, alertTypes []string, handler func(Alert) error) error {
 	// go-redis XRead expects Streams as [key1, key2, ..., id1, id2, ...]
@@ -430,7 +692,7 @@ func (r *RedisAlerts) GetEditWarAlertsSince(ctx context.Context, since time.Time
 
 // GetActiveEditWars scans Redis for marker keys that flag active edit wars,
 // then enriches each entry with editor / change data when still available.
-// Marker keys ("editwar:<page>") have a 30-min TTL refreshed on every
+// Marker keys ("editwar:<wiki>:<page>") have a 30-min TTL refreshed on every
 // incoming edit, so they expire once editing activity stops.
 // Data keys (editors, timeline, changes) have a longer 12h TTL for
 // LLM analysis and may outlive the marker.
@@ -450,18 +712,19 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 				break
 			}
 
-			// Only consider plain marker keys ("editwar:<page>").
-			// Skip sub-keys like editwar:editors:*, editwar:changes:*, editwar:<wiki>:*.
+			// Only consider marker keys ("editwar:<wiki>:<page>", or the
+			// title-only "editwar:<page>" of unmigrated data). Skip sub-keys
+			// like editwar:editors:*, editwar:changes:*.
 			raw := key[len("editwar:"):]
-			if strings.Contains(raw, ":") {
-				continue // sub-key (editors:, changes:, enwiki:, etc.)
+			pageKey := models.ParsePageKey(raw)
+			if pageKey.IsLegacy() && strings.Contains(raw, ":") {
+				continue // sub-key (editors:, changes:, etc.)
 			}
 
-			pageTitle := raw
-			if seen[pageTitle] {
+			if seen[raw] {
 				continue
 			}
-			seen[pageTitle] = true
+			seen[raw] = true
 
 			// Verify the key is actually a string marker (value "1")
 			keyType, err := r.client.Type(ctx, key).Result()
@@ -472,7 +735,7 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 			// Derive start time from the first timeline entry's actual edit
 			// timestamp — this is the real time the first edit occurred.
 			startTime := time.Time{}
-			timelineKey := fmt.Sprintf("editwar:timeline:%s", pageTitle)
+			timelineKey := fmt.Sprintf("editwar:timeline:%s", pageKey)
 			if firstRaw, tlErr := r.client.LIndex(ctx, timelineKey, 0).Result(); tlErr == nil && firstRaw != "" {
 				var firstEntry struct {
 					Timestamp int64 `json:"timestamp"`
@@ -483,7 +746,7 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 			}
 			// Fallback: check persisted start key, then TTL approximation.
 			if startTime.IsZero() {
-				startKey := fmt.Sprintf("editwar:start:%s", pageTitle)
+				startKey := fmt.Sprintf("editwar:start:%s", pageKey)
 				if s, err := r.client.Get(ctx, startKey).Result(); err == nil && s != "" {
 					if t, err := time.Parse(time.RFC3339, s); err == nil {
 						startTime = t
@@ -505,7 +768,7 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 			}
 
 			// Try to enrich with editor data (may have expired).
-			editorsKey := fmt.Sprintf("editwar:editors:%s", pageTitle)
+			editorsKey := fmt.Sprintf("editwar:editors:%s", pageKey)
 			editorMap, _ := r.client.HGetAll(ctx, editorsKey).Result()
 
 			editors := make([]string, 0, len(editorMap))
@@ -517,12 +780,11 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 				totalEdits += count
 			}
 
-			// Count reverts from the changes list (may have expired).
-			changesKey := fmt.Sprintf("editwar:changes:%s", pageTitle)
+			// Count the reverts the processor recognised (may have expired).
+			revertsKey := fmt.Sprintf("editwar:reverts:%s", pageKey)
 			revertCount := 0
-			changes, err := r.client.LRange(ctx, changesKey, 0, -1).Result()
-			if err == nil {
-				revertCount = countRevertPatterns(changes)
+			if n, err := r.client.LLen(ctx, revertsKey).Result(); err == nil {
+				revertCount = int(n)
 			}
 
 			// If the editor hash expired but timeline data still exists,
@@ -549,26 +811,27 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 				}
 				totalEdits = len(tlEntries)
 
-				// Rebuild the changes list for revert counting
-				tlChanges := make([]string, 0, len(tlEntries))
+				// Timeline entries of reverting edits carry the revert
+				revertCount = 0
 				for _, raw := range tlEntries {
 					var entry struct {
-						ByteChange int `json:"byte_change"`
+						Revert json.RawMessage `json:"revert"`
 					}
-					if json.Unmarshal([]byte(raw), &entry) == nil {
-						tlChanges = append(tlChanges, strconv.Itoa(entry.ByteChange))
+					if json.Unmarshal([]byte(raw), &entry) == nil && len(entry.Revert) > 0 && string(entry.Revert) != "null" {
+						revertCount++
 					}
 				}
-				revertCount = countRevertPatterns(tlChanges)
 			}
 
 			severity := classifyEditWarSeverity(len(editors), totalEdits, revertCount)
 
 			// Retrieve persisted server URL for frontend wiki link building
 			serverURL := ""
-			urlKey := fmt.Sprintf("editwar:serverurl:%s", pageTitle)
+			urlKey := fmt.Sprintf("editwar:serverurl:%s", pageKey)
 			if u, uErr := r.client.Get(ctx, urlKey).Result(); uErr == nil && u != "" {
 				serverURL = u
+			} else {
+				serverURL = models.ServerURLForWiki(pageKey.Wiki)
 			}
 
 			// Derive last-edit time from the last timeline entry, falling back
@@ -582,7 +845,7 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 					lastEdit = time.Unix(lastEntry.Timestamp, 0)
 				}
 			} else {
-				metaKey := fmt.Sprintf("hot:meta:%s", pageTitle)
+				metaKey := fmt.Sprintf("hot:meta:%s", pageKey)
 				if leStr, leErr := r.client.HGet(ctx, metaKey, "last_edit").Result(); leErr == nil && leStr != "" {
 					if ts, pErr := strconv.ParseInt(leStr, 10, 64); pErr == nil && ts > 0 {
 						lastEdit = time.Unix(ts, 0)
@@ -591,7 +854,8 @@ func (r *RedisAlerts) GetActiveEditWars(ctx context.Context, limit int) ([]map[s
 			}
 
 			war := map[string]interface{}{
-				"page_title":   pageTitle,
+				"page_title":   pageKey.Title,
+				"wiki":         pageKey.Wiki,
 				"editor_count": max(len(editors), 2), // at least 2 editors triggered the war
 				"edit_count":   totalEdits,
 				"revert_count": revertCount,
@@ -647,6 +911,18 @@ func DeriveSeverity(alert Alert) string {
 		default:
 			return "medium"
 		}
+	case AlertTypeThreeRevertRule:
+		return "high"
+	case AlertTypeCoordinatedEditing:
+		score, _ := alert.Data["score"].(float64)
+		switch {
+		case score >= 0.9:
+			return "critical"
+		case score >= 0.7:
+			return "high"
+		default:
+			return "medium"
+		}
 	case AlertTypeVandalism:
 		confidence, _ := alert.Data["confidence"].(float64)
 		switch {
@@ -664,51 +940,6 @@ func DeriveSeverity(alert Alert) string {
 	}
 }
 
-// countRevertPatterns counts sign-reversal patterns in a list of byte change strings.
-// Uses magnitude-tolerance matching (30%) consistent with the processor's countReverts.
-func countRevertPatterns(changes []string) int {
-	if len(changes) < 2 {
-		return 0
-	}
-	reverts := 0
-	for i := 1; i < len(changes); i++ {
-		var prev, curr int
-		fmt.Sscanf(changes[i-1], "%d", &prev)
-		fmt.Sscanf(changes[i], "%d", &curr)
-		// Two consecutive zero-byte edits often indicate reverts
-		if prev == 0 && curr == 0 {
-			reverts++
-			continue
-		}
-		// Must be opposite signs
-		if !((prev > 0 && curr < 0) || (prev < 0 && curr > 0)) {
-			continue
-		}
-		// Small edits (< 10 bytes) are always considered reverts
-		absPrev := prev
-		if absPrev < 0 {
-			absPrev = -absPrev
-		}
-		absCurr := curr
-		if absCurr < 0 {
-			absCurr = -absCurr
-		}
-		if absPrev < 10 || absCurr < 10 {
-			reverts++
-			continue
-		}
-		// Magnitude-tolerance check: ratio >= 0.6 means within 30% of each other
-		smaller, larger := float64(absPrev), float64(absCurr)
-		if smaller > larger {
-			smaller, larger = larger, smaller
-		}
-		if larger > 0 && smaller/larger >= 0.6 {
-			reverts++
-		}
-	}
-	return reverts
-}
-
 // classifyEditWarSeverity returns a severity level based on edit war metrics.
 // Matches the processor's calculateEditWarSeverity thresholds.
 func classifyEditWarSeverity(editorCount, editCount, revertCount int) string {
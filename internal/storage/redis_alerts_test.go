@@ -119,6 +119,82 @@ func TestPublishVandalismAlert(t *testing.T) {
 	assert.Equal(t, float64(-900), alert.Data["byte_change"])
 }
 
+func TestThreeRevertViolation_RoundTrip(t *testing.T) {
+	ra, _, _ := setupTestAlerts(t)
+	ctx := context.Background()
+
+	at := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	v := ThreeRevertViolation{
+		Wiki: "enwiki", Title: "Contested", User: "Bob",
+		RevertCount: 4, Limit: 3, WindowHours: 24, Severity: "high", At: at,
+	}
+	for i := 0; i < 4; i++ {
+		v.Reverts = append(v.Reverts, ViolationRevert{
+			RevisionID:        int64(100 + 2*i),
+			Timestamp:         at.Add(time.Duration(i-3) * time.Hour),
+			RevertedUsers:     []string{"Alice"},
+			RevertedRevisions: []int64{int64(99 + 2*i)},
+		})
+	}
+	require.NoError(t, ra.PublishThreeRevertViolation(ctx, v))
+
+	got, err := ra.GetThreeRevertViolations(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, v, got[0])
+
+	recent, err := ra.GetRecentAlerts(ctx, "3rr", 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, AlertTypeThreeRevertRule, recent[0].Type)
+	assert.Equal(t, "high", DeriveSeverity(recent[0]))
+}
+
+func TestPublishCoordinationAlert(t *testing.T) {
+	ra, _, _ := setupTestAlerts(t)
+	ctx := context.Background()
+
+	g := CoordinatedGroup{
+		Wiki:        "enwiki",
+		Accounts:    []string{"Sock1", "Sock2"},
+		NewAccounts: []string{"Sock2"},
+		SharedPages: []string{"Contested A", "Contested B", "Contested C"},
+		Pairs: []CoordinatedPair{{
+			Users: [2]string{"Sock1", "Sock2"}, SharedPages: []string{"Contested A", "Contested B", "Contested C"}, Overlap: 1,
+			TimingCorrelation: 1, Score: 0.95,
+		}},
+		Score: 0.95,
+		At:    time.Now().UTC(),
+	}
+	require.NoError(t, ra.PublishCoordinationAlert(ctx, g))
+
+	recent, err := ra.GetRecentAlerts(ctx, "coordination", 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, AlertTypeCoordinatedEditing, recent[0].Type)
+	assert.Equal(t, []interface{}{"Sock1", "Sock2"}, recent[0].Data["accounts"])
+	assert.Len(t, recent[0].Data["pairs"], 1)
+	// No severity in the data, so it follows from the score
+	assert.Equal(t, "critical", DeriveSeverity(recent[0]))
+}
+
+func TestPublishRuleAlert_ExpiresAndDeletes(t *testing.T) {
+	ra, mr, _ := setupTestAlerts(t)
+	ctx := context.Background()
+
+	m := RuleMatch{UserID: "u1", RuleID: "r1", RuleName: "Blanking", Wiki: "enwiki", Title: "Paris", At: time.Now().UTC()}
+	require.NoError(t, ra.PublishRuleAlert(ctx, m))
+
+	got, err := ra.GetUserAlerts(ctx, "u1", time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Blanking", got[0].Data["rule_name"])
+	assert.Equal(t, userAlertsTTL, mr.TTL("alerts:user:u1"))
+
+	require.NoError(t, ra.DeleteUserAlerts(ctx, "u1"))
+	assert.False(t, mr.Exists("alerts:user:u1"))
+}
+
 // ---------------------------------------------------------------------------
 // GetRecentAlerts
 // ---------------------------------------------------------------------------
@@ -300,8 +376,10 @@ func TestGetActiveEditWars_WithMarkerAndEditors(t *testing.T) {
 	rc.HSet(ctx, "editwar:editors:TestPage", "Alice", "5")
 	rc.HSet(ctx, "editwar:editors:TestPage", "Bob", "3")
 
-	// Set up changes for revert counting
-	rc.RPush(ctx, "editwar:changes:TestPage", "100", "-95", "200", "-190")
+	// Set up the recognised reverts
+	rc.RPush(ctx, "editwar:reverts:TestPage",
+		`{"revision_id":2,"reverter":"Bob","reverted_users":["Alice"],"method":"comment"}`,
+		`{"revision_id":4,"reverter":"Alice","reverted_users":["Bob"],"method":"hash"}`)
 
 	wars, err := ra.GetActiveEditWars(ctx, 10)
 	require.NoError(t, err)
@@ -311,6 +389,7 @@ func TestGetActiveEditWars_WithMarkerAndEditors(t *testing.T) {
 	assert.Equal(t, "TestPage", war["page_title"])
 	assert.Equal(t, true, war["active"])
 	assert.Equal(t, 8, war["edit_count"])  // 5 + 3
+	assert.Equal(t, 2, war["revert_count"])
 	assert.Contains(t, war["editors"], "Alice")
 	assert.Contains(t, war["editors"], "Bob")
 }
@@ -491,32 +570,6 @@ func TestDeriveSeverity_UnknownType(t *testing.T) {
 	assert.Equal(t, "low", DeriveSeverity(alert))
 }
 
-// ---------------------------------------------------------------------------
-// countRevertPatterns
-// ---------------------------------------------------------------------------
-
-func TestCountRevertPatterns(t *testing.T) {
-	tests := []struct {
-		name    string
-		changes []string
-		want    int
-	}{
-		{"empty", nil, 0},
-		{"single", []string{"100"}, 0},
-		{"no reverts", []string{"100", "200", "50"}, 0},
-		{"opposite signs similar magnitude", []string{"100", "-95"}, 1},
-		{"both zero", []string{"0", "0"}, 1},
-		{"small edits opposite", []string{"5", "-3"}, 1},
-		{"dissimilar magnitudes", []string{"1000", "-10"}, 0},
-		{"multiple reverts", []string{"100", "-100", "200", "-200"}, 2},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.want, countRevertPatterns(tt.changes))
-		})
-	}
-}
-
 // ---------------------------------------------------------------------------
 // classifyEditWarSeverity
 // ---------------------------------------------------------------------------
@@ -0,0 +1,444 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	botRunsActiveKey  = "botruns:active"
+	botRunsRecentKey  = "botruns:recent"
+	suspectedBotsKey  = "bots:suspected"
+	maxRecentBotRuns  = 500
+	botRunSamplePages = 10
+	botRunEditTimes   = 200 // inter-edit times kept per run for cadence scoring
+
+	// botRunTTL bounds the state of a run that is never finished, e.g.
+	// because the processor stopped mid-run.
+	botRunTTL = 24 * time.Hour
+)
+
+// BotRunEdit is one edit folded into its account's run.
+type BotRunEdit struct {
+	Wiki    string
+	User    string
+	Title   string
+	Bot     bool
+	Summary string // normalized edit summary
+	At      time.Time
+}
+
+// BotRun summarizes a burst of rapid edits by one account on one wiki. It
+// stands in for the individual edits in the live feed.
+type BotRun struct {
+	ID              string    `json:"id"` // stable for the life of the run, so clients can update it in place
+	Wiki            string    `json:"wiki"`
+	User            string    `json:"user"`
+	Bot             bool      `json:"bot"` // the account has the bot flag
+	Active          bool      `json:"active"`
+	Edits           int64     `json:"edits"`
+	Pages           int64     `json:"pages"` // distinct pages, estimated
+	SamplePages     []string  `json:"sample_pages"`
+	TopSummary      string    `json:"top_summary,omitempty"` // most common edit summary, with numbers replaced by #
+	TopSummaryShare float64   `json:"top_summary_share"`
+	EditsPerMinute  float64   `json:"edits_per_minute"`
+	SuspectScore    float64   `json:"suspect_score,omitempty"` // set when an account without the bot flag edits like one
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+}
+
+// SuspectedBot is an account without the bot flag whose editing cadence
+// looks automated, with the evidence from its latest run.
+type SuspectedBot struct {
+	Wiki           string    `json:"wiki"`
+	User           string    `json:"user"`
+	Score          float64   `json:"score"`
+	Reasons        []string  `json:"reasons"`
+	Edits          int64     `json:"edits"` // in the run that was scored
+	EditsPerMinute float64   `json:"edits_per_minute"`
+	IntervalCV     float64   `json:"interval_cv"` // coefficient of variation of the time between edits
+	SummaryShare   float64   `json:"summary_share"`
+	FirstFlagged   time.Time `json:"first_flagged"`
+	LastFlagged    time.Time `json:"last_flagged"`
+}
+
+// BotRunStats counts one day's bot runs.
+type BotRunStats struct {
+	Runs   int64 `json:"runs"`   // runs started
+	Edits  int64 `json:"edits"`  // edits collapsed into runs
+	Active int64 `json:"active"` // runs ongoing now
+}
+
+// BotRunStore keeps the bot runs in progress, the most recently finished
+// ones and the accounts suspected of being unflagged bots.
+//
+// A run in progress is a hash of counters keyed by wiki and account, with a
+// HyperLogLog of its pages, its most recent pages, a count per edit summary
+// and its latest edit times alongside. botruns:active scores each run by its
+// last edit so idle runs can be found and finished; finished runs are kept
+// as JSON in the botruns:recent list.
+type BotRunStore struct {
+	client *redis.Client
+	cfg    config.BotRunConfig
+}
+
+// NewBotRunStore creates a new bot run store
+func NewBotRunStore(client *redis.Client, cfg config.BotRunConfig) *BotRunStore {
+	return &BotRunStore{client: client, cfg: cfg}
+}
+
+func botRunKey(kind, wiki, user string) string {
+	if kind == "" {
+		return fmt.Sprintf("botrun:%s:%s", wiki, user)
+	}
+	return fmt.Sprintf("botrun:%s:%s:%s", kind, wiki, user)
+}
+
+func botRunStatsKey(day time.Time) string {
+	return "stats:botruns:" + day.UTC().Format("2006-01-02")
+}
+
+func suspectedBotKey(wiki, user string) string {
+	return fmt.Sprintf("bots:suspect:%s:%s", wiki, user)
+}
+
+// CountEdit counts an edit towards its account's rate window and returns the
+// window's count, and whether the account already has a run in progress.
+func (s *BotRunStore) CountEdit(ctx context.Context, wiki, user string, at time.Time) (int64, bool, error) {
+	window := int64(s.cfg.RateWindow / time.Second)
+	if window < 1 {
+		window = 1
+	}
+	key := fmt.Sprintf("%s:%d", botRunKey("rate", wiki, user), at.Unix()/window)
+
+	pipe := s.client.Pipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*s.cfg.RateWindow)
+	active := pipe.Exists(ctx, botRunKey("", wiki, user))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to count edit: %w", err)
+	}
+	return count.Val(), active.Val() > 0, nil
+}
+
+// AddEdit folds an edit into its account's run, starting the run if there is
+// none. It reports whether the run was started, and when the run was last
+// summarized.
+func (s *BotRunStore) AddEdit(ctx context.Context, e BotRunEdit) (bool, time.Time, error) {
+	key := botRunKey("", e.Wiki, e.User)
+	ms := e.At.UnixMilli()
+	statsKey := botRunStatsKey(e.At)
+
+	pipe := s.client.Pipeline()
+	summarized := pipe.HGet(ctx, key, "summarized")
+	started := pipe.HSetNX(ctx, key, "start", ms)
+	pipe.HIncrBy(ctx, key, "edits", 1)
+	pipe.HSet(ctx, key, "end", ms)
+	if e.Bot {
+		pipe.HSet(ctx, key, "bot", 1)
+	}
+	pipe.Expire(ctx, key, botRunTTL)
+
+	pagesKey := botRunKey("pages", e.Wiki, e.User)
+	pipe.PFAdd(ctx, pagesKey, e.Title)
+	pipe.Expire(ctx, pagesKey, botRunTTL)
+
+	sampleKey := botRunKey("sample", e.Wiki, e.User)
+	pipe.ZAdd(ctx, sampleKey, redis.Z{Score: float64(ms), Member: e.Title})
+	pipe.ZRemRangeByRank(ctx, sampleKey, 0, -botRunSamplePages-1)
+	pipe.Expire(ctx, sampleKey, botRunTTL)
+
+	if e.Summary != "" {
+		summariesKey := botRunKey("summaries", e.Wiki, e.User)
+		pipe.HIncrBy(ctx, summariesKey, e.Summary, 1)
+		pipe.Expire(ctx, summariesKey, botRunTTL)
+	}
+
+	timesKey := botRunKey("times", e.Wiki, e.User)
+	pipe.RPush(ctx, timesKey, ms)
+	pipe.LTrim(ctx, timesKey, -botRunEditTimes, -1)
+	pipe.Expire(ctx, timesKey, botRunTTL)
+
+	// GT: an edit arriving out of order must not make the run look idle
+	pipe.ZAddArgs(ctx, botRunsActiveKey, redis.ZAddArgs{
+		GT:      true,
+		Members: []redis.Z{{Score: float64(e.At.Unix()), Member: e.Wiki + "|" + e.User}},
+	})
+	pipe.HIncrBy(ctx, statsKey, "edits", 1)
+	pipe.Expire(ctx, statsKey, 192*time.Hour) // 8 days, like the other daily stats
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return false, time.Time{}, fmt.Errorf("failed to add edit to bot run: %w", err)
+	}
+
+	if started.Val() {
+		if err := s.client.HIncrBy(ctx, statsKey, "runs", 1).Err(); err != nil {
+			return false, time.Time{}, fmt.Errorf("failed to count bot run: %w", err)
+		}
+	}
+	var last time.Time
+	if v, err := strconv.ParseInt(summarized.Val(), 10, 64); err == nil {
+		last = time.UnixMilli(v)
+	}
+	return started.Val(), last, nil
+}
+
+// MarkSummarized records that the run was summarized in the feed at at.
+func (s *BotRunStore) MarkSummarized(ctx context.Context, wiki, user string, at time.Time) error {
+	if err := s.client.HSet(ctx, botRunKey("", wiki, user), "summarized", at.UnixMilli()).Err(); err != nil {
+		return fmt.Errorf("failed to mark bot run summarized: %w", err)
+	}
+	return nil
+}
+
+// GetRun returns the account's run in progress, or nil if it has none.
+func (s *BotRunStore) GetRun(ctx context.Context, wiki, user string) (*BotRun, error) {
+	pipe := s.client.Pipeline()
+	fields := pipe.HGetAll(ctx, botRunKey("", wiki, user))
+	pages := pipe.PFCount(ctx, botRunKey("pages", wiki, user))
+	sample := pipe.ZRevRange(ctx, botRunKey("sample", wiki, user), 0, -1)
+	summaries := pipe.HGetAll(ctx, botRunKey("summaries", wiki, user))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get bot run: %w", err)
+	}
+	f := fields.Val()
+	if len(f) == 0 {
+		return nil, nil
+	}
+
+	start, _ := strconv.ParseInt(f["start"], 10, 64)
+	end, _ := strconv.ParseInt(f["end"], 10, 64)
+	run := &BotRun{
+		ID:          fmt.Sprintf("%s:%s:%d", wiki, user, start),
+		Wiki:        wiki,
+		User:        user,
+		Bot:         f["bot"] == "1",
+		Active:      true,
+		Pages:       pages.Val(),
+		SamplePages: sample.Val(),
+		Start:       time.UnixMilli(start).UTC(),
+		End:         time.UnixMilli(end).UTC(),
+	}
+	run.Edits, _ = strconv.ParseInt(f["edits"], 10, 64)
+	run.SuspectScore, _ = strconv.ParseFloat(f["suspect_score"], 64)
+
+	var top int64
+	for summary, v := range summaries.Val() {
+		n, _ := strconv.ParseInt(v, 10, 64)
+		if n > top || (n == top && summary < run.TopSummary) {
+			run.TopSummary, top = summary, n
+		}
+	}
+	if run.Edits > 0 {
+		run.TopSummaryShare = math.Round(float64(top)/float64(run.Edits)*1000) / 1000
+	}
+	// Runs shorter than a minute are rated as lasting one
+	minutes := math.Max(run.End.Sub(run.Start).Minutes(), 1)
+	run.EditsPerMinute = math.Round(float64(run.Edits)/minutes*10) / 10
+	return run, nil
+}
+
+// EditTimes returns the times of the run's latest edits, oldest first.
+func (s *BotRunStore) EditTimes(ctx context.Context, wiki, user string) ([]time.Time, error) {
+	vals, err := s.client.LRange(ctx, botRunKey("times", wiki, user), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot run edit times: %w", err)
+	}
+	times := make([]time.Time, 0, len(vals))
+	for _, v := range vals {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			times = append(times, time.UnixMilli(ms))
+		}
+	}
+	return times, nil
+}
+
+// IdleRuns returns the runs in progress whose last edit was before before.
+func (s *BotRunStore) IdleRuns(ctx context.Context, before time.Time) ([]BotRun, error) {
+	members, err := s.client.ZRangeByScore(ctx, botRunsActiveKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(before.Unix()-1, 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idle bot runs: %w", err)
+	}
+
+	var runs []BotRun
+	for _, m := range members {
+		wiki, user, _ := strings.Cut(m, "|")
+		run, err := s.GetRun(ctx, wiki, user)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			// The run's state expired before it could be finished
+			s.client.ZRem(ctx, botRunsActiveKey, m)
+			continue
+		}
+		runs = append(runs, *run)
+	}
+	return runs, nil
+}
+
+// FinishRun ends a run: it moves to the recently finished runs and its
+// state is deleted, so the account's next burst starts a new run.
+func (s *BotRunStore) FinishRun(ctx context.Context, run *BotRun) error {
+	run.Active = false
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bot run: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, botRunsRecentKey, data)
+	pipe.LTrim(ctx, botRunsRecentKey, 0, maxRecentBotRuns-1)
+	pipe.Del(ctx,
+		botRunKey("", run.Wiki, run.User),
+		botRunKey("pages", run.Wiki, run.User),
+		botRunKey("sample", run.Wiki, run.User),
+		botRunKey("summaries", run.Wiki, run.User),
+		botRunKey("times", run.Wiki, run.User),
+	)
+	pipe.ZRem(ctx, botRunsActiveKey, run.Wiki+"|"+run.User)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to finish bot run: %w", err)
+	}
+	return nil
+}
+
+// GetRuns returns runs newest first: those in progress, then the most
+// recently finished. wiki restricts them to one wiki if not empty.
+func (s *BotRunStore) GetRuns(ctx context.Context, wiki string, limit int) ([]BotRun, error) {
+	members, err := s.client.ZRevRange(ctx, botRunsActiveKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active bot runs: %w", err)
+	}
+
+	runs := make([]BotRun, 0, limit)
+	for _, m := range members {
+		if len(runs) >= limit {
+			return runs, nil
+		}
+		w, user, _ := strings.Cut(m, "|")
+		if wiki != "" && w != wiki {
+			continue
+		}
+		run, err := s.GetRun(ctx, w, user)
+		if err != nil {
+			return nil, err
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+
+	recent, err := s.client.LRange(ctx, botRunsRecentKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent bot runs: %w", err)
+	}
+	for _, data := range recent {
+		if len(runs) >= limit {
+			break
+		}
+		var run BotRun
+		if err := json.Unmarshal([]byte(data), &run); err != nil {
+			continue
+		}
+		if wiki == "" || run.Wiki == wiki {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// GetStats returns the bot run counts for the day containing day.
+func (s *BotRunStore) GetStats(ctx context.Context, day time.Time) (BotRunStats, error) {
+	pipe := s.client.Pipeline()
+	fields := pipe.HGetAll(ctx, botRunStatsKey(day))
+	active := pipe.ZCard(ctx, botRunsActiveKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return BotRunStats{}, fmt.Errorf("failed to get bot run stats: %w", err)
+	}
+	stats := BotRunStats{Active: active.Val()}
+	stats.Runs, _ = strconv.ParseInt(fields.Val()["runs"], 10, 64)
+	stats.Edits, _ = strconv.ParseInt(fields.Val()["edits"], 10, 64)
+	return stats, nil
+}
+
+// FlagSuspect lists an account as a suspected bot, or refreshes its
+// evidence if it is listed already, and marks its run in progress with the
+// score. Accounts not flagged again within the configured TTL drop off the
+// list.
+func (s *BotRunStore) FlagSuspect(ctx context.Context, bot *SuspectedBot) error {
+	key := suspectedBotKey(bot.Wiki, bot.User)
+	if data, err := s.client.Get(ctx, key).Bytes(); err == nil {
+		var prev SuspectedBot
+		if json.Unmarshal(data, &prev) == nil && !prev.FirstFlagged.IsZero() {
+			bot.FirstFlagged = prev.FirstFlagged
+		}
+	} else if err != redis.Nil {
+		return fmt.Errorf("failed to get suspected bot: %w", err)
+	}
+	if bot.FirstFlagged.IsZero() {
+		bot.FirstFlagged = bot.LastFlagged
+	}
+
+	data, err := json.Marshal(bot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suspected bot: %w", err)
+	}
+	cutoff := bot.LastFlagged.Add(-s.cfg.SuspectTTL).Unix()
+
+	pipe := s.client.Pipeline()
+	pipe.Set(ctx, key, data, s.cfg.SuspectTTL)
+	pipe.ZAdd(ctx, suspectedBotsKey, redis.Z{Score: float64(bot.LastFlagged.Unix()), Member: bot.Wiki + "|" + bot.User})
+	pipe.ZRemRangeByScore(ctx, suspectedBotsKey, "-inf", strconv.FormatInt(cutoff, 10))
+	runKey := botRunKey("", bot.Wiki, bot.User)
+	pipe.HSet(ctx, runKey, "suspect_score", bot.Score)
+	pipe.Expire(ctx, runKey, botRunTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to flag suspected bot: %w", err)
+	}
+	return nil
+}
+
+// GetSuspects returns the suspected bots, most recently flagged first. wiki
+// restricts them to one wiki if not empty.
+func (s *BotRunStore) GetSuspects(ctx context.Context, wiki string, limit int) ([]SuspectedBot, error) {
+	members, err := s.client.ZRevRange(ctx, suspectedBotsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suspected bots: %w", err)
+	}
+
+	suspects := make([]SuspectedBot, 0, limit)
+	for _, m := range members {
+		if len(suspects) >= limit {
+			break
+		}
+		w, user, _ := strings.Cut(m, "|")
+		if wiki != "" && w != wiki {
+			continue
+		}
+		data, err := s.client.Get(ctx, suspectedBotKey(w, user)).Bytes()
+		if err == redis.Nil {
+			s.client.ZRem(ctx, suspectedBotsKey, m)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get suspected bot: %w", err)
+		}
+		var bot SuspectedBot
+		if err := json.Unmarshal(data, &bot); err != nil {
+			continue
+		}
+		suspects = append(suspects, bot)
+	}
+	return suspects, nil
+}
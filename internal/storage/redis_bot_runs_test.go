@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestBotRuns(t *testing.T) (*BotRunStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewBotRunStore(client, config.BotRunConfig{
+		RateWindow: time.Minute,
+		SuspectTTL: 7 * 24 * time.Hour,
+	}), mr
+}
+
+func TestBotRunStore_Run(t *testing.T) {
+	store, mr := setupTestBotRuns(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	n, active, err := store.CountEdit(ctx, "enwiki", "SweepBot", start)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.False(t, active)
+
+	for i := 0; i < 12; i++ {
+		summary := "fix typo"
+		if i%4 == 3 {
+			summary = "tag stub"
+		}
+		started, last, err := store.AddEdit(ctx, BotRunEdit{
+			Wiki: "enwiki", User: "SweepBot", Bot: true,
+			Title:   []string{"Alpha", "Beta", "Gamma"}[i%3],
+			Summary: summary,
+			At:      start.Add(time.Duration(i) * 10 * time.Second),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, i == 0, started)
+		assert.True(t, last.IsZero())
+	}
+
+	_, active, err = store.CountEdit(ctx, "enwiki", "SweepBot", start)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	run, err := store.GetRun(ctx, "enwiki", "SweepBot")
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.True(t, run.Bot)
+	assert.True(t, run.Active)
+	assert.Equal(t, int64(12), run.Edits)
+	assert.Equal(t, int64(3), run.Pages)
+	assert.Equal(t, []string{"Gamma", "Beta", "Alpha"}, run.SamplePages)
+	assert.Equal(t, "fix typo", run.TopSummary)
+	assert.Equal(t, 0.75, run.TopSummaryShare)
+	assert.Equal(t, start, run.Start)
+	assert.Equal(t, start.Add(110*time.Second), run.End)
+	assert.Equal(t, 6.5, run.EditsPerMinute)
+
+	times, err := store.EditTimes(ctx, "enwiki", "SweepBot")
+	require.NoError(t, err)
+	assert.Len(t, times, 12)
+
+	stats, err := store.GetStats(ctx, start)
+	require.NoError(t, err)
+	assert.Equal(t, BotRunStats{Runs: 1, Edits: 12, Active: 1}, stats)
+
+	idle, err := store.IdleRuns(ctx, start.Add(110*time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, idle)
+	idle, err = store.IdleRuns(ctx, start.Add(5*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, idle, 1)
+
+	require.NoError(t, store.FinishRun(ctx, &idle[0]))
+	missing, err := store.GetRun(ctx, "enwiki", "SweepBot")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.False(t, mr.Exists("botrun:times:enwiki:SweepBot"))
+
+	runs, err := store.GetRuns(ctx, "enwiki", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.False(t, runs[0].Active)
+	assert.Equal(t, run.ID, runs[0].ID)
+
+	runs, err = store.GetRuns(ctx, "dewiki", 10)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestBotRunStore_Suspects(t *testing.T) {
+	store, mr := setupTestBotRuns(t)
+	ctx := context.Background()
+	first := time.Now().Truncate(time.Second)
+
+	_, _, err := store.AddEdit(ctx, BotRunEdit{Wiki: "enwiki", User: "Stealth", Title: "Alpha", At: first})
+	require.NoError(t, err)
+	require.NoError(t, store.FlagSuspect(ctx, &SuspectedBot{Wiki: "enwiki", User: "Stealth", Score: 0.8, LastFlagged: first}))
+	require.NoError(t, store.FlagSuspect(ctx, &SuspectedBot{Wiki: "dewiki", User: "Leise", Score: 0.75, LastFlagged: first.Add(time.Minute)}))
+
+	run, err := store.GetRun(ctx, "enwiki", "Stealth")
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, 0.8, run.SuspectScore)
+
+	// Flagging again keeps the first time it was flagged
+	later := first.Add(time.Hour)
+	require.NoError(t, store.FlagSuspect(ctx, &SuspectedBot{Wiki: "enwiki", User: "Stealth", Score: 0.9, LastFlagged: later}))
+
+	all, err := store.GetSuspects(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "Stealth", all[0].User)
+	assert.Equal(t, 0.9, all[0].Score)
+	assert.True(t, all[0].FirstFlagged.Equal(first))
+	assert.True(t, all[0].LastFlagged.Equal(later))
+
+	de, err := store.GetSuspects(ctx, "dewiki", 10)
+	require.NoError(t, err)
+	require.Len(t, de, 1)
+	assert.Equal(t, "Leise", de[0].User)
+
+	// Suspects not flagged again expire
+	mr.FastForward(8 * 24 * time.Hour)
+	all, err = store.GetSuspects(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/metrics"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// EditDeduplicator remembers which revisions each consumer has processed so
+// that Kafka redelivery after a rebalance, or the EventStreams Last-Event-ID
+// replay after a reconnect, does not count the same edit twice.
+//
+// Each revision gets one hash, dedup:{wiki}:{revision}, with a field per
+// consumer that has claimed it. The hash expires after the configured window,
+// so memory is bounded by the edit rate times the window.
+type EditDeduplicator struct {
+	redis  *redis.Client
+	window time.Duration
+}
+
+// NewEditDeduplicator creates a deduplicator. It returns nil when dedup is
+// disabled; a nil *EditDeduplicator processes every edit.
+func NewEditDeduplicator(client *redis.Client, cfg *config.DedupConfig) *EditDeduplicator {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return &EditDeduplicator{redis: client, window: cfg.Window}
+}
+
+// dedupKey returns the Redis key identifying edit, or false if the event has
+// no stable ID (e.g. categorize events, which carry no revision).
+func dedupKey(edit *models.WikipediaEdit) (string, bool) {
+	if edit.IsLogEvent() {
+		if edit.LogID == 0 {
+			return "", false
+		}
+		return fmt.Sprintf("dedup:%s:log:%d", edit.Wiki, edit.LogID), true
+	}
+	if edit.Revision.New == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("dedup:%s:%d", edit.Wiki, edit.Revision.New), true
+}
+
+// Process runs fn unless consumer has already processed this revision within
+// the window. If fn fails the claim is released, so a retry of the same
+// message is processed rather than mistaken for a duplicate. Redis errors
+// fail open: counting an edit twice is better than dropping it.
+func (d *EditDeduplicator) Process(ctx context.Context, consumer string, edit *models.WikipediaEdit, fn func() error) error {
+	if d == nil {
+		return fn()
+	}
+	key, ok := dedupKey(edit)
+	if !ok {
+		return fn()
+	}
+
+	first, err := d.claim(ctx, key, consumer)
+	if err != nil {
+		log.Printf("Dedup check failed for %s (%s), processing anyway: %v", key, consumer, err)
+		return fn()
+	}
+	metrics.EditsDedupCheckedTotal.WithLabelValues(consumer).Inc()
+	if !first {
+		metrics.EditsDuplicateTotal.WithLabelValues(consumer).Inc()
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		if relErr := d.redis.HDel(ctx, key, consumer).Err(); relErr != nil {
+			log.Printf("Failed to release dedup claim %s (%s): %v", key, consumer, relErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// claim marks key as processed by consumer and reports whether it was the
+// first to do so.
+func (d *EditDeduplicator) claim(ctx context.Context, key, consumer string) (bool, error) {
+	pipe := d.redis.TxPipeline()
+	set := pipe.HSetNX(ctx, key, consumer, 1)
+	pipe.Expire(ctx, key, d.window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to claim %s: %w", key, err)
+	}
+	return set.Val(), nil
+}
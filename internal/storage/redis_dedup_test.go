@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupDedupTest(t *testing.T) (*EditDeduplicator, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rc.Close() })
+
+	return NewEditDeduplicator(rc, &config.DedupConfig{Enabled: true, Window: 10 * time.Minute}), mr
+}
+
+func dedupTestEdit(wiki string, rev int64) *models.WikipediaEdit {
+	edit := &models.WikipediaEdit{Type: "edit", Title: "Paris", Wiki: wiki}
+	edit.Revision.New = rev
+	return edit
+}
+
+func TestEditDeduplicator_SkipsReplays(t *testing.T) {
+	d, mr := setupDedupTest(t)
+	ctx := context.Background()
+
+	calls := 0
+	count := func() error { calls++; return nil }
+
+	for i := 0; i < 3; i++ {
+		if err := d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 100), count); err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 for a replayed revision", calls)
+	}
+
+	// Other consumers, wikis and revisions are independent
+	d.Process(ctx, "trending-aggregator", dedupTestEdit("enwiki", 100), count)
+	d.Process(ctx, "spike-detector", dedupTestEdit("frwiki", 100), count)
+	d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 101), count)
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+
+	if ttl := mr.TTL("dedup:enwiki:100"); ttl <= 0 || ttl > 10*time.Minute {
+		t.Errorf("TTL = %v, want within the dedup window", ttl)
+	}
+
+	// Once the window passes the revision is processed again
+	mr.FastForward(11 * time.Minute)
+	d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 100), count)
+	if calls != 5 {
+		t.Errorf("calls = %d, want 5 after the window expired", calls)
+	}
+}
+
+func TestEditDeduplicator_ReleasesOnFailure(t *testing.T) {
+	d, _ := setupDedupTest(t)
+	ctx := context.Background()
+	edit := dedupTestEdit("enwiki", 200)
+
+	failErr := errors.New("redis timeout")
+	if err := d.Process(ctx, "spike-detector", edit, func() error { return failErr }); !errors.Is(err, failErr) {
+		t.Fatalf("err = %v, want %v", err, failErr)
+	}
+
+	// The retry of a failed edit is not a duplicate
+	called := false
+	d.Process(ctx, "spike-detector", edit, func() error { called = true; return nil })
+	if !called {
+		t.Error("retry after a failure was skipped as a duplicate")
+	}
+}
+
+func TestEditDeduplicator_PassThrough(t *testing.T) {
+	d, mr := setupDedupTest(t)
+	ctx := context.Background()
+
+	// Events without a revision cannot be deduplicated
+	calls := 0
+	count := func() error { calls++; return nil }
+	d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 0), count)
+	d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 0), count)
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 for edits without a revision", calls)
+	}
+
+	// Log events are keyed by log ID
+	logEvent := &models.WikipediaEdit{Type: "log", Wiki: "enwiki", LogID: 77}
+	d.Process(ctx, "log-event-recorder", logEvent, count)
+	d.Process(ctx, "log-event-recorder", logEvent, count)
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	// A nil deduplicator (dedup disabled) and a Redis outage both process the edit
+	var disabled *EditDeduplicator
+	disabled.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 300), count)
+	mr.Close()
+	d.Process(ctx, "spike-detector", dedupTestEdit("enwiki", 300), count)
+	if calls != 5 {
+		t.Errorf("calls = %d, want 5", calls)
+	}
+}
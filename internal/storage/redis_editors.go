@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const editorsTopAllKey = "editors:top"
+
+// EditorActivity is one edit as it counts towards its editor's profile.
+type EditorActivity struct {
+	Wiki       string
+	User       string
+	Title      string
+	Bot        bool
+	NewPage    bool
+	ByteChange int
+	Revert     bool     // the edit reverted other editors' work...
+	Reverted   []string // ...of these editors, when the summary names them
+	InEditWar  bool     // the page had an active edit war
+	At         time.Time
+}
+
+// EditorPage is a page an editor edited recently.
+type EditorPage struct {
+	Title    string    `json:"title"`
+	LastEdit time.Time `json:"last_edit"`
+}
+
+// EditorProfile aggregates one editor's activity on one wiki since WikiSurge
+// first saw them.
+type EditorProfile struct {
+	Wiki            string           `json:"wiki"`
+	User            string           `json:"user"`
+	Anonymous       bool             `json:"anonymous"` // IP editor or temporary account; an IP User is a pseudonym if pseudonymization is on
+	Bot             bool             `json:"bot"`
+	Edits           int64            `json:"edits"`
+	NewPages        int64            `json:"new_pages"`
+	PagesTouched    int64            `json:"pages_touched"` // distinct pages, estimated
+	BytesAdded      int64            `json:"bytes_added"`
+	BytesRemoved    int64            `json:"bytes_removed"`
+	RevertsGiven    int64            `json:"reverts_given"`
+	RevertsReceived int64            `json:"reverts_received"`
+	EditWars        int64            `json:"edit_wars"` // pages edited during an edit war on them
+	EditWarPages    []string         `json:"edit_war_pages,omitempty"`
+	EditsByWiki     map[string]int64 `json:"edits_by_wiki,omitempty"` // the same account on every wiki
+	RecentPages     []EditorPage     `json:"recent_pages,omitempty"`
+	FirstSeen       time.Time        `json:"first_seen"`
+	LastSeen        time.Time        `json:"last_seen"`
+}
+
+// EditorStore keeps per-editor activity aggregates in Redis: a hash of
+// counters per editor and wiki, with the editor's recent pages, a
+// HyperLogLog of all pages they touched and the pages they edited during
+// edit wars alongside. Profiles expire once the editor has been idle for the
+// configured TTL. Non-bot editors are also ranked by edit count, per wiki
+// and across wikis.
+type EditorStore struct {
+	client *redis.Client
+	cfg    config.EditorProfilesConfig
+}
+
+// NewEditorStore creates a new editor profile store
+func NewEditorStore(client *redis.Client, cfg config.EditorProfilesConfig) *EditorStore {
+	return &EditorStore{client: client, cfg: cfg}
+}
+
+func editorKey(kind, wiki, user string) string {
+	if kind == "" {
+		return fmt.Sprintf("editor:%s:%s", wiki, user)
+	}
+	return fmt.Sprintf("editor:%s:%s:%s", kind, wiki, user)
+}
+
+func editorsTopKey(wiki string) string {
+	return "editors:top:" + wiki
+}
+
+// Name returns the name an editor is stored and served under. That is the
+// user name itself except for IP editors when pseudonymization is on, who
+// become "ip-" and a keyed hash of the address. The hash is of the canonical
+// form, so any spelling of an IPv6 address looks up the same profile.
+func (s *EditorStore) Name(user string) string {
+	if !s.cfg.PseudonymizeIPs {
+		return user
+	}
+	ip := net.ParseIP(user)
+	if ip == nil {
+		return user
+	}
+	mac := hmac.New(sha256.New, []byte(s.cfg.PseudonymKey))
+	mac.Write([]byte(ip.String()))
+	return "ip-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Record folds one edit into its editor's profile, and credits the editors
+// it reverted with a revert received.
+func (s *EditorStore) Record(ctx context.Context, a EditorActivity) error {
+	user := s.Name(a.User)
+	key := editorKey("", a.Wiki, user)
+	ttl := s.cfg.TTL
+	ts := a.At.Unix()
+
+	pipe := s.client.Pipeline()
+	edits := pipe.HIncrBy(ctx, key, "edits", 1)
+	if a.NewPage {
+		pipe.HIncrBy(ctx, key, "new_pages", 1)
+	}
+	if a.ByteChange > 0 {
+		pipe.HIncrBy(ctx, key, "bytes_added", int64(a.ByteChange))
+	} else if a.ByteChange < 0 {
+		pipe.HIncrBy(ctx, key, "bytes_removed", int64(-a.ByteChange))
+	}
+	if a.Revert {
+		pipe.HIncrBy(ctx, key, "reverts_given", 1)
+	}
+	pipe.HSetNX(ctx, key, "first_seen", ts)
+	pipe.HSet(ctx, key, "last_seen", ts, "bot", a.Bot, "anon", models.IsAnonymousUser(a.User))
+	pipe.Expire(ctx, key, ttl)
+
+	pagesKey := editorKey("pages", a.Wiki, user)
+	pipe.ZAdd(ctx, pagesKey, redis.Z{Score: float64(ts), Member: a.Title})
+	pipe.ZRemRangeByRank(ctx, pagesKey, 0, int64(-s.cfg.RecentPages-1))
+	pipe.Expire(ctx, pagesKey, ttl)
+
+	touchedKey := editorKey("touched", a.Wiki, user)
+	pipe.PFAdd(ctx, touchedKey, a.Title)
+	pipe.Expire(ctx, touchedKey, ttl)
+
+	if a.InEditWar {
+		warsKey := editorKey("wars", a.Wiki, user)
+		pipe.SAdd(ctx, warsKey, a.Title)
+		pipe.Expire(ctx, warsKey, ttl)
+	}
+
+	// User accounts are global, so the same name on another wiki is the
+	// same person
+	wikisKey := "editor:wikis:" + user
+	pipe.HIncrBy(ctx, wikisKey, a.Wiki, 1)
+	pipe.Expire(ctx, wikisKey, ttl)
+
+	for _, victim := range a.Reverted {
+		victimKey := editorKey("", a.Wiki, s.Name(victim))
+		pipe.HIncrBy(ctx, victimKey, "reverts_received", 1)
+		pipe.HSetNX(ctx, victimKey, "anon", models.IsAnonymousUser(victim))
+		pipe.Expire(ctx, victimKey, ttl)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record editor activity: %w", err)
+	}
+	if a.Bot {
+		return nil // bots would fill the rankings
+	}
+
+	// Rankings are scored with the profile's own count, so an editor trimmed
+	// from a full ranking gets back in once they overtake its last place
+	keep := int64(-s.cfg.MaxRanked - 1)
+	score := float64(edits.Val())
+	pipe = s.client.Pipeline()
+	pipe.ZAdd(ctx, editorsTopKey(a.Wiki), redis.Z{Score: score, Member: user})
+	pipe.ZRemRangeByRank(ctx, editorsTopKey(a.Wiki), 0, keep)
+	pipe.ZAdd(ctx, editorsTopAllKey, redis.Z{Score: score, Member: a.Wiki + "|" + user})
+	pipe.ZRemRangeByRank(ctx, editorsTopAllKey, 0, keep)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to rank editor: %w", err)
+	}
+	return nil
+}
+
+// GetProfile returns the profile of user on wiki, or nil if there is none.
+// user may be an IP address, which is pseudonymized the same way as when
+// the profile was recorded.
+func (s *EditorStore) GetProfile(ctx context.Context, wiki, user string) (*EditorProfile, error) {
+	user = s.Name(user)
+	key := editorKey("", wiki, user)
+
+	pipe := s.client.Pipeline()
+	fields := pipe.HGetAll(ctx, key)
+	touched := pipe.PFCount(ctx, editorKey("touched", wiki, user))
+	wars := pipe.SMembers(ctx, editorKey("wars", wiki, user))
+	pages := pipe.ZRevRangeWithScores(ctx, editorKey("pages", wiki, user), 0, -1)
+	wikis := pipe.HGetAll(ctx, "editor:wikis:"+user)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get editor profile: %w", err)
+	}
+	if len(fields.Val()) == 0 {
+		return nil, nil
+	}
+
+	p := parseEditorProfile(wiki, user, fields.Val())
+	p.PagesTouched = touched.Val()
+	p.EditWarPages = wars.Val()
+	slices.Sort(p.EditWarPages)
+	p.EditWars = int64(len(p.EditWarPages))
+	for _, z := range pages.Val() {
+		title, _ := z.Member.(string)
+		p.RecentPages = append(p.RecentPages, EditorPage{Title: title, LastEdit: time.Unix(int64(z.Score), 0).UTC()})
+	}
+	if len(wikis.Val()) > 0 {
+		p.EditsByWiki = make(map[string]int64, len(wikis.Val()))
+		for w, n := range wikis.Val() {
+			p.EditsByWiki[w], _ = strconv.ParseInt(n, 10, 64)
+		}
+	}
+	return p, nil
+}
+
+// GetTopEditors returns the limit most active non-bot editors, on one wiki
+// or, if wiki is empty, across all wikis. Profiles come without their page
+// lists. Ranked editors whose profiles have expired are dropped from the
+// ranking.
+func (s *EditorStore) GetTopEditors(ctx context.Context, wiki string, limit int) ([]EditorProfile, error) {
+	key := editorsTopAllKey
+	if wiki != "" {
+		key = editorsTopKey(wiki)
+	}
+
+	profiles := make([]EditorProfile, 0, limit)
+	for start := int64(0); len(profiles) < limit; {
+		members, err := s.client.ZRevRange(ctx, key, start, start+int64(limit)-1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top editors: %w", err)
+		}
+		if len(members) == 0 {
+			break
+		}
+		start += int64(len(members))
+
+		type ref struct{ wiki, user string }
+		refs := make([]ref, len(members))
+		pipe := s.client.Pipeline()
+		fields := make([]*redis.MapStringStringCmd, len(members))
+		touched := make([]*redis.IntCmd, len(members))
+		wars := make([]*redis.IntCmd, len(members))
+		for i, m := range members {
+			refs[i] = ref{wiki, m}
+			if wiki == "" {
+				refs[i].wiki, refs[i].user, _ = strings.Cut(m, "|")
+			}
+			fields[i] = pipe.HGetAll(ctx, editorKey("", refs[i].wiki, refs[i].user))
+			touched[i] = pipe.PFCount(ctx, editorKey("touched", refs[i].wiki, refs[i].user))
+			wars[i] = pipe.SCard(ctx, editorKey("wars", refs[i].wiki, refs[i].user))
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get editor profiles: %w", err)
+		}
+
+		var expired []interface{}
+		for i, m := range members {
+			if len(fields[i].Val()) == 0 {
+				expired = append(expired, m)
+				continue
+			}
+			if len(profiles) == limit {
+				continue
+			}
+			p := parseEditorProfile(refs[i].wiki, refs[i].user, fields[i].Val())
+			p.PagesTouched = touched[i].Val()
+			p.EditWars = wars[i].Val()
+			profiles = append(profiles, *p)
+		}
+		if len(expired) > 0 {
+			if err := s.client.ZRem(ctx, key, expired...).Err(); err != nil {
+				return nil, fmt.Errorf("failed to drop expired editors: %w", err)
+			}
+			start -= int64(len(expired))
+		}
+	}
+	return profiles, nil
+}
+
+// parseEditorProfile reads an editor's counters hash.
+func parseEditorProfile(wiki, user string, fields map[string]string) *EditorProfile {
+	n := func(field string) int64 {
+		v, _ := strconv.ParseInt(fields[field], 10, 64)
+		return v
+	}
+	p := &EditorProfile{
+		Wiki:            wiki,
+		User:            user,
+		Anonymous:       fields["anon"] == "1",
+		Bot:             fields["bot"] == "1",
+		Edits:           n("edits"),
+		NewPages:        n("new_pages"),
+		BytesAdded:      n("bytes_added"),
+		BytesRemoved:    n("bytes_removed"),
+		RevertsGiven:    n("reverts_given"),
+		RevertsReceived: n("reverts_received"),
+	}
+	if ts := n("first_seen"); ts > 0 {
+		p.FirstSeen = time.Unix(ts, 0).UTC()
+	}
+	if ts := n("last_seen"); ts > 0 {
+		p.LastSeen = time.Unix(ts, 0).UTC()
+	}
+	return p
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestEditors(t *testing.T, cfg config.EditorProfilesConfig) (*EditorStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg.TTL, cfg.RecentPages, cfg.MaxRanked = 30*24*time.Hour, 2, 3
+	return NewEditorStore(client, cfg), mr
+}
+
+func TestEditorStore_Profile(t *testing.T) {
+	store, mr := setupTestEditors(t, config.EditorProfilesConfig{})
+	ctx := context.Background()
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	for i, a := range []EditorActivity{
+		{Title: "Alpha", ByteChange: 120, NewPage: true},
+		{Title: "Beta", ByteChange: -40, Revert: true, Reverted: []string{"Vandal"}},
+		{Title: "Gamma", ByteChange: 5, InEditWar: true},
+		{Title: "Alpha", ByteChange: 10},
+	} {
+		a.Wiki, a.User, a.At = "enwiki", "Alice", at.Add(time.Duration(i)*time.Minute)
+		require.NoError(t, store.Record(ctx, a))
+	}
+	require.NoError(t, store.Record(ctx, EditorActivity{Wiki: "dewiki", User: "Alice", Title: "Alpha", At: at}))
+
+	p, err := store.GetProfile(ctx, "enwiki", "Alice")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, int64(4), p.Edits)
+	assert.Equal(t, int64(1), p.NewPages)
+	assert.Equal(t, int64(3), p.PagesTouched)
+	assert.Equal(t, int64(135), p.BytesAdded)
+	assert.Equal(t, int64(40), p.BytesRemoved)
+	assert.Equal(t, int64(1), p.RevertsGiven)
+	assert.Equal(t, int64(1), p.EditWars)
+	assert.Equal(t, []string{"Gamma"}, p.EditWarPages)
+	assert.Equal(t, map[string]int64{"enwiki": 4, "dewiki": 1}, p.EditsByWiki)
+	assert.Equal(t, at, p.FirstSeen)
+	assert.Equal(t, at.Add(3*time.Minute), p.LastSeen)
+	// Only the two most recent pages are listed
+	require.Len(t, p.RecentPages, 2)
+	assert.Equal(t, "Alpha", p.RecentPages[0].Title)
+	assert.Equal(t, "Gamma", p.RecentPages[1].Title)
+
+	victim, err := store.GetProfile(ctx, "enwiki", "Vandal")
+	require.NoError(t, err)
+	require.NotNil(t, victim)
+	assert.Equal(t, int64(1), victim.RevertsReceived)
+	assert.Zero(t, victim.Edits)
+
+	missing, err := store.GetProfile(ctx, "enwiki", "Nobody")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	// Idle profiles expire
+	mr.FastForward(31 * 24 * time.Hour)
+	p, err = store.GetProfile(ctx, "enwiki", "Alice")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestEditorStore_PseudonymizesIPs(t *testing.T) {
+	store, mr := setupTestEditors(t, config.EditorProfilesConfig{PseudonymizeIPs: true, PseudonymKey: "k"})
+	ctx := context.Background()
+	at := time.Now()
+
+	require.NoError(t, store.Record(ctx, EditorActivity{Wiki: "enwiki", User: "2001:DB8::1", Title: "Alpha", At: at}))
+	require.NoError(t, store.Record(ctx, EditorActivity{
+		Wiki: "enwiki", User: "Patroller", Title: "Alpha", At: at,
+		Revert: true, Reverted: []string{"2001:db8:0::1"},
+	}))
+
+	name := store.Name("2001:db8::1")
+	assert.Regexp(t, `^ip-[0-9a-f]{16}$`, name)
+	assert.Equal(t, "Patroller", store.Name("Patroller"))
+
+	for _, lookup := range []string{"2001:DB8::1", name} {
+		p, err := store.GetProfile(ctx, "enwiki", lookup)
+		require.NoError(t, err)
+		require.NotNil(t, p, lookup)
+		assert.Equal(t, name, p.User)
+		assert.True(t, p.Anonymous)
+		assert.Equal(t, int64(1), p.Edits)
+		assert.Equal(t, int64(1), p.RevertsReceived)
+	}
+
+	// The address itself is never stored
+	for _, key := range mr.Keys() {
+		assert.NotContains(t, strings.ToLower(key), "2001:db8", key)
+	}
+	members, err := mr.ZMembers("editors:top:enwiki")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{name, "Patroller"}, members)
+}
+
+func TestEditorStore_TopEditors(t *testing.T) {
+	store, mr := setupTestEditors(t, config.EditorProfilesConfig{})
+	ctx := context.Background()
+	at := time.Now()
+
+	record := func(wiki, user string, edits int, bot bool) {
+		t.Helper()
+		for i := 0; i < edits; i++ {
+			require.NoError(t, store.Record(ctx, EditorActivity{Wiki: wiki, User: user, Title: "Page", Bot: bot, At: at}))
+		}
+	}
+	record("enwiki", "Alice", 5, false)
+	record("enwiki", "Bob", 3, false)
+	record("enwiki", "Carol", 1, false)
+	record("enwiki", "HelperBot", 50, true)
+	record("dewiki", "Dora", 4, false)
+
+	top, err := store.GetTopEditors(ctx, "enwiki", 10)
+	require.NoError(t, err)
+	require.Len(t, top, 3)
+	assert.Equal(t, "Alice", top[0].User)
+	assert.Equal(t, int64(5), top[0].Edits)
+	assert.Equal(t, "Carol", top[2].User)
+
+	// Across wikis the ranking is capped at max_ranked, dropping the least active
+	all, err := store.GetTopEditors(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"Alice", "Dora", "Bob"}, []string{all[0].User, all[1].User, all[2].User})
+	assert.Equal(t, "dewiki", all[1].Wiki)
+
+	// Expired profiles leave the ranking
+	mr.Del("editor:enwiki:Alice")
+	top, err = store.GetTopEditors(ctx, "enwiki", 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "Bob", top[0].User)
+	ranked, err := mr.ZMembers("editors:top:enwiki")
+	require.NoError(t, err)
+	assert.NotContains(t, ranked, "Alice")
+}
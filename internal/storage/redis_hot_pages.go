@@ -25,6 +25,7 @@ type HotPageTracker struct {
 	maxMembersPerPage int
 	// metrics would go here if needed
 	cleanupInterval   time.Duration
+	clock             TimeProvider // "now" for window math; an EventClock when replaying
 	
 	// Internal state
 	shutdown       chan struct{}
@@ -80,6 +81,7 @@ func NewHotPageTracker(client *redis.Client, cfg *config.HotPages) *HotPageTrack
 		maxHotPages:       maxHotPages,
 		maxMembersPerPage: maxMembersPerPage,
 		cleanupInterval:   cleanupInterval,
+		clock:             &RealTimeProvider{},
 		shutdown:          make(chan struct{}),
 		hotPagesCache:     make(map[string]bool),
 	}
@@ -90,10 +92,16 @@ func NewHotPageTracker(client *redis.Client, cfg *config.HotPages) *HotPageTrack
 	return tracker
 }
 
+// SetTimeProvider sets the clock that GetPageStats measures its 5-minute and
+// 1-hour windows back from, and that edits without a timestamp are recorded at.
+func (h *HotPageTracker) SetTimeProvider(tp TimeProvider) {
+	h.clock = tp
+}
+
 // ProcessEdit - First Stage: Activity Counter (Promotion Gate)
 // Purpose: Lightweight tracking before promotion
 func (h *HotPageTracker) ProcessEdit(ctx context.Context, edit *models.WikipediaEdit) error {
-	activityKey := fmt.Sprintf("activity:%s", edit.Title)
+	activityKey := fmt.Sprintf("activity:%s", edit.PageKey())
 	
 	// INCR the key
 	count, err := h.redis.Incr(ctx, activityKey).Result()
@@ -124,7 +132,8 @@ func (h *HotPageTracker) ProcessEdit(ctx context.Context, edit *models.Wikipedia
 // promoteToHot - Hot Page Promotion
 // Purpose: Upgrade page to full tracking
 func (h *HotPageTracker) promoteToHot(ctx context.Context, edit *models.WikipediaEdit) error {
-	windowKey := fmt.Sprintf("hot:window:%s", edit.Title)
+	key := edit.PageKey()
+	windowKey := fmt.Sprintf("hot:window:%s", key)
 
 	// If the page is already being tracked as hot, just add the edit to
 	// the existing window instead of re-running the full promotion pipeline.
@@ -132,10 +141,10 @@ func (h *HotPageTracker) promoteToHot(ctx context.Context, edit *models.Wikipedi
 	// when activity counter >= hotThreshold for subsequent edits.
 	exists, err := h.redis.Exists(ctx, windowKey).Result()
 	if err != nil {
-		log.Printf("Failed to check hot window existence for %s: %v", edit.Title, err)
+		log.Printf("Failed to check hot window existence for %s: %v", key, err)
 		// Fall through to full promotion as a safe default.
 	} else if exists > 0 {
-		return h.AddEditToWindow(ctx, edit.Title, edit)
+		return h.AddEditToWindow(ctx, key, edit)
 	}
 
 	// Check circuit breaker: If current hot pages >= maxHotPages
@@ -146,15 +155,15 @@ func (h *HotPageTracker) promoteToHot(ctx context.Context, edit *models.Wikipedi
 	
 	if currentCount >= h.maxHotPages {
 		log.Printf("Circuit breaker: Rejecting promotion of page %s (current: %d, max: %d)", 
-			edit.Title, currentCount, h.maxHotPages)
+			key, currentCount, h.maxHotPages)
 		metrics.PromotionRejectedTotal.WithLabelValues().Inc()
 		return nil // Graceful degradation
 	}
 	
-	metadataKey := fmt.Sprintf("hot:meta:%s", edit.Title)
+	metadataKey := fmt.Sprintf("hot:meta:%s", key)
 	
 	// Use edit's timestamp if available, otherwise use current time
-	timestamp := time.Now().Unix()
+	timestamp := h.clock.Now().Unix()
 	if edit.Timestamp > 0 {
 		timestamp = edit.Timestamp
 	}
@@ -220,21 +229,21 @@ func (h *HotPageTracker) promoteToHot(ctx context.Context, edit *models.Wikipedi
 	// Invalidate hot pages cache so GetHotPagesCount reflects the new page
 	h.mu.Lock()
 	if h.hotPagesCache != nil {
-		h.hotPagesCache[edit.Title] = true
+		h.hotPagesCache[key.String()] = true
 	}
 	h.mu.Unlock()
 	
 	// Increment hot pages promoted metric
 	metrics.HotPagesPromotedTotal.WithLabelValues().Inc()
 	
-	log.Printf("Page promoted to hot tracking: %s", edit.Title)
+	log.Printf("Page promoted to hot tracking: %s", key)
 	return nil
 }
 
 // AddEditToWindow - Add edit to existing hot page window
 // Purpose: Add edit to existing hot page window
-func (h *HotPageTracker) AddEditToWindow(ctx context.Context, pageTitle string, edit *models.WikipediaEdit) error {
-	windowKey := fmt.Sprintf("hot:window:%s", pageTitle)
+func (h *HotPageTracker) AddEditToWindow(ctx context.Context, key models.PageKey, edit *models.WikipediaEdit) error {
+	windowKey := fmt.Sprintf("hot:window:%s", key)
 	
 	// Check if page is hot (EXISTS hot:window:{page})
 	exists, err := h.redis.Exists(ctx, windowKey).Result()
@@ -247,10 +256,10 @@ func (h *HotPageTracker) AddEditToWindow(ctx context.Context, pageTitle string,
 		return nil
 	}
 	
-	metadataKey := fmt.Sprintf("hot:meta:%s", pageTitle)
+	metadataKey := fmt.Sprintf("hot:meta:%s", key)
 	
 	// Use edit's timestamp if available, otherwise use current time
-	timestamp := time.Now().Unix()
+	timestamp := h.clock.Now().Unix()
 	if edit.Timestamp > 0 {
 		timestamp = edit.Timestamp
 	}
@@ -312,8 +321,8 @@ func (h *HotPageTracker) AddEditToWindow(ctx context.Context, pageTitle string,
 
 // GetPageWindow - Retrieve edits in time window
 // Purpose: Retrieve edits in time window
-func (h *HotPageTracker) GetPageWindow(ctx context.Context, pageTitle string, startTime, endTime time.Time) ([]string, error) {
-	windowKey := fmt.Sprintf("hot:window:%s", pageTitle)
+func (h *HotPageTracker) GetPageWindow(ctx context.Context, key models.PageKey, startTime, endTime time.Time) ([]string, error) {
+	windowKey := fmt.Sprintf("hot:window:%s", key)
 	
 	// ZRANGEBYSCORE with start and end timestamps
 	startScore := float64(startTime.Unix())
@@ -342,9 +351,9 @@ func (h *HotPageTracker) GetPageWindow(ctx context.Context, pageTitle string, st
 
 // GetPageStats - Get statistics for spike detection
 // Purpose: Get statistics for spike detection
-func (h *HotPageTracker) GetPageStats(ctx context.Context, pageTitle string) (*PageStats, error) {
-	windowKey := fmt.Sprintf("hot:window:%s", pageTitle)
-	metadataKey := fmt.Sprintf("hot:meta:%s", pageTitle)
+func (h *HotPageTracker) GetPageStats(ctx context.Context, key models.PageKey) (*PageStats, error) {
+	windowKey := fmt.Sprintf("hot:window:%s", key)
+	metadataKey := fmt.Sprintf("hot:meta:%s", key)
 	
 	// Check if hot page
 	exists, err := h.redis.Exists(ctx, windowKey).Result()
@@ -369,7 +378,7 @@ func (h *HotPageTracker) GetPageStats(ctx context.Context, pageTitle string) (*P
 		return nil, fmt.Errorf("failed to get page metadata: %w", err)
 	}
 	
-	now := time.Now().Unix()
+	now := h.clock.Now().Unix()
 	
 	// Calculate stats
 	// Edits in last 1 hour
@@ -489,9 +498,9 @@ func (h *HotPageTracker) cleanupStaleHotPages(ctx context.Context) (int, error)
 			
 			// If count = 0 OR TTL expired, delete
 			if count == 0 || ttl < 0 {
-				// Extract page title from key
-				pageTitle := strings.TrimPrefix(key, "hot:window:")
-				metadataKey := fmt.Sprintf("hot:meta:%s", pageTitle)
+				// Extract page key from the window key
+				pageKey := strings.TrimPrefix(key, "hot:window:")
+				metadataKey := fmt.Sprintf("hot:meta:%s", pageKey)
 				
 				// DELETE window key and metadata key
 				pipe := h.redis.Pipeline()
@@ -500,7 +509,7 @@ func (h *HotPageTracker) cleanupStaleHotPages(ctx context.Context) (int, error)
 				_, err = pipe.Exec(ctx)
 				
 				if err != nil {
-					log.Printf("Failed to delete stale hot page %s: %v", pageTitle, err)
+					log.Printf("Failed to delete stale hot page %s: %v", pageKey, err)
 				} else {
 					cleanedCount++
 					metrics.HotPagesExpiredTotal.WithLabelValues().Inc()
@@ -545,8 +554,7 @@ func (h *HotPageTracker) GetHotPagesCount(ctx context.Context) (int, error) {
 		}
 		
 		for _, key := range keys {
-			pageTitle := strings.TrimPrefix(key, "hot:window:")
-			hotPages[pageTitle] = true
+			hotPages[strings.TrimPrefix(key, "hot:window:")] = true
 		}
 		
 		cursor = nextCursor
@@ -569,8 +577,8 @@ func (h *HotPageTracker) GetHotPagesCount(ctx context.Context) (int, error) {
 }
 
 // IsHot - Check if page currently hot
-func (h *HotPageTracker) IsHot(ctx context.Context, pageTitle string) (bool, error) {
-	windowKey := fmt.Sprintf("hot:window:%s", pageTitle)
+func (h *HotPageTracker) IsHot(ctx context.Context, key models.PageKey) (bool, error) {
+	windowKey := fmt.Sprintf("hot:window:%s", key)
 	exists, err := h.redis.Exists(ctx, windowKey).Result()
 	if err != nil {
 		return false, fmt.Errorf("failed to check if page is hot: %w", err)
@@ -579,9 +587,9 @@ func (h *HotPageTracker) IsHot(ctx context.Context, pageTitle string) (bool, err
 }
 
 // GetHotPagesList - Return list of all currently hot pages
-func (h *HotPageTracker) GetHotPagesList(ctx context.Context) ([]string, error) {
+func (h *HotPageTracker) GetHotPagesList(ctx context.Context) ([]models.PageKey, error) {
 	var cursor uint64
-	var hotPages []string
+	var hotPages []models.PageKey
 	
 	for {
 		keys, nextCursor, err := h.redis.Scan(ctx, cursor, "hot:window:*", 100).Result()
@@ -590,8 +598,7 @@ func (h *HotPageTracker) GetHotPagesList(ctx context.Context) ([]string, error)
 		}
 		
 		for _, key := range keys {
-			pageTitle := strings.TrimPrefix(key, "hot:window:")
-			hotPages = append(hotPages, pageTitle)
+			hotPages = append(hotPages, models.ParsePageKey(strings.TrimPrefix(key, "hot:window:")))
 		}
 		
 		cursor = nextCursor
@@ -613,6 +620,7 @@ func (h *HotPageTracker) Shutdown() {
 // HotPage represents a hot page with metadata (for compatibility)
 type HotPage struct {
 	PageName     string    `json:"page_name"`
+	Wiki         string    `json:"wiki,omitempty"`
 	EditCount    int       `json:"edit_count"`
 	EditorsCount int       `json:"editors_count"`
 	LastActivity time.Time `json:"last_activity"`
@@ -633,19 +641,20 @@ func (h *HotPageTracker) GetHotPages(ctx context.Context, limit int) ([]HotPage,
 	
 	hotPages := make([]HotPage, 0, min(limit, len(pagesList)))
 	
-	for i, pageTitle := range pagesList {
+	for i, key := range pagesList {
 		if i >= limit {
 			break
 		}
 		
-		stats, err := h.GetPageStats(ctx, pageTitle)
+		stats, err := h.GetPageStats(ctx, key)
 		if err != nil {
-			log.Printf("Failed to get stats for page %s: %v", pageTitle, err)
+			log.Printf("Failed to get stats for page %s: %v", key, err)
 			continue
 		}
 		
 		hotPages = append(hotPages, HotPage{
-			PageName:     pageTitle,
+			PageName:     key.Title,
+			Wiki:         key.Wiki,
 			EditCount:    int(stats.TotalEdits),
 			EditorsCount: len(stats.UniqueEditors),
 			LastActivity: time.Now(), // Approximate
@@ -658,20 +667,22 @@ func (h *HotPageTracker) GetHotPages(ctx context.Context, limit int) ([]HotPage,
 
 // IsHotPage is a legacy method that calls IsHot 
 func (h *HotPageTracker) IsHotPage(ctx context.Context, wiki, title string) (bool, error) {
-	return h.IsHot(ctx, title)
+	return h.IsHot(ctx, models.NewPageKey(wiki, title))
 }
 
 // GetPageEditCount returns the current edit count for a page (legacy compatibility)
 func (h *HotPageTracker) GetPageEditCount(ctx context.Context, wiki, title string) (int, error) {
+	key := models.NewPageKey(wiki, title)
+
 	// First check if the page is hot
-	isHot, err := h.IsHot(ctx, title)
+	isHot, err := h.IsHot(ctx, key)
 	if err != nil {
 		return 0, err
 	}
 	
 	if !isHot {
 		// Check activity counter for non-hot pages
-		activityKey := fmt.Sprintf("activity:%s", title)
+		activityKey := fmt.Sprintf("activity:%s", key)
 		count, err := h.redis.Get(ctx, activityKey).Int()
 		if err == redis.Nil {
 			return 0, nil
@@ -683,7 +694,7 @@ func (h *HotPageTracker) GetPageEditCount(ctx context.Context, wiki, title strin
 	}
 	
 	// For hot pages, get from metadata
-	metadataKey := fmt.Sprintf("hot:meta:%s", title)
+	metadataKey := fmt.Sprintf("hot:meta:%s", key)
 	countStr, err := h.redis.HGet(ctx, metadataKey, "edit_count").Result()
 	if err == redis.Nil {
 		return 0, nil
@@ -73,7 +73,7 @@ func TestHotPageTracker_PromotionThresholdLogic(t *testing.T) {
 	require.NoError(t, err)
 	
 	// Should not be hot yet (count = 1, threshold = 2)
-	isHot, err := tracker.IsHot(ctx, "TestPage")
+	isHot, err := tracker.IsHot(ctx, models.NewPageKey("enwiki", "TestPage"))
 	require.NoError(t, err)
 	assert.False(t, isHot)
 	
@@ -83,7 +83,7 @@ func TestHotPageTracker_PromotionThresholdLogic(t *testing.T) {
 	require.NoError(t, err)
 	
 	// Now should be hot (count = 2, threshold = 2)
-	isHot, err = tracker.IsHot(ctx, "TestPage")
+	isHot, err = tracker.IsHot(ctx, models.NewPageKey("enwiki", "TestPage"))
 	require.NoError(t, err)
 	assert.True(t, isHot)
 }
@@ -133,7 +133,7 @@ func TestHotPageTracker_CircuitBreaker(t *testing.T) {
 	assert.Equal(t, 2, count)
 	
 	// Page3 should not be hot (rejected by circuit breaker)
-	isHot, err := tracker.IsHot(ctx, "Page3")
+	isHot, err := tracker.IsHot(ctx, models.NewPageKey("enwiki", "Page3"))
 	require.NoError(t, err)
 	assert.False(t, isHot)
 }
@@ -158,12 +158,12 @@ func TestHotPageTracker_WindowCapping(t *testing.T) {
 	// Add more edits to test window capping
 	for i := 0; i < 5; i++ { // Add 5 more edits (total would be 7)
 		edit := createTestEdit(fmt.Sprintf("window-%d", i), "TestPage", "user1")
-		err := tracker.AddEditToWindow(ctx, "TestPage", edit)
+		err := tracker.AddEditToWindow(ctx, edit.PageKey(), edit)
 		require.NoError(t, err)
 	}
 	
 	// Check window size - should be capped at maxMembersPerPage (3)
-	windowKey := "hot:window:TestPage"
+	windowKey := "hot:window:enwiki:TestPage"
 	count, err := client.ZCard(ctx, windowKey).Result()
 	require.NoError(t, err)
 	assert.LessOrEqual(t, count, int64(config.MaxMembersPerPage))
@@ -180,7 +180,7 @@ func TestHotPageTracker_TTLExpiration(t *testing.T) {
 	client.FlushDB(ctx)
 	
 	// Create activity counter
-	activityKey := "activity:TestPage"
+	activityKey := "activity:enwiki:TestPage"
 	client.Incr(ctx, activityKey)
 	client.Expire(ctx, activityKey, time.Second*1) // Short TTL
 	
@@ -210,12 +210,12 @@ func TestHotPageTracker_CleanupLogic(t *testing.T) {
 	}
 	
 	// Verify it's hot
-	isHot, err := tracker.IsHot(ctx, "CleanupPage")
+	isHot, err := tracker.IsHot(ctx, models.NewPageKey("enwiki", "CleanupPage"))
 	require.NoError(t, err)
 	assert.True(t, isHot)
 	
 	// Manually clear the window (simulating expiration)
-	windowKey := "hot:window:CleanupPage"
+	windowKey := "hot:window:enwiki:CleanupPage"
 	err = client.Del(ctx, windowKey).Err()
 	require.NoError(t, err)
 	
@@ -247,7 +247,7 @@ func TestHotPageTracker_GetPageStats(t *testing.T) {
 	}
 	
 	// Get stats
-	stats, err := tracker.GetPageStats(ctx, "StatsPage")
+	stats, err := tracker.GetPageStats(ctx, models.NewPageKey("enwiki", "StatsPage"))
 	require.NoError(t, err)
 	
 	// Verify stats
@@ -280,7 +280,7 @@ func TestHotPageTracker_GetPageWindow(t *testing.T) {
 	start := now.Add(-time.Hour)
 	end := now.Add(time.Hour)
 	
-	window, err := tracker.GetPageWindow(ctx, "WindowPage", start, end)
+	window, err := tracker.GetPageWindow(ctx, models.NewPageKey("enwiki", "WindowPage"), start, end)
 	require.NoError(t, err)
 	
 	// Should have edits in window
@@ -315,13 +315,13 @@ func TestHotPageTracker_GetHotPagesList(t *testing.T) {
 	assert.Equal(t, 3, len(hotPages))
 	
 	// Check that all pages are in the list
-	pageMap := make(map[string]bool)
+	pageMap := make(map[models.PageKey]bool)
 	for _, page := range hotPages {
 		pageMap[page] = true
 	}
 	
 	for _, page := range pages {
-		assert.True(t, pageMap[page], "Page %s should be in hot pages list", page)
+		assert.True(t, pageMap[models.NewPageKey("enwiki", page)], "Page %s should be in hot pages list", page)
 	}
 }
 
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	logEventsRecentKey = "logevents:recent"
+
+	// Per-page history is short: it exists to annotate edit wars, which
+	// rarely see more than a handful of admin actions.
+	logEventsPerPage    = 50
+	logEventsRecentSize = 500
+	logEventsTTL        = 7 * 24 * time.Hour
+)
+
+// LogEventStore keeps recent MediaWiki log events (protections, blocks,
+// deletions, moves) in Redis, both per page and as a global feed.
+type LogEventStore struct {
+	client *redis.Client
+}
+
+// NewLogEventStore creates a new log event store
+func NewLogEventStore(client *redis.Client) *LogEventStore {
+	return &LogEventStore{client: client}
+}
+
+func logEventsPageKey(wiki, title string) string {
+	return fmt.Sprintf("logevents:page:%s:%s", wiki, title)
+}
+
+// Record stores a log event, newest first, under its page and in the
+// global feed.
+func (s *LogEventStore) Record(ctx context.Context, ev *models.LogEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log event: %w", err)
+	}
+
+	pageKey := logEventsPageKey(ev.Wiki, ev.Title)
+	pipe := s.client.Pipeline()
+	pipe.LPush(ctx, pageKey, data)
+	pipe.LTrim(ctx, pageKey, 0, logEventsPerPage-1)
+	pipe.Expire(ctx, pageKey, logEventsTTL)
+	pipe.LPush(ctx, logEventsRecentKey, data)
+	pipe.LTrim(ctx, logEventsRecentKey, 0, logEventsRecentSize-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record log event: %w", err)
+	}
+	return nil
+}
+
+// GetPageEvents returns up to limit log events for a page, newest first.
+func (s *LogEventStore) GetPageEvents(ctx context.Context, wiki, title string, limit int) ([]models.LogEvent, error) {
+	return s.read(ctx, logEventsPageKey(wiki, title), limit, func(*models.LogEvent) bool { return true })
+}
+
+// GetRecent returns up to limit log events across all pages, newest first,
+// optionally restricted to one wiki and/or log type.
+func (s *LogEventStore) GetRecent(ctx context.Context, wiki, logType string, limit int) ([]models.LogEvent, error) {
+	return s.read(ctx, logEventsRecentKey, limit, func(ev *models.LogEvent) bool {
+		return (wiki == "" || ev.Wiki == wiki) && (logType == "" || ev.LogType == logType)
+	})
+}
+
+func (s *LogEventStore) read(ctx context.Context, key string, limit int, keep func(*models.LogEvent) bool) ([]models.LogEvent, error) {
+	raw, err := s.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log events: %w", err)
+	}
+
+	events := make([]models.LogEvent, 0, min(limit, len(raw)))
+	for _, r := range raw {
+		if len(events) >= limit {
+			break
+		}
+		var ev models.LogEvent
+		if err := json.Unmarshal([]byte(r), &ev); err != nil {
+			continue
+		}
+		if keep(&ev) {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+)
+
+func setupTestLogEvents(t *testing.T) (*LogEventStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewLogEventStore(client), mr
+}
+
+func TestLogEventStore_RecordAndRead(t *testing.T) {
+	store, mr := setupTestLogEvents(t)
+	ctx := context.Background()
+
+	events := []*models.LogEvent{
+		{LogID: 1, Wiki: "enwiki", Title: "Foo", LogType: "protect", LogAction: "protect", User: "A"},
+		{LogID: 2, Wiki: "dewiki", Title: "Foo", LogType: "protect", LogAction: "protect", User: "B"},
+		{LogID: 3, Wiki: "enwiki", Title: "User:Vandal", LogType: "block", LogAction: "block", User: "A"},
+		{LogID: 4, Wiki: "enwiki", Title: "Foo", LogType: "move", LogAction: "move", User: "C"},
+	}
+	for _, ev := range events {
+		require.NoError(t, store.Record(ctx, ev))
+	}
+
+	page, err := store.GetPageEvents(ctx, "enwiki", "Foo", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, int64(4), page[0].LogID, "newest first")
+	assert.Equal(t, int64(1), page[1].LogID)
+	assert.True(t, mr.TTL(logEventsPageKey("enwiki", "Foo")) > 0)
+
+	recent, err := store.GetRecent(ctx, "", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, recent, 4)
+
+	recent, err = store.GetRecent(ctx, "enwiki", "protect", 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, int64(1), recent[0].LogID)
+
+	recent, err = store.GetRecent(ctx, "", "", 2)
+	require.NoError(t, err)
+	assert.Len(t, recent, 2)
+}
+
+func TestLogEventStore_TrimsPageHistory(t *testing.T) {
+	store, _ := setupTestLogEvents(t)
+	ctx := context.Background()
+
+	for i := 0; i < logEventsPerPage+10; i++ {
+		require.NoError(t, store.Record(ctx, &models.LogEvent{
+			LogID: int64(i), Wiki: "enwiki", Title: "Busy", LogType: "protect", Comment: fmt.Sprint(i),
+		}))
+	}
+
+	page, err := store.GetPageEvents(ctx, "enwiki", "Busy", 1000)
+	require.NoError(t, err)
+	assert.Len(t, page, logEventsPerPage)
+}
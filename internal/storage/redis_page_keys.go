@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// legacyPageKeyPrefixes lists the per-page key families that were keyed by
+// bare title before page keys became wiki-qualified. Spike markers are not
+// listed because they always carried the wiki.
+var legacyPageKeyPrefixes = []string{
+	"activity:",
+	"hot:window:",
+	"hot:meta:",
+	"trending:",
+	"editwar:editors:",
+	"editwar:changes:",
+	"editwar:timeline:",
+	"editwar:start:",
+	"editwar:serverurl:",
+	"editwar:reanalyze_ctr:",
+	"editwar:analysis:",
+	"editwar:coords:",
+	"stats:page:",
+}
+
+// datedPageKeyPrefixes lists the legacy families whose keys end in a
+// ":{YYYY-MM-DD}" date after the page.
+var datedPageKeyPrefixes = map[string]bool{
+	"stats:page:": true,
+}
+
+// editWarSubKeyPrefixes lists the edit war key families that were only ever
+// written with wiki-qualified page keys. They are not migrated, but must not
+// be mistaken for title-only edit war markers either.
+var editWarSubKeyPrefixes = []string{
+	"editwar:reverts:",
+	"editwar:3rr:",
+}
+
+const editWarActiveSetKey = "editwar:active_set"
+
+// legacyPageKey is a title-only key found during migration.
+type legacyPageKey struct {
+	key    string
+	prefix string
+	title  string
+	suffix string // ":{date}" of dated keys
+}
+
+// MigrateLegacyPageKeys rewrites per-page state written before keys were
+// wiki-qualified, so "hot:window:Paris" becomes "hot:window:enwiki:Paris".
+// The wiki is recovered from the server URL stored alongside the page where
+// there is one, and defaultWiki is used otherwise. If the qualified key
+// already exists it was written by current code and wins, and the legacy key
+// is dropped. The migration is idempotent; it returns the number of keys and
+// set members rewritten.
+func MigrateLegacyPageKeys(ctx context.Context, client *redis.Client, defaultWiki string) (int, error) {
+	var found []legacyPageKey
+	for _, prefix := range legacyPageKeyPrefixes {
+		keys, err := scanLegacyPageKeys(ctx, client, prefix)
+		if err != nil {
+			return 0, err
+		}
+		found = append(found, keys...)
+	}
+	markers, err := scanLegacyPageKeys(ctx, client, "editwar:")
+	if err != nil {
+		return 0, err
+	}
+	found = append(found, markers...)
+
+	trendingMembers, err := client.ZRangeWithScores(ctx, "trending:global", 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read trending set: %w", err)
+	}
+	activeMembers, err := client.SMembers(ctx, editWarActiveSetKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read active edit war set: %w", err)
+	}
+
+	// Resolve every wiki before renaming anything, since the server URLs
+	// used for inference live in keys that are about to move.
+	wikis := make(map[string]string)
+	resolve := func(title string) {
+		if _, ok := wikis[title]; !ok {
+			wikis[title] = inferLegacyWiki(ctx, client, title, defaultWiki)
+		}
+	}
+	for _, lk := range found {
+		resolve(lk.title)
+	}
+	for _, z := range trendingMembers {
+		if member, ok := z.Member.(string); ok && models.ParsePageKey(member).IsLegacy() {
+			resolve(member)
+		}
+	}
+	for _, member := range activeMembers {
+		if models.ParsePageKey(member).IsLegacy() {
+			resolve(member)
+		}
+	}
+
+	migrated := 0
+	for _, lk := range found {
+		newKey := lk.prefix + models.NewPageKey(wikis[lk.title], lk.title).String() + lk.suffix
+		renamed, err := client.RenameNX(ctx, lk.key, newKey).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to rename %s: %w", lk.key, err)
+		}
+		if !renamed {
+			if err := client.Del(ctx, lk.key).Err(); err != nil {
+				return migrated, fmt.Errorf("failed to delete %s: %w", lk.key, err)
+			}
+		}
+		migrated++
+	}
+
+	for _, z := range trendingMembers {
+		member, ok := z.Member.(string)
+		if !ok || !models.ParsePageKey(member).IsLegacy() {
+			continue
+		}
+		pipe := client.TxPipeline()
+		pipe.ZAddNX(ctx, "trending:global", redis.Z{
+			Score:  z.Score,
+			Member: models.NewPageKey(wikis[member], member).String(),
+		})
+		pipe.ZRem(ctx, "trending:global", member)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return migrated, fmt.Errorf("failed to migrate trending member %s: %w", member, err)
+		}
+		migrated++
+	}
+
+	for _, member := range activeMembers {
+		if !models.ParsePageKey(member).IsLegacy() {
+			continue
+		}
+		pipe := client.TxPipeline()
+		pipe.SAdd(ctx, editWarActiveSetKey, models.NewPageKey(wikis[member], member).String())
+		pipe.SRem(ctx, editWarActiveSetKey, member)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return migrated, fmt.Errorf("failed to migrate active edit war %s: %w", member, err)
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// scanLegacyPageKeys returns the title-only keys under prefix. The bare
+// "editwar:" prefix matches edit war markers only; its sub-key families are
+// scanned under their own prefixes.
+func scanLegacyPageKeys(ctx context.Context, client *redis.Client, prefix string) ([]legacyPageKey, error) {
+	var found []legacyPageKey
+	iter := client.Scan(ctx, 0, prefix+"*", 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if isTrendingSetKey(key) || key == editWarActiveSetKey {
+			continue
+		}
+		if prefix == "editwar:" && isEditWarSubKey(key) {
+			continue
+		}
+		name, suffix := strings.TrimPrefix(key, prefix), ""
+		if datedPageKeyPrefixes[prefix] {
+			i := strings.LastIndex(name, ":")
+			if i < 0 {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", name[i+1:]); err != nil {
+				continue
+			}
+			name, suffix = name[:i], name[i:]
+		}
+		page := models.ParsePageKey(name)
+		if !page.IsLegacy() {
+			continue
+		}
+		found = append(found, legacyPageKey{key: key, prefix: prefix, title: page.Title, suffix: suffix})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan %s keys: %w", prefix, err)
+	}
+	return found, nil
+}
+
+func isEditWarSubKey(key string) bool {
+	for _, prefix := range legacyPageKeyPrefixes {
+		if strings.HasPrefix(prefix, "editwar:") && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range editWarSubKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// inferLegacyWiki looks for a server URL recorded against the title-only
+// keys of a page and maps it back to a wiki database name.
+func inferLegacyWiki(ctx context.Context, client *redis.Client, title, defaultWiki string) string {
+	candidates := []func() (string, error){
+		func() (string, error) { return client.HGet(ctx, "hot:meta:"+title, "server_url").Result() },
+		func() (string, error) { return client.HGet(ctx, "trending:"+title, "server_url").Result() },
+		func() (string, error) { return client.Get(ctx, "editwar:serverurl:"+title).Result() },
+	}
+	for _, get := range candidates {
+		serverURL, err := get()
+		if err != nil || serverURL == "" {
+			continue
+		}
+		if wiki := models.WikiFromServerURL(serverURL); wiki != "" {
+			return wiki
+		}
+	}
+	return defaultWiki
+}
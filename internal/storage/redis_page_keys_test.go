@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestPageKeyRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return mr, client
+}
+
+func TestMigrateLegacyPageKeys(t *testing.T) {
+	mr, client := setupTestPageKeyRedis(t)
+	ctx := context.Background()
+
+	// frwiki page, inferable from its hot page metadata
+	mr.HSet("hot:meta:Paris", "server_url", "https://fr.wikipedia.org", "edit_count", "4")
+	mr.ZAdd("hot:window:Paris", 1, "edit-1")
+	mr.Set("activity:Paris", "4")
+	mr.SetTTL("activity:Paris", 10*time.Minute)
+	mr.HSet("trending:Paris", "raw_score", "3", "last_updated", "1700000000")
+	mr.ZAdd("trending:global", 3, "Paris")
+	mr.HSet("stats:page:Paris:2026-03-02", "edits", "4")
+
+	// Edit war on a page with no server URL recorded anywhere
+	mr.Set("editwar:Talk:Berlin", "1")
+	mr.HSet("editwar:editors:Talk:Berlin", "UserA", "3")
+	mr.SAdd("editwar:active_set", "Talk:Berlin")
+
+	// Already-migrated state is left alone
+	mr.Set("editwar:dewiki:Hamburg", "1")
+	mr.Set("spike:enwiki:London", "1")
+	mr.HSet("stats:page:dewiki:Hamburg:2026-03-02", "edits", "1")
+
+	migrated, err := MigrateLegacyPageKeys(ctx, client, "enwiki")
+	require.NoError(t, err)
+	assert.Equal(t, 9, migrated)
+
+	for _, key := range []string{
+		"hot:meta:frwiki:Paris",
+		"hot:window:frwiki:Paris",
+		"activity:frwiki:Paris",
+		"trending:frwiki:Paris",
+		"stats:page:frwiki:Paris:2026-03-02",
+		"editwar:enwiki:Talk:Berlin",
+		"editwar:editors:enwiki:Talk:Berlin",
+		"editwar:dewiki:Hamburg",
+		"spike:enwiki:London",
+		"stats:page:dewiki:Hamburg:2026-03-02",
+	} {
+		assert.True(t, mr.Exists(key), "expected %s to exist", key)
+	}
+	for _, key := range []string{"hot:meta:Paris", "activity:Paris", "stats:page:Paris:2026-03-02", "editwar:Talk:Berlin"} {
+		assert.False(t, mr.Exists(key), "expected %s to be renamed", key)
+	}
+	assert.Greater(t, mr.TTL("activity:frwiki:Paris"), time.Duration(0), "TTL should survive the rename")
+
+	score, err := client.ZScore(ctx, "trending:global", "frwiki:Paris").Result()
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, score)
+	_, err = client.ZScore(ctx, "trending:global", "Paris").Result()
+	assert.ErrorIs(t, err, redis.Nil)
+
+	members, err := client.SMembers(ctx, "editwar:active_set").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"enwiki:Talk:Berlin"}, members)
+
+	// A second run has nothing left to do
+	migrated, err = MigrateLegacyPageKeys(ctx, client, "enwiki")
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
+
+func TestMigrateLegacyPageKeys_QualifiedKeyWins(t *testing.T) {
+	mr, client := setupTestPageKeyRedis(t)
+	ctx := context.Background()
+
+	mr.HSet("trending:Paris", "raw_score", "1", "last_updated", "1600000000")
+	mr.HSet("trending:enwiki:Paris", "raw_score", "7", "last_updated", "1700000000")
+
+	migrated, err := MigrateLegacyPageKeys(ctx, client, "enwiki")
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	assert.False(t, mr.Exists("trending:Paris"))
+	assert.Equal(t, "7", mr.HGet("trending:enwiki:Paris", "raw_score"))
+}
+
+func TestMigrateLegacyPageKeys_SkipsQualifiedSubKeys(t *testing.T) {
+	mr, client := setupTestPageKeyRedis(t)
+	ctx := context.Background()
+
+	mr.Lpush("editwar:reverts:enwiki:Foo", `{"reverter":"UserA"}`)
+	mr.Lpush("editwar:reverts:enwiki:Talk:Foo", `{"reverter":"UserB"}`)
+	mr.ZAdd("editwar:3rr:enwiki:Foo", 1700000000, `{"reverter":"UserA"}`)
+
+	migrated, err := MigrateLegacyPageKeys(ctx, client, "enwiki")
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+
+	for _, key := range []string{"editwar:reverts:enwiki:Foo", "editwar:reverts:enwiki:Talk:Foo", "editwar:3rr:enwiki:Foo"} {
+		assert.True(t, mr.Exists(key), "expected %s to be left alone", key)
+	}
+	keys, err := client.Keys(ctx, "editwar:enwiki:*").Result()
+	require.NoError(t, err)
+	assert.Empty(t, keys, "no edit war markers should be created")
+}
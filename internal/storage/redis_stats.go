@@ -7,12 +7,14 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Agnikulu/WikiSurge/internal/models"
 	"github.com/redis/go-redis/v9"
 )
 
 // StatsTracker tracks real-time edit statistics in Redis for dashboard display.
 type StatsTracker struct {
 	redis *redis.Client
+	clock TimeProvider
 }
 
 // LanguageCount represents edits per language.
@@ -21,6 +23,12 @@ type LanguageCount struct {
 	Count    int64
 }
 
+// ProjectCount represents edits per project family (wikipedia, wikidata, ...).
+type ProjectCount struct {
+	Project string
+	Count   int64
+}
+
 // TimelinePoint represents edit count in a time bucket.
 type TimelinePoint struct {
 	Timestamp int64 `json:"timestamp"`
@@ -29,20 +37,37 @@ type TimelinePoint struct {
 
 // NewStatsTracker creates a new stats tracker.
 func NewStatsTracker(client *redis.Client) *StatsTracker {
-	return &StatsTracker{redis: client}
+	return &StatsTracker{redis: client, clock: &RealTimeProvider{}}
+}
+
+// SetTimeProvider sets the clock that decides which day and minute bucket is
+// "now" for reads and for RecordEdit/RecordPageEdit.
+func (st *StatsTracker) SetTimeProvider(tp TimeProvider) {
+	st.clock = tp
+}
+
+// RecordEdit records an edit's project, language and timestamp for aggregate stats.
+func (st *StatsTracker) RecordEdit(ctx context.Context, project, language string, isBot bool) error {
+	return st.RecordEditAt(ctx, project, language, isBot, st.clock.Now())
 }
 
-// RecordEdit records an edit's language and timestamp for aggregate stats.
-// Called by the processor for every edit that passes through.
-func (st *StatsTracker) RecordEdit(ctx context.Context, language string, isBot bool) error {
+// RecordEditAt is RecordEdit for an edit made at the given time. The processor
+// passes the edit's event time, so a replayed edit lands in the day and
+// timeline bucket it was originally made in.
+func (st *StatsTracker) RecordEditAt(ctx context.Context, project, language string, isBot bool, at time.Time) error {
 	pipe := st.redis.Pipeline()
 
 	// Increment per-language counter partitioned by date so it resets daily
-	dateStr := time.Now().UTC().Format("2006-01-02")
+	dateStr := at.UTC().Format("2006-01-02")
 	langKey := fmt.Sprintf("stats:languages:%s", dateStr)
 	pipe.HIncrBy(ctx, langKey, language, 1)
 	pipe.Expire(ctx, langKey, 192*time.Hour) // 8 days — supports weekly digests
 
+	// Increment per-project counter partitioned by date
+	projectKey := fmt.Sprintf("stats:projects:%s", dateStr)
+	pipe.HIncrBy(ctx, projectKey, project, 1)
+	pipe.Expire(ctx, projectKey, 192*time.Hour) // 8 days
+
 	// Increment total counter for today
 	pipe.HIncrBy(ctx, langKey, "__total__", 1)
 
@@ -56,7 +81,7 @@ func (st *StatsTracker) RecordEdit(ctx context.Context, language string, isBot b
 	pipe.Expire(ctx, botKey, 192*time.Hour) // 8 days
 
 	// Increment minute-bucket counter for timeline
-	minuteBucket := time.Now().Truncate(time.Minute).Unix()
+	minuteBucket := at.Truncate(time.Minute).Unix()
 	timelineKey := fmt.Sprintf("stats:timeline:%d", minuteBucket)
 	pipe.Incr(ctx, timelineKey)
 	pipe.Expire(ctx, timelineKey, 192*time.Hour) // 8 days
@@ -74,7 +99,7 @@ func (st *StatsTracker) RecordEdit(ctx context.Context, language string, isBot b
 
 // GetLanguageCounts returns edit counts per language for today, sorted by count descending.
 func (st *StatsTracker) GetLanguageCounts(ctx context.Context) ([]LanguageCount, int64, error) {
-	dateStr := time.Now().UTC().Format("2006-01-02")
+	dateStr := st.clock.Now().UTC().Format("2006-01-02")
 	langKey := fmt.Sprintf("stats:languages:%s", dateStr)
 	data, err := st.redis.HGetAll(ctx, langKey).Result()
 	if err != nil {
@@ -99,9 +124,31 @@ func (st *StatsTracker) GetLanguageCounts(ctx context.Context) ([]LanguageCount,
 	return counts, total, nil
 }
 
+// GetProjectCounts returns edit counts per project family for today, sorted by count descending.
+func (st *StatsTracker) GetProjectCounts(ctx context.Context) ([]ProjectCount, error) {
+	dateStr := st.clock.Now().UTC().Format("2006-01-02")
+	projectKey := fmt.Sprintf("stats:projects:%s", dateStr)
+	data, err := st.redis.HGetAll(ctx, projectKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]ProjectCount, 0, len(data))
+	for project, countStr := range data {
+		count, _ := strconv.ParseInt(countStr, 10, 64)
+		counts = append(counts, ProjectCount{Project: project, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+
+	return counts, nil
+}
+
 // GetEditTypes returns human vs bot edit counts for today.
 func (st *StatsTracker) GetEditTypes(ctx context.Context) (human, bot int64, err error) {
-	dateStr := time.Now().UTC().Format("2006-01-02")
+	dateStr := st.clock.Now().UTC().Format("2006-01-02")
 	botKey := fmt.Sprintf("stats:edit_types:%s", dateStr)
 	data, err := st.redis.HGetAll(ctx, botKey).Result()
 	if err != nil {
@@ -114,7 +161,7 @@ func (st *StatsTracker) GetEditTypes(ctx context.Context) (human, bot int64, err
 
 // GetDailyEditCount returns the total edit count for today.
 func (st *StatsTracker) GetDailyEditCount(ctx context.Context) (int64, error) {
-	dateStr := time.Now().UTC().Format("2006-01-02")
+	dateStr := st.clock.Now().UTC().Format("2006-01-02")
 	langKey := fmt.Sprintf("stats:languages:%s", dateStr)
 	totalStr, err := st.redis.HGet(ctx, langKey, "__total__").Result()
 	if err == redis.Nil {
@@ -131,7 +178,7 @@ func (st *StatsTracker) GetDailyEditCount(ctx context.Context) (int64, error) {
 func (st *StatsTracker) GetEditCountForPeriod(ctx context.Context, since time.Time) (int64, error) {
 	var total int64
 	start := since.UTC().Truncate(24 * time.Hour)
-	end := time.Now().UTC().Truncate(24 * time.Hour)
+	end := st.clock.Now().UTC().Truncate(24 * time.Hour)
 	for d := start; !d.After(end); d = d.Add(24 * time.Hour) {
 		dateStr := d.Format("2006-01-02")
 		langKey := fmt.Sprintf("stats:languages:%s", dateStr)
@@ -154,7 +201,7 @@ func (st *StatsTracker) GetLanguageCountsForPeriod(ctx context.Context, since ti
 	var grandTotal int64
 
 	start := since.UTC().Truncate(24 * time.Hour)
-	end := time.Now().UTC().Truncate(24 * time.Hour)
+	end := st.clock.Now().UTC().Truncate(24 * time.Hour)
 	for d := start; !d.After(end); d = d.Add(24 * time.Hour) {
 		dateStr := d.Format("2006-01-02")
 		langKey := fmt.Sprintf("stats:languages:%s", dateStr)
@@ -184,11 +231,16 @@ func (st *StatsTracker) GetLanguageCountsForPeriod(ctx context.Context, since ti
 }
 
 // RecordPageEdit increments a per-page daily edit counter.
-// Key pattern: stats:page:{title}:{YYYY-MM-DD}, field: "edits", TTL: 8 days.
+// Key pattern: stats:page:{wiki}:{title}:{YYYY-MM-DD}, field: "edits", TTL: 8 days.
 // This data survives long enough for weekly digests to report watchlist activity.
-func (st *StatsTracker) RecordPageEdit(ctx context.Context, pageTitle string) error {
-	dateStr := time.Now().UTC().Format("2006-01-02")
-	key := fmt.Sprintf("stats:page:%s:%s", pageTitle, dateStr)
+func (st *StatsTracker) RecordPageEdit(ctx context.Context, page models.PageKey) error {
+	return st.RecordPageEditAt(ctx, page, st.clock.Now())
+}
+
+// RecordPageEditAt is RecordPageEdit for an edit made at the given time.
+func (st *StatsTracker) RecordPageEditAt(ctx context.Context, page models.PageKey, at time.Time) error {
+	dateStr := at.UTC().Format("2006-01-02")
+	key := fmt.Sprintf("stats:page:%s:%s", page, dateStr)
 
 	pipe := st.redis.Pipeline()
 	pipe.HIncrBy(ctx, key, "edits", 1)
@@ -198,13 +250,13 @@ func (st *StatsTracker) RecordPageEdit(ctx context.Context, pageTitle string) er
 }
 
 // GetPageEditCount returns the total edit count for a page across all dates in [since, now].
-func (st *StatsTracker) GetPageEditCount(ctx context.Context, pageTitle string, since time.Time) (int64, error) {
+func (st *StatsTracker) GetPageEditCount(ctx context.Context, page models.PageKey, since time.Time) (int64, error) {
 	var total int64
 	start := since.UTC().Truncate(24 * time.Hour)
-	end := time.Now().UTC().Truncate(24 * time.Hour)
+	end := st.clock.Now().UTC().Truncate(24 * time.Hour)
 	for d := start; !d.After(end); d = d.Add(24 * time.Hour) {
 		dateStr := d.Format("2006-01-02")
-		key := fmt.Sprintf("stats:page:%s:%s", pageTitle, dateStr)
+		key := fmt.Sprintf("stats:page:%s:%s", page, dateStr)
 		countStr, err := st.redis.HGet(ctx, key, "edits").Result()
 		if err == redis.Nil {
 			continue
@@ -220,12 +272,12 @@ func (st *StatsTracker) GetPageEditCount(ctx context.Context, pageTitle string,
 
 // GetTimeline returns edit counts per minute for the given duration.
 func (st *StatsTracker) GetTimeline(ctx context.Context, duration time.Duration) ([]TimelinePoint, error) {
-	now := time.Now().Truncate(time.Minute).Unix()
-	from := time.Now().Add(-duration).Truncate(time.Minute).Unix()
+	now := st.clock.Now().Truncate(time.Minute).Unix()
+	from := st.clock.Now().Add(-duration).Truncate(time.Minute).Unix()
 
 	// Prune stale members older than 8 days to prevent unbounded growth.
 	// This is cheap (O(log N + M)) and runs on every read, keeping the set tidy.
-	pruneThreshold := time.Now().Add(-192 * time.Hour).Truncate(time.Minute).Unix()
+	pruneThreshold := st.clock.Now().Add(-192 * time.Hour).Truncate(time.Minute).Unix()
 	st.redis.ZRemRangeByScore(ctx, "stats:timeline:index", "-inf", strconv.FormatInt(pruneThreshold, 10))
 
 	// Get minute buckets in range
@@ -262,3 +314,82 @@ func (st *StatsTracker) GetTimeline(ctx context.Context, duration time.Duration)
 
 	return points, nil
 }
+
+// Series names in MinuteCounts. Per-wiki and per-namespace series are named
+// "wiki:{wiki}", "new:{wiki}" (page creations) and "ns:{namespace}".
+const (
+	SeriesTotal    = "__total__"
+	SeriesNewPages = "__new__"
+)
+
+// streamEditsKey counts every edit the stream has delivered, so progress can
+// be seen regardless of the minute the edits are counted in.
+const streamEditsKey = "stats:stream:edits"
+
+// MinuteCounts is one minute of stream-wide edit counts, keyed by series.
+type MinuteCounts struct {
+	Minute time.Time
+	Counts map[string]int64
+}
+
+// RecordStreamEditAt adds an edit to the per-minute stream counters read by
+// the aggregate anomaly detector: the global total, the edit's wiki and
+// namespace, and page creations. One hash per minute, kept for 3 hours.
+// It also advances the running count read by StreamEditCount.
+func (st *StatsTracker) RecordStreamEditAt(ctx context.Context, wiki string, namespace int, isNew bool, at time.Time) error {
+	key := fmt.Sprintf("stats:minute:%d", at.Truncate(time.Minute).Unix())
+
+	pipe := st.redis.Pipeline()
+	pipe.HIncrBy(ctx, key, SeriesTotal, 1)
+	pipe.HIncrBy(ctx, key, "wiki:"+wiki, 1)
+	pipe.HIncrBy(ctx, key, "ns:"+strconv.Itoa(namespace), 1)
+	if isNew {
+		pipe.HIncrBy(ctx, key, SeriesNewPages, 1)
+		pipe.HIncrBy(ctx, key, "new:"+wiki, 1)
+	}
+	pipe.Expire(ctx, key, 3*time.Hour)
+	pipe.Incr(ctx, streamEditsKey)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// StreamEditCount returns how many edits RecordStreamEditAt has counted.
+// It only matters whether it moves: a stream that delivers nothing leaves it
+// where it is.
+func (st *StatsTracker) StreamEditCount(ctx context.Context) (int64, error) {
+	n, err := st.redis.Get(ctx, streamEditsKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to get stream edit count: %w", err)
+	}
+	return n, nil
+}
+
+// GetMinuteCounts returns the stream counters for every minute in [from, to),
+// including minutes with no edits.
+func (st *StatsTracker) GetMinuteCounts(ctx context.Context, from, to time.Time) ([]MinuteCounts, error) {
+	from = from.Truncate(time.Minute)
+	pipe := st.redis.Pipeline()
+	var minutes []time.Time
+	var cmds []*redis.MapStringStringCmd
+	for m := from; m.Before(to); m = m.Add(time.Minute) {
+		minutes = append(minutes, m)
+		cmds = append(cmds, pipe.HGetAll(ctx, fmt.Sprintf("stats:minute:%d", m.Unix())))
+	}
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get minute counts: %w", err)
+	}
+
+	result := make([]MinuteCounts, len(minutes))
+	for i, m := range minutes {
+		counts := make(map[string]int64)
+		for series, v := range cmds[i].Val() {
+			n, _ := strconv.ParseInt(v, 10, 64)
+			counts[series] = n
+		}
+		result[i] = MinuteCounts{Minute: m, Counts: counts}
+	}
+	return result, nil
+}
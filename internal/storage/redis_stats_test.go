@@ -8,6 +8,8 @@ import (
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
 )
 
 func setupStatsTest(t *testing.T) (*StatsTracker, *redis.Client, *miniredis.Miniredis) {
@@ -118,12 +120,12 @@ func TestRecordPageEdit_BasicIncrement(t *testing.T) {
 
 	// Record 5 edits for the same page
 	for i := 0; i < 5; i++ {
-		if err := st.RecordPageEdit(ctx, "Go_(programming_language)"); err != nil {
+		if err := st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "Go_(programming_language)")); err != nil {
 			t.Fatalf("RecordPageEdit: %v", err)
 		}
 	}
 
-	count, err := st.GetPageEditCount(ctx, "Go_(programming_language)", time.Now().UTC().Add(-1*time.Hour))
+	count, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Go_(programming_language)"), time.Now().UTC().Add(-1*time.Hour))
 	if err != nil {
 		t.Fatalf("GetPageEditCount: %v", err)
 	}
@@ -136,13 +138,13 @@ func TestRecordPageEdit_MultiplePages(t *testing.T) {
 	st, _, _ := setupStatsTest(t)
 	ctx := context.Background()
 
-	st.RecordPageEdit(ctx, "Bitcoin")
-	st.RecordPageEdit(ctx, "Bitcoin")
-	st.RecordPageEdit(ctx, "Bitcoin")
-	st.RecordPageEdit(ctx, "Ethereum")
+	st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "Bitcoin"))
+	st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "Bitcoin"))
+	st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "Bitcoin"))
+	st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "Ethereum"))
 
-	btcCount, _ := st.GetPageEditCount(ctx, "Bitcoin", time.Now().UTC().Add(-1*time.Hour))
-	ethCount, _ := st.GetPageEditCount(ctx, "Ethereum", time.Now().UTC().Add(-1*time.Hour))
+	btcCount, _ := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Bitcoin"), time.Now().UTC().Add(-1*time.Hour))
+	ethCount, _ := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Ethereum"), time.Now().UTC().Add(-1*time.Hour))
 
 	if btcCount != 3 {
 		t.Errorf("Bitcoin count = %d, want 3", btcCount)
@@ -152,6 +154,23 @@ func TestRecordPageEdit_MultiplePages(t *testing.T) {
 	}
 }
 
+func TestRecordPageEdit_SameTitleOnTwoWikis(t *testing.T) {
+	st, _, _ := setupStatsTest(t)
+	ctx := context.Background()
+
+	en, fr := models.NewPageKey("enwiki", "Paris"), models.NewPageKey("frwiki", "Paris")
+	st.RecordPageEdit(ctx, en)
+	st.RecordPageEdit(ctx, fr)
+	st.RecordPageEdit(ctx, fr)
+
+	since := time.Now().UTC().Add(-1 * time.Hour)
+	enCount, _ := st.GetPageEditCount(ctx, en, since)
+	frCount, _ := st.GetPageEditCount(ctx, fr, since)
+	if enCount != 1 || frCount != 2 {
+		t.Errorf("counts = enwiki %d, frwiki %d, want 1 and 2", enCount, frCount)
+	}
+}
+
 func TestGetPageEditCount_MultiDay(t *testing.T) {
 	st, rc, _ := setupStatsTest(t)
 	ctx := context.Background()
@@ -160,13 +179,13 @@ func TestGetPageEditCount_MultiDay(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		d := time.Now().UTC().Add(-time.Duration(i) * 24 * time.Hour)
 		dateStr := d.Format("2006-01-02")
-		key := fmt.Sprintf("stats:page:%s:%s", "Bitcoin", dateStr)
+		key := fmt.Sprintf("stats:page:%s:%s", "enwiki:Bitcoin", dateStr)
 		rc.HSet(ctx, key, "edits", 10*(i+1))
 	}
 
 	// Query across all 3 days
 	since := time.Now().UTC().Add(-3 * 24 * time.Hour)
-	total, err := st.GetPageEditCount(ctx, "Bitcoin", since)
+	total, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Bitcoin"), since)
 	if err != nil {
 		t.Fatalf("GetPageEditCount: %v", err)
 	}
@@ -180,7 +199,7 @@ func TestGetPageEditCount_NoData(t *testing.T) {
 	st, _, _ := setupStatsTest(t)
 	ctx := context.Background()
 
-	count, err := st.GetPageEditCount(ctx, "NonExistentPage", time.Now().UTC().Add(-24*time.Hour))
+	count, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "NonExistentPage"), time.Now().UTC().Add(-24*time.Hour))
 	if err != nil {
 		t.Fatalf("GetPageEditCount: %v", err)
 	}
@@ -197,12 +216,12 @@ func TestGetPageEditCount_OnlyCountsRequestedPeriod(t *testing.T) {
 	today := time.Now().UTC().Format("2006-01-02")
 	fiveDaysAgo := time.Now().UTC().Add(-5 * 24 * time.Hour).Format("2006-01-02")
 
-	rc.HSet(ctx, fmt.Sprintf("stats:page:%s:%s", "Bitcoin", today), "edits", 100)
-	rc.HSet(ctx, fmt.Sprintf("stats:page:%s:%s", "Bitcoin", fiveDaysAgo), "edits", 200)
+	rc.HSet(ctx, fmt.Sprintf("stats:page:%s:%s", "enwiki:Bitcoin", today), "edits", 100)
+	rc.HSet(ctx, fmt.Sprintf("stats:page:%s:%s", "enwiki:Bitcoin", fiveDaysAgo), "edits", 200)
 
 	// Query only last 2 days — should NOT include 5-day-old data
 	since := time.Now().UTC().Add(-2 * 24 * time.Hour)
-	count, err := st.GetPageEditCount(ctx, "Bitcoin", since)
+	count, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Bitcoin"), since)
 	if err != nil {
 		t.Fatalf("GetPageEditCount: %v", err)
 	}
@@ -212,7 +231,7 @@ func TestGetPageEditCount_OnlyCountsRequestedPeriod(t *testing.T) {
 
 	// Query last 7 days — should include both
 	since7 := time.Now().UTC().Add(-7 * 24 * time.Hour)
-	count7, err := st.GetPageEditCount(ctx, "Bitcoin", since7)
+	count7, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", "Bitcoin"), since7)
 	if err != nil {
 		t.Fatalf("GetPageEditCount 7d: %v", err)
 	}
@@ -225,10 +244,10 @@ func TestRecordPageEdit_TTL(t *testing.T) {
 	st, rc, mr := setupStatsTest(t)
 	ctx := context.Background()
 
-	st.RecordPageEdit(ctx, "TestPage")
+	st.RecordPageEdit(ctx, models.NewPageKey("enwiki", "TestPage"))
 
 	dateStr := time.Now().UTC().Format("2006-01-02")
-	key := fmt.Sprintf("stats:page:%s:%s", "TestPage", dateStr)
+	key := fmt.Sprintf("stats:page:%s:%s", "enwiki:TestPage", dateStr)
 
 	// Verify key exists
 	val, err := rc.HGet(ctx, key, "edits").Result()
@@ -263,13 +282,13 @@ func TestRecordPageEdit_SpecialCharactersInTitle(t *testing.T) {
 	}
 
 	for _, title := range titles {
-		if err := st.RecordPageEdit(ctx, title); err != nil {
+		if err := st.RecordPageEdit(ctx, models.NewPageKey("enwiki", title)); err != nil {
 			t.Errorf("RecordPageEdit(%q) failed: %v", title, err)
 		}
 	}
 
 	for _, title := range titles {
-		count, err := st.GetPageEditCount(ctx, title, time.Now().UTC().Add(-1*time.Hour))
+		count, err := st.GetPageEditCount(ctx, models.NewPageKey("enwiki", title), time.Now().UTC().Add(-1*time.Hour))
 		if err != nil {
 			t.Errorf("GetPageEditCount(%q) failed: %v", title, err)
 		}
@@ -278,3 +297,95 @@ func TestRecordPageEdit_SpecialCharactersInTitle(t *testing.T) {
 		}
 	}
 }
+
+func TestRecordEdit_ProjectCounts(t *testing.T) {
+	st, _, _ := setupStatsTest(t)
+	ctx := context.Background()
+
+	for _, p := range []string{"wikipedia", "wikidata", "wikipedia", "wiktionary", "wikipedia"} {
+		if err := st.RecordEdit(ctx, p, "en", false); err != nil {
+			t.Fatalf("RecordEdit: %v", err)
+		}
+	}
+
+	counts, err := st.GetProjectCounts(ctx)
+	if err != nil {
+		t.Fatalf("GetProjectCounts: %v", err)
+	}
+	if len(counts) != 3 {
+		t.Fatalf("got %d projects, want 3", len(counts))
+	}
+	if counts[0].Project != "wikipedia" || counts[0].Count != 3 {
+		t.Errorf("top project = %+v, want wikipedia with 3", counts[0])
+	}
+
+	total, _ := st.GetDailyEditCount(ctx)
+	if total != 5 {
+		t.Errorf("daily total = %d, want 5", total)
+	}
+}
+
+func TestRecordEditAt_UsesEventTime(t *testing.T) {
+	st, rc, _ := setupStatsTest(t)
+	ctx := context.Background()
+
+	// A replayed edit from three days ago lands in that day's counters and
+	// timeline bucket, not today's.
+	at := time.Now().UTC().Add(-72 * time.Hour)
+	if err := st.RecordEditAt(ctx, "wikipedia", "en", false, at); err != nil {
+		t.Fatalf("RecordEditAt: %v", err)
+	}
+
+	if total, _ := st.GetDailyEditCount(ctx); total != 0 {
+		t.Errorf("today's total = %d, want 0", total)
+	}
+	dayKey := fmt.Sprintf("stats:languages:%s", at.Format("2006-01-02"))
+	if n, _ := rc.HGet(ctx, dayKey, "__total__").Int64(); n != 1 {
+		t.Errorf("%s total = %d, want 1", dayKey, n)
+	}
+	bucketKey := fmt.Sprintf("stats:timeline:%d", at.Truncate(time.Minute).Unix())
+	if n, _ := rc.Get(ctx, bucketKey).Int64(); n != 1 {
+		t.Errorf("%s = %d, want 1", bucketKey, n)
+	}
+
+	// Reads are relative to the tracker's clock, so an event clock at that
+	// time sees the edit as today's.
+	tp := NewMockTimeProvider()
+	tp.SetTime(at)
+	st.SetTimeProvider(tp)
+	if total, _ := st.GetDailyEditCount(ctx); total != 1 {
+		t.Errorf("daily total at event time = %d, want 1", total)
+	}
+}
+
+func TestRecordStreamEditAt_MinuteCounts(t *testing.T) {
+	st, _, _ := setupStatsTest(t)
+	ctx := context.Background()
+
+	minute := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	st.RecordStreamEditAt(ctx, "enwiki", 0, false, minute.Add(5*time.Second))
+	st.RecordStreamEditAt(ctx, "enwiki", 0, true, minute.Add(20*time.Second))
+	st.RecordStreamEditAt(ctx, "dewiki", 2, false, minute.Add(2*time.Minute))
+
+	buckets, err := st.GetMinuteCounts(ctx, minute, minute.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("GetMinuteCounts: %v", err)
+	}
+	if len(buckets) != 3 {
+		t.Fatalf("got %d buckets, want 3 (empty minutes included)", len(buckets))
+	}
+
+	first := buckets[0].Counts
+	if first[SeriesTotal] != 2 || first["wiki:enwiki"] != 2 || first["ns:0"] != 2 {
+		t.Errorf("first minute = %v", first)
+	}
+	if first[SeriesNewPages] != 1 || first["new:enwiki"] != 1 {
+		t.Errorf("page creations = %v", first)
+	}
+	if len(buckets[1].Counts) != 0 {
+		t.Errorf("empty minute = %v", buckets[1].Counts)
+	}
+	if buckets[2].Counts["wiki:dewiki"] != 1 || buckets[2].Counts["ns:2"] != 1 {
+		t.Errorf("third minute = %v", buckets[2].Counts)
+	}
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const storiesActiveKey = "stories:active"
+
+// severityRank orders alert severities; unknown values rank lowest.
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// StoryPage is one wiki's article within a story, summarising the spikes it
+// has had while the story was active.
+type StoryPage struct {
+	Wiki       string    `json:"wiki"`
+	Title      string    `json:"title"`
+	ServerURL  string    `json:"server_url,omitempty"`
+	SpikeRatio float64   `json:"spike_ratio"` // highest ratio seen
+	Edits5Min  int64     `json:"edits_5min"`  // at the latest spike
+	Severity   string    `json:"severity"`    // highest severity seen
+	Spikes     int       `json:"spikes"`
+	FirstSpike time.Time `json:"first_spike"`
+	LastSpike  time.Time `json:"last_spike"`
+}
+
+// Story groups the spikes of articles about the same Wikidata item across
+// language editions: one world event rather than unrelated page alerts.
+type Story struct {
+	QID       string      `json:"qid"`
+	Title     string      `json:"title"` // English title when there is one
+	Severity  string      `json:"severity"`
+	FirstSeen time.Time   `json:"first_seen"`
+	LastSeen  time.Time   `json:"last_seen"`
+	Pages     []StoryPage `json:"pages"` // most severe first
+}
+
+// StoryStore keeps active stories in Redis: a hash per Wikidata item with one
+// field per wiki, and a sorted set of items by the time of their last spike.
+// A story expires once none of its pages has spiked for the window.
+type StoryStore struct {
+	client *redis.Client
+	window time.Duration
+}
+
+// NewStoryStore creates a new story store
+func NewStoryStore(client *redis.Client, window time.Duration) *StoryStore {
+	return &StoryStore{client: client, window: window}
+}
+
+func storyKey(qid string) string {
+	return "story:" + qid
+}
+
+// storySpikeRetries bounds how often AddSpike retries when another
+// processor updated the same page of the story in between.
+const storySpikeRetries = 5
+
+// setStoryPageScript replaces a wiki's page in a story if it still holds the
+// value it was merged from, and records the story as active. It returns 0,
+// writing nothing, if the page changed in the meantime.
+//
+// KEYS[1] story:{qid}, KEYS[2] stories:active
+// ARGV[1] wiki, ARGV[2] expected page ("" for none), ARGV[3] new page,
+// ARGV[4] window (seconds), ARGV[5] qid, ARGV[6] spike time (unix seconds)
+var setStoryPageScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if (current or '') ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('ZADD', KEYS[2], ARGV[6], ARGV[5])
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', '(' .. (tonumber(ARGV[6]) - tonumber(ARGV[4])))
+return 1
+`)
+
+// AddSpike records a spike of page, which is about the Wikidata item qid, and
+// returns the story as it now stands. The page is merged with what the story
+// already holds for its wiki and written back only if that is unchanged, so
+// concurrent spikes of the same page are all counted.
+func (s *StoryStore) AddSpike(ctx context.Context, qid string, page StoryPage) (*Story, error) {
+	key := storyKey(qid)
+	for attempt := 0; attempt < storySpikeRetries; attempt++ {
+		existing, err := s.client.HGet(ctx, key, page.Wiki).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read story %s: %w", qid, err)
+		}
+
+		data, err := json.Marshal(mergeStoryPage(existing, page))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal story page: %w", err)
+		}
+		set, err := setStoryPageScript.Run(ctx, s.client, []string{key, storiesActiveKey},
+			page.Wiki, existing, data, int64(s.window.Seconds()), qid, page.LastSpike.Unix()).Int()
+		if err != nil {
+			return nil, fmt.Errorf("failed to record story spike: %w", err)
+		}
+		if set == 1 {
+			return s.GetStory(ctx, qid)
+		}
+	}
+	return nil, fmt.Errorf("failed to record story spike: story %s kept changing", qid)
+}
+
+// mergeStoryPage folds a new spike of page into the wiki's existing entry,
+// if that is about the same article.
+func mergeStoryPage(existing string, page StoryPage) StoryPage {
+	if existing != "" {
+		var prev StoryPage
+		if json.Unmarshal([]byte(existing), &prev) == nil && prev.Title == page.Title {
+			page.FirstSpike = prev.FirstSpike
+			page.Spikes += prev.Spikes
+			if prev.SpikeRatio > page.SpikeRatio {
+				page.SpikeRatio = prev.SpikeRatio
+			}
+			if severityRank[prev.Severity] > severityRank[page.Severity] {
+				page.Severity = prev.Severity
+			}
+		}
+	}
+	if page.FirstSpike.IsZero() {
+		page.FirstSpike = page.LastSpike
+	}
+	return page
+}
+
+// GetStory returns the story for a Wikidata item, or nil if it has expired.
+func (s *StoryStore) GetStory(ctx context.Context, qid string) (*Story, error) {
+	fields, err := s.client.HGetAll(ctx, storyKey(qid)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read story %s: %w", qid, err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	pages := make([]StoryPage, 0, len(fields))
+	var latest time.Time
+	for _, raw := range fields {
+		var page StoryPage
+		if err := json.Unmarshal([]byte(raw), &page); err != nil {
+			continue
+		}
+		pages = append(pages, page)
+		if page.LastSpike.After(latest) {
+			latest = page.LastSpike
+		}
+	}
+
+	// The hash lives as long as its newest spike, so drop wikis whose last
+	// spike fell out of the window before that.
+	story := &Story{QID: qid}
+	for _, page := range pages {
+		if latest.Sub(page.LastSpike) > s.window {
+			continue
+		}
+		story.Pages = append(story.Pages, page)
+		if story.FirstSeen.IsZero() || page.FirstSpike.Before(story.FirstSeen) {
+			story.FirstSeen = page.FirstSpike
+		}
+		if page.LastSpike.After(story.LastSeen) {
+			story.LastSeen = page.LastSpike
+		}
+		if severityRank[page.Severity] > severityRank[story.Severity] {
+			story.Severity = page.Severity
+		}
+	}
+	if len(story.Pages) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(story.Pages, func(i, j int) bool {
+		a, b := story.Pages[i], story.Pages[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if a.SpikeRatio != b.SpikeRatio {
+			return a.SpikeRatio > b.SpikeRatio
+		}
+		return a.Wiki < b.Wiki
+	})
+
+	story.Title = story.Pages[0].Title
+	for _, p := range story.Pages {
+		if p.Wiki == "enwiki" {
+			story.Title = p.Title
+			break
+		}
+	}
+	return story, nil
+}
+
+// GetStories returns up to limit active stories spanning at least minWikis
+// wikis, most recently active first.
+func (s *StoryStore) GetStories(ctx context.Context, minWikis, limit int) ([]Story, error) {
+	qids, err := s.client.ZRevRange(ctx, storiesActiveKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stories: %w", err)
+	}
+
+	stories := make([]Story, 0)
+	for _, qid := range qids {
+		if len(stories) >= limit {
+			break
+		}
+		story, err := s.GetStory(ctx, qid)
+		if err != nil {
+			return nil, err
+		}
+		if story == nil {
+			// The hash expired; drop the index entry too
+			s.client.ZRem(ctx, storiesActiveKey, qid)
+			continue
+		}
+		if len(story.Pages) >= minWikis {
+			stories = append(stories, *story)
+		}
+	}
+	return stories, nil
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestStories(t *testing.T) *StoryStore {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewStoryStore(client, 6*time.Hour)
+}
+
+func TestStoryStore_GroupsWikis(t *testing.T) {
+	store := setupTestStories(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	_, err := store.AddSpike(ctx, "Q1", StoryPage{Wiki: "dewiki", Title: "Erdbeben", SpikeRatio: 12, Severity: "high", Spikes: 1, LastSpike: start})
+	require.NoError(t, err)
+	_, err = store.AddSpike(ctx, "Q2", StoryPage{Wiki: "frwiki", Title: "Autre", SpikeRatio: 6, Severity: "medium", Spikes: 1, LastSpike: start})
+	require.NoError(t, err)
+
+	// A lone wiki is not a story yet
+	stories, err := store.GetStories(ctx, 2, 10)
+	require.NoError(t, err)
+	assert.Empty(t, stories)
+
+	story, err := store.AddSpike(ctx, "Q1", StoryPage{Wiki: "enwiki", Title: "Earthquake", SpikeRatio: 6, Severity: "medium", Spikes: 1, LastSpike: start.Add(20 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, story.Pages, 2)
+	assert.Equal(t, "Earthquake", story.Title, "the English title names the story")
+	assert.Equal(t, "high", story.Severity)
+	assert.Equal(t, "dewiki", story.Pages[0].Wiki, "most severe page first")
+	assert.Equal(t, start, story.FirstSeen)
+
+	// A second spike on the same page is merged into its entry
+	story, err = store.AddSpike(ctx, "Q1", StoryPage{Wiki: "dewiki", Title: "Erdbeben", SpikeRatio: 8, Severity: "critical", Spikes: 1, LastSpike: start.Add(30 * time.Minute)})
+	require.NoError(t, err)
+	de := story.Pages[0]
+	assert.Equal(t, 2, de.Spikes)
+	assert.Equal(t, 12.0, de.SpikeRatio)
+	assert.Equal(t, "critical", de.Severity)
+	assert.Equal(t, start, de.FirstSpike)
+
+	stories, err = store.GetStories(ctx, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, stories, 1)
+	assert.Equal(t, "Q1", stories[0].QID)
+
+	stories, err = store.GetStories(ctx, 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, stories)
+}
+
+func TestStoryStore_DropsSpikesOutsideWindow(t *testing.T) {
+	store := setupTestStories(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	_, err := store.AddSpike(ctx, "Q1", StoryPage{Wiki: "dewiki", Title: "Erdbeben", Severity: "high", Spikes: 1, LastSpike: start})
+	require.NoError(t, err)
+	story, err := store.AddSpike(ctx, "Q1", StoryPage{Wiki: "enwiki", Title: "Earthquake", Severity: "high", Spikes: 1, LastSpike: start.Add(7 * time.Hour)})
+	require.NoError(t, err)
+
+	require.Len(t, story.Pages, 1, "spikes 7h apart are not the same story")
+	assert.Equal(t, "enwiki", story.Pages[0].Wiki)
+}
+
+func TestStoryStore_ConcurrentSpikesAllCount(t *testing.T) {
+	store := setupTestStories(t)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.AddSpike(ctx, "Q1", StoryPage{Wiki: "dewiki", Title: "Erdbeben", SpikeRatio: 8, Severity: "high", Spikes: 1, LastSpike: at})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	story, err := store.GetStory(ctx, "Q1")
+	require.NoError(t, err)
+	require.NotNil(t, story)
+	require.Len(t, story.Pages, 1)
+	assert.Equal(t, 4, story.Pages[0].Spikes)
+}
@@ -14,49 +14,12 @@ import (
 	"github.com/Agnikulu/WikiSurge/internal/models"
 )
 
-// TimeProvider allows for mockable time in tests
-type TimeProvider interface {
-	Now() time.Time
-}
-
-// RealTimeProvider uses actual system time
-type RealTimeProvider struct{}
-
-func (r *RealTimeProvider) Now() time.Time {
-	return time.Now()
-}
-
-// MockTimeProvider allows setting custom time for tests
-type MockTimeProvider struct {
-	currentTime time.Time
-}
-
-// NewMockTimeProvider creates a new mock time provider starting at the current time
-func NewMockTimeProvider() *MockTimeProvider {
-	return &MockTimeProvider{currentTime: time.Now()}
-}
-
-func (m *MockTimeProvider) Now() time.Time {
-	return m.currentTime
-}
-
-func (m *MockTimeProvider) SetTime(t time.Time) {
-	m.currentTime = t
-}
-
-func (m *MockTimeProvider) FastForward(d time.Duration) {
-	m.currentTime = m.currentTime.Add(d)
-}
-
-// AdvanceTime is an alias for FastForward
-func (m *MockTimeProvider) AdvanceTime(d time.Duration) {
-	m.FastForward(d)
-}
-
 // TrendingScorer manages trending page scoring and tracking in Redis with lazy decay
 type TrendingScorer struct {
 	redis            *redis.Client
 	config           *config.TrendingConfig
+	model            TrendingModel
+	modelErr         error // why model is nil, if it is
 	timeProvider     TimeProvider
 	halfLifeMinutes  float64
 	maxPages         int
@@ -70,6 +33,7 @@ type TrendingScorer struct {
 // TrendingEntry represents a trending page entry with computed scores
 type TrendingEntry struct {
 	PageTitle    string  `json:"page_title"`
+	Wiki         string  `json:"wiki,omitempty"`
 	RawScore     float64 `json:"raw_score"`
 	LastUpdated  int64   `json:"last_updated"`
 	CurrentScore float64 `json:"current_score"`
@@ -118,6 +82,10 @@ func newTrendingScorer(redis *redis.Client, config *config.TrendingConfig, timeP
 		pruneInterval = config.PruneInterval
 	}
 	
+	// Config validation rejects unknown model names; a config that skipped
+	// it gets a scorer whose ProcessEdit reports the bad name
+	model, modelErr := NewTrendingModel(redis, config)
+
 	// Initialize metrics
 	trendingMetrics := &TrendingMetrics{
 		UpdatesTotal: prometheus.NewCounter(prometheus.CounterOpts{
@@ -144,6 +112,8 @@ func newTrendingScorer(redis *redis.Client, config *config.TrendingConfig, timeP
 	return &TrendingScorer{
 		redis:           redis,
 		config:          config,
+		model:           model,
+		modelErr:        modelErr,
 		timeProvider:    timeProvider,
 		halfLifeMinutes: halfLifeMinutes,
 		maxPages:        maxPages,
@@ -154,157 +124,201 @@ func newTrendingScorer(redis *redis.Client, config *config.TrendingConfig, timeP
 	}
 }
 
+// SetTimeProvider replaces the clock used for decay, e.g. with a processor's
+// EventClock so scores decay by event time.
+func (t *TrendingScorer) SetTimeProvider(tp TimeProvider) {
+	t.timeProvider = tp
+}
+
+// SetModel replaces the model that scores each edit, e.g. with one not
+// built in.
+func (t *TrendingScorer) SetModel(m TrendingModel) {
+	t.model = m
+	t.modelErr = nil
+}
+
+// Model returns the model scoring edits, or nil if the configured model is
+// unknown.
+func (t *TrendingScorer) Model() TrendingModel {
+	return t.model
+}
+
 // Stop gracefully shuts down the trending scorer
 func (t *TrendingScorer) Stop() {
 	t.cancel()
 	t.pruneWg.Wait()
 }
 
-// IncrementScore adds score for an edit, applying decay lazily
-func (t *TrendingScorer) IncrementScore(pageTitle string, scoreIncrement float64) error {
-	now := t.timeProvider.Now().Unix()
-	
-	// Keys
-	pageKey := fmt.Sprintf("trending:%s", pageTitle)
-	globalKey := "trending:global"
-	
-	// Get current values
-	ctx := context.Background()
-	pipe := t.redis.Pipeline()
-	
-	rawScoreCmd := pipe.HGet(ctx, pageKey, "raw_score")
-	lastUpdatedCmd := pipe.HGet(ctx, pageKey, "last_updated")
-	
-	_, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		return fmt.Errorf("failed to get current values: %w", err)
-	}
-	
-	// Parse existing values
-	var rawScore float64
-	var lastUpdated int64
-	
-	if rawScoreCmd.Err() == nil {
-		rawScore, _ = strconv.ParseFloat(rawScoreCmd.Val(), 64)
-	}
-	
-	if lastUpdatedCmd.Err() == nil {
-		lastUpdated, _ = strconv.ParseInt(lastUpdatedCmd.Val(), 10, 64)
-	}
-	
-	// Apply lazy decay if entry exists
-	if lastUpdated > 0 {
-		elapsedMinutes := float64(now-lastUpdated) / 60.0
-		decayFactor := math.Pow(0.5, elapsedMinutes/t.halfLifeMinutes)
-		rawScore *= decayFactor
+// trendingGlobalKey ranks every trending page. Its scores are log-space:
+// log2(score) + t/halfLife, where t is when the score was last updated. A
+// page's decayed score at time now is 2^(member score - now/halfLife), so
+// decay shifts every member by the same amount and never changes the order.
+// Ranking needs no rescoring, and pages that have decayed away sit at the
+// bottom until pruned. The per-wiki, language, project and namespace
+// leaderboards hold the same scores for their subset of pages.
+const trendingGlobalKey = "trending:global"
+
+// trendingPageTTL keeps a page's hash for weekly digests.
+const trendingPageTTL = 192 * time.Hour // 8 days
+
+// incrementScript decays a page's score to the later of its last update and
+// now, adds the increment (itself decayed if the edit is older than the last
+// update) and writes the page hash and its log-space rank in one step, so
+// concurrent processors cannot lose each other's updates. Every leaderboard
+// the page belongs to gets the same rank, and the scoped ones are registered
+// for pruning.
+//
+// KEYS[1] trending:{page}, KEYS[2] trending:sets, KEYS[3] trending:global,
+// KEYS[4...] scoped leaderboards
+// ARGV[1] member, ARGV[2] increment, ARGV[3] now (unix seconds),
+// ARGV[4] half-life (seconds), ARGV[5] page TTL (seconds), ARGV[6] server URL
+var incrementScript = redis.NewScript(`
+local inc = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local halfLife = tonumber(ARGV[4])
+
+local raw = inc
+local at = now
+local last = tonumber(redis.call('HGET', KEYS[1], 'last_updated'))
+local prev = tonumber(redis.call('HGET', KEYS[1], 'raw_score'))
+if last and prev then
+	if last > now then
+		at = last
+		raw = prev + inc * 2 ^ ((now - last) / halfLife)
+	else
+		raw = prev * 2 ^ ((last - now) / halfLife) + inc
+	end
+end
+
+local rank = string.format('%.17g', math.log(raw) / math.log(2) + at / halfLife)
+redis.call('HSET', KEYS[1], 'raw_score', string.format('%.17g', raw), 'last_updated', string.format('%d', at))
+if ARGV[6] ~= '' then
+	redis.call('HSETNX', KEYS[1], 'server_url', ARGV[6])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+for i = 3, #KEYS do
+	redis.call('ZADD', KEYS[i], rank, ARGV[1])
+	if i > 3 then
+		redis.call('SADD', KEYS[2], KEYS[i])
+	end
+end
+return string.format('%.17g', raw)
+`)
+
+// IncrementScore adds score for an edit, applying decay lazily. The page
+// is left out of namespace leaderboards, since its namespace is not known.
+func (t *TrendingScorer) IncrementScore(key models.PageKey, scoreIncrement float64) error {
+	return t.incrementScore(context.Background(), key, noNamespace, scoreIncrement, "")
+}
+
+// incrementScore adds to a page's score atomically in every leaderboard it
+// belongs to, recording serverURL for the page if it has none yet.
+func (t *TrendingScorer) incrementScore(ctx context.Context, key models.PageKey, ns int, scoreIncrement float64, serverURL string) error {
+	if scoreIncrement <= 0 {
+		return nil // log-space cannot hold zero, and it would change nothing
 	}
-	
-	// Add increment
-	rawScore += scoreIncrement
-	
-	// Update Redis with pipeline
-	pipe2 := t.redis.Pipeline()
-	pipe2.HSet(ctx, pageKey, "raw_score", rawScore)
-	pipe2.HSet(ctx, pageKey, "last_updated", now)
-	pipe2.Expire(ctx, pageKey, 192*time.Hour) // 8 days — supports weekly digests
-	pipe2.ZAdd(ctx, globalKey, redis.Z{Score: rawScore, Member: pageTitle})
-	
-	_, err = pipe2.Exec(ctx)
+
+	keys := append([]string{fmt.Sprintf("trending:%s", key), trendingSetsKey}, t.setsFor(key, ns)...)
+	err := incrementScript.Run(ctx, t.redis, keys,
+		key.String(),
+		scoreIncrement,
+		t.timeProvider.Now().Unix(),
+		t.halfLifeMinutes*60,
+		int64(trendingPageTTL.Seconds()),
+		serverURL,
+	).Err()
 	if err != nil {
 		return fmt.Errorf("failed to update trending score: %w", err)
 	}
-	
-	// Update metrics
+
 	t.metrics.UpdatesTotal.Inc()
-	
 	return nil
 }
 
+// logScoreAt returns the log-space rank a page with the given decayed score
+// would have at now.
+func (t *TrendingScorer) logScoreAt(score float64, now time.Time) float64 {
+	return math.Log2(score) + float64(now.Unix())/(t.halfLifeMinutes*60)
+}
+
+// scoreAt is the inverse of logScoreAt: the decayed score at now of a page
+// ranked at rank.
+func (t *TrendingScorer) scoreAt(rank float64, now time.Time) float64 {
+	return math.Exp2(rank - float64(now.Unix())/(t.halfLifeMinutes*60))
+}
+
 // GetTopTrending returns the top N trending pages with current decayed scores
 func (t *TrendingScorer) GetTopTrending(limit int) ([]*TrendingEntry, error) {
+	return t.GetTopTrendingIn(TrendingScope{}, limit)
+}
+
+// GetTopTrendingIn returns the top N pages of one leaderboard with current
+// decayed scores. It returns ErrTrendingScopeDisabled for project or
+// namespace leaderboards this deployment does not keep.
+func (t *TrendingScorer) GetTopTrendingIn(scope TrendingScope, limit int) ([]*TrendingEntry, error) {
+	if (scope.Project != "" && !t.config.ProjectSets) || (scope.Namespace != nil && !t.config.NamespaceSets) {
+		return nil, ErrTrendingScopeDisabled
+	}
+	if limit <= 0 {
+		return []*TrendingEntry{}, nil
+	}
 	ctx := context.Background()
-	globalKey := "trending:global"
-	now := t.timeProvider.Now().Unix()
-	
-	// Get top pages from sorted set (may have stale scores)
-	results, err := t.redis.ZRevRangeWithScores(ctx, globalKey, 0, int64(limit*2-1)).Result()
+	now := t.timeProvider.Now()
+
+	// The set is already in current-score order
+	results, err := t.redis.ZRevRangeWithScores(ctx, scope.key(), 0, int64(limit-1)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trending pages: %w", err)
 	}
-	
-	entries := make([]*TrendingEntry, 0, len(results))
 
-	// Pipeline all HGetAll calls to avoid N+1 round-trips.
-	type pageResult struct {
-		title string
-		cmd   *redis.MapStringStringCmd
-	}
 	pipe := t.redis.Pipeline()
-	pageResults := make([]pageResult, 0, len(results))
-	for _, result := range results {
-		pageTitle := result.Member.(string)
-		pageKey := fmt.Sprintf("trending:%s", pageTitle)
-		cmd := pipe.HGetAll(ctx, pageKey)
-		pageResults = append(pageResults, pageResult{title: pageTitle, cmd: cmd})
+	cmds := make([]*redis.SliceCmd, len(results))
+	for i, z := range results {
+		cmds[i] = pipe.HMGet(ctx, fmt.Sprintf("trending:%s", z.Member), "raw_score", "last_updated", "server_url")
 	}
 	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
 		return nil, fmt.Errorf("failed to pipeline trending page data: %w", err)
 	}
 
-	// Process results from the pipeline
-	for _, pr := range pageResults {
-		data, err := pr.cmd.Result()
-		if err != nil {
-			continue // Skip pages that no longer exist
-		}
-		
-		rawScore, err := strconv.ParseFloat(data["raw_score"], 64)
-		if err != nil {
-			continue
-		}
-		
-		lastUpdated, err := strconv.ParseInt(data["last_updated"], 10, 64)
-		if err != nil {
-			continue
+	entries := make([]*TrendingEntry, 0, len(results))
+	for i, z := range results {
+		key := models.ParsePageKey(z.Member.(string))
+		entry := &TrendingEntry{
+			PageTitle:    key.Title,
+			Wiki:         key.Wiki,
+			CurrentScore: t.scoreAt(z.Score, now),
 		}
-		
-		// Calculate current decayed score
-		elapsedMinutes := float64(now-lastUpdated) / 60.0
-		decayFactor := math.Pow(0.5, elapsedMinutes/t.halfLifeMinutes)
-		currentScore := rawScore * decayFactor
-		
-		entries = append(entries, &TrendingEntry{
-			PageTitle:    pr.title,
-			RawScore:     rawScore,
-			LastUpdated:  lastUpdated,
-			CurrentScore: currentScore,
-			ServerURL:    data["server_url"],
-		})
-	}
-	
-	// Sort by current score (may differ from stored score due to decay)
-	for i := 0; i < len(entries); i++ {
-		for j := i + 1; j < len(entries); j++ {
-			if entries[i].CurrentScore < entries[j].CurrentScore {
-				entries[i], entries[j] = entries[j], entries[i]
+		entry.RawScore = entry.CurrentScore
+
+		// The page hash has the exact score as of its last update; the
+		// rank alone only gives it to within rounding
+		vals := cmds[i].Val()
+		if len(vals) == 3 {
+			raw, rawErr := strconv.ParseFloat(stringOrEmpty(vals[0]), 64)
+			last, lastErr := strconv.ParseInt(stringOrEmpty(vals[1]), 10, 64)
+			if rawErr == nil && lastErr == nil {
+				entry.RawScore = raw
+				entry.LastUpdated = last
+				entry.CurrentScore = raw * math.Exp2(-float64(now.Unix()-last)/(t.halfLifeMinutes*60))
 			}
+			entry.ServerURL = stringOrEmpty(vals[2])
 		}
+		entries = append(entries, entry)
 	}
-	
-	// Return only requested limit
-	if len(entries) > limit {
-		entries = entries[:limit]
-	}
-	
+
 	return entries, nil
 }
 
+func stringOrEmpty(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
 // GetTrendingRank returns the rank of a specific page (0-indexed, -1 if not found)
-func (t *TrendingScorer) GetTrendingRank(pageTitle string) (int, error) {
+func (t *TrendingScorer) GetTrendingRank(key models.PageKey) (int, error) {
 	ctx := context.Background()
-	globalKey := "trending:global"
 	
-	rank, err := t.redis.ZRevRank(ctx, globalKey, pageTitle).Result()
+	rank, err := t.redis.ZRevRank(ctx, trendingGlobalKey, key.String()).Result()
 	if err == redis.Nil {
 		return -1, nil // Page not found
 	}
@@ -318,9 +332,7 @@ func (t *TrendingScorer) GetTrendingRank(pageTitle string) (int, error) {
 // GetPageRank returns the trending rank for a page (compatibility method for indexing strategy)
 // Returns 1-based rank (like the old RedisTrending), or 0 if not found
 func (t *TrendingScorer) GetPageRank(ctx context.Context, wiki, title string) (int, error) {
-	// Create page key - in our new system we just use title, but keeping compatibility
-	pageTitle := title
-	rank, err := t.GetTrendingRank(pageTitle)
+	rank, err := t.GetTrendingRank(models.NewPageKey(wiki, title))
 	if err != nil {
 		return 0, err
 	}
@@ -330,30 +342,10 @@ func (t *TrendingScorer) GetPageRank(ctx context.Context, wiki, title string) (i
 	return rank + 1, nil // Convert to 1-based rank
 }
 
-// calculateIncrement determines score to add for an edit
-func (t *TrendingScorer) calculateIncrement(edit *models.WikipediaEdit) float64 {
-	baseScore := 1.0
-	
-	// Large edit bonus
-	byteChange := edit.ByteChange()
-	if byteChange < 0 {
-		byteChange = -byteChange
-	}
-	if byteChange > 1000 {
-		baseScore *= 1.5
-	}
-	
-	// Bot penalty
-	if edit.Bot {
-		baseScore *= 0.5
-	}
-	
-	// New page bonus
-	if edit.Type == "new" {
-		baseScore *= 2.0
-	}
-	
-	return baseScore
+// calculateIncrement determines score to add for an edit using the
+// configured trending model
+func (t *TrendingScorer) calculateIncrement(ctx context.Context, edit *models.WikipediaEdit) (float64, error) {
+	return t.model.Score(ctx, edit, t.timeProvider.Now())
 }
 
 // StartPruning starts background cleanup task
@@ -386,97 +378,114 @@ func (t *TrendingScorer) StartPruning() {
 	}()
 }
 
-// pruneTrendingSet removes dead/low-score entries
+// pruneTrendingSet drops pages whose score has decayed below 0.01 from
+// every leaderboard, with their page hashes, and then caps each leaderboard
+// at maxPages. It returns the number of leaderboard entries removed.
 func (t *TrendingScorer) pruneTrendingSet() (int, error) {
 	ctx := context.Background()
-	globalKey := "trending:global"
-	now := t.timeProvider.Now().Unix()
-	pruneCount := 0
-	
-	// Remove very low scores from global set
-	removed, err := t.redis.ZRemRangeByScore(ctx, globalKey, "-inf", "0.01").Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to remove low scores: %w", err)
-	}
-	pruneCount += int(removed)
-	
-	// Cap set to max pages
-	count, err := t.redis.ZCard(ctx, globalKey).Result()
+	cutoff := t.logScoreAt(0.01, t.timeProvider.Now())
+
+	sets, err := t.redis.SMembers(ctx, trendingSetsKey).Result()
 	if err != nil {
-		return pruneCount, fmt.Errorf("failed to get set size: %w", err)
-	}
-	
-	if count > int64(t.maxPages) {
-		toRemove := count - int64(t.maxPages)
-		removed, err := t.redis.ZRemRangeByRank(ctx, globalKey, 0, toRemove-1).Result()
-		if err != nil {
-			return pruneCount, fmt.Errorf("failed to cap set size: %w", err)
-		}
-		pruneCount += int(removed)
+		return 0, fmt.Errorf("failed to list trending sets: %w", err)
 	}
-	
-	// Clean up individual page keys for very old entries
-	pattern := "trending:*"
-	iter := t.redis.Scan(ctx, 0, pattern, 100).Iterator()
-	
-	for iter.Next(ctx) {
-		pageKey := iter.Val()
-		if pageKey == globalKey {
-			continue
-		}
-		
-		// Get page data
-		data, err := t.redis.HGetAll(ctx, pageKey).Result()
+	sets = append(sets, trendingGlobalKey)
+
+	pruned := 0
+	dead := make(map[string]struct{})
+	for _, set := range sets {
+		n, decayed, err := t.pruneSet(ctx, set, cutoff)
+		pruned += n
 		if err != nil {
-			continue
+			return pruned, err
 		}
-		
-		rawScoreStr, hasScore := data["raw_score"]
-		lastUpdatedStr, hasTime := data["last_updated"]
-		
-		if !hasScore || !hasTime {
-			// Invalid data, remove it
-			t.redis.Del(ctx, pageKey)
-			pruneCount++
-			continue
-		}
-		
-		rawScore, err := strconv.ParseFloat(rawScoreStr, 64)
-		if err != nil {
-			t.redis.Del(ctx, pageKey)
-			pruneCount++
-			continue
+		for _, member := range decayed {
+			dead[member] = struct{}{}
 		}
-		
-		lastUpdated, err := strconv.ParseInt(lastUpdatedStr, 10, 64)
+	}
+
+	// A page capped out of one leaderboard may still rank in another, so
+	// only decayed pages lose their hash; the rest expire
+	for member := range dead {
+		err := dropDecayedPageScript.Run(ctx, t.redis, []string{fmt.Sprintf("trending:%s", member)},
+			strconv.FormatFloat(cutoff, 'f', -1, 64), t.halfLifeMinutes*60).Err()
 		if err != nil {
-			t.redis.Del(ctx, pageKey)
-			pruneCount++
-			continue
-		}
-		
-		// Calculate current score
-		elapsedMinutes := float64(now-lastUpdated) / 60.0
-		decayFactor := math.Pow(0.5, elapsedMinutes/t.halfLifeMinutes)
-		currentScore := rawScore * decayFactor
-		
-		// Remove if score too low
-		if currentScore < 0.01 {
-			// Extract page title from key
-			pageTitle := pageKey[9:] // Remove "trending:" prefix
-			
-			// Remove from both places
-			t.redis.Del(ctx, pageKey)
-			t.redis.ZRem(ctx, globalKey, pageTitle)
-			pruneCount++
+			return pruned, fmt.Errorf("failed to delete pruned page hash: %w", err)
 		}
 	}
-	
-	if err := iter.Err(); err != nil {
-		return pruneCount, fmt.Errorf("scan error: %w", err)
+
+	return pruned, nil
+}
+
+// pruneSetScript removes the members of a leaderboard ranked below a cutoff,
+// then its lowest-ranked members beyond a cap, and unregisters the
+// leaderboard once empty. Running as one script, it cannot remove a page
+// that incrementScript has just lifted above the cutoff.
+//
+// KEYS[1] the leaderboard, KEYS[2] trending:sets
+// ARGV[1] cutoff rank (exclusive), ARGV[2] cap, ARGV[3] "1" to keep the
+// leaderboard registered when empty
+// Returns the number of members removed followed by the decayed ones.
+var pruneSetScript = redis.NewScript(`
+local dead = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+local removed = #dead
+if removed > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+end
+
+local count = redis.call('ZCARD', KEYS[1])
+local cap = tonumber(ARGV[2])
+if count > cap then
+	removed = removed + #redis.call('ZPOPMIN', KEYS[1], count - cap) / 2
+	count = cap
+end
+if count == 0 and ARGV[3] ~= '1' then
+	redis.call('SREM', KEYS[2], KEYS[1])
+end
+
+local result = {removed}
+for _, member in ipairs(dead) do
+	result[#result + 1] = member
+end
+return result
+`)
+
+// dropDecayedPageScript deletes a pruned page's hash unless an increment
+// has lifted its score back above the cutoff since it was pruned.
+//
+// KEYS[1] trending:{page}
+// ARGV[1] cutoff rank, ARGV[2] half-life (seconds)
+var dropDecayedPageScript = redis.NewScript(`
+local raw = tonumber(redis.call('HGET', KEYS[1], 'raw_score'))
+local last = tonumber(redis.call('HGET', KEYS[1], 'last_updated'))
+if raw and last and raw > 0 and
+	math.log(raw) / math.log(2) + last / tonumber(ARGV[2]) >= tonumber(ARGV[1]) then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// pruneSet prunes one leaderboard with pruneSetScript. It returns how many
+// members it removed and which of them had decayed.
+func (t *TrendingScorer) pruneSet(ctx context.Context, set string, cutoff float64) (int, []string, error) {
+	keepRegistered := "0"
+	if set == trendingGlobalKey {
+		keepRegistered = "1"
+	}
+	res, err := pruneSetScript.Run(ctx, t.redis, []string{set, trendingSetsKey},
+		strconv.FormatFloat(cutoff, 'f', -1, 64), t.maxPages, keepRegistered).Slice()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to prune %s: %w", set, err)
 	}
-	
-	return pruneCount, nil
+
+	removed, _ := res[0].(int64)
+	dead := make([]string, 0, len(res)-1)
+	for _, member := range res[1:] {
+		if m, ok := member.(string); ok {
+			dead = append(dead, m)
+		}
+	}
+	return int(removed), dead, nil
 }
 
 // ProcessEdit processes an edit and updates trending scores (for aggregator)
@@ -485,16 +494,15 @@ func (t *TrendingScorer) ProcessEdit(edit *models.WikipediaEdit) error {
 		return nil
 	}
 	
-	increment := t.calculateIncrement(edit)
-	if err := t.IncrementScore(edit.Title, increment); err != nil {
-		return err
+	if t.model == nil {
+		return t.modelErr
 	}
 
-	// Persist server_url so API can build correct wiki links for any language
-	if edit.ServerURL != "" {
-		pageKey := fmt.Sprintf("trending:%s", edit.Title)
-		_ = t.redis.HSetNX(context.Background(), pageKey, "server_url", edit.ServerURL).Err()
+	key := edit.PageKey()
+	increment, err := t.calculateIncrement(context.Background(), edit)
+	if err != nil {
+		return fmt.Errorf("failed to score edit with %s model: %w", t.model.Name(), err)
 	}
-
-	return nil
+	// Persist server_url so API can build correct wiki links for any language
+	return t.incrementScore(context.Background(), key, edit.Namespace, increment, edit.ServerURL)
 }
\ No newline at end of file
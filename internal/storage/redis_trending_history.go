@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// trendingHistoryLockPrefix claims one snapshot interval, so processors
+// sharing a Redis take each snapshot once.
+const trendingHistoryLockPrefix = "trendhist:lock:"
+
+// trendingHistoryKey is the sorted set of a wiki's leaderboard snapshots, or
+// the global leaderboard's for wiki "".
+func trendingHistoryKey(wiki string) string {
+	if wiki == "" {
+		return "trendhist:global"
+	}
+	return "trendhist:wiki:" + wiki
+}
+
+// trendingPeakKey is the hash of best ranks reached in a leaderboard.
+func trendingPeakKey(wiki string) string {
+	if wiki == "" {
+		return "trendhist:peak:global"
+	}
+	return "trendhist:peak:wiki:" + wiki
+}
+
+// TrendingSnapshot is the top of one leaderboard at a moment in the past.
+type TrendingSnapshot struct {
+	At    time.Time              `json:"at"`
+	Wiki  string                 `json:"wiki,omitempty"` // empty for the global leaderboard
+	Pages []TrendingSnapshotPage `json:"pages"`
+}
+
+// TrendingSnapshotPage is one page in a snapshot.
+type TrendingSnapshotPage struct {
+	Rank  int     `json:"rank"`
+	Title string  `json:"title"`
+	Wiki  string  `json:"wiki,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// Ranks maps each page in the snapshot to its rank.
+func (s *TrendingSnapshot) Ranks() map[models.PageKey]int {
+	ranks := make(map[models.PageKey]int, len(s.Pages))
+	for _, p := range s.Pages {
+		ranks[models.NewPageKey(p.Wiki, p.Title)] = p.Rank
+	}
+	return ranks
+}
+
+// snapshotEntry is how a page is stored in a snapshot; its rank is its
+// position.
+type snapshotEntry struct {
+	Page  string  `json:"p"` // {wiki}:{title}
+	Score float64 `json:"s"`
+}
+
+// TrendingMover is a page whose rank changed between a snapshot and now.
+type TrendingMover struct {
+	Title        string  `json:"title"`
+	Wiki         string  `json:"wiki,omitempty"`
+	Rank         int     `json:"rank"`
+	PreviousRank int     `json:"previous_rank,omitempty"` // 0 for new entries
+	RankChange   int     `json:"rank_change"`             // positive when rising
+	Score        float64 `json:"score"`
+}
+
+// TrendingMovers compares a leaderboard now with a snapshot of it.
+type TrendingMovers struct {
+	Wiki    string          `json:"wiki,omitempty"`
+	Since   time.Time       `json:"since"` // when the snapshot compared against was taken
+	Rising  []TrendingMover `json:"rising"`
+	Falling []TrendingMover `json:"falling"`
+	New     []TrendingMover `json:"new"` // pages that were not in the snapshot
+}
+
+// CompareTrending reports how the pages in current moved since prev. Pages
+// ranked in both are rising or falling, biggest moves first; the rest are
+// new, best ranked first.
+func CompareTrending(prev *TrendingSnapshot, current []*TrendingEntry) *TrendingMovers {
+	movers := &TrendingMovers{
+		Wiki:    prev.Wiki,
+		Since:   prev.At,
+		Rising:  []TrendingMover{},
+		Falling: []TrendingMover{},
+		New:     []TrendingMover{},
+	}
+	before := prev.Ranks()
+	for i, e := range current {
+		m := TrendingMover{Title: e.PageTitle, Wiki: e.Wiki, Rank: i + 1, Score: e.CurrentScore}
+		rank, ok := before[models.NewPageKey(e.Wiki, e.PageTitle)]
+		switch {
+		case !ok:
+			movers.New = append(movers.New, m)
+		case rank > m.Rank:
+			m.PreviousRank, m.RankChange = rank, rank-m.Rank
+			movers.Rising = append(movers.Rising, m)
+		case rank < m.Rank:
+			m.PreviousRank, m.RankChange = rank, rank-m.Rank
+			movers.Falling = append(movers.Falling, m)
+		}
+	}
+	sort.SliceStable(movers.Rising, func(i, j int) bool { return movers.Rising[i].RankChange > movers.Rising[j].RankChange })
+	sort.SliceStable(movers.Falling, func(i, j int) bool { return movers.Falling[i].RankChange < movers.Falling[j].RankChange })
+	return movers
+}
+
+// TrendingHistory keeps periodic snapshots of the top of the global and
+// per-wiki trending leaderboards, and the best rank each page has reached
+// in them.
+//
+// A leaderboard's snapshots are one sorted set scored by snapshot time, one
+// member per snapshot holding its pages in rank order, trimmed to the
+// retention as new ones are added. Peak ranks are a hash per leaderboard of
+// "rank:unix" by page.
+type TrendingHistory struct {
+	client *redis.Client
+	cfg    config.TrendingHistoryConfig
+}
+
+// NewTrendingHistory creates a new trending history store
+func NewTrendingHistory(client *redis.Client, cfg config.TrendingHistoryConfig) *TrendingHistory {
+	return &TrendingHistory{client: client, cfg: cfg}
+}
+
+// Snapshot records the top of the global leaderboard and of every wiki's as
+// scorer ranks them at at. Snapshots are aligned to the interval, and an
+// interval someone has already snapshotted is skipped. If the snapshot fails
+// the interval is released for the next attempt. It returns the number of
+// leaderboards recorded.
+func (h *TrendingHistory) Snapshot(ctx context.Context, scorer *TrendingScorer, at time.Time) (recorded int, err error) {
+	slot := at.Truncate(h.cfg.Interval)
+	lockKey := trendingHistoryLockPrefix + strconv.FormatInt(slot.Unix(), 10)
+	claimed, err := h.client.SetNX(ctx, lockKey, 1, h.cfg.Interval).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim trending snapshot: %w", err)
+	}
+	if !claimed {
+		return 0, nil
+	}
+	defer func() {
+		if err != nil {
+			h.client.Del(context.WithoutCancel(ctx), lockKey)
+		}
+	}()
+
+	sets, err := h.client.SMembers(ctx, trendingSetsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list trending sets: %w", err)
+	}
+	wikis := []string{""}
+	for _, set := range sets {
+		if wiki, ok := strings.CutPrefix(set, "trending:wiki:"); ok {
+			wikis = append(wikis, wiki)
+		}
+	}
+
+	pipe := h.client.Pipeline()
+	tops := make([]*redis.ZSliceCmd, len(wikis))
+	peaks := make([]*redis.MapStringStringCmd, len(wikis))
+	for i, wiki := range wikis {
+		tops[i] = pipe.ZRevRangeWithScores(ctx, TrendingScope{Wiki: wiki}.key(), 0, int64(h.cfg.TopN-1))
+		peaks[i] = pipe.HGetAll(ctx, trendingPeakKey(wiki))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to read trending leaderboards: %w", err)
+	}
+
+	// All or nothing, so a retry of the interval does not add to half of it
+	cutoff := slot.Add(-h.cfg.Retention).Unix()
+	pipe = h.client.TxPipeline()
+	for i, wiki := range wikis {
+		top := tops[i].Val()
+		if len(top) == 0 {
+			continue
+		}
+
+		entries := make([]snapshotEntry, len(top))
+		for j, z := range top {
+			score := scorer.scoreAt(z.Score, at)
+			entries[j] = snapshotEntry{Page: z.Member.(string), Score: math.Round(score*100) / 100}
+		}
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode trending snapshot: %w", err)
+		}
+
+		key := trendingHistoryKey(wiki)
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(slot.Unix()), Member: fmt.Sprintf("%d|%s", slot.Unix(), data)})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", cutoff))
+		pipe.Expire(ctx, key, h.cfg.Retention)
+		h.updatePeaks(ctx, pipe, trendingPeakKey(wiki), entries, peaks[i].Val(), slot.Unix(), cutoff)
+		recorded++
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to store trending snapshots: %w", err)
+	}
+	return recorded, nil
+}
+
+// updatePeaks queues the peak rank changes for one leaderboard: a page's
+// peak is replaced when it ranks at least as well now or its peak is older
+// than the retention, and expired peaks of pages no longer ranked are
+// dropped.
+func (h *TrendingHistory) updatePeaks(ctx context.Context, pipe redis.Pipeliner, key string, entries []snapshotEntry, peaks map[string]string, now, cutoff int64) {
+	ranked := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		ranked[e.Page] = true
+		rank, at, ok := parsePeak(peaks[e.Page])
+		if !ok || i+1 <= rank || at < cutoff {
+			pipe.HSet(ctx, key, e.Page, fmt.Sprintf("%d:%d", i+1, now))
+		}
+	}
+	for page, v := range peaks {
+		if _, at, ok := parsePeak(v); !ranked[page] && (!ok || at < cutoff) {
+			pipe.HDel(ctx, key, page)
+		}
+	}
+	pipe.Expire(ctx, key, h.cfg.Retention)
+}
+
+func parsePeak(v string) (rank int, at int64, ok bool) {
+	r, a, found := strings.Cut(v, ":")
+	if !found {
+		return 0, 0, false
+	}
+	rank, rankErr := strconv.Atoi(r)
+	at, atErr := strconv.ParseInt(a, 10, 64)
+	return rank, at, rankErr == nil && atErr == nil
+}
+
+// At returns the latest snapshot of a wiki's leaderboard, or of the global
+// one for wiki "", taken at or before at, or the latest of all for a zero
+// at. It returns nil if there is none.
+//
+// Snapshots are stamped by the trending scorer's clock, which follows edit
+// time. Callers asking about "now" should start from the latest snapshot
+// rather than the wall clock, which is ahead of it during a replay or
+// while the processor catches up.
+func (h *TrendingHistory) At(ctx context.Context, wiki string, at time.Time) (*TrendingSnapshot, error) {
+	upper := "+inf"
+	if !at.IsZero() {
+		upper = strconv.FormatInt(at.Unix(), 10)
+	}
+	members, err := h.client.ZRevRangeByScore(ctx, trendingHistoryKey(wiki), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   upper,
+		Count: 1,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending snapshot: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	ts, data, _ := strings.Cut(members[0], "|")
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed trending snapshot time %q", ts)
+	}
+	var entries []snapshotEntry
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode trending snapshot: %w", err)
+	}
+
+	snap := &TrendingSnapshot{At: time.Unix(unix, 0).UTC(), Wiki: wiki, Pages: make([]TrendingSnapshotPage, len(entries))}
+	for i, e := range entries {
+		key := models.ParsePageKey(e.Page)
+		snap.Pages[i] = TrendingSnapshotPage{Rank: i + 1, Title: key.Title, Wiki: key.Wiki, Score: e.Score}
+	}
+	return snap, nil
+}
+
+// PeakRanks returns the best rank each page has reached in a wiki's
+// leaderboard snapshots, or the global one's for wiki "", within the
+// retention before now.
+func (h *TrendingHistory) PeakRanks(ctx context.Context, wiki string, now time.Time) (map[models.PageKey]int, error) {
+	peaks, err := h.client.HGetAll(ctx, trendingPeakKey(wiki)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get peak trending ranks: %w", err)
+	}
+	cutoff := now.Add(-h.cfg.Retention).Unix()
+	ranks := make(map[models.PageKey]int, len(peaks))
+	for page, v := range peaks {
+		if rank, at, ok := parsePeak(v); ok && at >= cutoff {
+			ranks[models.ParsePageKey(page)] = rank
+		}
+	}
+	return ranks, nil
+}
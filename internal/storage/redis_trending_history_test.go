@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendingHistory_Snapshots(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	ctx := context.Background()
+
+	start := time.Date(2026, 6, 1, 15, 0, 0, 0, time.UTC)
+	clock := &MockTimeProvider{currentTime: start}
+	scorer.SetTimeProvider(clock)
+	history := NewTrendingHistory(scorer.redis, config.TrendingHistoryConfig{
+		Enabled: true, Interval: 5 * time.Minute, Retention: time.Hour, TopN: 3,
+	})
+
+	bump := func(wiki, title string, score float64) {
+		t.Helper()
+		require.NoError(t, scorer.IncrementScore(models.NewPageKey(wiki, title), score))
+	}
+	bump("enwiki", "Alpha", 40)
+	bump("enwiki", "Beta", 20)
+	bump("enwiki", "Gamma", 10)
+	bump("enwiki", "Delta", 5)
+	bump("fiwiki", "Helsinki", 3)
+
+	n, err := history.Snapshot(ctx, scorer, start)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n, "global, enwiki and fiwiki")
+	n, err = history.Snapshot(ctx, scorer, start.Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n, "interval already snapshotted")
+
+	// Gamma takes the lead and Delta enters the top 3 behind Alpha
+	clock.SetTime(start.Add(5 * time.Minute))
+	bump("enwiki", "Gamma", 100)
+	bump("enwiki", "Delta", 30)
+	_, err = history.Snapshot(ctx, scorer, clock.Now())
+	require.NoError(t, err)
+
+	// "What was trending at 3:02" is the 3:00 snapshot
+	snap, err := history.At(ctx, "enwiki", start.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	assert.Equal(t, start, snap.At)
+	require.Len(t, snap.Pages, 3)
+	assert.Equal(t, TrendingSnapshotPage{Rank: 1, Title: "Alpha", Wiki: "enwiki", Score: 40}, snap.Pages[0])
+	assert.Equal(t, "Gamma", snap.Pages[2].Title)
+
+	later, err := history.At(ctx, "enwiki", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Gamma", later.Pages[0].Title)
+
+	fi, err := history.At(ctx, "fiwiki", start)
+	require.NoError(t, err)
+	require.Len(t, fi.Pages, 1)
+	assert.Equal(t, "Helsinki", fi.Pages[0].Title)
+
+	none, err := history.At(ctx, "", start.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	peaks, err := history.PeakRanks(ctx, "enwiki", clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, peaks[models.NewPageKey("enwiki", "Alpha")], "kept from the first snapshot")
+	assert.Equal(t, 1, peaks[models.NewPageKey("enwiki", "Gamma")])
+	assert.Equal(t, 3, peaks[models.NewPageKey("enwiki", "Delta")])
+
+	current, err := scorer.GetTopTrendingIn(TrendingScope{Wiki: "enwiki"}, 4)
+	require.NoError(t, err)
+	movers := CompareTrending(snap, current)
+	require.Len(t, movers.Rising, 1)
+	assert.Equal(t, TrendingMover{Title: "Gamma", Wiki: "enwiki", Rank: 1, PreviousRank: 3, RankChange: 2, Score: current[0].CurrentScore}, movers.Rising[0])
+	require.Len(t, movers.Falling, 2)
+	assert.Equal(t, "Beta", movers.Falling[0].Title, "biggest drop first")
+	assert.Equal(t, -2, movers.Falling[0].RankChange)
+	assert.Equal(t, "Alpha", movers.Falling[1].Title)
+	require.Len(t, movers.New, 1)
+	assert.Equal(t, "Delta", movers.New[0].Title)
+
+	// Snapshots and peaks older than the retention are dropped
+	clock.SetTime(start.Add(2 * time.Hour))
+	bump("enwiki", "Epsilon", 1000)
+	_, err = history.Snapshot(ctx, scorer, clock.Now())
+	require.NoError(t, err)
+	old, err := history.At(ctx, "enwiki", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, old)
+	peaks, err = history.PeakRanks(ctx, "enwiki", clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, map[models.PageKey]int{
+		models.NewPageKey("enwiki", "Epsilon"): 1,
+		models.NewPageKey("enwiki", "Gamma"):   2,
+		models.NewPageKey("enwiki", "Alpha"):   3,
+	}, peaks)
+}
+
+func TestTrendingHistory_FailedSnapshotReleasesInterval(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	ctx := context.Background()
+
+	start := time.Date(2026, 6, 1, 15, 0, 0, 0, time.UTC)
+	scorer.SetTimeProvider(&MockTimeProvider{currentTime: start})
+	history := NewTrendingHistory(scorer.redis, config.TrendingHistoryConfig{
+		Enabled: true, Interval: 5 * time.Minute, Retention: time.Hour, TopN: 3,
+	})
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Alpha"), 10))
+
+	// The leaderboard registry cannot be read, so the snapshot fails...
+	members, err := mr.Members(trendingSetsKey)
+	require.NoError(t, err)
+	mr.Del(trendingSetsKey)
+	require.NoError(t, mr.Set(trendingSetsKey, "not a set"))
+	_, err = history.Snapshot(ctx, scorer, start)
+	require.Error(t, err)
+
+	// ...and the next attempt in the same interval takes it
+	mr.Del(trendingSetsKey)
+	_, err = mr.SetAdd(trendingSetsKey, members...)
+	require.NoError(t, err)
+	n, err := history.Snapshot(ctx, scorer, start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "global and enwiki")
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+)
+
+// trendingSetsKey lists the leaderboards currently holding pages, so the
+// pruner can find them without scanning.
+const trendingSetsKey = "trending:sets"
+
+// noNamespace marks an increment whose page namespace is unknown.
+const noNamespace = -1
+
+// trendingSetPrefixes are the key families of the scoped leaderboards.
+var trendingSetPrefixes = []string{
+	"trending:wiki:",
+	"trending:lang:",
+	"trending:project:",
+	"trending:ns:",
+}
+
+// ErrTrendingScopeDisabled is returned when asking for a project or
+// namespace leaderboard the deployment does not maintain.
+var ErrTrendingScopeDisabled = errors.New("trending leaderboard is not enabled")
+
+// TrendingScope selects a trending leaderboard. The zero value is the global
+// one; otherwise exactly one field should be set. Wiki and language
+// leaderboards are always kept, project and namespace ones only when
+// enabled in the trending config.
+type TrendingScope struct {
+	Wiki      string // wiki database name, e.g. "fiwiki"
+	Language  string // language code across projects, e.g. "fi"
+	Project   string // project family across languages, e.g. "wiktionary"
+	Namespace *int   // namespace number across wikis
+}
+
+// key returns the sorted set holding the scope's leaderboard.
+func (s TrendingScope) key() string {
+	switch {
+	case s.Wiki != "":
+		return "trending:wiki:" + s.Wiki
+	case s.Language != "":
+		return "trending:lang:" + s.Language
+	case s.Project != "":
+		return "trending:project:" + s.Project
+	case s.Namespace != nil:
+		return "trending:ns:" + strconv.Itoa(*s.Namespace)
+	}
+	return trendingGlobalKey
+}
+
+// setsFor returns every leaderboard a page belongs to, global first. Pages
+// without a wiki, left over from before keys were wiki-qualified, are only
+// ranked globally.
+func (t *TrendingScorer) setsFor(key models.PageKey, ns int) []string {
+	sets := []string{trendingGlobalKey}
+	if key.Wiki == "" {
+		return sets
+	}
+
+	project, lang := models.ParseWikiDBName(key.Wiki)
+	sets = append(sets, TrendingScope{Wiki: key.Wiki}.key())
+	if lang != "" {
+		sets = append(sets, TrendingScope{Language: lang}.key())
+	}
+	if t.config.ProjectSets && project != "" {
+		sets = append(sets, TrendingScope{Project: project}.key())
+	}
+	if t.config.NamespaceSets && ns != noNamespace {
+		sets = append(sets, TrendingScope{Namespace: &ns}.key())
+	}
+	return sets
+}
+
+// isTrendingSetKey reports whether a "trending:" key is a leaderboard or the
+// leaderboard registry rather than a page hash.
+func isTrendingSetKey(key string) bool {
+	if key == trendingGlobalKey || key == trendingSetsKey {
+		return true
+	}
+	for _, prefix := range trendingSetPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
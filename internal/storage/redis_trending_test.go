@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -70,7 +71,7 @@ func TestTrendingScorer_IncrementScore(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := scorer.IncrementScore(tt.pageTitle, tt.increment)
+			err := scorer.IncrementScore(models.NewPageKey("enwiki", tt.pageTitle), tt.increment)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -101,14 +102,14 @@ func TestTrendingScorer_LazyDecay(t *testing.T) {
 	scorer.timeProvider = mockTime
 	
 	// Add initial score
-	err := scorer.IncrementScore("Test Page", 100.0)
+	err := scorer.IncrementScore(models.NewPageKey("enwiki", "Test Page"), 100.0)
 	require.NoError(t, err)
 	
 	// Simulate 30 minutes passing (one half-life)
 	mockTime.FastForward(30 * time.Minute)
 	
 	// Add another increment - this should trigger lazy decay
-	err = scorer.IncrementScore("Test Page", 10.0)
+	err = scorer.IncrementScore(models.NewPageKey("enwiki", "Test Page"), 10.0)
 	require.NoError(t, err)
 	
 	// Get the page 
@@ -144,13 +145,13 @@ func TestTrendingScorer_GetTopTrending(t *testing.T) {
 	baseTime := time.Now().Unix()
 	
 	for _, p := range pages {
-		err := scorer.IncrementScore(p.title, p.score)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", p.title), p.score)
 		require.NoError(t, err)
 		
 		// Manually adjust the timestamp if needed
 		if p.delay > 0 {
 			adjustedTime := baseTime - int64(p.delay.Seconds())
-			pageKey := fmt.Sprintf("trending:%s", p.title)
+			pageKey := fmt.Sprintf("trending:enwiki:%s", p.title)
 			err = scorer.redis.HSet(context.Background(), pageKey, "last_updated", adjustedTime).Err()
 			require.NoError(t, err)
 		}
@@ -191,7 +192,7 @@ func TestTrendingScorer_GetTrendingRank(t *testing.T) {
 	}
 	
 	for _, p := range pages {
-		err := scorer.IncrementScore(p.title, p.score)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", p.title), p.score)
 		require.NoError(t, err)
 	}
 	
@@ -208,7 +209,7 @@ func TestTrendingScorer_GetTrendingRank(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rank, err := scorer.GetTrendingRank(tt.pageTitle)
+			rank, err := scorer.GetTrendingRank(models.NewPageKey("enwiki", tt.pageTitle))
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedRank, rank)
 		})
@@ -294,7 +295,8 @@ func TestTrendingScorer_CalculateIncrement(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := scorer.calculateIncrement(tt.edit)
+			result, err := scorer.calculateIncrement(context.Background(), tt.edit)
+			require.NoError(t, err)
 			assert.InDelta(t, tt.expected, result, 0.01)
 		})
 	}
@@ -307,6 +309,7 @@ func TestTrendingScorer_ProcessEdit(t *testing.T) {
 	
 	edit := &models.WikipediaEdit{
 		Title: "Test Page",
+		Wiki:  "enwiki",
 		Type:  "edit",
 		Bot:   false,
 		User:  "TestUser",
@@ -325,9 +328,31 @@ func TestTrendingScorer_ProcessEdit(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 1)
 	assert.Equal(t, "Test Page", entries[0].PageTitle)
+	assert.Equal(t, "enwiki", entries[0].Wiki)
 	assert.Equal(t, 1.0, entries[0].CurrentScore)
 }
 
+func TestTrendingScorer_SameTitleOnDifferentWikis(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Paris"), 3.0))
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("frwiki", "Paris"), 1.0))
+
+	entries, err := scorer.GetTopTrending(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "enwiki", entries[0].Wiki)
+	assert.Equal(t, 3.0, entries[0].CurrentScore)
+	assert.Equal(t, "frwiki", entries[1].Wiki)
+	assert.Equal(t, 1.0, entries[1].CurrentScore)
+
+	rank, err := scorer.GetPageRank(context.Background(), "frwiki", "Paris")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rank)
+}
+
 func TestTrendingScorer_PruneTrendingSet(t *testing.T) {
 	scorer, mr := setupTestTrendingScorer(t)
 	defer mr.Close()
@@ -340,7 +365,7 @@ func TestTrendingScorer_PruneTrendingSet(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		title := fmt.Sprintf("Page %d", i)
 		score := float64(i + 1)
-		err := scorer.IncrementScore(title, score)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", title), score)
 		require.NoError(t, err)
 	}
 	
@@ -377,12 +402,12 @@ func TestTrendingScorer_LazyDecayOptimization(t *testing.T) {
 	scorer.timeProvider = mockTime
 	
 	// Add score at T=0
-	err := scorer.IncrementScore("Test Page", 100.0)
+	err := scorer.IncrementScore(models.NewPageKey("enwiki", "Test Page"), 100.0)
 	require.NoError(t, err)
 	
 	// Verify raw score is stored correctly
 	ctx := context.Background()
-	rawScore, err := scorer.redis.HGet(ctx, "trending:Test Page", "raw_score").Result()
+	rawScore, err := scorer.redis.HGet(ctx, "trending:enwiki:Test Page", "raw_score").Result()
 	require.NoError(t, err)
 	assert.Equal(t, "100", rawScore)
 	
@@ -390,7 +415,7 @@ func TestTrendingScorer_LazyDecayOptimization(t *testing.T) {
 	mockTime.FastForward(30 * time.Minute)
 	
 	// Raw score should still be 100 (no decay applied yet)
-	rawScore, err = scorer.redis.HGet(ctx, "trending:Test Page", "raw_score").Result()
+	rawScore, err = scorer.redis.HGet(ctx, "trending:enwiki:Test Page", "raw_score").Result()
 	require.NoError(t, err)
 	assert.Equal(t, "100", rawScore) // Still 100 - lazy decay
 	
@@ -404,11 +429,11 @@ func TestTrendingScorer_LazyDecayOptimization(t *testing.T) {
 	assert.Equal(t, 100.0, entries[0].RawScore)            // Still original
 	
 	// Now update the score - this should apply decay to raw score
-	err = scorer.IncrementScore("Test Page", 1.0)
+	err = scorer.IncrementScore(models.NewPageKey("enwiki", "Test Page"), 1.0)
 	require.NoError(t, err)
 	
 	// Raw score should now be decayed + increment
-	rawScore, err = scorer.redis.HGet(ctx, "trending:Test Page", "raw_score").Result()
+	rawScore, err = scorer.redis.HGet(ctx, "trending:enwiki:Test Page", "raw_score").Result()
 	require.NoError(t, err)
 	
 	// Should be approximately 50 + 1 = 51
@@ -423,22 +448,182 @@ func TestTrendingScorer_PageKeyTTL_8Days(t *testing.T) {
 	defer scorer.Stop()
 
 	// Increment to create the page key
-	err := scorer.IncrementScore("LongLived Page", 5.0)
+	err := scorer.IncrementScore(models.NewPageKey("enwiki", "LongLived Page"), 5.0)
 	require.NoError(t, err)
 
 	// Verify key exists
 	ctx := context.Background()
-	exists, err := scorer.redis.Exists(ctx, "trending:LongLived Page").Result()
+	exists, err := scorer.redis.Exists(ctx, "trending:enwiki:LongLived Page").Result()
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), exists, "page key should exist")
 
 	// Fast-forward 7 days — key should still exist (TTL is 8 days)
 	mr.FastForward(7 * 24 * time.Hour)
-	exists, _ = scorer.redis.Exists(ctx, "trending:LongLived Page").Result()
+	exists, _ = scorer.redis.Exists(ctx, "trending:enwiki:LongLived Page").Result()
 	assert.Equal(t, int64(1), exists, "page key should survive 7 days (TTL is 8)")
 
 	// Fast-forward past 8 days total (1 more day + buffer)
 	mr.FastForward(2 * 24 * time.Hour)
-	exists, _ = scorer.redis.Exists(ctx, "trending:LongLived Page").Result()
+	exists, _ = scorer.redis.Exists(ctx, "trending:enwiki:LongLived Page").Result()
 	assert.Equal(t, int64(0), exists, "page key should expire after 8+ days")
-}
\ No newline at end of file
+}
+func TestTrendingScorer_ConcurrentIncrements(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	scorer.timeProvider = &MockTimeProvider{currentTime: time.Now()}
+
+	// Every update lands even when processors race on one page
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				assert.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Contended"), 1.0))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := scorer.GetTopTrending(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 400.0, entries[0].CurrentScore)
+}
+
+func TestTrendingScorer_LogSpaceRanking(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	mockTime := &MockTimeProvider{currentTime: time.Now()}
+	scorer.timeProvider = mockTime
+
+	// 40 an hour ago has decayed to 10, below a fresh 12
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Yesterday's news"), 40.0))
+	mockTime.FastForward(time.Hour)
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Breaking"), 12.0))
+
+	entries, err := scorer.GetTopTrending(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Breaking", entries[0].PageTitle)
+	assert.InDelta(t, 10.0, entries[1].CurrentScore, 0.001)
+
+	rank, err := scorer.GetTrendingRank(models.NewPageKey("enwiki", "Yesterday's news"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, rank)
+
+	// An edit stamped before the page's last update is decayed to that time
+	// rather than rewinding the clock
+	mockTime.currentTime = mockTime.currentTime.Add(-30 * time.Minute)
+	require.NoError(t, scorer.IncrementScore(models.NewPageKey("enwiki", "Breaking"), 8.0))
+	mockTime.FastForward(30 * time.Minute)
+	entries, err = scorer.GetTopTrending(1)
+	require.NoError(t, err)
+	assert.InDelta(t, 16.0, entries[0].CurrentScore, 0.001)
+
+	// Decay never reorders the set, and the pruner drops what fell below 0.01
+	mockTime.FastForward(5 * time.Hour)
+	count, err := scorer.pruneTrendingSet()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "global, enwiki and en leaderboards")
+	entries, err = scorer.GetTopTrending(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Breaking", entries[0].PageTitle)
+	exists, err := scorer.redis.Exists(context.Background(), "trending:enwiki:Yesterday's news").Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+}
+
+func TestTrendingScorer_ScopedLeaderboards(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	scorer.config.NamespaceSets = true
+	scorer.maxPages = 3
+
+	edit := func(wiki, title string, ns int, score float64) {
+		t.Helper()
+		e := &models.WikipediaEdit{Wiki: wiki, Title: title, Namespace: ns, Type: "new"}
+		for i := 0; i < int(score); i++ {
+			require.NoError(t, scorer.ProcessEdit(e))
+		}
+	}
+	// enwiki is busy enough to fill the global top 3; fiwiki is not
+	for i := 0; i < 5; i++ {
+		edit("enwiki", fmt.Sprintf("Big %d", i), 0, float64(10+i))
+	}
+	edit("fiwiki", "Helsinki", 0, 3)
+	edit("fiwiki", "Keskustelu:Helsinki", 1, 2)
+	edit("fiwiktionary", "kissa", 0, 1)
+
+	titles := func(scope TrendingScope, limit int) []string {
+		t.Helper()
+		entries, err := scorer.GetTopTrendingIn(scope, limit)
+		require.NoError(t, err)
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.PageTitle)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Big 4", "Big 3", "Big 2"}, titles(TrendingScope{}, 3))
+	assert.Equal(t, []string{"Helsinki", "Keskustelu:Helsinki"}, titles(TrendingScope{Wiki: "fiwiki"}, 10))
+	assert.Equal(t, []string{"Helsinki", "Keskustelu:Helsinki", "kissa"}, titles(TrendingScope{Language: "fi"}, 10))
+	talk := 1
+	assert.Equal(t, []string{"Keskustelu:Helsinki"}, titles(TrendingScope{Namespace: &talk}, 10))
+
+	_, err := scorer.GetTopTrendingIn(TrendingScope{Project: "wiktionary"}, 10)
+	assert.ErrorIs(t, err, ErrTrendingScopeDisabled)
+
+	// Pruning caps every leaderboard without dropping fiwiki pages the
+	// global cap pushed out
+	_, err = scorer.pruneTrendingSet()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Big 4", "Big 3", "Big 2"}, titles(TrendingScope{}, 10))
+	assert.Equal(t, []string{"Helsinki", "Keskustelu:Helsinki"}, titles(TrendingScope{Wiki: "fiwiki"}, 10))
+	entries, err := scorer.GetTopTrendingIn(TrendingScope{Wiki: "fiwiki"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 6.0, entries[0].CurrentScore, "page hash kept")
+
+	// Decayed pages leave every leaderboard, and empty ones are unregistered
+	scorer.timeProvider = &MockTimeProvider{currentTime: time.Now().Add(10 * time.Hour)}
+	_, err = scorer.pruneTrendingSet()
+	require.NoError(t, err)
+	assert.Empty(t, titles(TrendingScope{Wiki: "fiwiki"}, 10))
+	sets, err := scorer.redis.SMembers(context.Background(), trendingSetsKey).Result()
+	require.NoError(t, err)
+	assert.Empty(t, sets)
+}
+
+func TestTrendingScorer_PruneKeepsRevivedPages(t *testing.T) {
+	scorer, mr := setupTestTrendingScorer(t)
+	defer mr.Close()
+	defer scorer.Stop()
+	mockTime := &MockTimeProvider{currentTime: time.Now()}
+	scorer.timeProvider = mockTime
+	ctx := context.Background()
+	page := models.NewPageKey("enwiki", "Comeback")
+
+	require.NoError(t, scorer.IncrementScore(page, 5.0))
+	mockTime.FastForward(10 * time.Hour)
+
+	// The leaderboards are pruned, then an edit revives the page before its
+	// hash is dropped
+	cutoff := scorer.logScoreAt(0.01, mockTime.Now())
+	_, dead, err := scorer.pruneSet(ctx, trendingGlobalKey, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, []string{page.String()}, dead)
+	require.NoError(t, scorer.IncrementScore(page, 3.0))
+	require.NoError(t, dropDecayedPageScript.Run(ctx, scorer.redis, []string{"trending:" + page.String()},
+		strconv.FormatFloat(cutoff, 'f', -1, 64), scorer.halfLifeMinutes*60).Err())
+
+	entries, err := scorer.GetTopTrending(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Comeback", entries[0].PageTitle)
+	assert.InDelta(t, 3.0, entries[0].CurrentScore, 0.01)
+	assert.True(t, mr.Exists("trending:"+page.String()), "revived page keeps its hash")
+}
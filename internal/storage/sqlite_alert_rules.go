@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Agnikulu/WikiSurge/internal/models"
+)
+
+// alertRuleColumns lists the columns scanAlertRules expects, in order.
+const alertRuleColumns = `id, user_id, name, expression, enabled, created_at, updated_at`
+
+// CreateAlertRule adds a rule for a user. The expression is stored as given;
+// callers validate it with models.ParseRule first.
+func (s *UserStore) CreateAlertRule(userID, name, expression string, enabled bool) (*models.AlertRule, error) {
+	now := time.Now().UTC()
+	rule := &models.AlertRule{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Name:       name,
+		Expression: expression,
+		Enabled:    enabled,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO alert_rules (`+alertRuleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rule.ID, rule.UserID, rule.Name, rule.Expression, boolToInt(rule.Enabled),
+		rule.CreatedAt.Format(time.RFC3339), rule.UpdatedAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// GetAlertRule fetches one of a user's rules. Returns nil, nil if the user
+// has no rule with that ID.
+func (s *UserStore) GetAlertRule(userID, ruleID string) (*models.AlertRule, error) {
+	rules, err := s.queryAlertRules(`SELECT `+alertRuleColumns+` FROM alert_rules
+		WHERE id = ? AND user_id = ?`, ruleID, userID)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return rules[0], nil
+}
+
+// ListAlertRules returns a user's rules, oldest first.
+func (s *UserStore) ListAlertRules(userID string) ([]*models.AlertRule, error) {
+	return s.queryAlertRules(`SELECT `+alertRuleColumns+` FROM alert_rules
+		WHERE user_id = ? ORDER BY created_at, rowid`, userID)
+}
+
+// ListEnabledAlertRules returns every user's enabled rules, for evaluation.
+func (s *UserStore) ListEnabledAlertRules() ([]*models.AlertRule, error) {
+	return s.queryAlertRules(`SELECT ` + alertRuleColumns + ` FROM alert_rules
+		WHERE enabled = 1 ORDER BY user_id, created_at, rowid`)
+}
+
+// UpdateAlertRule saves a rule's name, expression and enabled flag.
+func (s *UserStore) UpdateAlertRule(rule *models.AlertRule) error {
+	rule.UpdatedAt = time.Now().UTC()
+	result, err := s.db.Exec(`
+		UPDATE alert_rules SET name = ?, expression = ?, enabled = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`,
+		rule.Name, rule.Expression, boolToInt(rule.Enabled), rule.UpdatedAt.Format(time.RFC3339),
+		rule.ID, rule.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("update alert rule: %w", err)
+	}
+	return checkRowsAffected(result, "alert rule not found")
+}
+
+// DeleteAlertRule removes one of a user's rules.
+func (s *UserStore) DeleteAlertRule(userID, ruleID string) error {
+	result, err := s.db.Exec(`DELETE FROM alert_rules WHERE id = ? AND user_id = ?`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("delete alert rule: %w", err)
+	}
+	return checkRowsAffected(result, "alert rule not found")
+}
+
+func (s *UserStore) queryAlertRules(query string, args ...interface{}) ([]*models.AlertRule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*models.AlertRule{}
+	for rows.Next() {
+		r, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func scanAlertRule(rows *sql.Rows) (*models.AlertRule, error) {
+	r := &models.AlertRule{}
+	var enabled int
+	var createdAt, updatedAt string
+
+	err := rows.Scan(&r.ID, &r.UserID, &r.Name, &r.Expression, &enabled, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("scan alert rule: %w", err)
+	}
+
+	r.Enabled = enabled == 1
+	r.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	r.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return r, nil
+}
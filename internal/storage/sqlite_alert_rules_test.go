@@ -0,0 +1,76 @@
+package storage
+
+import "testing"
+
+func TestAlertRules(t *testing.T) {
+	store := newTestUserStore(t)
+
+	alice, _ := store.CreateUser("alice@example.com", "pw")
+	bob, _ := store.CreateUser("bob@example.com", "pw")
+
+	election, err := store.CreateAlertRule(alice.ID, "Elections", `title ~ "election"`, true)
+	if err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	if _, err := store.CreateAlertRule(alice.ID, "Blanking", "watched and bytes < -5000", false); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	if _, err := store.CreateAlertRule(bob.ID, "Bots", "bot = true", true); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	if _, err := store.CreateAlertRule("no-such-user", "Orphan", "new", true); err == nil {
+		t.Error("expected error creating a rule for a missing user")
+	}
+
+	rules, err := store.ListAlertRules(alice.ID)
+	if err != nil {
+		t.Fatalf("ListAlertRules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Name != "Elections" || rules[1].Enabled {
+		t.Errorf("ListAlertRules = %+v, want Elections then disabled Blanking", rules)
+	}
+
+	// Rules are only visible to their owner
+	if got, _ := store.GetAlertRule(bob.ID, election.ID); got != nil {
+		t.Error("expected nil fetching another user's rule")
+	}
+	if err := store.DeleteAlertRule(bob.ID, election.ID); err == nil {
+		t.Error("expected error deleting another user's rule")
+	}
+
+	election.Expression = `wiki = enwiki and title ~ "election"`
+	election.Enabled = false
+	if err := store.UpdateAlertRule(election); err != nil {
+		t.Fatalf("UpdateAlertRule: %v", err)
+	}
+	got, err := store.GetAlertRule(alice.ID, election.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetAlertRule: %v, %v", got, err)
+	}
+	if got.Expression != election.Expression || got.Enabled {
+		t.Errorf("GetAlertRule = %+v, want the updated, disabled rule", got)
+	}
+
+	enabled, err := store.ListEnabledAlertRules()
+	if err != nil {
+		t.Fatalf("ListEnabledAlertRules: %v", err)
+	}
+	if len(enabled) != 1 || enabled[0].UserID != bob.ID {
+		t.Errorf("ListEnabledAlertRules = %+v, want only Bob's rule", enabled)
+	}
+
+	// Deleting a user deletes their rules
+	if err := store.DeleteUser(bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if enabled, _ = store.ListEnabledAlertRules(); len(enabled) != 0 {
+		t.Errorf("ListEnabledAlertRules after delete = %+v, want none", enabled)
+	}
+
+	if err := store.DeleteAlertRule(alice.ID, election.ID); err != nil {
+		t.Fatalf("DeleteAlertRule: %v", err)
+	}
+	if rules, _ = store.ListAlertRules(alice.ID); len(rules) != 1 {
+		t.Errorf("ListAlertRules after delete = %d rules, want 1", len(rules))
+	}
+}
@@ -59,6 +59,18 @@ func (s *UserStore) migrate() error {
 	CREATE INDEX IF NOT EXISTS idx_users_digest_freq ON users(digest_freq);
 	CREATE INDEX IF NOT EXISTS idx_users_unsub_token ON users(unsub_token);
 	CREATE INDEX IF NOT EXISTS idx_users_verified ON users(verified);
+
+	CREATE TABLE IF NOT EXISTS alert_rules (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name       TEXT NOT NULL,
+		expression TEXT NOT NULL,
+		enabled    INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_alert_rules_user_id ON alert_rules(user_id);
 	`
 	_, err := s.db.Exec(schema)
 	if err != nil {
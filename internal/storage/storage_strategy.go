@@ -81,7 +81,7 @@ func NewIndexingStrategy(cfg *config.SelectiveCriteria, redisClient *redis.Clien
 
 // ShouldIndex determines if an edit should be indexed in Elasticsearch
 func (s *IndexingStrategy) ShouldIndex(ctx context.Context, edit *models.WikipediaEdit) (*IndexingDecision, error) {
-	pageKey := fmt.Sprintf("%s:%s", edit.Wiki, edit.Title)
+	pageKey := edit.PageKey().String()
 
 	// Check watchlist first (highest priority)
 	if s.isInWatchlist(pageKey) {
@@ -158,7 +158,7 @@ func (s *IndexingStrategy) ShouldIndex(ctx context.Context, edit *models.Wikiped
 
 // AddToWatchlist adds a page to the watchlist for always indexing
 func (s *IndexingStrategy) AddToWatchlist(ctx context.Context, wiki, title string) error {
-	pageKey := fmt.Sprintf("%s:%s", wiki, title)
+	pageKey := models.NewPageKey(wiki, title).String()
 	
 	s.watchlistMu.Lock()
 	s.watchlist[pageKey] = true
@@ -177,7 +177,7 @@ func (s *IndexingStrategy) AddToWatchlist(ctx context.Context, wiki, title strin
 
 // RemoveFromWatchlist removes a page from the watchlist
 func (s *IndexingStrategy) RemoveFromWatchlist(ctx context.Context, wiki, title string) error {
-	pageKey := fmt.Sprintf("%s:%s", wiki, title)
+	pageKey := models.NewPageKey(wiki, title).String()
 	
 	s.watchlistMu.Lock()
 	delete(s.watchlist, pageKey)
@@ -267,7 +267,7 @@ func (s *IndexingStrategy) UpdateIndexingStats(ctx context.Context, decision *In
 
 // getPageContext retrieves current page status with caching
 func (s *IndexingStrategy) getPageContext(ctx context.Context, wiki, title string) (*PageContext, error) {
-	pageKey := fmt.Sprintf("%s:%s", wiki, title)
+	pageKey := models.NewPageKey(wiki, title).String()
 	
 	// Check cache first
 	s.contextCacheMu.RLock()
@@ -315,7 +315,7 @@ func (s *IndexingStrategy) getPageContext(ctx context.Context, wiki, title strin
 	}
 	
 	// Check spiking status
-	spikingKey := fmt.Sprintf("spike:%s:%s", wiki, title)
+	spikingKey := fmt.Sprintf("spike:%s", pageKey)
 	spikeExists, err := s.redis.Exists(ctx, spikingKey).Result()
 	if err != nil {
 		log.Printf("Failed to check spike status for %s: %v", pageKey, err)
@@ -332,7 +332,7 @@ func (s *IndexingStrategy) getPageContext(ctx context.Context, wiki, title strin
 	}
 	
 	// Check edit war status
-	editWarKey := fmt.Sprintf("editwar:%s:%s", wiki, title)
+	editWarKey := fmt.Sprintf("editwar:%s", pageKey)
 	editWarExists, err := s.redis.Exists(ctx, editWarKey).Result()
 	if err != nil {
 		log.Printf("Failed to check edit war status for %s: %v", pageKey, err)
@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Trending model names accepted in redis.trending.model.
+const (
+	TrendingModelWeighted = "weighted"
+	TrendingModelVelocity = "velocity"
+)
+
+// wikiRateHalfLife is how quickly a wiki's measured edit rate forgets the
+// past, for per-wiki normalisation.
+const wikiRateHalfLife = 10 * time.Minute
+
+// TrendingModel decides how much a single edit adds to its page's trending
+// score. The TrendingScorer owns decay and ranking; a model only answers
+// "how much is this edit worth at this moment".
+type TrendingModel interface {
+	// Name identifies the model in logs and config.
+	Name() string
+	// Score returns the increment for edit, made at event time at. A score of
+	// zero or less leaves the page's trending score untouched.
+	Score(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (float64, error)
+}
+
+// NewTrendingModel returns the built-in model named by cfg.Model. An empty
+// name selects the weighted model.
+func NewTrendingModel(client *redis.Client, cfg *config.TrendingConfig) (TrendingModel, error) {
+	switch cfg.Model {
+	case "", TrendingModelWeighted:
+		return NewWeightedTrendingModel(client, cfg.Weights), nil
+	case TrendingModelVelocity:
+		return NewVelocityTrendingModel(client, cfg.Weights, cfg.Velocity), nil
+	default:
+		return nil, fmt.Errorf("unknown trending model %q", cfg.Model)
+	}
+}
+
+// WeightedTrendingModel scores an edit by what it is: a base weight
+// multiplied by the weights for bots, new pages, large edits, anonymous
+// editors, the page's namespace, editors new to the page and, optionally,
+// how quiet the edit's wiki is.
+type WeightedTrendingModel struct {
+	redis   *redis.Client
+	weights config.TrendingWeights
+}
+
+// NewWeightedTrendingModel creates a weighted model. Weights left at zero
+// take their defaults, which reproduce the original fixed scoring.
+func NewWeightedTrendingModel(client *redis.Client, w config.TrendingWeights) *WeightedTrendingModel {
+	w.Base = orDefault(w.Base, 1.0)
+	w.Bot = orDefault(w.Bot, 0.5)
+	w.NewPage = orDefault(w.NewPage, 2.0)
+	w.LargeEdit = orDefault(w.LargeEdit, 1.5)
+	if w.LargeEditBytes == 0 {
+		w.LargeEditBytes = 1000
+	}
+	w.UniqueEditor = orDefault(w.UniqueEditor, 1.0)
+	if w.EditorWindow == 0 {
+		w.EditorWindow = time.Hour
+	}
+	w.Anonymous = orDefault(w.Anonymous, 1.0)
+	w.WikiReferenceRate = orDefault(w.WikiReferenceRate, 100)
+	w.MaxWikiBoost = orDefault(w.MaxWikiBoost, 10)
+	return &WeightedTrendingModel{redis: client, weights: w}
+}
+
+// Name implements TrendingModel.
+func (m *WeightedTrendingModel) Name() string { return TrendingModelWeighted }
+
+// Score implements TrendingModel. Redis is only consulted for the weights
+// that need history: unique editors and wiki normalisation.
+func (m *WeightedTrendingModel) Score(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (float64, error) {
+	w := m.weights
+	score := w.Base
+
+	if ns, ok := w.Namespaces[edit.Namespace]; ok {
+		if ns == 0 {
+			return 0, nil
+		}
+		score *= ns
+	}
+
+	byteChange := edit.ByteChange()
+	if byteChange < 0 {
+		byteChange = -byteChange
+	}
+	if byteChange > w.LargeEditBytes {
+		score *= w.LargeEdit
+	}
+	if edit.Bot {
+		score *= w.Bot
+	}
+	if edit.Type == "new" {
+		score *= w.NewPage
+	}
+	if edit.IsAnonymous() {
+		score *= w.Anonymous
+	}
+
+	if w.UniqueEditor != 1 && edit.User != "" {
+		first, err := m.newEditor(ctx, edit, at)
+		if err != nil {
+			return 0, err
+		}
+		if first {
+			score *= w.UniqueEditor
+		}
+	}
+
+	if w.WikiNormalization > 0 {
+		boost, err := m.wikiBoost(ctx, edit.Wiki, at)
+		if err != nil {
+			return 0, err
+		}
+		score *= boost
+	}
+
+	return score, nil
+}
+
+// newEditor records that the edit's user edited its page at at, and reports
+// whether they had not edited it within the editor window before.
+func (m *WeightedTrendingModel) newEditor(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (bool, error) {
+	key := fmt.Sprintf("trendmodel:editors:%s", edit.PageKey())
+	cutoff := at.Add(-m.weights.EditorWindow)
+
+	pipe := m.redis.Pipeline()
+	last := pipe.ZScore(ctx, key, edit.User)
+	pipe.ZAddGT(ctx, key, redis.Z{Score: float64(at.Unix()), Member: edit.User})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", cutoff.Unix()))
+	pipe.Expire(ctx, key, m.weights.EditorWindow)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return false, fmt.Errorf("failed to record page editor: %w", err)
+	}
+
+	seen, err := last.Result()
+	if err == redis.Nil {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get page editor: %w", err)
+	}
+	return seen < float64(cutoff.Unix()), nil
+}
+
+// wikiBoost counts the edit towards its wiki's edit rate and returns
+// (reference rate / wiki rate)^WikiNormalization, capped at MaxWikiBoost.
+// Wikis busier than the reference rate get a factor below one.
+func (m *WeightedTrendingModel) wikiBoost(ctx context.Context, wiki string, at time.Time) (float64, error) {
+	counts, err := bumpDecayedCounts(ctx, m.redis, "trendmodel:wiki:"+wiki, at, 6*wikiRateHalfLife, wikiRateHalfLife)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update wiki edit rate: %w", err)
+	}
+	rate := decayedRate(counts[0], wikiRateHalfLife) * 60 // per minute
+	boost := math.Pow(m.weights.WikiReferenceRate/rate, m.weights.WikiNormalization)
+	return math.Min(boost, m.weights.MaxWikiBoost), nil
+}
+
+// VelocityTrendingModel scores an edit by how fast its page is being edited
+// now compared with the page's own normal rate, so a quiet article that
+// suddenly draws ten edits outranks a busy one drawing its usual ten. Both
+// rates are exponentially decayed edit counts: the current one with a
+// half-life of Window, the normal one with a half-life of Baseline.
+// Bot edits count for the Bot weight of a human's.
+type VelocityTrendingModel struct {
+	redis    *redis.Client
+	base     float64
+	bot      float64
+	velocity config.TrendingVelocity
+}
+
+// NewVelocityTrendingModel creates a velocity model. Only the base and bot
+// weights apply; zero values take their defaults.
+func NewVelocityTrendingModel(client *redis.Client, w config.TrendingWeights, v config.TrendingVelocity) *VelocityTrendingModel {
+	if v.Window == 0 {
+		v.Window = 10 * time.Minute
+	}
+	if v.Baseline == 0 {
+		v.Baseline = 24 * time.Hour
+	}
+	v.BaselineFloor = orDefault(v.BaselineFloor, 1)
+	v.MaxRatio = orDefault(v.MaxRatio, 20)
+	return &VelocityTrendingModel{
+		redis:    client,
+		base:     orDefault(w.Base, 1.0),
+		bot:      orDefault(w.Bot, 0.5),
+		velocity: v,
+	}
+}
+
+// Name implements TrendingModel.
+func (m *VelocityTrendingModel) Name() string { return TrendingModelVelocity }
+
+// Score implements TrendingModel: the base weight times the ratio of the
+// page's current edit rate to its normal rate, which is never taken to be
+// below BaselineFloor edits an hour and is capped at MaxRatio.
+func (m *VelocityTrendingModel) Score(ctx context.Context, edit *models.WikipediaEdit, at time.Time) (float64, error) {
+	v := m.velocity
+	key := fmt.Sprintf("trendmodel:velocity:%s", edit.PageKey())
+	counts, err := bumpDecayedCounts(ctx, m.redis, key, at, 4*v.Baseline, v.Window, v.Baseline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update page edit rate: %w", err)
+	}
+
+	current := decayedRate(counts[0], v.Window) * 3600
+	normal := math.Max(decayedRate(counts[1], v.Baseline)*3600, v.BaselineFloor)
+	score := m.base * math.Min(current/normal, v.MaxRatio)
+	if edit.Bot {
+		score *= m.bot
+	}
+	return score, nil
+}
+
+// bumpDecayedCountsScript is bumpDecayedCounts as one atomic step, so
+// processors updating the same wiki or page at once cannot lose counts.
+//
+// KEYS[1] the counters hash
+// ARGV[1] edit time (unix ms), ARGV[2] TTL (seconds), ARGV[3...] the
+// counters' half-lives (seconds)
+var bumpDecayedCountsScript = redis.NewScript(`
+local at = tonumber(ARGV[1])
+local updated = at
+local elapsed = 0
+local last = tonumber(redis.call('HGET', KEYS[1], 'updated'))
+if last then
+	if last > at then
+		updated = last
+	else
+		elapsed = (at - last) / 1000
+	end
+end
+
+local counts = {}
+local values = {'updated', string.format('%d', updated)}
+for i = 3, #ARGV do
+	local field = 'c' .. (i - 3)
+	local prev = tonumber(redis.call('HGET', KEYS[1], field)) or 0
+	local count = string.format('%.17g', prev * 0.5 ^ (elapsed / tonumber(ARGV[i])) + 1)
+	counts[#counts + 1] = count
+	values[#values + 1] = field
+	values[#values + 1] = count
+end
+redis.call('HSET', KEYS[1], unpack(values))
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return counts
+`)
+
+// bumpDecayedCounts adds one to each counter kept in the hash at key after
+// decaying it for the time since the hash was last updated; counter i halves
+// every halfLives[i]. It returns the new values. Edits older than the last
+// update are counted without decay.
+func bumpDecayedCounts(ctx context.Context, client *redis.Client, key string, at time.Time, ttl time.Duration, halfLives ...time.Duration) ([]float64, error) {
+	args := []interface{}{at.UnixMilli(), int64(ttl.Seconds())}
+	for _, halfLife := range halfLives {
+		args = append(args, halfLife.Seconds())
+	}
+	res, err := bumpDecayedCountsScript.Run(ctx, client, []string{key}, args...).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]float64, len(res))
+	for i, v := range res {
+		if counts[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("bad decayed count %q: %w", v, err)
+		}
+	}
+	return counts, nil
+}
+
+// decayedRate converts a count that halves every halfLife into the steady
+// rate, per second, that would hold it at that value.
+func decayedRate(count float64, halfLife time.Duration) float64 {
+	return count * math.Ln2 / halfLife.Seconds()
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
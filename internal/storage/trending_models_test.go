@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Agnikulu/WikiSurge/internal/config"
+	"github.com/Agnikulu/WikiSurge/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func trendingModelClient(t *testing.T) *redis.Client {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func modelEdit(wiki, title, user string, ns int) *models.WikipediaEdit {
+	e := &models.WikipediaEdit{Wiki: wiki, Title: title, User: user, Namespace: ns, Type: "edit"}
+	e.Length.Old, e.Length.New = 100, 200
+	return e
+}
+
+func TestWeightedTrendingModel(t *testing.T) {
+	client := trendingModelClient(t)
+	ctx := context.Background()
+	start := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+
+	m := NewWeightedTrendingModel(client, config.TrendingWeights{
+		UniqueEditor: 2,
+		EditorWindow: time.Hour,
+		Anonymous:    0.5,
+		Namespaces:   map[int]float64{2: 0, 4: 0.25},
+	})
+
+	score := func(e *models.WikipediaEdit, at time.Time) float64 {
+		t.Helper()
+		s, err := m.Score(ctx, e, at)
+		require.NoError(t, err)
+		return s
+	}
+
+	// A first edit by Alice is unique; her next one within the hour is not
+	assert.Equal(t, 2.0, score(modelEdit("enwiki", "Paris", "Alice", 0), start))
+	assert.Equal(t, 1.0, score(modelEdit("enwiki", "Paris", "Alice", 0), start.Add(30*time.Minute)))
+	assert.Equal(t, 2.0, score(modelEdit("enwiki", "Paris", "Bob", 0), start.Add(31*time.Minute)))
+	// Editing the same title on another wiki is another page
+	assert.Equal(t, 2.0, score(modelEdit("frwiki", "Paris", "Alice", 0), start.Add(31*time.Minute)))
+	// After an hour away she counts as new again
+	assert.Equal(t, 2.0, score(modelEdit("enwiki", "Paris", "Alice", 0), start.Add(2*time.Hour)))
+
+	// Anonymous editors and listed namespaces are weighted; namespace 2 not at all
+	assert.Equal(t, 1.0, score(modelEdit("enwiki", "Paris", "192.0.2.1", 0), start))
+	assert.Equal(t, 0.5, score(modelEdit("enwiki", "Wikipedia:Village pump", "Carol", 4), start))
+	assert.Equal(t, 0.0, score(modelEdit("enwiki", "User:Carol", "Carol", 2), start))
+}
+
+func TestWeightedTrendingModel_WikiNormalization(t *testing.T) {
+	client := trendingModelClient(t)
+	ctx := context.Background()
+	start := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+
+	m := NewWeightedTrendingModel(client, config.TrendingWeights{
+		WikiNormalization: 1,
+		WikiReferenceRate: 100,
+		MaxWikiBoost:      10,
+	})
+
+	// enwiki runs at 180 edits a minute, iswiki at 2, for an hour
+	var en, is float64
+	for sec := 0; sec < 3600; sec++ {
+		at := start.Add(time.Duration(sec) * time.Second)
+		for i := 0; i < 3; i++ {
+			var err error
+			en, err = m.Score(ctx, modelEdit("enwiki", fmt.Sprintf("P%d", i), "Alice", 0), at)
+			require.NoError(t, err)
+		}
+		if sec%30 == 0 {
+			var err error
+			is, err = m.Score(ctx, modelEdit("iswiki", "Reykjavík", "Jón", 0), at)
+			require.NoError(t, err)
+		}
+	}
+
+	assert.InDelta(t, 100.0/180, en, 0.05, "busier than the reference, so scaled down")
+	assert.Equal(t, 10.0, is, "boost is capped")
+}
+
+func TestVelocityTrendingModel(t *testing.T) {
+	client := trendingModelClient(t)
+	ctx := context.Background()
+	start := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	m := NewVelocityTrendingModel(client, config.TrendingWeights{}, config.TrendingVelocity{
+		Window:        10 * time.Minute,
+		Baseline:      6 * time.Hour,
+		BaselineFloor: 1,
+		MaxRatio:      20,
+	})
+	score := func(title string, at time.Time) float64 {
+		t.Helper()
+		s, err := m.Score(ctx, modelEdit("enwiki", title, "Alice", 0), at)
+		require.NoError(t, err)
+		return s
+	}
+
+	// A page edited every two minutes for two days is at its normal rate
+	var steady float64
+	for at := start; at.Before(start.Add(48 * time.Hour)); at = at.Add(2 * time.Minute) {
+		steady = score("Busy", at)
+	}
+	assert.InDelta(t, 1.0, steady, 0.15)
+
+	// A quiet page drawing the same rate is far above its normal rate
+	var burst float64
+	end := start.Add(48 * time.Hour)
+	for at := end.Add(-20 * time.Minute); at.Before(end); at = at.Add(2 * time.Minute) {
+		burst = score("Quiet", at)
+	}
+	assert.Greater(t, burst, 10*steady)
+
+	// Ten edits in a second hit the cap
+	for i := 0; i < 10; i++ {
+		burst = score("Sudden", end)
+	}
+	assert.Equal(t, 20.0, burst)
+
+	// Bots count for half of a first edit's 6 ln 2 (one edit in a ten
+	// minute half-life, per hour, over the one-an-hour floor)
+	bot := modelEdit("enwiki", "Botty", "SweepBot", 0)
+	bot.Bot = true
+	s, err := m.Score(ctx, bot, end)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5*6*math.Ln2, s, 0.001)
+}
+
+func TestTrendingScorer_VelocityModel(t *testing.T) {
+	client := trendingModelClient(t)
+	scorer := NewTrendingScorerForTest(client, &config.TrendingConfig{Enabled: true, Model: TrendingModelVelocity})
+	defer scorer.Stop()
+	assert.Equal(t, TrendingModelVelocity, scorer.Model().Name())
+
+	require.NoError(t, scorer.ProcessEdit(modelEdit("enwiki", "Quiet", "Alice", 0)))
+	entries, err := scorer.GetTopTrending(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Greater(t, entries[0].RawScore, 1.0)
+
+	_, err = NewTrendingModel(client, &config.TrendingConfig{Model: "popularity"})
+	assert.Error(t, err)
+
+	// An unvalidated unknown model is an error, not a silent fallback
+	unknown := NewTrendingScorerForTest(client, &config.TrendingConfig{Enabled: true, Model: "popularity"})
+	defer unknown.Stop()
+	assert.ErrorContains(t, unknown.ProcessEdit(modelEdit("enwiki", "Quiet", "Alice", 0)), "popularity")
+}
+
+func TestBumpDecayedCounts_Concurrent(t *testing.T) {
+	client := trendingModelClient(t)
+	ctx := context.Background()
+	at := time.Unix(1700000000, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := bumpDecayedCounts(ctx, client, "trendmodel:wiki:enwiki", at, time.Hour, time.Minute, time.Hour)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// An edit a minute later halves the first counter but barely the second
+	counts, err := bumpDecayedCounts(ctx, client, "trendmodel:wiki:enwiki", at.Add(time.Minute), time.Hour, time.Minute, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, counts, 2)
+	assert.InDelta(t, 11.0, counts[0], 1e-9)
+	assert.InDelta(t, 20*math.Pow(0.5, 1.0/60)+1, counts[1], 1e-9)
+}
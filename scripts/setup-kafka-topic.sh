@@ -6,6 +6,7 @@ set -euo pipefail
 
 # Configuration
 TOPIC_NAME="wikipedia.edits"
+LOG_EVENTS_TOPIC="wikipedia.logevents"  # protections, blocks, deletions, moves
 PARTITIONS=3
 REPLICATION_FACTOR=1
 RETENTION_HOURS=168  # 7 days
@@ -162,6 +163,15 @@ main() {
     else
         create_topic
     fi
+
+    # Log events are low volume; a single partition keeps them ordered
+    if ! rpk topic list | grep -q "^${LOG_EVENTS_TOPIC}$"; then
+        log_info "Creating topic: ${LOG_EVENTS_TOPIC}"
+        rpk topic create "${LOG_EVENTS_TOPIC}" \
+            --partitions 1 \
+            --replication-factor "${REPLICATION_FACTOR}" \
+            --config "retention.ms=$((RETENTION_HOURS * 3600 * 1000))"
+    fi
     
     # Show final configuration
     echo ""
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -41,7 +44,7 @@ func BenchmarkTrendingScorer_IncrementScore(b *testing.B) {
 	
 	for i := 0; i < b.N; i++ {
 		pageTitle := fmt.Sprintf("Page_%d", i%1000) // 1000 unique pages
-		err := scorer.IncrementScore(pageTitle, 1.0)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), 1.0)
 		require.NoError(b, err)
 	}
 }
@@ -70,7 +73,7 @@ func BenchmarkTrendingScorer_GetTopTrending(b *testing.B) {
 	for i := 0; i < 1000; i++ {
 		pageTitle := fmt.Sprintf("Page_%d", i)
 		score := float64(1000 - i) // Decreasing scores
-		err := scorer.IncrementScore(pageTitle, score)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), score)
 		require.NoError(b, err)
 	}
 	
@@ -215,7 +218,7 @@ func BenchmarkTrendingScorer_LazyDecay(b *testing.B) {
 	// Pre-populate with old data
 	for i := 0; i < 100; i++ {
 		pageTitle := fmt.Sprintf("OldPage_%d", i)
-		err := scorer.IncrementScore(pageTitle, 100.0)
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), 100.0)
 		require.NoError(b, err)
 	}
 	
@@ -227,7 +230,7 @@ func BenchmarkTrendingScorer_LazyDecay(b *testing.B) {
 	// Test performance of updates to old pages (triggers decay)
 	for i := 0; i < b.N; i++ {
 		pageTitle := fmt.Sprintf("OldPage_%d", i%100)
-		err := scorer.IncrementScore(pageTitle, 1.0) // This triggers lazy decay
+		err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), 1.0) // This triggers lazy decay
 		require.NoError(b, err)
 	}
 }
@@ -258,7 +261,7 @@ func BenchmarkTrendingScorer_PruneTrendingSet(b *testing.B) {
 		for j := 0; j < 2000; j++ { // Exceed max pages
 			pageTitle := fmt.Sprintf("Page_%d_%d", i, j)
 			score := float64(j)
-			err := scorer.IncrementScore(pageTitle, score)
+			err := scorer.IncrementScore(models.NewPageKey("enwiki", pageTitle), score)
 			require.NoError(b, err)
 		}
 		
@@ -272,6 +275,120 @@ func BenchmarkTrendingScorer_PruneTrendingSet(b *testing.B) {
 	}
 }
 
+// trending100k is a scorer tracking 100k pages, seeded once and shared by
+// the 100k benchmarks since seeding dominates a single run. It runs against
+// miniredis unless BENCH_REDIS_ADDR names a scratch Redis server, whose
+// database 15 is flushed first; miniredis sorts a whole sorted set to read a
+// range, so only a real server gives realistic GetTopTrending numbers.
+var trending100k struct {
+	once   sync.Once
+	scorer *storage.TrendingScorer
+	keys   []models.PageKey
+}
+
+const trackedPages = 100_000
+
+func seedTrending100k(b *testing.B) (*storage.TrendingScorer, []models.PageKey) {
+	b.Helper()
+	trending100k.once.Do(func() {
+		opts := &redis.Options{Addr: os.Getenv("BENCH_REDIS_ADDR"), DB: 15, PoolSize: 64}
+		if opts.Addr == "" {
+			mr, err := miniredis.Run()
+			require.NoError(b, err)
+			opts.Addr, opts.DB = mr.Addr(), 0
+		}
+		client := redis.NewClient(opts)
+		require.NoError(b, client.FlushDB(context.Background()).Err())
+
+		scorer := storage.NewTrendingScorerForTest(client, &config.TrendingConfig{
+			Enabled:         true,
+			MaxPages:        2 * trackedPages,
+			HalfLifeMinutes: 30.0,
+		})
+		keys := make([]models.PageKey, trackedPages)
+		wikis := []string{"enwiki", "dewiki", "frwiki", "jawiki", "iswiki"}
+		for i := range keys {
+			keys[i] = models.NewPageKey(wikis[i%len(wikis)], fmt.Sprintf("Page_%d", i))
+		}
+
+		var wg sync.WaitGroup
+		next := make(chan int)
+		for w := 0; w < 32; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					if err := scorer.IncrementScore(keys[i], float64(1+i%97)); err != nil {
+						panic(err)
+					}
+				}
+			}()
+		}
+		for i := range keys {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		trending100k.scorer, trending100k.keys = scorer, keys
+	})
+	return trending100k.scorer, trending100k.keys
+}
+
+// BenchmarkTrendingScorer_IncrementScore_100kPages measures one processor
+// updating random pages while 100k pages are tracked.
+func BenchmarkTrendingScorer_IncrementScore_100kPages(b *testing.B) {
+	scorer, keys := seedTrending100k(b)
+	rng := rand.New(rand.NewSource(1))
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if err := scorer.IncrementScore(keys[rng.Intn(len(keys))], 1.0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "updates/s")
+}
+
+// BenchmarkTrendingScorer_IncrementScoreParallel_100kPages measures many
+// processors updating at once. Updates are atomic scripts, so none are lost
+// however they interleave.
+func BenchmarkTrendingScorer_IncrementScoreParallel_100kPages(b *testing.B) {
+	scorer, keys := seedTrending100k(b)
+
+	b.ResetTimer()
+	start := time.Now()
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for pb.Next() {
+			if err := scorer.IncrementScore(keys[rng.Intn(len(keys))], 1.0); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "updates/s")
+}
+
+// BenchmarkTrendingScorer_GetTopTrending_100kPages measures reading the top
+// 20 out of 100k pages: one range read and one pipelined hash read, with no
+// rescoring or sorting.
+func BenchmarkTrendingScorer_GetTopTrending_100kPages(b *testing.B) {
+	scorer, _ := seedTrending100k(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entries, err := scorer.GetTopTrending(20)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(entries) != 20 {
+			b.Fatalf("got %d entries", len(entries))
+		}
+	}
+}
+
 // Benchmark results helper
 func BenchmarkResults(b *testing.B) {
 	b.Skip("This is not a real benchmark - just documentation")
@@ -283,4 +400,10 @@ func BenchmarkResults(b *testing.B) {
 	// BenchmarkTrendingPipeline_FullPipeline:    ~3000-10000 ns/op
 	// BenchmarkTrendingScorer_LazyDecay:         ~2000-8000 ns/op  (should be similar to regular updates)
 	// BenchmarkTrendingScorer_PruneTrendingSet:  ~1ms-10ms per prune cycle
+	//
+	// At 100k tracked pages on miniredis, which runs Lua in Go and sorts the
+	// whole set for every range read (set BENCH_REDIS_ADDR for a real server):
+	// BenchmarkTrendingScorer_IncrementScore_100kPages:         ~180000 ns/op (~5500 updates/s)
+	// BenchmarkTrendingScorer_IncrementScoreParallel_100kPages: ~180000 ns/op (miniredis is single-threaded)
+	// BenchmarkTrendingScorer_GetTopTrending_100kPages:         ~25ms/op (O(log N + 20) on Redis)
 }
\ No newline at end of file
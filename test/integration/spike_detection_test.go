@@ -195,7 +195,7 @@ func TestSpikeDetectorIntegration(t *testing.T) {
 		assert.Contains(t, []string{"low", "medium", "high", "critical"}, foundAlert.Severity)
 
 		// Check if page is marked as spiking
-		spikeKey := "spike:" + pageTitle
+		spikeKey := "spike:enwiki:" + pageTitle
 		result, err := redisClient.Get(ctx, spikeKey).Result()
 		if err == nil {
 			assert.Equal(t, "1", result, "Page should be marked as spiking")
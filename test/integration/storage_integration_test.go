@@ -203,7 +203,7 @@ func testTrendingTracking(t *testing.T, ctx context.Context, trending *storage.T
 	}
 
 	// Test top trending check
-	rank, err = trending.GetTrendingRank(baseEdit.Title) 
+	rank, err = trending.GetTrendingRank(baseEdit.PageKey())
 	if err != nil {
 		t.Fatalf("Failed to check top trending: %v", err)
 	}
@@ -258,7 +258,7 @@ func TestTrendingPipeline_LoadTest(t *testing.T) {
 	// Test ranking functionality
 	if len(entries) > 0 {
 		topPageTitle := entries[0].PageTitle
-		rank, err := scorer.GetTrendingRank(topPageTitle)
+		rank, err := scorer.GetTrendingRank(models.NewPageKey(entries[0].Wiki, topPageTitle))
 		require.NoError(t, err)
 		assert.Equal(t, 0, rank, "Top page should have rank 0")
 	}
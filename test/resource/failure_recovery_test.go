@@ -385,7 +385,7 @@ func TestSpikeDetectorMissingData(t *testing.T) {
 	}
 
 	// Process for a page where we manually corrupt data
-	env.redisClient.Set(ctx, "activity:CorruptedPage", "not_a_number_but_wont_matter_for_INCR", 0)
+	env.redisClient.Set(ctx, "activity:enwiki:CorruptedPage", "not_a_number_but_wont_matter_for_INCR", 0)
 	edit2 := makeEdit(2, "CorruptedPage", "User1")
 	err2 := spikeDetector.ProcessEdit(ctx, edit2)
 	// Should not panic
@@ -168,7 +168,7 @@ func TestRedisMemoryBounds(t *testing.T) {
 	promotedCount := 0
 	for i := 0; i < 100; i++ {
 		title := fmt.Sprintf("Unique_Page_%d", i)
-		isHot, _ := env.hotPageTracker.IsHot(ctx, title)
+		isHot, _ := env.hotPageTracker.IsHot(ctx, models.NewPageKey("enwiki", title))
 		if isHot {
 			promotedCount++
 		}
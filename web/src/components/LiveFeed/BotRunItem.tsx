@@ -0,0 +1,85 @@
+import { memo } from 'react';
+import type { BotRun } from '../../types';
+import { formatRelativeTime, truncateTitle, extractLanguage } from '../../utils/formatting';
+import { Bot, Layers } from 'lucide-react';
+
+interface BotRunItemProps {
+  run: BotRun;
+}
+
+/** A burst of edits by one account, shown as one row in place of its edits. */
+export const BotRunItem = memo(function BotRunItem({ run }: BotRunItemProps) {
+  const lang = extractLanguage(run.wiki);
+  const suspected = !run.bot && (run.suspect_score ?? 0) > 0;
+
+  return (
+    <article
+      aria-label={`${run.user} made ${run.edits} edits to ${run.pages} pages`}
+      className="flex items-start gap-3 p-3 rounded-lg border transition-all duration-200"
+      style={{
+        borderColor: suspected ? 'rgba(255,170,0,0.3)' : 'rgba(0,221,255,0.2)',
+        borderLeftWidth: '2px',
+        background: 'rgba(0,221,255,0.03)',
+      }}
+    >
+      <div className="flex-shrink-0 mt-0.5" aria-hidden="true">
+        <div className="w-7 h-7 rounded-full flex items-center justify-center" style={{ background: 'rgba(0,221,255,0.08)' }}>
+          <Layers className="h-3.5 w-3.5" style={{ color: '#00ddff' }} />
+        </div>
+      </div>
+
+      <div className="flex-1 min-w-0">
+        <div className="flex items-center gap-1.5 flex-wrap">
+          <h3 className="font-semibold text-sm truncate max-w-[240px]" style={{ color: '#00ddff', fontFamily: 'monospace' }}>
+            {run.user}
+          </h3>
+          {run.bot && (
+            <span className="badge badge-bot text-[10px] leading-none px-1.5 py-0.5">bot</span>
+          )}
+          {suspected && (
+            <span
+              className="inline-flex items-center gap-0.5 text-[10px] leading-none px-1.5 py-0.5 rounded-full font-medium"
+              style={{ background: 'rgba(255,170,0,0.15)', color: '#ffaa00', border: '1px solid rgba(255,170,0,0.3)' }}
+              title={`Cadence score ${run.suspect_score?.toFixed(2)}`}
+            >
+              <Bot className="h-2.5 w-2.5" />
+              bot-like
+            </span>
+          )}
+          {run.active && (
+            <span className="text-[10px] leading-none px-1.5 py-0.5 rounded-full" style={{ color: '#00ff88', border: '1px solid rgba(0,255,136,0.3)' }}>
+              running
+            </span>
+          )}
+        </div>
+
+        <div className="flex items-center gap-1.5 mt-1 text-xs flex-wrap" style={{ color: 'rgba(0,255,136,0.4)', fontFamily: 'monospace' }}>
+          <span style={{ color: 'rgba(0,255,136,0.6)' }}>
+            {run.edits} edits · {run.pages} pages · {run.edits_per_minute}/min
+          </span>
+          <span aria-hidden="true">·</span>
+          <span
+            className="inline-flex items-center px-1.5 py-0 rounded text-[10px] font-medium uppercase tracking-wide"
+            style={{ background: 'rgba(0,221,255,0.1)', color: '#00ddff' }}
+          >
+            {lang}
+          </span>
+          <span aria-hidden="true">·</span>
+          <time dateTime={run.end} style={{ color: 'rgba(0,255,136,0.3)' }}>
+            {formatRelativeTime(run.end)}
+          </time>
+        </div>
+
+        <p
+          className="mt-1 text-xs truncate max-w-[320px]"
+          style={{ color: 'rgba(0,255,136,0.25)', fontFamily: 'monospace' }}
+          title={run.sample_pages.join(', ')}
+        >
+          {run.top_summary
+            ? `${truncateTitle(run.top_summary, 60)} (${Math.round(run.top_summary_share * 100)}%)`
+            : truncateTitle(run.sample_pages.join(', '), 80)}
+        </p>
+      </div>
+    </article>
+  );
+});
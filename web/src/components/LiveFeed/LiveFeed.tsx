@@ -1,11 +1,12 @@
 import { useState, useRef, useCallback, useEffect, useMemo } from 'react';
 import { List } from 'react-window';
-import type { Edit } from '../../types';
+import type { BotRun, Edit } from '../../types';
 import { useWebSocket } from '../../hooks/useWebSocket';
 import type { ConnectionState } from '../../hooks/useWebSocket';
 import { buildWebSocketUrl, WS_ENDPOINTS } from '../../utils/websocket';
 import { useAppStore } from '../../store/appStore';
 import { EditItem } from './EditItem';
+import { BotRunItem } from './BotRunItem';
 import { FilterControls } from './FilterControls';
 import { EditDetailsModal } from './EditDetailsModal';
 import {
@@ -20,6 +21,12 @@ import {
   ArrowUp,
 } from 'lucide-react';
 
+type FeedItem = Edit | BotRun;
+
+function isBotRun(item: FeedItem): item is BotRun {
+  return 'sample_pages' in item;
+}
+
 export function LiveFeed() {
   const filters = useAppStore((s) => s.filters);
 
@@ -44,7 +51,7 @@ export function LiveFeed() {
   }, [wsFilter]);
 
   const {
-    data: edits,
+    data: messages,
     connectionState,
     connected,
     reconnectCount,
@@ -53,7 +60,18 @@ export function LiveFeed() {
     pause,
     resume,
     isPaused,
-  } = useWebSocket<Edit>({ url: wsUrl });
+  } = useWebSocket<FeedItem>({ url: wsUrl });
+
+  // A bot run is re-sent as it grows; only its newest summary is shown
+  const edits = useMemo(() => {
+    const seenRuns = new Set<string>();
+    return messages.filter((item) => {
+      if (!isBotRun(item)) return true;
+      if (seenRuns.has(item.id)) return false;
+      seenRuns.add(item.id);
+      return true;
+    });
+  }, [messages]);
 
   // Sync WebSocket connection state to global store for Header indicators
   const setWsConnected = useAppStore((s) => s.setWsConnected);
@@ -111,7 +129,11 @@ export function LiveFeed() {
     return (
       <div style={style} {...ariaAttributes} className={isFirstItem ? 'animate-feed-in' : ''}>
         <div className="px-1 pb-0.5">
-          <EditItem edit={edit} onClick={handleEditClick} />
+          {isBotRun(edit) ? (
+            <BotRunItem run={edit} />
+          ) : (
+            <EditItem edit={edit} onClick={handleEditClick} />
+          )}
         </div>
       </div>
     );
@@ -32,12 +32,38 @@ export interface Edit {
   language?: string;
 }
 
+/**
+ * BotRun summarizes a burst of rapid edits by one account. The live feed
+ * sends it (type "bot_run") in place of the individual edits, again as the
+ * run continues and once more when it ends; `id` stays the same throughout.
+ */
+export interface BotRun {
+  id: string;
+  wiki: string;
+  user: string;
+  /** The account has the bot flag */
+  bot: boolean;
+  active: boolean;
+  edits: number;
+  pages: number;
+  sample_pages: string[];
+  top_summary?: string;
+  top_summary_share: number;
+  edits_per_minute: number;
+  /** Set when an account without the bot flag edits like a bot */
+  suspect_score?: number;
+  start: string;
+  end: string;
+}
+
 export interface TrendingPage {
   title: string;
   score: number;
   edits_1h: number;
   last_edit: string;
   rank: number;
+  rank_change?: number;
+  peak_rank?: number;
   language: string;
   server_url?: string;
 }